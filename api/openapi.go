@@ -0,0 +1,42 @@
+// Package api содержит спецификацию OpenAPI 3 всех маршрутов сервиса
+package api
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"github.com/getkin/kin-openapi/openapi3"
+	"regexp"
+	"sync"
+)
+
+//go:embed openapi.yaml
+var specYAML []byte
+
+// uuidRegexp совпадает с проверкой uuid в binding gin: версия и вариант не проверяются
+var uuidRegexp = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+func validateUUID(value string) error {
+	if !uuidRegexp.MatchString(value) {
+		return errors.New("not a valid uuid")
+	}
+	return nil
+}
+
+// Spec разбирает и проверяет встроенную спецификацию. Документ разбирается один раз,
+// вызывающие получают один и тот же *openapi3.T и не должны его изменять
+var Spec = sync.OnceValues(func() (*openapi3.T, error) {
+	openapi3.DefineStringFormatCallback("uuid", validateUUID)
+
+	doc, err := openapi3.NewLoader().LoadFromData(specYAML)
+	if err != nil {
+		return nil, fmt.Errorf("load openapi spec: %w", err)
+	}
+
+	if err := doc.Validate(context.Background()); err != nil {
+		return nil, fmt.Errorf("validate openapi spec: %w", err)
+	}
+
+	return doc, nil
+})
@@ -0,0 +1,220 @@
+// apikey управляет ключами доступа к API:
+//
+//	apikey [-config path] create -name billing -scopes stock:read,stock:reserve [-warehouses <uuid>,<uuid>]
+//	apikey [-config path] list
+//	apikey [-config path] warehouses -id <uuid> -set <uuid>,<uuid>
+//	apikey [-config path] revoke -id <uuid>
+//
+// Ключ выводится только при создании, в хранилище сохраняется его хэш.
+// Ключ без списка складов дает доступ ко всем складам
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/shamank/warehouse-service/internal/app"
+	"github.com/shamank/warehouse-service/internal/auth"
+	"github.com/shamank/warehouse-service/internal/config"
+	"github.com/shamank/warehouse-service/internal/database"
+	"github.com/shamank/warehouse-service/internal/domain/models"
+	"log/slog"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+const usage = `usage: apikey [-config path] <command> [flags]
+
+commands:
+  create -name NAME -scopes SCOPE[,SCOPE] [-warehouses UUID[,UUID]]
+                                           create key and print it
+  list                                     list keys
+  warehouses -id UUID -set [UUID[,UUID]]   replace allowed warehouses, empty -set allows all
+  revoke -id UUID                          revoke key
+
+scopes: ` + "stock:read, stock:reserve, stock:release, admin\n"
+
+func main() {
+	var configPath string
+
+	flag.StringVar(&configPath, "config", "", "path to config file")
+	flag.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	flag.Parse()
+
+	if configPath == "" {
+		configPath = os.Getenv("CONFIG_PATH")
+	}
+
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	cfg := config.InitConfig(configPath)
+
+	db, err := database.Open(cfg)
+	if err != nil {
+		fail(err)
+	}
+	defer db.Close()
+
+	repo, err := app.NewRepository(db, cfg.Storage, slog.New(slog.NewTextHandler(os.Stderr, nil)))
+	if err != nil {
+		fail(err)
+	}
+
+	command, args := flag.Arg(0), flag.Args()[1:]
+	switch command {
+	case "create":
+		err = create(repo, args)
+	case "list":
+		err = list(repo)
+	case "warehouses":
+		err = setWarehouses(repo, args)
+	case "revoke":
+		err = revoke(repo, args)
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		fail(err)
+	}
+}
+
+func create(keys auth.KeyManager, args []string) error {
+	flags := flag.NewFlagSet("create", flag.ExitOnError)
+	name := flags.String("name", "", "client name")
+	scopesList := flags.String("scopes", "", "comma-separated scopes")
+	warehousesList := flags.String("warehouses", "", "comma-separated allowed warehouses, all if empty")
+	_ = flags.Parse(args)
+
+	if *name == "" || *scopesList == "" {
+		return fmt.Errorf("-name and -scopes are required")
+	}
+
+	scopes := strings.Split(*scopesList, ",")
+	if err := auth.ValidateScopes(scopes); err != nil {
+		return err
+	}
+
+	warehouses, err := parseWarehouses(*warehousesList)
+	if err != nil {
+		return err
+	}
+
+	key, err := auth.GenerateAPIKey()
+	if err != nil {
+		return err
+	}
+
+	apiKey := models.APIKey{
+		UUID:       uuid.NewString(),
+		Name:       *name,
+		Hash:       auth.HashAPIKey(key),
+		Scopes:     scopes,
+		Warehouses: warehouses,
+		CreatedAt:  time.Now().UTC(),
+	}
+	if err := keys.CreateAPIKey(apiKey); err != nil {
+		return err
+	}
+
+	fmt.Printf("id:  %s\nkey: %s\n", apiKey.UUID, key)
+	fmt.Fprintln(os.Stderr, "store the key now, it cannot be shown again")
+
+	return nil
+}
+
+func list(keys auth.KeyManager) error {
+	apiKeys, err := keys.ListAPIKeys()
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tNAME\tSCOPES\tWAREHOUSES\tCREATED\tREVOKED")
+	for _, key := range apiKeys {
+		revoked := "-"
+		if key.RevokedAt != nil {
+			revoked = key.RevokedAt.Format(time.RFC3339)
+		}
+		warehouses := "all"
+		if len(key.Warehouses) > 0 {
+			warehouses = strings.Join(key.Warehouses, ",")
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			key.UUID, key.Name, strings.Join(key.Scopes, ","), warehouses, key.CreatedAt.Format(time.RFC3339), revoked)
+	}
+
+	return w.Flush()
+}
+
+func setWarehouses(keys auth.KeyManager, args []string) error {
+	flags := flag.NewFlagSet("warehouses", flag.ExitOnError)
+	id := flags.String("id", "", "key id")
+	warehousesList := flags.String("set", "", "comma-separated allowed warehouses, all if empty")
+	_ = flags.Parse(args)
+
+	if _, err := uuid.Parse(*id); err != nil {
+		return fmt.Errorf("-id must be a key uuid: %w", err)
+	}
+
+	warehouses, err := parseWarehouses(*warehousesList)
+	if err != nil {
+		return err
+	}
+
+	if err := keys.SetAPIKeyWarehouses(*id, warehouses); err != nil {
+		return err
+	}
+
+	if len(warehouses) == 0 {
+		fmt.Println(*id, "allowed all warehouses")
+	} else {
+		fmt.Println(*id, "allowed warehouses", strings.Join(warehouses, ","))
+	}
+
+	return nil
+}
+
+// parseWarehouses разбирает список uuid складов через запятую. Пустая строка - все склады
+func parseWarehouses(list string) ([]string, error) {
+	if list == "" {
+		return nil, nil
+	}
+
+	warehouses := strings.Split(list, ",")
+	for _, warehouse := range warehouses {
+		if _, err := uuid.Parse(warehouse); err != nil {
+			return nil, fmt.Errorf("warehouse %q must be a uuid: %w", warehouse, err)
+		}
+	}
+
+	return warehouses, nil
+}
+
+func revoke(keys auth.KeyManager, args []string) error {
+	flags := flag.NewFlagSet("revoke", flag.ExitOnError)
+	id := flags.String("id", "", "key id")
+	_ = flags.Parse(args)
+
+	if _, err := uuid.Parse(*id); err != nil {
+		return fmt.Errorf("-id must be a key uuid: %w", err)
+	}
+
+	if err := keys.RevokeAPIKey(*id); err != nil {
+		return err
+	}
+
+	fmt.Println("revoked", *id)
+
+	return nil
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, "apikey:", err)
+	os.Exit(1)
+}
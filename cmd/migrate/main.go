@@ -1,21 +1,27 @@
 package main
 
 import (
-	"database/sql"
 	"flag"
-	"fmt"
-	"github.com/golang-migrate/migrate/v4"
-	"github.com/golang-migrate/migrate/v4/database/postgres"
 	"github.com/shamank/warehouse-service/internal/config"
+	"github.com/shamank/warehouse-service/internal/database"
 	"os"
 )
 
 const MigrationsPATH = "./migrations/"
 
+// migrationsPaths - каталоги миграций по умолчанию для каждого хранилища
+var migrationsPaths = map[string]string{
+	config.StoragePostgres: MigrationsPATH,
+	config.StorageSQLite:   MigrationsPATH + "sqlite",
+	config.StorageMySQL:    MigrationsPATH + "mysql",
+}
+
 func main() {
 	var configPath string
+	var migrationPath string
 
 	flag.StringVar(&configPath, "config", "", "path to config file")
+	flag.StringVar(&migrationPath, "path", "", "path to migrations directory, by default the one for the configured storage")
 	flag.Parse()
 
 	if configPath == "" {
@@ -24,34 +30,16 @@ func main() {
 
 	cfg := config.InitConfig(configPath)
 
-	db, err := sql.Open("postgres", fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
-		cfg.Postgres.Host,
-		cfg.Postgres.Port,
-		cfg.Postgres.User,
-		cfg.Postgres.Password,
-		cfg.Postgres.Database,
-		cfg.Postgres.SSLMode,
-	))
-
-	if err != nil {
-		panic(err)
-	}
-
-	driver, err := postgres.WithInstance(db, &postgres.Config{})
-	if err != nil {
-		panic(err)
+	if migrationPath == "" {
+		migrationPath = migrationsPaths[cfg.Storage]
 	}
 
-	m, err := migrate.NewWithDatabaseInstance(
-		"file://"+MigrationsPATH,
-		"postgres",
-		driver,
-	)
+	db, err := database.Open(cfg)
 	if err != nil {
 		panic(err)
 	}
 
-	if err := m.Up(); err != nil {
+	if err := database.Migrate(db, cfg.Storage, migrationPath); err != nil {
 		panic(err)
 	}
 
@@ -2,24 +2,28 @@ package main
 
 import (
 	"context"
-	"database/sql"
-	"errors"
 	"flag"
-	"fmt"
-	"github.com/golang-migrate/migrate/v4"
-	"github.com/golang-migrate/migrate/v4/database/postgres"
-	_ "github.com/golang-migrate/migrate/v4/source/file"
 	"github.com/shamank/warehouse-service/internal/app"
 	"github.com/shamank/warehouse-service/internal/config"
+	"github.com/shamank/warehouse-service/internal/database"
 	"github.com/shamank/warehouse-service/internal/server"
 	"log/slog"
 	"os"
 	"os/signal"
 	"syscall"
-	"time"
+)
+
+// коды завершения процесса
+const (
+	exitOK      = 0
+	exitFailure = 1
 )
 
 func main() {
+	os.Exit(run())
+}
+
+func run() int {
 
 	var configPath string    // путь к файлу конфигурации
 	var migrationPath string // путь к папке с миграциями
@@ -38,68 +42,39 @@ func main() {
 
 	cfg := config.InitConfig(configPath)
 
-	db, err := sql.Open("postgres", fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
-		cfg.Postgres.Host, cfg.Postgres.Port, cfg.Postgres.User, cfg.Postgres.Password, cfg.Postgres.Database, cfg.Postgres.SSLMode),
-	)
+	logger := initLogger("debug")
+
+	db, err := database.Open(cfg)
 	if err != nil {
-		return
+		logger.Error("cannot open database", "error", err)
+		return exitFailure
 	}
-	defer db.Close()
-
-	logger := initLogger("debug")
 
 	if migrationPath != "" {
-		if err := checkMigrations(db, migrationPath); err != nil {
-			logger.Error(err.Error())
-			return
+		if err := database.Migrate(db, cfg.Storage, migrationPath); err != nil {
+			logger.Error("cannot migrate database", "error", err)
+			_ = db.Close()
+			return exitFailure
 		}
 	}
 
 	serv := server.NewServer(cfg.HTTP)
 
-	application := app.NewApp(logger, db, serv)
-	go func() {
-		logger.Info("warehouse service started!")
-		if err := application.Run(cfg.InsertTestData); err != nil {
-			logger.Error(err.Error())
-		}
-	}()
-
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGTERM, syscall.SIGINT)
+	// db закрывает приложение после остановки
+	application := app.NewApp(logger, db, cfg, serv)
 
-	<-quit
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	defer stop()
 
-	const timeout = 5 * time.Second
-	ctx, shutdown := context.WithTimeout(context.Background(), timeout)
-	defer shutdown()
-
-	if err := application.Stop(ctx); err != nil {
-		return
+	logger.Info("warehouse service started!")
+	if err := application.Run(ctx); err != nil {
+		logger.Error("warehouse service failed", "error", err)
+		return exitFailure
 	}
 
 	logger.Info("warehouse service stopped")
-}
-
-func checkMigrations(db *sql.DB, migrationPath string) error {
-	driver, err := postgres.WithInstance(db, &postgres.Config{})
-	if err != nil {
-		panic(err)
-	}
 
-	m, err := migrate.NewWithDatabaseInstance(
-		"file://"+migrationPath,
-		"postgres",
-		driver,
-	)
-	if err != nil {
-		panic(err)
-	}
-
-	if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
-		panic(err)
-	}
-	return nil
+	return exitOK
 }
 
 func initLogger(levelString string) *slog.Logger {
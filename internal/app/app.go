@@ -3,47 +3,128 @@ package app
 import (
 	"context"
 	"database/sql"
-	_ "github.com/lib/pq"
+	"errors"
+	"fmt"
+	"github.com/shamank/warehouse-service/internal/auth"
+	"github.com/shamank/warehouse-service/internal/config"
 	"github.com/shamank/warehouse-service/internal/handler"
+	"github.com/shamank/warehouse-service/internal/repository/mysql"
 	"github.com/shamank/warehouse-service/internal/repository/postgres"
+	"github.com/shamank/warehouse-service/internal/repository/sqlite"
 	"github.com/shamank/warehouse-service/internal/server"
 	"github.com/shamank/warehouse-service/internal/service"
 	"log/slog"
 )
 
+// Repository - хранилище сервиса с ключами доступа, которое умеет заполнять себя тестовыми данными
+type Repository interface {
+	service.Repository
+	auth.KeyManager
+	GenerateTestData() error
+}
+
 type App struct {
 	logger     *slog.Logger
 	db         *sql.DB
+	cfg        *config.Config
 	httpServer *server.Server
 }
 
-func NewApp(logger *slog.Logger, db *sql.DB, httpServer *server.Server) *App {
+// NewApp собирает приложение. App владеет db и закрывает его после остановки
+func NewApp(logger *slog.Logger, db *sql.DB, cfg *config.Config, httpServer *server.Server) *App {
 	return &App{
 		logger:     logger,
 		db:         db,
+		cfg:        cfg,
 		httpServer: httpServer,
 	}
 }
 
-func (a *App) Run(withTestData bool) error {
+// Run работает до отмены ctx, после чего дожидается завершения начатых запросов не дольше cfg.ShutdownTimeout.
+// Возвращает nil при штатной остановке и ошибку, если приложение не запустилось, упало или не остановилось вовремя
+func (a *App) Run(ctx context.Context) error {
+	lifecycle := NewLifecycle(a.logger, a.cfg.ShutdownTimeout)
+	// хранилище закрывается последним, когда запросы к нему уже завершены
+	lifecycle.OnStop("database", a.db.Close)
+
+	if err := a.init(lifecycle); err != nil {
+		return errors.Join(err, lifecycle.Close())
+	}
+
+	return lifecycle.Run(ctx)
+}
 
-	repos := postgres.NewPostgresRepo(a.db, a.logger)
+// init создает хранилище, сервис и обработчики и добавляет компоненты приложения в lifecycle
+func (a *App) init(lifecycle *Lifecycle) error {
+	repos, err := NewRepository(a.db, a.cfg.Storage, a.logger)
+	if err != nil {
+		return err
+	}
 
-	if withTestData {
+	if a.cfg.InsertTestData {
 		if err := repos.GenerateTestData(); err != nil {
 			return err
 		}
 	}
 
 	services := service.NewService(repos, a.logger)
+	services.SetAdjustmentThreshold(a.cfg.Adjustments.ApprovalThreshold)
 	handlers := handler.NewHandler(services, a.logger)
 
+	if a.cfg.Auth.Enabled {
+		authenticator, err := a.newAuthenticator(repos)
+		if err != nil {
+			return err
+		}
+		handlers.SetAuthenticator(authenticator)
+	} else {
+		a.logger.Warn("authentication is disabled, API is open to everyone")
+	}
+
+	if err := handlers.SetTrustedProxies(a.cfg.HTTP.TrustedProxies); err != nil {
+		return err
+	}
+
+	if err := handlers.SetCORS(a.cfg.CORS); err != nil {
+		return err
+	}
+
+	if a.cfg.RateLimit.Enabled {
+		if err := handlers.SetRateLimits(a.cfg.RateLimit); err != nil {
+			return err
+		}
+	}
+
 	a.httpServer.SetHandler(handlers.InitAPIRoutes())
+	lifecycle.Add("http server", a.httpServer)
+
+	return nil
+}
 
-	return a.httpServer.Start()
+// newAuthenticator проверяет ключи доступа по keys, а JWT - по JWKS-файлу, если он задан
+func (a *App) newAuthenticator(keys auth.KeyStore) (*auth.Authenticator, error) {
+	var verifier *auth.JWTVerifier
+	if a.cfg.Auth.JWKSFile != "" {
+		var err error
+		verifier, err = auth.NewJWTVerifier(a.cfg.Auth.JWKSFile, a.cfg.Auth.Issuer, a.cfg.Auth.Audience)
+		if err != nil {
+			return nil, err
+		}
+	}
 
+	return auth.NewAuthenticator(keys, verifier), nil
 }
 
-func (a *App) Stop(ctx context.Context) error {
-	return a.httpServer.Stop(ctx)
+// NewRepository создает хранилище storage поверх db
+func NewRepository(db *sql.DB, storage string, logger *slog.Logger) (Repository, error) {
+	switch storage {
+	case config.StoragePostgres:
+		return postgres.NewPostgresRepo(db, logger), nil
+	case config.StorageSQLite:
+		return sqlite.NewSQLiteRepo(db, logger), nil
+	case config.StorageMySQL:
+		return mysql.NewMySQLRepo(db, logger), nil
+	default:
+		return nil, fmt.Errorf("unknown storage: %s", storage)
+	}
 }
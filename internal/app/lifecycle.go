@@ -0,0 +1,126 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// Component - часть приложения со своим циклом работы: HTTP-сервер, фоновые обработчики
+type Component interface {
+	// Run работает до остановки компонента. После Stop возвращает nil
+	Run() error
+	// Stop останавливает компонент, дожидаясь завершения начатой работы, но не дольше ctx
+	Stop(ctx context.Context) error
+}
+
+type namedComponent struct {
+	name string
+	Component
+}
+
+type closer struct {
+	name  string
+	close func() error
+}
+
+// Lifecycle запускает компоненты в порядке добавления и останавливает в обратном,
+// а после их остановки освобождает ресурсы (соединение с хранилищем)
+type Lifecycle struct {
+	logger          *slog.Logger
+	shutdownTimeout time.Duration
+	components      []namedComponent
+	closers         []closer
+}
+
+func NewLifecycle(logger *slog.Logger, shutdownTimeout time.Duration) *Lifecycle {
+	return &Lifecycle{
+		logger:          logger,
+		shutdownTimeout: shutdownTimeout,
+	}
+}
+
+// Add добавляет компонент. Компоненты, добавленные позже, останавливаются раньше
+func (l *Lifecycle) Add(name string, component Component) {
+	l.components = append(l.components, namedComponent{name: name, Component: component})
+}
+
+// OnStop добавляет ресурс, который закрывается после остановки всех компонентов, в обратном порядке добавления
+func (l *Lifecycle) OnStop(name string, close func() error) {
+	l.closers = append(l.closers, closer{name: name, close: close})
+}
+
+type runResult struct {
+	name string
+	err  error
+}
+
+// Run запускает компоненты и ждет отмены ctx или завершения любого из них, затем останавливает все.
+// Возвращает nil, если работа завершена отменой ctx и все остановилось вовремя
+func (l *Lifecycle) Run(ctx context.Context) error {
+	results := make(chan runResult, len(l.components))
+	for _, component := range l.components {
+		go func(component namedComponent) {
+			results <- runResult{name: component.name, err: component.Run()}
+		}(component)
+	}
+
+	var failure error
+	running := len(l.components)
+	select {
+	case <-ctx.Done():
+		l.logger.Info("shutting down", "timeout", l.shutdownTimeout)
+	case result := <-results:
+		running--
+		failure = result.err
+		if failure == nil {
+			failure = errors.New("stopped unexpectedly")
+		}
+		failure = fmt.Errorf("%s: %w", result.name, failure)
+		l.logger.Error("component failed, shutting down", "error", failure)
+	}
+
+	stopCtx, cancel := context.WithTimeout(context.Background(), l.shutdownTimeout)
+	defer cancel()
+
+	errs := []error{failure}
+	for i := len(l.components) - 1; i >= 0; i-- {
+		component := l.components[i]
+		if err := component.Stop(stopCtx); err != nil {
+			errs = append(errs, fmt.Errorf("stop %s: %w", component.name, err))
+		}
+	}
+
+	// компоненты, не завершившиеся за отведенное время, не ждем
+wait:
+	for ; running > 0; running-- {
+		select {
+		case result := <-results:
+			if result.err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", result.name, result.err))
+			}
+		case <-stopCtx.Done():
+			errs = append(errs, fmt.Errorf("%d components did not stop: %w", running, stopCtx.Err()))
+			break wait
+		}
+	}
+
+	errs = append(errs, l.Close())
+
+	return errors.Join(errs...)
+}
+
+// Close освобождает ресурсы, добавленные OnStop. Вызывается Run, отдельно - если до Run дело не дошло
+func (l *Lifecycle) Close() error {
+	var errs []error
+	for i := len(l.closers) - 1; i >= 0; i-- {
+		if err := l.closers[i].close(); err != nil {
+			errs = append(errs, fmt.Errorf("close %s: %w", l.closers[i].name, err))
+		}
+	}
+	l.closers = nil
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,217 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/shamank/warehouse-service/internal/config"
+	"github.com/shamank/warehouse-service/internal/handler"
+	"github.com/shamank/warehouse-service/internal/handler/mocks"
+	"github.com/shamank/warehouse-service/internal/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeComponent работает, пока его не остановят, или до ошибки fail
+type fakeComponent struct {
+	name   string
+	events *[]string
+	mx     *sync.Mutex
+
+	fail    chan error
+	stopped chan struct{}
+	// stopDelay - сколько длится остановка
+	stopDelay time.Duration
+}
+
+func newFakeComponent(name string, events *[]string, mx *sync.Mutex) *fakeComponent {
+	return &fakeComponent{name: name, events: events, mx: mx, fail: make(chan error, 1), stopped: make(chan struct{})}
+}
+
+func (c *fakeComponent) record(event string) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	*c.events = append(*c.events, event)
+}
+
+func (c *fakeComponent) Run() error {
+	select {
+	case err := <-c.fail:
+		return err
+	case <-c.stopped:
+		return nil
+	}
+}
+
+func (c *fakeComponent) Stop(ctx context.Context) error {
+	c.record("stop " + c.name)
+
+	select {
+	case <-time.After(c.stopDelay):
+		close(c.stopped)
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func TestLifecycle_Run(t *testing.T) {
+	var events []string
+	var mx sync.Mutex
+
+	lifecycle := NewLifecycle(slog.Default(), time.Second)
+	lifecycle.OnStop("database", func() error {
+		mx.Lock()
+		defer mx.Unlock()
+		events = append(events, "close database")
+		return nil
+	})
+	lifecycle.Add("worker", newFakeComponent("worker", &events, &mx))
+	lifecycle.Add("http server", newFakeComponent("http server", &events, &mx))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	require.NoError(t, lifecycle.Run(ctx))
+	assert.Equal(t, []string{"stop http server", "stop worker", "close database"}, events)
+}
+
+func TestLifecycle_ComponentFailure(t *testing.T) {
+	var events []string
+	var mx sync.Mutex
+
+	worker := newFakeComponent("worker", &events, &mx)
+	httpServer := newFakeComponent("http server", &events, &mx)
+
+	lifecycle := NewLifecycle(slog.Default(), time.Second)
+	lifecycle.Add("worker", worker)
+	lifecycle.Add("http server", httpServer)
+
+	listenErr := errors.New("address already in use")
+	httpServer.fail <- listenErr
+
+	err := lifecycle.Run(context.Background())
+	assert.ErrorIs(t, err, listenErr)
+	assert.EqualError(t, err, "http server: address already in use")
+	// остальные компоненты тоже останавливаются
+	assert.Equal(t, []string{"stop http server", "stop worker"}, events)
+}
+
+func TestLifecycle_ShutdownTimeout(t *testing.T) {
+	var events []string
+	var mx sync.Mutex
+
+	slow := newFakeComponent("worker", &events, &mx)
+	slow.stopDelay = time.Minute
+
+	closed := false
+	lifecycle := NewLifecycle(slog.Default(), 10*time.Millisecond)
+	lifecycle.OnStop("database", func() error {
+		closed = true
+		return nil
+	})
+	lifecycle.Add("worker", slow)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := lifecycle.Run(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.True(t, closed, "resources are closed even after timeout")
+}
+
+func TestLifecycle_InFlightReserveCompletes(t *testing.T) {
+	reserveStarted := make(chan struct{})
+	finishReserve := make(chan struct{})
+
+	service := mocks.NewService(t)
+	service.On("ReserveProducts", mock.Anything, []string{"a1as1"}).Run(func(mock.Arguments) {
+		close(reserveStarted)
+		<-finishReserve
+	}).Return(nil)
+
+	addr := freeAddr(t)
+	host, port, err := net.SplitHostPort(addr)
+	require.NoError(t, err)
+
+	httpServer := server.NewServer(config.HTTPConfig{Host: host, Port: port, MaxHeaderBytes: 1})
+	httpServer.SetHandler(handler.NewHandler(service, slog.Default()).InitAPIRoutes())
+
+	lifecycle := NewLifecycle(slog.Default(), 5*time.Second)
+	lifecycle.Add("http server", httpServer)
+
+	ctx, shutdown := context.WithCancel(context.Background())
+	stopped := make(chan error)
+	go func() {
+		stopped <- lifecycle.Run(ctx)
+	}()
+
+	require.Eventually(t, func() bool {
+		conn, err := net.Dial("tcp", addr)
+		if err == nil {
+			_ = conn.Close()
+		}
+		return err == nil
+	}, 5*time.Second, 10*time.Millisecond)
+
+	type response struct {
+		status int
+		body   string
+		err    error
+	}
+	responses := make(chan response)
+	go func() {
+		resp, err := http.Post("http://"+addr+"/api/reserveProducts", "application/json", bytes.NewBufferString(`["a1as1"]`))
+		if err != nil {
+			responses <- response{err: err}
+			return
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		responses <- response{status: resp.StatusCode, body: string(body), err: err}
+	}()
+	<-reserveStarted
+
+	// сигнал остановки приходит во время резервирования
+	shutdown()
+
+	// новые соединения больше не принимаются, а начатый запрос продолжается
+	require.Eventually(t, func() bool {
+		conn, err := net.Dial("tcp", addr)
+		if err == nil {
+			_ = conn.Close()
+		}
+		return err != nil
+	}, 5*time.Second, 10*time.Millisecond)
+
+	select {
+	case err := <-stopped:
+		t.Fatalf("stopped before in-flight request completed: %v", err)
+	default:
+	}
+
+	close(finishReserve)
+
+	resp := <-responses
+	require.NoError(t, resp.err)
+	assert.Equal(t, http.StatusOK, resp.status)
+	assert.Equal(t, `{"message":"OK"}`, resp.body)
+
+	assert.NoError(t, <-stopped)
+}
+
+func freeAddr(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	return listener.Addr().String()
+}
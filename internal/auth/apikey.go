@@ -0,0 +1,46 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"github.com/shamank/warehouse-service/internal/domain/models"
+)
+
+// APIKeyPrefix отличает ключ доступа от JWT в заголовке Authorization
+const APIKeyPrefix = "whk_"
+
+// KeyStore - хранилище ключей доступа
+//
+//go:generate mockery --name=KeyStore
+type KeyStore interface {
+	GetAPIKeyByHash(hash string) (models.APIKey, error)
+}
+
+// KeyManager - хранилище ключей доступа с управлением ими из CLI
+type KeyManager interface {
+	KeyStore
+	CreateAPIKey(key models.APIKey) error
+	ListAPIKeys() ([]models.APIKey, error)
+	RevokeAPIKey(keyUUID string) error
+	SetAPIKeyWarehouses(keyUUID string, warehouses []string) error
+}
+
+// GenerateAPIKey создает случайный ключ доступа. Ключ показывается клиенту один раз,
+// в хранилище сохраняется только HashAPIKey от него
+func GenerateAPIKey() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+
+	return APIKeyPrefix + base64.RawURLEncoding.EncodeToString(secret), nil
+}
+
+// HashAPIKey возвращает SHA-256 ключа в hex. Ключ содержит 256 случайных бит,
+// поэтому медленный хэш для паролей не нужен, а поиск по хэшу идет по индексу
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
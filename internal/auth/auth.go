@@ -0,0 +1,129 @@
+// Package auth проверяет учетные данные клиентов API: ключи доступа из хранилища и JWT,
+// подписанные ключами из JWKS-файла
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/shamank/warehouse-service/internal/domain/models"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// области доступа. ScopeAdmin включает все остальные
+const (
+	ScopeStockRead    = "stock:read"
+	ScopeStockReserve = "stock:reserve"
+	ScopeStockRelease = "stock:release"
+	ScopeAdmin        = "admin"
+)
+
+// Scopes - все известные области доступа
+var Scopes = []string{ScopeStockRead, ScopeStockReserve, ScopeStockRelease, ScopeAdmin}
+
+var (
+	ErrNoCredentials      = errors.New("credentials are required")
+	ErrInvalidCredentials = errors.New("invalid credentials")
+	ErrUnknownScope       = errors.New("unknown scope")
+)
+
+// APIKeyHeader - заголовок с ключом доступа. Ключ можно передать и как Bearer-токен
+const APIKeyHeader = "X-API-Key"
+
+// Principal - клиент, прошедший проверку. Subject - имя ключа или sub из JWT.
+// Warehouses - склады, с которыми клиент может работать, пустой список - все склады
+type Principal struct {
+	Subject    string
+	Scopes     []string
+	Warehouses []string
+}
+
+// HasScope сообщает, разрешена ли клиенту область scope
+func (p Principal) HasScope(scope string) bool {
+	return slices.Contains(p.Scopes, scope) || slices.Contains(p.Scopes, ScopeAdmin)
+}
+
+// CanAccessWarehouse сообщает, может ли клиент видеть остатки склада и резервировать на нем
+func (p Principal) CanAccessWarehouse(warehouseUUID string) bool {
+	return len(p.Warehouses) == 0 || slices.Contains(p.Warehouses, warehouseUUID)
+}
+
+type principalKey struct{}
+
+// WithPrincipal сохраняет клиента в контексте запроса
+func WithPrincipal(ctx context.Context, principal Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFromContext возвращает клиента из контекста. Без проверки доступа клиента в контексте нет
+func PrincipalFromContext(ctx context.Context) (Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(Principal)
+	return principal, ok
+}
+
+// ValidateScopes проверяет, что все scopes известны
+func ValidateScopes(scopes []string) error {
+	for _, scope := range scopes {
+		if !slices.Contains(Scopes, scope) {
+			return fmt.Errorf("%w: %s", ErrUnknownScope, scope)
+		}
+	}
+	return nil
+}
+
+// Authenticator проверяет ключи доступа по хранилищу keys и JWT по verifier.
+// Без verifier принимаются только ключи доступа
+type Authenticator struct {
+	keys     KeyStore
+	verifier *JWTVerifier
+}
+
+func NewAuthenticator(keys KeyStore, verifier *JWTVerifier) *Authenticator {
+	return &Authenticator{
+		keys:     keys,
+		verifier: verifier,
+	}
+}
+
+// Authenticate проверяет учетные данные запроса: ключ из X-API-Key или Bearer-токен,
+// который считается ключом доступа, если начинается с APIKeyPrefix, и JWT в остальных случаях
+func (a *Authenticator) Authenticate(r *http.Request) (Principal, error) {
+	if key := r.Header.Get(APIKeyHeader); key != "" {
+		return a.authenticateKey(key)
+	}
+
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return Principal{}, ErrNoCredentials
+	}
+
+	if strings.HasPrefix(token, APIKeyPrefix) {
+		return a.authenticateKey(token)
+	}
+	if a.verifier == nil {
+		return Principal{}, ErrInvalidCredentials
+	}
+
+	return a.verifier.Verify(token)
+}
+
+func (a *Authenticator) authenticateKey(key string) (Principal, error) {
+	apiKey, err := a.keys.GetAPIKeyByHash(HashAPIKey(key))
+	if err != nil {
+		if errors.Is(err, models.ErrAPIKeyNotFound) {
+			return Principal{}, ErrInvalidCredentials
+		}
+		return Principal{}, err
+	}
+	if apiKey.RevokedAt != nil {
+		return Principal{}, ErrInvalidCredentials
+	}
+
+	return Principal{
+		Subject:    apiKey.Name,
+		Scopes:     apiKey.Scopes,
+		Warehouses: apiKey.Warehouses,
+	}, nil
+}
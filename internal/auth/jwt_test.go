@@ -0,0 +1,190 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const (
+	testIssuer   = "https://auth.example.com"
+	testAudience = "warehouse"
+)
+
+// writeJWKS сохраняет открытые ключи rsaKey (kid rsa) и ecKey (kid ec) в JWKS-файл
+func writeJWKS(t *testing.T, rsaKey *rsa.PrivateKey, ecKey *ecdsa.PrivateKey) string {
+	encode := func(n *big.Int) string {
+		return base64.RawURLEncoding.EncodeToString(n.Bytes())
+	}
+
+	jwks := map[string]any{
+		"keys": []map[string]string{
+			{"kty": "RSA", "kid": "rsa", "use": "sig", "n": encode(rsaKey.N), "e": encode(big.NewInt(int64(rsaKey.E)))},
+			{"kty": "EC", "kid": "ec", "crv": "P-256", "x": encode(ecKey.X), "y": encode(ecKey.Y)},
+			// ключ шифрования пропускается
+			{"kty": "RSA", "kid": "enc", "use": "enc", "n": encode(rsaKey.N), "e": encode(big.NewInt(int64(rsaKey.E)))},
+		},
+	}
+	data, err := json.Marshal(jwks)
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+
+	return path
+}
+
+func TestJWTVerifier_Verify(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	verifier, err := NewJWTVerifier(writeJWKS(t, rsaKey, ecKey), testIssuer, testAudience)
+	require.NoError(t, err)
+
+	validClaims := func() jwt.MapClaims {
+		return jwt.MapClaims{
+			"sub":   "billing",
+			"iss":   testIssuer,
+			"aud":   testAudience,
+			"exp":   time.Now().Add(time.Hour).Unix(),
+			"scope": "stock:read stock:reserve",
+		}
+	}
+
+	sign := func(method jwt.SigningMethod, kid string, key any, claims jwt.MapClaims) string {
+		token := jwt.NewWithClaims(method, claims)
+		if kid != "" {
+			token.Header["kid"] = kid
+		}
+		signed, err := token.SignedString(key)
+		require.NoError(t, err)
+		return signed
+	}
+
+	withClaim := func(name string, value any) jwt.MapClaims {
+		claims := validClaims()
+		if value == nil {
+			delete(claims, name)
+		} else {
+			claims[name] = value
+		}
+		return claims
+	}
+
+	testCases := []struct {
+		name  string
+		token string
+
+		expectedPrincipal Principal
+		expectedError     bool
+	}{
+		{
+			name:              "rsa",
+			token:             sign(jwt.SigningMethodRS256, "rsa", rsaKey, validClaims()),
+			expectedPrincipal: Principal{Subject: "billing", Scopes: []string{ScopeStockRead, ScopeStockReserve}},
+		},
+		{
+			name:              "ec without scopes",
+			token:             sign(jwt.SigningMethodES256, "ec", ecKey, withClaim("scope", nil)),
+			expectedPrincipal: Principal{Subject: "billing", Scopes: []string{}},
+		},
+		{
+			name:              "scope and scp array",
+			token:             sign(jwt.SigningMethodRS256, "rsa", rsaKey, withClaim("scp", []string{"admin"})),
+			expectedPrincipal: Principal{Subject: "billing", Scopes: []string{ScopeStockRead, ScopeStockReserve, ScopeAdmin}},
+		},
+		{
+			name:  "warehouses",
+			token: sign(jwt.SigningMethodRS256, "rsa", rsaKey, withClaim("warehouses", []string{"warehouse1", "warehouse2"})),
+			expectedPrincipal: Principal{
+				Subject:    "billing",
+				Scopes:     []string{ScopeStockRead, ScopeStockReserve},
+				Warehouses: []string{"warehouse1", "warehouse2"},
+			},
+		},
+		{
+			name:          "expired",
+			token:         sign(jwt.SigningMethodRS256, "rsa", rsaKey, withClaim("exp", time.Now().Add(-time.Minute).Unix())),
+			expectedError: true,
+		},
+		{
+			name:          "without expiration",
+			token:         sign(jwt.SigningMethodRS256, "rsa", rsaKey, withClaim("exp", nil)),
+			expectedError: true,
+		},
+		{
+			name:          "wrong issuer",
+			token:         sign(jwt.SigningMethodRS256, "rsa", rsaKey, withClaim("iss", "https://evil.example.com")),
+			expectedError: true,
+		},
+		{
+			name:          "wrong audience",
+			token:         sign(jwt.SigningMethodRS256, "rsa", rsaKey, withClaim("aud", "billing")),
+			expectedError: true,
+		},
+		{
+			name:          "unknown signer",
+			token:         sign(jwt.SigningMethodRS256, "rsa", otherKey, validClaims()),
+			expectedError: true,
+		},
+		{
+			name:          "unknown kid",
+			token:         sign(jwt.SigningMethodRS256, "other", rsaKey, validClaims()),
+			expectedError: true,
+		},
+		{
+			name:          "encryption key",
+			token:         sign(jwt.SigningMethodRS256, "enc", rsaKey, validClaims()),
+			expectedError: true,
+		},
+		{
+			name:          "no kid with several keys",
+			token:         sign(jwt.SigningMethodRS256, "", rsaKey, validClaims()),
+			expectedError: true,
+		},
+		{
+			name:          "hmac",
+			token:         sign(jwt.SigningMethodHS256, "rsa", []byte("secret"), validClaims()),
+			expectedError: true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			principal, err := verifier.Verify(testCase.token)
+
+			if testCase.expectedError {
+				assert.ErrorIs(t, err, ErrInvalidCredentials)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, testCase.expectedPrincipal, principal)
+		})
+	}
+}
+
+func TestNewJWTVerifier_InvalidJWKS(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"keys":[{"kty":"oct","kid":"hmac","k":"c2VjcmV0"}]}`), 0o600))
+
+	_, err := NewJWTVerifier(path, "", "")
+	assert.ErrorContains(t, err, `unsupported key type "oct"`)
+
+	_, err = NewJWTVerifier(filepath.Join(t.TempDir(), "missing.json"), "", "")
+	assert.Error(t, err)
+}
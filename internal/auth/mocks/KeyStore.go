@@ -0,0 +1,55 @@
+// Code generated by mockery v2.38.0. DO NOT EDIT.
+
+package mocks
+
+import (
+	models "github.com/shamank/warehouse-service/internal/domain/models"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// KeyStore is an autogenerated mock type for the KeyStore type
+type KeyStore struct {
+	mock.Mock
+}
+
+// GetAPIKeyByHash provides a mock function with given fields: hash
+func (_m *KeyStore) GetAPIKeyByHash(hash string) (models.APIKey, error) {
+	ret := _m.Called(hash)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetAPIKeyByHash")
+	}
+
+	var r0 models.APIKey
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (models.APIKey, error)); ok {
+		return rf(hash)
+	}
+	if rf, ok := ret.Get(0).(func(string) models.APIKey); ok {
+		r0 = rf(hash)
+	} else {
+		r0 = ret.Get(0).(models.APIKey)
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(hash)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewKeyStore creates a new instance of KeyStore. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewKeyStore(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *KeyStore {
+	mock := &KeyStore{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
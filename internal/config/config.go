@@ -8,11 +8,27 @@ import (
 
 const defaultConfigPath = "./configs/dev.yaml"
 
+// доступные хранилища данных
+const (
+	StoragePostgres = "postgres"
+	StorageSQLite   = "sqlite"
+	StorageMySQL    = "mysql"
+)
+
 type (
 	Config struct {
-		HTTP           HTTPConfig     `yaml:"http"`
-		Postgres       PostgresConfig `yaml:"postgres"`
-		InsertTestData bool           `yaml:"insertTestData"`
+		HTTP           HTTPConfig       `yaml:"http"`
+		Storage        string           `yaml:"storage" env:"STORAGE" env-default:"postgres"`
+		Postgres       PostgresConfig   `yaml:"postgres"`
+		SQLite         SQLiteConfig     `yaml:"sqlite"`
+		MySQL          MySQLConfig      `yaml:"mysql"`
+		Auth           AuthConfig       `yaml:"auth"`
+		CORS           CORSConfig       `yaml:"cors"`
+		RateLimit      RateLimitConfig  `yaml:"rate-limit"`
+		Adjustments    AdjustmentConfig `yaml:"adjustments"`
+		InsertTestData bool             `yaml:"insertTestData"`
+		// ShutdownTimeout - сколько ждать завершения начатых запросов и фоновой работы при остановке
+		ShutdownTimeout time.Duration `yaml:"shutdown-timeout" env:"SHUTDOWN_TIMEOUT" env-default:"5s"`
 	}
 
 	HTTPConfig struct {
@@ -21,16 +37,78 @@ type (
 		WriteTimeOut   time.Duration `yaml:"write-timeout"`
 		ReadTimeOut    time.Duration `yaml:"read-timeout"`
 		MaxHeaderBytes int           `yaml:"maxHeaderBytes"`
+		// TrustedProxies - адреса и подсети прокси, которым доверяется X-Forwarded-For при определении IP клиента.
+		// Без них IP клиента - адрес соединения
+		TrustedProxies []string `yaml:"trusted-proxies" env:"HTTP_TRUSTED_PROXIES"`
+	}
+
+	// AuthConfig - проверка клиентов API. Без JWKSFile принимаются только ключи доступа из хранилища
+	AuthConfig struct {
+		Enabled  bool   `yaml:"enabled" env:"AUTH_ENABLED"`
+		JWKSFile string `yaml:"jwks-file" env:"AUTH_JWKS_FILE"`
+		Issuer   string `yaml:"issuer" env:"AUTH_ISSUER"`
+		Audience string `yaml:"audience" env:"AUTH_AUDIENCE"`
+	}
+
+	// CORSConfig - политика CORS для браузерных клиентов. Без allowed-origins запросы с других доменов не разрешаются.
+	// Источник задается точно (https://shop.example.com), шаблоном (https://*.example.com) или "*" - любой
+	CORSConfig struct {
+		AllowedOrigins   []string      `yaml:"allowed-origins" env:"CORS_ALLOWED_ORIGINS"`
+		AllowedMethods   []string      `yaml:"allowed-methods" env:"CORS_ALLOWED_METHODS"`
+		AllowedHeaders   []string      `yaml:"allowed-headers" env:"CORS_ALLOWED_HEADERS" env-default:"Content-Type,Authorization,X-API-Key"`
+		AllowCredentials bool          `yaml:"allow-credentials" env:"CORS_ALLOW_CREDENTIALS"`
+		MaxAge           time.Duration `yaml:"max-age" env:"CORS_MAX_AGE" env-default:"10m"`
+	}
+
+	// RateLimitConfig - ограничения частоты запросов клиента (по ключу доступа или IP) на каждом маршруте.
+	// Routes переопределяет Default для маршрутов вида "POST /api/reserveProducts"
+	RateLimitConfig struct {
+		Enabled     bool                 `yaml:"enabled" env:"RATE_LIMIT_ENABLED"`
+		Default     RateLimit            `yaml:"default"`
+		Routes      map[string]RateLimit `yaml:"routes"`
+		Concurrency ConcurrencyConfig    `yaml:"concurrency"`
+	}
+
+	// RateLimit - Rate запросов в секунду с всплеском до Burst. Нулевой Rate снимает ограничение
+	RateLimit struct {
+		Rate  float64 `yaml:"rate"`
+		Burst int     `yaml:"burst"`
+	}
+
+	// ConcurrencyConfig - общий лимит одновременных резервирований и снятий резерва.
+	// Нулевой MaxInFlight снимает ограничение
+	ConcurrencyConfig struct {
+		MaxInFlight  int           `yaml:"max-in-flight"`
+		MaxQueue     int           `yaml:"max-queue"`
+		QueueTimeout time.Duration `yaml:"queue-timeout"`
+	}
+
+	// AdjustmentConfig - корректировки остатков. Корректировка больше ApprovalThreshold штук ждет
+	// подтверждения администратора, нулевой порог применяет все корректировки сразу
+	AdjustmentConfig struct {
+		ApprovalThreshold int `yaml:"approval-threshold" env:"ADJUSTMENT_APPROVAL_THRESHOLD" env-default:"100"`
 	}
 
 	PostgresConfig struct {
 		Host     string `yaml:"host"`
 		Port     string `yaml:"port"`
 		User     string `yaml:"user"`
-		Password string `env:"POSTGRES_PASSWORD" env-required:"true"`
+		Password string `env:"POSTGRES_PASSWORD"`
 		Database string `yaml:"database"`
 		SSLMode  string `yaml:"ssl-mode"`
 	}
+
+	SQLiteConfig struct {
+		Path string `yaml:"path" env:"SQLITE_PATH" env-default:"./data/warehouse.db"`
+	}
+
+	MySQLConfig struct {
+		Host     string `yaml:"host"`
+		Port     string `yaml:"port"`
+		User     string `yaml:"user"`
+		Password string `env:"MYSQL_PASSWORD"`
+		Database string `yaml:"database"`
+	}
 )
 
 func InitConfig(configPath string) *Config {
@@ -49,5 +127,20 @@ func InitConfig(configPath string) *Config {
 		panic("cannot read config: " + err.Error())
 	}
 
+	switch cfg.Storage {
+	case StoragePostgres:
+		// пароль нужен только для выбранного хранилища
+		if cfg.Postgres.Password == "" {
+			panic("cannot read config: POSTGRES_PASSWORD is required")
+		}
+	case StorageMySQL:
+		if cfg.MySQL.Password == "" {
+			panic("cannot read config: MYSQL_PASSWORD is required")
+		}
+	case StorageSQLite:
+	default:
+		panic("unknown storage: " + cfg.Storage)
+	}
+
 	return &cfg
 }
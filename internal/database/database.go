@@ -0,0 +1,110 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/go-sql-driver/mysql"
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database"
+	migratemysql "github.com/golang-migrate/migrate/v4/database/mysql"
+	"github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/database/sqlite"
+	_ "github.com/golang-migrate/migrate/v4/source/file"
+	_ "github.com/lib/pq"
+	"github.com/shamank/warehouse-service/internal/config"
+	_ "modernc.org/sqlite"
+	"os"
+	"path/filepath"
+)
+
+// Open открывает соединение с хранилищем, выбранным в конфигурации
+func Open(cfg *config.Config) (*sql.DB, error) {
+	switch cfg.Storage {
+	case config.StoragePostgres:
+		return sql.Open("postgres", fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
+			cfg.Postgres.Host, cfg.Postgres.Port, cfg.Postgres.User, cfg.Postgres.Password, cfg.Postgres.Database, cfg.Postgres.SSLMode),
+		)
+	case config.StorageSQLite:
+		return OpenSQLite(cfg.SQLite.Path)
+	case config.StorageMySQL:
+		mysqlConfig := mysql.NewConfig()
+		mysqlConfig.User = cfg.MySQL.User
+		mysqlConfig.Passwd = cfg.MySQL.Password
+		mysqlConfig.Net = "tcp"
+		mysqlConfig.Addr = cfg.MySQL.Host + ":" + cfg.MySQL.Port
+		mysqlConfig.DBName = cfg.MySQL.Database
+
+		return OpenMySQL(mysqlConfig)
+	default:
+		return nil, fmt.Errorf("unknown storage: %s", cfg.Storage)
+	}
+}
+
+// OpenSQLite открывает файл базы SQLite, создавая при необходимости каталог для него
+func OpenSQLite(path string) (*sql.DB, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+
+	// транзакции берут блокировку на запись сразу (BEGIN IMMEDIATE),
+	// иначе два параллельных резервирования могут получить SQLITE_BUSY при попытке записи
+	db, err := sql.Open("sqlite", "file:"+path+"?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_txlock=immediate")
+	if err != nil {
+		return nil, err
+	}
+
+	// SQLite допускает только одного писателя, поэтому держим одно соединение
+	db.SetMaxOpenConns(1)
+
+	return db, nil
+}
+
+// OpenMySQL открывает соединение с MySQL, дополняя cfg параметрами, без которых хранилище работает неверно
+func OpenMySQL(cfg *mysql.Config) (*sql.DB, error) {
+	// файлы миграций содержат несколько запросов
+	cfg.MultiStatements = true
+	// RowsAffected должен считать найденные строки, а не измененные,
+	// иначе обновление с нулевой дельтой выглядит как отсутствие товара
+	cfg.ClientFoundRows = true
+	// datetime сканируется в time.Time, а не в []byte
+	cfg.ParseTime = true
+
+	return sql.Open("mysql", cfg.FormatDSN())
+}
+
+// Migrate применяет миграции из migrationPath к выбранному хранилищу
+func Migrate(db *sql.DB, storage string, migrationPath string) error {
+	var (
+		driver database.Driver
+		err    error
+	)
+
+	switch storage {
+	case config.StoragePostgres:
+		driver, err = postgres.WithInstance(db, &postgres.Config{})
+	case config.StorageSQLite:
+		driver, err = sqlite.WithInstance(db, &sqlite.Config{})
+	case config.StorageMySQL:
+		driver, err = migratemysql.WithInstance(db, &migratemysql.Config{})
+	default:
+		return fmt.Errorf("unknown storage: %s", storage)
+	}
+	if err != nil {
+		return err
+	}
+
+	m, err := migrate.NewWithDatabaseInstance(
+		"file://"+migrationPath,
+		storage,
+		driver,
+	)
+	if err != nil {
+		return err
+	}
+
+	if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// причины корректировки остатка. Повреждение и потеря только уменьшают остаток, находка - только увеличивает
+const (
+	AdjustmentDamaged    = "damaged"
+	AdjustmentLost       = "lost"
+	AdjustmentFound      = "found"
+	AdjustmentCorrection = "correction"
+)
+
+// статусы корректировки
+const (
+	// AdjustmentPending - корректировка больше порога ждет подтверждения администратора, остаток не изменен
+	AdjustmentPending  = "pending"
+	AdjustmentApplied  = "applied"
+	AdjustmentRejected = "rejected"
+)
+
+// Adjustment - корректировка свободного остатка товара на складе на Delta штук, с BinPath - в этой ячейке.
+// Actor - кто обнаружил расхождение, ReviewedBy - клиент, подтвердивший или отклонивший корректировку
+type Adjustment struct {
+	UUID           string
+	WarehouseUUID  string
+	ProductArticle string
+	BinPath        string
+	Delta          int
+	Reason         string
+	Comment        string
+	Actor          string
+	Status         string
+	CreatedAt      time.Time
+	ReviewedBy     string
+	ReviewedAt     *time.Time
+}
@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// APIKey - ключ доступа клиента к API. Сам ключ не хранится, только его хэш.
+// Warehouses - склады, доступные клиенту, пустой список - все склады. RevokedAt не nil у отозванного ключа
+type APIKey struct {
+	UUID       string
+	Name       string
+	Hash       string
+	Scopes     []string
+	Warehouses []string
+	CreatedAt  time.Time
+	RevokedAt  *time.Time
+}
@@ -0,0 +1,31 @@
+package models
+
+// корзины остатка товара на складе. Продается и резервируется только BucketSellable - свободный остаток Quantity,
+// остальные корзины непродаваемые и хранятся отдельно от Quantity и ReservedQuantity
+const (
+	BucketSellable   = "sellable"
+	BucketQuarantine = "quarantine"
+	BucketDamaged    = "damaged"
+	BucketInTransit  = "in_transit"
+)
+
+// StockBuckets - все корзины остатка, продаваемая первой
+var StockBuckets = []string{BucketSellable, BucketQuarantine, BucketDamaged, BucketInTransit}
+
+type (
+	// BucketStock - остаток товара на складе в непродаваемой корзине
+	BucketStock struct {
+		ProductArticle string
+		WarehouseUUID  string
+		Bucket         string
+		Quantity       int
+	}
+
+	// BucketMove - перемещение товара между корзинами склада
+	BucketMove struct {
+		ProductArticle string
+		Quantity       int
+		From           string
+		To             string
+	}
+)
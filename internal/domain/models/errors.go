@@ -0,0 +1,46 @@
+package models
+
+import "errors"
+
+// ошибки предметной области, общие для хранилищ, сервиса и обработчиков
+var (
+	ErrWarehouseNotFound   = errors.New("warehouse not found")
+	ErrProductNotFound     = errors.New("product not found")
+	ErrReservationNotFound = errors.New("reservation not found")
+	ErrAPIKeyNotFound      = errors.New("api key not found")
+	ErrSerialNotFound      = errors.New("serial number not found")
+	ErrLocationNotFound    = errors.New("location not found")
+	ErrPickListNotFound    = errors.New("pick list not found")
+	ErrAdjustmentNotFound  = errors.New("adjustment not found")
+	ErrStocktakeNotFound   = errors.New("stocktake not found")
+	ErrReturnNotFound      = errors.New("return not found")
+	ErrKitNotFound         = errors.New("kit not found")
+
+	ErrNotEnoughProducts  = errors.New("not enough products in warehouses")
+	ErrNotEnoughReserved  = errors.New("not enough reserved products in reservation")
+	ErrReservationClosed  = errors.New("reservation is already closed")
+	ErrLotMismatch        = errors.New("lot already exists with other dates")
+	ErrSerialExists       = errors.New("serial number is already in stock")
+	ErrLocationExists     = errors.New("location already exists")
+	ErrReservationPicking = errors.New("reservation is already on an open pick list")
+	ErrPickLineConfirmed  = errors.New("pick list line is already confirmed")
+	ErrAdjustmentReviewed = errors.New("adjustment is already reviewed")
+	ErrStocktakeClosed    = errors.New("stocktake is already closed")
+	ErrStocktakeOpen      = errors.New("stocktake is already open")
+	ErrReturnStatus       = errors.New("return is not in the required status")
+
+	ErrWarehouseAccessDenied = errors.New("access to warehouse denied")
+	ErrSelfApproval          = errors.New("adjustment cannot be approved by its author")
+
+	ErrInvalidQuantity   = errors.New("quantity must be positive")
+	ErrDuplicateArticle  = errors.New("duplicate article in request")
+	ErrInvalidLotDates   = errors.New("lot expires before it is manufactured")
+	ErrInvalidSerials    = errors.New("serial numbers do not match products")
+	ErrInvalidLocation   = errors.New("invalid location")
+	ErrInvalidAdjustment = errors.New("invalid adjustment")
+	ErrInvalidCount      = errors.New("invalid stocktake count")
+	ErrInvalidReturn     = errors.New("invalid return")
+	ErrInvalidBucketMove = errors.New("invalid stock bucket move")
+	ErrInvalidKit        = errors.New("invalid kit")
+	ErrInvalidSubstitute = errors.New("invalid substitute")
+)
@@ -0,0 +1,41 @@
+package models
+
+type (
+	// Kit - набор: артикул каталога, который не хранится на складе, а собирается из компонентов.
+	// Доступность набора - минимум по компонентам свободного остатка, деленного на количество компонента в наборе
+	Kit struct {
+		ProductArticle string
+		ProductName    string
+		ProductSize    string
+		Components     []KitComponent
+	}
+
+	// KitComponent - компонент набора и его количество в одном наборе
+	KitComponent struct {
+		ProductArticle string
+		Quantity       int
+	}
+)
+
+// Expand заменяет quantity наборов количествами компонентов
+func (k Kit) Expand(quantity int) []KitComponent {
+	components := make([]KitComponent, len(k.Components))
+	for i, component := range k.Components {
+		components[i] = KitComponent{ProductArticle: component.ProductArticle, Quantity: component.Quantity * quantity}
+	}
+
+	return components
+}
+
+// Available возвращает, сколько наборов можно собрать из свободного остатка компонентов free по артикулам
+func (k Kit) Available(free map[string]int) int {
+	available := -1
+	for _, component := range k.Components {
+		count := max(free[component.ProductArticle], 0) / component.Quantity
+		if available < 0 || count < available {
+			available = count
+		}
+	}
+
+	return max(available, 0)
+}
@@ -0,0 +1,47 @@
+package models
+
+// уровни мест хранения на складе, от верхнего к нижнему. Товар хранится только в ячейках
+const (
+	LocationZone  = "zone"
+	LocationAisle = "aisle"
+	LocationShelf = "shelf"
+	LocationBin   = "bin"
+)
+
+// LocationKinds - уровни мест хранения в порядке вложенности
+var LocationKinds = []string{LocationZone, LocationAisle, LocationShelf, LocationBin}
+
+type (
+	// Location - место хранения на складе. ParentUUID пустой у зоны,
+	// Path - коды от зоны до места через "/", уникален в пределах склада
+	Location struct {
+		UUID          string
+		WarehouseUUID string
+		ParentUUID    string
+		Kind          string
+		Code          string
+		Path          string
+	}
+
+	// BinStock - остаток товара в ячейке
+	BinStock struct {
+		ProductArticle   string
+		Path             string
+		Quantity         int
+		ReservedQuantity int
+	}
+
+	// BinQuantity - количество товара в ячейке с путем Path
+	BinQuantity struct {
+		Path     string
+		Quantity int
+	}
+
+	// StockMove - перемещение товара между ячейками склада. Пустой путь - товар, не размещенный по ячейкам
+	StockMove struct {
+		ProductArticle string
+		Quantity       int
+		FromPath       string
+		ToPath         string
+	}
+)
@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+type (
+	// Lot - партия товара. Даты хранятся без времени, nil - дата не указана
+	Lot struct {
+		Number         string
+		ManufacturedAt *time.Time
+		ExpiresAt      *time.Time
+	}
+
+	// LotStock - остаток партии товара на складе
+	LotStock struct {
+		ProductArticle   string
+		Lot              Lot
+		Quantity         int
+		ReservedQuantity int
+	}
+
+	// ReceiptItem - поступление товара на склад. Lot nil - товар без партии.
+	// Serials - серийные номера штучного товара, по одному на единицу. BinPath - ячейка, в которую размещается товар,
+	// пустой - товар не размещается по ячейкам
+	ReceiptItem struct {
+		ProductArticle string
+		Quantity       int
+		Lot            *Lot
+		Serials        []string
+		BinPath        string
+	}
+)
+
+// Expired сообщает, истек ли срок годности партии к дню today.
+// В последний день срока партия еще годна
+func (l Lot) Expired(today time.Time) bool {
+	return l.ExpiresAt != nil && l.ExpiresAt.Before(today.Truncate(24*time.Hour))
+}
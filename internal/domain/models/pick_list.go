@@ -0,0 +1,41 @@
+package models
+
+import "time"
+
+// статусы листа отбора
+const (
+	PickListOpen      = "open"
+	PickListCompleted = "completed"
+)
+
+// статусы строки листа отбора
+const (
+	PickLinePending = "pending"
+	PickLinePicked  = "picked"
+	// PickLineShort - собрано меньше, чем нужно: недостача списана со склада
+	PickLineShort = "short"
+)
+
+type (
+	// PickList - лист отбора по одному или нескольким резервам. Строки - в порядке обхода
+	PickList struct {
+		UUID      string
+		Status    string
+		CreatedAt time.Time
+		UpdatedAt time.Time
+		Lines     []PickLine
+	}
+
+	// PickLine - товар одного резерва, который нужно собрать из ячейки с путем LocationPath.
+	// Пустой LocationPath - товар, не размещенный по ячейкам
+	PickLine struct {
+		LineNo          int
+		ReservationUUID string
+		ProductArticle  string
+		WarehouseUUID   string
+		LocationPath    string
+		Quantity        int
+		PickedQuantity  int
+		Status          string
+	}
+)
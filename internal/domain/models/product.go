@@ -1,9 +1,15 @@
 package models
 
+// Product - товар и его остаток на складе.
+// ExpiredQuantity - часть свободного остатка Quantity в партиях с истекшим сроком годности.
+// Serialized - штучный товар, каждая единица которого учитывается по серийному номеру
 type Product struct {
-	UUID     string
-	Name     string
-	Size     string
-	Code     string
-	Quantity int
+	UUID             string
+	Name             string
+	Size             string
+	Code             string
+	Quantity         int
+	ReservedQuantity int
+	ExpiredQuantity  int
+	Serialized       bool
 }
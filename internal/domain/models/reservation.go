@@ -0,0 +1,45 @@
+package models
+
+import "time"
+
+// статусы резерва
+const (
+	ReservationActive    = "active"
+	ReservationReleased  = "released"
+	ReservationCancelled = "cancelled"
+)
+
+type (
+	// Reservation - резерв товаров, созданный одним запросом
+	Reservation struct {
+		UUID      string
+		Status    string
+		CreatedAt time.Time
+		UpdatedAt time.Time
+		Items     []ReservationItem
+	}
+
+	// ReservationItem - часть резерва одного товара на одном складе.
+	// ShortQuantity - товар, который не нашли при отборе и списали со склада, UnfulfilledQuantity - часть
+	// ShortQuantity, которую не удалось зарезервировать на других складах. В резерве остается
+	// Quantity - ReleasedQuantity - ShortQuantity.
+	// Serials - серийные номера штучного товара, которые сейчас в резерве,
+	// Bins - ячейки, в которых лежит еще не освобожденный товар, в порядке обхода,
+	// SubstituteFor - артикул, вместо которого зарезервирован товар
+	ReservationItem struct {
+		ProductArticle      string
+		WarehouseUUID       string
+		Quantity            int
+		ReleasedQuantity    int
+		ShortQuantity       int
+		UnfulfilledQuantity int
+		Serials             []string
+		Bins                []BinQuantity
+		SubstituteFor       string
+	}
+)
+
+// Remaining возвращает, сколько товара позиции еще в резерве
+func (item ReservationItem) Remaining() int {
+	return item.Quantity - item.ReleasedQuantity - item.ShortQuantity
+}
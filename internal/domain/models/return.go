@@ -0,0 +1,40 @@
+package models
+
+import "time"
+
+// шаги возврата: создан по заявке покупателя, принят на склад, осмотрен
+const (
+	ReturnCreated   = "created"
+	ReturnReceived  = "received"
+	ReturnInspected = "inspected"
+)
+
+// результаты осмотра возврата
+const (
+	// ReturnRestock - товар возвращается в продажу, в свободный остаток склада
+	ReturnRestock = "restock"
+	// ReturnQuarantine - товар уходит в непродаваемую корзину BucketQuarantine
+	ReturnQuarantine = "quarantine"
+	// ReturnScrap - товар утилизируется, остаток не меняется
+	ReturnScrap = "scrap"
+)
+
+// Return - возврат Quantity штук товара покупателем на склад. Reference - номер заказа или заявки покупателя,
+// Outcome и Comment заполняются при осмотре. Кто и когда выполнил шаг, видно по *By и *At
+type Return struct {
+	UUID           string
+	WarehouseUUID  string
+	ProductArticle string
+	Quantity       int
+	Reference      string
+	Reason         string
+	Status         string
+	Outcome        string
+	Comment        string
+	CreatedBy      string
+	CreatedAt      time.Time
+	ReceivedBy     string
+	ReceivedAt     *time.Time
+	InspectedBy    string
+	InspectedAt    *time.Time
+}
@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// статусы серийного номера
+const (
+	SerialAvailable = "available"
+	SerialReserved  = "reserved"
+	SerialShipped   = "shipped"
+	// SerialReturned - отгруженный номер, снова принятый на склад. Резервируется как SerialAvailable
+	SerialReturned = "returned"
+	// SerialMissing - номер, который не нашли при отборе. Найденный номер принимается на склад как SerialReturned
+	SerialMissing = "missing"
+)
+
+type (
+	// ProductSerial - серийный номер штучного товара и его текущее состояние.
+	// ReservationUUID пустой, если номер не в резерве v2
+	ProductSerial struct {
+		ProductArticle  string
+		SerialNumber    string
+		WarehouseUUID   string
+		Status          string
+		ReservationUUID string
+		UpdatedAt       time.Time
+	}
+
+	// SerialEvent - смена состояния серийного номера
+	SerialEvent struct {
+		Status          string
+		WarehouseUUID   string
+		ReservationUUID string
+		CreatedAt       time.Time
+	}
+)
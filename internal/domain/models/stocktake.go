@@ -0,0 +1,58 @@
+package models
+
+import "time"
+
+// статусы инвентаризации
+const (
+	StocktakeOpen   = "open"
+	StocktakeClosed = "closed"
+)
+
+type (
+	// Stocktake - инвентаризация склада или, с ZoneUUID, зоны. Строки - в порядке обхода.
+	// В режиме Blind ожидаемые количества не показываются до закрытия
+	Stocktake struct {
+		UUID          string
+		WarehouseUUID string
+		ZoneUUID      string
+		ZonePath      string
+		Blind         bool
+		Status        string
+		CreatedBy     string
+		CreatedAt     time.Time
+		ClosedBy      string
+		ClosedAt      *time.Time
+		Lines         []StocktakeLine
+	}
+
+	// StocktakeLine - товар в ячейке с путем LocationPath, пустой LocationPath - товар на складе целиком.
+	// Expected - остаток вместе с резервом при открытии инвентаризации, OnHand - в момент пересчета.
+	// Counted - пересчитанное количество, nil - строку еще не пересчитали. Unadjusted - часть недостачи,
+	// не списанная при закрытии: к закрытию товар зарезервировали, а резерв корректировки не трогают
+	StocktakeLine struct {
+		LineNo         int
+		ProductArticle string
+		LocationPath   string
+		Expected       int
+		Counted        *int
+		OnHand         int
+		CountedAt      *time.Time
+		AdjustmentUUID string
+		Unadjusted     int
+	}
+
+	// StocktakeCount - пересчитанное количество товара в ячейке или, без LocationPath, на складе
+	StocktakeCount struct {
+		ProductArticle string
+		LocationPath   string
+		Counted        int
+	}
+)
+
+// Variance возвращает расхождение пересчета с остатком: больше нуля - излишек, меньше - недостача
+func (l StocktakeLine) Variance() int {
+	if l.Counted == nil {
+		return 0
+	}
+	return *l.Counted - l.OnHand
+}
@@ -0,0 +1,10 @@
+package models
+
+// Substitute - товар, которым можно заменить другой при нехватке.
+// SameSize - замена подходит, только если ее размер совпадает с размером заменяемого товара
+type Substitute struct {
+	ProductArticle string
+	ProductName    string
+	ProductSize    string
+	SameSize       bool
+}
@@ -1,10 +1,27 @@
 package models
 
 type (
+	// WarehouseProduct - остаток товара на складе.
+	// Из GetProductsQuantity Quantity приходит без партий с истекшим сроком годности: их нельзя резервировать
 	WarehouseProduct struct {
 		WarehouseUUID    string
 		ProductUUID      string
 		Quantity         int
 		ReservedQuantity int
 	}
+
+	// ProductStock - остатки товара на складе вместе с данными товара и доступностью склада.
+	// ExpiredQuantity - часть Quantity в партиях с истекшим сроком годности, Buckets - непродаваемый остаток
+	// по корзинам, пустые корзины не перечисляются
+	ProductStock struct {
+		ProductArticle        string
+		ProductName           string
+		ProductSize           string
+		WarehouseUUID         string
+		WarehouseAvailability bool
+		Quantity              int
+		ReservedQuantity      int
+		ExpiredQuantity       int
+		Buckets               map[string]int
+	}
 )
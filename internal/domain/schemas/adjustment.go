@@ -0,0 +1,32 @@
+package schemas
+
+import "time"
+
+type (
+	// NewAdjustment - корректировка остатка товара на складе на Delta штук. Actor учитывается, только если
+	// проверка доступа выключена, иначе автор - клиент API
+	NewAdjustment struct {
+		Article string `json:"article" binding:"required"`
+		Delta   int    `json:"delta"`
+		Reason  string `json:"reason" binding:"required,oneof=damaged lost found correction"`
+		Comment string `json:"comment" binding:"max=1000"`
+		Actor   string `json:"actor" binding:"max=255"`
+	}
+
+	// Adjustment - корректировка остатка, с Location - в ячейке. ReviewedBy и ReviewedAt заполнены
+	// у подтвержденной или отклоненной администратором корректировки
+	Adjustment struct {
+		ID            string     `json:"id"`
+		WarehouseUUID string     `json:"warehouse_uuid"`
+		Article       string     `json:"article"`
+		Location      string     `json:"location,omitempty"`
+		Delta         int        `json:"delta"`
+		Reason        string     `json:"reason"`
+		Comment       string     `json:"comment,omitempty"`
+		Actor         string     `json:"actor"`
+		Status        string     `json:"status"`
+		CreatedAt     time.Time  `json:"created_at"`
+		ReviewedBy    string     `json:"reviewed_by,omitempty"`
+		ReviewedAt    *time.Time `json:"reviewed_at,omitempty"`
+	}
+)
@@ -0,0 +1,9 @@
+package schemas
+
+// BucketMove - перемещение товара между корзинами остатка склада
+type BucketMove struct {
+	Article  string `json:"article" binding:"required"`
+	Quantity int    `json:"quantity" binding:"required,min=1"`
+	From     string `json:"from" binding:"required,oneof=sellable quarantine damaged in_transit"`
+	To       string `json:"to" binding:"required,oneof=sellable quarantine damaged in_transit"`
+}
@@ -0,0 +1,25 @@
+package schemas
+
+type (
+	// KitDefinition - состав набора
+	KitDefinition struct {
+		Components []KitComponent `json:"components" binding:"required,min=1,max=100,dive"`
+	}
+
+	// KitComponent - компонент набора и его количество в одном наборе
+	KitComponent struct {
+		Article  string `json:"article" binding:"required"`
+		Quantity int    `json:"quantity" binding:"required,min=1"`
+	}
+
+	// Kit - набор и сколько наборов можно собрать. Available - по доступным складам вместе, компоненты одного
+	// набора могут быть на разных складах; в Warehouses - сколько наборов можно собрать на каждом складе отдельно
+	Kit struct {
+		Article    string           `json:"article"`
+		Name       string           `json:"name"`
+		Size       string           `json:"size"`
+		Components []KitComponent   `json:"components"`
+		Available  int              `json:"available"`
+		Warehouses []WarehouseStock `json:"warehouses"`
+	}
+)
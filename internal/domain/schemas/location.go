@@ -0,0 +1,55 @@
+package schemas
+
+type (
+	// NewLocation - место хранения, которое создается на складе. Без ParentID создается зона
+	NewLocation struct {
+		Kind     string `json:"kind" binding:"required,oneof=zone aisle shelf bin"`
+		Code     string `json:"code" binding:"required,max=32,excludes=/"`
+		ParentID string `json:"parent_id" binding:"omitempty,uuid"`
+	}
+
+	// Location - место хранения на складе. Path - коды от зоны до места через "/"
+	Location struct {
+		ID       string `json:"id"`
+		ParentID string `json:"parent_id,omitempty"`
+		Kind     string `json:"kind"`
+		Code     string `json:"code"`
+		Path     string `json:"path"`
+	}
+
+	// StockMove - перемещение свободного товара между ячейками по их путям.
+	// Пустой From - товар, еще не размещенный по ячейкам, пустой To - снять товар с ячейки
+	StockMove struct {
+		Article  string `json:"article" binding:"required"`
+		Quantity int    `json:"quantity" binding:"required,min=1"`
+		From     string `json:"from"`
+		To       string `json:"to"`
+	}
+
+	// ProductBin - остаток товара в ячейке
+	ProductBin struct {
+		Location string `json:"location"`
+		Quantity int    `json:"quantity"`
+		Reserved int    `json:"reserved"`
+	}
+
+	// BinQuantity - количество товара в ячейке
+	BinQuantity struct {
+		Location string `json:"location"`
+		Quantity int    `json:"quantity"`
+	}
+
+	// ReservationPickList - что и откуда собрать по резерву, строки в порядке обхода склада
+	ReservationPickList struct {
+		ReservationID string     `json:"reservation_id"`
+		Lines         []PickLine `json:"lines"`
+	}
+
+	// PickLine - строка листа отбора. Пустой Location - товар, не размещенный по ячейкам
+	PickLine struct {
+		WarehouseUUID string `json:"warehouse_uuid"`
+		Location      string `json:"location"`
+		Article       string `json:"article"`
+		Quantity      int    `json:"quantity"`
+	}
+)
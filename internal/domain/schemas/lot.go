@@ -0,0 +1,75 @@
+package schemas
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type (
+	// ReceiptItem - поступление товара на склад. Без Lot товар принимается без партии.
+	// Serials обязательны для штучного товара, по одному на единицу. Номер без "/", он передается в пути запросов.
+	// Bin - путь ячейки, в которую сразу размещается товар
+	ReceiptItem struct {
+		Article  string   `json:"article" binding:"required"`
+		Quantity int      `json:"quantity" binding:"required,min=1"`
+		Lot      *Lot     `json:"lot"`
+		Serials  []string `json:"serials" binding:"omitempty,dive,required,max=255,excludes=/"`
+		Bin      string   `json:"bin"`
+	}
+
+	// Lot - партия товара. Номер уникален в пределах товара
+	Lot struct {
+		Number         string `json:"number" binding:"required,max=64"`
+		ManufacturedAt *Date  `json:"manufactured_at"`
+		ExpiresAt      *Date  `json:"expires_at"`
+	}
+
+	// ProductLot - остаток партии товара на складе. Expired - срок годности истек, партия не резервируется
+	ProductLot struct {
+		Lot
+		Quantity int  `json:"quantity"`
+		Reserved int  `json:"reserved"`
+		Expired  bool `json:"expired"`
+	}
+)
+
+// Date - дата без времени, в JSON - строка YYYY-MM-DD
+type Date struct {
+	time.Time
+}
+
+// NewDate возвращает дату в UTC, nil для nil
+func NewDate(t *time.Time) *Date {
+	if t == nil {
+		return nil
+	}
+	return &Date{Time: t.UTC()}
+}
+
+// TimePtr возвращает дату как время полуночи UTC, nil для nil
+func (d *Date) TimePtr() *time.Time {
+	if d == nil {
+		return nil
+	}
+	t := d.Time
+	return &t
+}
+
+func (d Date) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.Format(time.DateOnly))
+}
+
+func (d *Date) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+
+	parsed, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return err
+	}
+	d.Time = parsed
+
+	return nil
+}
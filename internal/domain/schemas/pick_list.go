@@ -0,0 +1,37 @@
+package schemas
+
+import "time"
+
+type (
+	// NewPickList - запрос на лист отбора по активным резервам
+	NewPickList struct {
+		Reservations []string `json:"reservations" binding:"required,min=1,max=100,dive,uuid"`
+	}
+
+	// PickList - лист отбора по одному или нескольким резервам. Строки сгруппированы по складам
+	// и идут в порядке обхода ячеек, товар вне ячеек - в конце склада
+	PickList struct {
+		ID        string         `json:"id"`
+		Status    string         `json:"status"`
+		CreatedAt time.Time      `json:"created_at"`
+		UpdatedAt time.Time      `json:"updated_at"`
+		Lines     []PickListLine `json:"lines"`
+	}
+
+	// PickListLine - строка листа отбора: товар одного резерва в одной ячейке. Picked - сколько собрано
+	PickListLine struct {
+		Line          int    `json:"line"`
+		ReservationID string `json:"reservation_id"`
+		WarehouseUUID string `json:"warehouse_uuid"`
+		Location      string `json:"location"`
+		Article       string `json:"article"`
+		Quantity      int    `json:"quantity"`
+		Picked        int    `json:"picked"`
+		Status        string `json:"status"`
+	}
+
+	// PickConfirm - сколько штук собрано по строке листа отбора. Меньше, чем в строке, - недостача
+	PickConfirm struct {
+		Picked *int `json:"picked" binding:"required,min=0"`
+	}
+)
@@ -1,9 +1,108 @@
 package schemas
 
-type Product struct {
-	UUID     string `json:"-"`
-	Name     string `json:"name"`
-	Size     string `json:"size"`
-	Code     string `json:"code"`
-	Quantity int    `json:"quantity"`
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+)
+
+// поля, по которым можно сортировать остатки на складе
+const (
+	SortByArticle  = "article"
+	SortByName     = "name"
+	SortByQuantity = "quantity"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+type (
+	// Product - остаток товара на складе.
+	// Quantity - свободный остаток, Expired - его часть в партиях с истекшим сроком годности,
+	// Available - то, что можно продать: без просроченного, на недоступном складе - ноль.
+	// OnHand - продаваемый товар на складе вместе с резервом, Buckets - непродаваемый остаток по корзинам,
+	// например на карантине. Lots и Bins заполняются только по запросу
+	Product struct {
+		UUID      string         `json:"-"`
+		Name      string         `json:"name"`
+		Size      string         `json:"size"`
+		Code      string         `json:"code"`
+		Quantity  int            `json:"quantity"`
+		Available int            `json:"available"`
+		Reserved  int            `json:"reserved"`
+		OnHand    int            `json:"on_hand"`
+		Expired   int            `json:"expired"`
+		Lots      []ProductLot   `json:"lots,omitempty"`
+		Bins      []ProductBin   `json:"bins,omitempty"`
+		Buckets   map[string]int `json:"buckets,omitempty"`
+	}
+
+	// RemainingProductsFilter - фильтры, сортировка и страница остатков на складе
+	RemainingProductsFilter struct {
+		ArticlePrefix string
+		Name          string // поиск по подстроке без учета регистра
+		Size          string
+		MinQuantity   int
+		InStock       bool
+		// товары без остатка, резерва и непродаваемого остатка по умолчанию не возвращаются
+		IncludeZeroStock bool
+		Sort             string // одно из SortBy*, по умолчанию SortByArticle
+		Desc             bool
+		Limit            int
+		After            *ProductCursor // nil - первая страница
+		// вернуть остатки товаров по партиям
+		ByLot bool
+		// вернуть остатки товаров по ячейкам
+		ByLocation bool
+	}
+
+	// RemainingProducts - страница остатков на складе
+	RemainingProducts struct {
+		Warehouse  WarehouseInfo `json:"warehouse"`
+		Items      []Product     `json:"items"`
+		NextCursor *string       `json:"next_cursor"`
+		Total      int           `json:"total"`
+	}
+
+	WarehouseInfo struct {
+		UUID        string `json:"uuid"`
+		Name        string `json:"name"`
+		IsAvailable bool   `json:"is_available"`
+	}
+
+	// ProductCursor - позиция последнего товара на странице.
+	// Артикул уникален, поэтому вместе со значением поля сортировки однозначно задает позицию
+	ProductCursor struct {
+		Sort     string `json:"s"`
+		Desc     bool   `json:"d,omitempty"`
+		Article  string `json:"a"`
+		Name     string `json:"n,omitempty"`
+		Quantity int    `json:"q,omitempty"`
+	}
+)
+
+// Encode возвращает непрозрачное представление курсора для передачи клиенту
+func (c ProductCursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeProductCursor разбирает курсор, полученный от клиента
+func DecodeProductCursor(cursor string) (*ProductCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var result ProductCursor
+	if err := json.Unmarshal(data, &result); err != nil || result.Article == "" {
+		return nil, ErrInvalidCursor
+	}
+
+	switch result.Sort {
+	case SortByArticle, SortByName, SortByQuantity:
+	default:
+		return nil, ErrInvalidCursor
+	}
+
+	return &result, nil
 }
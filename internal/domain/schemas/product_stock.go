@@ -0,0 +1,34 @@
+package schemas
+
+type (
+	// ProductsStockQuery - запрос остатков по списку товаров и/или складов.
+	// Пустой список означает отсутствие фильтра
+	ProductsStockQuery struct {
+		Articles   []string `json:"articles" binding:"max=1000"`
+		Warehouses []string `json:"warehouses" binding:"max=1000,dive,uuid"`
+	}
+
+	// ProductStock - остатки товара. Available и Reserved считаются только по доступным складам,
+	// в Warehouses перечислены все склады, где есть товар. У набора (Kit) Available - сколько наборов можно
+	// собрать из компонентов, на складах - из компонентов этого склада
+	ProductStock struct {
+		Name       string           `json:"name"`
+		Size       string           `json:"size"`
+		Code       string           `json:"code"`
+		Kit        bool             `json:"kit,omitempty"`
+		Available  int              `json:"available"`
+		Reserved   int              `json:"reserved"`
+		Warehouses []WarehouseStock `json:"warehouses"`
+	}
+
+	// WarehouseStock - остатки товара на складе. Available не включает Expired - товар в просроченных партиях,
+	// Buckets - непродаваемый остаток по корзинам
+	WarehouseStock struct {
+		WarehouseUUID string         `json:"warehouse_uuid"`
+		IsAvailable   bool           `json:"is_available"`
+		Available     int            `json:"available"`
+		Reserved      int            `json:"reserved"`
+		Expired       int            `json:"expired"`
+		Buckets       map[string]int `json:"buckets,omitempty"`
+	}
+)
@@ -0,0 +1,39 @@
+package schemas
+
+import "time"
+
+type (
+	// Reservation - резерв товаров, созданный через /api/v2/reservations.
+	// PartiallyFulfilled - при сборке чего-то не нашли и не смогли зарезервировать на других складах
+	Reservation struct {
+		ID                 string            `json:"id"`
+		Status             string            `json:"status"`
+		CreatedAt          time.Time         `json:"created_at"`
+		UpdatedAt          time.Time         `json:"updated_at"`
+		Items              []ReservationItem `json:"items"`
+		PartiallyFulfilled bool              `json:"partially_fulfilled,omitempty"`
+	}
+
+	// ReservationItem - часть резерва одного товара на одном складе.
+	// Released - сколько из Quantity уже возвращено на склад, Serials - серийные номера, которые еще в резерве,
+	// Bins - ячейки, из которых нужно собрать то, что еще в резерве. Short - сколько не нашли при сборке и списали,
+	// Unfulfilled - сколько из Short не удалось зарезервировать на других складах,
+	// SubstituteFor - артикул, вместо которого зарезервирован товар
+	ReservationItem struct {
+		Article       string        `json:"article"`
+		WarehouseUUID string        `json:"warehouse_uuid"`
+		Quantity      int           `json:"quantity"`
+		Released      int           `json:"released"`
+		Short         int           `json:"short,omitempty"`
+		Unfulfilled   int           `json:"unfulfilled,omitempty"`
+		Serials       []string      `json:"serials,omitempty"`
+		Bins          []BinQuantity `json:"bins,omitempty"`
+		SubstituteFor string        `json:"substitute_for,omitempty"`
+	}
+
+	// ReleaseItem - сколько штук товара вернуть из резерва на склады
+	ReleaseItem struct {
+		Article  string `json:"article" binding:"required"`
+		Quantity int    `json:"quantity" binding:"required,min=1"`
+	}
+)
@@ -0,0 +1,15 @@
+package schemas
+
+type (
+	// ReserveItem - позиция резервирования с явным количеством.
+	// WarehouseUUID ограничивает резерв одним складом, AllowedWarehouses - списком складов.
+	// Без ограничений товар резервируется на любых доступных складах. AllowSubstitutes - при нехватке
+	// зарезервировать все количество одной из замен товара
+	ReserveItem struct {
+		Article           string   `json:"article" binding:"required"`
+		Quantity          int      `json:"quantity" binding:"required,min=1"`
+		WarehouseUUID     string   `json:"warehouse_uuid" binding:"omitempty,uuid,excluded_with=AllowedWarehouses"`
+		AllowedWarehouses []string `json:"allowed_warehouses" binding:"omitempty,dive,uuid"`
+		AllowSubstitutes  bool     `json:"allow_substitutes"`
+	}
+)
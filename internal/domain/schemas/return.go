@@ -0,0 +1,38 @@
+package schemas
+
+import "time"
+
+type (
+	// NewReturn - возврат товара покупателем на склад. Reference - номер заказа или заявки покупателя
+	NewReturn struct {
+		Article   string `json:"article" binding:"required"`
+		Quantity  int    `json:"quantity" binding:"required,min=1"`
+		Reference string `json:"reference" binding:"max=255"`
+		Reason    string `json:"reason" binding:"max=1000"`
+	}
+
+	// ReturnInspection - результат осмотра принятого возврата
+	ReturnInspection struct {
+		Outcome string `json:"outcome" binding:"required,oneof=restock quarantine scrap"`
+		Comment string `json:"comment" binding:"max=1000"`
+	}
+
+	// Return - возврат со всеми пройденными шагами: создание, приемка на склад и осмотр
+	Return struct {
+		ID            string     `json:"id"`
+		WarehouseUUID string     `json:"warehouse_uuid"`
+		Article       string     `json:"article"`
+		Quantity      int        `json:"quantity"`
+		Reference     string     `json:"reference,omitempty"`
+		Reason        string     `json:"reason,omitempty"`
+		Status        string     `json:"status"`
+		Outcome       string     `json:"outcome,omitempty"`
+		Comment       string     `json:"comment,omitempty"`
+		CreatedBy     string     `json:"created_by,omitempty"`
+		CreatedAt     time.Time  `json:"created_at"`
+		ReceivedBy    string     `json:"received_by,omitempty"`
+		ReceivedAt    *time.Time `json:"received_at,omitempty"`
+		InspectedBy   string     `json:"inspected_by,omitempty"`
+		InspectedAt   *time.Time `json:"inspected_at,omitempty"`
+	}
+)
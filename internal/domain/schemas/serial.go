@@ -0,0 +1,23 @@
+package schemas
+
+import "time"
+
+type (
+	// Serial - серийный номер штучного товара. ReservationID заполнен, пока номер в резерве v2
+	Serial struct {
+		Article       string    `json:"article"`
+		SerialNumber  string    `json:"serial_number"`
+		WarehouseUUID string    `json:"warehouse_uuid"`
+		Status        string    `json:"status"`
+		ReservationID string    `json:"reservation_id,omitempty"`
+		UpdatedAt     time.Time `json:"updated_at"`
+	}
+
+	// SerialEvent - смена состояния серийного номера
+	SerialEvent struct {
+		Status        string    `json:"status"`
+		WarehouseUUID string    `json:"warehouse_uuid"`
+		ReservationID string    `json:"reservation_id,omitempty"`
+		CreatedAt     time.Time `json:"created_at"`
+	}
+)
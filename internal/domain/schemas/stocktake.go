@@ -0,0 +1,55 @@
+package schemas
+
+import "time"
+
+type (
+	// NewStocktake - инвентаризация склада или, с ZoneID, одной зоны. В режиме Blind ожидаемые количества
+	// не показываются до закрытия
+	NewStocktake struct {
+		ZoneID string `json:"zone_id" binding:"omitempty,uuid"`
+		Blind  bool   `json:"blind"`
+	}
+
+	// Stocktake - инвентаризация. Строки идут в порядке обхода склада
+	Stocktake struct {
+		ID            string          `json:"id"`
+		WarehouseUUID string          `json:"warehouse_uuid"`
+		Zone          string          `json:"zone,omitempty"`
+		Blind         bool            `json:"blind"`
+		Status        string          `json:"status"`
+		CreatedBy     string          `json:"created_by,omitempty"`
+		CreatedAt     time.Time       `json:"created_at"`
+		ClosedBy      string          `json:"closed_by,omitempty"`
+		ClosedAt      *time.Time      `json:"closed_at,omitempty"`
+		Lines         []StocktakeLine `json:"lines"`
+	}
+
+	// StocktakeLine - строка инвентаризации: товар в ячейке Location или, без нее, на складе целиком.
+	// Expected - остаток вместе с резервом при открытии, OnHand - в момент пересчета, Variance - расхождение
+	// пересчета с OnHand. В слепой инвентаризации до закрытия Expected, OnHand и Variance не заполняются.
+	// Unadjusted - часть недостачи, не списанная при закрытии, потому что товар был в резерве
+	StocktakeLine struct {
+		Line         int        `json:"line"`
+		Location     string     `json:"location,omitempty"`
+		Article      string     `json:"article"`
+		Expected     *int       `json:"expected,omitempty"`
+		Counted      *int       `json:"counted,omitempty"`
+		OnHand       *int       `json:"on_hand,omitempty"`
+		Variance     *int       `json:"variance,omitempty"`
+		CountedAt    *time.Time `json:"counted_at,omitempty"`
+		AdjustmentID string     `json:"adjustment_id,omitempty"`
+		Unadjusted   int        `json:"unadjusted,omitempty"`
+	}
+
+	// StocktakeCount - пересчитанное количество товара в ячейке Location или, без нее, на складе
+	StocktakeCount struct {
+		Article  string `json:"article" binding:"required"`
+		Location string `json:"location"`
+		Counted  *int   `json:"counted" binding:"required,min=0"`
+	}
+
+	// StocktakeCounts - пересчет по инвентаризации
+	StocktakeCounts struct {
+		Counts []StocktakeCount `json:"counts" binding:"required,min=1,max=1000,dive"`
+	}
+)
@@ -0,0 +1,28 @@
+package schemas
+
+type (
+	// SubstitutesDefinition - замены товара в порядке, в котором они пробуются при нехватке
+	SubstitutesDefinition struct {
+		Substitutes []SubstituteRule `json:"substitutes" binding:"max=20,dive"`
+	}
+
+	// SubstituteRule - замена товара. SameSize - только при совпадении размера с заменяемым товаром
+	SubstituteRule struct {
+		Article  string `json:"article" binding:"required"`
+		SameSize bool   `json:"same_size"`
+	}
+
+	// Substitutes - замены товара в порядке, в котором они пробуются при нехватке
+	Substitutes struct {
+		Article     string       `json:"article"`
+		Substitutes []Substitute `json:"substitutes"`
+	}
+
+	// Substitute - замена товара с ее названием и размером
+	Substitute struct {
+		Article  string `json:"article"`
+		Name     string `json:"name"`
+		Size     string `json:"size"`
+		SameSize bool   `json:"same_size"`
+	}
+)
@@ -6,8 +6,11 @@ type (
 		Count         int
 	}
 
+	// ProductWarehouseSplitted - распределение товара по складам. SubstituteFor - артикул, вместо которого
+	// резервируется товар, пустой - товар резервируется сам по себе
 	ProductWarehouseSplitted struct {
 		ProductArticle string
 		WarehouseData  []WarehouseCounter
+		SubstituteFor  string
 	}
 )
@@ -0,0 +1,208 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/shamank/warehouse-service/internal/auth"
+	"github.com/shamank/warehouse-service/internal/domain/models"
+	"github.com/shamank/warehouse-service/internal/domain/schemas"
+	"github.com/shamank/warehouse-service/internal/handler/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"log/slog"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAuthorization(t *testing.T) {
+	reader := auth.Principal{Subject: "reader", Scopes: []string{auth.ScopeStockRead}}
+	reserver := auth.Principal{Subject: "reserver", Scopes: []string{auth.ScopeStockReserve}}
+	admin := auth.Principal{Subject: "admin", Scopes: []string{auth.ScopeAdmin}}
+
+	testCases := []struct {
+		name   string
+		method string
+		url    string
+		body   string
+		// principal и authErr - результат проверки учетных данных
+		principal auth.Principal
+		authErr   error
+		// setup задает ожидания сервиса, nil - запрос не должен дойти до сервиса
+		setup func(service *mocks.Service)
+
+		expectedStatusCode int
+		expectedResult     string
+	}{
+		{
+			name:               "ping is public",
+			method:             "GET",
+			url:                "/api/ping",
+			authErr:            auth.ErrNoCredentials,
+			expectedStatusCode: 200,
+			expectedResult:     `{"message":"pong"}`,
+		},
+		{
+			name:               "no credentials",
+			method:             "GET",
+			url:                "/api/v2/reservations/" + reservationID,
+			authErr:            auth.ErrNoCredentials,
+			expectedStatusCode: 401,
+			expectedResult:     `{"error":"credentials are required"}`,
+		},
+		{
+			name:               "invalid credentials before validation",
+			method:             "GET",
+			url:                "/api/v2/reservations/123",
+			authErr:            auth.ErrInvalidCredentials,
+			expectedStatusCode: 401,
+			expectedResult:     `{"error":"invalid credentials"}`,
+		},
+		{
+			name:               "storage error",
+			method:             "GET",
+			url:                "/api/v2/reservations/" + reservationID,
+			authErr:            errors.New("connection refused"),
+			expectedStatusCode: 500,
+			expectedResult:     `{"error":"internal server error"}`,
+		},
+		{
+			name:      "read scope",
+			method:    "GET",
+			url:       "/api/v2/reservations/" + reservationID,
+			principal: reader,
+			setup: func(service *mocks.Service) {
+				service.On("GetReservation", mock.Anything, reservationID).Return(testReservation, nil)
+			},
+			expectedStatusCode: 200,
+			expectedResult:     testReservationJSON,
+		},
+		{
+			name:               "reserve without reserve scope",
+			method:             "POST",
+			url:                "/api/v2/reservations",
+			body:               `[{"article":"a1as1","quantity":5}]`,
+			principal:          reader,
+			expectedStatusCode: 403,
+			expectedResult:     `{"error":"scope stock:reserve is required"}`,
+		},
+		{
+			name:      "v1 reserve with reserve scope",
+			method:    "POST",
+			url:       "/api/reserveProducts",
+			body:      `["a1as1"]`,
+			principal: reserver,
+			setup: func(service *mocks.Service) {
+				service.On("ReserveProducts", mock.Anything, []string{"a1as1"}).Return(nil)
+			},
+			expectedStatusCode: 200,
+			expectedResult:     `{"message":"OK"}`,
+		},
+		{
+			name:               "release without release scope",
+			method:             "POST",
+			url:                "/api/releaseProducts",
+			body:               `["a1as1"]`,
+			principal:          reserver,
+			expectedStatusCode: 403,
+			expectedResult:     `{"error":"scope stock:release is required"}`,
+		},
+		{
+			name:               "cancel without release scope",
+			method:             "DELETE",
+			url:                "/api/v2/reservations/" + reservationID,
+			principal:          reserver,
+			expectedStatusCode: 403,
+			expectedResult:     `{"error":"scope stock:release is required"}`,
+		},
+		{
+			name:               "approve adjustment without admin scope",
+			method:             "POST",
+			url:                "/api/v2/adjustments/" + adjustmentID + "/approve",
+			principal:          reserver,
+			expectedStatusCode: 403,
+			expectedResult:     `{"error":"scope admin is required"}`,
+		},
+		{
+			name:      "admin has every scope",
+			method:    "POST",
+			url:       "/api/getRemainingProductsBatch",
+			body:      `{"articles":["a1as1"]}`,
+			principal: admin,
+			setup: func(service *mocks.Service) {
+				service.On("GetRemainingProductsBatch", mock.Anything, mock.Anything).Return([]schemas.ProductStock{}, nil)
+			},
+			expectedStatusCode: 200,
+			expectedResult:     `[]`,
+		},
+		{
+			name:      "principal is passed to service",
+			method:    "GET",
+			url:       "/api/v2/reservations/" + reservationID,
+			principal: reader,
+			setup: func(service *mocks.Service) {
+				withReader := mock.MatchedBy(func(ctx context.Context) bool {
+					principal, ok := auth.PrincipalFromContext(ctx)
+					return ok && principal.Subject == reader.Subject
+				})
+				service.On("GetReservation", withReader, reservationID).Return(testReservation, nil)
+			},
+			expectedStatusCode: 200,
+			expectedResult:     testReservationJSON,
+		},
+		{
+			name:      "v2 warehouse access denied",
+			method:    "GET",
+			url:       "/api/v2/warehouses/e4aa0556-aec5-41d4-8280-885865842719/stock",
+			principal: reader,
+			setup: func(service *mocks.Service) {
+				service.On("GetRemainingProducts", mock.Anything, mock.Anything, mock.Anything).
+					Return(schemas.RemainingProducts{}, fmt.Errorf("%w: client \"reader\" is not allowed to use warehouse e4aa0556-aec5-41d4-8280-885865842719", models.ErrWarehouseAccessDenied))
+			},
+			expectedStatusCode: 403,
+			expectedResult:     `{"error":"access to warehouse denied: client \"reader\" is not allowed to use warehouse e4aa0556-aec5-41d4-8280-885865842719"}`,
+		},
+		{
+			name:      "v1 warehouse access denied",
+			method:    "POST",
+			url:       "/api/reserveProducts",
+			body:      `["a1as1"]`,
+			principal: reserver,
+			setup: func(service *mocks.Service) {
+				service.On("ReserveProducts", mock.Anything, []string{"a1as1"}).
+					Return(fmt.Errorf("%w: client \"reserver\" is not allowed to use warehouse e4aa0556-aec5-41d4-8280-885865842719", models.ErrWarehouseAccessDenied))
+			},
+			expectedStatusCode: 403,
+			expectedResult:     `{"error":"access to warehouse denied: client \"reserver\" is not allowed to use warehouse e4aa0556-aec5-41d4-8280-885865842719"}`,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			service := mocks.NewService(t)
+			if testCase.setup != nil {
+				testCase.setup(service)
+			}
+
+			authenticator := mocks.NewAuthenticator(t)
+			authenticator.On("Authenticate", mock.Anything).Return(testCase.principal, testCase.authErr).Maybe()
+
+			handler := NewHandler(service, slog.Default())
+			handler.SetAuthenticator(authenticator)
+
+			r := handler.InitAPIRoutes()
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(testCase.method, testCase.url, bytes.NewBufferString(testCase.body))
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedResult, w.Body.String())
+			if testCase.expectedStatusCode == 401 {
+				assert.Equal(t, `Bearer realm="warehouse"`, w.Header().Get("WWW-Authenticate"))
+			}
+		})
+	}
+}
@@ -0,0 +1,219 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/shamank/warehouse-service/internal/config"
+	"net/http"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+var errCORSCredentialsWithAnyOrigin = errors.New("cors: allow-credentials cannot be used with any origin")
+
+// corsPolicy отвечает на preflight-запросы и разрешает браузеру читать ответы для допустимых источников
+type corsPolicy struct {
+	origins     []string
+	anyOrigin   bool
+	methods     []string
+	headers     []string
+	anyHeader   bool
+	credentials bool
+	maxAge      string
+	// routes - методы зарегистрированных маршрутов по шаблону пути gin, заполняется после регистрации маршрутов
+	routes map[string][]string
+}
+
+// newCORSPolicy проверяет конфигурацию CORS. Шаблоны источников и методы приводятся к каноническому виду
+func newCORSPolicy(cfg config.CORSConfig) (*corsPolicy, error) {
+	policy := &corsPolicy{
+		credentials: cfg.AllowCredentials,
+		maxAge:      strconv.Itoa(int(cfg.MaxAge.Seconds())),
+	}
+
+	for _, origin := range cfg.AllowedOrigins {
+		if origin == "*" {
+			policy.anyOrigin = true
+			continue
+		}
+
+		origin = strings.ToLower(strings.TrimSuffix(origin, "/"))
+		if _, err := path.Match(origin, ""); err != nil {
+			return nil, fmt.Errorf("cors: invalid origin pattern %q: %w", origin, err)
+		}
+		policy.origins = append(policy.origins, origin)
+	}
+	if policy.anyOrigin && policy.credentials {
+		return nil, errCORSCredentialsWithAnyOrigin
+	}
+
+	for _, method := range cfg.AllowedMethods {
+		policy.methods = append(policy.methods, strings.ToUpper(method))
+	}
+
+	for _, header := range cfg.AllowedHeaders {
+		if header == "*" {
+			policy.anyHeader = true
+			continue
+		}
+		policy.headers = append(policy.headers, http.CanonicalHeaderKey(header))
+	}
+
+	return policy, nil
+}
+
+// setRoutes запоминает методы маршрутов: preflight разрешает только методы, которые есть у пути
+func (p *corsPolicy) setRoutes(routes gin.RoutesInfo) {
+	p.routes = make(map[string][]string)
+	for _, route := range routes {
+		p.routes[route.Path] = append(p.routes[route.Path], route.Method)
+	}
+}
+
+// allowOrigin сообщает, может ли браузер со страницы origin обращаться к API
+func (p *corsPolicy) allowOrigin(origin string) bool {
+	if p.anyOrigin {
+		return true
+	}
+
+	origin = strings.ToLower(origin)
+	for _, pattern := range p.origins {
+		// "*" в шаблоне не совпадает с "/", поэтому https://*.example.com не пропустит https://evil.com/.example.com
+		if matched, _ := path.Match(pattern, origin); matched {
+			return true
+		}
+	}
+
+	return false
+}
+
+// pathMethods возвращает методы, разрешенные для пути: зарегистрированные и не исключенные конфигурацией
+func (p *corsPolicy) pathMethods(requestPath string) []string {
+	var methods []string
+	for pattern, routeMethods := range p.routes {
+		if !matchRoute(pattern, requestPath) {
+			continue
+		}
+		for _, method := range routeMethods {
+			if len(p.methods) > 0 && !slices.Contains(p.methods, method) {
+				continue
+			}
+			if !slices.Contains(methods, method) {
+				methods = append(methods, method)
+			}
+		}
+	}
+	slices.Sort(methods)
+
+	return methods
+}
+
+// allowHeaders проверяет заголовки из Access-Control-Request-Headers
+func (p *corsPolicy) allowHeaders(requested string) bool {
+	if p.anyHeader {
+		return true
+	}
+
+	for _, header := range strings.Split(requested, ",") {
+		header = strings.TrimSpace(header)
+		if header != "" && !slices.Contains(p.headers, http.CanonicalHeaderKey(header)) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// handle отвечает на preflight-запросы сам, не пропуская их к маршрутам.
+// Если источник, метод или заголовки не разрешены, ответ не содержит заголовков CORS и браузер заблокирует запрос
+func (p *corsPolicy) handle(c *gin.Context) {
+	origin := c.GetHeader("Origin")
+	if origin == "" {
+		c.Next()
+		return
+	}
+
+	if !p.anyOrigin || p.credentials {
+		c.Writer.Header().Add("Vary", "Origin")
+	}
+
+	requestedMethod := c.GetHeader("Access-Control-Request-Method")
+	if c.Request.Method == http.MethodOptions && requestedMethod != "" {
+		p.preflight(c, origin, requestedMethod)
+		c.AbortWithStatus(http.StatusNoContent)
+		return
+	}
+
+	if p.allowOrigin(origin) {
+		p.setAllowOrigin(c, origin)
+	}
+
+	c.Next()
+}
+
+func (p *corsPolicy) preflight(c *gin.Context, origin, requestedMethod string) {
+	c.Writer.Header().Add("Vary", "Access-Control-Request-Method")
+	c.Writer.Header().Add("Vary", "Access-Control-Request-Headers")
+
+	if !p.allowOrigin(origin) {
+		return
+	}
+
+	methods := p.pathMethods(c.Request.URL.Path)
+	if !slices.Contains(methods, requestedMethod) {
+		return
+	}
+
+	requestedHeaders := c.GetHeader("Access-Control-Request-Headers")
+	if !p.allowHeaders(requestedHeaders) {
+		return
+	}
+
+	p.setAllowOrigin(c, origin)
+	c.Header("Access-Control-Allow-Methods", strings.Join(methods, ", "))
+	if requestedHeaders != "" {
+		c.Header("Access-Control-Allow-Headers", requestedHeaders)
+	}
+	c.Header("Access-Control-Max-Age", p.maxAge)
+}
+
+func (p *corsPolicy) setAllowOrigin(c *gin.Context, origin string) {
+	if p.anyOrigin && !p.credentials {
+		c.Header("Access-Control-Allow-Origin", "*")
+		return
+	}
+
+	c.Header("Access-Control-Allow-Origin", origin)
+	if p.credentials {
+		c.Header("Access-Control-Allow-Credentials", "true")
+	}
+}
+
+// matchRoute сравнивает путь запроса с шаблоном маршрута gin (/api/v2/reservations/:id)
+func matchRoute(pattern, requestPath string) bool {
+	patternParts := strings.Split(pattern, "/")
+	pathParts := strings.Split(requestPath, "/")
+
+	for i, part := range patternParts {
+		if strings.HasPrefix(part, "*") {
+			return true
+		}
+		if i >= len(pathParts) {
+			return false
+		}
+		if strings.HasPrefix(part, ":") {
+			if pathParts[i] == "" {
+				return false
+			}
+			continue
+		}
+		if part != pathParts[i] {
+			return false
+		}
+	}
+
+	return len(patternParts) == len(pathParts)
+}
@@ -0,0 +1,301 @@
+package handler
+
+import (
+	"github.com/shamank/warehouse-service/internal/config"
+	"github.com/shamank/warehouse-service/internal/domain/schemas"
+	"github.com/shamank/warehouse-service/internal/handler/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"log/slog"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCORSPolicy_AllowOrigin(t *testing.T) {
+	testCases := []struct {
+		name     string
+		origins  []string
+		origin   string
+		expected bool
+	}{
+		{name: "no origins configured", origin: "https://shop.example.com", expected: false},
+		{name: "any origin", origins: []string{"*"}, origin: "http://localhost:3000", expected: true},
+		{name: "exact origin", origins: []string{"https://shop.example.com"}, origin: "https://shop.example.com", expected: true},
+		{name: "exact origin is case insensitive", origins: []string{"https://Shop.Example.com"}, origin: "https://shop.example.COM", expected: true},
+		{name: "trailing slash in config", origins: []string{"https://shop.example.com/"}, origin: "https://shop.example.com", expected: true},
+		{name: "other scheme", origins: []string{"https://shop.example.com"}, origin: "http://shop.example.com", expected: false},
+		{name: "other port", origins: []string{"https://shop.example.com"}, origin: "https://shop.example.com:8443", expected: false},
+		{name: "second origin in list", origins: []string{"https://a.example.com", "https://b.example.com"}, origin: "https://b.example.com", expected: true},
+		{name: "subdomain pattern", origins: []string{"https://*.example.com"}, origin: "https://admin.example.com", expected: true},
+		{name: "subdomain pattern without subdomain", origins: []string{"https://*.example.com"}, origin: "https://example.com", expected: false},
+		{name: "subdomain pattern with other domain", origins: []string{"https://*.example.com"}, origin: "https://example.com.evil.org", expected: false},
+		{name: "subdomain pattern with path trick", origins: []string{"https://*.example.com"}, origin: "https://evil.org/.example.com", expected: false},
+		{name: "port pattern", origins: []string{"http://localhost:*"}, origin: "http://localhost:5173", expected: true},
+		{name: "null origin", origins: []string{"https://shop.example.com"}, origin: "null", expected: false},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			policy, err := newCORSPolicy(config.CORSConfig{AllowedOrigins: testCase.origins})
+			require.NoError(t, err)
+
+			assert.Equal(t, testCase.expected, policy.allowOrigin(testCase.origin))
+		})
+	}
+}
+
+func TestNewCORSPolicy_Invalid(t *testing.T) {
+	_, err := newCORSPolicy(config.CORSConfig{AllowedOrigins: []string{"*"}, AllowCredentials: true})
+	assert.ErrorIs(t, err, errCORSCredentialsWithAnyOrigin)
+
+	_, err = newCORSPolicy(config.CORSConfig{AllowedOrigins: []string{"https://[example.com"}})
+	assert.Error(t, err)
+}
+
+func TestCORS(t *testing.T) {
+	const origin = "https://shop.example.com"
+
+	cfg := config.CORSConfig{
+		AllowedOrigins: []string{origin},
+		AllowedHeaders: []string{"Content-Type", "X-API-Key"},
+		MaxAge:         10 * time.Minute,
+	}
+
+	testCases := []struct {
+		name    string
+		cfg     config.CORSConfig
+		method  string
+		url     string
+		headers map[string]string
+		// setup задает ожидания сервиса, nil - запрос не должен дойти до сервиса
+		setup func(service *mocks.Service)
+
+		expectedStatusCode int
+		expectedHeaders    map[string]string
+	}{
+		{
+			name:   "preflight",
+			cfg:    cfg,
+			method: "OPTIONS",
+			url:    "/api/v2/reservations",
+			headers: map[string]string{
+				"Origin":                         origin,
+				"Access-Control-Request-Method":  "POST",
+				"Access-Control-Request-Headers": "content-type, x-api-key",
+			},
+			expectedStatusCode: 204,
+			expectedHeaders: map[string]string{
+				"Access-Control-Allow-Origin":      origin,
+				"Access-Control-Allow-Methods":     "POST",
+				"Access-Control-Allow-Headers":     "content-type, x-api-key",
+				"Access-Control-Max-Age":           "600",
+				"Access-Control-Allow-Credentials": "",
+				"Content-Type":                     "",
+			},
+		},
+		{
+			name:   "preflight lists only methods of the path",
+			cfg:    cfg,
+			method: "OPTIONS",
+			url:    "/api/v2/reservations/" + reservationID,
+			headers: map[string]string{
+				"Origin":                        origin,
+				"Access-Control-Request-Method": "DELETE",
+			},
+			expectedStatusCode: 204,
+			expectedHeaders: map[string]string{
+				"Access-Control-Allow-Origin":  origin,
+				"Access-Control-Allow-Methods": "DELETE, GET",
+			},
+		},
+		{
+			name: "preflight with method excluded by config",
+			cfg: config.CORSConfig{
+				AllowedOrigins: []string{origin},
+				AllowedMethods: []string{"get", "post"},
+			},
+			method: "OPTIONS",
+			url:    "/api/v2/reservations/" + reservationID,
+			headers: map[string]string{
+				"Origin":                        origin,
+				"Access-Control-Request-Method": "DELETE",
+			},
+			expectedStatusCode: 204,
+			expectedHeaders: map[string]string{
+				"Access-Control-Allow-Origin":  "",
+				"Access-Control-Allow-Methods": "",
+			},
+		},
+		{
+			name:   "preflight for method without route",
+			cfg:    cfg,
+			method: "OPTIONS",
+			url:    "/api/getRemainingProductsBatch",
+			headers: map[string]string{
+				"Origin":                        origin,
+				"Access-Control-Request-Method": "PUT",
+			},
+			expectedStatusCode: 204,
+			expectedHeaders: map[string]string{
+				"Access-Control-Allow-Origin":  "",
+				"Access-Control-Allow-Methods": "",
+			},
+		},
+		{
+			name:   "preflight for unknown path",
+			cfg:    cfg,
+			method: "OPTIONS",
+			url:    "/api/unknown",
+			headers: map[string]string{
+				"Origin":                        origin,
+				"Access-Control-Request-Method": "GET",
+			},
+			expectedStatusCode: 204,
+			expectedHeaders: map[string]string{
+				"Access-Control-Allow-Origin": "",
+			},
+		},
+		{
+			name:   "preflight with header not allowed",
+			cfg:    cfg,
+			method: "OPTIONS",
+			url:    "/api/v2/reservations",
+			headers: map[string]string{
+				"Origin":                         origin,
+				"Access-Control-Request-Method":  "POST",
+				"Access-Control-Request-Headers": "X-Debug",
+			},
+			expectedStatusCode: 204,
+			expectedHeaders: map[string]string{
+				"Access-Control-Allow-Origin":  "",
+				"Access-Control-Allow-Headers": "",
+			},
+		},
+		{
+			name:   "preflight from other origin",
+			cfg:    cfg,
+			method: "OPTIONS",
+			url:    "/api/v2/reservations",
+			headers: map[string]string{
+				"Origin":                        "https://evil.org",
+				"Access-Control-Request-Method": "POST",
+			},
+			expectedStatusCode: 204,
+			expectedHeaders: map[string]string{
+				"Access-Control-Allow-Origin":  "",
+				"Access-Control-Allow-Methods": "",
+				"Vary":                         "Origin",
+			},
+		},
+		{
+			name:   "actual request",
+			cfg:    cfg,
+			method: "GET",
+			url:    "/api/v2/reservations/" + reservationID,
+			headers: map[string]string{
+				"Origin": origin,
+			},
+			setup: func(service *mocks.Service) {
+				service.On("GetReservation", mock.Anything, reservationID).Return(schemas.Reservation{}, nil)
+			},
+			expectedStatusCode: 200,
+			expectedHeaders: map[string]string{
+				"Access-Control-Allow-Origin": origin,
+				"Vary":                        "Origin",
+				"Content-Type":                "application/json; charset=utf-8",
+			},
+		},
+		{
+			name: "actual request with credentials",
+			cfg: config.CORSConfig{
+				AllowedOrigins:   []string{"https://*.example.com"},
+				AllowCredentials: true,
+			},
+			method: "GET",
+			url:    "/api/ping",
+			headers: map[string]string{
+				"Origin": origin,
+			},
+			expectedStatusCode: 200,
+			expectedHeaders: map[string]string{
+				"Access-Control-Allow-Origin":      origin,
+				"Access-Control-Allow-Credentials": "true",
+			},
+		},
+		{
+			name:   "actual request with any origin",
+			cfg:    config.CORSConfig{AllowedOrigins: []string{"*"}},
+			method: "GET",
+			url:    "/api/ping",
+			headers: map[string]string{
+				"Origin": origin,
+			},
+			expectedStatusCode: 200,
+			expectedHeaders: map[string]string{
+				"Access-Control-Allow-Origin": "*",
+				"Vary":                        "",
+			},
+		},
+		{
+			name:               "request without origin",
+			cfg:                cfg,
+			method:             "GET",
+			url:                "/api/ping",
+			expectedStatusCode: 200,
+			expectedHeaders: map[string]string{
+				"Access-Control-Allow-Origin": "",
+				"Vary":                        "",
+			},
+		},
+		{
+			name:               "options without preflight",
+			cfg:                cfg,
+			method:             "OPTIONS",
+			url:                "/api/ping",
+			expectedStatusCode: 404,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			service := mocks.NewService(t)
+			if testCase.setup != nil {
+				testCase.setup(service)
+			}
+
+			handler := NewHandler(service, slog.Default())
+			require.NoError(t, handler.SetCORS(testCase.cfg))
+
+			r := handler.InitAPIRoutes()
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(testCase.method, testCase.url, nil)
+			for name, value := range testCase.headers {
+				req.Header.Set(name, value)
+			}
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			for name, value := range testCase.expectedHeaders {
+				assert.Equal(t, value, w.Header().Get(name), name)
+			}
+		})
+	}
+}
+
+func TestCORS_Disabled(t *testing.T) {
+	r := NewHandler(mocks.NewService(t), slog.Default()).InitAPIRoutes()
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("OPTIONS", "/api/v2/reservations", nil)
+	req.Header.Set("Origin", "https://shop.example.com")
+	req.Header.Set("Access-Control-Request-Method", "POST")
+
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, 204, w.Code)
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+}
@@ -0,0 +1,118 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/shamank/warehouse-service/api"
+	"github.com/shamank/warehouse-service/internal/handler/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"log/slog"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+)
+
+// ginParam - параметр пути gin (:id), в спецификации он записывается как {id}
+var ginParam = regexp.MustCompile(`:(\w+)`)
+
+func TestRoutesInSpec(t *testing.T) {
+	doc, err := api.Spec()
+	require.NoError(t, err)
+
+	r := NewHandler(mocks.NewService(t), slog.Default()).InitAPIRoutes()
+
+	for _, route := range r.Routes() {
+		path := ginParam.ReplaceAllString(route.Path, "{$1}")
+
+		pathItem := doc.Paths.Value(path)
+		if !assert.NotNil(t, pathItem, "route %s is missing in api/openapi.yaml", path) {
+			continue
+		}
+		assert.NotNil(t, pathItem.GetOperation(route.Method), "route %s %s is missing in api/openapi.yaml", route.Method, path)
+	}
+}
+
+func TestOpenAPIRoutes(t *testing.T) {
+	r := NewHandler(mocks.NewService(t), slog.Default()).InitAPIRoutes()
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/openapi.json", nil))
+
+	assert.Equal(t, 200, w.Code)
+	var spec map[string]any
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &spec))
+	assert.Equal(t, "3.0.3", spec["openapi"])
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/docs", nil))
+
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Body.String(), `url: "/openapi.json"`)
+}
+
+func TestValidateRequests(t *testing.T) {
+	testCases := []struct {
+		name   string
+		method string
+		url    string
+		body   string
+
+		expectedResult string
+	}{
+		{
+			name:           "v1 stock without warehouse",
+			method:         "GET",
+			url:            "/api/getRemainingProducts",
+			expectedResult: `{"error":"parameter \"warehouse_uuid\" in query has an error: value is required but missing"}`,
+		},
+		{
+			name:           "limit is not a number",
+			method:         "GET",
+			url:            "/api/v2/warehouses/e4aa0556-aec5-41d4-8280-885865842719/stock?limit=ten",
+			expectedResult: `{"error":"parameter \"limit\" in query has an error: value ten: an invalid integer: invalid syntax"}`,
+		},
+		{
+			name:           "unknown sort",
+			method:         "GET",
+			url:            "/api/v2/warehouses/e4aa0556-aec5-41d4-8280-885865842719/stock?sort=size",
+			expectedResult: `{"error":"query parameter \"sort\": value is not one of the allowed values [\"article\",\"-article\",\"name\",\"-name\",\"quantity\",\"-quantity\"]"}`,
+		},
+		{
+			name:           "v1 reserve with unknown payload",
+			method:         "POST",
+			url:            "/api/reserveProducts",
+			body:           `{"article":"a1as1"}`,
+			expectedResult: `{"error":"request body: doesn't match any schema from \"anyOf\""}`,
+		},
+		{
+			name:           "batch with invalid warehouse",
+			method:         "POST",
+			url:            "/api/getRemainingProductsBatch",
+			body:           `{"warehouses":["123"]}`,
+			expectedResult: `{"error":"request body /warehouses/0: string doesn't match the format \"uuid\" (not a valid uuid)"}`,
+		},
+		{
+			name:           "reservation without quantity",
+			method:         "POST",
+			url:            "/api/v2/reservations",
+			body:           `[{"article":"a1as1"}]`,
+			expectedResult: `{"error":"request body /0/quantity: property \"quantity\" is missing"}`,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			r := NewHandler(mocks.NewService(t), slog.Default()).InitAPIRoutes()
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(testCase.method, testCase.url, bytes.NewBufferString(testCase.body))
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, 400, w.Code)
+			assert.Equal(t, testCase.expectedResult, w.Body.String())
+		})
+	}
+}
@@ -1,22 +1,78 @@
 package handler
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	apidoc "github.com/shamank/warehouse-service/api"
+	"github.com/shamank/warehouse-service/internal/auth"
+	"github.com/shamank/warehouse-service/internal/config"
+	"github.com/shamank/warehouse-service/internal/domain/models"
 	"github.com/shamank/warehouse-service/internal/domain/schemas"
 	"log/slog"
+	"net"
 	"net/http"
+	"strings"
 )
 
 //go:generate mockery --name=Service
 type Service interface {
-	GetRemainingProducts(warehouseUUID string) ([]schemas.Product, error)
-	ReserveProducts(productsToReserve []string) error
-	ReleaseProducts(productsToRelease []string) error
+	GetRemainingProducts(ctx context.Context, warehouseUUID string, filter schemas.RemainingProductsFilter) (schemas.RemainingProducts, error)
+	GetRemainingProductsBatch(ctx context.Context, query schemas.ProductsStockQuery) ([]schemas.ProductStock, error)
+	ReserveProducts(ctx context.Context, productsToReserve []string) error
+	ReserveItems(ctx context.Context, items []schemas.ReserveItem) error
+	ReleaseProducts(ctx context.Context, productsToRelease []string) error
+	CreateReservation(ctx context.Context, items []schemas.ReserveItem) (schemas.Reservation, error)
+	GetReservation(ctx context.Context, reservationUUID string) (schemas.Reservation, error)
+	ReleaseReservation(ctx context.Context, reservationUUID string, items []schemas.ReleaseItem) (schemas.Reservation, error)
+	CancelReservation(ctx context.Context, reservationUUID string) (schemas.Reservation, error)
+	ReceiveProducts(ctx context.Context, warehouseUUID string, items []schemas.ReceiptItem) error
+	GetSerial(ctx context.Context, article string, serialNumber string) (schemas.Serial, error)
+	GetSerialHistory(ctx context.Context, article string, serialNumber string) ([]schemas.SerialEvent, error)
+	CreateLocation(ctx context.Context, warehouseUUID string, request schemas.NewLocation) (schemas.Location, error)
+	GetLocations(ctx context.Context, warehouseUUID string) ([]schemas.Location, error)
+	MoveStock(ctx context.Context, warehouseUUID string, moves []schemas.StockMove) error
+	GetReservationPickList(ctx context.Context, reservationUUID string) (schemas.ReservationPickList, error)
+	CreatePickList(ctx context.Context, request schemas.NewPickList) (schemas.PickList, error)
+	GetPickList(ctx context.Context, pickListUUID string) (schemas.PickList, error)
+	ConfirmPickLine(ctx context.Context, pickListUUID string, lineNo int, picked int) (schemas.PickList, error)
+	CreateAdjustment(ctx context.Context, warehouseUUID string, request schemas.NewAdjustment) (schemas.Adjustment, error)
+	GetAdjustments(ctx context.Context, warehouseUUID string, status string) ([]schemas.Adjustment, error)
+	ApproveAdjustment(ctx context.Context, adjustmentUUID string) (schemas.Adjustment, error)
+	RejectAdjustment(ctx context.Context, adjustmentUUID string) (schemas.Adjustment, error)
+	CreateStocktake(ctx context.Context, warehouseUUID string, request schemas.NewStocktake) (schemas.Stocktake, error)
+	GetStocktake(ctx context.Context, stocktakeUUID string) (schemas.Stocktake, error)
+	CountStocktake(ctx context.Context, stocktakeUUID string, counts []schemas.StocktakeCount) (schemas.Stocktake, error)
+	CloseStocktake(ctx context.Context, stocktakeUUID string) (schemas.Stocktake, error)
+	CreateReturn(ctx context.Context, warehouseUUID string, request schemas.NewReturn) (schemas.Return, error)
+	GetReturn(ctx context.Context, returnUUID string) (schemas.Return, error)
+	ReceiveReturn(ctx context.Context, returnUUID string) (schemas.Return, error)
+	InspectReturn(ctx context.Context, returnUUID string, request schemas.ReturnInspection) (schemas.Return, error)
+	MoveBucketStock(ctx context.Context, warehouseUUID string, moves []schemas.BucketMove) error
+	SaveKit(ctx context.Context, article string, definition schemas.KitDefinition) (schemas.Kit, error)
+	GetKit(ctx context.Context, article string) (schemas.Kit, error)
+	DeleteKit(ctx context.Context, article string) error
+	SaveSubstitutes(ctx context.Context, article string, definition schemas.SubstitutesDefinition) (schemas.Substitutes, error)
+	GetSubstitutes(ctx context.Context, article string) (schemas.Substitutes, error)
+}
+
+// Authenticator проверяет учетные данные запроса
+//
+//go:generate mockery --name=Authenticator
+type Authenticator interface {
+	Authenticate(r *http.Request) (auth.Principal, error)
 }
 
 type Handler struct {
 	service Service
 	logger  *slog.Logger
+	auth    Authenticator
+	cors    *corsPolicy
+	limits  *rateLimits
+	proxies []string
 }
 
 func NewHandler(service Service, logger *slog.Logger) *Handler {
@@ -26,28 +82,222 @@ func NewHandler(service Service, logger *slog.Logger) *Handler {
 	}
 }
 
+// SetAuthenticator включает проверку учетных данных и областей доступа на маршрутах /api, кроме /api/ping.
+// Без него API открыт всем
+func (h *Handler) SetAuthenticator(authenticator Authenticator) {
+	h.auth = authenticator
+}
+
+// SetCORS задает политику CORS. Без нее браузерам с других доменов API недоступен
+func (h *Handler) SetCORS(cfg config.CORSConfig) error {
+	policy, err := newCORSPolicy(cfg)
+	if err != nil {
+		return err
+	}
+
+	h.cors = policy
+
+	return nil
+}
+
+// SetRateLimits включает ограничения частоты запросов по клиентам и маршрутам
+// и общий лимит одновременных операций с резервами
+func (h *Handler) SetRateLimits(cfg config.RateLimitConfig) error {
+	limits, err := newRateLimits(cfg)
+	if err != nil {
+		return err
+	}
+
+	h.limits = limits
+
+	return nil
+}
+
+// SetTrustedProxies задает прокси, которым доверяется X-Forwarded-For. Без них IP клиента, по которому
+// ограничивается частота запросов анонимов, - адрес соединения
+func (h *Handler) SetTrustedProxies(proxies []string) error {
+	for _, proxy := range proxies {
+		if net.ParseIP(proxy) != nil {
+			continue
+		}
+		if _, _, err := net.ParseCIDR(proxy); err != nil {
+			return fmt.Errorf("invalid trusted proxy %q: %w", proxy, err)
+		}
+	}
+
+	h.proxies = proxies
+
+	return nil
+}
+
 func (h *Handler) InitAPIRoutes() *gin.Engine {
+	// спецификация встроена в бинарник, ошибка в ней - ошибка сборки, а не окружения
+	doc, err := apidoc.Spec()
+	if err != nil {
+		panic(err)
+	}
+	validateRequests, err := ValidateRequests(doc)
+	if err != nil {
+		panic(err)
+	}
+
+	cors := h.cors
+	if cors == nil {
+		cors = &corsPolicy{}
+	}
+
 	r := gin.Default()
+	// адреса проверены в SetTrustedProxies
+	if err := r.SetTrustedProxies(h.proxies); err != nil {
+		panic(err)
+	}
+
+	r.Use(cors.handle)
 
-	r.Use(CORS)
+	r.GET("/openapi.json", openAPI(doc))
+	r.GET("/docs", docs)
 
 	api := r.Group("/api")
 
-	{
-		api.GET("/ping", func(c *gin.Context) {
-			c.JSON(http.StatusOK, gin.H{
-				"message": "pong",
-			})
+	api.GET("/ping", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{
+			"message": "pong",
 		})
-		api.GET("/getRemainingProducts", h.getRemainingProducts)
-		api.POST("/reserveProducts", h.reserveProducts)
-		api.POST("/releaseProducts", h.releaseProducts)
+	})
+
+	// v1 - RPC-маршруты, у которых есть замена в v2
+	deprecated := Deprecated(V1DeprecatedAt, V1Sunset, "/api/v2")
+
+	// запросы проверяются по спецификации после проверки доступа, чтобы не отвечать анонимам подробностями,
+	// а частота - до проверки, чтобы клиент не мог обойти ограничение некорректными запросами.
+	// Место в очереди операций с резервами занимают только корректные запросы
+	read := api.Group("", h.authorize(auth.ScopeStockRead), h.limitRate, validateRequests)
+	{
+		read.POST("/getRemainingProductsBatch", h.getRemainingProductsBatch)
+		read.GET("/getRemainingProducts", deprecated, h.getRemainingProducts)
+		read.GET("/v2/warehouses/:id/stock", h.getWarehouseStock)
+		read.GET("/v2/reservations/:id", h.getReservation)
+		read.GET("/v2/products/:article/serials/:serial", h.getSerial)
+		read.GET("/v2/products/:article/serials/:serial/history", h.getSerialHistory)
+		read.GET("/v2/warehouses/:id/locations", h.getLocations)
+		read.GET("/v2/reservations/:id/pick-list", h.getReservationPickList)
+		read.GET("/v2/pick-lists/:id", h.getPickList)
+		read.GET("/v2/warehouses/:id/adjustments", h.getAdjustments)
+		read.GET("/v2/stocktakes/:id", h.getStocktake)
+		read.GET("/v2/returns/:id", h.getReturn)
+		read.GET("/v2/kits/:article", h.getKit)
+		read.GET("/v2/products/:article/substitutes", h.getSubstitutes)
+	}
+
+	reserve := api.Group("", h.authorize(auth.ScopeStockReserve), h.limitRate, validateRequests, h.limitConcurrency)
+	{
+		reserve.POST("/reserveProducts", deprecated, h.reserveProducts)
+		reserve.POST("/v2/reservations", h.createReservation)
+		reserve.POST("/v2/pick-lists", h.createPickList)
+		reserve.POST("/v2/pick-lists/:id/lines/:line/confirm", h.confirmPickLine)
+	}
+
+	release := api.Group("", h.authorize(auth.ScopeStockRelease), h.limitRate, validateRequests, h.limitConcurrency)
+	{
+		release.POST("/releaseProducts", deprecated, h.releaseProducts)
+		release.POST("/v2/reservations/:id/release", h.releaseReservation)
+		release.DELETE("/v2/reservations/:id", h.cancelReservation)
+	}
+
+	// приемка и складские операции меняют остатки в обход резервов и доступны только администраторам
+	admin := api.Group("", h.authorize(auth.ScopeAdmin), h.limitRate, validateRequests)
+	{
+		admin.POST("/v2/warehouses/:id/receipts", h.receiveProducts)
+		admin.POST("/v2/warehouses/:id/locations", h.createLocation)
+		admin.POST("/v2/warehouses/:id/moves", h.moveStock)
+		admin.POST("/v2/warehouses/:id/adjustments", h.createAdjustment)
+		admin.POST("/v2/warehouses/:id/stocktakes", h.createStocktake)
+		admin.POST("/v2/stocktakes/:id/counts", h.countStocktake)
+		admin.POST("/v2/stocktakes/:id/close", h.closeStocktake)
+		admin.POST("/v2/warehouses/:id/returns", h.createReturn)
+		admin.POST("/v2/returns/:id/receive", h.receiveReturn)
+		admin.POST("/v2/returns/:id/inspect", h.inspectReturn)
+		admin.POST("/v2/warehouses/:id/bucket-moves", h.moveBucketStock)
+		admin.POST("/v2/adjustments/:id/approve", h.approveAdjustment)
+		admin.POST("/v2/adjustments/:id/reject", h.rejectAdjustment)
+		admin.PUT("/v2/kits/:article", h.saveKit)
+		admin.DELETE("/v2/kits/:article", h.deleteKit)
+		admin.PUT("/v2/products/:article/substitutes", h.saveSubstitutes)
+	}
+
+	cors.setRoutes(r.Routes())
+	if h.limits != nil {
+		for _, route := range h.limits.setRoutes(r.Routes()) {
+			h.logger.Warn("rate limit is configured for unknown route", "route", route)
+		}
 	}
 
 	return r
 
 }
 
+type remainingProductsRequest struct {
+	Limit         int    `form:"limit" binding:"omitempty,min=1,max=500"`
+	Cursor        string `form:"cursor"`
+	ArticlePrefix string `form:"article_prefix"`
+	Name          string `form:"name"`
+	Size          string `form:"size"`
+	MinQuantity   int    `form:"min_quantity" binding:"min=0"`
+	InStock       bool   `form:"in_stock"`
+	// вернуть и товары без остатка и без резерва
+	IncludeZeroStock bool `form:"include_zero_stock"`
+	// поле сортировки, минус в начале - по убыванию
+	Sort string `form:"sort" binding:"omitempty,oneof=article -article name -name quantity -quantity"`
+	// вернуть остатки по партиям
+	ByLot bool `form:"by_lot"`
+	// вернуть остатки по ячейкам
+	ByLocation bool `form:"by_location"`
+}
+
+// errCursorSortMismatch - курсор указывает позицию только в той сортировке, в которой он был получен
+var errCursorSortMismatch = errors.New("cursor does not match sort")
+
+// bindRemainingProductsFilter разбирает параметры страницы остатков из query
+func bindRemainingProductsFilter(c *gin.Context) (schemas.RemainingProductsFilter, error) {
+	var request remainingProductsRequest
+
+	if err := c.ShouldBindQuery(&request); err != nil {
+		return schemas.RemainingProductsFilter{}, err
+	}
+
+	filter := schemas.RemainingProductsFilter{
+		ArticlePrefix:    request.ArticlePrefix,
+		Name:             request.Name,
+		Size:             request.Size,
+		MinQuantity:      request.MinQuantity,
+		InStock:          request.InStock,
+		IncludeZeroStock: request.IncludeZeroStock,
+		Sort:             strings.TrimPrefix(request.Sort, "-"),
+		Desc:             strings.HasPrefix(request.Sort, "-"),
+		Limit:            request.Limit,
+		ByLot:            request.ByLot,
+		ByLocation:       request.ByLocation,
+	}
+	if filter.Sort == "" {
+		filter.Sort = schemas.SortByArticle
+	}
+
+	if request.Cursor != "" {
+		cursor, err := schemas.DecodeProductCursor(request.Cursor)
+		if err != nil {
+			return schemas.RemainingProductsFilter{}, err
+		}
+
+		if cursor.Sort != filter.Sort || cursor.Desc != filter.Desc {
+			return schemas.RemainingProductsFilter{}, errCursorSortMismatch
+		}
+
+		filter.After = cursor
+	}
+
+	return filter, nil
+}
+
 func (h *Handler) getRemainingProducts(c *gin.Context) {
 	params := c.Request.URL.Query()
 
@@ -61,7 +311,29 @@ func (h *Handler) getRemainingProducts(c *gin.Context) {
 		return
 	}
 
-	result, err := h.service.GetRemainingProducts(warehouseUUID)
+	filter, err := bindRemainingProductsFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+
+		return
+	}
+
+	result, err := h.service.GetRemainingProducts(c.Request.Context(), warehouseUUID, filter)
+	if errors.Is(err, models.ErrWarehouseAccessDenied) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if errors.Is(err, models.ErrWarehouseNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "unkown error",
@@ -74,12 +346,63 @@ func (h *Handler) getRemainingProducts(c *gin.Context) {
 
 }
 
-type reserveProductRequest []string
+func (h *Handler) getRemainingProductsBatch(c *gin.Context) {
+	var query schemas.ProductsStockQuery
+
+	err := c.BindJSON(&query)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	if len(query.Articles) == 0 && len(query.Warehouses) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "articles or warehouses is required",
+		})
+		return
+	}
+
+	result, err := h.service.GetRemainingProductsBatch(c.Request.Context(), query)
+	if errors.Is(err, models.ErrWarehouseAccessDenied) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "unkown error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+
+}
+
+// reserveProductRequest принимает тело резервирования в одном из двух форматов:
+// массив артикулов, где количество задается повтором артикула,
+// или массив позиций schemas.ReserveItem с количеством и ограничениями по складам
+type reserveProductRequest struct {
+	articles []string
+	items    []schemas.ReserveItem
+}
+
+func (r *reserveProductRequest) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &r.articles); err == nil {
+		return nil
+	}
+	r.articles = nil
+
+	return json.Unmarshal(data, &r.items)
+}
 
 func (h *Handler) reserveProducts(c *gin.Context) {
-	var productsToReserve reserveProductRequest
+	var request reserveProductRequest
 
-	err := c.BindJSON(&productsToReserve)
+	err := c.BindJSON(&request)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error": err.Error(),
@@ -88,7 +411,23 @@ func (h *Handler) reserveProducts(c *gin.Context) {
 
 	}
 
-	err = h.service.ReserveProducts(productsToReserve)
+	if request.items == nil {
+		err = h.service.ReserveProducts(c.Request.Context(), request.articles)
+	} else if err = binding.Validator.ValidateStruct(request.items); err == nil {
+		err = h.service.ReserveItems(c.Request.Context(), request.items)
+		if errors.Is(err, models.ErrProductNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+	}
+	if errors.Is(err, models.ErrWarehouseAccessDenied) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error": err.Error(),
@@ -115,7 +454,13 @@ func (h *Handler) releaseProducts(c *gin.Context) {
 		return
 	}
 
-	err = h.service.ReleaseProducts(productsToRelease)
+	err = h.service.ReleaseProducts(c.Request.Context(), productsToRelease)
+	if errors.Is(err, models.ErrWarehouseAccessDenied) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error": err.Error(),
@@ -2,11 +2,15 @@ package handler
 
 import (
 	"bytes"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"github.com/gin-gonic/gin"
+	"github.com/shamank/warehouse-service/internal/domain/models"
 	"github.com/shamank/warehouse-service/internal/domain/schemas"
 	"github.com/shamank/warehouse-service/internal/handler/mocks"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"log/slog"
 	"net/http/httptest"
 	"testing"
@@ -14,8 +18,8 @@ import (
 
 func TestGetRemainingProducts(t *testing.T) {
 	type Args struct {
-		input  string
-		output []schemas.Product
+		input  schemas.RemainingProductsFilter
+		output schemas.RemainingProducts
 		error  error
 	}
 
@@ -27,34 +31,100 @@ func TestGetRemainingProducts(t *testing.T) {
 		expectedResult     string
 	}
 
+	cursor := schemas.ProductCursor{Sort: schemas.SortByName, Desc: true, Article: "asd-xsdad", Name: "nike"}.Encode()
+
 	testCases := []TestCase{
 		{
 			url: "/getRemainingProducts?warehouse_uuid=e4aa0556-aec5-41d4-8280-885865842719",
 			args: Args{
-				input: "e4aa0556-aec5-41d4-8280-885865842719",
-				output: []schemas.Product{
-					{
-						Name:     "nike",
-						Size:     "XL",
-						Code:     "asd-xsdad",
-						Quantity: 1,
+				input: schemas.RemainingProductsFilter{Sort: schemas.SortByArticle},
+				output: schemas.RemainingProducts{
+					Warehouse: schemas.WarehouseInfo{
+						UUID:        "e4aa0556-aec5-41d4-8280-885865842719",
+						Name:        "warehouse",
+						IsAvailable: true,
 					},
+					Items: []schemas.Product{
+						{
+							Name:      "nike",
+							Size:      "XL",
+							Code:      "asd-xsdad",
+							Quantity:  1,
+							Available: 1,
+							Reserved:  2,
+							OnHand:    3,
+						},
+					},
+					Total: 1,
 				},
 				error: nil,
 			},
 			expectedStatusCode: 200,
-			expectedResult:     `[{"name":"nike","size":"XL","code":"asd-xsdad","quantity":1}]`,
+			expectedResult: `{"warehouse":{"uuid":"e4aa0556-aec5-41d4-8280-885865842719","name":"warehouse","is_available":true},` +
+				`"items":[{"name":"nike","size":"XL","code":"asd-xsdad","quantity":1,"available":1,"reserved":2,"on_hand":3,"expired":0}],"next_cursor":null,"total":1}`,
+		},
+		{
+			url: "/getRemainingProducts?warehouse_uuid=e4aa0556-aec5-41d4-8280-885865842719&include_zero_stock=true",
+			args: Args{
+				input: schemas.RemainingProductsFilter{Sort: schemas.SortByArticle, IncludeZeroStock: true},
+				error: models.ErrWarehouseNotFound,
+			},
+			expectedStatusCode: 404,
+			expectedResult:     `{"error":"warehouse not found"}`,
+		},
+		{
+			url: "/getRemainingProducts?warehouse_uuid=e4aa0556-aec5-41d4-8280-885865842719&limit=1&sort=-name&cursor=" + cursor +
+				"&article_prefix=asd&name=ni&size=XL&min_quantity=1&in_stock=true",
+			args: Args{
+				input: schemas.RemainingProductsFilter{
+					ArticlePrefix: "asd",
+					Name:          "ni",
+					Size:          "XL",
+					MinQuantity:   1,
+					InStock:       true,
+					Sort:          schemas.SortByName,
+					Desc:          true,
+					Limit:         1,
+					After:         &schemas.ProductCursor{Sort: schemas.SortByName, Desc: true, Article: "asd-xsdad", Name: "nike"},
+				},
+				output: schemas.RemainingProducts{
+					Items: []schemas.Product{},
+					Total: 1,
+				},
+			},
+			expectedStatusCode: 200,
+			expectedResult:     `{"warehouse":{"uuid":"","name":"","is_available":false},"items":[],"next_cursor":null,"total":1}`,
 		},
 		{
 			url:                "/getRemainingProducts",
 			expectedStatusCode: 400,
 			expectedResult:     `{"error":"warehouse_uuid is required"}`,
 		},
+		{
+			url:                "/getRemainingProducts?warehouse_uuid=e4aa0556-aec5-41d4-8280-885865842719&sort=size",
+			expectedStatusCode: 400,
+			expectedResult:     `{"error":"Key: 'remainingProductsRequest.Sort' Error:Field validation for 'Sort' failed on the 'oneof' tag"}`,
+		},
+		{
+			url:                "/getRemainingProducts?warehouse_uuid=e4aa0556-aec5-41d4-8280-885865842719&limit=1000",
+			expectedStatusCode: 400,
+			expectedResult:     `{"error":"Key: 'remainingProductsRequest.Limit' Error:Field validation for 'Limit' failed on the 'max' tag"}`,
+		},
+		{
+			url:                "/getRemainingProducts?warehouse_uuid=e4aa0556-aec5-41d4-8280-885865842719&cursor=abc",
+			expectedStatusCode: 400,
+			expectedResult:     `{"error":"invalid cursor"}`,
+		},
+		{
+			url:                "/getRemainingProducts?warehouse_uuid=e4aa0556-aec5-41d4-8280-885865842719&sort=name&cursor=" + cursor,
+			expectedStatusCode: 400,
+			expectedResult:     `{"error":"cursor does not match sort"}`,
+		},
 	}
 
 	for _, testCase := range testCases {
 		service := mocks.NewService(t)
-		service.On("GetRemainingProducts", testCase.args.input).Return(testCase.args.output, nil).Maybe()
+		service.On("GetRemainingProducts", mock.Anything, "e4aa0556-aec5-41d4-8280-885865842719", testCase.args.input).Return(testCase.args.output, testCase.args.error).Maybe()
 
 		handler := NewHandler(service, slog.Default())
 
@@ -72,9 +142,87 @@ func TestGetRemainingProducts(t *testing.T) {
 	}
 }
 
+func TestGetRemainingProductsBatch(t *testing.T) {
+	type Args struct {
+		input  schemas.ProductsStockQuery
+		output []schemas.ProductStock
+		error  error
+	}
+
+	type TestCase struct {
+		body string
+		args Args
+
+		expectedStatusCode int
+		expectedResult     string
+	}
+
+	testCases := []TestCase{
+		{
+			body: `{"articles":["asd-xsdad"]}`,
+			args: Args{
+				input: schemas.ProductsStockQuery{Articles: []string{"asd-xsdad"}},
+				output: []schemas.ProductStock{
+					{
+						Name:      "nike",
+						Size:      "XL",
+						Code:      "asd-xsdad",
+						Available: 1,
+						Reserved:  2,
+						Warehouses: []schemas.WarehouseStock{
+							{WarehouseUUID: "e4aa0556-aec5-41d4-8280-885865842719", IsAvailable: true, Available: 1, Reserved: 2},
+						},
+					},
+				},
+			},
+			expectedStatusCode: 200,
+			expectedResult:     `[{"name":"nike","size":"XL","code":"asd-xsdad","available":1,"reserved":2,"warehouses":[{"warehouse_uuid":"e4aa0556-aec5-41d4-8280-885865842719","is_available":true,"available":1,"reserved":2,"expired":0}]}]`,
+		},
+		{
+			body:               `{}`,
+			expectedStatusCode: 400,
+			expectedResult:     `{"error":"articles or warehouses is required"}`,
+		},
+		{
+			body:               `{"warehouses":["warehouse1"]}`,
+			expectedStatusCode: 400,
+			expectedResult:     `{"error":"Key: 'ProductsStockQuery.Warehouses[0]' Error:Field validation for 'Warehouses[0]' failed on the 'uuid' tag"}`,
+		},
+		{
+			body: `{"warehouses":["e4aa0556-aec5-41d4-8280-885865842719"]}`,
+			args: Args{
+				input: schemas.ProductsStockQuery{Warehouses: []string{"e4aa0556-aec5-41d4-8280-885865842719"}},
+				error: errors.New("some error"),
+			},
+			expectedStatusCode: 500,
+			expectedResult:     `{"error":"unkown error"}`,
+		},
+	}
+
+	for _, testCase := range testCases {
+		service := mocks.NewService(t)
+		service.On("GetRemainingProductsBatch", mock.Anything, testCase.args.input).Return(testCase.args.output, testCase.args.error).Maybe()
+
+		handler := NewHandler(service, slog.Default())
+
+		r := gin.New()
+
+		r.POST("/getRemainingProductsBatch", handler.getRemainingProductsBatch)
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("POST", "/getRemainingProductsBatch", bytes.NewBufferString(testCase.body))
+
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, testCase.expectedStatusCode, w.Code)
+		assert.Equal(t, testCase.expectedResult, w.Body.String())
+	}
+}
+
 func TestReserveProducts(t *testing.T) {
 	type Args struct {
 		input []string
+		items []schemas.ReserveItem
 		error error
 	}
 
@@ -102,7 +250,7 @@ func TestReserveProducts(t *testing.T) {
 			url:                "/reserveProducts",
 			body:               "[12312, 3231, true]",
 			expectedStatusCode: 400,
-			expectedResult:     `{"error":"json: cannot unmarshal number into Go value of type string"}`,
+			expectedResult:     unmarshalErrorBody("[12312, 3231, true]", &reserveProductRequest{}),
 		},
 		{
 			url:  "/reserveProducts",
@@ -114,11 +262,53 @@ func TestReserveProducts(t *testing.T) {
 			expectedStatusCode: 400,
 			expectedResult:     `{"error":"not enough products in warehouses"}`,
 		},
+		{
+			url:  "/reserveProducts",
+			body: `[{"article":"a1as1","quantity":500},{"article":"xd123ed12fg","quantity":2,"allowed_warehouses":["e4aa0556-aec5-41d4-8280-885865842719"]}]`,
+			args: Args{
+				items: []schemas.ReserveItem{
+					{Article: "a1as1", Quantity: 500},
+					{Article: "xd123ed12fg", Quantity: 2, AllowedWarehouses: []string{"e4aa0556-aec5-41d4-8280-885865842719"}},
+				},
+				error: nil,
+			},
+			expectedStatusCode: 200,
+			expectedResult:     `{"message":"OK"}`,
+		},
+		{
+			url:  "/reserveProducts",
+			body: `[{"article":"unknown","quantity":1}]`,
+			args: Args{
+				items: []schemas.ReserveItem{{Article: "unknown", Quantity: 1}},
+				error: fmt.Errorf("%w: unknown", models.ErrProductNotFound),
+			},
+			expectedStatusCode: 404,
+			expectedResult:     `{"error":"product not found: unknown"}`,
+		},
+		{
+			url:                "/reserveProducts",
+			body:               `[{"article":"a1as1","quantity":-1}]`,
+			expectedStatusCode: 400,
+			expectedResult:     `{"error":"[0]: Key: 'ReserveItem.Quantity' Error:Field validation for 'Quantity' failed on the 'min' tag"}`,
+		},
+		{
+			url:                "/reserveProducts",
+			body:               `[{"article":"a1as1","quantity":1,"warehouse_uuid":"e4aa0556-aec5-41d4-8280-885865842719","allowed_warehouses":["e4aa0556-aec5-41d4-8280-885865842719"]}]`,
+			expectedStatusCode: 400,
+			expectedResult:     `{"error":"[0]: Key: 'ReserveItem.WarehouseUUID' Error:Field validation for 'WarehouseUUID' failed on the 'excluded_with' tag"}`,
+		},
+		{
+			url:                "/reserveProducts",
+			body:               `[{"article":"a1as1","quantity":1.5}]`,
+			expectedStatusCode: 400,
+			expectedResult:     unmarshalErrorBody(`[{"article":"a1as1","quantity":1.5}]`, &reserveProductRequest{}),
+		},
 	}
 
 	for _, testCase := range testCases {
 		service := mocks.NewService(t)
-		service.On("ReserveProducts", testCase.args.input).Return(testCase.args.error).Maybe()
+		service.On("ReserveProducts", mock.Anything, testCase.args.input).Return(testCase.args.error).Maybe()
+		service.On("ReserveItems", mock.Anything, testCase.args.items).Return(testCase.args.error).Maybe()
 
 		handler := NewHandler(service, slog.Default())
 
@@ -168,7 +358,7 @@ func TestReleaseProducts(t *testing.T) {
 			url:                "/releaseProducts",
 			body:               "[true, 3231, true]",
 			expectedStatusCode: 400,
-			expectedResult:     `{"error":"json: cannot unmarshal bool into Go value of type string"}`,
+			expectedResult:     unmarshalErrorBody("[true, 3231, true]", &releaseProductRequest{}),
 		},
 		{
 			url:  "/releaseProducts",
@@ -184,7 +374,7 @@ func TestReleaseProducts(t *testing.T) {
 
 	for _, testCase := range testCases {
 		service := mocks.NewService(t)
-		service.On("ReleaseProducts", testCase.args.input).Return(testCase.args.error).Maybe()
+		service.On("ReleaseProducts", mock.Anything, testCase.args.input).Return(testCase.args.error).Maybe()
 
 		handler := NewHandler(service, slog.Default())
 
@@ -203,3 +393,11 @@ func TestReleaseProducts(t *testing.T) {
 	}
 
 }
+
+// unmarshalErrorBody возвращает тело ответа с ошибкой декодирования body в v.
+// Текст ошибки encoding/json отличается между версиями Go, поэтому он не захардкожен
+func unmarshalErrorBody(body string, v any) string {
+	err := json.Unmarshal([]byte(body), v)
+	result, _ := json.Marshal(gin.H{"error": err.Error()})
+	return string(result)
+}
@@ -1,20 +1,143 @@
 package handler
 
 import (
+	"errors"
+	"fmt"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers/legacy"
 	"github.com/gin-gonic/gin"
+	"github.com/shamank/warehouse-service/internal/auth"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 )
 
-func CORS(c *gin.Context) {
-	c.Header("Access-Control-Allow-Origin", "*")
-	c.Header("Access-Control-Allow-Methods", "*")
-	c.Header("Access-Control-Allow-Headers", "*")
-	c.Header("Content-Type", "application/json")
+// principalKey - ключ gin.Context, под которым authorize сохраняет auth.Principal
+const principalKey = "principal"
 
-	if c.Request.Method != "OPTIONS" {
+// authorize пропускает запрос, если у клиента есть область доступа scope.
+// Без учетных данных или с неверными отвечает 401, без нужной области - 403
+func (h *Handler) authorize(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if h.auth == nil {
+			c.Next()
+			return
+		}
+
+		principal, err := h.auth.Authenticate(c.Request)
+		if errors.Is(err, auth.ErrNoCredentials) || errors.Is(err, auth.ErrInvalidCredentials) {
+			c.Header("WWW-Authenticate", `Bearer realm="warehouse"`)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		if err != nil {
+			h.logger.Error("authentication failed", "path", c.FullPath(), "error", err)
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+				"error": "internal server error",
+			})
+			return
+		}
+
+		if !principal.HasScope(scope) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error": "scope " + scope + " is required",
+			})
+			return
+		}
+
+		// сервис проверяет доступ к складам по клиенту из контекста запроса
+		c.Request = c.Request.WithContext(auth.WithPrincipal(c.Request.Context(), principal))
+		c.Set(principalKey, principal)
 		c.Next()
+	}
+}
+
+// сроки вывода из эксплуатации RPC-маршрутов v1
+var (
+	V1DeprecatedAt = time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC)
+	V1Sunset       = time.Date(2027, time.April, 30, 0, 0, 0, 0, time.UTC)
+)
+
+// Deprecated помечает ответы устаревших маршрутов заголовками Deprecation (RFC 9745) и Sunset (RFC 8594)
+// и ссылкой на замену successor
+func Deprecated(deprecatedAt time.Time, sunset time.Time, successor string) gin.HandlerFunc {
+	deprecation := "@" + strconv.FormatInt(deprecatedAt.Unix(), 10)
+	sunsetDate := sunset.UTC().Format(http.TimeFormat)
+	link := "<" + successor + `>; rel="successor-version"`
 
-	} else {
-		c.AbortWithStatus(http.StatusOK)
+	return func(c *gin.Context) {
+		c.Header("Deprecation", deprecation)
+		c.Header("Sunset", sunsetDate)
+		c.Header("Link", link)
+		c.Next()
+	}
+}
+
+// ValidateRequests проверяет запросы по спецификации doc и отвечает 400 на несоответствие.
+// Запросы к маршрутам, которых нет в спецификации, пропускаются дальше без проверки
+func ValidateRequests(doc *openapi3.T) (gin.HandlerFunc, error) {
+	router, err := legacy.NewRouter(doc)
+	if err != nil {
+		return nil, err
 	}
+
+	options := &openapi3filter.Options{
+		AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+	}
+
+	return func(c *gin.Context) {
+		route, pathParams, err := router.FindRoute(c.Request)
+		if err != nil {
+			c.Next()
+			return
+		}
+
+		// обработчики разбирают тело как JSON независимо от заголовка, клиенты v1 его могут не передавать
+		if c.Request.ContentLength != 0 && c.GetHeader("Content-Type") == "" {
+			c.Request.Header.Set("Content-Type", "application/json")
+		}
+
+		err = openapi3filter.ValidateRequest(c.Request.Context(), &openapi3filter.RequestValidationInput{
+			Request:    c.Request,
+			PathParams: pathParams,
+			Route:      route,
+			Options:    options,
+		})
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+				"error": validationMessage(err),
+			})
+			return
+		}
+
+		c.Next()
+	}, nil
+}
+
+// validationMessage сокращает ошибку проверки до места и причины, без дампа схемы и значения
+func validationMessage(err error) string {
+	var requestErr *openapi3filter.RequestError
+	if !errors.As(err, &requestErr) {
+		return err.Error()
+	}
+
+	var schemaErr *openapi3.SchemaError
+	if !errors.As(requestErr.Err, &schemaErr) {
+		return requestErr.Error()
+	}
+
+	location := "request body"
+	if requestErr.Parameter != nil {
+		location = fmt.Sprintf("%s parameter %q", requestErr.Parameter.In, requestErr.Parameter.Name)
+	}
+
+	if pointer := schemaErr.JSONPointer(); len(pointer) > 0 {
+		location += " /" + strings.Join(pointer, "/")
+	}
+
+	return location + ": " + schemaErr.Reason
 }
@@ -3,38 +3,737 @@
 package mocks
 
 import (
-	schemas "github.com/shamank/warehouse-service/internal/domain/schemas"
+	context "context"
+
 	mock "github.com/stretchr/testify/mock"
+
+	schemas "github.com/shamank/warehouse-service/internal/domain/schemas"
 )
 
-// Service is an autogenerated mock type for the Service type
-type Service struct {
-	mock.Mock
+// Service is an autogenerated mock type for the Service type
+type Service struct {
+	mock.Mock
+}
+
+// ApproveAdjustment provides a mock function with given fields: ctx, adjustmentUUID
+func (_m *Service) ApproveAdjustment(ctx context.Context, adjustmentUUID string) (schemas.Adjustment, error) {
+	ret := _m.Called(ctx, adjustmentUUID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ApproveAdjustment")
+	}
+
+	var r0 schemas.Adjustment
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (schemas.Adjustment, error)); ok {
+		return rf(ctx, adjustmentUUID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) schemas.Adjustment); ok {
+		r0 = rf(ctx, adjustmentUUID)
+	} else {
+		r0 = ret.Get(0).(schemas.Adjustment)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, adjustmentUUID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CancelReservation provides a mock function with given fields: ctx, reservationUUID
+func (_m *Service) CancelReservation(ctx context.Context, reservationUUID string) (schemas.Reservation, error) {
+	ret := _m.Called(ctx, reservationUUID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CancelReservation")
+	}
+
+	var r0 schemas.Reservation
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (schemas.Reservation, error)); ok {
+		return rf(ctx, reservationUUID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) schemas.Reservation); ok {
+		r0 = rf(ctx, reservationUUID)
+	} else {
+		r0 = ret.Get(0).(schemas.Reservation)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, reservationUUID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CloseStocktake provides a mock function with given fields: ctx, stocktakeUUID
+func (_m *Service) CloseStocktake(ctx context.Context, stocktakeUUID string) (schemas.Stocktake, error) {
+	ret := _m.Called(ctx, stocktakeUUID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CloseStocktake")
+	}
+
+	var r0 schemas.Stocktake
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (schemas.Stocktake, error)); ok {
+		return rf(ctx, stocktakeUUID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) schemas.Stocktake); ok {
+		r0 = rf(ctx, stocktakeUUID)
+	} else {
+		r0 = ret.Get(0).(schemas.Stocktake)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, stocktakeUUID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ConfirmPickLine provides a mock function with given fields: ctx, pickListUUID, lineNo, picked
+func (_m *Service) ConfirmPickLine(ctx context.Context, pickListUUID string, lineNo int, picked int) (schemas.PickList, error) {
+	ret := _m.Called(ctx, pickListUUID, lineNo, picked)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ConfirmPickLine")
+	}
+
+	var r0 schemas.PickList
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int, int) (schemas.PickList, error)); ok {
+		return rf(ctx, pickListUUID, lineNo, picked)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, int, int) schemas.PickList); ok {
+		r0 = rf(ctx, pickListUUID, lineNo, picked)
+	} else {
+		r0 = ret.Get(0).(schemas.PickList)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, int, int) error); ok {
+		r1 = rf(ctx, pickListUUID, lineNo, picked)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CountStocktake provides a mock function with given fields: ctx, stocktakeUUID, counts
+func (_m *Service) CountStocktake(ctx context.Context, stocktakeUUID string, counts []schemas.StocktakeCount) (schemas.Stocktake, error) {
+	ret := _m.Called(ctx, stocktakeUUID, counts)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CountStocktake")
+	}
+
+	var r0 schemas.Stocktake
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, []schemas.StocktakeCount) (schemas.Stocktake, error)); ok {
+		return rf(ctx, stocktakeUUID, counts)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, []schemas.StocktakeCount) schemas.Stocktake); ok {
+		r0 = rf(ctx, stocktakeUUID, counts)
+	} else {
+		r0 = ret.Get(0).(schemas.Stocktake)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, []schemas.StocktakeCount) error); ok {
+		r1 = rf(ctx, stocktakeUUID, counts)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateAdjustment provides a mock function with given fields: ctx, warehouseUUID, request
+func (_m *Service) CreateAdjustment(ctx context.Context, warehouseUUID string, request schemas.NewAdjustment) (schemas.Adjustment, error) {
+	ret := _m.Called(ctx, warehouseUUID, request)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateAdjustment")
+	}
+
+	var r0 schemas.Adjustment
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, schemas.NewAdjustment) (schemas.Adjustment, error)); ok {
+		return rf(ctx, warehouseUUID, request)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, schemas.NewAdjustment) schemas.Adjustment); ok {
+		r0 = rf(ctx, warehouseUUID, request)
+	} else {
+		r0 = ret.Get(0).(schemas.Adjustment)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, schemas.NewAdjustment) error); ok {
+		r1 = rf(ctx, warehouseUUID, request)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateLocation provides a mock function with given fields: ctx, warehouseUUID, request
+func (_m *Service) CreateLocation(ctx context.Context, warehouseUUID string, request schemas.NewLocation) (schemas.Location, error) {
+	ret := _m.Called(ctx, warehouseUUID, request)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateLocation")
+	}
+
+	var r0 schemas.Location
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, schemas.NewLocation) (schemas.Location, error)); ok {
+		return rf(ctx, warehouseUUID, request)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, schemas.NewLocation) schemas.Location); ok {
+		r0 = rf(ctx, warehouseUUID, request)
+	} else {
+		r0 = ret.Get(0).(schemas.Location)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, schemas.NewLocation) error); ok {
+		r1 = rf(ctx, warehouseUUID, request)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreatePickList provides a mock function with given fields: ctx, request
+func (_m *Service) CreatePickList(ctx context.Context, request schemas.NewPickList) (schemas.PickList, error) {
+	ret := _m.Called(ctx, request)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreatePickList")
+	}
+
+	var r0 schemas.PickList
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, schemas.NewPickList) (schemas.PickList, error)); ok {
+		return rf(ctx, request)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, schemas.NewPickList) schemas.PickList); ok {
+		r0 = rf(ctx, request)
+	} else {
+		r0 = ret.Get(0).(schemas.PickList)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, schemas.NewPickList) error); ok {
+		r1 = rf(ctx, request)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateReservation provides a mock function with given fields: ctx, items
+func (_m *Service) CreateReservation(ctx context.Context, items []schemas.ReserveItem) (schemas.Reservation, error) {
+	ret := _m.Called(ctx, items)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateReservation")
+	}
+
+	var r0 schemas.Reservation
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, []schemas.ReserveItem) (schemas.Reservation, error)); ok {
+		return rf(ctx, items)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []schemas.ReserveItem) schemas.Reservation); ok {
+		r0 = rf(ctx, items)
+	} else {
+		r0 = ret.Get(0).(schemas.Reservation)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []schemas.ReserveItem) error); ok {
+		r1 = rf(ctx, items)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateReturn provides a mock function with given fields: ctx, warehouseUUID, request
+func (_m *Service) CreateReturn(ctx context.Context, warehouseUUID string, request schemas.NewReturn) (schemas.Return, error) {
+	ret := _m.Called(ctx, warehouseUUID, request)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateReturn")
+	}
+
+	var r0 schemas.Return
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, schemas.NewReturn) (schemas.Return, error)); ok {
+		return rf(ctx, warehouseUUID, request)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, schemas.NewReturn) schemas.Return); ok {
+		r0 = rf(ctx, warehouseUUID, request)
+	} else {
+		r0 = ret.Get(0).(schemas.Return)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, schemas.NewReturn) error); ok {
+		r1 = rf(ctx, warehouseUUID, request)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateStocktake provides a mock function with given fields: ctx, warehouseUUID, request
+func (_m *Service) CreateStocktake(ctx context.Context, warehouseUUID string, request schemas.NewStocktake) (schemas.Stocktake, error) {
+	ret := _m.Called(ctx, warehouseUUID, request)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateStocktake")
+	}
+
+	var r0 schemas.Stocktake
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, schemas.NewStocktake) (schemas.Stocktake, error)); ok {
+		return rf(ctx, warehouseUUID, request)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, schemas.NewStocktake) schemas.Stocktake); ok {
+		r0 = rf(ctx, warehouseUUID, request)
+	} else {
+		r0 = ret.Get(0).(schemas.Stocktake)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, schemas.NewStocktake) error); ok {
+		r1 = rf(ctx, warehouseUUID, request)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DeleteKit provides a mock function with given fields: ctx, article
+func (_m *Service) DeleteKit(ctx context.Context, article string) error {
+	ret := _m.Called(ctx, article)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteKit")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, article)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetAdjustments provides a mock function with given fields: ctx, warehouseUUID, status
+func (_m *Service) GetAdjustments(ctx context.Context, warehouseUUID string, status string) ([]schemas.Adjustment, error) {
+	ret := _m.Called(ctx, warehouseUUID, status)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetAdjustments")
+	}
+
+	var r0 []schemas.Adjustment
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) ([]schemas.Adjustment, error)); ok {
+		return rf(ctx, warehouseUUID, status)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) []schemas.Adjustment); ok {
+		r0 = rf(ctx, warehouseUUID, status)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]schemas.Adjustment)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, warehouseUUID, status)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetKit provides a mock function with given fields: ctx, article
+func (_m *Service) GetKit(ctx context.Context, article string) (schemas.Kit, error) {
+	ret := _m.Called(ctx, article)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetKit")
+	}
+
+	var r0 schemas.Kit
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (schemas.Kit, error)); ok {
+		return rf(ctx, article)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) schemas.Kit); ok {
+		r0 = rf(ctx, article)
+	} else {
+		r0 = ret.Get(0).(schemas.Kit)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, article)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetLocations provides a mock function with given fields: ctx, warehouseUUID
+func (_m *Service) GetLocations(ctx context.Context, warehouseUUID string) ([]schemas.Location, error) {
+	ret := _m.Called(ctx, warehouseUUID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetLocations")
+	}
+
+	var r0 []schemas.Location
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) ([]schemas.Location, error)); ok {
+		return rf(ctx, warehouseUUID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) []schemas.Location); ok {
+		r0 = rf(ctx, warehouseUUID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]schemas.Location)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, warehouseUUID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetPickList provides a mock function with given fields: ctx, pickListUUID
+func (_m *Service) GetPickList(ctx context.Context, pickListUUID string) (schemas.PickList, error) {
+	ret := _m.Called(ctx, pickListUUID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetPickList")
+	}
+
+	var r0 schemas.PickList
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (schemas.PickList, error)); ok {
+		return rf(ctx, pickListUUID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) schemas.PickList); ok {
+		r0 = rf(ctx, pickListUUID)
+	} else {
+		r0 = ret.Get(0).(schemas.PickList)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, pickListUUID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetRemainingProducts provides a mock function with given fields: ctx, warehouseUUID, filter
+func (_m *Service) GetRemainingProducts(ctx context.Context, warehouseUUID string, filter schemas.RemainingProductsFilter) (schemas.RemainingProducts, error) {
+	ret := _m.Called(ctx, warehouseUUID, filter)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetRemainingProducts")
+	}
+
+	var r0 schemas.RemainingProducts
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, schemas.RemainingProductsFilter) (schemas.RemainingProducts, error)); ok {
+		return rf(ctx, warehouseUUID, filter)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, schemas.RemainingProductsFilter) schemas.RemainingProducts); ok {
+		r0 = rf(ctx, warehouseUUID, filter)
+	} else {
+		r0 = ret.Get(0).(schemas.RemainingProducts)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, schemas.RemainingProductsFilter) error); ok {
+		r1 = rf(ctx, warehouseUUID, filter)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetRemainingProductsBatch provides a mock function with given fields: ctx, query
+func (_m *Service) GetRemainingProductsBatch(ctx context.Context, query schemas.ProductsStockQuery) ([]schemas.ProductStock, error) {
+	ret := _m.Called(ctx, query)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetRemainingProductsBatch")
+	}
+
+	var r0 []schemas.ProductStock
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, schemas.ProductsStockQuery) ([]schemas.ProductStock, error)); ok {
+		return rf(ctx, query)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, schemas.ProductsStockQuery) []schemas.ProductStock); ok {
+		r0 = rf(ctx, query)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]schemas.ProductStock)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, schemas.ProductsStockQuery) error); ok {
+		r1 = rf(ctx, query)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
 }
 
-// GetRemainingProducts provides a mock function with given fields: warehouseUUID
-func (_m *Service) GetRemainingProducts(warehouseUUID string) ([]schemas.Product, error) {
-	ret := _m.Called(warehouseUUID)
+// GetReservation provides a mock function with given fields: ctx, reservationUUID
+func (_m *Service) GetReservation(ctx context.Context, reservationUUID string) (schemas.Reservation, error) {
+	ret := _m.Called(ctx, reservationUUID)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetRemainingProducts")
+		panic("no return value specified for GetReservation")
+	}
+
+	var r0 schemas.Reservation
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (schemas.Reservation, error)); ok {
+		return rf(ctx, reservationUUID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) schemas.Reservation); ok {
+		r0 = rf(ctx, reservationUUID)
+	} else {
+		r0 = ret.Get(0).(schemas.Reservation)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, reservationUUID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetReservationPickList provides a mock function with given fields: ctx, reservationUUID
+func (_m *Service) GetReservationPickList(ctx context.Context, reservationUUID string) (schemas.ReservationPickList, error) {
+	ret := _m.Called(ctx, reservationUUID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetReservationPickList")
+	}
+
+	var r0 schemas.ReservationPickList
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (schemas.ReservationPickList, error)); ok {
+		return rf(ctx, reservationUUID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) schemas.ReservationPickList); ok {
+		r0 = rf(ctx, reservationUUID)
+	} else {
+		r0 = ret.Get(0).(schemas.ReservationPickList)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, reservationUUID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetReturn provides a mock function with given fields: ctx, returnUUID
+func (_m *Service) GetReturn(ctx context.Context, returnUUID string) (schemas.Return, error) {
+	ret := _m.Called(ctx, returnUUID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetReturn")
+	}
+
+	var r0 schemas.Return
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (schemas.Return, error)); ok {
+		return rf(ctx, returnUUID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) schemas.Return); ok {
+		r0 = rf(ctx, returnUUID)
+	} else {
+		r0 = ret.Get(0).(schemas.Return)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, returnUUID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetSerial provides a mock function with given fields: ctx, article, serialNumber
+func (_m *Service) GetSerial(ctx context.Context, article string, serialNumber string) (schemas.Serial, error) {
+	ret := _m.Called(ctx, article, serialNumber)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetSerial")
 	}
 
-	var r0 []schemas.Product
+	var r0 schemas.Serial
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string) ([]schemas.Product, error)); ok {
-		return rf(warehouseUUID)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (schemas.Serial, error)); ok {
+		return rf(ctx, article, serialNumber)
 	}
-	if rf, ok := ret.Get(0).(func(string) []schemas.Product); ok {
-		r0 = rf(warehouseUUID)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) schemas.Serial); ok {
+		r0 = rf(ctx, article, serialNumber)
+	} else {
+		r0 = ret.Get(0).(schemas.Serial)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, article, serialNumber)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetSerialHistory provides a mock function with given fields: ctx, article, serialNumber
+func (_m *Service) GetSerialHistory(ctx context.Context, article string, serialNumber string) ([]schemas.SerialEvent, error) {
+	ret := _m.Called(ctx, article, serialNumber)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetSerialHistory")
+	}
+
+	var r0 []schemas.SerialEvent
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) ([]schemas.SerialEvent, error)); ok {
+		return rf(ctx, article, serialNumber)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) []schemas.SerialEvent); ok {
+		r0 = rf(ctx, article, serialNumber)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]schemas.Product)
+			r0 = ret.Get(0).([]schemas.SerialEvent)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(string) error); ok {
-		r1 = rf(warehouseUUID)
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, article, serialNumber)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetStocktake provides a mock function with given fields: ctx, stocktakeUUID
+func (_m *Service) GetStocktake(ctx context.Context, stocktakeUUID string) (schemas.Stocktake, error) {
+	ret := _m.Called(ctx, stocktakeUUID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetStocktake")
+	}
+
+	var r0 schemas.Stocktake
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (schemas.Stocktake, error)); ok {
+		return rf(ctx, stocktakeUUID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) schemas.Stocktake); ok {
+		r0 = rf(ctx, stocktakeUUID)
+	} else {
+		r0 = ret.Get(0).(schemas.Stocktake)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, stocktakeUUID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetSubstitutes provides a mock function with given fields: ctx, article
+func (_m *Service) GetSubstitutes(ctx context.Context, article string) (schemas.Substitutes, error) {
+	ret := _m.Called(ctx, article)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetSubstitutes")
+	}
+
+	var r0 schemas.Substitutes
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (schemas.Substitutes, error)); ok {
+		return rf(ctx, article)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) schemas.Substitutes); ok {
+		r0 = rf(ctx, article)
+	} else {
+		r0 = ret.Get(0).(schemas.Substitutes)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, article)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// InspectReturn provides a mock function with given fields: ctx, returnUUID, request
+func (_m *Service) InspectReturn(ctx context.Context, returnUUID string, request schemas.ReturnInspection) (schemas.Return, error) {
+	ret := _m.Called(ctx, returnUUID, request)
+
+	if len(ret) == 0 {
+		panic("no return value specified for InspectReturn")
+	}
+
+	var r0 schemas.Return
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, schemas.ReturnInspection) (schemas.Return, error)); ok {
+		return rf(ctx, returnUUID, request)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, schemas.ReturnInspection) schemas.Return); ok {
+		r0 = rf(ctx, returnUUID, request)
+	} else {
+		r0 = ret.Get(0).(schemas.Return)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, schemas.ReturnInspection) error); ok {
+		r1 = rf(ctx, returnUUID, request)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -42,17 +741,173 @@ func (_m *Service) GetRemainingProducts(warehouseUUID string) ([]schemas.Product
 	return r0, r1
 }
 
-// ReleaseProducts provides a mock function with given fields: productsToRelease
-func (_m *Service) ReleaseProducts(productsToRelease []string) error {
-	ret := _m.Called(productsToRelease)
+// MoveBucketStock provides a mock function with given fields: ctx, warehouseUUID, moves
+func (_m *Service) MoveBucketStock(ctx context.Context, warehouseUUID string, moves []schemas.BucketMove) error {
+	ret := _m.Called(ctx, warehouseUUID, moves)
+
+	if len(ret) == 0 {
+		panic("no return value specified for MoveBucketStock")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, []schemas.BucketMove) error); ok {
+		r0 = rf(ctx, warehouseUUID, moves)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MoveStock provides a mock function with given fields: ctx, warehouseUUID, moves
+func (_m *Service) MoveStock(ctx context.Context, warehouseUUID string, moves []schemas.StockMove) error {
+	ret := _m.Called(ctx, warehouseUUID, moves)
+
+	if len(ret) == 0 {
+		panic("no return value specified for MoveStock")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, []schemas.StockMove) error); ok {
+		r0 = rf(ctx, warehouseUUID, moves)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ReceiveProducts provides a mock function with given fields: ctx, warehouseUUID, items
+func (_m *Service) ReceiveProducts(ctx context.Context, warehouseUUID string, items []schemas.ReceiptItem) error {
+	ret := _m.Called(ctx, warehouseUUID, items)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ReceiveProducts")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, []schemas.ReceiptItem) error); ok {
+		r0 = rf(ctx, warehouseUUID, items)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ReceiveReturn provides a mock function with given fields: ctx, returnUUID
+func (_m *Service) ReceiveReturn(ctx context.Context, returnUUID string) (schemas.Return, error) {
+	ret := _m.Called(ctx, returnUUID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ReceiveReturn")
+	}
+
+	var r0 schemas.Return
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (schemas.Return, error)); ok {
+		return rf(ctx, returnUUID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) schemas.Return); ok {
+		r0 = rf(ctx, returnUUID)
+	} else {
+		r0 = ret.Get(0).(schemas.Return)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, returnUUID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RejectAdjustment provides a mock function with given fields: ctx, adjustmentUUID
+func (_m *Service) RejectAdjustment(ctx context.Context, adjustmentUUID string) (schemas.Adjustment, error) {
+	ret := _m.Called(ctx, adjustmentUUID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RejectAdjustment")
+	}
+
+	var r0 schemas.Adjustment
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (schemas.Adjustment, error)); ok {
+		return rf(ctx, adjustmentUUID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) schemas.Adjustment); ok {
+		r0 = rf(ctx, adjustmentUUID)
+	} else {
+		r0 = ret.Get(0).(schemas.Adjustment)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, adjustmentUUID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ReleaseProducts provides a mock function with given fields: ctx, productsToRelease
+func (_m *Service) ReleaseProducts(ctx context.Context, productsToRelease []string) error {
+	ret := _m.Called(ctx, productsToRelease)
 
 	if len(ret) == 0 {
 		panic("no return value specified for ReleaseProducts")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func([]string) error); ok {
-		r0 = rf(productsToRelease)
+	if rf, ok := ret.Get(0).(func(context.Context, []string) error); ok {
+		r0 = rf(ctx, productsToRelease)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ReleaseReservation provides a mock function with given fields: ctx, reservationUUID, items
+func (_m *Service) ReleaseReservation(ctx context.Context, reservationUUID string, items []schemas.ReleaseItem) (schemas.Reservation, error) {
+	ret := _m.Called(ctx, reservationUUID, items)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ReleaseReservation")
+	}
+
+	var r0 schemas.Reservation
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, []schemas.ReleaseItem) (schemas.Reservation, error)); ok {
+		return rf(ctx, reservationUUID, items)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, []schemas.ReleaseItem) schemas.Reservation); ok {
+		r0 = rf(ctx, reservationUUID, items)
+	} else {
+		r0 = ret.Get(0).(schemas.Reservation)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, []schemas.ReleaseItem) error); ok {
+		r1 = rf(ctx, reservationUUID, items)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ReserveItems provides a mock function with given fields: ctx, items
+func (_m *Service) ReserveItems(ctx context.Context, items []schemas.ReserveItem) error {
+	ret := _m.Called(ctx, items)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ReserveItems")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, []schemas.ReserveItem) error); ok {
+		r0 = rf(ctx, items)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -60,17 +915,17 @@ func (_m *Service) ReleaseProducts(productsToRelease []string) error {
 	return r0
 }
 
-// ReserveProducts provides a mock function with given fields: productsToReserve
-func (_m *Service) ReserveProducts(productsToReserve []string) error {
-	ret := _m.Called(productsToReserve)
+// ReserveProducts provides a mock function with given fields: ctx, productsToReserve
+func (_m *Service) ReserveProducts(ctx context.Context, productsToReserve []string) error {
+	ret := _m.Called(ctx, productsToReserve)
 
 	if len(ret) == 0 {
 		panic("no return value specified for ReserveProducts")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func([]string) error); ok {
-		r0 = rf(productsToReserve)
+	if rf, ok := ret.Get(0).(func(context.Context, []string) error); ok {
+		r0 = rf(ctx, productsToReserve)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -78,6 +933,62 @@ func (_m *Service) ReserveProducts(productsToReserve []string) error {
 	return r0
 }
 
+// SaveKit provides a mock function with given fields: ctx, article, definition
+func (_m *Service) SaveKit(ctx context.Context, article string, definition schemas.KitDefinition) (schemas.Kit, error) {
+	ret := _m.Called(ctx, article, definition)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SaveKit")
+	}
+
+	var r0 schemas.Kit
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, schemas.KitDefinition) (schemas.Kit, error)); ok {
+		return rf(ctx, article, definition)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, schemas.KitDefinition) schemas.Kit); ok {
+		r0 = rf(ctx, article, definition)
+	} else {
+		r0 = ret.Get(0).(schemas.Kit)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, schemas.KitDefinition) error); ok {
+		r1 = rf(ctx, article, definition)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SaveSubstitutes provides a mock function with given fields: ctx, article, definition
+func (_m *Service) SaveSubstitutes(ctx context.Context, article string, definition schemas.SubstitutesDefinition) (schemas.Substitutes, error) {
+	ret := _m.Called(ctx, article, definition)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SaveSubstitutes")
+	}
+
+	var r0 schemas.Substitutes
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, schemas.SubstitutesDefinition) (schemas.Substitutes, error)); ok {
+		return rf(ctx, article, definition)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, schemas.SubstitutesDefinition) schemas.Substitutes); ok {
+		r0 = rf(ctx, article, definition)
+	} else {
+		r0 = ret.Get(0).(schemas.Substitutes)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, schemas.SubstitutesDefinition) error); ok {
+		r1 = rf(ctx, article, definition)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // NewService creates a new instance of Service. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewService(t interface {
@@ -0,0 +1,154 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/shamank/warehouse-service/internal/auth"
+	"github.com/shamank/warehouse-service/internal/config"
+	"github.com/shamank/warehouse-service/internal/ratelimit"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// rateLimits - ограничители частоты по маршрутам и общая очередь резервирований и снятий резерва
+type rateLimits struct {
+	defaultLimit ratelimit.Limit
+	overrides    map[string]ratelimit.Limit
+	// limiters - ограничитель каждого маршрута "METHOD /path", заполняется после регистрации маршрутов
+	limiters map[string]*ratelimit.Limiter
+	queue    *ratelimit.Queue
+}
+
+// newRateLimits проверяет конфигурацию ограничений
+func newRateLimits(cfg config.RateLimitConfig) (*rateLimits, error) {
+	limits := &rateLimits{
+		defaultLimit: ratelimit.Limit(cfg.Default),
+		overrides:    make(map[string]ratelimit.Limit, len(cfg.Routes)),
+	}
+
+	if err := validateLimit("default", cfg.Default); err != nil {
+		return nil, err
+	}
+	for route, limit := range cfg.Routes {
+		method, path, ok := strings.Cut(route, " ")
+		if !ok || method != strings.ToUpper(method) || !strings.HasPrefix(path, "/") {
+			return nil, fmt.Errorf("rate-limit: route %q must look like \"POST /api/reserveProducts\"", route)
+		}
+		if err := validateLimit(route, limit); err != nil {
+			return nil, err
+		}
+		limits.overrides[route] = ratelimit.Limit(limit)
+	}
+
+	concurrency := cfg.Concurrency
+	if concurrency.MaxInFlight < 0 || concurrency.MaxQueue < 0 || concurrency.QueueTimeout < 0 {
+		return nil, errors.New("rate-limit: concurrency limits must not be negative")
+	}
+	if concurrency.MaxInFlight > 0 {
+		limits.queue = ratelimit.NewQueue(concurrency.MaxInFlight, concurrency.MaxQueue, concurrency.QueueTimeout)
+	}
+
+	return limits, nil
+}
+
+func validateLimit(name string, limit config.RateLimit) error {
+	if limit.Rate < 0 || limit.Burst < 0 {
+		return fmt.Errorf("rate-limit: %s: rate and burst must not be negative", name)
+	}
+	return nil
+}
+
+// setRoutes создает ограничитель на каждый маршрут и возвращает ограничения из конфигурации для неизвестных маршрутов
+func (l *rateLimits) setRoutes(routes gin.RoutesInfo) (unknown []string) {
+	l.limiters = make(map[string]*ratelimit.Limiter, len(routes))
+	for _, route := range routes {
+		key := route.Method + " " + route.Path
+		limit, ok := l.overrides[key]
+		if !ok {
+			limit = l.defaultLimit
+		}
+		l.limiters[key] = ratelimit.NewLimiter(limit)
+	}
+
+	for route := range l.overrides {
+		if _, ok := l.limiters[route]; !ok {
+			unknown = append(unknown, route)
+		}
+	}
+
+	return unknown
+}
+
+// limitRate отклоняет запросы клиента сверх ограничения маршрута с 429.
+// Клиент определяется по учетным данным, а без проверки доступа - по IP
+func (h *Handler) limitRate(c *gin.Context) {
+	if h.limits == nil {
+		c.Next()
+		return
+	}
+
+	limiter := h.limits.limiters[c.Request.Method+" "+c.FullPath()]
+	if limiter == nil {
+		c.Next()
+		return
+	}
+
+	result := limiter.Allow(clientKey(c))
+	if result.Limit == 0 {
+		// ограничение на маршруте снято
+		c.Next()
+		return
+	}
+
+	c.Header("RateLimit-Limit", strconv.Itoa(result.Limit))
+	c.Header("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	c.Header("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+
+	if !result.Allowed {
+		c.Header("Retry-After", strconv.Itoa(ceilSeconds(result.RetryAfter)))
+		c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+			"error": "rate limit exceeded",
+		})
+		return
+	}
+
+	c.Next()
+}
+
+// limitConcurrency ограничивает одновременные операции с резервами.
+// Если очередь заполнена или ожидание истекло, отвечает 503: перегружен сервис, а не клиент
+func (h *Handler) limitConcurrency(c *gin.Context) {
+	if h.limits == nil || h.limits.queue == nil {
+		c.Next()
+		return
+	}
+
+	release, err := h.limits.queue.Acquire(c.Request.Context())
+	if err != nil {
+		c.Header("Retry-After", "1")
+		c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	defer release()
+
+	c.Next()
+}
+
+// clientKey - ключ корзины клиента: имя из учетных данных или IP
+func clientKey(c *gin.Context) string {
+	if value, ok := c.Get(principalKey); ok {
+		return "client:" + value.(auth.Principal).Subject
+	}
+	return "ip:" + c.ClientIP()
+}
+
+// ceilSeconds округляет до целых секунд вверх, но не меньше одной
+func ceilSeconds(d time.Duration) int {
+	return max(1, int(math.Ceil(d.Seconds())))
+}
@@ -0,0 +1,207 @@
+package handler
+
+import (
+	"bytes"
+	"github.com/shamank/warehouse-service/internal/auth"
+	"github.com/shamank/warehouse-service/internal/config"
+	"github.com/shamank/warehouse-service/internal/domain/schemas"
+	"github.com/shamank/warehouse-service/internal/handler/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRateLimit(t *testing.T) {
+	service := mocks.NewService(t)
+	service.On("ReserveProducts", mock.Anything, mock.Anything).Return(nil)
+	service.On("GetReservation", mock.Anything, reservationID).Return(testReservation, nil)
+
+	handler := NewHandler(service, slog.Default())
+	require.NoError(t, handler.SetRateLimits(config.RateLimitConfig{
+		Default: config.RateLimit{Rate: 0.001, Burst: 2},
+		Routes: map[string]config.RateLimit{
+			"POST /api/reserveProducts": {Rate: 0.001, Burst: 1},
+			"GET /api/ping":             {Rate: 0.001, Burst: 1},
+		},
+	}))
+
+	r := handler.InitAPIRoutes()
+
+	request := func(method, url, body, remoteAddr string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, url, bytes.NewBufferString(body))
+		req.RemoteAddr = remoteAddr
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	const reserve, client1, client2 = "/api/reserveProducts", "192.0.2.1:1234", "192.0.2.2:1234"
+
+	w := request("POST", reserve, `["a1as1"]`, client1)
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, "1", w.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "0", w.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "1000", w.Header().Get("RateLimit-Reset"))
+
+	w = request("POST", reserve, `["a1as1"]`, client1)
+	assert.Equal(t, 429, w.Code)
+	assert.Equal(t, `{"error":"rate limit exceeded"}`, w.Body.String())
+	assert.Equal(t, "1000", w.Header().Get("Retry-After"))
+	assert.Equal(t, "0", w.Header().Get("RateLimit-Remaining"))
+
+	// ограничение считается по клиенту
+	w = request("POST", reserve, `["a1as1"]`, client2)
+	assert.Equal(t, 200, w.Code)
+
+	// и по маршруту: у чтения резерва своя корзина с ограничением по умолчанию
+	w = request("GET", "/api/v2/reservations/"+reservationID, "", client1)
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, "2", w.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "1", w.Header().Get("RateLimit-Remaining"))
+
+	// некорректные запросы тоже расходуют ограничение
+	w = request("GET", "/api/v2/reservations/123", "", client1)
+	assert.Equal(t, 400, w.Code)
+	w = request("GET", "/api/v2/reservations/"+reservationID, "", client1)
+	assert.Equal(t, 429, w.Code)
+
+	// публичные маршруты не ограничиваются
+	for i := 0; i < 3; i++ {
+		w = request("GET", "/api/ping", "", client1)
+		assert.Equal(t, 200, w.Code)
+		assert.Empty(t, w.Header().Get("RateLimit-Limit"))
+	}
+}
+
+func TestRateLimit_ByPrincipal(t *testing.T) {
+	service := mocks.NewService(t)
+	service.On("GetReservation", mock.Anything, reservationID).Return(testReservation, nil)
+
+	authenticator := mocks.NewAuthenticator(t)
+	authenticator.On("Authenticate", mock.MatchedBy(func(r *http.Request) bool {
+		return r.Header.Get(auth.APIKeyHeader) == "key1"
+	})).Return(auth.Principal{Subject: "billing", Scopes: []string{auth.ScopeAdmin}}, nil)
+	authenticator.On("Authenticate", mock.Anything).Return(auth.Principal{Subject: "shop", Scopes: []string{auth.ScopeAdmin}}, nil)
+
+	handler := NewHandler(service, slog.Default())
+	handler.SetAuthenticator(authenticator)
+	require.NoError(t, handler.SetRateLimits(config.RateLimitConfig{Default: config.RateLimit{Rate: 0.001, Burst: 1}}))
+
+	r := handler.InitAPIRoutes()
+
+	request := func(key string) int {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/api/v2/reservations/"+reservationID, nil)
+		req.Header.Set(auth.APIKeyHeader, key)
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// запросы с одного IP, но от разных клиентов
+	assert.Equal(t, 200, request("key1"))
+	assert.Equal(t, 429, request("key1"))
+	assert.Equal(t, 200, request("key2"))
+}
+
+func TestRateLimit_ForwardedFor(t *testing.T) {
+	service := mocks.NewService(t)
+	service.On("GetReservation", mock.Anything, reservationID).Return(testReservation, nil)
+
+	handler := NewHandler(service, slog.Default())
+	require.NoError(t, handler.SetRateLimits(config.RateLimitConfig{Default: config.RateLimit{Rate: 0.001, Burst: 1}}))
+	assert.Error(t, handler.SetTrustedProxies([]string{"proxy.local"}))
+	require.NoError(t, handler.SetTrustedProxies([]string{"10.0.0.0/8"}))
+
+	r := handler.InitAPIRoutes()
+
+	request := func(remoteAddr, forwardedFor string) int {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/api/v2/reservations/"+reservationID, nil)
+		req.RemoteAddr = remoteAddr
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// клиент не получает новую корзину, подставляя X-Forwarded-For
+	assert.Equal(t, 200, request("192.0.2.1:1234", "198.51.100.1"))
+	assert.Equal(t, 429, request("192.0.2.1:1234", "198.51.100.2"))
+
+	// а за доверенным прокси клиенты различаются по X-Forwarded-For
+	assert.Equal(t, 200, request("10.0.0.1:1234", "198.51.100.3"))
+	assert.Equal(t, 200, request("10.0.0.1:1234", "198.51.100.4"))
+	assert.Equal(t, 429, request("10.0.0.1:1234", "198.51.100.4"))
+}
+
+func TestConcurrencyLimit(t *testing.T) {
+	started := make(chan struct{})
+	finish := make(chan struct{})
+
+	service := mocks.NewService(t)
+	service.On("ReserveProducts", mock.Anything, mock.Anything).Run(func(mock.Arguments) {
+		close(started)
+		<-finish
+	}).Return(nil).Once()
+
+	handler := NewHandler(service, slog.Default())
+	require.NoError(t, handler.SetRateLimits(config.RateLimitConfig{
+		Concurrency: config.ConcurrencyConfig{MaxInFlight: 1, MaxQueue: 1, QueueTimeout: 10 * time.Millisecond},
+	}))
+
+	r := handler.InitAPIRoutes()
+
+	done := make(chan int)
+	go func() {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("POST", "/api/reserveProducts", bytes.NewBufferString(`["a1as1"]`)))
+		done <- w.Code
+	}()
+	<-started
+
+	// операция не дождалась места в очереди
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("POST", "/api/releaseProducts", bytes.NewBufferString(`["a1as1"]`)))
+	assert.Equal(t, 503, w.Code)
+	assert.Equal(t, "1", w.Header().Get("Retry-After"))
+	assert.Equal(t, `{"error":"timed out waiting for operation slot"}`, w.Body.String())
+
+	// чтение не ограничивается очередью
+	service.On("GetReservation", mock.Anything, reservationID).Return(schemas.Reservation{}, nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/api/v2/reservations/"+reservationID, nil))
+	assert.Equal(t, 200, w.Code)
+
+	// и приемка тоже
+	service.On("ReceiveProducts", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("POST", "/api/v2/warehouses/e4aa0556-aec5-41d4-8280-885865842719/receipts",
+		bytes.NewBufferString(`[{"article":"soap","quantity":1}]`)))
+	assert.Equal(t, 204, w.Code)
+
+	close(finish)
+	assert.Equal(t, 200, <-done)
+}
+
+func TestNewRateLimits_Invalid(t *testing.T) {
+	testCases := []struct {
+		name string
+		cfg  config.RateLimitConfig
+	}{
+		{name: "negative rate", cfg: config.RateLimitConfig{Default: config.RateLimit{Rate: -1}}},
+		{name: "route without method", cfg: config.RateLimitConfig{Routes: map[string]config.RateLimit{"/api/reserveProducts": {Rate: 1}}}},
+		{name: "lower case method", cfg: config.RateLimitConfig{Routes: map[string]config.RateLimit{"post /api/reserveProducts": {Rate: 1}}}},
+		{name: "negative queue", cfg: config.RateLimitConfig{Concurrency: config.ConcurrencyConfig{MaxInFlight: 1, MaxQueue: -1}}},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			_, err := newRateLimits(testCase.cfg)
+			assert.Error(t, err)
+		})
+	}
+}
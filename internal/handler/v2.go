@@ -0,0 +1,873 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/shamank/warehouse-service/internal/domain/models"
+	"github.com/shamank/warehouse-service/internal/domain/schemas"
+	"io"
+	"net/http"
+)
+
+// resourceURI - uuid ресурса из пути /api/v2
+type resourceURI struct {
+	ID string `uri:"id" binding:"required,uuid"`
+}
+
+// pickLineURI - строка листа отбора из пути /api/v2/pick-lists/{id}/lines/{line}
+type pickLineURI struct {
+	ID   string `uri:"id" binding:"required,uuid"`
+	Line int    `uri:"line" binding:"required,min=1"`
+}
+
+// adjustmentsQuery - фильтр корректировок склада по статусу
+type adjustmentsQuery struct {
+	Status string `form:"status" binding:"omitempty,oneof=pending applied rejected"`
+}
+
+// serialURI - серийный номер товара из пути /api/v2/products/{article}/serials/{serial}
+type serialURI struct {
+	Article string `uri:"article" binding:"required"`
+	Serial  string `uri:"serial" binding:"required"`
+}
+
+// articleURI - артикул из путей /api/v2/kits/{article} и /api/v2/products/{article}/substitutes
+type articleURI struct {
+	Article string `uri:"article" binding:"required"`
+}
+
+// errorStatus возвращает HTTP-статус для ошибки сервиса
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, models.ErrWarehouseNotFound),
+		errors.Is(err, models.ErrProductNotFound),
+		errors.Is(err, models.ErrReservationNotFound),
+		errors.Is(err, models.ErrSerialNotFound),
+		errors.Is(err, models.ErrLocationNotFound),
+		errors.Is(err, models.ErrPickListNotFound),
+		errors.Is(err, models.ErrAdjustmentNotFound),
+		errors.Is(err, models.ErrStocktakeNotFound),
+		errors.Is(err, models.ErrReturnNotFound),
+		errors.Is(err, models.ErrKitNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, models.ErrNotEnoughProducts),
+		errors.Is(err, models.ErrNotEnoughReserved),
+		errors.Is(err, models.ErrReservationClosed),
+		errors.Is(err, models.ErrLotMismatch),
+		errors.Is(err, models.ErrSerialExists),
+		errors.Is(err, models.ErrLocationExists),
+		errors.Is(err, models.ErrReservationPicking),
+		errors.Is(err, models.ErrPickLineConfirmed),
+		errors.Is(err, models.ErrAdjustmentReviewed),
+		errors.Is(err, models.ErrStocktakeOpen),
+		errors.Is(err, models.ErrStocktakeClosed),
+		errors.Is(err, models.ErrReturnStatus):
+		return http.StatusConflict
+	case errors.Is(err, models.ErrWarehouseAccessDenied),
+		errors.Is(err, models.ErrSelfApproval):
+		return http.StatusForbidden
+	case errors.Is(err, models.ErrInvalidQuantity),
+		errors.Is(err, models.ErrDuplicateArticle),
+		errors.Is(err, models.ErrInvalidLotDates),
+		errors.Is(err, models.ErrInvalidSerials),
+		errors.Is(err, models.ErrInvalidLocation),
+		errors.Is(err, models.ErrInvalidAdjustment),
+		errors.Is(err, models.ErrInvalidCount),
+		errors.Is(err, models.ErrInvalidReturn),
+		errors.Is(err, models.ErrInvalidBucketMove),
+		errors.Is(err, models.ErrInvalidKit),
+		errors.Is(err, models.ErrInvalidSubstitute):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// abortWithServiceError отвечает ошибкой сервиса. Текст внутренних ошибок клиенту не отдается
+func (h *Handler) abortWithServiceError(c *gin.Context, err error) {
+	status := errorStatus(err)
+	if status == http.StatusInternalServerError {
+		h.logger.Error("request failed", "path", c.FullPath(), "error", err)
+		c.JSON(status, gin.H{
+			"error": "internal server error",
+		})
+		return
+	}
+
+	c.JSON(status, gin.H{
+		"error": err.Error(),
+	})
+}
+
+// getWarehouseStock - GET /api/v2/warehouses/{id}/stock, замена /api/getRemainingProducts
+func (h *Handler) getWarehouseStock(c *gin.Context) {
+	var uri resourceURI
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	filter, err := bindRemainingProductsFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	result, err := h.service.GetRemainingProducts(c.Request.Context(), uri.ID, filter)
+	if err != nil {
+		h.abortWithServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// createReservation - POST /api/v2/reservations, замена /api/reserveProducts
+func (h *Handler) createReservation(c *gin.Context) {
+	var items []schemas.ReserveItem
+	if err := c.ShouldBindJSON(&items); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	if len(items) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "items are required",
+		})
+		return
+	}
+
+	reservation, err := h.service.CreateReservation(c.Request.Context(), items)
+	if err != nil {
+		h.abortWithServiceError(c, err)
+		return
+	}
+
+	c.Header("Location", "/api/v2/reservations/"+reservation.ID)
+	c.JSON(http.StatusCreated, reservation)
+}
+
+// getReservation - GET /api/v2/reservations/{id}
+func (h *Handler) getReservation(c *gin.Context) {
+	var uri resourceURI
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	reservation, err := h.service.GetReservation(c.Request.Context(), uri.ID)
+	if err != nil {
+		h.abortWithServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, reservation)
+}
+
+// cancelReservation - DELETE /api/v2/reservations/{id}, возвращает на склады все, что осталось в резерве
+func (h *Handler) cancelReservation(c *gin.Context) {
+	var uri resourceURI
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	reservation, err := h.service.CancelReservation(c.Request.Context(), uri.ID)
+	if err != nil {
+		h.abortWithServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, reservation)
+}
+
+// releaseReservation - POST /api/v2/reservations/{id}/release, замена /api/releaseProducts.
+// Тело - массив schemas.ReleaseItem. Без тела освобождается весь оставшийся резерв
+func (h *Handler) releaseReservation(c *gin.Context) {
+	var uri resourceURI
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	var items []schemas.ReleaseItem
+	if err := c.ShouldBindJSON(&items); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	reservation, err := h.service.ReleaseReservation(c.Request.Context(), uri.ID, items)
+	if err != nil {
+		h.abortWithServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, reservation)
+}
+
+// receiveProducts - POST /api/v2/warehouses/{id}/receipts, приемка товаров на склад, в том числе по партиям
+func (h *Handler) receiveProducts(c *gin.Context) {
+	var uri resourceURI
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	var items []schemas.ReceiptItem
+	if err := c.ShouldBindJSON(&items); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	if len(items) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "items are required",
+		})
+		return
+	}
+
+	if err := h.service.ReceiveProducts(c.Request.Context(), uri.ID, items); err != nil {
+		h.abortWithServiceError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// getSerial - GET /api/v2/products/{article}/serials/{serial}, текущее состояние серийного номера
+func (h *Handler) getSerial(c *gin.Context) {
+	var uri serialURI
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	serial, err := h.service.GetSerial(c.Request.Context(), uri.Article, uri.Serial)
+	if err != nil {
+		h.abortWithServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, serial)
+}
+
+// getSerialHistory - GET /api/v2/products/{article}/serials/{serial}/history, смены состояния серийного номера
+func (h *Handler) getSerialHistory(c *gin.Context) {
+	var uri serialURI
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	history, err := h.service.GetSerialHistory(c.Request.Context(), uri.Article, uri.Serial)
+	if err != nil {
+		h.abortWithServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, history)
+}
+
+// createLocation - POST /api/v2/warehouses/{id}/locations, новое место хранения на складе
+func (h *Handler) createLocation(c *gin.Context) {
+	var uri resourceURI
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	var request schemas.NewLocation
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	location, err := h.service.CreateLocation(c.Request.Context(), uri.ID, request)
+	if err != nil {
+		h.abortWithServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, location)
+}
+
+// getLocations - GET /api/v2/warehouses/{id}/locations, места хранения склада в порядке обхода
+func (h *Handler) getLocations(c *gin.Context) {
+	var uri resourceURI
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	locations, err := h.service.GetLocations(c.Request.Context(), uri.ID)
+	if err != nil {
+		h.abortWithServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, locations)
+}
+
+// moveStock - POST /api/v2/warehouses/{id}/moves, перемещение свободного товара между ячейками
+func (h *Handler) moveStock(c *gin.Context) {
+	var uri resourceURI
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	var moves []schemas.StockMove
+	if err := c.ShouldBindJSON(&moves); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	if len(moves) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "moves are required",
+		})
+		return
+	}
+
+	if err := h.service.MoveStock(c.Request.Context(), uri.ID, moves); err != nil {
+		h.abortWithServiceError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// getReservationPickList - GET /api/v2/reservations/{id}/pick-list, что и из каких ячеек собрать по резерву
+func (h *Handler) getReservationPickList(c *gin.Context) {
+	var uri resourceURI
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	pickList, err := h.service.GetReservationPickList(c.Request.Context(), uri.ID)
+	if err != nil {
+		h.abortWithServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, pickList)
+}
+
+// createPickList - POST /api/v2/pick-lists, лист отбора по одному или нескольким резервам
+func (h *Handler) createPickList(c *gin.Context) {
+	var request schemas.NewPickList
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	pickList, err := h.service.CreatePickList(c.Request.Context(), request)
+	if err != nil {
+		h.abortWithServiceError(c, err)
+		return
+	}
+
+	c.Header("Location", "/api/v2/pick-lists/"+pickList.ID)
+	c.JSON(http.StatusCreated, pickList)
+}
+
+// getPickList - GET /api/v2/pick-lists/{id}
+func (h *Handler) getPickList(c *gin.Context) {
+	var uri resourceURI
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	pickList, err := h.service.GetPickList(c.Request.Context(), uri.ID)
+	if err != nil {
+		h.abortWithServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, pickList)
+}
+
+// confirmPickLine - POST /api/v2/pick-lists/{id}/lines/{line}/confirm, сколько собрано по строке листа отбора
+func (h *Handler) confirmPickLine(c *gin.Context) {
+	var uri pickLineURI
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	var request schemas.PickConfirm
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	pickList, err := h.service.ConfirmPickLine(c.Request.Context(), uri.ID, uri.Line, *request.Picked)
+	if err != nil {
+		h.abortWithServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, pickList)
+}
+
+// createAdjustment - POST /api/v2/warehouses/{id}/adjustments, списание или оприходование товара с причиной
+func (h *Handler) createAdjustment(c *gin.Context) {
+	var uri resourceURI
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	var request schemas.NewAdjustment
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	adjustment, err := h.service.CreateAdjustment(c.Request.Context(), uri.ID, request)
+	if err != nil {
+		h.abortWithServiceError(c, err)
+		return
+	}
+
+	// корректировка выше порога принята, но остаток не изменен до подтверждения
+	status := http.StatusCreated
+	if adjustment.Status == models.AdjustmentPending {
+		status = http.StatusAccepted
+	}
+
+	c.JSON(status, adjustment)
+}
+
+// getAdjustments - GET /api/v2/warehouses/{id}/adjustments
+func (h *Handler) getAdjustments(c *gin.Context) {
+	var uri resourceURI
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	var query adjustmentsQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	adjustments, err := h.service.GetAdjustments(c.Request.Context(), uri.ID, query.Status)
+	if err != nil {
+		h.abortWithServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, adjustments)
+}
+
+// approveAdjustment - POST /api/v2/adjustments/{id}/approve, только для администратора
+func (h *Handler) approveAdjustment(c *gin.Context) {
+	h.reviewAdjustment(c, h.service.ApproveAdjustment)
+}
+
+// rejectAdjustment - POST /api/v2/adjustments/{id}/reject, только для администратора
+func (h *Handler) rejectAdjustment(c *gin.Context) {
+	h.reviewAdjustment(c, h.service.RejectAdjustment)
+}
+
+func (h *Handler) reviewAdjustment(c *gin.Context, review func(ctx context.Context, adjustmentUUID string) (schemas.Adjustment, error)) {
+	var uri resourceURI
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	adjustment, err := review(c.Request.Context(), uri.ID)
+	if err != nil {
+		h.abortWithServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, adjustment)
+}
+
+// createStocktake - POST /api/v2/warehouses/{id}/stocktakes, инвентаризация склада или зоны
+func (h *Handler) createStocktake(c *gin.Context) {
+	var uri resourceURI
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	var request schemas.NewStocktake
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	stocktake, err := h.service.CreateStocktake(c.Request.Context(), uri.ID, request)
+	if err != nil {
+		h.abortWithServiceError(c, err)
+		return
+	}
+
+	c.Header("Location", "/api/v2/stocktakes/"+stocktake.ID)
+	c.JSON(http.StatusCreated, stocktake)
+}
+
+// getStocktake - GET /api/v2/stocktakes/{id}
+func (h *Handler) getStocktake(c *gin.Context) {
+	var uri resourceURI
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	stocktake, err := h.service.GetStocktake(c.Request.Context(), uri.ID)
+	if err != nil {
+		h.abortWithServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, stocktake)
+}
+
+// countStocktake - POST /api/v2/stocktakes/{id}/counts, пересчитанные количества
+func (h *Handler) countStocktake(c *gin.Context) {
+	var uri resourceURI
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	var request schemas.StocktakeCounts
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	stocktake, err := h.service.CountStocktake(c.Request.Context(), uri.ID, request.Counts)
+	if err != nil {
+		h.abortWithServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, stocktake)
+}
+
+// closeStocktake - POST /api/v2/stocktakes/{id}/close, закрытие с корректировками по расхождениям
+func (h *Handler) closeStocktake(c *gin.Context) {
+	var uri resourceURI
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	stocktake, err := h.service.CloseStocktake(c.Request.Context(), uri.ID)
+	if err != nil {
+		h.abortWithServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, stocktake)
+}
+
+// createReturn - POST /api/v2/warehouses/{id}/returns, возврат товара покупателем
+func (h *Handler) createReturn(c *gin.Context) {
+	var uri resourceURI
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	var request schemas.NewReturn
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ret, err := h.service.CreateReturn(c.Request.Context(), uri.ID, request)
+	if err != nil {
+		h.abortWithServiceError(c, err)
+		return
+	}
+
+	c.Header("Location", "/api/v2/returns/"+ret.ID)
+	c.JSON(http.StatusCreated, ret)
+}
+
+// getReturn - GET /api/v2/returns/{id}
+func (h *Handler) getReturn(c *gin.Context) {
+	var uri resourceURI
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ret, err := h.service.GetReturn(c.Request.Context(), uri.ID)
+	if err != nil {
+		h.abortWithServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ret)
+}
+
+// receiveReturn - POST /api/v2/returns/{id}/receive, возвращенный товар прибыл на склад
+func (h *Handler) receiveReturn(c *gin.Context) {
+	var uri resourceURI
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ret, err := h.service.ReceiveReturn(c.Request.Context(), uri.ID)
+	if err != nil {
+		h.abortWithServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ret)
+}
+
+// inspectReturn - POST /api/v2/returns/{id}/inspect, результат осмотра возврата
+func (h *Handler) inspectReturn(c *gin.Context) {
+	var uri resourceURI
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	var request schemas.ReturnInspection
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ret, err := h.service.InspectReturn(c.Request.Context(), uri.ID, request)
+	if err != nil {
+		h.abortWithServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ret)
+}
+
+// moveBucketStock - POST /api/v2/warehouses/{id}/bucket-moves, перемещение товара между корзинами остатка
+func (h *Handler) moveBucketStock(c *gin.Context) {
+	var uri resourceURI
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	var moves []schemas.BucketMove
+	if err := c.ShouldBindJSON(&moves); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	if len(moves) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "moves are required",
+		})
+		return
+	}
+
+	if err := h.service.MoveBucketStock(c.Request.Context(), uri.ID, moves); err != nil {
+		h.abortWithServiceError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// getKit - GET /api/v2/kits/{article}, состав набора и сколько наборов можно собрать
+func (h *Handler) getKit(c *gin.Context) {
+	var uri articleURI
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	kit, err := h.service.GetKit(c.Request.Context(), uri.Article)
+	if err != nil {
+		h.abortWithServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, kit)
+}
+
+// saveKit - PUT /api/v2/kits/{article}, состав набора. Прежний состав заменяется
+func (h *Handler) saveKit(c *gin.Context) {
+	var uri articleURI
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	var definition schemas.KitDefinition
+	if err := c.ShouldBindJSON(&definition); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	kit, err := h.service.SaveKit(c.Request.Context(), uri.Article, definition)
+	if err != nil {
+		h.abortWithServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, kit)
+}
+
+// deleteKit - DELETE /api/v2/kits/{article}, удаление состава набора
+func (h *Handler) deleteKit(c *gin.Context) {
+	var uri articleURI
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	if err := h.service.DeleteKit(c.Request.Context(), uri.Article); err != nil {
+		h.abortWithServiceError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// getSubstitutes - GET /api/v2/products/{article}/substitutes, замены товара при нехватке
+func (h *Handler) getSubstitutes(c *gin.Context) {
+	var uri articleURI
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	substitutes, err := h.service.GetSubstitutes(c.Request.Context(), uri.Article)
+	if err != nil {
+		h.abortWithServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, substitutes)
+}
+
+// saveSubstitutes - PUT /api/v2/products/{article}/substitutes, замены товара. Прежние замены заменяются
+func (h *Handler) saveSubstitutes(c *gin.Context) {
+	var uri articleURI
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	var definition schemas.SubstitutesDefinition
+	if err := c.ShouldBindJSON(&definition); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	substitutes, err := h.service.SaveSubstitutes(c.Request.Context(), uri.Article, definition)
+	if err != nil {
+		h.abortWithServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, substitutes)
+}
@@ -0,0 +1,1012 @@
+package handler
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/shamank/warehouse-service/internal/domain/models"
+	"github.com/shamank/warehouse-service/internal/domain/schemas"
+	"github.com/shamank/warehouse-service/internal/handler/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"log/slog"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+const reservationID = "5b0f2c3e-7a41-4d8e-9c6b-2e1f0a9d8c7b"
+
+var testReservation = schemas.Reservation{
+	ID:        reservationID,
+	Status:    models.ReservationActive,
+	CreatedAt: time.Date(2026, time.October, 19, 10, 0, 0, 0, time.UTC),
+	UpdatedAt: time.Date(2026, time.October, 19, 10, 0, 0, 0, time.UTC),
+	Items: []schemas.ReservationItem{
+		{Article: "a1as1", WarehouseUUID: "e4aa0556-aec5-41d4-8280-885865842719", Quantity: 5},
+	},
+}
+
+const testReservationJSON = `{"id":"5b0f2c3e-7a41-4d8e-9c6b-2e1f0a9d8c7b","status":"active",` +
+	`"created_at":"2026-10-19T10:00:00Z","updated_at":"2026-10-19T10:00:00Z",` +
+	`"items":[{"article":"a1as1","warehouse_uuid":"e4aa0556-aec5-41d4-8280-885865842719","quantity":5,"released":0}]}`
+
+const pickListID = "8e3b1f6a-2c7d-4a90-b5e4-6d1c9f2a7b03"
+
+var testPickList = schemas.PickList{
+	ID:        pickListID,
+	Status:    models.PickListOpen,
+	CreatedAt: time.Date(2026, time.October, 19, 11, 0, 0, 0, time.UTC),
+	UpdatedAt: time.Date(2026, time.October, 19, 11, 0, 0, 0, time.UTC),
+	Lines: []schemas.PickListLine{
+		{Line: 1, ReservationID: reservationID, WarehouseUUID: "e4aa0556-aec5-41d4-8280-885865842719", Location: "A/1/1/01",
+			Article: "a1as1", Quantity: 5, Status: models.PickLinePending},
+	},
+}
+
+const testPickListJSON = `{"id":"8e3b1f6a-2c7d-4a90-b5e4-6d1c9f2a7b03","status":"open",` +
+	`"created_at":"2026-10-19T11:00:00Z","updated_at":"2026-10-19T11:00:00Z",` +
+	`"lines":[{"line":1,"reservation_id":"5b0f2c3e-7a41-4d8e-9c6b-2e1f0a9d8c7b",` +
+	`"warehouse_uuid":"e4aa0556-aec5-41d4-8280-885865842719","location":"A/1/1/01","article":"a1as1",` +
+	`"quantity":5,"picked":0,"status":"pending"}]}`
+
+const adjustmentID = "7b3e9a2c-4d1f-4e8b-9c6a-0f5d2e8b1a47"
+
+func testAdjustment(status string) schemas.Adjustment {
+	return schemas.Adjustment{
+		ID:            adjustmentID,
+		WarehouseUUID: "e4aa0556-aec5-41d4-8280-885865842719",
+		Article:       "a1as1",
+		Delta:         -3,
+		Reason:        models.AdjustmentDamaged,
+		Actor:         "inventory",
+		Status:        status,
+		CreatedAt:     time.Date(2026, time.October, 19, 12, 0, 0, 0, time.UTC),
+	}
+}
+
+func testAdjustmentJSON(status string) string {
+	return `{"id":"7b3e9a2c-4d1f-4e8b-9c6a-0f5d2e8b1a47","warehouse_uuid":"e4aa0556-aec5-41d4-8280-885865842719",` +
+		`"article":"a1as1","delta":-3,"reason":"damaged","actor":"inventory","status":"` + status + `",` +
+		`"created_at":"2026-10-19T12:00:00Z"}`
+}
+
+const stocktakeID = "5e1c7a3b-2f4d-4c8e-9a6b-1d0f3e7c2b58"
+
+// testStocktake - слепая инвентаризация, в которой ожидаемое количество еще не показывается
+var testStocktake = schemas.Stocktake{
+	ID:            stocktakeID,
+	WarehouseUUID: "e4aa0556-aec5-41d4-8280-885865842719",
+	Zone:          "A",
+	Blind:         true,
+	Status:        models.StocktakeOpen,
+	CreatedBy:     "inventory",
+	CreatedAt:     time.Date(2026, time.October, 19, 12, 0, 0, 0, time.UTC),
+	Lines:         []schemas.StocktakeLine{{Line: 1, Location: "A/1/1/01", Article: "a1as1"}},
+}
+
+const testStocktakeJSON = `{"id":"5e1c7a3b-2f4d-4c8e-9a6b-1d0f3e7c2b58","warehouse_uuid":"e4aa0556-aec5-41d4-8280-885865842719",` +
+	`"zone":"A","blind":true,"status":"open","created_by":"inventory","created_at":"2026-10-19T12:00:00Z",` +
+	`"lines":[{"line":1,"location":"A/1/1/01","article":"a1as1"}]}`
+
+const returnID = "3a4b5c6d-7e8f-4a9b-8c0d-1e2f3a4b5c6d"
+
+func testReturn(status string, outcome string) schemas.Return {
+	return schemas.Return{
+		ID:            returnID,
+		WarehouseUUID: "e4aa0556-aec5-41d4-8280-885865842719",
+		Article:       "a1as1",
+		Quantity:      2,
+		Reference:     "order-42",
+		Status:        status,
+		Outcome:       outcome,
+		CreatedBy:     "support",
+		CreatedAt:     time.Date(2026, time.October, 19, 12, 0, 0, 0, time.UTC),
+	}
+}
+
+func testReturnJSON(status string, outcome string) string {
+	var outcomeJSON string
+	if outcome != "" {
+		outcomeJSON = `"outcome":"` + outcome + `",`
+	}
+	return `{"id":"3a4b5c6d-7e8f-4a9b-8c0d-1e2f3a4b5c6d","warehouse_uuid":"e4aa0556-aec5-41d4-8280-885865842719",` +
+		`"article":"a1as1","quantity":2,"reference":"order-42","status":"` + status + `",` + outcomeJSON +
+		`"created_by":"support","created_at":"2026-10-19T12:00:00Z"}`
+}
+
+func TestV2Routes(t *testing.T) {
+	type TestCase struct {
+		name   string
+		method string
+		url    string
+		body   string
+		// setup задает ожидания сервиса, nil - сервис не должен вызываться
+		setup func(service *mocks.Service)
+
+		expectedStatusCode int
+		expectedResult     string
+		expectedLocation   string
+	}
+
+	testCases := []TestCase{
+		{
+			name:   "warehouse stock",
+			method: "GET",
+			url:    "/api/v2/warehouses/e4aa0556-aec5-41d4-8280-885865842719/stock?limit=10&sort=-quantity",
+			setup: func(service *mocks.Service) {
+				filter := schemas.RemainingProductsFilter{Sort: schemas.SortByQuantity, Desc: true, Limit: 10}
+				service.On("GetRemainingProducts", mock.Anything, "e4aa0556-aec5-41d4-8280-885865842719", filter).
+					Return(schemas.RemainingProducts{Items: []schemas.Product{}, Total: 0}, nil)
+			},
+			expectedStatusCode: 200,
+			expectedResult:     `{"warehouse":{"uuid":"","name":"","is_available":false},"items":[],"next_cursor":null,"total":0}`,
+		},
+		{
+			name:               "warehouse stock with invalid id",
+			method:             "GET",
+			url:                "/api/v2/warehouses/123/stock",
+			expectedStatusCode: 400,
+			expectedResult:     `{"error":"path parameter \"id\": string doesn't match the format \"uuid\" (not a valid uuid)"}`,
+		},
+		{
+			name:   "unknown warehouse",
+			method: "GET",
+			url:    "/api/v2/warehouses/e4aa0556-aec5-41d4-8280-885865842719/stock",
+			setup: func(service *mocks.Service) {
+				service.On("GetRemainingProducts", mock.Anything, "e4aa0556-aec5-41d4-8280-885865842719", mock.Anything).
+					Return(schemas.RemainingProducts{}, models.ErrWarehouseNotFound)
+			},
+			expectedStatusCode: 404,
+			expectedResult:     `{"error":"warehouse not found"}`,
+		},
+		{
+			name:   "warehouse stock by lot",
+			method: "GET",
+			url:    "/api/v2/warehouses/e4aa0556-aec5-41d4-8280-885865842719/stock?by_lot=true",
+			setup: func(service *mocks.Service) {
+				expiresAt := time.Date(2027, time.January, 31, 0, 0, 0, 0, time.UTC)
+				filter := schemas.RemainingProductsFilter{Sort: schemas.SortByArticle, ByLot: true}
+				service.On("GetRemainingProducts", mock.Anything, "e4aa0556-aec5-41d4-8280-885865842719", filter).
+					Return(schemas.RemainingProducts{Items: []schemas.Product{{
+						Code:      "cream",
+						Quantity:  4,
+						Available: 4,
+						OnHand:    4,
+						Lots: []schemas.ProductLot{
+							{Lot: schemas.Lot{Number: "L-1", ExpiresAt: &schemas.Date{Time: expiresAt}}, Quantity: 4},
+						},
+					}}}, nil)
+			},
+			expectedStatusCode: 200,
+			expectedResult: `{"warehouse":{"uuid":"","name":"","is_available":false},"items":[{"name":"","size":"","code":"cream",` +
+				`"quantity":4,"available":4,"reserved":0,"on_hand":4,"expired":0,"lots":[{"number":"L-1","manufactured_at":null,` +
+				`"expires_at":"2027-01-31","quantity":4,"reserved":0,"expired":false}]}],"next_cursor":null,"total":0}`,
+		},
+		{
+			name:   "receive products",
+			method: "POST",
+			url:    "/api/v2/warehouses/e4aa0556-aec5-41d4-8280-885865842719/receipts",
+			body:   `[{"article":"cream","quantity":4,"lot":{"number":"L-1","expires_at":"2027-01-31"}},{"article":"soap","quantity":1}]`,
+			setup: func(service *mocks.Service) {
+				service.On("ReceiveProducts", mock.Anything, "e4aa0556-aec5-41d4-8280-885865842719", []schemas.ReceiptItem{
+					{Article: "cream", Quantity: 4, Lot: &schemas.Lot{
+						Number:    "L-1",
+						ExpiresAt: &schemas.Date{Time: time.Date(2027, time.January, 31, 0, 0, 0, 0, time.UTC)},
+					}},
+					{Article: "soap", Quantity: 1},
+				}).Return(nil)
+			},
+			expectedStatusCode: 204,
+			expectedResult:     ``,
+		},
+		{
+			name:               "receive products with invalid date",
+			method:             "POST",
+			url:                "/api/v2/warehouses/e4aa0556-aec5-41d4-8280-885865842719/receipts",
+			body:               `[{"article":"cream","quantity":4,"lot":{"number":"L-1","expires_at":"31.01.2027"}}]`,
+			expectedStatusCode: 400,
+			expectedResult: `{"error":"request body /0/lot/expires_at: string doesn't match the format \"date\" ` +
+				`(regular expression \"^[0-9]{4}-(0[0-9]|10|11|12)-([0-2][0-9]|30|31)$\")"}`,
+		},
+		{
+			name:   "receive lot with other dates",
+			method: "POST",
+			url:    "/api/v2/warehouses/e4aa0556-aec5-41d4-8280-885865842719/receipts",
+			body:   `[{"article":"cream","quantity":4,"lot":{"number":"L-1"}}]`,
+			setup: func(service *mocks.Service) {
+				service.On("ReceiveProducts", mock.Anything, "e4aa0556-aec5-41d4-8280-885865842719", mock.Anything).
+					Return(fmt.Errorf("%w: cream L-1", models.ErrLotMismatch))
+			},
+			expectedStatusCode: 409,
+			expectedResult:     `{"error":"lot already exists with other dates: cream L-1"}`,
+		},
+		{
+			name:   "receive serials of wrong count",
+			method: "POST",
+			url:    "/api/v2/warehouses/e4aa0556-aec5-41d4-8280-885865842719/receipts",
+			body:   `[{"article":"phone","quantity":2,"serials":["IMEI-1"]}]`,
+			setup: func(service *mocks.Service) {
+				service.On("ReceiveProducts", mock.Anything, "e4aa0556-aec5-41d4-8280-885865842719", []schemas.ReceiptItem{
+					{Article: "phone", Quantity: 2, Serials: []string{"IMEI-1"}},
+				}).Return(fmt.Errorf("%w: phone needs 2 serial numbers, got 1", models.ErrInvalidSerials))
+			},
+			expectedStatusCode: 400,
+			expectedResult:     `{"error":"serial numbers do not match products: phone needs 2 serial numbers, got 1"}`,
+		},
+		{
+			name:               "receive serial with slash",
+			method:             "POST",
+			url:                "/api/v2/warehouses/e4aa0556-aec5-41d4-8280-885865842719/receipts",
+			body:               `[{"article":"phone","quantity":1,"serials":["SN/1"]}]`,
+			expectedStatusCode: 400,
+			expectedResult:     `{"error":"request body /0/serials/0: string doesn't match the regular expression \"^[^/]+$\""}`,
+		},
+		{
+			name:   "receive serial already in stock",
+			method: "POST",
+			url:    "/api/v2/warehouses/e4aa0556-aec5-41d4-8280-885865842719/receipts",
+			body:   `[{"article":"phone","quantity":1,"serials":["IMEI-1"]}]`,
+			setup: func(service *mocks.Service) {
+				service.On("ReceiveProducts", mock.Anything, "e4aa0556-aec5-41d4-8280-885865842719", mock.Anything).
+					Return(fmt.Errorf("%w: phone IMEI-1", models.ErrSerialExists))
+			},
+			expectedStatusCode: 409,
+			expectedResult:     `{"error":"serial number is already in stock: phone IMEI-1"}`,
+		},
+		{
+			name:   "get serial",
+			method: "GET",
+			url:    "/api/v2/products/phone/serials/IMEI-1",
+			setup: func(service *mocks.Service) {
+				service.On("GetSerial", mock.Anything, "phone", "IMEI-1").Return(schemas.Serial{
+					Article:       "phone",
+					SerialNumber:  "IMEI-1",
+					WarehouseUUID: "e4aa0556-aec5-41d4-8280-885865842719",
+					Status:        models.SerialReserved,
+					ReservationID: reservationID,
+					UpdatedAt:     time.Date(2026, time.October, 19, 10, 0, 0, 0, time.UTC),
+				}, nil)
+			},
+			expectedStatusCode: 200,
+			expectedResult: `{"article":"phone","serial_number":"IMEI-1","warehouse_uuid":"e4aa0556-aec5-41d4-8280-885865842719",` +
+				`"status":"reserved","reservation_id":"5b0f2c3e-7a41-4d8e-9c6b-2e1f0a9d8c7b","updated_at":"2026-10-19T10:00:00Z"}`,
+		},
+		{
+			name:   "unknown serial",
+			method: "GET",
+			url:    "/api/v2/products/phone/serials/IMEI-2",
+			setup: func(service *mocks.Service) {
+				service.On("GetSerial", mock.Anything, "phone", "IMEI-2").Return(schemas.Serial{}, models.ErrSerialNotFound)
+			},
+			expectedStatusCode: 404,
+			expectedResult:     `{"error":"serial number not found"}`,
+		},
+		{
+			name:   "serial history",
+			method: "GET",
+			url:    "/api/v2/products/phone/serials/IMEI-1/history",
+			setup: func(service *mocks.Service) {
+				service.On("GetSerialHistory", mock.Anything, "phone", "IMEI-1").Return([]schemas.SerialEvent{
+					{
+						Status:        models.SerialAvailable,
+						WarehouseUUID: "e4aa0556-aec5-41d4-8280-885865842719",
+						CreatedAt:     time.Date(2026, time.October, 19, 10, 0, 0, 0, time.UTC),
+					},
+				}, nil)
+			},
+			expectedStatusCode: 200,
+			expectedResult: `[{"status":"available","warehouse_uuid":"e4aa0556-aec5-41d4-8280-885865842719",` +
+				`"created_at":"2026-10-19T10:00:00Z"}]`,
+		},
+		{
+			name:   "create location",
+			method: "POST",
+			url:    "/api/v2/warehouses/e4aa0556-aec5-41d4-8280-885865842719/locations",
+			body:   `{"kind":"bin","code":"07","parent_id":"6a2f4c81-3b5d-4e97-a0c6-8d1e2f3b4c5a"}`,
+			setup: func(service *mocks.Service) {
+				service.On("CreateLocation", mock.Anything, "e4aa0556-aec5-41d4-8280-885865842719", schemas.NewLocation{
+					Kind: "bin", Code: "07", ParentID: "6a2f4c81-3b5d-4e97-a0c6-8d1e2f3b4c5a",
+				}).Return(schemas.Location{
+					ID:       "0b8e3f6a-2c4d-4e5f-9a1b-7c8d9e0f1a2b",
+					ParentID: "6a2f4c81-3b5d-4e97-a0c6-8d1e2f3b4c5a",
+					Kind:     "bin",
+					Code:     "07",
+					Path:     "A/12/3/07",
+				}, nil)
+			},
+			expectedStatusCode: 201,
+			expectedResult: `{"id":"0b8e3f6a-2c4d-4e5f-9a1b-7c8d9e0f1a2b","parent_id":"6a2f4c81-3b5d-4e97-a0c6-8d1e2f3b4c5a",` +
+				`"kind":"bin","code":"07","path":"A/12/3/07"}`,
+		},
+		{
+			name:               "create location with slash in code",
+			method:             "POST",
+			url:                "/api/v2/warehouses/e4aa0556-aec5-41d4-8280-885865842719/locations",
+			body:               `{"kind":"zone","code":"A/1"}`,
+			expectedStatusCode: 400,
+			expectedResult:     `{"error":"request body /code: string doesn't match the regular expression \"^[^/]+$\""}`,
+		},
+		{
+			name:   "create existing location",
+			method: "POST",
+			url:    "/api/v2/warehouses/e4aa0556-aec5-41d4-8280-885865842719/locations",
+			body:   `{"kind":"zone","code":"A"}`,
+			setup: func(service *mocks.Service) {
+				service.On("CreateLocation", mock.Anything, "e4aa0556-aec5-41d4-8280-885865842719", schemas.NewLocation{Kind: "zone", Code: "A"}).
+					Return(schemas.Location{}, fmt.Errorf("%w: A", models.ErrLocationExists))
+			},
+			expectedStatusCode: 409,
+			expectedResult:     `{"error":"location already exists: A"}`,
+		},
+		{
+			name:   "get locations",
+			method: "GET",
+			url:    "/api/v2/warehouses/e4aa0556-aec5-41d4-8280-885865842719/locations",
+			setup: func(service *mocks.Service) {
+				service.On("GetLocations", mock.Anything, "e4aa0556-aec5-41d4-8280-885865842719").Return([]schemas.Location{
+					{ID: "6a2f4c81-3b5d-4e97-a0c6-8d1e2f3b4c5a", Kind: "zone", Code: "A", Path: "A"},
+				}, nil)
+			},
+			expectedStatusCode: 200,
+			expectedResult:     `[{"id":"6a2f4c81-3b5d-4e97-a0c6-8d1e2f3b4c5a","kind":"zone","code":"A","path":"A"}]`,
+		},
+		{
+			name:   "move stock",
+			method: "POST",
+			url:    "/api/v2/warehouses/e4aa0556-aec5-41d4-8280-885865842719/moves",
+			body:   `[{"article":"soap","quantity":2,"from":"A/1/1/01","to":"A/1/1/02"}]`,
+			setup: func(service *mocks.Service) {
+				service.On("MoveStock", mock.Anything, "e4aa0556-aec5-41d4-8280-885865842719", []schemas.StockMove{
+					{Article: "soap", Quantity: 2, From: "A/1/1/01", To: "A/1/1/02"},
+				}).Return(nil)
+			},
+			expectedStatusCode: 204,
+			expectedResult:     ``,
+		},
+		{
+			name:   "move stock to unknown bin",
+			method: "POST",
+			url:    "/api/v2/warehouses/e4aa0556-aec5-41d4-8280-885865842719/moves",
+			body:   `[{"article":"soap","quantity":2,"to":"A/1/1/09"}]`,
+			setup: func(service *mocks.Service) {
+				service.On("MoveStock", mock.Anything, "e4aa0556-aec5-41d4-8280-885865842719", []schemas.StockMove{
+					{Article: "soap", Quantity: 2, To: "A/1/1/09"},
+				}).Return(fmt.Errorf("%w: A/1/1/09", models.ErrLocationNotFound))
+			},
+			expectedStatusCode: 404,
+			expectedResult:     `{"error":"location not found: A/1/1/09"}`,
+		},
+		{
+			name:   "move bucket stock",
+			method: "POST",
+			url:    "/api/v2/warehouses/e4aa0556-aec5-41d4-8280-885865842719/bucket-moves",
+			body:   `[{"article":"soap","quantity":2,"from":"sellable","to":"damaged"}]`,
+			setup: func(service *mocks.Service) {
+				service.On("MoveBucketStock", mock.Anything, "e4aa0556-aec5-41d4-8280-885865842719", []schemas.BucketMove{
+					{Article: "soap", Quantity: 2, From: models.BucketSellable, To: models.BucketDamaged},
+				}).Return(nil)
+			},
+			expectedStatusCode: 204,
+			expectedResult:     ``,
+		},
+		{
+			name:   "move bucket stock from empty bucket",
+			method: "POST",
+			url:    "/api/v2/warehouses/e4aa0556-aec5-41d4-8280-885865842719/bucket-moves",
+			body:   `[{"article":"soap","quantity":2,"from":"quarantine","to":"sellable"}]`,
+			setup: func(service *mocks.Service) {
+				service.On("MoveBucketStock", mock.Anything, "e4aa0556-aec5-41d4-8280-885865842719", []schemas.BucketMove{
+					{Article: "soap", Quantity: 2, From: models.BucketQuarantine, To: models.BucketSellable},
+				}).Return(fmt.Errorf("%w: soap in quarantine", models.ErrNotEnoughProducts))
+			},
+			expectedStatusCode: 409,
+			expectedResult:     `{"error":"not enough products in warehouses: soap in quarantine"}`,
+		},
+		{
+			name:   "get kit",
+			method: "GET",
+			url:    "/api/v2/kits/gift",
+			setup: func(service *mocks.Service) {
+				service.On("GetKit", mock.Anything, "gift").Return(schemas.Kit{
+					Article:    "gift",
+					Name:       "gift set",
+					Components: []schemas.KitComponent{{Article: "soap", Quantity: 1}, {Article: "cream", Quantity: 2}},
+					Available:  3,
+					Warehouses: []schemas.WarehouseStock{{WarehouseUUID: "e4aa0556-aec5-41d4-8280-885865842719", IsAvailable: true, Available: 3}},
+				}, nil)
+			},
+			expectedStatusCode: 200,
+			expectedResult: `{"article":"gift","name":"gift set","size":"",` +
+				`"components":[{"article":"soap","quantity":1},{"article":"cream","quantity":2}],"available":3,` +
+				`"warehouses":[{"warehouse_uuid":"e4aa0556-aec5-41d4-8280-885865842719","is_available":true,"available":3,"reserved":0,"expired":0}]}`,
+		},
+		{
+			name:   "save kit",
+			method: "PUT",
+			url:    "/api/v2/kits/gift",
+			body:   `{"components":[{"article":"soap","quantity":1}]}`,
+			setup: func(service *mocks.Service) {
+				service.On("SaveKit", mock.Anything, "gift", schemas.KitDefinition{Components: []schemas.KitComponent{{Article: "soap", Quantity: 1}}}).
+					Return(schemas.Kit{Article: "gift", Components: []schemas.KitComponent{{Article: "soap", Quantity: 1}}, Warehouses: []schemas.WarehouseStock{}}, nil)
+			},
+			expectedStatusCode: 200,
+			expectedResult:     `{"article":"gift","name":"","size":"","components":[{"article":"soap","quantity":1}],"available":0,"warehouses":[]}`,
+		},
+		{
+			name:               "save kit without components",
+			method:             "PUT",
+			url:                "/api/v2/kits/gift",
+			body:               `{"components":[]}`,
+			expectedStatusCode: 400,
+			expectedResult:     `{"error":"request body /components: minimum number of items is 1"}`,
+		},
+		{
+			name:   "save nested kit",
+			method: "PUT",
+			url:    "/api/v2/kits/box",
+			body:   `{"components":[{"article":"gift","quantity":2}]}`,
+			setup: func(service *mocks.Service) {
+				service.On("SaveKit", mock.Anything, "box", schemas.KitDefinition{Components: []schemas.KitComponent{{Article: "gift", Quantity: 2}}}).
+					Return(schemas.Kit{}, fmt.Errorf("%w: component gift is a kit", models.ErrInvalidKit))
+			},
+			expectedStatusCode: 400,
+			expectedResult:     `{"error":"invalid kit: component gift is a kit"}`,
+		},
+		{
+			name:   "delete kit",
+			method: "DELETE",
+			url:    "/api/v2/kits/gift",
+			setup: func(service *mocks.Service) {
+				service.On("DeleteKit", mock.Anything, "gift").Return(nil)
+			},
+			expectedStatusCode: 204,
+			expectedResult:     ``,
+		},
+		{
+			name:   "delete unknown kit",
+			method: "DELETE",
+			url:    "/api/v2/kits/soap",
+			setup: func(service *mocks.Service) {
+				service.On("DeleteKit", mock.Anything, "soap").Return(fmt.Errorf("%w: soap", models.ErrKitNotFound))
+			},
+			expectedStatusCode: 404,
+			expectedResult:     `{"error":"kit not found: soap"}`,
+		},
+		{
+			name:   "get substitutes",
+			method: "GET",
+			url:    "/api/v2/products/shirt-m/substitutes",
+			setup: func(service *mocks.Service) {
+				service.On("GetSubstitutes", mock.Anything, "shirt-m").Return(schemas.Substitutes{
+					Article:     "shirt-m",
+					Substitutes: []schemas.Substitute{{Article: "shirt-m-blue", Name: "shirt", Size: "M", SameSize: true}},
+				}, nil)
+			},
+			expectedStatusCode: 200,
+			expectedResult:     `{"article":"shirt-m","substitutes":[{"article":"shirt-m-blue","name":"shirt","size":"M","same_size":true}]}`,
+		},
+		{
+			name:   "save substitutes",
+			method: "PUT",
+			url:    "/api/v2/products/shirt-m/substitutes",
+			body:   `{"substitutes":[]}`,
+			setup: func(service *mocks.Service) {
+				service.On("SaveSubstitutes", mock.Anything, "shirt-m", schemas.SubstitutesDefinition{Substitutes: []schemas.SubstituteRule{}}).
+					Return(schemas.Substitutes{Article: "shirt-m", Substitutes: []schemas.Substitute{}}, nil)
+			},
+			expectedStatusCode: 200,
+			expectedResult:     `{"article":"shirt-m","substitutes":[]}`,
+		},
+		{
+			name:   "save product as its own substitute",
+			method: "PUT",
+			url:    "/api/v2/products/shirt-m/substitutes",
+			body:   `{"substitutes":[{"article":"shirt-m"}]}`,
+			setup: func(service *mocks.Service) {
+				service.On("SaveSubstitutes", mock.Anything, "shirt-m", schemas.SubstitutesDefinition{Substitutes: []schemas.SubstituteRule{{Article: "shirt-m"}}}).
+					Return(schemas.Substitutes{}, fmt.Errorf("%w: shirt-m is listed twice or is the product itself", models.ErrInvalidSubstitute))
+			},
+			expectedStatusCode: 400,
+			expectedResult:     `{"error":"invalid substitute: shirt-m is listed twice or is the product itself"}`,
+		},
+		{
+			name:               "save substitutes without list",
+			method:             "PUT",
+			url:                "/api/v2/products/shirt-m/substitutes",
+			body:               `{}`,
+			expectedStatusCode: 400,
+			expectedResult:     `{"error":"request body /substitutes: property \"substitutes\" is missing"}`,
+		},
+		{
+			name:   "pick list",
+			method: "GET",
+			url:    "/api/v2/reservations/" + reservationID + "/pick-list",
+			setup: func(service *mocks.Service) {
+				service.On("GetReservationPickList", mock.Anything, reservationID).Return(schemas.ReservationPickList{
+					ReservationID: reservationID,
+					Lines: []schemas.PickLine{
+						{WarehouseUUID: "e4aa0556-aec5-41d4-8280-885865842719", Location: "A/1/1/01", Article: "a1as1", Quantity: 3},
+						{WarehouseUUID: "e4aa0556-aec5-41d4-8280-885865842719", Article: "a1as1", Quantity: 2},
+					},
+				}, nil)
+			},
+			expectedStatusCode: 200,
+			expectedResult: `{"reservation_id":"` + reservationID + `","lines":[` +
+				`{"warehouse_uuid":"e4aa0556-aec5-41d4-8280-885865842719","location":"A/1/1/01","article":"a1as1","quantity":3},` +
+				`{"warehouse_uuid":"e4aa0556-aec5-41d4-8280-885865842719","location":"","article":"a1as1","quantity":2}]}`,
+		},
+		{
+			name:   "create pick list",
+			method: "POST",
+			url:    "/api/v2/pick-lists",
+			body:   `{"reservations":["` + reservationID + `"]}`,
+			setup: func(service *mocks.Service) {
+				service.On("CreatePickList", mock.Anything, schemas.NewPickList{Reservations: []string{reservationID}}).Return(testPickList, nil)
+			},
+			expectedStatusCode: 201,
+			expectedResult:     testPickListJSON,
+			expectedLocation:   "/api/v2/pick-lists/" + pickListID,
+		},
+		{
+			name:   "create pick list of reservation being picked",
+			method: "POST",
+			url:    "/api/v2/pick-lists",
+			body:   `{"reservations":["` + reservationID + `"]}`,
+			setup: func(service *mocks.Service) {
+				service.On("CreatePickList", mock.Anything, mock.Anything).
+					Return(schemas.PickList{}, fmt.Errorf("%w: %s", models.ErrReservationPicking, reservationID))
+			},
+			expectedStatusCode: 409,
+			expectedResult:     `{"error":"reservation is already on an open pick list: ` + reservationID + `"}`,
+		},
+		{
+			name:   "get pick list",
+			method: "GET",
+			url:    "/api/v2/pick-lists/" + pickListID,
+			setup: func(service *mocks.Service) {
+				service.On("GetPickList", mock.Anything, pickListID).Return(testPickList, nil)
+			},
+			expectedStatusCode: 200,
+			expectedResult:     testPickListJSON,
+		},
+		{
+			name:   "confirm short pick",
+			method: "POST",
+			url:    "/api/v2/pick-lists/" + pickListID + "/lines/1/confirm",
+			body:   `{"picked":0}`,
+			setup: func(service *mocks.Service) {
+				service.On("ConfirmPickLine", mock.Anything, pickListID, 1, 0).Return(testPickList, nil)
+			},
+			expectedStatusCode: 200,
+			expectedResult:     testPickListJSON,
+		},
+		{
+			name:   "confirm confirmed line",
+			method: "POST",
+			url:    "/api/v2/pick-lists/" + pickListID + "/lines/1/confirm",
+			body:   `{"picked":5}`,
+			setup: func(service *mocks.Service) {
+				service.On("ConfirmPickLine", mock.Anything, pickListID, 1, 5).
+					Return(schemas.PickList{}, fmt.Errorf("%w: line 1 is picked", models.ErrPickLineConfirmed))
+			},
+			expectedStatusCode: 409,
+			expectedResult:     `{"error":"pick list line is already confirmed: line 1 is picked"}`,
+		},
+		{
+			name:               "confirm without picked quantity",
+			method:             "POST",
+			url:                "/api/v2/pick-lists/" + pickListID + "/lines/1/confirm",
+			body:               `{}`,
+			expectedStatusCode: 400,
+			expectedResult:     `{"error":"request body /picked: property \"picked\" is missing"}`,
+		},
+		{
+			name:               "confirm line zero",
+			method:             "POST",
+			url:                "/api/v2/pick-lists/" + pickListID + "/lines/0/confirm",
+			body:               `{"picked":1}`,
+			expectedStatusCode: 400,
+			expectedResult:     `{"error":"path parameter \"line\": number must be at least 1"}`,
+		},
+		{
+			name:   "create adjustment",
+			method: "POST",
+			url:    "/api/v2/warehouses/e4aa0556-aec5-41d4-8280-885865842719/adjustments",
+			body:   `{"article":"a1as1","delta":-3,"reason":"damaged"}`,
+			setup: func(service *mocks.Service) {
+				service.On("CreateAdjustment", mock.Anything, "e4aa0556-aec5-41d4-8280-885865842719",
+					schemas.NewAdjustment{Article: "a1as1", Delta: -3, Reason: models.AdjustmentDamaged}).
+					Return(testAdjustment(models.AdjustmentApplied), nil)
+			},
+			expectedStatusCode: 201,
+			expectedResult:     testAdjustmentJSON(models.AdjustmentApplied),
+		},
+		{
+			name:   "create adjustment above threshold",
+			method: "POST",
+			url:    "/api/v2/warehouses/e4aa0556-aec5-41d4-8280-885865842719/adjustments",
+			body:   `{"article":"a1as1","delta":-3,"reason":"damaged"}`,
+			setup: func(service *mocks.Service) {
+				service.On("CreateAdjustment", mock.Anything, mock.Anything, mock.Anything).
+					Return(testAdjustment(models.AdjustmentPending), nil)
+			},
+			expectedStatusCode: 202,
+			expectedResult:     testAdjustmentJSON(models.AdjustmentPending),
+		},
+		{
+			name:   "create adjustment beyond free stock",
+			method: "POST",
+			url:    "/api/v2/warehouses/e4aa0556-aec5-41d4-8280-885865842719/adjustments",
+			body:   `{"article":"a1as1","delta":-30,"reason":"lost"}`,
+			setup: func(service *mocks.Service) {
+				service.On("CreateAdjustment", mock.Anything, mock.Anything, mock.Anything).
+					Return(schemas.Adjustment{}, fmt.Errorf("%w: only 5 of a1as1 are free, cannot write off 30", models.ErrNotEnoughProducts))
+			},
+			expectedStatusCode: 409,
+			expectedResult:     `{"error":"not enough products in warehouses: only 5 of a1as1 are free, cannot write off 30"}`,
+		},
+		{
+			name:               "create adjustment with unknown reason",
+			method:             "POST",
+			url:                "/api/v2/warehouses/e4aa0556-aec5-41d4-8280-885865842719/adjustments",
+			body:               `{"article":"a1as1","delta":-3,"reason":"stolen"}`,
+			expectedStatusCode: 400,
+			expectedResult:     `{"error":"request body /reason: value is not one of the allowed values [\"damaged\",\"lost\",\"found\",\"correction\"]"}`,
+		},
+		{
+			name:   "get pending adjustments",
+			method: "GET",
+			url:    "/api/v2/warehouses/e4aa0556-aec5-41d4-8280-885865842719/adjustments?status=pending",
+			setup: func(service *mocks.Service) {
+				service.On("GetAdjustments", mock.Anything, "e4aa0556-aec5-41d4-8280-885865842719", models.AdjustmentPending).
+					Return([]schemas.Adjustment{testAdjustment(models.AdjustmentPending)}, nil)
+			},
+			expectedStatusCode: 200,
+			expectedResult:     "[" + testAdjustmentJSON(models.AdjustmentPending) + "]",
+		},
+		{
+			name:   "approve adjustment",
+			method: "POST",
+			url:    "/api/v2/adjustments/" + adjustmentID + "/approve",
+			setup: func(service *mocks.Service) {
+				service.On("ApproveAdjustment", mock.Anything, adjustmentID).Return(testAdjustment(models.AdjustmentApplied), nil)
+			},
+			expectedStatusCode: 200,
+			expectedResult:     testAdjustmentJSON(models.AdjustmentApplied),
+		},
+		{
+			name:   "approve own adjustment",
+			method: "POST",
+			url:    "/api/v2/adjustments/" + adjustmentID + "/approve",
+			setup: func(service *mocks.Service) {
+				service.On("ApproveAdjustment", mock.Anything, adjustmentID).Return(schemas.Adjustment{}, models.ErrSelfApproval)
+			},
+			expectedStatusCode: 403,
+			expectedResult:     `{"error":"adjustment cannot be approved by its author"}`,
+		},
+		{
+			name:   "reject reviewed adjustment",
+			method: "POST",
+			url:    "/api/v2/adjustments/" + adjustmentID + "/reject",
+			setup: func(service *mocks.Service) {
+				service.On("RejectAdjustment", mock.Anything, adjustmentID).
+					Return(schemas.Adjustment{}, fmt.Errorf("%w: applied", models.ErrAdjustmentReviewed))
+			},
+			expectedStatusCode: 409,
+			expectedResult:     `{"error":"adjustment is already reviewed: applied"}`,
+		},
+		{
+			name:   "create stocktake",
+			method: "POST",
+			url:    "/api/v2/warehouses/e4aa0556-aec5-41d4-8280-885865842719/stocktakes",
+			body:   `{"zone_id":"4a7d2c1e-8b3f-4e6a-9d5c-2f1e0b7a3c68","blind":true}`,
+			setup: func(service *mocks.Service) {
+				service.On("CreateStocktake", mock.Anything, "e4aa0556-aec5-41d4-8280-885865842719",
+					schemas.NewStocktake{ZoneID: "4a7d2c1e-8b3f-4e6a-9d5c-2f1e0b7a3c68", Blind: true}).
+					Return(testStocktake, nil)
+			},
+			expectedStatusCode: 201,
+			expectedResult:     testStocktakeJSON,
+			expectedLocation:   "/api/v2/stocktakes/" + stocktakeID,
+		},
+		{
+			name:   "create stocktake while another is open",
+			method: "POST",
+			url:    "/api/v2/warehouses/e4aa0556-aec5-41d4-8280-885865842719/stocktakes",
+			body:   `{}`,
+			setup: func(service *mocks.Service) {
+				service.On("CreateStocktake", mock.Anything, mock.Anything, mock.Anything).
+					Return(schemas.Stocktake{}, fmt.Errorf("%w: warehouse e4aa0556-aec5-41d4-8280-885865842719", models.ErrStocktakeOpen))
+			},
+			expectedStatusCode: 409,
+			expectedResult:     `{"error":"stocktake is already open: warehouse e4aa0556-aec5-41d4-8280-885865842719"}`,
+		},
+		{
+			name:   "get stocktake",
+			method: "GET",
+			url:    "/api/v2/stocktakes/" + stocktakeID,
+			setup: func(service *mocks.Service) {
+				service.On("GetStocktake", mock.Anything, stocktakeID).Return(testStocktake, nil)
+			},
+			expectedStatusCode: 200,
+			expectedResult:     testStocktakeJSON,
+		},
+		{
+			name:   "count stocktake",
+			method: "POST",
+			url:    "/api/v2/stocktakes/" + stocktakeID + "/counts",
+			body:   `{"counts":[{"article":"a1as1","location":"A/1/1/01","counted":0}]}`,
+			setup: func(service *mocks.Service) {
+				counted := 0
+				service.On("CountStocktake", mock.Anything, stocktakeID,
+					[]schemas.StocktakeCount{{Article: "a1as1", Location: "A/1/1/01", Counted: &counted}}).
+					Return(testStocktake, nil)
+			},
+			expectedStatusCode: 200,
+			expectedResult:     testStocktakeJSON,
+		},
+		{
+			name:               "count stocktake with negative quantity",
+			method:             "POST",
+			url:                "/api/v2/stocktakes/" + stocktakeID + "/counts",
+			body:               `{"counts":[{"article":"a1as1","counted":-1}]}`,
+			expectedStatusCode: 400,
+			expectedResult:     `{"error":"request body /counts/0/counted: number must be at least 0"}`,
+		},
+		{
+			name:   "close closed stocktake",
+			method: "POST",
+			url:    "/api/v2/stocktakes/" + stocktakeID + "/close",
+			setup: func(service *mocks.Service) {
+				service.On("CloseStocktake", mock.Anything, stocktakeID).
+					Return(schemas.Stocktake{}, fmt.Errorf("%w: %s", models.ErrStocktakeClosed, stocktakeID))
+			},
+			expectedStatusCode: 409,
+			expectedResult:     `{"error":"stocktake is already closed: ` + stocktakeID + `"}`,
+		},
+		{
+			name:   "create return",
+			method: "POST",
+			url:    "/api/v2/warehouses/e4aa0556-aec5-41d4-8280-885865842719/returns",
+			body:   `{"article":"a1as1","quantity":2,"reference":"order-42"}`,
+			setup: func(service *mocks.Service) {
+				service.On("CreateReturn", mock.Anything, "e4aa0556-aec5-41d4-8280-885865842719",
+					schemas.NewReturn{Article: "a1as1", Quantity: 2, Reference: "order-42"}).
+					Return(testReturn(models.ReturnCreated, ""), nil)
+			},
+			expectedStatusCode: 201,
+			expectedResult:     testReturnJSON(models.ReturnCreated, ""),
+			expectedLocation:   "/api/v2/returns/" + returnID,
+		},
+		{
+			name:   "get return",
+			method: "GET",
+			url:    "/api/v2/returns/" + returnID,
+			setup: func(service *mocks.Service) {
+				service.On("GetReturn", mock.Anything, returnID).Return(testReturn(models.ReturnReceived, ""), nil)
+			},
+			expectedStatusCode: 200,
+			expectedResult:     testReturnJSON(models.ReturnReceived, ""),
+		},
+		{
+			name:   "receive received return",
+			method: "POST",
+			url:    "/api/v2/returns/" + returnID + "/receive",
+			setup: func(service *mocks.Service) {
+				service.On("ReceiveReturn", mock.Anything, returnID).
+					Return(schemas.Return{}, fmt.Errorf("%w: %s is received", models.ErrReturnStatus, returnID))
+			},
+			expectedStatusCode: 409,
+			expectedResult:     `{"error":"return is not in the required status: ` + returnID + ` is received"}`,
+		},
+		{
+			name:   "inspect return",
+			method: "POST",
+			url:    "/api/v2/returns/" + returnID + "/inspect",
+			body:   `{"outcome":"quarantine"}`,
+			setup: func(service *mocks.Service) {
+				service.On("InspectReturn", mock.Anything, returnID, schemas.ReturnInspection{Outcome: models.ReturnQuarantine}).
+					Return(testReturn(models.ReturnInspected, models.ReturnQuarantine), nil)
+			},
+			expectedStatusCode: 200,
+			expectedResult:     testReturnJSON(models.ReturnInspected, models.ReturnQuarantine),
+		},
+		{
+			name:               "inspect return with unknown outcome",
+			method:             "POST",
+			url:                "/api/v2/returns/" + returnID + "/inspect",
+			body:               `{"outcome":"resell"}`,
+			expectedStatusCode: 400,
+			expectedResult:     `{"error":"request body /outcome: value is not one of the allowed values [\"restock\",\"quarantine\",\"scrap\"]"}`,
+		},
+		{
+			name:   "create reservation",
+			method: "POST",
+			url:    "/api/v2/reservations",
+			body:   `[{"article":"a1as1","quantity":5}]`,
+			setup: func(service *mocks.Service) {
+				service.On("CreateReservation", mock.Anything, []schemas.ReserveItem{{Article: "a1as1", Quantity: 5}}).
+					Return(testReservation, nil)
+			},
+			expectedStatusCode: 201,
+			expectedResult:     testReservationJSON,
+			expectedLocation:   "/api/v2/reservations/" + reservationID,
+		},
+		{
+			name:   "create reservation with substitutes",
+			method: "POST",
+			url:    "/api/v2/reservations",
+			body:   `[{"article":"a1as1","quantity":5,"allow_substitutes":true}]`,
+			setup: func(service *mocks.Service) {
+				reservation := testReservation
+				reservation.Items = []schemas.ReservationItem{
+					{Article: "a1as2", WarehouseUUID: "e4aa0556-aec5-41d4-8280-885865842719", Quantity: 5, SubstituteFor: "a1as1"},
+				}
+				service.On("CreateReservation", mock.Anything, []schemas.ReserveItem{{Article: "a1as1", Quantity: 5, AllowSubstitutes: true}}).
+					Return(reservation, nil)
+			},
+			expectedStatusCode: 201,
+			expectedResult: `{"id":"5b0f2c3e-7a41-4d8e-9c6b-2e1f0a9d8c7b","status":"active",` +
+				`"created_at":"2026-10-19T10:00:00Z","updated_at":"2026-10-19T10:00:00Z",` +
+				`"items":[{"article":"a1as2","warehouse_uuid":"e4aa0556-aec5-41d4-8280-885865842719","quantity":5,"released":0,"substitute_for":"a1as1"}]}`,
+			expectedLocation: "/api/v2/reservations/" + reservationID,
+		},
+		{
+			name:               "create reservation without items",
+			method:             "POST",
+			url:                "/api/v2/reservations",
+			body:               `[]`,
+			expectedStatusCode: 400,
+			expectedResult:     `{"error":"request body: minimum number of items is 1"}`,
+		},
+		{
+			name:               "create reservation with old payload",
+			method:             "POST",
+			url:                "/api/v2/reservations",
+			body:               `["a1as1"]`,
+			expectedStatusCode: 400,
+			expectedResult:     `{"error":"request body /0: value must be an object"}`,
+		},
+		{
+			name:   "create reservation without stock",
+			method: "POST",
+			url:    "/api/v2/reservations",
+			body:   `[{"article":"a1as1","quantity":500}]`,
+			setup: func(service *mocks.Service) {
+				service.On("CreateReservation", mock.Anything, mock.Anything).Return(schemas.Reservation{}, models.ErrNotEnoughProducts)
+			},
+			expectedStatusCode: 409,
+			expectedResult:     `{"error":"not enough products in warehouses"}`,
+		},
+		{
+			name:   "create reservation with storage error",
+			method: "POST",
+			url:    "/api/v2/reservations",
+			body:   `[{"article":"a1as1","quantity":1}]`,
+			setup: func(service *mocks.Service) {
+				service.On("CreateReservation", mock.Anything, mock.Anything).Return(schemas.Reservation{}, errors.New("connection refused"))
+			},
+			expectedStatusCode: 500,
+			expectedResult:     `{"error":"internal server error"}`,
+		},
+		{
+			name:   "get reservation",
+			method: "GET",
+			url:    "/api/v2/reservations/" + reservationID,
+			setup: func(service *mocks.Service) {
+				service.On("GetReservation", mock.Anything, reservationID).Return(testReservation, nil)
+			},
+			expectedStatusCode: 200,
+			expectedResult:     testReservationJSON,
+		},
+		{
+			name:   "unknown reservation",
+			method: "GET",
+			url:    "/api/v2/reservations/" + reservationID,
+			setup: func(service *mocks.Service) {
+				service.On("GetReservation", mock.Anything, reservationID).Return(schemas.Reservation{}, models.ErrReservationNotFound)
+			},
+			expectedStatusCode: 404,
+			expectedResult:     `{"error":"reservation not found"}`,
+		},
+		{
+			name:   "cancel reservation",
+			method: "DELETE",
+			url:    "/api/v2/reservations/" + reservationID,
+			setup: func(service *mocks.Service) {
+				service.On("CancelReservation", mock.Anything, reservationID).Return(testReservation, nil)
+			},
+			expectedStatusCode: 200,
+			expectedResult:     testReservationJSON,
+		},
+		{
+			name:   "cancel closed reservation",
+			method: "DELETE",
+			url:    "/api/v2/reservations/" + reservationID,
+			setup: func(service *mocks.Service) {
+				service.On("CancelReservation", mock.Anything, reservationID).
+					Return(schemas.Reservation{}, fmt.Errorf("%w: %s", models.ErrReservationClosed, models.ReservationReleased))
+			},
+			expectedStatusCode: 409,
+			expectedResult:     `{"error":"reservation is already closed: released"}`,
+		},
+		{
+			name:   "release whole reservation",
+			method: "POST",
+			url:    "/api/v2/reservations/" + reservationID + "/release",
+			setup: func(service *mocks.Service) {
+				service.On("ReleaseReservation", mock.Anything, reservationID, []schemas.ReleaseItem(nil)).Return(testReservation, nil)
+			},
+			expectedStatusCode: 200,
+			expectedResult:     testReservationJSON,
+		},
+		{
+			name:   "release part of reservation",
+			method: "POST",
+			url:    "/api/v2/reservations/" + reservationID + "/release",
+			body:   `[{"article":"a1as1","quantity":2}]`,
+			setup: func(service *mocks.Service) {
+				service.On("ReleaseReservation", mock.Anything, reservationID, []schemas.ReleaseItem{{Article: "a1as1", Quantity: 2}}).
+					Return(testReservation, nil)
+			},
+			expectedStatusCode: 200,
+			expectedResult:     testReservationJSON,
+		},
+		{
+			name:               "release with invalid quantity",
+			method:             "POST",
+			url:                "/api/v2/reservations/" + reservationID + "/release",
+			body:               `[{"article":"a1as1","quantity":-2}]`,
+			expectedStatusCode: 400,
+			expectedResult:     `{"error":"request body /0/quantity: number must be at least 1"}`,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			service := mocks.NewService(t)
+			if testCase.setup != nil {
+				testCase.setup(service)
+			}
+
+			handler := NewHandler(service, slog.Default())
+
+			r := handler.InitAPIRoutes()
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(testCase.method, testCase.url, bytes.NewBufferString(testCase.body))
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedResult, w.Body.String())
+			assert.Equal(t, testCase.expectedLocation, w.Header().Get("Location"))
+			assert.Empty(t, w.Header().Get("Deprecation"), "v2 must not be deprecated")
+		})
+	}
+}
+
+func TestV1DeprecationHeaders(t *testing.T) {
+	service := mocks.NewService(t)
+	service.On("ReserveProducts", mock.Anything, []string{"a1as1"}).Return(nil)
+	service.On("GetRemainingProductsBatch", mock.Anything, mock.Anything).Return([]schemas.ProductStock{}, nil)
+
+	r := NewHandler(service, slog.Default()).InitAPIRoutes()
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("POST", "/api/reserveProducts", bytes.NewBufferString(`["a1as1"]`)))
+
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, "@1792368000", w.Header().Get("Deprecation"))
+	assert.Equal(t, "Fri, 30 Apr 2027 00:00:00 GMT", w.Header().Get("Sunset"))
+	assert.Equal(t, `</api/v2>; rel="successor-version"`, w.Header().Get("Link"))
+
+	// у пакетного запроса остатков нет замены в v2, он не устарел
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("POST", "/api/getRemainingProductsBatch", bytes.NewBufferString(`{"articles":["a1as1"]}`)))
+
+	assert.Equal(t, 200, w.Code)
+	assert.Empty(t, w.Header().Get("Deprecation"))
+	assert.Empty(t, w.Header().Get("Sunset"))
+}
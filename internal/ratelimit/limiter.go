@@ -0,0 +1,115 @@
+// Package ratelimit ограничивает частоту запросов клиентов (token bucket)
+// и количество одновременно выполняемых операций
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// Limit - параметры корзины: Rate токенов в секунду, не больше Burst токенов.
+// Нулевой Rate снимает ограничение
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// Result - решение по запросу и состояние корзины клиента для заголовков RateLimit-*
+type Result struct {
+	Allowed bool
+	// Limit - размер корзины
+	Limit int
+	// Remaining - сколько запросов осталось без ожидания
+	Remaining int
+	// Reset - через сколько корзина наполнится полностью
+	Reset time.Duration
+	// RetryAfter - через сколько появится токен, если запрос отклонен
+	RetryAfter time.Duration
+}
+
+// sweepInterval - как часто удаляются корзины неактивных клиентов
+const sweepInterval = time.Minute
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// Limiter хранит корзины токенов по ключу клиента
+type Limiter struct {
+	limit Limit
+	now   func() time.Time
+
+	mx        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+func NewLimiter(limit Limit) *Limiter {
+	if limit.Burst < 1 {
+		limit.Burst = max(1, int(math.Ceil(limit.Rate)))
+	}
+
+	return &Limiter{
+		limit:   limit,
+		now:     time.Now,
+		buckets: make(map[string]*bucket),
+	}
+}
+
+// Allow списывает токен из корзины клиента key
+func (l *Limiter) Allow(key string) Result {
+	if l.limit.Rate <= 0 {
+		return Result{Allowed: true}
+	}
+
+	l.mx.Lock()
+	defer l.mx.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(l.limit.Burst), last: now}
+		l.buckets[key] = b
+	}
+	b.tokens = l.refill(b, now)
+	b.last = now
+
+	result := Result{Limit: l.limit.Burst}
+	if b.tokens >= 1 {
+		b.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = l.duration(1 - b.tokens)
+	}
+	result.Remaining = int(b.tokens)
+	result.Reset = l.duration(float64(l.limit.Burst) - b.tokens)
+
+	return result
+}
+
+func (l *Limiter) refill(b *bucket, now time.Time) float64 {
+	return math.Min(float64(l.limit.Burst), b.tokens+now.Sub(b.last).Seconds()*l.limit.Rate)
+}
+
+// duration - время, за которое накопится tokens токенов
+func (l *Limiter) duration(tokens float64) time.Duration {
+	return time.Duration(tokens / l.limit.Rate * float64(time.Second))
+}
+
+// sweep удаляет полные корзины: клиент без корзины получает полную, так что ограничение не меняется
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < sweepInterval {
+		return
+	}
+	l.lastSweep = now
+
+	for key, b := range l.buckets {
+		if l.refill(b, now) >= float64(l.limit.Burst) {
+			delete(l.buckets, key)
+		}
+	}
+}
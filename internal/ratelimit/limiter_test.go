@@ -0,0 +1,73 @@
+package ratelimit
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestLimiter_Allow(t *testing.T) {
+	now := time.Date(2026, time.October, 19, 10, 0, 0, 0, time.UTC)
+
+	limiter := NewLimiter(Limit{Rate: 2, Burst: 3})
+	limiter.now = func() time.Time { return now }
+
+	// всплеск до размера корзины
+	for i := 2; i >= 0; i-- {
+		result := limiter.Allow("client1")
+		assert.True(t, result.Allowed)
+		assert.Equal(t, 3, result.Limit)
+		assert.Equal(t, i, result.Remaining)
+	}
+
+	result := limiter.Allow("client1")
+	assert.False(t, result.Allowed)
+	assert.Equal(t, 0, result.Remaining)
+	assert.Equal(t, 500*time.Millisecond, result.RetryAfter)
+	assert.Equal(t, 1500*time.Millisecond, result.Reset)
+
+	// у другого клиента своя корзина
+	assert.True(t, limiter.Allow("client2").Allowed)
+
+	// за полсекунды накопился один токен
+	now = now.Add(500 * time.Millisecond)
+	assert.True(t, limiter.Allow("client1").Allowed)
+	assert.False(t, limiter.Allow("client1").Allowed)
+
+	// корзина не копит больше Burst
+	now = now.Add(time.Hour)
+	result = limiter.Allow("client1")
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 2, result.Remaining)
+}
+
+func TestLimiter_Unlimited(t *testing.T) {
+	limiter := NewLimiter(Limit{})
+
+	for i := 0; i < 100; i++ {
+		assert.Equal(t, Result{Allowed: true}, limiter.Allow("client1"))
+	}
+}
+
+func TestLimiter_DefaultBurst(t *testing.T) {
+	limiter := NewLimiter(Limit{Rate: 2.5})
+
+	assert.Equal(t, 3, limiter.Allow("client1").Limit)
+}
+
+func TestLimiter_Sweep(t *testing.T) {
+	now := time.Date(2026, time.October, 19, 10, 0, 0, 0, time.UTC)
+
+	limiter := NewLimiter(Limit{Rate: 1, Burst: 1})
+	limiter.now = func() time.Time { return now }
+
+	limiter.Allow("client1")
+	limiter.Allow("client2")
+	assert.Len(t, limiter.buckets, 2)
+
+	// через минуту корзины полные и удаляются, остается только корзина текущего клиента
+	now = now.Add(sweepInterval)
+	result := limiter.Allow("client1")
+	assert.True(t, result.Allowed)
+	assert.Len(t, limiter.buckets, 1)
+}
@@ -0,0 +1,74 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+var (
+	ErrQueueFull    = errors.New("too many operations in progress")
+	ErrQueueTimeout = errors.New("timed out waiting for operation slot")
+)
+
+// Queue ограничивает количество одновременно выполняемых операций.
+// Операции сверх лимита ждут в очереди ограниченной длины не дольше timeout
+type Queue struct {
+	slots      chan struct{}
+	maxWaiting int
+	timeout    time.Duration
+
+	mx      sync.Mutex
+	waiting int
+}
+
+// NewQueue создает очередь на maxInFlight операций с maxWaiting ожидающими. Нулевой timeout - ждать без ограничения
+func NewQueue(maxInFlight, maxWaiting int, timeout time.Duration) *Queue {
+	return &Queue{
+		slots:      make(chan struct{}, maxInFlight),
+		maxWaiting: maxWaiting,
+		timeout:    timeout,
+	}
+}
+
+// Acquire занимает место для операции. release нужно вызвать после ее завершения
+func (q *Queue) Acquire(ctx context.Context) (release func(), err error) {
+	release = func() { <-q.slots }
+
+	select {
+	case q.slots <- struct{}{}:
+		return release, nil
+	default:
+	}
+
+	q.mx.Lock()
+	if q.waiting >= q.maxWaiting {
+		q.mx.Unlock()
+		return nil, ErrQueueFull
+	}
+	q.waiting++
+	q.mx.Unlock()
+
+	defer func() {
+		q.mx.Lock()
+		q.waiting--
+		q.mx.Unlock()
+	}()
+
+	if q.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, q.timeout)
+		defer cancel()
+	}
+
+	select {
+	case q.slots <- struct{}{}:
+		return release, nil
+	case <-ctx.Done():
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return nil, ErrQueueTimeout
+		}
+		return nil, ctx.Err()
+	}
+}
@@ -0,0 +1,55 @@
+package ratelimit
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestQueue_Acquire(t *testing.T) {
+	queue := NewQueue(1, 1, time.Second)
+
+	release, err := queue.Acquire(context.Background())
+	require.NoError(t, err)
+
+	// вторая операция ждет в очереди, пока первая не освободит место
+	acquired := make(chan struct{})
+	go func() {
+		release, err := queue.Acquire(context.Background())
+		if assert.NoError(t, err) {
+			release()
+		}
+		close(acquired)
+	}()
+
+	require.Eventually(t, func() bool {
+		queue.mx.Lock()
+		defer queue.mx.Unlock()
+		return queue.waiting == 1
+	}, time.Second, time.Millisecond)
+
+	// очередь заполнена
+	_, err = queue.Acquire(context.Background())
+	assert.ErrorIs(t, err, ErrQueueFull)
+
+	release()
+	<-acquired
+}
+
+func TestQueue_Timeout(t *testing.T) {
+	queue := NewQueue(1, 1, 10*time.Millisecond)
+
+	release, err := queue.Acquire(context.Background())
+	require.NoError(t, err)
+	defer release()
+
+	_, err = queue.Acquire(context.Background())
+	assert.ErrorIs(t, err, ErrQueueTimeout)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = queue.Acquire(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+}
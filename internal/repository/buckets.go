@@ -0,0 +1,24 @@
+package repository
+
+import "github.com/shamank/warehouse-service/internal/domain/models"
+
+// BucketOrigin - партии и ячейки, из которых взят товар при перемещении между корзинами остатка.
+// Остальное количество - товар без партии и вне ячеек
+type BucketOrigin struct {
+	Lots []LotQuantity
+	Bins []BinAllocation
+}
+
+// AppendProductStock добавляет к stocks строку остатков товара на складе с непродаваемой корзиной bucket.
+// Строки одного товара и склада идут подряд и различаются только корзиной, пустой bucket - корзин у товара нет
+func AppendProductStock(stocks []models.ProductStock, stock models.ProductStock, bucket string, quantity int) []models.ProductStock {
+	if n := len(stocks); n > 0 && stocks[n-1].ProductArticle == stock.ProductArticle && stocks[n-1].WarehouseUUID == stock.WarehouseUUID {
+		stocks[n-1].Buckets[bucket] = quantity
+		return stocks
+	}
+
+	if bucket != "" {
+		stock.Buckets = map[string]int{bucket: quantity}
+	}
+	return append(stocks, stock)
+}
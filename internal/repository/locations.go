@@ -0,0 +1,28 @@
+package repository
+
+// BinAllocation - количество товара, взятое из ячейки
+type BinAllocation struct {
+	LocationUUID string
+	Path         string
+	Quantity     int
+}
+
+// AllocateBins берет count штук из ячеек в порядке bins: хранилища упорядочивают их по пути, то есть в порядке обхода.
+// Возвращает, сколько взято из каждой ячейки, и остаток, который приходится на товар вне ячеек
+func AllocateBins(bins []BinAllocation, count int) ([]BinAllocation, int) {
+	allocations := make([]BinAllocation, 0, len(bins))
+	for _, bin := range bins {
+		if count == 0 {
+			break
+		}
+
+		taken := min(bin.Quantity, count)
+		if taken <= 0 {
+			continue
+		}
+		allocations = append(allocations, BinAllocation{LocationUUID: bin.LocationUUID, Path: bin.Path, Quantity: taken})
+		count -= taken
+	}
+
+	return allocations, count
+}
@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"database/sql"
+	"github.com/shamank/warehouse-service/internal/domain/models"
+	"time"
+)
+
+// LotQuantity - количество товара в партии
+type LotQuantity struct {
+	LotNumber string
+	Quantity  int
+}
+
+// AllocateLots берет count штук из партий в порядке lots: хранилища упорядочивают их по сроку годности (FEFO).
+// Возвращает, сколько взято из каждой партии, и остаток, который приходится на товар без партии
+func AllocateLots(lots []LotQuantity, count int) ([]LotQuantity, int) {
+	allocations := make([]LotQuantity, 0, len(lots))
+	for _, lot := range lots {
+		if count == 0 {
+			break
+		}
+
+		taken := min(lot.Quantity, count)
+		if taken <= 0 {
+			continue
+		}
+		allocations = append(allocations, LotQuantity{LotNumber: lot.LotNumber, Quantity: taken})
+		count -= taken
+	}
+
+	return allocations, count
+}
+
+// Today возвращает текущую дату в UTC в виде YYYY-MM-DD для сравнения со сроком годности в запросах.
+// Партия просрочена, если ее срок годности раньше этой даты
+func Today() string {
+	return time.Now().UTC().Format(time.DateOnly)
+}
+
+// FormatDate переводит дату партии в YYYY-MM-DD для записи в колонку date, nil - NULL
+func FormatDate(date *time.Time) any {
+	if date == nil {
+		return nil
+	}
+	return date.Format(time.DateOnly)
+}
+
+// ScannedDate возвращает дату партии, прочитанную из колонки date, nil - NULL
+func ScannedDate(date sql.NullTime) *time.Time {
+	if !date.Valid {
+		return nil
+	}
+	value := date.Time.UTC()
+	return &value
+}
+
+// LotsEqual сообщает, совпадают ли даты партий с одинаковым номером
+func LotsEqual(a, b models.Lot) bool {
+	return equalDates(a.ManufacturedAt, b.ManufacturedAt) && equalDates(a.ExpiresAt, b.ExpiresAt)
+}
+
+func equalDates(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return a.Format(time.DateOnly) == b.Format(time.DateOnly)
+}
+
+// ExpiredQuantitySQL - подзапрос свободного остатка в просроченных партиях для строки wp из warehouse_products.
+// todayArg - плейсхолдер параметра с датой из Today
+func ExpiredQuantitySQL(todayArg string) string {
+	return `(SELECT COALESCE(SUM(wl.quantity), 0) FROM warehouse_lots wl
+				INNER JOIN lots l ON l.product_uuid = wl.product_uuid AND l.lot_number = wl.lot_number
+				WHERE wl.warehouse_uuid = wp.warehouse_uuid AND wl.product_uuid = wp.product_uuid
+					AND l.expires_at < ` + todayArg + `)`
+}
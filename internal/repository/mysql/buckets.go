@@ -0,0 +1,255 @@
+package mysql
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/shamank/warehouse-service/internal/domain/models"
+	"github.com/shamank/warehouse-service/internal/repository"
+	"strings"
+)
+
+// GetBucketsStock возвращает непродаваемые остатки товаров по корзинам складов. Пустые корзины пропускаются.
+// Пустой список articles или warehouseUUIDs означает отсутствие фильтра по нему
+func (r *MySQLRepo) GetBucketsStock(articles []string, warehouseUUIDs []string) ([]models.BucketStock, error) {
+	query := `SELECT p.article, sb.warehouse_uuid, sb.bucket, sb.quantity
+				FROM stock_buckets sb
+					INNER JOIN products p ON p.uuid = sb.product_uuid`
+
+	conditions := []string{"sb.quantity > 0"}
+	args := make([]any, 0, len(articles)+len(warehouseUUIDs))
+
+	if len(articles) > 0 {
+		conditions = append(conditions, "p.article IN ("+placeholders(len(articles))+")")
+		for _, article := range articles {
+			args = append(args, article)
+		}
+	}
+	if len(warehouseUUIDs) > 0 {
+		conditions = append(conditions, "sb.warehouse_uuid IN ("+placeholders(len(warehouseUUIDs))+")")
+		for _, warehouseUUID := range warehouseUUIDs {
+			args = append(args, warehouseUUID)
+		}
+	}
+	query += " WHERE " + strings.Join(conditions, " AND ") + " ORDER BY p.article, sb.warehouse_uuid, sb.bucket"
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		r.logger.Error("error getting buckets stock", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	stocks := make([]models.BucketStock, 0)
+
+	for rows.Next() {
+		var stock models.BucketStock
+		if err := rows.Scan(&stock.ProductArticle, &stock.WarehouseUUID, &stock.Bucket, &stock.Quantity); err != nil {
+			r.logger.Error("error scanning buckets stock", "error", err)
+			return nil, err
+		}
+		stocks = append(stocks, stock)
+	}
+
+	return stocks, rows.Err()
+}
+
+// MoveBucketStock перемещает товар между корзинами склада в одной транзакции. Товар переходит вместе с партиями
+// и ячейками: из продаваемой корзины он уходит как списание свободного остатка, в продаваемую возвращается
+// в те партии и ячейки, из которых ушел. Если в корзине товара меньше, чем перемещается, возвращается
+// models.ErrNotEnoughProducts
+func (r *MySQLRepo) MoveBucketStock(warehouseUUID string, moves []models.BucketMove) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	for _, move := range moves {
+		if err := r.moveBucketStock(tx, warehouseUUID, move); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *MySQLRepo) moveBucketStock(tx *sql.Tx, warehouseUUID string, move models.BucketMove) error {
+	var productUUID string
+	err := tx.QueryRow(`SELECT uuid FROM products WHERE article = ?`, move.ProductArticle).Scan(&productUUID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%w: %s", models.ErrProductNotFound, move.ProductArticle)
+	}
+	if err != nil {
+		r.logger.Error("error getting product", "error", err)
+		return err
+	}
+
+	var origin repository.BucketOrigin
+	if move.From == models.BucketSellable {
+		origin, err = r.writeOffFree(tx, move.ProductArticle, warehouseUUID, move.Quantity)
+	} else {
+		origin, err = r.takeFromBucket(tx, warehouseUUID, productUUID, move.ProductArticle, move.From, move.Quantity)
+	}
+	if err != nil {
+		return err
+	}
+
+	if move.To == models.BucketSellable {
+		return r.returnToSellable(tx, warehouseUUID, move.ProductArticle, move.Quantity, origin)
+	}
+
+	return r.addToBucket(tx, warehouseUUID, productUUID, move.To, move.Quantity, origin)
+}
+
+// addToBucket добавляет товар в корзину непродаваемого остатка склада вместе с партиями и ячейками origin.
+// Товар в корзине всегда есть и в warehouse_products, пусть и с нулевым остатком, чтобы попадать в отчеты об остатках
+func (r *MySQLRepo) addToBucket(tx *sql.Tx, warehouseUUID string, productUUID string, bucket string, quantity int, origin repository.BucketOrigin) error {
+	_, err := tx.Exec(`INSERT INTO warehouse_products (warehouse_uuid, product_uuid, quantity, reserved_quantity) VALUES (?, ?, 0, 0)
+				ON DUPLICATE KEY UPDATE quantity = quantity + VALUES(quantity)`,
+		warehouseUUID, productUUID)
+	if err != nil {
+		r.logger.Error("error adding warehouse product", "error", err)
+		return err
+	}
+
+	_, err = tx.Exec(`INSERT INTO stock_buckets (warehouse_uuid, product_uuid, bucket, quantity) VALUES (?, ?, ?, ?)
+				ON DUPLICATE KEY UPDATE quantity = quantity + VALUES(quantity)`,
+		warehouseUUID, productUUID, bucket, quantity)
+	if err != nil {
+		r.logger.Error("error adding to stock bucket", "error", err)
+		return err
+	}
+
+	for _, lot := range origin.Lots {
+		_, err := tx.Exec(`INSERT INTO stock_bucket_lots (warehouse_uuid, product_uuid, bucket, lot_number, quantity) VALUES (?, ?, ?, ?, ?)
+				ON DUPLICATE KEY UPDATE quantity = quantity + VALUES(quantity)`,
+			warehouseUUID, productUUID, bucket, lot.LotNumber, lot.Quantity)
+		if err != nil {
+			r.logger.Error("error adding lot to stock bucket", "error", err)
+			return err
+		}
+	}
+
+	for _, bin := range origin.Bins {
+		_, err := tx.Exec(`INSERT INTO stock_bucket_bins (warehouse_uuid, product_uuid, bucket, location_uuid, quantity) VALUES (?, ?, ?, ?, ?)
+				ON DUPLICATE KEY UPDATE quantity = quantity + VALUES(quantity)`,
+			warehouseUUID, productUUID, bucket, bin.LocationUUID, bin.Quantity)
+		if err != nil {
+			r.logger.Error("error adding bin to stock bucket", "error", err)
+			return err
+		}
+	}
+
+	return nil
+}
+
+// takeFromBucket забирает товар из корзины непродаваемого остатка склада так же, как writeOffFree со склада:
+// сначала без партии и вне ячеек, затем партии в порядке FEFO и ячейки в обратном порядке обхода.
+// Возвращает партии и ячейки, из которых взят товар
+func (r *MySQLRepo) takeFromBucket(tx *sql.Tx, warehouseUUID string, productUUID string, productArticle string, bucket string, quantity int) (repository.BucketOrigin, error) {
+	result, err := tx.Exec(`UPDATE stock_buckets SET quantity = quantity - ?
+				WHERE warehouse_uuid = ? AND product_uuid = ? AND bucket = ? AND quantity >= ?`,
+		quantity, warehouseUUID, productUUID, bucket, quantity)
+	if err != nil {
+		r.logger.Error("error taking from stock bucket", "error", err)
+		return repository.BucketOrigin{}, err
+	}
+	if rows, err := result.RowsAffected(); err != nil || rows == 0 {
+		return repository.BucketOrigin{}, errors.Join(fmt.Errorf("%w: %s in %s", models.ErrNotEnoughProducts, productArticle, bucket), err)
+	}
+
+	var total, inLots, inBins int
+	err = tx.QueryRow(`SELECT sb.quantity,
+					(SELECT COALESCE(SUM(sbl.quantity), 0) FROM stock_bucket_lots sbl
+						WHERE sbl.warehouse_uuid = sb.warehouse_uuid AND sbl.product_uuid = sb.product_uuid AND sbl.bucket = sb.bucket),
+					(SELECT COALESCE(SUM(sbb.quantity), 0) FROM stock_bucket_bins sbb
+						WHERE sbb.warehouse_uuid = sb.warehouse_uuid AND sbb.product_uuid = sb.product_uuid AND sbb.bucket = sb.bucket)
+				FROM stock_buckets sb
+				WHERE sb.warehouse_uuid = ? AND sb.product_uuid = ? AND sb.bucket = ?`,
+		warehouseUUID, productUUID, bucket).Scan(&total, &inLots, &inBins)
+	if err != nil {
+		r.logger.Error("error getting stock bucket lots and bins", "error", err)
+		return repository.BucketOrigin{}, err
+	}
+
+	var origin repository.BucketOrigin
+
+	// в total уже нет взятого количества, а в inLots и inBins оно еще есть
+	if fromLots := inLots - total; fromLots > 0 {
+		rows, err := tx.Query(`SELECT sbl.lot_number, sbl.quantity FROM stock_bucket_lots sbl
+					INNER JOIN lots l ON l.product_uuid = sbl.product_uuid AND l.lot_number = sbl.lot_number
+				WHERE sbl.warehouse_uuid = ? AND sbl.product_uuid = ? AND sbl.bucket = ? AND sbl.quantity > 0
+				ORDER BY l.expires_at IS NULL, l.expires_at, sbl.lot_number`, warehouseUUID, productUUID, bucket)
+		if err != nil {
+			r.logger.Error("error getting stock bucket lots", "error", err)
+			return repository.BucketOrigin{}, err
+		}
+		lots, err := scanLotQuantities(rows)
+		if err != nil {
+			r.logger.Error("error scanning stock bucket lots", "error", err)
+			return repository.BucketOrigin{}, err
+		}
+
+		origin.Lots, _ = repository.AllocateLots(lots, fromLots)
+		for _, lot := range origin.Lots {
+			_, err := tx.Exec(`UPDATE stock_bucket_lots SET quantity = quantity - ?
+						WHERE warehouse_uuid = ? AND product_uuid = ? AND bucket = ? AND lot_number = ?`,
+				lot.Quantity, warehouseUUID, productUUID, bucket, lot.LotNumber)
+			if err != nil {
+				r.logger.Error("error taking lot from stock bucket", "error", err)
+				return repository.BucketOrigin{}, err
+			}
+		}
+	}
+
+	if fromBins := inBins - total; fromBins > 0 {
+		rows, err := tx.Query(`SELECT sbb.location_uuid, l.path, sbb.quantity FROM stock_bucket_bins sbb
+					INNER JOIN locations l ON l.uuid = sbb.location_uuid
+				WHERE sbb.warehouse_uuid = ? AND sbb.product_uuid = ? AND sbb.bucket = ? AND sbb.quantity > 0
+				ORDER BY l.path DESC`, warehouseUUID, productUUID, bucket)
+		if err != nil {
+			r.logger.Error("error getting stock bucket bins", "error", err)
+			return repository.BucketOrigin{}, err
+		}
+		bins, err := scanBinAllocations(rows)
+		if err != nil {
+			r.logger.Error("error scanning stock bucket bins", "error", err)
+			return repository.BucketOrigin{}, err
+		}
+
+		origin.Bins, _ = repository.AllocateBins(bins, fromBins)
+		for _, bin := range origin.Bins {
+			_, err := tx.Exec(`UPDATE stock_bucket_bins SET quantity = quantity - ?
+						WHERE warehouse_uuid = ? AND product_uuid = ? AND bucket = ? AND location_uuid = ?`,
+				bin.Quantity, warehouseUUID, productUUID, bucket, bin.LocationUUID)
+			if err != nil {
+				r.logger.Error("error taking bin from stock bucket", "error", err)
+				return repository.BucketOrigin{}, err
+			}
+		}
+	}
+
+	return origin, nil
+}
+
+// returnToSellable возвращает товар из корзины в свободный остаток склада, в партии и ячейки origin
+func (r *MySQLRepo) returnToSellable(tx *sql.Tx, warehouseUUID string, productArticle string, quantity int, origin repository.BucketOrigin) error {
+	if err := r.updateProductQuantities(tx, productArticle, warehouseUUID, quantity, 0); err != nil {
+		return err
+	}
+
+	for _, lot := range origin.Lots {
+		if err := r.updateLotQuantities(tx, productArticle, warehouseUUID, lot.LotNumber, lot.Quantity, 0); err != nil {
+			return err
+		}
+	}
+
+	for _, bin := range origin.Bins {
+		if err := r.updateBinQuantities(tx, productArticle, bin.LocationUUID, bin.Quantity, 0); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,108 @@
+package mysql
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/shamank/warehouse-service/internal/domain/models"
+)
+
+// SaveKit задает состав набора, заменяя прежний. Набор и компоненты должны быть в каталоге, иначе models.ErrProductNotFound
+func (r *MySQLRepo) SaveKit(kit models.Kit) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	var kitUUID string
+	err = tx.QueryRow(`SELECT uuid FROM products WHERE article = ?`, kit.ProductArticle).Scan(&kitUUID)
+	if errors.Is(err, sql.ErrNoRows) {
+		tx.Rollback()
+		return fmt.Errorf("%w: %s", models.ErrProductNotFound, kit.ProductArticle)
+	}
+	if err != nil {
+		tx.Rollback()
+		r.logger.Error("error getting kit product", "error", err)
+		return err
+	}
+
+	if _, err := tx.Exec(`DELETE FROM kit_components WHERE kit_uuid = ?`, kitUUID); err != nil {
+		tx.Rollback()
+		r.logger.Error("error deleting kit components", "error", err)
+		return err
+	}
+
+	for _, component := range kit.Components {
+		result, err := tx.Exec(`INSERT INTO kit_components (kit_uuid, component_uuid, quantity)
+					SELECT ?, uuid, ? FROM products WHERE article = ?`, kitUUID, component.Quantity, component.ProductArticle)
+		if err != nil {
+			tx.Rollback()
+			r.logger.Error("error creating kit component", "error", err)
+			return err
+		}
+		if rows, err := result.RowsAffected(); err != nil || rows == 0 {
+			tx.Rollback()
+			return errors.Join(fmt.Errorf("%w: %s", models.ErrProductNotFound, component.ProductArticle), err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetKits возвращает наборы с компонентами по артикулам наборов. Артикулы, которые не наборы, пропускаются,
+// пустой список articles возвращает все наборы
+func (r *MySQLRepo) GetKits(articles []string) ([]models.Kit, error) {
+	query := `SELECT k.article, k.name, k.size, c.article, kc.quantity
+				FROM kit_components kc
+					INNER JOIN products k ON k.uuid = kc.kit_uuid
+					INNER JOIN products c ON c.uuid = kc.component_uuid`
+
+	args := make([]any, 0, len(articles))
+	if len(articles) > 0 {
+		query += " WHERE k.article IN (" + placeholders(len(articles)) + ")"
+		for _, article := range articles {
+			args = append(args, article)
+		}
+	}
+	query += " ORDER BY k.article, c.article"
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		r.logger.Error("error getting kits", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	kits := make([]models.Kit, 0)
+
+	for rows.Next() {
+		var kit models.Kit
+		var component models.KitComponent
+		err := rows.Scan(&kit.ProductArticle, &kit.ProductName, &kit.ProductSize, &component.ProductArticle, &component.Quantity)
+		if err != nil {
+			r.logger.Error("error scanning kits", "error", err)
+			return nil, err
+		}
+
+		if len(kits) == 0 || kits[len(kits)-1].ProductArticle != kit.ProductArticle {
+			kits = append(kits, kit)
+		}
+		kits[len(kits)-1].Components = append(kits[len(kits)-1].Components, component)
+	}
+
+	return kits, rows.Err()
+}
+
+// DeleteKit удаляет состав набора, артикул остается в каталоге. Если артикул не набор, возвращается models.ErrKitNotFound
+func (r *MySQLRepo) DeleteKit(article string) error {
+	result, err := r.db.Exec(`DELETE FROM kit_components WHERE kit_uuid = (SELECT uuid FROM products WHERE article = ?)`, article)
+	if err != nil {
+		r.logger.Error("error deleting kit", "error", err)
+		return err
+	}
+	if rows, err := result.RowsAffected(); err != nil || rows == 0 {
+		return errors.Join(fmt.Errorf("%w: %s", models.ErrKitNotFound, article), err)
+	}
+
+	return nil
+}
@@ -0,0 +1,166 @@
+package mysql
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/go-sql-driver/mysql"
+	"github.com/shamank/warehouse-service/internal/config"
+	"github.com/shamank/warehouse-service/internal/database"
+	"github.com/shamank/warehouse-service/internal/repository/repotest"
+	"github.com/stretchr/testify/require"
+	"log/slog"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+)
+
+const migrationsPath = "../../../migrations/mysql"
+
+// serverConfig - сервер MySQL, на котором выполняются тесты.
+// nil, если сервер недоступен и тесты нужно пропустить
+var serverConfig *mysql.Config
+
+var databaseCounter atomic.Int64
+
+// TestMain поднимает сервер для тестов.
+// Если задан MYSQL_TEST_DSN, используется существующий сервер (пользователь должен иметь право создавать базы).
+// Иначе запускается локальный mysqld из PATH во временном каталоге, а если его нет - тесты пропускаются
+func TestMain(m *testing.M) {
+	os.Exit(run(m))
+}
+
+func run(m *testing.M) int {
+	if dsn := os.Getenv("MYSQL_TEST_DSN"); dsn != "" {
+		cfg, err := mysql.ParseDSN(dsn)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "invalid MYSQL_TEST_DSN:", err)
+			return 1
+		}
+		serverConfig = cfg
+		return m.Run()
+	}
+
+	if _, err := exec.LookPath("mysqld"); err != nil {
+		return m.Run()
+	}
+
+	cfg, stop, err := startMySQL()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "cannot start mysqld:", err)
+		return 1
+	}
+	defer stop()
+
+	serverConfig = cfg
+	return m.Run()
+}
+
+// startMySQL запускает mysqld во временном каталоге и ждет, пока он начнет принимать соединения
+func startMySQL() (*mysql.Config, func(), error) {
+	dir, err := os.MkdirTemp("", "warehouse-mysql")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	currentUser, err := user.Current()
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, nil, err
+	}
+
+	dataDir := filepath.Join(dir, "data")
+	socket := filepath.Join(dir, "mysqld.sock")
+
+	initialize := exec.Command("mysqld", "--no-defaults", "--initialize-insecure",
+		"--datadir="+dataDir, "--user="+currentUser.Username)
+	if output, err := initialize.CombinedOutput(); err != nil {
+		os.RemoveAll(dir)
+		return nil, nil, fmt.Errorf("%w: %s", err, output)
+	}
+
+	server := exec.Command("mysqld", "--no-defaults", "--datadir="+dataDir, "--socket="+socket,
+		"--skip-networking", "--mysqlx=OFF", "--user="+currentUser.Username)
+	if err := server.Start(); err != nil {
+		os.RemoveAll(dir)
+		return nil, nil, err
+	}
+
+	stop := func() {
+		server.Process.Signal(syscall.SIGTERM)
+		server.Wait()
+		os.RemoveAll(dir)
+	}
+
+	cfg := mysql.NewConfig()
+	cfg.User = "root"
+	cfg.Net = "unix"
+	cfg.Addr = socket
+
+	if err := waitForServer(cfg, 30*time.Second); err != nil {
+		stop()
+		return nil, nil, err
+	}
+
+	return cfg, stop, nil
+}
+
+func waitForServer(cfg *mysql.Config, timeout time.Duration) error {
+	db, err := sql.Open("mysql", cfg.FormatDSN())
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	deadline := time.Now().Add(timeout)
+	for {
+		err := db.Ping()
+		if err == nil {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return errors.Join(errors.New("mysqld is not ready"), err)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+// newDatabase создает отдельную базу с примененными миграциями и удаляет ее после теста
+func newDatabase(t *testing.T) *sql.DB {
+	t.Helper()
+
+	admin, err := sql.Open("mysql", serverConfig.FormatDSN())
+	require.NoError(t, err)
+	t.Cleanup(func() { admin.Close() })
+
+	name := fmt.Sprintf("warehouse_test_%d_%d", os.Getpid(), databaseCounter.Add(1))
+	_, err = admin.Exec("CREATE DATABASE " + name)
+	require.NoError(t, err)
+	t.Cleanup(func() { admin.Exec("DROP DATABASE " + name) })
+
+	cfg := serverConfig.Clone()
+	cfg.DBName = name
+
+	db, err := database.OpenMySQL(cfg)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	require.NoError(t, database.Migrate(db, config.StorageMySQL, migrationsPath))
+
+	return db
+}
+
+func TestMySQLRepo(t *testing.T) {
+	if serverConfig == nil {
+		t.Skip("mysqld not found in PATH and MYSQL_TEST_DSN is not set")
+	}
+
+	repotest.Run(t, func(t *testing.T) repotest.Repository {
+		return NewMySQLRepo(newDatabase(t), slog.Default())
+	})
+}
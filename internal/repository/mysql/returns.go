@@ -0,0 +1,147 @@
+package mysql
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/shamank/warehouse-service/internal/domain/models"
+	"github.com/shamank/warehouse-service/internal/repository"
+	"time"
+)
+
+const returnsQuery = `SELECT pr.uuid, pr.warehouse_uuid, p.article, pr.quantity, pr.reference, pr.reason, pr.status,
+					COALESCE(pr.outcome, ''), pr.comment, pr.created_by, pr.created_at, COALESCE(pr.received_by, ''), pr.received_at,
+					COALESCE(pr.inspected_by, ''), pr.inspected_at
+				FROM product_returns pr
+					INNER JOIN products p ON p.uuid = pr.product_uuid`
+
+// CreateReturn сохраняет возврат. Остаток не меняется, пока возврат не осмотрен
+func (r *MySQLRepo) CreateReturn(ret models.Return) error {
+	result, err := r.db.Exec(`INSERT INTO product_returns (uuid, warehouse_uuid, product_uuid, quantity, reference, reason, status,
+					created_by, created_at)
+				SELECT ?, ?, uuid, ?, ?, ?, ?, ?, ? FROM products WHERE article = ?`,
+		ret.UUID, ret.WarehouseUUID, ret.Quantity, ret.Reference, ret.Reason, ret.Status, ret.CreatedBy, ret.CreatedAt, ret.ProductArticle)
+	if err != nil {
+		r.logger.Error("error creating return", "error", err)
+		return err
+	}
+	if rows, err := result.RowsAffected(); err != nil || rows == 0 {
+		return errors.Join(fmt.Errorf("%w: %s", models.ErrProductNotFound, ret.ProductArticle), err)
+	}
+
+	return nil
+}
+
+// GetReturn возвращает возврат или models.ErrReturnNotFound
+func (r *MySQLRepo) GetReturn(returnUUID string) (models.Return, error) {
+	ret, err := scanReturn(r.db.QueryRow(returnsQuery+" WHERE pr.uuid = ?", returnUUID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Return{}, models.ErrReturnNotFound
+	}
+	if err != nil {
+		r.logger.Error("error getting return", "error", err)
+		return models.Return{}, err
+	}
+
+	return ret, nil
+}
+
+// ReceiveReturn отмечает, что возврат принят на склад. Возврат не в статусе models.ReturnCreated - models.ErrReturnStatus
+func (r *MySQLRepo) ReceiveReturn(returnUUID string, receivedBy string, receivedAt time.Time) error {
+	result, err := r.db.Exec("UPDATE product_returns SET status = ?, received_by = ?, received_at = ? WHERE uuid = ? AND status = ?",
+		models.ReturnReceived, repository.NullString(receivedBy), receivedAt, returnUUID, models.ReturnCreated)
+	if err != nil {
+		r.logger.Error("error receiving return", "error", err)
+		return err
+	}
+	if rows, err := result.RowsAffected(); err != nil || rows == 0 {
+		return errors.Join(r.returnStatusError(returnUUID), err)
+	}
+
+	return nil
+}
+
+// InspectReturn сохраняет результат осмотра принятого возврата и в той же транзакции возвращает товар в свободный
+// остаток (models.ReturnRestock) или в корзину models.BucketQuarantine (models.ReturnQuarantine).
+// Утилизированный товар (models.ReturnScrap) только учитывается. Возврат не в статусе models.ReturnReceived -
+// models.ErrReturnStatus
+func (r *MySQLRepo) InspectReturn(returnUUID string, outcome string, comment string, inspectedBy string, inspectedAt time.Time) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	result, err := tx.Exec(`UPDATE product_returns SET status = ?, outcome = ?, comment = ?, inspected_by = ?, inspected_at = ?
+				WHERE uuid = ? AND status = ?`,
+		models.ReturnInspected, outcome, comment, repository.NullString(inspectedBy), inspectedAt, returnUUID, models.ReturnReceived)
+	if err != nil {
+		tx.Rollback()
+		r.logger.Error("error inspecting return", "error", err)
+		return err
+	}
+	if rows, err := result.RowsAffected(); err != nil || rows == 0 {
+		tx.Rollback()
+		return errors.Join(r.returnStatusError(returnUUID), err)
+	}
+
+	var warehouseUUID, productUUID, productArticle string
+	var quantity int
+	err = tx.QueryRow(`SELECT pr.warehouse_uuid, pr.product_uuid, p.article, pr.quantity FROM product_returns pr
+					INNER JOIN products p ON p.uuid = pr.product_uuid
+				WHERE pr.uuid = ?`, returnUUID).Scan(&warehouseUUID, &productUUID, &productArticle, &quantity)
+	if err != nil {
+		tx.Rollback()
+		r.logger.Error("error getting return", "error", err)
+		return err
+	}
+
+	switch outcome {
+	case models.ReturnRestock:
+		err = r.receiveProduct(tx, warehouseUUID, models.ReceiptItem{ProductArticle: productArticle, Quantity: quantity})
+	case models.ReturnQuarantine:
+		err = r.addToBucket(tx, warehouseUUID, productUUID, models.BucketQuarantine, quantity, repository.BucketOrigin{})
+	}
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// returnStatusError возвращает models.ErrReturnNotFound или models.ErrReturnStatus с текущим статусом возврата
+func (r *MySQLRepo) returnStatusError(returnUUID string) error {
+	var status string
+	err := r.db.QueryRow("SELECT status FROM product_returns WHERE uuid = ?", returnUUID).Scan(&status)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.ErrReturnNotFound
+	}
+	if err != nil {
+		r.logger.Error("error getting return", "error", err)
+		return err
+	}
+
+	return fmt.Errorf("%w: %s is %s", models.ErrReturnStatus, returnUUID, status)
+}
+
+func scanReturn(row interface{ Scan(dest ...any) error }) (models.Return, error) {
+	var ret models.Return
+	var receivedAt, inspectedAt sql.NullTime
+	err := row.Scan(&ret.UUID, &ret.WarehouseUUID, &ret.ProductArticle, &ret.Quantity, &ret.Reference, &ret.Reason, &ret.Status,
+		&ret.Outcome, &ret.Comment, &ret.CreatedBy, &ret.CreatedAt, &ret.ReceivedBy, &receivedAt, &ret.InspectedBy, &inspectedAt)
+	if err != nil {
+		return models.Return{}, err
+	}
+
+	ret.CreatedAt = ret.CreatedAt.UTC()
+	if receivedAt.Valid {
+		at := receivedAt.Time.UTC()
+		ret.ReceivedAt = &at
+	}
+	if inspectedAt.Valid {
+		at := inspectedAt.Time.UTC()
+		ret.InspectedAt = &at
+	}
+
+	return ret, nil
+}
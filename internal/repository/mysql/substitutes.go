@@ -0,0 +1,81 @@
+package mysql
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/shamank/warehouse-service/internal/domain/models"
+)
+
+// SaveSubstitutes задает замены товара, заменяя прежние; порядок substitutes - порядок, в котором замены пробуются.
+// Товар и замены должны быть в каталоге, иначе models.ErrProductNotFound
+func (r *MySQLRepo) SaveSubstitutes(article string, substitutes []models.Substitute) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	var productUUID string
+	err = tx.QueryRow(`SELECT uuid FROM products WHERE article = ?`, article).Scan(&productUUID)
+	if errors.Is(err, sql.ErrNoRows) {
+		tx.Rollback()
+		return fmt.Errorf("%w: %s", models.ErrProductNotFound, article)
+	}
+	if err != nil {
+		tx.Rollback()
+		r.logger.Error("error getting product", "error", err)
+		return err
+	}
+
+	if _, err := tx.Exec(`DELETE FROM product_substitutes WHERE product_uuid = ?`, productUUID); err != nil {
+		tx.Rollback()
+		r.logger.Error("error deleting product substitutes", "error", err)
+		return err
+	}
+
+	for i, substitute := range substitutes {
+		result, err := tx.Exec(`INSERT INTO product_substitutes (product_uuid, substitute_uuid, priority, same_size)
+					SELECT ?, uuid, ?, ? FROM products WHERE article = ?`, productUUID, i+1, substitute.SameSize, substitute.ProductArticle)
+		if err != nil {
+			tx.Rollback()
+			r.logger.Error("error creating product substitute", "error", err)
+			return err
+		}
+		if rows, err := result.RowsAffected(); err != nil || rows == 0 {
+			tx.Rollback()
+			return errors.Join(fmt.Errorf("%w: %s", models.ErrProductNotFound, substitute.ProductArticle), err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetSubstitutes возвращает замены товара в порядке, в котором они пробуются. Товар без замен - пустой список
+func (r *MySQLRepo) GetSubstitutes(article string) ([]models.Substitute, error) {
+	query := `SELECT s.article, s.name, s.size, ps.same_size
+				FROM product_substitutes ps
+					INNER JOIN products p ON p.uuid = ps.product_uuid
+					INNER JOIN products s ON s.uuid = ps.substitute_uuid
+				WHERE p.article = ?
+				ORDER BY ps.priority`
+
+	rows, err := r.db.Query(query, article)
+	if err != nil {
+		r.logger.Error("error getting product substitutes", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	substitutes := make([]models.Substitute, 0)
+
+	for rows.Next() {
+		var substitute models.Substitute
+		if err := rows.Scan(&substitute.ProductArticle, &substitute.ProductName, &substitute.ProductSize, &substitute.SameSize); err != nil {
+			r.logger.Error("error scanning product substitutes", "error", err)
+			return nil, err
+		}
+		substitutes = append(substitutes, substitute)
+	}
+
+	return substitutes, rows.Err()
+}
@@ -0,0 +1,355 @@
+package postgres
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/lib/pq"
+	"github.com/shamank/warehouse-service/internal/domain/models"
+	"github.com/shamank/warehouse-service/internal/repository"
+	"strings"
+)
+
+// колонки warehouse_lots и location_stock, из которых берется товар: свободный остаток при резервировании и резерв при отгрузке
+const (
+	lotsFree     = "quantity"
+	lotsReserved = "reserved_quantity"
+)
+
+// ReceiveProducts принимает товар на склад. Партия создается при первом поступлении,
+// для существующей партии даты должны совпадать, иначе возвращается models.ErrLotMismatch.
+// Серийный номер, который уже есть на складе, возвращает models.ErrSerialExists
+func (r *PostgresRepo) ReceiveProducts(warehouseUUID string, items []models.ReceiptItem) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	for _, item := range items {
+		if err := r.receiveProduct(tx, warehouseUUID, item); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *PostgresRepo) receiveProduct(tx *sql.Tx, warehouseUUID string, item models.ReceiptItem) error {
+	var productUUID string
+	err := tx.QueryRow("SELECT uuid FROM products WHERE article = $1", item.ProductArticle).Scan(&productUUID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%w: %s", models.ErrProductNotFound, item.ProductArticle)
+	}
+	if err != nil {
+		r.logger.Error("error getting product", "error", err)
+		return err
+	}
+
+	_, err = tx.Exec(`INSERT INTO warehouse_products (warehouse_uuid, product_uuid, quantity, reserved_quantity) VALUES ($1, $2, $3, 0)
+				ON CONFLICT (warehouse_uuid, product_uuid) DO UPDATE SET quantity = warehouse_products.quantity + excluded.quantity`,
+		warehouseUUID, productUUID, item.Quantity)
+	if err != nil {
+		r.logger.Error("error receiving products", "error", err)
+		return err
+	}
+
+	if err := r.receiveSerials(tx, productUUID, item, warehouseUUID); err != nil {
+		return err
+	}
+
+	if item.BinPath != "" {
+		if err := r.placeInBin(tx, productUUID, warehouseUUID, item.BinPath, item.Quantity); err != nil {
+			return err
+		}
+	}
+
+	if item.Lot == nil {
+		return nil
+	}
+
+	var manufacturedAt, expiresAt sql.NullTime
+	err = tx.QueryRow("SELECT manufactured_at, expires_at FROM lots WHERE product_uuid = $1 AND lot_number = $2", productUUID, item.Lot.Number).
+		Scan(&manufacturedAt, &expiresAt)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		_, err = tx.Exec("INSERT INTO lots (product_uuid, lot_number, manufactured_at, expires_at) VALUES ($1, $2, $3, $4)",
+			productUUID, item.Lot.Number, repository.FormatDate(item.Lot.ManufacturedAt), repository.FormatDate(item.Lot.ExpiresAt))
+		if err != nil {
+			r.logger.Error("error creating lot", "error", err)
+			return err
+		}
+	case err != nil:
+		r.logger.Error("error getting lot", "error", err)
+		return err
+	default:
+		existing := models.Lot{ManufacturedAt: repository.ScannedDate(manufacturedAt), ExpiresAt: repository.ScannedDate(expiresAt)}
+		if !repository.LotsEqual(existing, *item.Lot) {
+			return fmt.Errorf("%w: %s %s", models.ErrLotMismatch, item.ProductArticle, item.Lot.Number)
+		}
+	}
+
+	_, err = tx.Exec(`INSERT INTO warehouse_lots (warehouse_uuid, product_uuid, lot_number, quantity, reserved_quantity) VALUES ($1, $2, $3, $4, 0)
+				ON CONFLICT (warehouse_uuid, product_uuid, lot_number) DO UPDATE SET quantity = warehouse_lots.quantity + excluded.quantity`,
+		warehouseUUID, productUUID, item.Lot.Number, item.Quantity)
+	if err != nil {
+		r.logger.Error("error receiving lot", "error", err)
+		return err
+	}
+
+	return nil
+}
+
+// GetLotsStock возвращает остатки партий товаров на складе в порядке FEFO. Партии без остатка и резерва пропускаются
+func (r *PostgresRepo) GetLotsStock(warehouseUUID string, articles []string) ([]models.LotStock, error) {
+	if len(articles) == 0 {
+		return []models.LotStock{}, nil
+	}
+
+	query := `SELECT p.article, l.lot_number, l.manufactured_at, l.expires_at, wl.quantity, wl.reserved_quantity
+				FROM warehouse_lots wl
+					INNER JOIN products p ON p.uuid = wl.product_uuid
+					INNER JOIN lots l ON l.product_uuid = wl.product_uuid AND l.lot_number = wl.lot_number
+				WHERE wl.warehouse_uuid = $1 AND p.article = ANY($2::varchar[])
+					AND wl.quantity + wl.reserved_quantity > 0
+				ORDER BY p.article, l.expires_at IS NULL, l.expires_at, l.lot_number`
+
+	rows, err := r.db.Query(query, warehouseUUID, pq.Array(articles))
+	if err != nil {
+		r.logger.Error("error getting lots stock", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	stocks := make([]models.LotStock, 0)
+
+	for rows.Next() {
+		var stock models.LotStock
+		var manufacturedAt, expiresAt sql.NullTime
+		err := rows.Scan(&stock.ProductArticle, &stock.Lot.Number, &manufacturedAt, &expiresAt, &stock.Quantity, &stock.ReservedQuantity)
+		if err != nil {
+			r.logger.Error("error scanning lots stock", "error", err)
+			return nil, err
+		}
+		stock.Lot.ManufacturedAt = repository.ScannedDate(manufacturedAt)
+		stock.Lot.ExpiresAt = repository.ScannedDate(expiresAt)
+		stocks = append(stocks, stock)
+	}
+
+	return stocks, rows.Err()
+}
+
+// writeOffLots списывает свободный товар из партий, если списанное не покрывает товар без партии,
+// в порядке FEFO, начиная с просроченных партий, и возвращает, сколько взято из каждой партии.
+// Вызывается после updateProductQuantities
+func (r *PostgresRepo) writeOffLots(tx *sql.Tx, productArticle string, warehouseUUID string) ([]repository.LotQuantity, error) {
+	var total, inLots int
+	err := tx.QueryRow(`SELECT wp.quantity, (SELECT COALESCE(SUM(wl.quantity), 0) FROM warehouse_lots wl
+					WHERE wl.warehouse_uuid = wp.warehouse_uuid AND wl.product_uuid = wp.product_uuid)
+				FROM warehouse_products wp
+					INNER JOIN products p ON p.uuid = wp.product_uuid
+				WHERE p.article = $1 AND wp.warehouse_uuid = $2`, productArticle, warehouseUUID).Scan(&total, &inLots)
+	if err != nil {
+		r.logger.Error("error getting lots quantity", "error", err)
+		return nil, err
+	}
+
+	// в total уже нет списанного количества, а в inLots оно еще есть
+	fromLots := inLots - total
+	if fromLots <= 0 {
+		return nil, nil
+	}
+
+	rows, err := tx.Query(`SELECT wl.lot_number, wl.quantity FROM warehouse_lots wl
+					INNER JOIN products p ON p.uuid = wl.product_uuid
+					INNER JOIN lots l ON l.product_uuid = wl.product_uuid AND l.lot_number = wl.lot_number
+				WHERE p.article = $1 AND wl.warehouse_uuid = $2 AND wl.quantity > 0
+				ORDER BY l.expires_at IS NULL, l.expires_at, wl.lot_number`, productArticle, warehouseUUID)
+	if err != nil {
+		r.logger.Error("error getting lots", "error", err)
+		return nil, err
+	}
+	lots, err := scanLotQuantities(rows)
+	if err != nil {
+		r.logger.Error("error scanning lots", "error", err)
+		return nil, err
+	}
+
+	allocations, _ := repository.AllocateLots(lots, fromLots)
+	for _, allocation := range allocations {
+		if err := r.updateLotQuantities(tx, productArticle, warehouseUUID, allocation.LotNumber, -allocation.Quantity, 0); err != nil {
+			return nil, err
+		}
+	}
+
+	return allocations, nil
+}
+
+// takeFromLots списывает count штук товара из колонки column партий на складе в порядке FEFO
+// и переносит их в резерв (lotsFree) или отгружает (lotsReserved). Просроченные партии не резервируются.
+// Вызывается после updateProductQuantities: то, что не взято из партий, приходится на товар без партии,
+// и его должно хватить, иначе возвращается models.ErrNotEnoughProducts
+func (r *PostgresRepo) takeFromLots(tx *sql.Tx, productArticle string, warehouseUUID string, count int, column string) ([]repository.LotQuantity, error) {
+	var total, inLots int
+	err := tx.QueryRow(`SELECT wp.`+column+`, (SELECT COALESCE(SUM(wl.`+column+`), 0) FROM warehouse_lots wl
+					WHERE wl.warehouse_uuid = wp.warehouse_uuid AND wl.product_uuid = wp.product_uuid)
+				FROM warehouse_products wp
+					INNER JOIN products p ON p.uuid = wp.product_uuid
+				WHERE p.article = $1 AND wp.warehouse_uuid = $2`, productArticle, warehouseUUID).Scan(&total, &inLots)
+	if err != nil {
+		r.logger.Error("error getting lots quantity", "error", err)
+		return nil, err
+	}
+
+	args := []any{productArticle, warehouseUUID}
+	conditions := []string{"p.article = $1", "wl.warehouse_uuid = $2", "wl." + column + " > 0"}
+	if column == lotsFree {
+		conditions = append(conditions, "(l.expires_at IS NULL OR l.expires_at >= $3)")
+		args = append(args, repository.Today())
+	}
+
+	rows, err := tx.Query(`SELECT wl.lot_number, wl.`+column+` FROM warehouse_lots wl
+					INNER JOIN products p ON p.uuid = wl.product_uuid
+					INNER JOIN lots l ON l.product_uuid = wl.product_uuid AND l.lot_number = wl.lot_number
+				WHERE `+strings.Join(conditions, " AND ")+`
+				ORDER BY l.expires_at IS NULL, l.expires_at, wl.lot_number`, args...)
+	if err != nil {
+		r.logger.Error("error getting lots", "error", err)
+		return nil, err
+	}
+	lots, err := scanLotQuantities(rows)
+	if err != nil {
+		r.logger.Error("error scanning lots", "error", err)
+		return nil, err
+	}
+
+	allocations, untracked := repository.AllocateLots(lots, count)
+	// в total уже нет списанного количества, а в inLots оно еще есть
+	if total-(inLots-(count-untracked)) < 0 {
+		return nil, models.ErrNotEnoughProducts
+	}
+
+	for _, allocation := range allocations {
+		quantityDelta, reservedDelta := -allocation.Quantity, allocation.Quantity
+		if column == lotsReserved {
+			quantityDelta, reservedDelta = 0, -allocation.Quantity
+		}
+		if err := r.updateLotQuantities(tx, productArticle, warehouseUUID, allocation.LotNumber, quantityDelta, reservedDelta); err != nil {
+			return nil, err
+		}
+	}
+
+	return allocations, nil
+}
+
+// createReservationLots запоминает партии, из которых собрана позиция резерва
+func (r *PostgresRepo) createReservationLots(tx *sql.Tx, reservationUUID string, productArticle string, warehouseUUID string, allocations []repository.LotQuantity) error {
+	query := `INSERT INTO reservation_item_lots (reservation_uuid, product_uuid, warehouse_uuid, lot_number, quantity)
+				SELECT $1, uuid, $2, $3, $4 FROM products WHERE article = $5
+				ON CONFLICT (reservation_uuid, product_uuid, warehouse_uuid, lot_number) DO UPDATE SET quantity = reservation_item_lots.quantity + excluded.quantity`
+
+	for _, allocation := range allocations {
+		if _, err := tx.Exec(query, reservationUUID, warehouseUUID, allocation.LotNumber, allocation.Quantity, productArticle); err != nil {
+			r.logger.Error("error creating reservation lots", "error", err)
+			return err
+		}
+	}
+
+	return nil
+}
+
+// releaseReservationLots возвращает count штук позиции резерва в партии, из которых они были взяты,
+// или с writeOff списывает их из резерва партий, не возвращая в остаток.
+// Первым возвращается товар без партии, он резервируется последним, затем партии в порядке, обратном FEFO.
+// Вызывается до обновления released_quantity или short_quantity позиции
+func (r *PostgresRepo) releaseReservationLots(tx *sql.Tx, reservationUUID string, productArticle string, warehouseUUID string, count int, writeOff bool) error {
+	var remaining, inLots int
+	err := tx.QueryRow(`SELECT ri.quantity - ri.released_quantity - ri.short_quantity,
+					(SELECT COALESCE(SUM(ril.quantity - ril.released_quantity), 0) FROM reservation_item_lots ril
+						WHERE ril.reservation_uuid = ri.reservation_uuid AND ril.product_uuid = ri.product_uuid
+							AND ril.warehouse_uuid = ri.warehouse_uuid)
+				FROM reservation_items ri
+					INNER JOIN products p ON p.uuid = ri.product_uuid
+				WHERE ri.reservation_uuid = $1 AND p.article = $2 AND ri.warehouse_uuid = $3`,
+		reservationUUID, productArticle, warehouseUUID).Scan(&remaining, &inLots)
+	if errors.Is(err, sql.ErrNoRows) {
+		// отсутствие позиции обнаружит обновление released_quantity
+		return nil
+	}
+	if err != nil {
+		r.logger.Error("error getting reservation lots quantity", "error", err)
+		return err
+	}
+
+	fromLots := count - (remaining - inLots)
+	if fromLots <= 0 {
+		return nil
+	}
+
+	rows, err := tx.Query(`SELECT ril.lot_number, ril.quantity - ril.released_quantity FROM reservation_item_lots ril
+					INNER JOIN products p ON p.uuid = ril.product_uuid
+					INNER JOIN lots l ON l.product_uuid = ril.product_uuid AND l.lot_number = ril.lot_number
+				WHERE ril.reservation_uuid = $1 AND p.article = $2 AND ril.warehouse_uuid = $3
+					AND ril.quantity > ril.released_quantity
+				ORDER BY l.expires_at IS NULL DESC, l.expires_at DESC, ril.lot_number DESC`,
+		reservationUUID, productArticle, warehouseUUID)
+	if err != nil {
+		r.logger.Error("error getting reservation lots", "error", err)
+		return err
+	}
+	lots, err := scanLotQuantities(rows)
+	if err != nil {
+		r.logger.Error("error scanning reservation lots", "error", err)
+		return err
+	}
+
+	allocations, _ := repository.AllocateLots(lots, fromLots)
+
+	query := `UPDATE reservation_item_lots SET released_quantity = released_quantity + $1
+				WHERE reservation_uuid = $2 AND warehouse_uuid = $3 AND lot_number = $4
+					AND product_uuid = (SELECT uuid FROM products WHERE article = $5)`
+
+	for _, allocation := range allocations {
+		quantityDelta := allocation.Quantity
+		if writeOff {
+			quantityDelta = 0
+		}
+		if _, err := tx.Exec(query, allocation.Quantity, reservationUUID, warehouseUUID, allocation.LotNumber, productArticle); err != nil {
+			r.logger.Error("error releasing reservation lots", "error", err)
+			return err
+		}
+		if err := r.updateLotQuantities(tx, productArticle, warehouseUUID, allocation.LotNumber, quantityDelta, -allocation.Quantity); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *PostgresRepo) updateLotQuantities(tx *sql.Tx, productArticle string, warehouseUUID string, lotNumber string, quantityDelta int, reservedQuantityDelta int) error {
+	query := `UPDATE warehouse_lots
+				SET quantity = quantity + $1, reserved_quantity = reserved_quantity + $2
+				WHERE product_uuid = (SELECT uuid FROM products WHERE article = $3) AND warehouse_uuid = $4 AND lot_number = $5`
+
+	if _, err := tx.Exec(query, quantityDelta, reservedQuantityDelta, productArticle, warehouseUUID, lotNumber); err != nil {
+		r.logger.Error("error occurred while updating lots", "error", err)
+		return err
+	}
+
+	return nil
+}
+
+func scanLotQuantities(rows *sql.Rows) ([]repository.LotQuantity, error) {
+	defer rows.Close()
+
+	lots := make([]repository.LotQuantity, 0)
+	for rows.Next() {
+		var lot repository.LotQuantity
+		if err := rows.Scan(&lot.LotNumber, &lot.Quantity); err != nil {
+			return nil, err
+		}
+		lots = append(lots, lot)
+	}
+
+	return lots, rows.Err()
+}
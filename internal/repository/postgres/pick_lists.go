@@ -0,0 +1,261 @@
+package postgres
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/shamank/warehouse-service/internal/domain/models"
+	"github.com/shamank/warehouse-service/internal/domain/schemas"
+	"github.com/shamank/warehouse-service/internal/repository"
+	"time"
+)
+
+// CreatePickList сохраняет лист отбора со строками. Если резерв уже есть в открытом листе отбора,
+// возвращается models.ErrReservationPicking
+func (r *PostgresRepo) CreatePickList(pickList models.PickList) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	checked := make(map[string]bool)
+	for _, line := range pickList.Lines {
+		if checked[line.ReservationUUID] {
+			continue
+		}
+		checked[line.ReservationUUID] = true
+
+		var picking int
+		err := tx.QueryRow(`SELECT COUNT(*) FROM pick_list_lines pll
+						INNER JOIN pick_lists pl ON pl.uuid = pll.pick_list_uuid
+					WHERE pll.reservation_uuid = $1 AND pl.status = $2`, line.ReservationUUID, models.PickListOpen).Scan(&picking)
+		if err != nil {
+			tx.Rollback()
+			r.logger.Error("error getting pick lists of reservation", "error", err)
+			return err
+		}
+		if picking > 0 {
+			tx.Rollback()
+			return fmt.Errorf("%w: %s", models.ErrReservationPicking, line.ReservationUUID)
+		}
+	}
+
+	_, err = tx.Exec("INSERT INTO pick_lists (uuid, status, created_at, updated_at) VALUES ($1, $2, $3, $4)",
+		pickList.UUID, pickList.Status, pickList.CreatedAt, pickList.UpdatedAt)
+	if err != nil {
+		tx.Rollback()
+		r.logger.Error("error creating pick list", "error", err)
+		return err
+	}
+
+	query := `INSERT INTO pick_list_lines (pick_list_uuid, line_no, reservation_uuid, product_uuid, warehouse_uuid, location_uuid, quantity, status)
+				SELECT $1, $2, $3, uuid, $4, $5, $6, $7 FROM products WHERE article = $8`
+
+	for _, line := range pickList.Lines {
+		var locationUUID string
+		if line.LocationPath != "" {
+			locationUUID, err = r.binUUID(tx, line.WarehouseUUID, line.LocationPath)
+			if err != nil {
+				tx.Rollback()
+				return err
+			}
+		}
+
+		_, err := tx.Exec(query, pickList.UUID, line.LineNo, line.ReservationUUID, line.WarehouseUUID, repository.NullString(locationUUID),
+			line.Quantity, line.Status, line.ProductArticle)
+		if err != nil {
+			tx.Rollback()
+			r.logger.Error("error creating pick list lines", "error", err)
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetPickList возвращает лист отбора со строками в порядке обхода или models.ErrPickListNotFound
+func (r *PostgresRepo) GetPickList(pickListUUID string) (models.PickList, error) {
+	var pickList models.PickList
+
+	err := r.db.QueryRow("SELECT uuid, status, created_at, updated_at FROM pick_lists WHERE uuid = $1", pickListUUID).
+		Scan(&pickList.UUID, &pickList.Status, &pickList.CreatedAt, &pickList.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.PickList{}, models.ErrPickListNotFound
+	}
+	if err != nil {
+		r.logger.Error("error getting pick list", "error", err)
+		return models.PickList{}, err
+	}
+	pickList.CreatedAt = pickList.CreatedAt.UTC()
+	pickList.UpdatedAt = pickList.UpdatedAt.UTC()
+
+	query := `SELECT pll.line_no, pll.reservation_uuid, p.article, pll.warehouse_uuid, COALESCE(l.path, ''),
+					pll.quantity, pll.picked_quantity, pll.status
+				FROM pick_list_lines pll
+					INNER JOIN products p ON p.uuid = pll.product_uuid
+					LEFT JOIN locations l ON l.uuid = pll.location_uuid
+				WHERE pll.pick_list_uuid = $1
+				ORDER BY pll.line_no`
+
+	rows, err := r.db.Query(query, pickListUUID)
+	if err != nil {
+		r.logger.Error("error getting pick list lines", "error", err)
+		return models.PickList{}, err
+	}
+	defer rows.Close()
+
+	pickList.Lines = make([]models.PickLine, 0)
+
+	for rows.Next() {
+		var line models.PickLine
+		err := rows.Scan(&line.LineNo, &line.ReservationUUID, &line.ProductArticle, &line.WarehouseUUID, &line.LocationPath,
+			&line.Quantity, &line.PickedQuantity, &line.Status)
+		if err != nil {
+			r.logger.Error("error scanning pick list lines", "error", err)
+			return models.PickList{}, err
+		}
+		pickList.Lines = append(pickList.Lines, line)
+	}
+
+	return pickList, rows.Err()
+}
+
+// ConfirmPickLine отмечает, сколько товара собрано по строке листа отбора. Недостача списывается со склада
+// и из резерва, затем резервируется на складах из reallocation; то, что не удалось зарезервировать,
+// отмечается в позиции резерва как невыполненное. Когда подтверждены все строки, лист отбора закрывается
+func (r *PostgresRepo) ConfirmPickLine(pickListUUID string, lineNo int, pickedQuantity int, reallocation []schemas.WarehouseCounter) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	var reservationUUID, productArticle, warehouseUUID string
+	var locationUUID sql.NullString
+	var quantity int
+	err = tx.QueryRow(`SELECT pll.reservation_uuid, p.article, pll.warehouse_uuid, pll.location_uuid, pll.quantity
+				FROM pick_list_lines pll
+					INNER JOIN products p ON p.uuid = pll.product_uuid
+				WHERE pll.pick_list_uuid = $1 AND pll.line_no = $2`, pickListUUID, lineNo).
+		Scan(&reservationUUID, &productArticle, &warehouseUUID, &locationUUID, &quantity)
+	if errors.Is(err, sql.ErrNoRows) {
+		tx.Rollback()
+		return fmt.Errorf("%w: line %d", models.ErrPickListNotFound, lineNo)
+	}
+	if err != nil {
+		tx.Rollback()
+		r.logger.Error("error getting pick list line", "error", err)
+		return err
+	}
+
+	short := quantity - pickedQuantity
+	status := models.PickLinePicked
+	if short > 0 {
+		status = models.PickLineShort
+	}
+
+	result, err := tx.Exec(`UPDATE pick_list_lines SET picked_quantity = $1, status = $2
+				WHERE pick_list_uuid = $3 AND line_no = $4 AND status = $5`,
+		pickedQuantity, status, pickListUUID, lineNo, models.PickLinePending)
+	if err != nil {
+		tx.Rollback()
+		r.logger.Error("error confirming pick list line", "error", err)
+		return err
+	}
+	if rows, err := result.RowsAffected(); err != nil || rows == 0 {
+		tx.Rollback()
+		return errors.Join(fmt.Errorf("%w: line %d", models.ErrPickLineConfirmed, lineNo), err)
+	}
+
+	if short > 0 {
+		err := r.shortPick(tx, reservationUUID, productArticle, warehouseUUID, locationUUID.String, short, reallocation)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	var pending int
+	err = tx.QueryRow("SELECT COUNT(*) FROM pick_list_lines WHERE pick_list_uuid = $1 AND status = $2",
+		pickListUUID, models.PickLinePending).Scan(&pending)
+	if err != nil {
+		tx.Rollback()
+		r.logger.Error("error getting pick list lines", "error", err)
+		return err
+	}
+
+	pickListStatus := models.PickListOpen
+	if pending == 0 {
+		pickListStatus = models.PickListCompleted
+	}
+
+	_, err = tx.Exec("UPDATE pick_lists SET status = $1, updated_at = $2 WHERE uuid = $3", pickListStatus, time.Now().UTC(), pickListUUID)
+	if err != nil {
+		tx.Rollback()
+		r.logger.Error("error updating pick list", "error", err)
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// shortPick списывает со склада и из позиции резерва count штук, которых не нашли в ячейке locationUUID,
+// и резервирует их на складах из reallocation
+func (r *PostgresRepo) shortPick(tx *sql.Tx, reservationUUID string, productArticle string, warehouseUUID string, locationUUID string, count int, reallocation []schemas.WarehouseCounter) error {
+	var (
+		remaining     int
+		substituteFor string
+	)
+	err := tx.QueryRow(`SELECT ri.quantity - ri.released_quantity - ri.short_quantity, COALESCE(s.article, '')
+				FROM reservation_items ri
+					INNER JOIN products p ON p.uuid = ri.product_uuid
+					LEFT JOIN products s ON s.uuid = ri.substitute_for
+				WHERE ri.reservation_uuid = $1 AND p.article = $2 AND ri.warehouse_uuid = $3`,
+		reservationUUID, productArticle, warehouseUUID).Scan(&remaining, &substituteFor)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		r.logger.Error("error getting reservation item", "error", err)
+		return err
+	}
+	if remaining < count {
+		return fmt.Errorf("%w: %s", models.ErrNotEnoughReserved, productArticle)
+	}
+
+	if err := r.updateProductQuantities(tx, productArticle, warehouseUUID, 0, -count); err != nil {
+		return err
+	}
+
+	if err := r.releaseReservationLots(tx, reservationUUID, productArticle, warehouseUUID, count, true); err != nil {
+		return err
+	}
+
+	if _, err := r.moveSerials(tx, productArticle, warehouseUUID, count, repository.LoseSerials(reservationUUID)); err != nil {
+		return err
+	}
+
+	if err := r.shortReservationBin(tx, reservationUUID, productArticle, warehouseUUID, locationUUID, count); err != nil {
+		return err
+	}
+
+	unfulfilled := count
+	for _, warehouseData := range reallocation {
+		if err := r.reserveItem(tx, reservationUUID, productArticle, substituteFor, warehouseData); err != nil {
+			return err
+		}
+		unfulfilled -= warehouseData.Count
+	}
+
+	_, err = tx.Exec(`UPDATE reservation_items SET short_quantity = short_quantity + $1, unfulfilled_quantity = unfulfilled_quantity + $2
+				WHERE reservation_uuid = $3 AND warehouse_uuid = $4 AND product_uuid = (SELECT uuid FROM products WHERE article = $5)`,
+		count, unfulfilled, reservationUUID, warehouseUUID, productArticle)
+	if err != nil {
+		r.logger.Error("error shorting reservation items", "error", err)
+		return err
+	}
+
+	_, err = tx.Exec("UPDATE reservations SET updated_at = $1 WHERE uuid = $2", time.Now().UTC(), reservationUUID)
+	if err != nil {
+		r.logger.Error("error updating reservation", "error", err)
+		return err
+	}
+
+	return nil
+}
@@ -2,11 +2,16 @@ package postgres
 
 import (
 	"database/sql"
+	"errors"
+	"github.com/lib/pq"
 	"github.com/shamank/warehouse-service/internal/domain/models"
 	"github.com/shamank/warehouse-service/internal/domain/schemas"
 	"github.com/shamank/warehouse-service/internal/repository"
 	"github.com/shamank/warehouse-service/internal/service"
 	"log/slog"
+	"strconv"
+	"strings"
+	"time"
 )
 
 var _ service.Repository = (*PostgresRepo)(nil)
@@ -23,14 +28,15 @@ func NewPostgresRepo(db *sql.DB, logger *slog.Logger) *PostgresRepo {
 	}
 }
 
+// GetProductsQuantity возвращает остатки товара на доступных складах. Товар в просроченных партиях не учитывается
 func (r *PostgresRepo) GetProductsQuantity(productArticle string) ([]models.WarehouseProduct, error) {
 
-	query := `select wp.warehouse_uuid, wp.quantity, wp.reserved_quantity from warehouse_products wp
+	query := `select wp.warehouse_uuid, wp.quantity - ` + repository.ExpiredQuantitySQL("$2") + `, wp.reserved_quantity from warehouse_products wp
     			inner join products p on wp.product_uuid = p.uuid
                 inner join warehouses w on wp.warehouse_uuid = w.uuid
         			where p.article = $1 and w.is_available = true`
 
-	rows, err := r.db.Query(query, productArticle)
+	rows, err := r.db.Query(query, productArticle, repository.Today())
 	if err != nil {
 		return nil, err
 	}
@@ -42,7 +48,7 @@ func (r *PostgresRepo) GetProductsQuantity(productArticle string) ([]models.Ware
 		var warehouseProduct models.WarehouseProduct
 		err := rows.Scan(&warehouseProduct.WarehouseUUID, &warehouseProduct.Quantity, &warehouseProduct.ReservedQuantity)
 		if err != nil {
-			r.logger.Error("error scanning warehouse products", err)
+			r.logger.Error("error scanning warehouse products", "error", err)
 			return nil, err
 		}
 		productByWarehouses = append(productByWarehouses, warehouseProduct)
@@ -50,38 +56,186 @@ func (r *PostgresRepo) GetProductsQuantity(productArticle string) ([]models.Ware
 	return productByWarehouses, nil
 }
 
-func (r *PostgresRepo) GetRemainingProductsByWarehouse(warehouseUUID string) ([]models.Product, error) {
+// колонки, по которым разрешена сортировка остатков. Товар без названия сортируется как с пустым,
+// иначе сравнение с курсором отбрасывало бы его со всех страниц
+var sortColumns = map[string]string{
+	schemas.SortByArticle:  "p.article",
+	schemas.SortByName:     "COALESCE(p.name, '')",
+	schemas.SortByQuantity: "wp.quantity",
+}
 
-	products := make([]models.Product, 0)
+// GetRemainingProductsByWarehouse возвращает страницу остатков на складе и число всех товаров, подходящих под фильтр.
+// Значения фильтра передаются только параметрами, а имена колонок берутся из sortColumns
+func (r *PostgresRepo) GetRemainingProductsByWarehouse(warehouseUUID string, filter schemas.RemainingProductsFilter) ([]models.Product, int, error) {
 
-	query := `SELECT p.name, p.size, p.article, sum(wp.quantity)
-					FROM warehouse_products wp
-					    INNER JOIN products p on p.uuid = wp.product_uuid
-                           WHERE wp.warehouse_uuid = $1
-                            GROUP BY p.name, p.size, p.article`
+	args := []any{warehouseUUID}
+	arg := func(value any) string {
+		args = append(args, value)
+		return "$" + strconv.Itoa(len(args))
+	}
 
-	rows, err := r.db.Query(query, warehouseUUID)
+	conditions := []string{"wp.warehouse_uuid = $1"}
+	if filter.ArticlePrefix != "" {
+		conditions = append(conditions, "p.article LIKE "+arg(repository.EscapeLike(filter.ArticlePrefix)+"%")+" ESCAPE '"+repository.LikeEscape+"'")
+	}
+	if filter.Name != "" {
+		conditions = append(conditions, "p.name ILIKE "+arg("%"+repository.EscapeLike(filter.Name)+"%")+" ESCAPE '"+repository.LikeEscape+"'")
+	}
+	if filter.Size != "" {
+		conditions = append(conditions, "p.size = "+arg(filter.Size))
+	}
+	if filter.MinQuantity > 0 {
+		conditions = append(conditions, "wp.quantity >= "+arg(filter.MinQuantity))
+	}
+	if filter.InStock {
+		conditions = append(conditions, "wp.quantity > 0")
+	}
+	if !filter.IncludeZeroStock {
+		conditions = append(conditions, `(wp.quantity + wp.reserved_quantity > 0 OR EXISTS (SELECT 1 FROM stock_buckets sb
+				WHERE sb.warehouse_uuid = wp.warehouse_uuid AND sb.product_uuid = wp.product_uuid AND sb.quantity > 0))`)
+	}
+
+	from := ` FROM warehouse_products wp
+					INNER JOIN products p ON p.uuid = wp.product_uuid`
+
+	var total int
+	err := r.db.QueryRow("SELECT count(*)"+from+" WHERE "+strings.Join(conditions, " AND "), args...).Scan(&total)
 	if err != nil {
-		r.logger.Error("error scanning warehouse products", err)
-		return nil, err
+		r.logger.Error("error counting warehouse products", "error", err)
+		return nil, 0, err
+	}
+
+	sortColumn, ok := sortColumns[filter.Sort]
+	if !ok {
+		sortColumn = sortColumns[schemas.SortByArticle]
+	}
+	direction, compare := "ASC", ">"
+	if filter.Desc {
+		direction, compare = "DESC", "<"
+	}
+
+	if filter.After != nil {
+		switch filter.Sort {
+		case schemas.SortByName:
+			conditions = append(conditions, "(COALESCE(p.name, ''), p.article) "+compare+" ("+arg(filter.After.Name)+", "+arg(filter.After.Article)+")")
+		case schemas.SortByQuantity:
+			conditions = append(conditions, "(wp.quantity, p.article) "+compare+" ("+arg(filter.After.Quantity)+", "+arg(filter.After.Article)+")")
+		default:
+			conditions = append(conditions, "p.article "+compare+" "+arg(filter.After.Article))
+		}
+	}
+
+	query := "SELECT COALESCE(p.name, ''), p.size, p.article, wp.quantity, wp.reserved_quantity, " + repository.ExpiredQuantitySQL(arg(repository.Today())) + from +
+		" WHERE " + strings.Join(conditions, " AND ") +
+		" ORDER BY " + sortColumn + " " + direction + ", p.article " + direction
+	if filter.Limit > 0 {
+		query += " LIMIT " + arg(filter.Limit)
 	}
 
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		r.logger.Error("error getting warehouse products", "error", err)
+		return nil, 0, err
+	}
 	defer rows.Close()
+
+	products := make([]models.Product, 0)
+
 	for rows.Next() {
 		var product models.Product
 
-		err := rows.Scan(&product.Name, &product.Size, &product.Code, &product.Quantity)
+		err := rows.Scan(&product.Name, &product.Size, &product.Code, &product.Quantity, &product.ReservedQuantity, &product.ExpiredQuantity)
 		if err != nil {
-			r.logger.Error("error scanning warehouse products", err)
+			r.logger.Error("error scanning warehouse products", "error", err)
+			return nil, 0, err
+		}
+		products = append(products, product)
+	}
+
+	return products, total, rows.Err()
+}
+
+// GetProductsByArticles возвращает товары с указанными артикулами, неизвестные артикулы пропускаются
+func (r *PostgresRepo) GetProductsByArticles(articles []string) ([]models.Product, error) {
+	rows, err := r.db.Query("SELECT uuid, name, size, article, serialized FROM products WHERE article = ANY($1::varchar[]) ORDER BY article", pq.Array(articles))
+	if err != nil {
+		r.logger.Error("error getting products", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	products := make([]models.Product, 0, len(articles))
+
+	for rows.Next() {
+		var product models.Product
+		if err := rows.Scan(&product.UUID, &product.Name, &product.Size, &product.Code, &product.Serialized); err != nil {
+			r.logger.Error("error scanning products", "error", err)
 			return nil, err
 		}
 		products = append(products, product)
 	}
 
-	return products, nil
+	return products, rows.Err()
+}
+
+// GetWarehouse возвращает склад по uuid или models.ErrWarehouseNotFound
+func (r *PostgresRepo) GetWarehouse(warehouseUUID string) (models.Warehouse, error) {
+	var warehouse models.Warehouse
+
+	err := r.db.QueryRow("SELECT uuid, name, is_available FROM warehouses WHERE uuid = $1", warehouseUUID).
+		Scan(&warehouse.UUID, &warehouse.Name, &warehouse.Availability)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Warehouse{}, models.ErrWarehouseNotFound
+	}
+	if err != nil {
+		r.logger.Error("error getting warehouse", "error", err)
+		return models.Warehouse{}, err
+	}
+
+	return warehouse, nil
+}
+
+// GetProductsStock возвращает остатки товаров по складам вместе с непродаваемыми корзинами за один запрос.
+// Пустой список articles или warehouseUUIDs означает отсутствие фильтра по нему
+func (r *PostgresRepo) GetProductsStock(articles []string, warehouseUUIDs []string) ([]models.ProductStock, error) {
+
+	query := `SELECT p.article, p.name, p.size, w.uuid, w.is_available, wp.quantity, wp.reserved_quantity, ` + repository.ExpiredQuantitySQL("$3") + `,
+					COALESCE(sb.bucket, ''), COALESCE(sb.quantity, 0)
+				FROM warehouse_products wp
+					INNER JOIN products p ON p.uuid = wp.product_uuid
+					INNER JOIN warehouses w ON w.uuid = wp.warehouse_uuid
+					LEFT JOIN stock_buckets sb ON sb.warehouse_uuid = wp.warehouse_uuid AND sb.product_uuid = wp.product_uuid
+						AND sb.quantity > 0
+				WHERE (cardinality($1::varchar[]) = 0 OR p.article = ANY($1::varchar[]))
+					AND (cardinality($2::uuid[]) = 0 OR wp.warehouse_uuid = ANY($2::uuid[]))
+				ORDER BY p.article, w.uuid, sb.bucket`
+
+	rows, err := r.db.Query(query, pq.Array(articles), pq.Array(warehouseUUIDs), repository.Today())
+	if err != nil {
+		r.logger.Error("error getting products stock", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	stocks := make([]models.ProductStock, 0)
 
+	for rows.Next() {
+		var stock models.ProductStock
+		var bucket string
+		var bucketQuantity int
+		err := rows.Scan(&stock.ProductArticle, &stock.ProductName, &stock.ProductSize, &stock.WarehouseUUID,
+			&stock.WarehouseAvailability, &stock.Quantity, &stock.ReservedQuantity, &stock.ExpiredQuantity, &bucket, &bucketQuantity)
+		if err != nil {
+			r.logger.Error("error scanning products stock", "error", err)
+			return nil, err
+		}
+		stocks = repository.AppendProductStock(stocks, stock, bucket, bucketQuantity)
+	}
+
+	return stocks, rows.Err()
 }
 
+// ReserveProducts резервирует товары, внутри склада партии берутся в порядке FEFO, ячейки - в порядке обхода
 func (r *PostgresRepo) ReserveProducts(products []schemas.ProductWarehouseSplitted) error {
 	tx, err := r.db.Begin()
 	if err != nil {
@@ -96,6 +250,22 @@ func (r *PostgresRepo) ReserveProducts(products []schemas.ProductWarehouseSplitt
 				tx.Rollback()
 				return err
 			}
+
+			if _, err := r.takeFromLots(tx, product.ProductArticle, warehouseData.WarehouseUUID, warehouseData.Count, lotsFree); err != nil {
+				tx.Rollback()
+				return err
+			}
+
+			_, err = r.moveSerials(tx, product.ProductArticle, warehouseData.WarehouseUUID, warehouseData.Count, repository.ReserveSerials(""))
+			if err != nil {
+				tx.Rollback()
+				return err
+			}
+
+			if _, err := r.takeFromBins(tx, product.ProductArticle, warehouseData.WarehouseUUID, warehouseData.Count, lotsFree); err != nil {
+				tx.Rollback()
+				return err
+			}
 		}
 	}
 
@@ -103,6 +273,7 @@ func (r *PostgresRepo) ReserveProducts(products []schemas.ProductWarehouseSplitt
 
 }
 
+// ReleaseProducts списывает товары из резерва, внутри склада партии отгружаются в порядке FEFO, ячейки - в порядке обхода
 func (r *PostgresRepo) ReleaseProducts(productsWithSplit []schemas.ProductWarehouseSplitted) error {
 	tx, err := r.db.Begin()
 	if err != nil {
@@ -117,11 +288,221 @@ func (r *PostgresRepo) ReleaseProducts(productsWithSplit []schemas.ProductWareho
 				tx.Rollback()
 				return err
 			}
+
+			if _, err := r.takeFromLots(tx, product.ProductArticle, warehouseData.WarehouseUUID, warehouseData.Count, lotsReserved); err != nil {
+				tx.Rollback()
+				return err
+			}
+
+			_, err = r.moveSerials(tx, product.ProductArticle, warehouseData.WarehouseUUID, warehouseData.Count, repository.ShipSerials())
+			if err != nil {
+				tx.Rollback()
+				return err
+			}
+
+			if _, err := r.takeFromBins(tx, product.ProductArticle, warehouseData.WarehouseUUID, warehouseData.Count, lotsReserved); err != nil {
+				tx.Rollback()
+				return err
+			}
 		}
 	}
 
-	tx.Commit()
-	return nil
+	return tx.Commit()
+}
+
+// CreateReservation резервирует товары и сохраняет резерв с uuid reservationUUID в одной транзакции.
+// Партии и ячейки, из которых взят товар, запоминаются, чтобы ReleaseReservation вернул его туда же
+func (r *PostgresRepo) CreateReservation(reservationUUID string, products []schemas.ProductWarehouseSplitted) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+
+	_, err = tx.Exec("INSERT INTO reservations (uuid, status, created_at, updated_at) VALUES ($1, $2, $3, $4)",
+		reservationUUID, models.ReservationActive, now, now)
+	if err != nil {
+		tx.Rollback()
+		r.logger.Error("error creating reservation", "error", err)
+		return err
+	}
+
+	for _, product := range products {
+		for _, warehouseData := range product.WarehouseData {
+			if err := r.reserveItem(tx, reservationUUID, product.ProductArticle, product.SubstituteFor, warehouseData); err != nil {
+				tx.Rollback()
+				return err
+			}
+		}
+	}
+
+	return tx.Commit()
+}
+
+// reserveItem резервирует товар на складе в позицию резерва v2: остаток, партии, серийные номера и ячейки.
+// Если позиция уже есть, количество добавляется к ней. substituteFor - артикул, вместо которого резервируется товар
+func (r *PostgresRepo) reserveItem(tx *sql.Tx, reservationUUID string, productArticle string, substituteFor string, warehouseData schemas.WarehouseCounter) error {
+	err := r.updateProductQuantities(tx, productArticle, warehouseData.WarehouseUUID, -warehouseData.Count, warehouseData.Count)
+	if err != nil {
+		return err
+	}
+
+	allocations, err := r.takeFromLots(tx, productArticle, warehouseData.WarehouseUUID, warehouseData.Count, lotsFree)
+	if err != nil {
+		return err
+	}
+
+	query := `INSERT INTO reservation_items (reservation_uuid, product_uuid, warehouse_uuid, quantity, substitute_for)
+				SELECT $1, uuid, $2, $3, (SELECT uuid FROM products WHERE article = $4) FROM products WHERE article = $5
+				ON CONFLICT (reservation_uuid, product_uuid, warehouse_uuid) DO UPDATE SET quantity = reservation_items.quantity + excluded.quantity`
+
+	if _, err := tx.Exec(query, reservationUUID, warehouseData.WarehouseUUID, warehouseData.Count, substituteFor, productArticle); err != nil {
+		r.logger.Error("error creating reservation items", "error", err)
+		return err
+	}
+
+	if err := r.createReservationLots(tx, reservationUUID, productArticle, warehouseData.WarehouseUUID, allocations); err != nil {
+		return err
+	}
+
+	_, err = r.moveSerials(tx, productArticle, warehouseData.WarehouseUUID, warehouseData.Count, repository.ReserveSerials(reservationUUID))
+	if err != nil {
+		return err
+	}
+
+	bins, err := r.takeFromBins(tx, productArticle, warehouseData.WarehouseUUID, warehouseData.Count, lotsFree)
+	if err != nil {
+		return err
+	}
+
+	return r.createReservationBins(tx, reservationUUID, productArticle, warehouseData.WarehouseUUID, bins)
+}
+
+// GetReservation возвращает резерв вместе с позициями, серийными номерами и ячейками в резерве или models.ErrReservationNotFound
+func (r *PostgresRepo) GetReservation(reservationUUID string) (models.Reservation, error) {
+	var reservation models.Reservation
+
+	err := r.db.QueryRow("SELECT uuid, status, created_at, updated_at FROM reservations WHERE uuid = $1", reservationUUID).
+		Scan(&reservation.UUID, &reservation.Status, &reservation.CreatedAt, &reservation.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Reservation{}, models.ErrReservationNotFound
+	}
+	if err != nil {
+		r.logger.Error("error getting reservation", "error", err)
+		return models.Reservation{}, err
+	}
+	reservation.CreatedAt = reservation.CreatedAt.UTC()
+	reservation.UpdatedAt = reservation.UpdatedAt.UTC()
+
+	query := `SELECT p.article, ri.warehouse_uuid, ri.quantity, ri.released_quantity, ri.short_quantity, ri.unfulfilled_quantity,
+					COALESCE(s.article, '')
+				FROM reservation_items ri
+					INNER JOIN products p ON p.uuid = ri.product_uuid
+					LEFT JOIN products s ON s.uuid = ri.substitute_for
+				WHERE ri.reservation_uuid = $1
+				ORDER BY p.article, ri.warehouse_uuid`
+
+	rows, err := r.db.Query(query, reservationUUID)
+	if err != nil {
+		r.logger.Error("error getting reservation items", "error", err)
+		return models.Reservation{}, err
+	}
+	defer rows.Close()
+
+	reservation.Items = make([]models.ReservationItem, 0)
+
+	for rows.Next() {
+		var item models.ReservationItem
+		if err := rows.Scan(&item.ProductArticle, &item.WarehouseUUID, &item.Quantity, &item.ReleasedQuantity, &item.ShortQuantity, &item.UnfulfilledQuantity, &item.SubstituteFor); err != nil {
+			r.logger.Error("error scanning reservation items", "error", err)
+			return models.Reservation{}, err
+		}
+		reservation.Items = append(reservation.Items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return models.Reservation{}, err
+	}
+
+	serials, err := r.reservationSerials(reservationUUID)
+	if err != nil {
+		return models.Reservation{}, err
+	}
+	bins, err := r.reservationBins(reservationUUID)
+	if err != nil {
+		return models.Reservation{}, err
+	}
+	for i, item := range reservation.Items {
+		key := [2]string{item.ProductArticle, item.WarehouseUUID}
+		reservation.Items[i].Serials = serials[key]
+		reservation.Items[i].Bins = bins[key]
+	}
+
+	return reservation, nil
+}
+
+// ReleaseReservation возвращает часть резерва на склады и переводит резерв в статус status
+func (r *PostgresRepo) ReleaseReservation(reservationUUID string, products []schemas.ProductWarehouseSplitted, status string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	query := `UPDATE reservation_items SET released_quantity = released_quantity + $1
+				WHERE reservation_uuid = $2 AND warehouse_uuid = $3
+					AND product_uuid = (SELECT uuid FROM products WHERE article = $4)`
+
+	for _, product := range products {
+		for _, warehouseData := range product.WarehouseData {
+			err := r.updateProductQuantities(tx, product.ProductArticle, warehouseData.WarehouseUUID, warehouseData.Count, -warehouseData.Count)
+			if err != nil {
+				tx.Rollback()
+				return err
+			}
+
+			err = r.releaseReservationLots(tx, reservationUUID, product.ProductArticle, warehouseData.WarehouseUUID, warehouseData.Count, false)
+			if err != nil {
+				tx.Rollback()
+				return err
+			}
+
+			_, err = r.moveSerials(tx, product.ProductArticle, warehouseData.WarehouseUUID, warehouseData.Count, repository.ReleaseSerials(reservationUUID))
+			if err != nil {
+				tx.Rollback()
+				return err
+			}
+
+			err = r.releaseReservationBins(tx, reservationUUID, product.ProductArticle, warehouseData.WarehouseUUID, warehouseData.Count)
+			if err != nil {
+				tx.Rollback()
+				return err
+			}
+
+			result, err := tx.Exec(query, warehouseData.Count, reservationUUID, warehouseData.WarehouseUUID, product.ProductArticle)
+			if err != nil {
+				tx.Rollback()
+				r.logger.Error("error releasing reservation items", "error", err)
+				return err
+			}
+			if rows, err := result.RowsAffected(); err != nil || rows == 0 {
+				tx.Rollback()
+				return errors.Join(repository.ErrNoUpdatedProducts, err)
+			}
+		}
+	}
+
+	result, err := tx.Exec("UPDATE reservations SET status = $1, updated_at = $2 WHERE uuid = $3", status, time.Now().UTC(), reservationUUID)
+	if err != nil {
+		tx.Rollback()
+		r.logger.Error("error updating reservation", "error", err)
+		return err
+	}
+	if rows, err := result.RowsAffected(); err != nil || rows == 0 {
+		tx.Rollback()
+		return errors.Join(models.ErrReservationNotFound, err)
+	}
+
+	return tx.Commit()
 }
 
 func (r *PostgresRepo) updateProductQuantities(tx *sql.Tx, productArticle string, warehouseUUID string, quantityDelta int, reservedQuantityDelta int) error {
@@ -132,13 +513,13 @@ func (r *PostgresRepo) updateProductQuantities(tx *sql.Tx, productArticle string
 
 	result, err := tx.Exec(query, quantityDelta, reservedQuantityDelta, productArticle, warehouseUUID)
 	if err != nil {
-		r.logger.Error("error occurred while updating products", err)
+		r.logger.Error("error occurred while updating products", "error", err)
 		return err
 	}
 
 	rows, err := result.RowsAffected()
 	if err != nil {
-		r.logger.Error("error rows affected: ", err)
+		r.logger.Error("error rows affected: ", "error", err)
 		return err
 	}
 
@@ -162,7 +543,8 @@ func (r *PostgresRepo) GenerateTestData() error {
 					('c175b84e-a62c-4094-871f-4c03c34aa37e', 'product3', 30, '789'),
 					('5cb17c38-aa38-4797-a295-475244bb2e53', 'product4', 40, '987'),
 					('d19031d1-eb57-4e2b-9c0b-db80fd694a51', 'product5', 50, '654'),
-					('463d8a77-7916-4c1f-94b3-2408017f22da', 'product6', 60, '321');`
+					('463d8a77-7916-4c1f-94b3-2408017f22da', 'product6', 60, '321'),
+					('0b6f2a0e-7d35-4c8e-9f1a-3e5b8c2d4a71', 'product7', 70, '111');`
 
 	query3 := `INSERT INTO warehouse_products (warehouse_uuid, product_uuid, quantity, reserved_quantity) VALUES
 					('af5fc7cd-afb0-43f8-a9d2-ce532512b2ac', '854427c7-c53c-40be-935f-a97df1c89a13', 15, 0),
@@ -173,15 +555,19 @@ func (r *PostgresRepo) GenerateTestData() error {
 					('f1dd9277-a8af-49ee-a5b1-3f8ee9e74cfd', '5cb17c38-aa38-4797-a295-475244bb2e53', 32, 23),
 					('c1bf338d-1953-4b9f-8dd7-71dfca0a29cc', '463d8a77-7916-4c1f-94b3-2408017f22da', 10, 30),
 					('c1bf338d-1953-4b9f-8dd7-71dfca0a29cc', '5cb17c38-aa38-4797-a295-475244bb2e53', 0, 20),
-					('c1bf338d-1953-4b9f-8dd7-71dfca0a29cc', 'd19031d1-eb57-4e2b-9c0b-db80fd694a51', 2, 5);`
+					('c1bf338d-1953-4b9f-8dd7-71dfca0a29cc', 'd19031d1-eb57-4e2b-9c0b-db80fd694a51', 2, 5),
+					('c1bf338d-1953-4b9f-8dd7-71dfca0a29cc', '0b6f2a0e-7d35-4c8e-9f1a-3e5b8c2d4a71', 0, 0);`
+
+	query4 := `INSERT INTO products (uuid, name, size, article, serialized) VALUES
+					('7e0c5b9a-3f1d-4a62-8c47-2d9e6b1f5a08', 'product8', '80', '222', true);`
 
 	tx, err := r.db.Begin()
 	if err != nil {
-		r.logger.Error("failed to start transaction", err)
+		r.logger.Error("failed to start transaction", "error", err)
 		return err
 	}
 
-	for _, query := range []string{query1, query2, query3} {
+	for _, query := range []string{query1, query2, query3, query4} {
 		if _, err := tx.Exec(query); err != nil {
 			tx.Rollback()
 			return err
@@ -0,0 +1,355 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/lib/pq"
+	"github.com/shamank/warehouse-service/internal/config"
+	"github.com/shamank/warehouse-service/internal/database"
+	"github.com/shamank/warehouse-service/internal/domain/schemas"
+	"github.com/shamank/warehouse-service/internal/repository/repotest"
+	"github.com/shamank/warehouse-service/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"log/slog"
+	"net"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+)
+
+const migrationsPath = "../../../migrations"
+
+// serverDSN - сервер PostgreSQL, на котором выполняются тесты.
+// Пустая строка, если сервер не запущен и тесты нужно пропустить
+var serverDSN string
+
+var databaseCounter atomic.Int64
+
+// TestMain поднимает одноразовый PostgreSQL. Способ задается переменной POSTGRES_TEST:
+//   - local - initdb и postgres из PATH во временном каталоге (не работает от root);
+//   - docker - контейнер из образа postgres.
+//
+// Вместо этого можно указать уже запущенный сервер через POSTGRES_TEST_DSN
+// (пользователь должен иметь право создавать базы). Без этих переменных тесты пропускаются
+func TestMain(m *testing.M) {
+	os.Exit(run(m))
+}
+
+func run(m *testing.M) int {
+	if dsn := os.Getenv("POSTGRES_TEST_DSN"); dsn != "" {
+		// к DSN дописывается имя тестовой базы, поэтому URL переводится в формат key=value
+		if strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://") {
+			var err error
+			if dsn, err = pq.ParseURL(dsn); err != nil {
+				fmt.Fprintln(os.Stderr, "invalid POSTGRES_TEST_DSN:", err)
+				return 1
+			}
+		}
+		serverDSN = dsn
+		return m.Run()
+	}
+
+	var (
+		dsn  string
+		stop func()
+		err  error
+	)
+
+	switch mode := os.Getenv("POSTGRES_TEST"); mode {
+	case "":
+		return m.Run()
+	case "local":
+		dsn, stop, err = startLocalPostgres()
+	case "docker":
+		dsn, stop, err = startDockerPostgres()
+	default:
+		err = fmt.Errorf("unknown POSTGRES_TEST mode %q, expected local or docker", mode)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "cannot start postgres:", err)
+		return 1
+	}
+	defer stop()
+
+	serverDSN = dsn
+	return m.Run()
+}
+
+// startLocalPostgres инициализирует кластер во временном каталоге и запускает postgres на unix-сокете в нем же
+func startLocalPostgres() (string, func(), error) {
+	dir, err := os.MkdirTemp("", "warehouse-postgres")
+	if err != nil {
+		return "", nil, err
+	}
+
+	initdb := exec.Command("initdb", "--pgdata="+dir, "--username=postgres", "--auth=trust", "--no-sync")
+	if output, err := initdb.CombinedOutput(); err != nil {
+		os.RemoveAll(dir)
+		return "", nil, fmt.Errorf("%w: %s", err, output)
+	}
+
+	server := exec.Command("postgres", "-D", dir, "-k", dir, "-c", "listen_addresses=", "-c", "fsync=off")
+	if err := server.Start(); err != nil {
+		os.RemoveAll(dir)
+		return "", nil, err
+	}
+
+	stop := func() {
+		server.Process.Signal(syscall.SIGINT)
+		server.Wait()
+		os.RemoveAll(dir)
+	}
+
+	dsn := fmt.Sprintf("host=%s user=postgres dbname=postgres sslmode=disable", dir)
+	if err := waitForServer(dsn, 30*time.Second); err != nil {
+		stop()
+		return "", nil, err
+	}
+
+	return dsn, stop, nil
+}
+
+// startDockerPostgres запускает контейнер postgres на случайном порту localhost
+func startDockerPostgres() (string, func(), error) {
+	output, err := exec.Command("docker", "run", "--detach", "--rm",
+		"--env", "POSTGRES_PASSWORD=postgres",
+		"--publish", "127.0.0.1::5432",
+		"postgres:16-alpine",
+	).Output()
+	if err != nil {
+		return "", nil, fmt.Errorf("docker run: %w", err)
+	}
+	container := strings.TrimSpace(string(output))
+
+	stop := func() {
+		exec.Command("docker", "stop", container).Run()
+	}
+
+	output, err = exec.Command("docker", "port", container, "5432/tcp").Output()
+	if err != nil {
+		stop()
+		return "", nil, fmt.Errorf("docker port: %w", err)
+	}
+
+	// docker port может вернуть несколько адресов, нам нужен первый
+	_, port, err := net.SplitHostPort(strings.Fields(string(output))[0])
+	if err != nil {
+		stop()
+		return "", nil, err
+	}
+
+	dsn := fmt.Sprintf("host=127.0.0.1 port=%s user=postgres password=postgres dbname=postgres sslmode=disable", port)
+	if err := waitForServer(dsn, 30*time.Second); err != nil {
+		stop()
+		return "", nil, err
+	}
+
+	return dsn, stop, nil
+}
+
+func waitForServer(dsn string, timeout time.Duration) error {
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	deadline := time.Now().Add(timeout)
+	for {
+		err := db.Ping()
+		if err == nil {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return errors.Join(errors.New("postgres is not ready"), err)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+// newRepo создает отдельную базу с примененными миграциями и тестовыми данными.
+// База удаляется после теста
+func newRepo(t *testing.T) *PostgresRepo {
+	t.Helper()
+
+	if serverDSN == "" {
+		t.Skip("POSTGRES_TEST and POSTGRES_TEST_DSN are not set")
+	}
+
+	admin, err := sql.Open("postgres", serverDSN)
+	require.NoError(t, err)
+	t.Cleanup(func() { admin.Close() })
+
+	name := fmt.Sprintf("warehouse_test_%d_%d", os.Getpid(), databaseCounter.Add(1))
+	_, err = admin.Exec("CREATE DATABASE " + name)
+	require.NoError(t, err)
+	t.Cleanup(func() { admin.Exec("DROP DATABASE " + name) })
+
+	db, err := sql.Open("postgres", serverDSN+" dbname="+name)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	require.NoError(t, database.Migrate(db, config.StoragePostgres, migrationsPath))
+
+	return NewPostgresRepo(db, slog.Default())
+}
+
+func TestPostgresRepo(t *testing.T) {
+	if serverDSN == "" {
+		t.Skip("POSTGRES_TEST and POSTGRES_TEST_DSN are not set")
+	}
+
+	repotest.Run(t, func(t *testing.T) repotest.Repository {
+		return newRepo(t)
+	})
+}
+
+func TestPostgresRepo_CheckConstraints(t *testing.T) {
+	testCases := []struct {
+		name       string
+		reserve    bool
+		count      int
+		constraint string
+	}{
+		{
+			// на третьем складе 2 штуки товара 654
+			name:       "reserve more than quantity",
+			reserve:    true,
+			count:      3,
+			constraint: "check_quantity",
+		},
+		{
+			// и 5 штук в резерве
+			name:       "release more than reserved",
+			reserve:    false,
+			count:      6,
+			constraint: "check_reserved_quantity",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			repo := newRepo(t)
+			require.NoError(t, repo.GenerateTestData())
+
+			products := []schemas.ProductWarehouseSplitted{
+				{
+					ProductArticle: "654",
+					WarehouseData:  []schemas.WarehouseCounter{{WarehouseUUID: repotest.Warehouse3, Count: testCase.count}},
+				},
+			}
+
+			var err error
+			if testCase.reserve {
+				err = repo.ReserveProducts(products)
+			} else {
+				err = repo.ReleaseProducts(products)
+			}
+
+			var pqErr *pq.Error
+			require.ErrorAs(t, err, &pqErr)
+			assert.Equal(t, "check_violation", pqErr.Code.Name())
+			assert.Equal(t, testCase.constraint, pqErr.Constraint)
+		})
+	}
+}
+
+func TestPostgresRepo_UnavailableWarehouse(t *testing.T) {
+	repo := newRepo(t)
+	require.NoError(t, repo.GenerateTestData())
+
+	// товар 321 есть только на втором (недоступном) и третьем складах
+	products, err := repo.GetProductsQuantity("321")
+	require.NoError(t, err)
+	require.Len(t, products, 1)
+	assert.Equal(t, repotest.Warehouse3, products[0].WarehouseUUID)
+
+	_, err = repo.db.Exec("UPDATE warehouses SET is_available = false WHERE uuid = $1", repotest.Warehouse3)
+	require.NoError(t, err)
+
+	products, err = repo.GetProductsQuantity("321")
+	require.NoError(t, err)
+	assert.Empty(t, products)
+
+	// резервирование через сервис не должно найти товар на недоступных складах
+	svc := service.NewService(repo, slog.Default())
+	assert.ErrorIs(t, svc.ReserveProducts(context.Background(), []string{"321"}), service.ErrNotEnoughProducts)
+}
+
+func TestPostgresRepo_ConcurrentReservations(t *testing.T) {
+	repo := newRepo(t)
+	require.NoError(t, repo.GenerateTestData())
+
+	svc := service.NewService(repo, slog.Default())
+
+	// товара 987 на доступных складах 45 штук, 100 клиентов резервируют по одной
+	// и еще 20 клиентов резервируют по 3 штуки товара 123, которого 15
+	const (
+		singleClients = 100
+		batchClients  = 20
+	)
+
+	var (
+		wg          sync.WaitGroup
+		reserved987 atomic.Int64
+		reserved123 atomic.Int64
+	)
+	unexpectedErrs := make(chan error, singleClients+batchClients)
+
+	reserve := func(products []string, counter *atomic.Int64) {
+		defer wg.Done()
+
+		err := svc.ReserveProducts(context.Background(), products)
+		switch {
+		case err == nil:
+			counter.Add(int64(len(products)))
+		case errors.Is(err, service.ErrNotEnoughProducts):
+		default:
+			unexpectedErrs <- err
+		}
+	}
+
+	for i := 0; i < singleClients; i++ {
+		wg.Add(1)
+		go reserve([]string{"987"}, &reserved987)
+	}
+	for i := 0; i < batchClients; i++ {
+		wg.Add(1)
+		go reserve([]string{"123", "123", "123"}, &reserved123)
+	}
+	wg.Wait()
+	close(unexpectedErrs)
+
+	for err := range unexpectedErrs {
+		t.Errorf("unexpected error: %v", err)
+	}
+	assert.EqualValues(t, 45, reserved987.Load())
+	assert.EqualValues(t, 15, reserved123.Load())
+
+	products, err := repo.GetProductsQuantity("987")
+	require.NoError(t, err)
+
+	var quantity, reserved int
+	for _, product := range products {
+		quantity += product.Quantity
+		reserved += product.ReservedQuantity
+	}
+	assert.Equal(t, 0, quantity)
+	assert.Equal(t, 45+7+20, reserved, "reserved quantity of 987 = new reservations + reserved in test data")
+
+	remaining, _, err := repo.GetRemainingProductsByWarehouse(repotest.Warehouse1, schemas.RemainingProductsFilter{ArticlePrefix: "123"})
+	require.NoError(t, err)
+	for _, product := range remaining {
+		if product.Code == "123" {
+			assert.Equal(t, 0, product.Quantity, "quantity of 123")
+		}
+	}
+}
@@ -0,0 +1,220 @@
+package postgres
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/lib/pq"
+	"github.com/shamank/warehouse-service/internal/domain/models"
+	"github.com/shamank/warehouse-service/internal/repository"
+	"time"
+)
+
+// GetSerial возвращает серийный номер товара или models.ErrSerialNotFound
+func (r *PostgresRepo) GetSerial(productArticle string, serialNumber string) (models.ProductSerial, error) {
+	query := `SELECT p.article, ps.serial_number, ps.warehouse_uuid, ps.status, ps.reservation_uuid, ps.updated_at
+				FROM product_serials ps
+					INNER JOIN products p ON p.uuid = ps.product_uuid
+				WHERE p.article = $1 AND ps.serial_number = $2`
+
+	var serial models.ProductSerial
+	var reservationUUID sql.NullString
+	err := r.db.QueryRow(query, productArticle, serialNumber).Scan(&serial.ProductArticle, &serial.SerialNumber,
+		&serial.WarehouseUUID, &serial.Status, &reservationUUID, &serial.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.ProductSerial{}, models.ErrSerialNotFound
+	}
+	if err != nil {
+		r.logger.Error("error getting serial", "error", err)
+		return models.ProductSerial{}, err
+	}
+	serial.ReservationUUID = reservationUUID.String
+	serial.UpdatedAt = serial.UpdatedAt.UTC()
+
+	return serial, nil
+}
+
+// GetSerialHistory возвращает события серийного номера от первого к последнему или models.ErrSerialNotFound
+func (r *PostgresRepo) GetSerialHistory(productArticle string, serialNumber string) ([]models.SerialEvent, error) {
+	if _, err := r.GetSerial(productArticle, serialNumber); err != nil {
+		return nil, err
+	}
+
+	query := `SELECT e.status, e.warehouse_uuid, e.reservation_uuid, e.created_at
+				FROM product_serial_events e
+					INNER JOIN products p ON p.uuid = e.product_uuid
+				WHERE p.article = $1 AND e.serial_number = $2
+				ORDER BY e.seq`
+
+	rows, err := r.db.Query(query, productArticle, serialNumber)
+	if err != nil {
+		r.logger.Error("error getting serial history", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := make([]models.SerialEvent, 0)
+
+	for rows.Next() {
+		var event models.SerialEvent
+		var reservationUUID sql.NullString
+		if err := rows.Scan(&event.Status, &event.WarehouseUUID, &reservationUUID, &event.CreatedAt); err != nil {
+			r.logger.Error("error scanning serial history", "error", err)
+			return nil, err
+		}
+		event.ReservationUUID = reservationUUID.String
+		event.CreatedAt = event.CreatedAt.UTC()
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
+
+// receiveSerials регистрирует серийные номера принятого товара. Новый номер становится доступным,
+// отгруженный ранее или не найденный при отборе - возвращенным. Номер, который уже на складе, - models.ErrSerialExists
+func (r *PostgresRepo) receiveSerials(tx *sql.Tx, productUUID string, item models.ReceiptItem, warehouseUUID string) error {
+	for _, serialNumber := range item.Serials {
+		var status string
+		err := tx.QueryRow("SELECT status FROM product_serials WHERE product_uuid = $1 AND serial_number = $2", productUUID, serialNumber).
+			Scan(&status)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			now := time.Now().UTC()
+			_, err := tx.Exec(`INSERT INTO product_serials (product_uuid, serial_number, warehouse_uuid, status, updated_at)
+						VALUES ($1, $2, $3, $4, $5)`, productUUID, serialNumber, warehouseUUID, models.SerialAvailable, now)
+			if err != nil {
+				r.logger.Error("error creating serial", "error", err)
+				return err
+			}
+			if err := r.addSerialEvent(tx, productUUID, serialNumber, warehouseUUID, models.SerialAvailable, "", now); err != nil {
+				return err
+			}
+		case err != nil:
+			r.logger.Error("error getting serial", "error", err)
+			return err
+		case status == models.SerialShipped || status == models.SerialMissing:
+			if err := r.setSerialStatus(tx, productUUID, serialNumber, warehouseUUID, models.SerialReturned, ""); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("%w: %s %s", models.ErrSerialExists, item.ProductArticle, serialNumber)
+		}
+	}
+
+	return nil
+}
+
+// moveSerials переводит count серийных номеров товара на складе по правилу move и возвращает их.
+// Для товара без серийных номеров ничего не делает
+func (r *PostgresRepo) moveSerials(tx *sql.Tx, productArticle string, warehouseUUID string, count int, move repository.SerialMove) ([]string, error) {
+	var productUUID string
+	var serialized bool
+	err := tx.QueryRow("SELECT uuid, serialized FROM products WHERE article = $1", productArticle).Scan(&productUUID, &serialized)
+	if err != nil {
+		r.logger.Error("error getting product", "error", err)
+		return nil, err
+	}
+	if !serialized {
+		return nil, nil
+	}
+
+	// NULL в FromReservation означает номера вне резервов v2
+	rows, err := tx.Query(`SELECT serial_number FROM product_serials
+				WHERE product_uuid = $1 AND warehouse_uuid = $2 AND status = ANY($3::varchar[])
+					AND reservation_uuid IS NOT DISTINCT FROM $4::uuid
+				ORDER BY serial_number LIMIT $5`,
+		productUUID, warehouseUUID, pq.Array(move.From), repository.NullString(move.FromReservation), count)
+	if err != nil {
+		r.logger.Error("error getting serials", "error", err)
+		return nil, err
+	}
+	serials, err := scanStrings(rows)
+	if err != nil {
+		r.logger.Error("error scanning serials", "error", err)
+		return nil, err
+	}
+	if len(serials) < count {
+		return nil, fmt.Errorf("%w: serial numbers of %s", move.Err, productArticle)
+	}
+
+	for _, serialNumber := range serials {
+		if err := r.setSerialStatus(tx, productUUID, serialNumber, warehouseUUID, move.To, move.ToReservation); err != nil {
+			return nil, err
+		}
+	}
+
+	return serials, nil
+}
+
+// setSerialStatus меняет состояние серийного номера и записывает событие в историю
+func (r *PostgresRepo) setSerialStatus(tx *sql.Tx, productUUID string, serialNumber string, warehouseUUID string, status string, reservationUUID string) error {
+	now := time.Now().UTC()
+
+	_, err := tx.Exec(`UPDATE product_serials SET warehouse_uuid = $1, status = $2, reservation_uuid = $3, updated_at = $4
+				WHERE product_uuid = $5 AND serial_number = $6`,
+		warehouseUUID, status, repository.NullString(reservationUUID), now, productUUID, serialNumber)
+	if err != nil {
+		r.logger.Error("error updating serial", "error", err)
+		return err
+	}
+
+	return r.addSerialEvent(tx, productUUID, serialNumber, warehouseUUID, status, reservationUUID, now)
+}
+
+func (r *PostgresRepo) addSerialEvent(tx *sql.Tx, productUUID string, serialNumber string, warehouseUUID string, status string, reservationUUID string, createdAt time.Time) error {
+	query := `INSERT INTO product_serial_events (product_uuid, serial_number, seq, status, warehouse_uuid, reservation_uuid, created_at)
+				SELECT $1, $2, COALESCE(MAX(seq), 0) + 1, $3, $4, $5, $6 FROM product_serial_events
+				WHERE product_uuid = $1 AND serial_number = $2`
+
+	_, err := tx.Exec(query, productUUID, serialNumber, status, warehouseUUID, repository.NullString(reservationUUID), createdAt)
+	if err != nil {
+		r.logger.Error("error adding serial event", "error", err)
+		return err
+	}
+
+	return nil
+}
+
+// reservationSerials возвращает номера, которые сейчас в резерве, по артикулу и складу
+func (r *PostgresRepo) reservationSerials(reservationUUID string) (map[[2]string][]string, error) {
+	query := `SELECT p.article, ps.warehouse_uuid, ps.serial_number
+				FROM product_serials ps
+					INNER JOIN products p ON p.uuid = ps.product_uuid
+				WHERE ps.reservation_uuid = $1 AND ps.status = $2
+				ORDER BY ps.serial_number`
+
+	rows, err := r.db.Query(query, reservationUUID, models.SerialReserved)
+	if err != nil {
+		r.logger.Error("error getting reservation serials", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	serials := make(map[[2]string][]string)
+	for rows.Next() {
+		var article, warehouseUUID, serialNumber string
+		if err := rows.Scan(&article, &warehouseUUID, &serialNumber); err != nil {
+			r.logger.Error("error scanning reservation serials", "error", err)
+			return nil, err
+		}
+		key := [2]string{article, warehouseUUID}
+		serials[key] = append(serials[key], serialNumber)
+	}
+
+	return serials, rows.Err()
+}
+
+func scanStrings(rows *sql.Rows) ([]string, error) {
+	defer rows.Close()
+
+	values := make([]string, 0)
+	for rows.Next() {
+		var value string
+		if err := rows.Scan(&value); err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+
+	return values, rows.Err()
+}
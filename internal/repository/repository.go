@@ -1,7 +1,21 @@
 package repository
 
-import "errors"
+import (
+	"errors"
+	"strings"
+)
 
 var (
 	ErrNoUpdatedProducts = errors.New("no updated products")
 )
+
+// LikeEscape - символ экранирования для шаблонов LIKE, собранных через EscapeLike.
+// Используется вместо обратной косой черты, которую MySQL по-разному трактует в литералах
+const LikeEscape = "!"
+
+var likeReplacer = strings.NewReplacer(LikeEscape, LikeEscape+LikeEscape, "%", LikeEscape+"%", "_", LikeEscape+"_")
+
+// EscapeLike экранирует спецсимволы LIKE, чтобы пользовательская строка искалась буквально
+func EscapeLike(s string) string {
+	return likeReplacer.Replace(s)
+}
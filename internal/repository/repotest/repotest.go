@@ -0,0 +1,1699 @@
+// Package repotest содержит контрактные тесты, которые должна проходить
+// каждая реализация service.Repository.
+package repotest
+
+import (
+	"github.com/shamank/warehouse-service/internal/auth"
+	"github.com/shamank/warehouse-service/internal/domain/models"
+	"github.com/shamank/warehouse-service/internal/domain/schemas"
+	"github.com/shamank/warehouse-service/internal/repository"
+	"github.com/shamank/warehouse-service/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// uuid складов и товаров из GenerateTestData
+const (
+	Warehouse1 = "af5fc7cd-afb0-43f8-a9d2-ce532512b2ac"
+	Warehouse2 = "f1dd9277-a8af-49ee-a5b1-3f8ee9e74cfd" // недоступный склад
+	Warehouse3 = "c1bf338d-1953-4b9f-8dd7-71dfca0a29cc"
+)
+
+// Repository - хранилище под тестом. Контракт проверяется на данных из GenerateTestData
+type Repository interface {
+	service.Repository
+	auth.KeyManager
+	GenerateTestData() error
+}
+
+// Factory возвращает пустое хранилище с примененными миграциями
+type Factory func(t *testing.T) Repository
+
+// Run прогоняет контрактные тесты на хранилищах, созданных newRepo.
+// Каждый подтест получает новое хранилище
+func Run(t *testing.T, newRepo Factory) {
+	setup := func(t *testing.T) Repository {
+		repo := newRepo(t)
+		require.NoError(t, repo.GenerateTestData())
+		return repo
+	}
+
+	t.Run("GetProductsQuantity", func(t *testing.T) {
+		repo := setup(t)
+
+		// товар 987 есть на всех трех складах, но второй склад недоступен
+		products, err := repo.GetProductsQuantity("987")
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []models.WarehouseProduct{
+			{WarehouseUUID: Warehouse1, Quantity: 45, ReservedQuantity: 7},
+			{WarehouseUUID: Warehouse3, Quantity: 0, ReservedQuantity: 20},
+		}, products)
+
+		products, err = repo.GetProductsQuantity("unknown")
+		require.NoError(t, err)
+		assert.Empty(t, products)
+	})
+
+	t.Run("GetRemainingProductsByWarehouse", func(t *testing.T) {
+		repo := setup(t)
+
+		product1 := models.Product{Name: "product1", Size: "10", Code: "123", Quantity: 15, ReservedQuantity: 0}
+		product2 := models.Product{Name: "product2", Size: "20", Code: "456", Quantity: 25, ReservedQuantity: 2}
+		product3 := models.Product{Name: "product3", Size: "30", Code: "789", Quantity: 35, ReservedQuantity: 5}
+		product4 := models.Product{Name: "product4", Size: "40", Code: "987", Quantity: 45, ReservedQuantity: 7}
+
+		testCases := []struct {
+			name           string
+			warehouseUUID  string
+			filter         schemas.RemainingProductsFilter
+			expectedResult []models.Product
+			expectedTotal  int
+		}{
+			{
+				name:           "all products sorted by article",
+				warehouseUUID:  Warehouse1,
+				expectedResult: []models.Product{product1, product2, product3, product4},
+				expectedTotal:  4,
+			},
+			{
+				name:           "unknown warehouse",
+				warehouseUUID:  "00000000-0000-0000-0000-000000000000",
+				expectedResult: []models.Product{},
+				expectedTotal:  0,
+			},
+			{
+				name:           "first page by quantity desc",
+				warehouseUUID:  Warehouse1,
+				filter:         schemas.RemainingProductsFilter{Sort: schemas.SortByQuantity, Desc: true, Limit: 2},
+				expectedResult: []models.Product{product4, product3},
+				expectedTotal:  4,
+			},
+			{
+				name:          "second page by quantity desc",
+				warehouseUUID: Warehouse1,
+				filter: schemas.RemainingProductsFilter{
+					Sort:  schemas.SortByQuantity,
+					Desc:  true,
+					Limit: 2,
+					After: &schemas.ProductCursor{Sort: schemas.SortByQuantity, Desc: true, Article: "789", Quantity: 35},
+				},
+				expectedResult: []models.Product{product2, product1},
+				expectedTotal:  4,
+			},
+			{
+				name:          "page by name",
+				warehouseUUID: Warehouse1,
+				filter: schemas.RemainingProductsFilter{
+					Sort:  schemas.SortByName,
+					After: &schemas.ProductCursor{Sort: schemas.SortByName, Article: "456", Name: "product2"},
+				},
+				expectedResult: []models.Product{product3, product4},
+				expectedTotal:  4,
+			},
+			{
+				name:          "page by article desc",
+				warehouseUUID: Warehouse1,
+				filter: schemas.RemainingProductsFilter{
+					Desc:  true,
+					After: &schemas.ProductCursor{Sort: schemas.SortByArticle, Desc: true, Article: "456"},
+				},
+				expectedResult: []models.Product{product1},
+				expectedTotal:  4,
+			},
+			{
+				name:           "article prefix",
+				warehouseUUID:  Warehouse1,
+				filter:         schemas.RemainingProductsFilter{ArticlePrefix: "45"},
+				expectedResult: []models.Product{product2},
+				expectedTotal:  1,
+			},
+			{
+				name:           "name search ignores case",
+				warehouseUUID:  Warehouse1,
+				filter:         schemas.RemainingProductsFilter{Name: "DUCT3"},
+				expectedResult: []models.Product{product3},
+				expectedTotal:  1,
+			},
+			{
+				name:           "name search escapes wildcards",
+				warehouseUUID:  Warehouse1,
+				filter:         schemas.RemainingProductsFilter{Name: "product_"},
+				expectedResult: []models.Product{},
+				expectedTotal:  0,
+			},
+			{
+				name:           "size and min quantity",
+				warehouseUUID:  Warehouse1,
+				filter:         schemas.RemainingProductsFilter{Size: "40", MinQuantity: 40},
+				expectedResult: []models.Product{product4},
+				expectedTotal:  1,
+			},
+			{
+				name:          "only in stock",
+				warehouseUUID: Warehouse3,
+				filter:        schemas.RemainingProductsFilter{InStock: true},
+				expectedResult: []models.Product{
+					{Name: "product6", Size: "60", Code: "321", Quantity: 10, ReservedQuantity: 30},
+					{Name: "product5", Size: "50", Code: "654", Quantity: 2, ReservedQuantity: 5},
+				},
+				expectedTotal: 2,
+			},
+			{
+				// товар 987 на третьем складе весь в резерве, а товара 111 там нет совсем
+				name:          "reserved products without zero stock",
+				warehouseUUID: Warehouse3,
+				expectedResult: []models.Product{
+					{Name: "product6", Size: "60", Code: "321", Quantity: 10, ReservedQuantity: 30},
+					{Name: "product5", Size: "50", Code: "654", Quantity: 2, ReservedQuantity: 5},
+					{Name: "product4", Size: "40", Code: "987", Quantity: 0, ReservedQuantity: 20},
+				},
+				expectedTotal: 3,
+			},
+			{
+				name:          "include zero stock",
+				warehouseUUID: Warehouse3,
+				filter:        schemas.RemainingProductsFilter{IncludeZeroStock: true, Limit: 2},
+				expectedResult: []models.Product{
+					{Name: "product7", Size: "70", Code: "111", Quantity: 0, ReservedQuantity: 0},
+					{Name: "product6", Size: "60", Code: "321", Quantity: 10, ReservedQuantity: 30},
+				},
+				expectedTotal: 4,
+			},
+		}
+
+		for _, testCase := range testCases {
+			t.Run(testCase.name, func(t *testing.T) {
+				products, total, err := repo.GetRemainingProductsByWarehouse(testCase.warehouseUUID, testCase.filter)
+				require.NoError(t, err)
+				assert.Equal(t, testCase.expectedResult, products)
+				assert.Equal(t, testCase.expectedTotal, total)
+			})
+		}
+	})
+
+	t.Run("GetProductsStock", func(t *testing.T) {
+		repo := setup(t)
+
+		stocks, err := repo.GetProductsStock([]string{"987", "654", "unknown"}, nil)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []models.ProductStock{
+			{ProductArticle: "987", ProductName: "product4", ProductSize: "40", WarehouseUUID: Warehouse1, WarehouseAvailability: true, Quantity: 45, ReservedQuantity: 7},
+			{ProductArticle: "987", ProductName: "product4", ProductSize: "40", WarehouseUUID: Warehouse2, WarehouseAvailability: false, Quantity: 32, ReservedQuantity: 23},
+			{ProductArticle: "987", ProductName: "product4", ProductSize: "40", WarehouseUUID: Warehouse3, WarehouseAvailability: true, Quantity: 0, ReservedQuantity: 20},
+			{ProductArticle: "654", ProductName: "product5", ProductSize: "50", WarehouseUUID: Warehouse3, WarehouseAvailability: true, Quantity: 2, ReservedQuantity: 5},
+		}, stocks)
+
+		stocks, err = repo.GetProductsStock([]string{"987", "321"}, []string{Warehouse2})
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []models.ProductStock{
+			{ProductArticle: "321", ProductName: "product6", ProductSize: "60", WarehouseUUID: Warehouse2, WarehouseAvailability: false, Quantity: 12, ReservedQuantity: 0},
+			{ProductArticle: "987", ProductName: "product4", ProductSize: "40", WarehouseUUID: Warehouse2, WarehouseAvailability: false, Quantity: 32, ReservedQuantity: 23},
+		}, stocks)
+
+		stocks, err = repo.GetProductsStock(nil, []string{Warehouse3})
+		require.NoError(t, err)
+		assert.Len(t, stocks, 4)
+	})
+
+	t.Run("GetProductsByArticles", func(t *testing.T) {
+		repo := setup(t)
+
+		products, err := repo.GetProductsByArticles([]string{"654", "unknown", "111"})
+		require.NoError(t, err)
+		require.Len(t, products, 2)
+		assert.Equal(t, "111", products[0].Code)
+		assert.Equal(t, "product7", products[0].Name)
+		assert.Equal(t, "654", products[1].Code)
+		assert.NotEmpty(t, products[1].UUID)
+
+		products, err = repo.GetProductsByArticles(nil)
+		require.NoError(t, err)
+		assert.Empty(t, products)
+	})
+
+	t.Run("GetWarehouse", func(t *testing.T) {
+		repo := setup(t)
+
+		warehouse, err := repo.GetWarehouse(Warehouse2)
+		require.NoError(t, err)
+		assert.Equal(t, models.Warehouse{UUID: Warehouse2, Name: "warehouse2", Availability: false}, warehouse)
+
+		_, err = repo.GetWarehouse("00000000-0000-0000-0000-000000000000")
+		assert.ErrorIs(t, err, models.ErrWarehouseNotFound)
+	})
+
+	t.Run("ReserveProducts", func(t *testing.T) {
+		repo := setup(t)
+
+		err := repo.ReserveProducts([]schemas.ProductWarehouseSplitted{
+			{
+				ProductArticle: "987",
+				WarehouseData:  []schemas.WarehouseCounter{{WarehouseUUID: Warehouse1, Count: 5}},
+			},
+			{
+				ProductArticle: "321",
+				WarehouseData:  []schemas.WarehouseCounter{{WarehouseUUID: Warehouse3, Count: 10}},
+			},
+		})
+		require.NoError(t, err)
+
+		assertQuantity(t, repo, "987", Warehouse1, 40, 12)
+		assertQuantity(t, repo, "321", Warehouse3, 0, 40)
+	})
+
+	t.Run("ReserveProducts is atomic", func(t *testing.T) {
+		repo := setup(t)
+
+		// второй товар нарушает check_quantity, первый не должен зарезервироваться
+		err := repo.ReserveProducts([]schemas.ProductWarehouseSplitted{
+			{
+				ProductArticle: "123",
+				WarehouseData:  []schemas.WarehouseCounter{{WarehouseUUID: Warehouse1, Count: 1}},
+			},
+			{
+				ProductArticle: "654",
+				WarehouseData:  []schemas.WarehouseCounter{{WarehouseUUID: Warehouse3, Count: 3}},
+			},
+		})
+		assert.Error(t, err)
+
+		assertQuantity(t, repo, "123", Warehouse1, 15, 0)
+		assertQuantity(t, repo, "654", Warehouse3, 2, 5)
+	})
+
+	t.Run("ReserveProducts unknown product", func(t *testing.T) {
+		repo := setup(t)
+
+		err := repo.ReserveProducts([]schemas.ProductWarehouseSplitted{
+			{
+				ProductArticle: "123",
+				WarehouseData:  []schemas.WarehouseCounter{{WarehouseUUID: Warehouse3, Count: 1}},
+			},
+		})
+		assert.ErrorIs(t, err, repository.ErrNoUpdatedProducts)
+	})
+
+	t.Run("ReleaseProducts", func(t *testing.T) {
+		repo := setup(t)
+
+		err := repo.ReleaseProducts([]schemas.ProductWarehouseSplitted{
+			{
+				ProductArticle: "987",
+				WarehouseData: []schemas.WarehouseCounter{
+					{WarehouseUUID: Warehouse1, Count: 7},
+					{WarehouseUUID: Warehouse3, Count: 1},
+				},
+			},
+		})
+		require.NoError(t, err)
+
+		assertQuantity(t, repo, "987", Warehouse1, 45, 0)
+		assertQuantity(t, repo, "987", Warehouse3, 0, 19)
+	})
+
+	t.Run("ReleaseProducts is atomic", func(t *testing.T) {
+		repo := setup(t)
+
+		// резерва на первом складе не хватает, освобождение на третьем должно откатиться
+		err := repo.ReleaseProducts([]schemas.ProductWarehouseSplitted{
+			{
+				ProductArticle: "987",
+				WarehouseData: []schemas.WarehouseCounter{
+					{WarehouseUUID: Warehouse3, Count: 1},
+					{WarehouseUUID: Warehouse1, Count: 8},
+				},
+			},
+		})
+		assert.Error(t, err)
+
+		assertQuantity(t, repo, "987", Warehouse1, 45, 7)
+		assertQuantity(t, repo, "987", Warehouse3, 0, 20)
+	})
+
+	t.Run("reservations", func(t *testing.T) {
+		repo := setup(t)
+
+		const reservationUUID = "3f0c4a52-8b3e-4a55-9d7c-5a1f2b6e9c10"
+
+		_, err := repo.GetReservation(reservationUUID)
+		require.ErrorIs(t, err, models.ErrReservationNotFound)
+
+		err = repo.CreateReservation(reservationUUID, []schemas.ProductWarehouseSplitted{
+			{
+				ProductArticle: "987",
+				WarehouseData:  []schemas.WarehouseCounter{{WarehouseUUID: Warehouse1, Count: 5}},
+			},
+			{
+				ProductArticle: "321",
+				WarehouseData:  []schemas.WarehouseCounter{{WarehouseUUID: Warehouse3, Count: 10}},
+			},
+		})
+		require.NoError(t, err)
+
+		assertQuantity(t, repo, "987", Warehouse1, 40, 12)
+		assertQuantity(t, repo, "321", Warehouse3, 0, 40)
+
+		reservation, err := repo.GetReservation(reservationUUID)
+		require.NoError(t, err)
+		assert.Equal(t, reservationUUID, reservation.UUID)
+		assert.Equal(t, models.ReservationActive, reservation.Status)
+		assert.WithinDuration(t, time.Now(), reservation.CreatedAt, time.Minute)
+		assert.Equal(t, []models.ReservationItem{
+			{ProductArticle: "321", WarehouseUUID: Warehouse3, Quantity: 10},
+			{ProductArticle: "987", WarehouseUUID: Warehouse1, Quantity: 5},
+		}, reservation.Items)
+
+		err = repo.ReleaseReservation(reservationUUID, []schemas.ProductWarehouseSplitted{
+			{
+				ProductArticle: "987",
+				WarehouseData:  []schemas.WarehouseCounter{{WarehouseUUID: Warehouse1, Count: 2}},
+			},
+		}, models.ReservationActive)
+		require.NoError(t, err)
+
+		assertQuantity(t, repo, "987", Warehouse1, 42, 10)
+
+		// освободить больше, чем зарезервировано, нельзя, и остатки не должны измениться
+		err = repo.ReleaseReservation(reservationUUID, []schemas.ProductWarehouseSplitted{
+			{
+				ProductArticle: "987",
+				WarehouseData:  []schemas.WarehouseCounter{{WarehouseUUID: Warehouse1, Count: 4}},
+			},
+		}, models.ReservationReleased)
+		require.Error(t, err)
+
+		assertQuantity(t, repo, "987", Warehouse1, 42, 10)
+
+		// позиции, которой нет в резерве, освобождать нельзя
+		err = repo.ReleaseReservation(reservationUUID, []schemas.ProductWarehouseSplitted{
+			{
+				ProductArticle: "987",
+				WarehouseData:  []schemas.WarehouseCounter{{WarehouseUUID: Warehouse3, Count: 1}},
+			},
+		}, models.ReservationReleased)
+		require.ErrorIs(t, err, repository.ErrNoUpdatedProducts)
+
+		assertQuantity(t, repo, "987", Warehouse3, 0, 20)
+
+		err = repo.ReleaseReservation(reservationUUID, []schemas.ProductWarehouseSplitted{
+			{
+				ProductArticle: "987",
+				WarehouseData:  []schemas.WarehouseCounter{{WarehouseUUID: Warehouse1, Count: 3}},
+			},
+			{
+				ProductArticle: "321",
+				WarehouseData:  []schemas.WarehouseCounter{{WarehouseUUID: Warehouse3, Count: 10}},
+			},
+		}, models.ReservationCancelled)
+		require.NoError(t, err)
+
+		assertQuantity(t, repo, "987", Warehouse1, 45, 7)
+		assertQuantity(t, repo, "321", Warehouse3, 10, 30)
+
+		reservation, err = repo.GetReservation(reservationUUID)
+		require.NoError(t, err)
+		assert.Equal(t, models.ReservationCancelled, reservation.Status)
+		assert.Equal(t, []models.ReservationItem{
+			{ProductArticle: "321", WarehouseUUID: Warehouse3, Quantity: 10, ReleasedQuantity: 10},
+			{ProductArticle: "987", WarehouseUUID: Warehouse1, Quantity: 5, ReleasedQuantity: 5},
+		}, reservation.Items)
+	})
+
+	t.Run("CreateReservation is atomic", func(t *testing.T) {
+		repo := setup(t)
+
+		const reservationUUID = "8d2e6f0a-1c4b-4e7d-b3a9-6f5e4d3c2b1a"
+
+		err := repo.CreateReservation(reservationUUID, []schemas.ProductWarehouseSplitted{
+			{
+				ProductArticle: "123",
+				WarehouseData:  []schemas.WarehouseCounter{{WarehouseUUID: Warehouse1, Count: 1}},
+			},
+			{
+				ProductArticle: "654",
+				WarehouseData:  []schemas.WarehouseCounter{{WarehouseUUID: Warehouse3, Count: 3}},
+			},
+		})
+		require.Error(t, err)
+
+		assertQuantity(t, repo, "123", Warehouse1, 15, 0)
+
+		_, err = repo.GetReservation(reservationUUID)
+		assert.ErrorIs(t, err, models.ErrReservationNotFound)
+	})
+
+	t.Run("lots", func(t *testing.T) {
+		repo := setup(t)
+
+		lot := func(number string, expiresAt string) *models.Lot {
+			result := &models.Lot{Number: number, ManufacturedAt: date("1999-01-01")}
+			if expiresAt != "" {
+				result.ExpiresAt = date(expiresAt)
+			}
+			return result
+		}
+		lotsOf := func() map[string][2]int {
+			stocks, err := repo.GetLotsStock(Warehouse1, []string{"123"})
+			require.NoError(t, err)
+
+			result := make(map[string][2]int)
+			for _, stock := range stocks {
+				result[stock.Lot.Number] = [2]int{stock.Quantity, stock.ReservedQuantity}
+			}
+			return result
+		}
+
+		// у товара 123 на первом складе 15 штук без партии
+		err := repo.ReceiveProducts(Warehouse1, []models.ReceiptItem{
+			{ProductArticle: "123", Quantity: 3, Lot: lot("expired", "2000-01-01")},
+			{ProductArticle: "123", Quantity: 4, Lot: lot("late", "2999-12-31")},
+			{ProductArticle: "123", Quantity: 2, Lot: lot("early", "2999-06-30")},
+			{ProductArticle: "123", Quantity: 1, Lot: lot("endless", "")},
+			{ProductArticle: "123", Quantity: 5},
+		})
+		require.NoError(t, err)
+
+		// просроченная партия не входит в остаток для резервирования
+		assertQuantity(t, repo, "123", Warehouse1, 27, 0)
+
+		stocks, err := repo.GetLotsStock(Warehouse1, []string{"123", "456"})
+		require.NoError(t, err)
+		assert.Equal(t, []models.LotStock{
+			{ProductArticle: "123", Lot: *lot("expired", "2000-01-01"), Quantity: 3},
+			{ProductArticle: "123", Lot: *lot("early", "2999-06-30"), Quantity: 2},
+			{ProductArticle: "123", Lot: *lot("late", "2999-12-31"), Quantity: 4},
+			{ProductArticle: "123", Lot: *lot("endless", ""), Quantity: 1},
+		}, stocks)
+
+		products, _, err := repo.GetRemainingProductsByWarehouse(Warehouse1, schemas.RemainingProductsFilter{ArticlePrefix: "123"})
+		require.NoError(t, err)
+		require.Len(t, products, 1)
+		assert.Equal(t, 30, products[0].Quantity)
+		assert.Equal(t, 3, products[0].ExpiredQuantity)
+
+		productStocks, err := repo.GetProductsStock([]string{"123"}, []string{Warehouse1})
+		require.NoError(t, err)
+		require.Len(t, productStocks, 1)
+		assert.Equal(t, 3, productStocks[0].ExpiredQuantity)
+
+		// резерв берет партии с ближайшим сроком годности, пропуская просроченную
+		err = repo.ReserveProducts([]schemas.ProductWarehouseSplitted{
+			{ProductArticle: "123", WarehouseData: []schemas.WarehouseCounter{{WarehouseUUID: Warehouse1, Count: 5}}},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, map[string][2]int{"expired": {3, 0}, "early": {0, 2}, "late": {1, 3}, "endless": {1, 0}}, lotsOf())
+
+		// отгрузка тоже идет по сроку годности
+		err = repo.ReleaseProducts([]schemas.ProductWarehouseSplitted{
+			{ProductArticle: "123", WarehouseData: []schemas.WarehouseCounter{{WarehouseUUID: Warehouse1, Count: 1}}},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, map[string][2]int{"expired": {3, 0}, "early": {0, 1}, "late": {1, 3}, "endless": {1, 0}}, lotsOf())
+
+		// партии кончаются, остаток берется из товара без партии
+		const reservationUUID = "5b7e2c1d-9a4f-4e3b-8c6d-2f1a0b9e8d7c"
+		err = repo.CreateReservation(reservationUUID, []schemas.ProductWarehouseSplitted{
+			{ProductArticle: "123", WarehouseData: []schemas.WarehouseCounter{{WarehouseUUID: Warehouse1, Count: 4}}},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, map[string][2]int{"expired": {3, 0}, "early": {0, 1}, "late": {0, 4}, "endless": {0, 1}}, lotsOf())
+		assertQuantity(t, repo, "123", Warehouse1, 18, 8)
+
+		// сначала возвращается товар без партии, затем партии в порядке, обратном FEFO
+		err = repo.ReleaseReservation(reservationUUID, []schemas.ProductWarehouseSplitted{
+			{ProductArticle: "123", WarehouseData: []schemas.WarehouseCounter{{WarehouseUUID: Warehouse1, Count: 3}}},
+		}, models.ReservationActive)
+		require.NoError(t, err)
+		assert.Equal(t, map[string][2]int{"expired": {3, 0}, "early": {0, 1}, "late": {0, 4}, "endless": {1, 0}}, lotsOf())
+
+		err = repo.ReleaseReservation(reservationUUID, []schemas.ProductWarehouseSplitted{
+			{ProductArticle: "123", WarehouseData: []schemas.WarehouseCounter{{WarehouseUUID: Warehouse1, Count: 1}}},
+		}, models.ReservationCancelled)
+		require.NoError(t, err)
+		assert.Equal(t, map[string][2]int{"expired": {3, 0}, "early": {0, 1}, "late": {1, 3}, "endless": {1, 0}}, lotsOf())
+		assertQuantity(t, repo, "123", Warehouse1, 22, 4)
+
+		// просроченный товар не резервируется, даже если его просят явно
+		err = repo.ReserveProducts([]schemas.ProductWarehouseSplitted{
+			{ProductArticle: "123", WarehouseData: []schemas.WarehouseCounter{{WarehouseUUID: Warehouse1, Count: 23}}},
+		})
+		assert.ErrorIs(t, err, models.ErrNotEnoughProducts)
+		assertQuantity(t, repo, "123", Warehouse1, 22, 4)
+
+		err = repo.ReserveProducts([]schemas.ProductWarehouseSplitted{
+			{ProductArticle: "123", WarehouseData: []schemas.WarehouseCounter{{WarehouseUUID: Warehouse1, Count: 22}}},
+		})
+		require.NoError(t, err)
+		assertQuantity(t, repo, "123", Warehouse1, 0, 26)
+	})
+
+	t.Run("ReceiveProducts", func(t *testing.T) {
+		repo := setup(t)
+
+		// товара 111 на первом складе еще нет
+		err := repo.ReceiveProducts(Warehouse1, []models.ReceiptItem{
+			{ProductArticle: "111", Quantity: 2, Lot: &models.Lot{Number: "A1", ExpiresAt: date("2999-01-01")}},
+			{ProductArticle: "111", Quantity: 3, Lot: &models.Lot{Number: "A1", ExpiresAt: date("2999-01-01")}},
+		})
+		require.NoError(t, err)
+		assertQuantity(t, repo, "111", Warehouse1, 5, 0)
+
+		// партия с тем же номером, но другим сроком - ошибка, вся приемка откатывается
+		err = repo.ReceiveProducts(Warehouse1, []models.ReceiptItem{
+			{ProductArticle: "123", Quantity: 1},
+			{ProductArticle: "111", Quantity: 1, Lot: &models.Lot{Number: "A1", ExpiresAt: date("2999-02-01")}},
+		})
+		assert.ErrorIs(t, err, models.ErrLotMismatch)
+		assertQuantity(t, repo, "123", Warehouse1, 15, 0)
+		assertQuantity(t, repo, "111", Warehouse1, 5, 0)
+
+		err = repo.ReceiveProducts(Warehouse1, []models.ReceiptItem{{ProductArticle: "unknown", Quantity: 1}})
+		assert.ErrorIs(t, err, models.ErrProductNotFound)
+
+		stocks, err := repo.GetLotsStock(Warehouse3, []string{"111"})
+		require.NoError(t, err)
+		assert.Empty(t, stocks)
+	})
+
+	t.Run("serials", func(t *testing.T) {
+		repo := setup(t)
+
+		const reservationUUID = "5b8e2c71-9d4a-4f36-a1e0-7c3b6d9f2e48"
+
+		products, err := repo.GetProductsByArticles([]string{"111", "222"})
+		require.NoError(t, err)
+		require.Len(t, products, 2)
+		assert.False(t, products[0].Serialized)
+		assert.True(t, products[1].Serialized)
+
+		// товар 222 штучный, на складах его еще нет
+		err = repo.ReceiveProducts(Warehouse1, []models.ReceiptItem{
+			{ProductArticle: "222", Quantity: 3, Serials: []string{"S1", "S2", "S3"}},
+		})
+		require.NoError(t, err)
+		assertQuantity(t, repo, "222", Warehouse1, 3, 0)
+
+		// номер, который уже на складе, повторно не принимается
+		err = repo.ReceiveProducts(Warehouse3, []models.ReceiptItem{
+			{ProductArticle: "222", Quantity: 1, Serials: []string{"S2"}},
+		})
+		assert.ErrorIs(t, err, models.ErrSerialExists)
+
+		serial, err := repo.GetSerial("222", "S2")
+		require.NoError(t, err)
+		assert.Equal(t, models.SerialAvailable, serial.Status)
+		assert.Equal(t, Warehouse1, serial.WarehouseUUID)
+
+		err = repo.CreateReservation(reservationUUID, []schemas.ProductWarehouseSplitted{
+			{ProductArticle: "222", WarehouseData: []schemas.WarehouseCounter{{WarehouseUUID: Warehouse1, Count: 2}}},
+		})
+		require.NoError(t, err)
+
+		reservation, err := repo.GetReservation(reservationUUID)
+		require.NoError(t, err)
+		assert.Equal(t, []models.ReservationItem{
+			{ProductArticle: "222", WarehouseUUID: Warehouse1, Quantity: 2, Serials: []string{"S1", "S2"}},
+		}, reservation.Items)
+
+		serial, err = repo.GetSerial("222", "S1")
+		require.NoError(t, err)
+		assert.Equal(t, models.SerialReserved, serial.Status)
+		assert.Equal(t, reservationUUID, serial.ReservationUUID)
+		assert.Equal(t, Warehouse1, serial.WarehouseUUID)
+		assert.WithinDuration(t, time.Now(), serial.UpdatedAt, time.Minute)
+
+		// v1: резерв и отгрузка без uuid резерва
+		v1 := []schemas.ProductWarehouseSplitted{
+			{ProductArticle: "222", WarehouseData: []schemas.WarehouseCounter{{WarehouseUUID: Warehouse1, Count: 1}}},
+		}
+		require.NoError(t, repo.ReserveProducts(v1))
+		require.NoError(t, repo.ReleaseProducts(v1))
+		assertQuantity(t, repo, "222", Warehouse1, 0, 2)
+
+		serial, err = repo.GetSerial("222", "S3")
+		require.NoError(t, err)
+		assert.Equal(t, models.SerialShipped, serial.Status)
+		assert.Empty(t, serial.ReservationUUID)
+
+		// номера резерва v2 нельзя отгрузить через v1
+		assert.ErrorIs(t, repo.ReleaseProducts(v1), models.ErrNotEnoughReserved)
+		assertQuantity(t, repo, "222", Warehouse1, 0, 2)
+
+		err = repo.ReleaseReservation(reservationUUID, []schemas.ProductWarehouseSplitted{
+			{ProductArticle: "222", WarehouseData: []schemas.WarehouseCounter{{WarehouseUUID: Warehouse1, Count: 1}}},
+		}, models.ReservationActive)
+		require.NoError(t, err)
+
+		reservation, err = repo.GetReservation(reservationUUID)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"S2"}, reservation.Items[0].Serials)
+
+		serial, err = repo.GetSerial("222", "S1")
+		require.NoError(t, err)
+		assert.Equal(t, models.SerialAvailable, serial.Status)
+		assert.Empty(t, serial.ReservationUUID)
+
+		// отгруженный номер возвращается на другой склад
+		err = repo.ReceiveProducts(Warehouse3, []models.ReceiptItem{
+			{ProductArticle: "222", Quantity: 1, Serials: []string{"S3"}},
+		})
+		require.NoError(t, err)
+		assertQuantity(t, repo, "222", Warehouse3, 1, 0)
+
+		history, err := repo.GetSerialHistory("222", "S3")
+		require.NoError(t, err)
+		require.Len(t, history, 4)
+		for i, expected := range []models.SerialEvent{
+			{Status: models.SerialAvailable, WarehouseUUID: Warehouse1},
+			{Status: models.SerialReserved, WarehouseUUID: Warehouse1},
+			{Status: models.SerialShipped, WarehouseUUID: Warehouse1},
+			{Status: models.SerialReturned, WarehouseUUID: Warehouse3},
+		} {
+			assert.Equal(t, expected.Status, history[i].Status)
+			assert.Equal(t, expected.WarehouseUUID, history[i].WarehouseUUID)
+			assert.Empty(t, history[i].ReservationUUID)
+		}
+
+		history, err = repo.GetSerialHistory("222", "S2")
+		require.NoError(t, err)
+		require.Len(t, history, 2)
+		assert.Equal(t, reservationUUID, history[1].ReservationUUID)
+
+		// возвращенный номер резервируется как доступный
+		err = repo.ReserveProducts([]schemas.ProductWarehouseSplitted{
+			{ProductArticle: "222", WarehouseData: []schemas.WarehouseCounter{{WarehouseUUID: Warehouse3, Count: 1}}},
+		})
+		require.NoError(t, err)
+		serial, err = repo.GetSerial("222", "S3")
+		require.NoError(t, err)
+		assert.Equal(t, models.SerialReserved, serial.Status)
+
+		_, err = repo.GetSerial("222", "unknown")
+		assert.ErrorIs(t, err, models.ErrSerialNotFound)
+		_, err = repo.GetSerialHistory("111", "S1")
+		assert.ErrorIs(t, err, models.ErrSerialNotFound)
+	})
+
+	t.Run("locations", func(t *testing.T) {
+		repo := setup(t)
+
+		const reservationUUID = "3e9a6f12-7c4b-4d85-b0a3-5f2e8d1c6b97"
+
+		// зона A, проход 1, стеллаж 1, ячейки 01 и 02
+		const (
+			zoneUUID  = "0d1e2f3a-4b5c-4d6e-8f7a-1b2c3d4e5f60"
+			aisleUUID = "1e2f3a4b-5c6d-4e7f-8a9b-2c3d4e5f6a71"
+			shelfUUID = "2f3a4b5c-6d7e-4f8a-9b0c-3d4e5f6a7b82"
+		)
+		bins := []models.Location{
+			{UUID: "4b5c6d7e-8f9a-4b0c-8d1e-5f6a7b8c9d04", WarehouseUUID: Warehouse1, ParentUUID: shelfUUID, Kind: models.LocationBin, Code: "02", Path: "A/1/1/02"},
+			{UUID: "3a4b5c6d-7e8f-4a9b-8c0d-4e5f6a7b8c93", WarehouseUUID: Warehouse1, ParentUUID: shelfUUID, Kind: models.LocationBin, Code: "01", Path: "A/1/1/01"},
+		}
+		for _, location := range append([]models.Location{
+			{UUID: zoneUUID, WarehouseUUID: Warehouse1, Kind: models.LocationZone, Code: "A", Path: "A"},
+			{UUID: aisleUUID, WarehouseUUID: Warehouse1, ParentUUID: zoneUUID, Kind: models.LocationAisle, Code: "1", Path: "A/1"},
+			{UUID: shelfUUID, WarehouseUUID: Warehouse1, ParentUUID: aisleUUID, Kind: models.LocationShelf, Code: "1", Path: "A/1/1"},
+		}, bins...) {
+			require.NoError(t, repo.CreateLocation(location))
+		}
+
+		err := repo.CreateLocation(models.Location{UUID: "5c6d7e8f-9a0b-4c1d-8e2f-6a7b8c9d0e15", WarehouseUUID: Warehouse1, ParentUUID: shelfUUID, Kind: models.LocationBin, Code: "01", Path: "A/1/1/01"})
+		assert.ErrorIs(t, err, models.ErrLocationExists)
+
+		location, err := repo.GetLocation(Warehouse1, bins[1].UUID)
+		require.NoError(t, err)
+		assert.Equal(t, bins[1], location)
+		_, err = repo.GetLocation(Warehouse3, bins[1].UUID)
+		assert.ErrorIs(t, err, models.ErrLocationNotFound)
+
+		locations, err := repo.GetLocations(Warehouse1)
+		require.NoError(t, err)
+		require.Len(t, locations, 5)
+		assert.Equal(t, "A", locations[0].Path)
+		assert.Empty(t, locations[0].ParentUUID)
+		assert.Equal(t, []models.Location{bins[1], bins[0]}, locations[3:])
+
+		binsOf := func() map[string][2]int {
+			stocks, err := repo.GetBinsStock(Warehouse1, []string{"123"})
+			require.NoError(t, err)
+
+			result := make(map[string][2]int)
+			for _, stock := range stocks {
+				result[stock.Path] = [2]int{stock.Quantity, stock.ReservedQuantity}
+			}
+			return result
+		}
+
+		// у товара 123 на первом складе 15 штук вне ячеек
+		err = repo.ReceiveProducts(Warehouse1, []models.ReceiptItem{{ProductArticle: "123", Quantity: 4, BinPath: "A/1/1/02"}})
+		require.NoError(t, err)
+		assertQuantity(t, repo, "123", Warehouse1, 19, 0)
+
+		// товар хранится только в ячейках
+		err = repo.ReceiveProducts(Warehouse1, []models.ReceiptItem{{ProductArticle: "123", Quantity: 1, BinPath: "A/1/1"}})
+		assert.ErrorIs(t, err, models.ErrInvalidLocation)
+		err = repo.ReceiveProducts(Warehouse1, []models.ReceiptItem{{ProductArticle: "123", Quantity: 1, BinPath: "A/1/1/03"}})
+		assert.ErrorIs(t, err, models.ErrLocationNotFound)
+		assertQuantity(t, repo, "123", Warehouse1, 19, 0)
+
+		err = repo.MoveStock(Warehouse1, []models.StockMove{
+			{ProductArticle: "123", Quantity: 10, ToPath: "A/1/1/01"},
+			{ProductArticle: "123", Quantity: 7, FromPath: "A/1/1/01"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, map[string][2]int{"A/1/1/01": {3, 0}, "A/1/1/02": {4, 0}}, binsOf())
+
+		// перемещения выполняются все вместе или ни одно
+		err = repo.MoveStock(Warehouse1, []models.StockMove{
+			{ProductArticle: "123", Quantity: 1, FromPath: "A/1/1/01", ToPath: "A/1/1/02"},
+			{ProductArticle: "123", Quantity: 13, ToPath: "A/1/1/02"},
+		})
+		assert.ErrorIs(t, err, models.ErrNotEnoughProducts)
+		err = repo.MoveStock(Warehouse1, []models.StockMove{
+			{ProductArticle: "123", Quantity: 1, FromPath: "A/1/1/02", ToPath: "A/1/1/01"},
+			{ProductArticle: "123", Quantity: 5, FromPath: "A/1/1/01", ToPath: "A/1/1/02"},
+		})
+		assert.ErrorIs(t, err, models.ErrNotEnoughProducts)
+		assert.Equal(t, map[string][2]int{"A/1/1/01": {3, 0}, "A/1/1/02": {4, 0}}, binsOf())
+		assertQuantity(t, repo, "123", Warehouse1, 19, 0)
+
+		// резерв берет ячейки в порядке обхода, затем товар вне ячеек
+		err = repo.CreateReservation(reservationUUID, []schemas.ProductWarehouseSplitted{
+			{ProductArticle: "123", WarehouseData: []schemas.WarehouseCounter{{WarehouseUUID: Warehouse1, Count: 9}}},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, map[string][2]int{"A/1/1/01": {0, 3}, "A/1/1/02": {0, 4}}, binsOf())
+
+		reservation, err := repo.GetReservation(reservationUUID)
+		require.NoError(t, err)
+		assert.Equal(t, []models.ReservationItem{
+			{ProductArticle: "123", WarehouseUUID: Warehouse1, Quantity: 9, Bins: []models.BinQuantity{
+				{Path: "A/1/1/01", Quantity: 3},
+				{Path: "A/1/1/02", Quantity: 4},
+			}},
+		}, reservation.Items)
+
+		// зарезервированный товар не перемещается
+		err = repo.MoveStock(Warehouse1, []models.StockMove{{ProductArticle: "123", Quantity: 1, FromPath: "A/1/1/01", ToPath: "A/1/1/02"}})
+		assert.ErrorIs(t, err, models.ErrNotEnoughProducts)
+
+		// сначала возвращается товар вне ячеек, затем ячейки в обратном порядке
+		err = repo.ReleaseReservation(reservationUUID, []schemas.ProductWarehouseSplitted{
+			{ProductArticle: "123", WarehouseData: []schemas.WarehouseCounter{{WarehouseUUID: Warehouse1, Count: 3}}},
+		}, models.ReservationActive)
+		require.NoError(t, err)
+		assert.Equal(t, map[string][2]int{"A/1/1/01": {0, 3}, "A/1/1/02": {1, 3}}, binsOf())
+
+		reservation, err = repo.GetReservation(reservationUUID)
+		require.NoError(t, err)
+		assert.Equal(t, []models.BinQuantity{{Path: "A/1/1/01", Quantity: 3}, {Path: "A/1/1/02", Quantity: 3}}, reservation.Items[0].Bins)
+
+		// v1: резерв и отгрузка тоже идут по ячейкам
+		v1 := []schemas.ProductWarehouseSplitted{
+			{ProductArticle: "123", WarehouseData: []schemas.WarehouseCounter{{WarehouseUUID: Warehouse1, Count: 1}}},
+		}
+		require.NoError(t, repo.ReserveProducts(v1))
+		assert.Equal(t, map[string][2]int{"A/1/1/01": {0, 3}, "A/1/1/02": {0, 4}}, binsOf())
+		require.NoError(t, repo.ReleaseProducts(v1))
+		assert.Equal(t, map[string][2]int{"A/1/1/01": {0, 2}, "A/1/1/02": {0, 4}}, binsOf())
+		assertQuantity(t, repo, "123", Warehouse1, 12, 6)
+
+		stocks, err := repo.GetBinsStock(Warehouse3, []string{"123"})
+		require.NoError(t, err)
+		assert.Empty(t, stocks)
+	})
+
+	t.Run("pick lists", func(t *testing.T) {
+		repo := setup(t)
+
+		const (
+			reservationUUID = "8c1d4e7f-2a5b-4c8d-9e0f-3a6b9c2d5e81"
+			pickListUUID    = "9d2e5f80-3b6c-4d9e-8f1a-4b7c0d3e6f92"
+		)
+
+		for _, location := range []models.Location{
+			{UUID: "0a1b2c3d-4e5f-4a6b-8c7d-9e0f1a2b3c41", WarehouseUUID: Warehouse1, Kind: models.LocationZone, Code: "A", Path: "A"},
+			{UUID: "1b2c3d4e-5f6a-4b7c-8d9e-0f1a2b3c4d52", WarehouseUUID: Warehouse1, ParentUUID: "0a1b2c3d-4e5f-4a6b-8c7d-9e0f1a2b3c41", Kind: models.LocationAisle, Code: "1", Path: "A/1"},
+			{UUID: "2c3d4e5f-6a7b-4c8d-9e0f-1a2b3c4d5e63", WarehouseUUID: Warehouse1, ParentUUID: "1b2c3d4e-5f6a-4b7c-8d9e-0f1a2b3c4d52", Kind: models.LocationShelf, Code: "1", Path: "A/1/1"},
+			{UUID: "3d4e5f6a-7b8c-4d9e-8f0a-2b3c4d5e6f74", WarehouseUUID: Warehouse1, ParentUUID: "2c3d4e5f-6a7b-4c8d-9e0f-1a2b3c4d5e63", Kind: models.LocationBin, Code: "01", Path: "A/1/1/01"},
+		} {
+			require.NoError(t, repo.CreateLocation(location))
+		}
+
+		// у товара 123 на первом складе 15 штук вне ячеек и 4 в ячейке, на третьем - 5
+		require.NoError(t, repo.ReceiveProducts(Warehouse1, []models.ReceiptItem{{ProductArticle: "123", Quantity: 4, BinPath: "A/1/1/01"}}))
+		require.NoError(t, repo.ReceiveProducts(Warehouse3, []models.ReceiptItem{{ProductArticle: "123", Quantity: 5}}))
+
+		err := repo.CreateReservation(reservationUUID, []schemas.ProductWarehouseSplitted{
+			{ProductArticle: "123", WarehouseData: []schemas.WarehouseCounter{{WarehouseUUID: Warehouse1, Count: 6}}},
+		})
+		require.NoError(t, err)
+
+		lines := []models.PickLine{
+			{LineNo: 1, ReservationUUID: reservationUUID, ProductArticle: "123", WarehouseUUID: Warehouse1, LocationPath: "A/1/1/01", Quantity: 4, Status: models.PickLinePending},
+			{LineNo: 2, ReservationUUID: reservationUUID, ProductArticle: "123", WarehouseUUID: Warehouse1, Quantity: 2, Status: models.PickLinePending},
+		}
+		now := time.Now().UTC().Truncate(time.Second)
+		err = repo.CreatePickList(models.PickList{UUID: pickListUUID, Status: models.PickListOpen, CreatedAt: now, UpdatedAt: now, Lines: lines})
+		require.NoError(t, err)
+
+		// резерв может быть только в одном открытом листе отбора
+		err = repo.CreatePickList(models.PickList{UUID: "ae3f6091-4c7d-4e0f-9a2b-5c8d1e4f7a03", Status: models.PickListOpen, CreatedAt: now, UpdatedAt: now, Lines: lines[:1]})
+		assert.ErrorIs(t, err, models.ErrReservationPicking)
+
+		pickList, err := repo.GetPickList(pickListUUID)
+		require.NoError(t, err)
+		assert.Equal(t, models.PickListOpen, pickList.Status)
+		assert.WithinDuration(t, now, pickList.CreatedAt, time.Second)
+		assert.Equal(t, lines, pickList.Lines)
+
+		_, err = repo.GetPickList("bf4a71a2-5d8e-4f1a-8b3c-6d9e2f5a8b14")
+		assert.ErrorIs(t, err, models.ErrPickListNotFound)
+
+		// в ячейке нашли 3 из 4, недостачу зарезервировать негде
+		require.NoError(t, repo.ConfirmPickLine(pickListUUID, 1, 3, nil))
+		assert.ErrorIs(t, repo.ConfirmPickLine(pickListUUID, 1, 4, nil), models.ErrPickLineConfirmed)
+		assert.ErrorIs(t, repo.ConfirmPickLine(pickListUUID, 3, 1, nil), models.ErrPickListNotFound)
+		assertQuantity(t, repo, "123", Warehouse1, 13, 5)
+
+		// вне ячеек не нашли ничего, недостача резервируется на третьем складе
+		err = repo.ConfirmPickLine(pickListUUID, 2, 0, []schemas.WarehouseCounter{{WarehouseUUID: Warehouse3, Count: 2}})
+		require.NoError(t, err)
+		assertQuantity(t, repo, "123", Warehouse1, 13, 3)
+		assertQuantity(t, repo, "123", Warehouse3, 3, 2)
+
+		pickList, err = repo.GetPickList(pickListUUID)
+		require.NoError(t, err)
+		assert.Equal(t, models.PickListCompleted, pickList.Status)
+		assert.Equal(t, []models.PickLine{
+			{LineNo: 1, ReservationUUID: reservationUUID, ProductArticle: "123", WarehouseUUID: Warehouse1, LocationPath: "A/1/1/01", Quantity: 4, PickedQuantity: 3, Status: models.PickLineShort},
+			{LineNo: 2, ReservationUUID: reservationUUID, ProductArticle: "123", WarehouseUUID: Warehouse1, Quantity: 2, Status: models.PickLineShort},
+		}, pickList.Lines)
+
+		reservation, err := repo.GetReservation(reservationUUID)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []models.ReservationItem{
+			{ProductArticle: "123", WarehouseUUID: Warehouse1, Quantity: 6, ShortQuantity: 3, UnfulfilledQuantity: 1, Bins: []models.BinQuantity{
+				{Path: "A/1/1/01", Quantity: 3},
+			}},
+			{ProductArticle: "123", WarehouseUUID: Warehouse3, Quantity: 2},
+		}, reservation.Items)
+
+		stocks, err := repo.GetBinsStock(Warehouse1, []string{"123"})
+		require.NoError(t, err)
+		require.Len(t, stocks, 1)
+		assert.Equal(t, [2]int{0, 3}, [2]int{stocks[0].Quantity, stocks[0].ReservedQuantity})
+
+		// при отмене резерва на склад возвращается только то, что не списано
+		err = repo.ReleaseReservation(reservationUUID, []schemas.ProductWarehouseSplitted{
+			{ProductArticle: "123", WarehouseData: []schemas.WarehouseCounter{{WarehouseUUID: Warehouse1, Count: 3}, {WarehouseUUID: Warehouse3, Count: 2}}},
+		}, models.ReservationCancelled)
+		require.NoError(t, err)
+		assertQuantity(t, repo, "123", Warehouse1, 16, 0)
+		assertQuantity(t, repo, "123", Warehouse3, 5, 0)
+
+		stocks, err = repo.GetBinsStock(Warehouse1, []string{"123"})
+		require.NoError(t, err)
+		require.Len(t, stocks, 1)
+		assert.Equal(t, [2]int{3, 0}, [2]int{stocks[0].Quantity, stocks[0].ReservedQuantity})
+	})
+
+	t.Run("adjustments", func(t *testing.T) {
+		repo := setup(t)
+
+		now := time.Now().UTC().Truncate(time.Second)
+		adjustment := func(uuid string, delta int, reason string, status string, minutes int) models.Adjustment {
+			return models.Adjustment{
+				UUID:           uuid,
+				WarehouseUUID:  Warehouse1,
+				ProductArticle: "123",
+				Delta:          delta,
+				Reason:         reason,
+				Actor:          "inventory",
+				Status:         status,
+				CreatedAt:      now.Add(time.Duration(minutes) * time.Minute),
+			}
+		}
+
+		// у товара 123 на первом складе 15 штук без партии и вне ячеек
+		found := adjustment("0f1e2d3c-4b5a-4697-8877-665544332211", 5, models.AdjustmentFound, models.AdjustmentApplied, 0)
+		found.Comment = "behind the shelf"
+		require.NoError(t, repo.CreateAdjustment(found))
+		assertQuantity(t, repo, "123", Warehouse1, 20, 0)
+
+		saved, err := repo.GetAdjustment(found.UUID)
+		require.NoError(t, err)
+		assert.Equal(t, found, saved)
+
+		_, err = repo.GetAdjustment("1a2b3c4d-5e6f-4a7b-8c9d-0e1f2a3b4c5d")
+		assert.ErrorIs(t, err, models.ErrAdjustmentNotFound)
+
+		for _, location := range []models.Location{
+			{UUID: "5e6f7a8b-9c0d-4e1f-8a2b-3c4d5e6f7a81", WarehouseUUID: Warehouse1, Kind: models.LocationZone, Code: "A", Path: "A"},
+			{UUID: "6f7a8b9c-0d1e-4f2a-9b3c-4d5e6f7a8b92", WarehouseUUID: Warehouse1, ParentUUID: "5e6f7a8b-9c0d-4e1f-8a2b-3c4d5e6f7a81", Kind: models.LocationAisle, Code: "1", Path: "A/1"},
+			{UUID: "7a8b9c0d-1e2f-4a3b-8c4d-5e6f7a8b9ca3", WarehouseUUID: Warehouse1, ParentUUID: "6f7a8b9c-0d1e-4f2a-9b3c-4d5e6f7a8b92", Kind: models.LocationShelf, Code: "1", Path: "A/1/1"},
+			{UUID: "8b9c0d1e-2f3a-4b4c-9d5e-6f7a8b9c0db4", WarehouseUUID: Warehouse1, ParentUUID: "7a8b9c0d-1e2f-4a3b-8c4d-5e6f7a8b9ca3", Kind: models.LocationBin, Code: "01", Path: "A/1/1/01"},
+		} {
+			require.NoError(t, repo.CreateLocation(location))
+		}
+		err = repo.ReceiveProducts(Warehouse1, []models.ReceiptItem{
+			{ProductArticle: "123", Quantity: 3, Lot: &models.Lot{Number: "L1", ManufacturedAt: date("1999-01-01"), ExpiresAt: date("2999-01-01")}},
+			{ProductArticle: "123", Quantity: 4, BinPath: "A/1/1/01"},
+		})
+		require.NoError(t, err)
+		assertQuantity(t, repo, "123", Warehouse1, 27, 0)
+
+		// списать больше свободного остатка нельзя, корректировка не сохраняется
+		tooMuch := adjustment("2b3c4d5e-6f7a-4b8c-9d0e-1f2a3b4c5d6e", -28, models.AdjustmentCorrection, models.AdjustmentApplied, 1)
+		assert.ErrorIs(t, repo.CreateAdjustment(tooMuch), models.ErrNotEnoughProducts)
+		assertQuantity(t, repo, "123", Warehouse1, 27, 0)
+		_, err = repo.GetAdjustment(tooMuch.UUID)
+		assert.ErrorIs(t, err, models.ErrAdjustmentNotFound)
+
+		// списание берет сначала товар без партии и вне ячеек, затем партии и ячейки
+		require.NoError(t, repo.CreateAdjustment(adjustment("3c4d5e6f-7a8b-4c9d-8e0f-2a3b4c5d6e7f", -25, models.AdjustmentLost, models.AdjustmentApplied, 2)))
+		assertQuantity(t, repo, "123", Warehouse1, 2, 0)
+
+		lots, err := repo.GetLotsStock(Warehouse1, []string{"123"})
+		require.NoError(t, err)
+		require.Len(t, lots, 1)
+		assert.Equal(t, 2, lots[0].Quantity)
+
+		bins, err := repo.GetBinsStock(Warehouse1, []string{"123"})
+		require.NoError(t, err)
+		require.Len(t, bins, 1)
+		assert.Equal(t, 2, bins[0].Quantity)
+
+		// корректировка, ждущая подтверждения, не меняет остаток
+		pending := adjustment("4d5e6f7a-8b9c-4d0e-9f1a-3b4c5d6e7f80", 100, models.AdjustmentFound, models.AdjustmentPending, 3)
+		require.NoError(t, repo.CreateAdjustment(pending))
+		assertQuantity(t, repo, "123", Warehouse1, 2, 0)
+
+		adjustments, err := repo.GetAdjustments(Warehouse1, models.AdjustmentPending)
+		require.NoError(t, err)
+		assert.Equal(t, []models.Adjustment{pending}, adjustments)
+
+		reviewedAt := now.Add(time.Hour)
+		require.NoError(t, repo.ReviewAdjustment(pending.UUID, models.AdjustmentApplied, "admin", reviewedAt))
+		assertQuantity(t, repo, "123", Warehouse1, 102, 0)
+		assert.ErrorIs(t, repo.ReviewAdjustment(pending.UUID, models.AdjustmentRejected, "admin", reviewedAt), models.ErrAdjustmentReviewed)
+		assert.ErrorIs(t, repo.ReviewAdjustment("1a2b3c4d-5e6f-4a7b-8c9d-0e1f2a3b4c5d", models.AdjustmentApplied, "admin", reviewedAt), models.ErrAdjustmentNotFound)
+
+		saved, err = repo.GetAdjustment(pending.UUID)
+		require.NoError(t, err)
+		assert.Equal(t, models.AdjustmentApplied, saved.Status)
+		assert.Equal(t, "admin", saved.ReviewedBy)
+		require.NotNil(t, saved.ReviewedAt)
+		assert.WithinDuration(t, reviewedAt, *saved.ReviewedAt, time.Second)
+
+		// отклоненная корректировка не меняет остаток
+		rejected := adjustment("5e6f7a8b-9c0d-4e1f-8a2b-4c5d6e7f8091", -50, models.AdjustmentDamaged, models.AdjustmentPending, 4)
+		require.NoError(t, repo.CreateAdjustment(rejected))
+		require.NoError(t, repo.ReviewAdjustment(rejected.UUID, models.AdjustmentRejected, "admin", reviewedAt))
+		assertQuantity(t, repo, "123", Warehouse1, 102, 0)
+
+		// подтверждение списания больше свободного остатка не проходит, корректировка ждет дальше
+		short := adjustment("6f7a8b9c-0d1e-4f2a-9b3c-5d6e7f8091a2", -500, models.AdjustmentCorrection, models.AdjustmentPending, 5)
+		require.NoError(t, repo.CreateAdjustment(short))
+		assert.ErrorIs(t, repo.ReviewAdjustment(short.UUID, models.AdjustmentApplied, "admin", reviewedAt), models.ErrNotEnoughProducts)
+		assertQuantity(t, repo, "123", Warehouse1, 102, 0)
+
+		adjustments, err = repo.GetAdjustments(Warehouse1, "")
+		require.NoError(t, err)
+		statuses := make([]string, len(adjustments))
+		for i, adjustment := range adjustments {
+			statuses[i] = adjustment.Status
+		}
+		assert.Equal(t, []string{models.AdjustmentApplied, models.AdjustmentApplied, models.AdjustmentApplied, models.AdjustmentRejected, models.AdjustmentPending}, statuses)
+
+		adjustments, err = repo.GetAdjustments(Warehouse3, "")
+		require.NoError(t, err)
+		assert.Empty(t, adjustments)
+	})
+
+	t.Run("stocktakes", func(t *testing.T) {
+		repo := setup(t)
+
+		now := time.Now().UTC().Truncate(time.Second)
+		stocktake := models.Stocktake{
+			UUID:          "9a8b7c6d-5e4f-4a3b-8c2d-1e0f9a8b7c6d",
+			WarehouseUUID: Warehouse1,
+			Blind:         true,
+			Status:        models.StocktakeOpen,
+			CreatedBy:     "inventory",
+			CreatedAt:     now,
+		}
+		require.NoError(t, repo.CreateStocktake(stocktake))
+
+		// на складе уже идет инвентаризация всего склада
+		other := stocktake
+		other.UUID = "0b1c2d3e-4f5a-4b6c-9d7e-8f9a0b1c2d3e"
+		assert.ErrorIs(t, repo.CreateStocktake(other), models.ErrStocktakeOpen)
+
+		saved, err := repo.GetStocktake(stocktake.UUID)
+		require.NoError(t, err)
+		assert.Equal(t, models.StocktakeOpen, saved.Status)
+		assert.True(t, saved.Blind)
+		assert.Equal(t, "inventory", saved.CreatedBy)
+		var line models.StocktakeLine
+		for _, l := range saved.Lines {
+			if l.ProductArticle == "123" {
+				line = l
+			}
+		}
+		assert.Equal(t, models.StocktakeLine{LineNo: line.LineNo, ProductArticle: "123", Expected: 15}, line)
+
+		_, err = repo.GetStocktake("1a2b3c4d-5e6f-4a7b-8c9d-0e1f2a3b4c5d")
+		assert.ErrorIs(t, err, models.ErrStocktakeNotFound)
+
+		// резерв во время инвентаризации не меняет остаток вместе с резервом, пересчет 14 - недостача 1
+		err = repo.ReserveProducts([]schemas.ProductWarehouseSplitted{
+			{ProductArticle: "123", WarehouseData: []schemas.WarehouseCounter{{WarehouseUUID: Warehouse1, Count: 5}}},
+		})
+		require.NoError(t, err)
+		require.NoError(t, repo.CountStocktake(stocktake.UUID, []models.StocktakeCount{{ProductArticle: "123", Counted: 14}}, now))
+
+		// отгрузка после пересчета не искажает расхождение
+		err = repo.ReleaseProducts([]schemas.ProductWarehouseSplitted{
+			{ProductArticle: "123", WarehouseData: []schemas.WarehouseCounter{{WarehouseUUID: Warehouse1, Count: 5}}},
+		})
+		require.NoError(t, err)
+		assertQuantity(t, repo, "123", Warehouse1, 10, 0)
+
+		saved, err = repo.GetStocktake(stocktake.UUID)
+		require.NoError(t, err)
+		for _, l := range saved.Lines {
+			if l.ProductArticle == "123" {
+				line = l
+			}
+		}
+		require.NotNil(t, line.Counted)
+		assert.Equal(t, 14, *line.Counted)
+		assert.Equal(t, 15, line.OnHand)
+		assert.Equal(t, -1, line.Variance())
+		require.NotNil(t, line.CountedAt)
+		assert.WithinDuration(t, now, *line.CountedAt, time.Second)
+
+		closedAt := now.Add(time.Hour)
+		saved.ClosedBy = "inventory"
+		saved.ClosedAt = &closedAt
+		for i := range saved.Lines {
+			if saved.Lines[i].LineNo == line.LineNo {
+				saved.Lines[i].AdjustmentUUID = "1c2d3e4f-5a6b-4c7d-8e9f-0a1b2c3d4e5f"
+				saved.Lines[i].Unadjusted = 2
+			}
+		}
+		adjustment := models.Adjustment{
+			UUID: "1c2d3e4f-5a6b-4c7d-8e9f-0a1b2c3d4e5f", WarehouseUUID: Warehouse1, ProductArticle: "123", Delta: -1,
+			Reason: models.AdjustmentCorrection, Actor: "inventory", Status: models.AdjustmentApplied, CreatedAt: closedAt,
+		}
+		require.NoError(t, repo.CloseStocktake(saved, []models.Adjustment{adjustment}))
+		assertQuantity(t, repo, "123", Warehouse1, 9, 0)
+
+		closed, err := repo.GetStocktake(stocktake.UUID)
+		require.NoError(t, err)
+		assert.Equal(t, models.StocktakeClosed, closed.Status)
+		assert.Equal(t, "inventory", closed.ClosedBy)
+		require.NotNil(t, closed.ClosedAt)
+		assert.WithinDuration(t, closedAt, *closed.ClosedAt, time.Second)
+		for _, l := range closed.Lines {
+			if l.ProductArticle == "123" {
+				assert.Equal(t, adjustment.UUID, l.AdjustmentUUID)
+				assert.Equal(t, 2, l.Unadjusted)
+			} else {
+				assert.Empty(t, l.AdjustmentUUID)
+				assert.Zero(t, l.Unadjusted)
+			}
+		}
+
+		savedAdjustment, err := repo.GetAdjustment(adjustment.UUID)
+		require.NoError(t, err)
+		assert.Equal(t, adjustment, savedAdjustment)
+
+		assert.ErrorIs(t, repo.CloseStocktake(saved, nil), models.ErrStocktakeClosed)
+		assert.ErrorIs(t, repo.CountStocktake(stocktake.UUID, []models.StocktakeCount{{ProductArticle: "123", Counted: 1}}, now), models.ErrStocktakeClosed)
+
+		// инвентаризация зоны считает по ячейкам зоны, товар вне снимка добавляется строкой
+		for _, location := range []models.Location{
+			{UUID: "5e6f7a8b-9c0d-4e1f-8a2b-3c4d5e6f7a81", WarehouseUUID: Warehouse1, Kind: models.LocationZone, Code: "A", Path: "A"},
+			{UUID: "6f7a8b9c-0d1e-4f2a-9b3c-4d5e6f7a8b92", WarehouseUUID: Warehouse1, ParentUUID: "5e6f7a8b-9c0d-4e1f-8a2b-3c4d5e6f7a81", Kind: models.LocationAisle, Code: "1", Path: "A/1"},
+			{UUID: "7a8b9c0d-1e2f-4a3b-8c4d-5e6f7a8b9ca3", WarehouseUUID: Warehouse1, ParentUUID: "6f7a8b9c-0d1e-4f2a-9b3c-4d5e6f7a8b92", Kind: models.LocationShelf, Code: "1", Path: "A/1/1"},
+			{UUID: "8b9c0d1e-2f3a-4b4c-9d5e-6f7a8b9c0db4", WarehouseUUID: Warehouse1, ParentUUID: "7a8b9c0d-1e2f-4a3b-8c4d-5e6f7a8b9ca3", Kind: models.LocationBin, Code: "01", Path: "A/1/1/01"},
+			{UUID: "9c0d1e2f-3a4b-4c5d-8e6f-7a8b9c0d1ec5", WarehouseUUID: Warehouse1, Kind: models.LocationZone, Code: "B", Path: "B"},
+			{UUID: "0d1e2f3a-4b5c-4d6e-9f7a-8b9c0d1e2fd6", WarehouseUUID: Warehouse1, ParentUUID: "9c0d1e2f-3a4b-4c5d-8e6f-7a8b9c0d1ec5", Kind: models.LocationAisle, Code: "1", Path: "B/1"},
+			{UUID: "1e2f3a4b-5c6d-4e7f-8a9b-9c0d1e2f3ae7", WarehouseUUID: Warehouse1, ParentUUID: "0d1e2f3a-4b5c-4d6e-9f7a-8b9c0d1e2fd6", Kind: models.LocationShelf, Code: "1", Path: "B/1/1"},
+			{UUID: "2f3a4b5c-6d7e-4f8a-9b0c-0d1e2f3a4bf8", WarehouseUUID: Warehouse1, ParentUUID: "1e2f3a4b-5c6d-4e7f-8a9b-9c0d1e2f3ae7", Kind: models.LocationBin, Code: "01", Path: "B/1/1/01"},
+		} {
+			require.NoError(t, repo.CreateLocation(location))
+		}
+		err = repo.ReceiveProducts(Warehouse1, []models.ReceiptItem{
+			{ProductArticle: "123", Quantity: 4, BinPath: "A/1/1/01"},
+			{ProductArticle: "123", Quantity: 3, BinPath: "B/1/1/01"},
+		})
+		require.NoError(t, err)
+
+		zone := models.Stocktake{
+			UUID:          "2d3e4f5a-6b7c-4d8e-9f0a-1b2c3d4e5f6a",
+			WarehouseUUID: Warehouse1,
+			ZoneUUID:      "5e6f7a8b-9c0d-4e1f-8a2b-3c4d5e6f7a81",
+			ZonePath:      "A",
+			Status:        models.StocktakeOpen,
+			CreatedAt:     now,
+		}
+		require.NoError(t, repo.CreateStocktake(zone))
+
+		// та же зона занята, другая зона свободна, весь склад - нет
+		sameZone := zone
+		sameZone.UUID = "3e4f5a6b-7c8d-4e9f-8a0b-2c3d4e5f6a7b"
+		assert.ErrorIs(t, repo.CreateStocktake(sameZone), models.ErrStocktakeOpen)
+		assert.ErrorIs(t, repo.CreateStocktake(other), models.ErrStocktakeOpen)
+		otherZone := models.Stocktake{
+			UUID: "4f5a6b7c-8d9e-4f0a-9b1c-3d4e5f6a7b8c", WarehouseUUID: Warehouse1, ZoneUUID: "9c0d1e2f-3a4b-4c5d-8e6f-7a8b9c0d1ec5",
+			ZonePath: "B", Status: models.StocktakeOpen, CreatedAt: now,
+		}
+		require.NoError(t, repo.CreateStocktake(otherZone))
+
+		saved, err = repo.GetStocktake(zone.UUID)
+		require.NoError(t, err)
+		assert.Equal(t, "A", saved.ZonePath)
+		assert.Equal(t, []models.StocktakeLine{{LineNo: 1, ProductArticle: "123", LocationPath: "A/1/1/01", Expected: 4}}, saved.Lines)
+
+		err = repo.CountStocktake(zone.UUID, []models.StocktakeCount{
+			{ProductArticle: "123", LocationPath: "A/1/1/01", Counted: 6},
+			{ProductArticle: "987", LocationPath: "A/1/1/01", Counted: 0},
+		}, now)
+		require.NoError(t, err)
+
+		saved, err = repo.GetStocktake(zone.UUID)
+		require.NoError(t, err)
+		require.Len(t, saved.Lines, 2)
+		assert.Equal(t, 2, saved.Lines[0].Variance())
+		assert.Equal(t, "987", saved.Lines[1].ProductArticle)
+		assert.Equal(t, "A/1/1/01", saved.Lines[1].LocationPath)
+		assert.Equal(t, 0, saved.Lines[1].Expected)
+		assert.Equal(t, 0, saved.Lines[1].Variance())
+
+		saved.ClosedAt = &closedAt
+		saved.Lines[0].AdjustmentUUID = "5a6b7c8d-9e0f-4a1b-8c2d-4e5f6a7b8c9d"
+		binAdjustment := models.Adjustment{
+			UUID: "5a6b7c8d-9e0f-4a1b-8c2d-4e5f6a7b8c9d", WarehouseUUID: Warehouse1, ProductArticle: "123", BinPath: "A/1/1/01",
+			Delta: 2, Reason: models.AdjustmentCorrection, Actor: "stocktake", Status: models.AdjustmentApplied, CreatedAt: closedAt,
+		}
+		require.NoError(t, repo.CloseStocktake(saved, []models.Adjustment{binAdjustment}))
+		assertQuantity(t, repo, "123", Warehouse1, 18, 0)
+
+		bins, err := repo.GetBinsStock(Warehouse1, []string{"123"})
+		require.NoError(t, err)
+		require.Len(t, bins, 2)
+		assert.Equal(t, "A/1/1/01", bins[0].Path)
+		assert.Equal(t, 6, bins[0].Quantity)
+
+		savedAdjustment, err = repo.GetAdjustment(binAdjustment.UUID)
+		require.NoError(t, err)
+		assert.Equal(t, binAdjustment, savedAdjustment)
+
+		// недостачу в ячейке можно списать только из свободного остатка ячейки, иначе инвентаризация не закрывается
+		require.NoError(t, repo.CountStocktake(otherZone.UUID, []models.StocktakeCount{{ProductArticle: "123", LocationPath: "B/1/1/01", Counted: 0}}, now))
+		short, err := repo.GetStocktake(otherZone.UUID)
+		require.NoError(t, err)
+		short.ClosedAt = &closedAt
+		short.Lines[0].AdjustmentUUID = "6b7c8d9e-0f1a-4b2c-9d3e-5f6a7b8c9d0e"
+		err = repo.MoveStock(Warehouse1, []models.StockMove{{ProductArticle: "123", Quantity: 2, FromPath: "B/1/1/01"}})
+		require.NoError(t, err)
+		writeOff := models.Adjustment{
+			UUID: "6b7c8d9e-0f1a-4b2c-9d3e-5f6a7b8c9d0e", WarehouseUUID: Warehouse1, ProductArticle: "123", BinPath: "B/1/1/01",
+			Delta: -3, Reason: models.AdjustmentCorrection, Actor: "stocktake", Status: models.AdjustmentApplied, CreatedAt: closedAt,
+		}
+		assert.ErrorIs(t, repo.CloseStocktake(short, []models.Adjustment{writeOff}), models.ErrNotEnoughProducts)
+
+		short, err = repo.GetStocktake(otherZone.UUID)
+		require.NoError(t, err)
+		assert.Equal(t, models.StocktakeOpen, short.Status)
+		assert.Empty(t, short.Lines[0].AdjustmentUUID)
+		assertQuantity(t, repo, "123", Warehouse1, 18, 0)
+	})
+
+	t.Run("returns", func(t *testing.T) {
+		repo := setup(t)
+
+		now := time.Now().UTC().Truncate(time.Second)
+		newReturn := func(uuid string, quantity int) models.Return {
+			return models.Return{
+				UUID:           uuid,
+				WarehouseUUID:  Warehouse1,
+				ProductArticle: "123",
+				Quantity:       quantity,
+				Reference:      "order-42",
+				Reason:         "wrong size",
+				Status:         models.ReturnCreated,
+				CreatedBy:      "support",
+				CreatedAt:      now,
+			}
+		}
+
+		restock := newReturn("3a4b5c6d-7e8f-4a9b-8c0d-1e2f3a4b5c6d", 2)
+		require.NoError(t, repo.CreateReturn(restock))
+
+		saved, err := repo.GetReturn(restock.UUID)
+		require.NoError(t, err)
+		assert.Equal(t, restock, saved)
+
+		_, err = repo.GetReturn("1a2b3c4d-5e6f-4a7b-8c9d-0e1f2a3b4c5d")
+		assert.ErrorIs(t, err, models.ErrReturnNotFound)
+
+		unknown := newReturn("4b5c6d7e-8f9a-4b0c-9d1e-2f3a4b5c6d7e", 1)
+		unknown.ProductArticle = "unknown"
+		assert.ErrorIs(t, repo.CreateReturn(unknown), models.ErrProductNotFound)
+
+		// осмотреть можно только принятый возврат
+		assert.ErrorIs(t, repo.InspectReturn(restock.UUID, models.ReturnRestock, "", "inspector", now), models.ErrReturnStatus)
+		assert.ErrorIs(t, repo.ReceiveReturn("1a2b3c4d-5e6f-4a7b-8c9d-0e1f2a3b4c5d", "clerk", now), models.ErrReturnNotFound)
+
+		receivedAt := now.Add(time.Hour)
+		require.NoError(t, repo.ReceiveReturn(restock.UUID, "clerk", receivedAt))
+		assert.ErrorIs(t, repo.ReceiveReturn(restock.UUID, "clerk", receivedAt), models.ErrReturnStatus)
+		assertQuantity(t, repo, "123", Warehouse1, 15, 0)
+
+		inspectedAt := now.Add(2 * time.Hour)
+		require.NoError(t, repo.InspectReturn(restock.UUID, models.ReturnRestock, "sealed", "inspector", inspectedAt))
+		assertQuantity(t, repo, "123", Warehouse1, 17, 0)
+		assert.ErrorIs(t, repo.InspectReturn(restock.UUID, models.ReturnScrap, "", "inspector", inspectedAt), models.ErrReturnStatus)
+
+		saved, err = repo.GetReturn(restock.UUID)
+		require.NoError(t, err)
+		assert.Equal(t, models.ReturnInspected, saved.Status)
+		assert.Equal(t, models.ReturnRestock, saved.Outcome)
+		assert.Equal(t, "sealed", saved.Comment)
+		assert.Equal(t, "clerk", saved.ReceivedBy)
+		require.NotNil(t, saved.ReceivedAt)
+		assert.WithinDuration(t, receivedAt, *saved.ReceivedAt, time.Second)
+		assert.Equal(t, "inspector", saved.InspectedBy)
+		require.NotNil(t, saved.InspectedAt)
+		assert.WithinDuration(t, inspectedAt, *saved.InspectedAt, time.Second)
+
+		// товар на карантине и утилизированный товар в свободный остаток не попадают
+		for returnUUID, outcome := range map[string]string{
+			"5c6d7e8f-9a0b-4c1d-8e2f-3a4b5c6d7e8f": models.ReturnQuarantine,
+			"6d7e8f9a-0b1c-4d2e-9f3a-4b5c6d7e8f9a": models.ReturnQuarantine,
+			"7e8f9a0b-1c2d-4e3f-8a4b-5c6d7e8f9a0b": models.ReturnScrap,
+		} {
+			ret := newReturn(returnUUID, 3)
+			require.NoError(t, repo.CreateReturn(ret))
+			require.NoError(t, repo.ReceiveReturn(ret.UUID, "clerk", receivedAt))
+			require.NoError(t, repo.InspectReturn(ret.UUID, outcome, "", "inspector", inspectedAt))
+		}
+		assertQuantity(t, repo, "123", Warehouse1, 17, 0)
+
+		buckets, err := repo.GetBucketsStock([]string{"123"}, nil)
+		require.NoError(t, err)
+		assert.Equal(t, []models.BucketStock{
+			{ProductArticle: "123", WarehouseUUID: Warehouse1, Bucket: models.BucketQuarantine, Quantity: 6},
+		}, buckets)
+	})
+
+	t.Run("buckets", func(t *testing.T) {
+		repo := setup(t)
+
+		move := func(article string, quantity int, from string, to string) models.BucketMove {
+			return models.BucketMove{ProductArticle: article, Quantity: quantity, From: from, To: to}
+		}
+		assertBuckets := func(t *testing.T, expected ...models.BucketStock) {
+			t.Helper()
+
+			buckets, err := repo.GetBucketsStock([]string{"123"}, []string{Warehouse1})
+			require.NoError(t, err)
+			assert.Equal(t, append([]models.BucketStock{}, expected...), buckets)
+		}
+		bucket := func(name string, quantity int) models.BucketStock {
+			return models.BucketStock{ProductArticle: "123", WarehouseUUID: Warehouse1, Bucket: name, Quantity: quantity}
+		}
+
+		// из продаваемой корзины товар уходит вместе с партиями, резервировать его больше нельзя
+		require.NoError(t, repo.ReceiveProducts(Warehouse1, []models.ReceiptItem{
+			{ProductArticle: "123", Quantity: 5, Lot: &models.Lot{Number: "lot", ExpiresAt: date("2999-12-31")}},
+		}))
+		require.NoError(t, repo.MoveBucketStock(Warehouse1, []models.BucketMove{
+			move("123", 16, models.BucketSellable, models.BucketDamaged),
+			move("123", 1, models.BucketSellable, models.BucketQuarantine),
+		}))
+		assertQuantity(t, repo, "123", Warehouse1, 3, 0)
+		assertBuckets(t, bucket(models.BucketDamaged, 16), bucket(models.BucketQuarantine, 1))
+
+		productStocks, err := repo.GetProductsStock([]string{"123"}, nil)
+		require.NoError(t, err)
+		require.Len(t, productStocks, 1)
+		assert.Equal(t, 3, productStocks[0].Quantity)
+		assert.Equal(t, map[string]int{models.BucketDamaged: 16, models.BucketQuarantine: 1}, productStocks[0].Buckets)
+
+		lots, err := repo.GetLotsStock(Warehouse1, []string{"123"})
+		require.NoError(t, err)
+		require.Len(t, lots, 1)
+		assert.Equal(t, 3, lots[0].Quantity)
+
+		require.NoError(t, repo.MoveBucketStock(Warehouse1, []models.BucketMove{
+			move("123", 2, models.BucketDamaged, models.BucketQuarantine),
+			move("123", 3, models.BucketQuarantine, models.BucketSellable),
+		}))
+		assertQuantity(t, repo, "123", Warehouse1, 6, 0)
+		assertBuckets(t, bucket(models.BucketDamaged, 14))
+
+		// перемещения выполняются все вместе или ни одно
+		err = repo.MoveBucketStock(Warehouse1, []models.BucketMove{
+			move("123", 4, models.BucketDamaged, models.BucketInTransit),
+			move("123", 11, models.BucketDamaged, models.BucketSellable),
+		})
+		assert.ErrorIs(t, err, models.ErrNotEnoughProducts)
+		err = repo.MoveBucketStock(Warehouse1, []models.BucketMove{move("123", 7, models.BucketSellable, models.BucketDamaged)})
+		assert.ErrorIs(t, err, models.ErrNotEnoughProducts)
+		err = repo.MoveBucketStock(Warehouse1, []models.BucketMove{move("unknown", 1, models.BucketDamaged, models.BucketSellable)})
+		assert.ErrorIs(t, err, models.ErrProductNotFound)
+		assertQuantity(t, repo, "123", Warehouse1, 6, 0)
+		assertBuckets(t, bucket(models.BucketDamaged, 14))
+
+		// товар только в непродаваемой корзине виден в отчетах об остатках
+		require.NoError(t, repo.MoveBucketStock(Warehouse1, []models.BucketMove{move("123", 6, models.BucketSellable, models.BucketDamaged)}))
+		products, _, err := repo.GetRemainingProductsByWarehouse(Warehouse1, schemas.RemainingProductsFilter{ArticlePrefix: "123"})
+		require.NoError(t, err)
+		require.Len(t, products, 1)
+		assert.Equal(t, 0, products[0].Quantity)
+
+		ret := models.Return{
+			UUID: "8f9a0b1c-2d3e-4f4a-9b5c-6d7e8f9a0b1c", WarehouseUUID: Warehouse1, ProductArticle: "654", Quantity: 2,
+			Status: models.ReturnCreated, CreatedAt: time.Now().UTC(),
+		}
+		require.NoError(t, repo.CreateReturn(ret))
+		require.NoError(t, repo.ReceiveReturn(ret.UUID, "clerk", time.Now().UTC()))
+		require.NoError(t, repo.InspectReturn(ret.UUID, models.ReturnQuarantine, "", "inspector", time.Now().UTC()))
+
+		stocks, err := repo.GetProductsStock([]string{"654"}, []string{Warehouse1})
+		require.NoError(t, err)
+		require.Len(t, stocks, 1)
+		assert.Equal(t, 0, stocks[0].Quantity)
+		assert.Equal(t, map[string]int{models.BucketQuarantine: 2}, stocks[0].Buckets)
+
+		buckets, err := repo.GetBucketsStock([]string{"654"}, nil)
+		require.NoError(t, err)
+		assert.Equal(t, []models.BucketStock{
+			{ProductArticle: "654", WarehouseUUID: Warehouse1, Bucket: models.BucketQuarantine, Quantity: 2},
+		}, buckets)
+	})
+
+	t.Run("bucket lots and bins", func(t *testing.T) {
+		repo := setup(t)
+
+		const (
+			zoneUUID  = "6d7e8f9a-0b1c-4d2e-8f3a-7b8c9d0e1f26"
+			aisleUUID = "7e8f9a0b-1c2d-4e3f-9a4b-8c9d0e1f2a37"
+			shelfUUID = "8f9a0b1c-2d3e-4f4a-8b5c-9d0e1f2a3b48"
+			binUUID   = "9a0b1c2d-3e4f-4a5b-9c6d-0e1f2a3b4c59"
+		)
+		for _, location := range []models.Location{
+			{UUID: zoneUUID, WarehouseUUID: Warehouse1, Kind: models.LocationZone, Code: "A", Path: "A"},
+			{UUID: aisleUUID, WarehouseUUID: Warehouse1, ParentUUID: zoneUUID, Kind: models.LocationAisle, Code: "1", Path: "A/1"},
+			{UUID: shelfUUID, WarehouseUUID: Warehouse1, ParentUUID: aisleUUID, Kind: models.LocationShelf, Code: "1", Path: "A/1/1"},
+			{UUID: binUUID, WarehouseUUID: Warehouse1, ParentUUID: shelfUUID, Kind: models.LocationBin, Code: "01", Path: "A/1/1/01"},
+		} {
+			require.NoError(t, repo.CreateLocation(location))
+		}
+
+		move := func(quantity int, from string, to string) models.BucketMove {
+			return models.BucketMove{ProductArticle: "111", Quantity: quantity, From: from, To: to}
+		}
+		lotsOf := func() map[string]int {
+			stocks, err := repo.GetLotsStock(Warehouse1, []string{"111"})
+			require.NoError(t, err)
+
+			result := make(map[string]int)
+			for _, stock := range stocks {
+				result[stock.Lot.Number] = stock.Quantity
+			}
+			return result
+		}
+		binsOf := func() map[string]int {
+			stocks, err := repo.GetBinsStock(Warehouse1, []string{"111"})
+			require.NoError(t, err)
+
+			result := make(map[string]int)
+			for _, stock := range stocks {
+				result[stock.Path] = stock.Quantity
+			}
+			return result
+		}
+
+		// просроченная партия лежит в ячейке, свежая - вне ячеек; резервировать можно только свежую
+		require.NoError(t, repo.ReceiveProducts(Warehouse1, []models.ReceiptItem{
+			{ProductArticle: "111", Quantity: 2, Lot: &models.Lot{Number: "old", ExpiresAt: date("2000-01-01")}, BinPath: "A/1/1/01"},
+			{ProductArticle: "111", Quantity: 3, Lot: &models.Lot{Number: "new", ExpiresAt: date("2999-01-01")}},
+		}))
+		assertQuantity(t, repo, "111", Warehouse1, 3, 0)
+
+		// товар уходит в корзины и переходит между ними вместе с партиями и ячейками
+		require.NoError(t, repo.MoveBucketStock(Warehouse1, []models.BucketMove{
+			move(5, models.BucketSellable, models.BucketQuarantine),
+			move(4, models.BucketQuarantine, models.BucketDamaged),
+		}))
+		assertQuantity(t, repo, "111", Warehouse1, 0, 0)
+		assert.Empty(t, lotsOf())
+		assert.Empty(t, binsOf())
+
+		// и возвращается в те же партии и ячейки: просроченный товар по-прежнему не резервируется
+		require.NoError(t, repo.MoveBucketStock(Warehouse1, []models.BucketMove{
+			move(4, models.BucketDamaged, models.BucketSellable),
+			move(1, models.BucketQuarantine, models.BucketSellable),
+		}))
+		assertQuantity(t, repo, "111", Warehouse1, 3, 0)
+		assert.Equal(t, map[string]int{"old": 2, "new": 3}, lotsOf())
+		assert.Equal(t, map[string]int{"A/1/1/01": 2}, binsOf())
+	})
+
+	t.Run("kits", func(t *testing.T) {
+		repo := setup(t)
+
+		component := func(article string, quantity int) models.KitComponent {
+			return models.KitComponent{ProductArticle: article, Quantity: quantity}
+		}
+
+		require.NoError(t, repo.SaveKit(models.Kit{ProductArticle: "111", Components: []models.KitComponent{component("456", 1)}}))
+		require.NoError(t, repo.SaveKit(models.Kit{ProductArticle: "111", Components: []models.KitComponent{component("987", 1), component("123", 2)}}))
+		require.NoError(t, repo.SaveKit(models.Kit{ProductArticle: "321", Components: []models.KitComponent{component("456", 3)}}))
+
+		gift := models.Kit{
+			ProductArticle: "111", ProductName: "product7", ProductSize: "70",
+			Components: []models.KitComponent{component("123", 2), component("987", 1)},
+		}
+
+		kits, err := repo.GetKits([]string{"111", "123"})
+		require.NoError(t, err)
+		assert.Equal(t, []models.Kit{gift}, kits)
+
+		kits, err = repo.GetKits(nil)
+		require.NoError(t, err)
+		require.Len(t, kits, 2)
+		assert.Equal(t, gift, kits[0])
+		assert.Equal(t, "321", kits[1].ProductArticle)
+
+		// состав меняется целиком или никак
+		err = repo.SaveKit(models.Kit{ProductArticle: "111", Components: []models.KitComponent{component("456", 1), component("unknown", 1)}})
+		assert.ErrorIs(t, err, models.ErrProductNotFound)
+		err = repo.SaveKit(models.Kit{ProductArticle: "unknown", Components: []models.KitComponent{component("456", 1)}})
+		assert.ErrorIs(t, err, models.ErrProductNotFound)
+
+		kits, err = repo.GetKits([]string{"111"})
+		require.NoError(t, err)
+		assert.Equal(t, []models.Kit{gift}, kits)
+
+		require.NoError(t, repo.DeleteKit("321"))
+		assert.ErrorIs(t, repo.DeleteKit("321"), models.ErrKitNotFound)
+		assert.ErrorIs(t, repo.DeleteKit("unknown"), models.ErrKitNotFound)
+
+		kits, err = repo.GetKits([]string{"321"})
+		require.NoError(t, err)
+		assert.Empty(t, kits)
+	})
+
+	t.Run("substitutes", func(t *testing.T) {
+		repo := setup(t)
+
+		require.NoError(t, repo.SaveSubstitutes("123", []models.Substitute{{ProductArticle: "456"}}))
+		require.NoError(t, repo.SaveSubstitutes("123", []models.Substitute{
+			{ProductArticle: "789", SameSize: true},
+			{ProductArticle: "456"},
+		}))
+
+		expected := []models.Substitute{
+			{ProductArticle: "789", ProductName: "product3", ProductSize: "30", SameSize: true},
+			{ProductArticle: "456", ProductName: "product2", ProductSize: "20"},
+		}
+		substitutes, err := repo.GetSubstitutes("123")
+		require.NoError(t, err)
+		assert.Equal(t, expected, substitutes)
+
+		// замены меняются целиком или никак
+		err = repo.SaveSubstitutes("123", []models.Substitute{{ProductArticle: "987"}, {ProductArticle: "unknown"}})
+		assert.ErrorIs(t, err, models.ErrProductNotFound)
+		assert.ErrorIs(t, repo.SaveSubstitutes("unknown", nil), models.ErrProductNotFound)
+
+		substitutes, err = repo.GetSubstitutes("123")
+		require.NoError(t, err)
+		assert.Equal(t, expected, substitutes)
+
+		substitutes, err = repo.GetSubstitutes("456")
+		require.NoError(t, err)
+		assert.Empty(t, substitutes)
+
+		// замена хранится в резерве под своим артикулом с артикулом заменяемого товара
+		const reservationUUID = "6a1b2c3d-4e5f-4a6b-8c7d-9e0f1a2b3c4d"
+		require.NoError(t, repo.CreateReservation(reservationUUID, []schemas.ProductWarehouseSplitted{
+			{ProductArticle: "456", WarehouseData: []schemas.WarehouseCounter{{WarehouseUUID: Warehouse1, Count: 3}}, SubstituteFor: "123"},
+			{ProductArticle: "789", WarehouseData: []schemas.WarehouseCounter{{WarehouseUUID: Warehouse1, Count: 1}}},
+		}))
+		assertQuantity(t, repo, "456", Warehouse1, 22, 5)
+
+		reservation, err := repo.GetReservation(reservationUUID)
+		require.NoError(t, err)
+		require.Len(t, reservation.Items, 2)
+		assert.Equal(t, "456", reservation.Items[0].ProductArticle)
+		assert.Equal(t, "123", reservation.Items[0].SubstituteFor)
+		assert.Equal(t, "", reservation.Items[1].SubstituteFor)
+
+		require.NoError(t, repo.SaveSubstitutes("123", nil))
+		substitutes, err = repo.GetSubstitutes("123")
+		require.NoError(t, err)
+		assert.Empty(t, substitutes)
+	})
+
+	t.Run("concurrent ReserveProducts never oversell", func(t *testing.T) {
+		repo := setup(t)
+
+		// товара 123 всего 15 штук на первом складе, резервируем по одной в 50 потоков
+		const workers = 50
+
+		var (
+			wg       sync.WaitGroup
+			reserved atomic.Int64
+		)
+
+		for i := 0; i < workers; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				err := repo.ReserveProducts([]schemas.ProductWarehouseSplitted{
+					{
+						ProductArticle: "123",
+						WarehouseData:  []schemas.WarehouseCounter{{WarehouseUUID: Warehouse1, Count: 1}},
+					},
+				})
+				if err == nil {
+					reserved.Add(1)
+				}
+			}()
+		}
+		wg.Wait()
+
+		assert.EqualValues(t, 15, reserved.Load())
+		assertQuantity(t, repo, "123", Warehouse1, 0, 15)
+	})
+
+	t.Run("api keys", func(t *testing.T) {
+		repo := newRepo(t)
+
+		const (
+			key1UUID = "8d0c5b7e-3f2a-4c61-9e8b-1a2b3c4d5e6f"
+			key2UUID = "2e7f9a1c-5b3d-4e8f-a0b1-c2d3e4f5a6b7"
+		)
+
+		_, err := repo.GetAPIKeyByHash(strings.Repeat("a", 64))
+		assert.ErrorIs(t, err, models.ErrAPIKeyNotFound)
+		assert.ErrorIs(t, repo.RevokeAPIKey(key1UUID), models.ErrAPIKeyNotFound)
+
+		createdAt := time.Now().UTC().Truncate(time.Second)
+		key1 := models.APIKey{
+			UUID:       key1UUID,
+			Name:       "billing",
+			Hash:       strings.Repeat("a", 64),
+			Scopes:     []string{"stock:read", "stock:reserve"},
+			Warehouses: []string{"7a1b2c3d-4e5f-4a6b-8c7d-9e0f1a2b3c4d", "0f9e8d7c-6b5a-4948-8776-655443322110"},
+			CreatedAt:  createdAt,
+		}
+		key2 := models.APIKey{
+			UUID:       key2UUID,
+			Name:       "admin",
+			Hash:       strings.Repeat("b", 64),
+			Scopes:     []string{"admin"},
+			Warehouses: []string{},
+			CreatedAt:  createdAt.Add(time.Second),
+		}
+		require.NoError(t, repo.CreateAPIKey(key1))
+		require.NoError(t, repo.CreateAPIKey(key2))
+
+		// хэш уникален
+		duplicate := key2
+		duplicate.UUID = "6c1d2e3f-4a5b-4c6d-8e7f-9a0b1c2d3e4f"
+		assert.Error(t, repo.CreateAPIKey(duplicate))
+
+		key, err := repo.GetAPIKeyByHash(key1.Hash)
+		require.NoError(t, err)
+		assert.Equal(t, key1, key)
+
+		require.NoError(t, repo.RevokeAPIKey(key1UUID))
+
+		key, err = repo.GetAPIKeyByHash(key1.Hash)
+		require.NoError(t, err)
+		require.NotNil(t, key.RevokedAt)
+		assert.WithinDuration(t, time.Now(), *key.RevokedAt, time.Minute)
+		revokedAt := *key.RevokedAt
+
+		// повторный отзыв не меняет время отзыва
+		require.NoError(t, repo.RevokeAPIKey(key1UUID))
+		key, err = repo.GetAPIKeyByHash(key1.Hash)
+		require.NoError(t, err)
+		assert.Equal(t, revokedAt, *key.RevokedAt)
+
+		// пустой список складов открывает клиенту все склады
+		require.NoError(t, repo.SetAPIKeyWarehouses(key1UUID, nil))
+		key1.Warehouses = []string{}
+		require.NoError(t, repo.SetAPIKeyWarehouses(key2UUID, []string{"7a1b2c3d-4e5f-4a6b-8c7d-9e0f1a2b3c4d"}))
+		key2.Warehouses = []string{"7a1b2c3d-4e5f-4a6b-8c7d-9e0f1a2b3c4d"}
+		// повторная установка тех же складов не считается отсутствием ключа
+		require.NoError(t, repo.SetAPIKeyWarehouses(key2UUID, key2.Warehouses))
+		assert.ErrorIs(t, repo.SetAPIKeyWarehouses("6c1d2e3f-4a5b-4c6d-8e7f-9a0b1c2d3e4f", nil), models.ErrAPIKeyNotFound)
+
+		keys, err := repo.ListAPIKeys()
+		require.NoError(t, err)
+		key1.RevokedAt = &revokedAt
+		assert.Equal(t, []models.APIKey{key1, key2}, keys)
+	})
+}
+
+// assertQuantity проверяет остатки товара на доступном складе
+// date возвращает дату YYYY-MM-DD для партий
+func date(value string) *time.Time {
+	result, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		panic(err)
+	}
+	return &result
+}
+
+func assertQuantity(t *testing.T, repo Repository, article string, warehouseUUID string, quantity int, reserved int) {
+	t.Helper()
+
+	products, err := repo.GetProductsQuantity(article)
+	require.NoError(t, err)
+
+	for _, product := range products {
+		if product.WarehouseUUID == warehouseUUID {
+			assert.Equal(t, quantity, product.Quantity, "quantity of %s in %s", article, warehouseUUID)
+			assert.Equal(t, reserved, product.ReservedQuantity, "reserved quantity of %s in %s", article, warehouseUUID)
+			return
+		}
+	}
+
+	t.Errorf("product %s not found in warehouse %s", article, warehouseUUID)
+}
@@ -0,0 +1,61 @@
+package repository
+
+import "github.com/shamank/warehouse-service/internal/domain/models"
+
+// SerialMove - переход серийных номеров штучного товара при операции с остатками.
+// Номера берутся из состояний From: с FromReservation - из этого резерва, без него - вне резервов v2.
+// Если номеров меньше, чем нужно, операция завершается ошибкой Err
+type SerialMove struct {
+	From            []string
+	FromReservation string
+	To              string
+	ToReservation   string
+	Err             error
+}
+
+// ReserveSerials резервирует номера, пустой reservationUUID - резерв v1
+func ReserveSerials(reservationUUID string) SerialMove {
+	return SerialMove{
+		From:          []string{models.SerialAvailable, models.SerialReturned},
+		To:            models.SerialReserved,
+		ToReservation: reservationUUID,
+		Err:           models.ErrNotEnoughProducts,
+	}
+}
+
+// ShipSerials отгружает номера, зарезервированные через v1
+func ShipSerials() SerialMove {
+	return SerialMove{
+		From: []string{models.SerialReserved},
+		To:   models.SerialShipped,
+		Err:  models.ErrNotEnoughReserved,
+	}
+}
+
+// ReleaseSerials возвращает на склад номера из резерва v2
+func ReleaseSerials(reservationUUID string) SerialMove {
+	return SerialMove{
+		From:            []string{models.SerialReserved},
+		FromReservation: reservationUUID,
+		To:              models.SerialAvailable,
+		Err:             models.ErrNotEnoughReserved,
+	}
+}
+
+// LoseSerials списывает номера из резерва v2, которые не нашли при отборе
+func LoseSerials(reservationUUID string) SerialMove {
+	return SerialMove{
+		From:            []string{models.SerialReserved},
+		FromReservation: reservationUUID,
+		To:              models.SerialMissing,
+		Err:             models.ErrNotEnoughReserved,
+	}
+}
+
+// NullString возвращает nil для пустой строки, чтобы в колонку записался NULL
+func NullString(s string) any {
+	if s == "" {
+		return nil
+	}
+	return s
+}
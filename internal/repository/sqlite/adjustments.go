@@ -0,0 +1,225 @@
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/shamank/warehouse-service/internal/domain/models"
+	"github.com/shamank/warehouse-service/internal/repository"
+	"time"
+)
+
+const adjustmentsQuery = `SELECT ia.uuid, ia.warehouse_uuid, p.article, COALESCE(l.path, ''), ia.delta, ia.reason, ia.comment,
+					ia.actor, ia.status, ia.created_at, COALESCE(ia.reviewed_by, ''), ia.reviewed_at
+				FROM inventory_adjustments ia
+					INNER JOIN products p ON p.uuid = ia.product_uuid
+					LEFT JOIN locations l ON l.uuid = ia.location_uuid`
+
+// CreateAdjustment сохраняет корректировку. Корректировка в статусе models.AdjustmentApplied сразу меняет остаток
+func (r *SQLiteRepo) CreateAdjustment(adjustment models.Adjustment) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	if err := r.createAdjustment(tx, adjustment); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *SQLiteRepo) createAdjustment(tx *sql.Tx, adjustment models.Adjustment) error {
+	var locationUUID string
+	if adjustment.BinPath != "" {
+		var err error
+		locationUUID, err = r.binUUID(tx, adjustment.WarehouseUUID, adjustment.BinPath)
+		if err != nil {
+			return err
+		}
+	}
+
+	if adjustment.Status == models.AdjustmentApplied {
+		if err := r.applyAdjustment(tx, adjustment); err != nil {
+			return err
+		}
+	}
+
+	result, err := tx.Exec(`INSERT INTO inventory_adjustments (uuid, warehouse_uuid, product_uuid, location_uuid, delta, reason, comment,
+					actor, status, created_at, reviewed_by, reviewed_at)
+				SELECT ?, ?, uuid, ?, ?, ?, ?, ?, ?, ?, ?, ? FROM products WHERE article = ?`,
+		adjustment.UUID, adjustment.WarehouseUUID, repository.NullString(locationUUID), adjustment.Delta, adjustment.Reason,
+		adjustment.Comment, adjustment.Actor, adjustment.Status, adjustment.CreatedAt, repository.NullString(adjustment.ReviewedBy),
+		adjustment.ReviewedAt, adjustment.ProductArticle)
+	if err != nil {
+		r.logger.Error("error creating adjustment", "error", err)
+		return err
+	}
+	if rows, err := result.RowsAffected(); err != nil || rows == 0 {
+		return errors.Join(fmt.Errorf("%w: %s", models.ErrProductNotFound, adjustment.ProductArticle), err)
+	}
+
+	return nil
+}
+
+// GetAdjustment возвращает корректировку или models.ErrAdjustmentNotFound
+func (r *SQLiteRepo) GetAdjustment(adjustmentUUID string) (models.Adjustment, error) {
+	adjustment, err := scanAdjustment(r.db.QueryRow(adjustmentsQuery+" WHERE ia.uuid = ?", adjustmentUUID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Adjustment{}, models.ErrAdjustmentNotFound
+	}
+	if err != nil {
+		r.logger.Error("error getting adjustment", "error", err)
+		return models.Adjustment{}, err
+	}
+
+	return adjustment, nil
+}
+
+// GetAdjustments возвращает корректировки склада от старых к новым, с непустым status - только в этом статусе
+func (r *SQLiteRepo) GetAdjustments(warehouseUUID string, status string) ([]models.Adjustment, error) {
+	query := adjustmentsQuery + " WHERE ia.warehouse_uuid = ?"
+	args := []any{warehouseUUID}
+	if status != "" {
+		query += " AND ia.status = ?"
+		args = append(args, status)
+	}
+
+	rows, err := r.db.Query(query+" ORDER BY ia.created_at, ia.uuid", args...)
+	if err != nil {
+		r.logger.Error("error getting adjustments", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	adjustments := make([]models.Adjustment, 0)
+	for rows.Next() {
+		adjustment, err := scanAdjustment(rows)
+		if err != nil {
+			r.logger.Error("error scanning adjustments", "error", err)
+			return nil, err
+		}
+		adjustments = append(adjustments, adjustment)
+	}
+
+	return adjustments, rows.Err()
+}
+
+// ReviewAdjustment подтверждает (models.AdjustmentApplied) или отклоняет (models.AdjustmentRejected) корректировку,
+// ждущую подтверждения. Подтвержденная корректировка меняет остаток. Уже рассмотренная корректировка -
+// models.ErrAdjustmentReviewed
+func (r *SQLiteRepo) ReviewAdjustment(adjustmentUUID string, status string, reviewedBy string, reviewedAt time.Time) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	adjustment, err := scanAdjustment(tx.QueryRow(adjustmentsQuery+" WHERE ia.uuid = ?", adjustmentUUID))
+	if errors.Is(err, sql.ErrNoRows) {
+		tx.Rollback()
+		return models.ErrAdjustmentNotFound
+	}
+	if err != nil {
+		tx.Rollback()
+		r.logger.Error("error getting adjustment", "error", err)
+		return err
+	}
+
+	result, err := tx.Exec(`UPDATE inventory_adjustments SET status = ?, reviewed_by = ?, reviewed_at = ?
+				WHERE uuid = ? AND status = ?`,
+		status, repository.NullString(reviewedBy), reviewedAt, adjustmentUUID, models.AdjustmentPending)
+	if err != nil {
+		tx.Rollback()
+		r.logger.Error("error reviewing adjustment", "error", err)
+		return err
+	}
+	if rows, err := result.RowsAffected(); err != nil || rows == 0 {
+		tx.Rollback()
+		return errors.Join(fmt.Errorf("%w: %s", models.ErrAdjustmentReviewed, adjustment.Status), err)
+	}
+
+	if status == models.AdjustmentApplied {
+		if err := r.applyAdjustment(tx, adjustment); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// applyAdjustment меняет свободный остаток на складе. Найденный товар принимается без партии и вне ячеек
+// или в ячейку корректировки, списание берет сначала товар без партии и вне ячеек, затем партии и ячейки,
+// а списание из ячейки - только из нее. Если свободного товара не хватает, возвращается models.ErrNotEnoughProducts
+func (r *SQLiteRepo) applyAdjustment(tx *sql.Tx, adjustment models.Adjustment) error {
+	if adjustment.Delta > 0 {
+		return r.receiveProduct(tx, adjustment.WarehouseUUID, models.ReceiptItem{
+			ProductArticle: adjustment.ProductArticle,
+			Quantity:       adjustment.Delta,
+			BinPath:        adjustment.BinPath,
+		})
+	}
+
+	count := -adjustment.Delta
+
+	if adjustment.BinPath != "" {
+		return r.writeOffBin(tx, adjustment.ProductArticle, adjustment.WarehouseUUID, adjustment.BinPath, count)
+	}
+
+	_, err := r.writeOffFree(tx, adjustment.ProductArticle, adjustment.WarehouseUUID, count)
+	return err
+}
+
+// writeOffFree списывает свободный товар со склада: сначала без партии и вне ячеек, затем партии и ячейки.
+// Возвращает партии и ячейки, из которых взят товар. Если свободного товара не хватает,
+// возвращается models.ErrNotEnoughProducts
+func (r *SQLiteRepo) writeOffFree(tx *sql.Tx, productArticle string, warehouseUUID string, count int) (repository.BucketOrigin, error) {
+	var free int
+	err := tx.QueryRow(`SELECT wp.quantity FROM warehouse_products wp
+					INNER JOIN products p ON p.uuid = wp.product_uuid
+				WHERE p.article = ? AND wp.warehouse_uuid = ?`, productArticle, warehouseUUID).Scan(&free)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		r.logger.Error("error getting products quantity", "error", err)
+		return repository.BucketOrigin{}, err
+	}
+	if free < count {
+		return repository.BucketOrigin{}, fmt.Errorf("%w: only %d of %s are free, cannot write off %d", models.ErrNotEnoughProducts, free, productArticle, count)
+	}
+
+	if err := r.updateProductQuantities(tx, productArticle, warehouseUUID, -count, 0); err != nil {
+		return repository.BucketOrigin{}, err
+	}
+
+	lots, err := r.writeOffLots(tx, productArticle, warehouseUUID)
+	if err != nil {
+		return repository.BucketOrigin{}, err
+	}
+
+	bins, err := r.writeOffBins(tx, productArticle, warehouseUUID)
+	if err != nil {
+		return repository.BucketOrigin{}, err
+	}
+
+	return repository.BucketOrigin{Lots: lots, Bins: bins}, nil
+}
+
+func scanAdjustment(row interface{ Scan(dest ...any) error }) (models.Adjustment, error) {
+	var adjustment models.Adjustment
+	var reviewedAt sql.NullTime
+
+	err := row.Scan(&adjustment.UUID, &adjustment.WarehouseUUID, &adjustment.ProductArticle, &adjustment.BinPath, &adjustment.Delta,
+		&adjustment.Reason, &adjustment.Comment, &adjustment.Actor, &adjustment.Status, &adjustment.CreatedAt, &adjustment.ReviewedBy,
+		&reviewedAt)
+	if err != nil {
+		return models.Adjustment{}, err
+	}
+
+	adjustment.CreatedAt = adjustment.CreatedAt.UTC()
+	if reviewedAt.Valid {
+		reviewed := reviewedAt.Time.UTC()
+		adjustment.ReviewedAt = &reviewed
+	}
+
+	return adjustment, nil
+}
@@ -0,0 +1,110 @@
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+	"github.com/shamank/warehouse-service/internal/auth"
+	"github.com/shamank/warehouse-service/internal/domain/models"
+	"strings"
+	"time"
+)
+
+var _ auth.KeyManager = (*SQLiteRepo)(nil)
+
+// CreateAPIKey сохраняет ключ доступа, области доступа и склады хранятся строками через пробел
+func (r *SQLiteRepo) CreateAPIKey(key models.APIKey) error {
+	_, err := r.db.Exec("INSERT INTO api_keys (uuid, name, key_hash, scopes, warehouses, created_at) VALUES (?, ?, ?, ?, ?, ?)",
+		key.UUID, key.Name, key.Hash, strings.Join(key.Scopes, " "), strings.Join(key.Warehouses, " "), key.CreatedAt.UTC())
+	if err != nil {
+		r.logger.Error("error creating api key", "error", err)
+		return err
+	}
+
+	return nil
+}
+
+// GetAPIKeyByHash возвращает ключ, в том числе отозванный, или models.ErrAPIKeyNotFound
+func (r *SQLiteRepo) GetAPIKeyByHash(hash string) (models.APIKey, error) {
+	row := r.db.QueryRow("SELECT uuid, name, key_hash, scopes, warehouses, created_at, revoked_at FROM api_keys WHERE key_hash = ?", hash)
+
+	key, err := scanAPIKey(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.APIKey{}, models.ErrAPIKeyNotFound
+	}
+	if err != nil {
+		r.logger.Error("error getting api key", "error", err)
+		return models.APIKey{}, err
+	}
+
+	return key, nil
+}
+
+// ListAPIKeys возвращает все ключи в порядке создания
+func (r *SQLiteRepo) ListAPIKeys() ([]models.APIKey, error) {
+	rows, err := r.db.Query("SELECT uuid, name, key_hash, scopes, warehouses, created_at, revoked_at FROM api_keys ORDER BY created_at, uuid")
+	if err != nil {
+		r.logger.Error("error listing api keys", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := make([]models.APIKey, 0)
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			r.logger.Error("error scanning api keys", "error", err)
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	return keys, rows.Err()
+}
+
+// RevokeAPIKey отзывает ключ. Повторный отзыв не меняет время отзыва
+func (r *SQLiteRepo) RevokeAPIKey(keyUUID string) error {
+	result, err := r.db.Exec("UPDATE api_keys SET revoked_at = COALESCE(revoked_at, ?) WHERE uuid = ?", time.Now().UTC(), keyUUID)
+	if err != nil {
+		r.logger.Error("error revoking api key", "error", err)
+		return err
+	}
+	if rows, err := result.RowsAffected(); err != nil || rows == 0 {
+		return errors.Join(models.ErrAPIKeyNotFound, err)
+	}
+
+	return nil
+}
+
+// SetAPIKeyWarehouses заменяет склады, доступные клиенту с ключом. Пустой warehouses открывает все склады
+func (r *SQLiteRepo) SetAPIKeyWarehouses(keyUUID string, warehouses []string) error {
+	result, err := r.db.Exec("UPDATE api_keys SET warehouses = ? WHERE uuid = ?", strings.Join(warehouses, " "), keyUUID)
+	if err != nil {
+		r.logger.Error("error updating api key warehouses", "error", err)
+		return err
+	}
+	if rows, err := result.RowsAffected(); err != nil || rows == 0 {
+		return errors.Join(models.ErrAPIKeyNotFound, err)
+	}
+
+	return nil
+}
+
+func scanAPIKey(row interface{ Scan(dest ...any) error }) (models.APIKey, error) {
+	var key models.APIKey
+	var scopes, warehouses string
+	var revokedAt sql.NullTime
+
+	if err := row.Scan(&key.UUID, &key.Name, &key.Hash, &scopes, &warehouses, &key.CreatedAt, &revokedAt); err != nil {
+		return models.APIKey{}, err
+	}
+
+	key.Scopes = strings.Fields(scopes)
+	key.Warehouses = strings.Fields(warehouses)
+	key.CreatedAt = key.CreatedAt.UTC()
+	if revokedAt.Valid {
+		revoked := revokedAt.Time.UTC()
+		key.RevokedAt = &revoked
+	}
+
+	return key, nil
+}
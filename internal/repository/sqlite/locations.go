@@ -0,0 +1,534 @@
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/shamank/warehouse-service/internal/domain/models"
+	"github.com/shamank/warehouse-service/internal/repository"
+)
+
+// CreateLocation сохраняет место хранения. Место с тем же путем на складе - models.ErrLocationExists
+func (r *SQLiteRepo) CreateLocation(location models.Location) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	var exists int
+	err = tx.QueryRow("SELECT COUNT(*) FROM locations WHERE warehouse_uuid = ? AND path = ?", location.WarehouseUUID, location.Path).
+		Scan(&exists)
+	if err != nil {
+		tx.Rollback()
+		r.logger.Error("error getting location", "error", err)
+		return err
+	}
+	if exists > 0 {
+		tx.Rollback()
+		return fmt.Errorf("%w: %s", models.ErrLocationExists, location.Path)
+	}
+
+	_, err = tx.Exec("INSERT INTO locations (uuid, warehouse_uuid, parent_uuid, kind, code, path) VALUES (?, ?, ?, ?, ?, ?)",
+		location.UUID, location.WarehouseUUID, repository.NullString(location.ParentUUID), location.Kind, location.Code, location.Path)
+	if err != nil {
+		tx.Rollback()
+		r.logger.Error("error creating location", "error", err)
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetLocation возвращает место хранения на складе или models.ErrLocationNotFound
+func (r *SQLiteRepo) GetLocation(warehouseUUID string, locationUUID string) (models.Location, error) {
+	row := r.db.QueryRow("SELECT uuid, warehouse_uuid, parent_uuid, kind, code, path FROM locations WHERE warehouse_uuid = ? AND uuid = ?",
+		warehouseUUID, locationUUID)
+
+	location, err := scanLocation(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Location{}, models.ErrLocationNotFound
+	}
+	if err != nil {
+		r.logger.Error("error getting location", "error", err)
+		return models.Location{}, err
+	}
+
+	return location, nil
+}
+
+// GetLocations возвращает места хранения склада в порядке обхода
+func (r *SQLiteRepo) GetLocations(warehouseUUID string) ([]models.Location, error) {
+	rows, err := r.db.Query("SELECT uuid, warehouse_uuid, parent_uuid, kind, code, path FROM locations WHERE warehouse_uuid = ? ORDER BY path",
+		warehouseUUID)
+	if err != nil {
+		r.logger.Error("error getting locations", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	locations := make([]models.Location, 0)
+
+	for rows.Next() {
+		location, err := scanLocation(rows)
+		if err != nil {
+			r.logger.Error("error scanning locations", "error", err)
+			return nil, err
+		}
+		locations = append(locations, location)
+	}
+
+	return locations, rows.Err()
+}
+
+// GetBinsStock возвращает остатки товаров по ячейкам склада в порядке обхода. Ячейки без остатка и резерва пропускаются
+func (r *SQLiteRepo) GetBinsStock(warehouseUUID string, articles []string) ([]models.BinStock, error) {
+	if len(articles) == 0 {
+		return []models.BinStock{}, nil
+	}
+
+	args := []any{warehouseUUID}
+	for _, article := range articles {
+		args = append(args, article)
+	}
+
+	query := `SELECT p.article, l.path, ls.quantity, ls.reserved_quantity
+				FROM location_stock ls
+					INNER JOIN products p ON p.uuid = ls.product_uuid
+					INNER JOIN locations l ON l.uuid = ls.location_uuid
+				WHERE ls.warehouse_uuid = ? AND p.article IN (` + placeholders(len(articles)) + `)
+					AND ls.quantity + ls.reserved_quantity > 0
+				ORDER BY p.article, l.path`
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		r.logger.Error("error getting bins stock", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	stocks := make([]models.BinStock, 0)
+
+	for rows.Next() {
+		var stock models.BinStock
+		if err := rows.Scan(&stock.ProductArticle, &stock.Path, &stock.Quantity, &stock.ReservedQuantity); err != nil {
+			r.logger.Error("error scanning bins stock", "error", err)
+			return nil, err
+		}
+		stocks = append(stocks, stock)
+	}
+
+	return stocks, rows.Err()
+}
+
+// MoveStock перемещает свободный товар между ячейками склада в одной транзакции.
+// Если в ячейке или вне ячеек товара меньше, чем перемещается, возвращается models.ErrNotEnoughProducts
+func (r *SQLiteRepo) MoveStock(warehouseUUID string, moves []models.StockMove) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	for _, move := range moves {
+		if err := r.moveStock(tx, warehouseUUID, move); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *SQLiteRepo) moveStock(tx *sql.Tx, warehouseUUID string, move models.StockMove) error {
+	var productUUID string
+	var unplaced int
+	err := tx.QueryRow(`SELECT wp.product_uuid, wp.quantity - (SELECT COALESCE(SUM(ls.quantity), 0) FROM location_stock ls
+					WHERE ls.warehouse_uuid = wp.warehouse_uuid AND ls.product_uuid = wp.product_uuid)
+				FROM warehouse_products wp
+					INNER JOIN products p ON p.uuid = wp.product_uuid
+				WHERE p.article = ? AND wp.warehouse_uuid = ?`, move.ProductArticle, warehouseUUID).Scan(&productUUID, &unplaced)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%w: %s", models.ErrProductNotFound, move.ProductArticle)
+	}
+	if err != nil {
+		r.logger.Error("error getting unplaced quantity", "error", err)
+		return err
+	}
+
+	if move.FromPath == "" {
+		if unplaced < move.Quantity {
+			return fmt.Errorf("%w: %s outside bins", models.ErrNotEnoughProducts, move.ProductArticle)
+		}
+	} else {
+		locationUUID, err := r.binUUID(tx, warehouseUUID, move.FromPath)
+		if err != nil {
+			return err
+		}
+
+		result, err := tx.Exec(`UPDATE location_stock SET quantity = quantity - ?
+					WHERE location_uuid = ? AND product_uuid = ? AND quantity >= ?`,
+			move.Quantity, locationUUID, productUUID, move.Quantity)
+		if err != nil {
+			r.logger.Error("error taking from bin", "error", err)
+			return err
+		}
+		if rows, err := result.RowsAffected(); err != nil || rows == 0 {
+			return errors.Join(fmt.Errorf("%w: %s in %s", models.ErrNotEnoughProducts, move.ProductArticle, move.FromPath), err)
+		}
+	}
+
+	if move.ToPath == "" {
+		return nil
+	}
+
+	return r.placeInBin(tx, productUUID, warehouseUUID, move.ToPath, move.Quantity)
+}
+
+// placeInBin кладет свободный товар в ячейку с путем path
+func (r *SQLiteRepo) placeInBin(tx *sql.Tx, productUUID string, warehouseUUID string, path string, quantity int) error {
+	locationUUID, err := r.binUUID(tx, warehouseUUID, path)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`INSERT INTO location_stock (location_uuid, product_uuid, warehouse_uuid, quantity, reserved_quantity) VALUES (?, ?, ?, ?, 0)
+				ON CONFLICT (location_uuid, product_uuid) DO UPDATE SET quantity = quantity + excluded.quantity`,
+		locationUUID, productUUID, warehouseUUID, quantity)
+	if err != nil {
+		r.logger.Error("error placing in bin", "error", err)
+		return err
+	}
+
+	return nil
+}
+
+// binUUID возвращает uuid ячейки склада по пути. Неизвестный путь - models.ErrLocationNotFound,
+// место другого уровня - models.ErrInvalidLocation
+func (r *SQLiteRepo) binUUID(tx *sql.Tx, warehouseUUID string, path string) (string, error) {
+	var locationUUID, kind string
+	err := tx.QueryRow("SELECT uuid, kind FROM locations WHERE warehouse_uuid = ? AND path = ?", warehouseUUID, path).
+		Scan(&locationUUID, &kind)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", fmt.Errorf("%w: %s", models.ErrLocationNotFound, path)
+	}
+	if err != nil {
+		r.logger.Error("error getting location", "error", err)
+		return "", err
+	}
+	if kind != models.LocationBin {
+		return "", fmt.Errorf("%w: %s is a %s, stock is kept only in bins", models.ErrInvalidLocation, path, kind)
+	}
+
+	return locationUUID, nil
+}
+
+// writeOffBins списывает свободный товар из ячеек, если списанное не покрывает товар вне ячеек,
+// в обратном порядке обхода, и возвращает, сколько взято из каждой ячейки. Вызывается после updateProductQuantities
+func (r *SQLiteRepo) writeOffBins(tx *sql.Tx, productArticle string, warehouseUUID string) ([]repository.BinAllocation, error) {
+	var total, inBins int
+	err := tx.QueryRow(`SELECT wp.quantity, (SELECT COALESCE(SUM(ls.quantity), 0) FROM location_stock ls
+					WHERE ls.warehouse_uuid = wp.warehouse_uuid AND ls.product_uuid = wp.product_uuid)
+				FROM warehouse_products wp
+					INNER JOIN products p ON p.uuid = wp.product_uuid
+				WHERE p.article = ? AND wp.warehouse_uuid = ?`, productArticle, warehouseUUID).Scan(&total, &inBins)
+	if err != nil {
+		r.logger.Error("error getting bins quantity", "error", err)
+		return nil, err
+	}
+
+	// в total уже нет списанного количества, а в inBins оно еще есть
+	fromBins := inBins - total
+	if fromBins <= 0 {
+		return nil, nil
+	}
+
+	rows, err := tx.Query(`SELECT ls.location_uuid, l.path, ls.quantity FROM location_stock ls
+					INNER JOIN products p ON p.uuid = ls.product_uuid
+					INNER JOIN locations l ON l.uuid = ls.location_uuid
+				WHERE p.article = ? AND ls.warehouse_uuid = ? AND ls.quantity > 0
+				ORDER BY l.path DESC`, productArticle, warehouseUUID)
+	if err != nil {
+		r.logger.Error("error getting bins", "error", err)
+		return nil, err
+	}
+	bins, err := scanBinAllocations(rows)
+	if err != nil {
+		r.logger.Error("error scanning bins", "error", err)
+		return nil, err
+	}
+
+	allocations, _ := repository.AllocateBins(bins, fromBins)
+	for _, allocation := range allocations {
+		if err := r.updateBinQuantities(tx, productArticle, allocation.LocationUUID, -allocation.Quantity, 0); err != nil {
+			return nil, err
+		}
+	}
+
+	return allocations, nil
+}
+
+// writeOffBin списывает count штук свободного товара из ячейки с путем path, а вместе с ними - со склада
+// и из партий. Если в ячейке свободного товара меньше, возвращается models.ErrNotEnoughProducts
+func (r *SQLiteRepo) writeOffBin(tx *sql.Tx, productArticle string, warehouseUUID string, path string, count int) error {
+	locationUUID, err := r.binUUID(tx, warehouseUUID, path)
+	if err != nil {
+		return err
+	}
+
+	var free int
+	err = tx.QueryRow(`SELECT ls.quantity FROM location_stock ls
+					INNER JOIN products p ON p.uuid = ls.product_uuid
+				WHERE p.article = ? AND ls.location_uuid = ?`, productArticle, locationUUID).Scan(&free)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		r.logger.Error("error getting bin quantity", "error", err)
+		return err
+	}
+	if free < count {
+		return fmt.Errorf("%w: only %d of %s are free in %s, cannot write off %d", models.ErrNotEnoughProducts, free, productArticle, path, count)
+	}
+
+	if err := r.updateBinQuantities(tx, productArticle, locationUUID, -count, 0); err != nil {
+		return err
+	}
+
+	if err := r.updateProductQuantities(tx, productArticle, warehouseUUID, -count, 0); err != nil {
+		return err
+	}
+
+	_, err = r.writeOffLots(tx, productArticle, warehouseUUID)
+	return err
+}
+
+// takeFromBins списывает count штук товара из колонки column ячеек склада в порядке обхода,
+// как takeFromLots для партий. Вызывается после updateProductQuantities: то, что не взято из ячеек,
+// приходится на товар вне ячеек, и его должно хватить, иначе возвращается models.ErrNotEnoughProducts
+func (r *SQLiteRepo) takeFromBins(tx *sql.Tx, productArticle string, warehouseUUID string, count int, column string) ([]repository.BinAllocation, error) {
+	var total, inBins int
+	err := tx.QueryRow(`SELECT wp.`+column+`, (SELECT COALESCE(SUM(ls.`+column+`), 0) FROM location_stock ls
+					WHERE ls.warehouse_uuid = wp.warehouse_uuid AND ls.product_uuid = wp.product_uuid)
+				FROM warehouse_products wp
+					INNER JOIN products p ON p.uuid = wp.product_uuid
+				WHERE p.article = ? AND wp.warehouse_uuid = ?`, productArticle, warehouseUUID).Scan(&total, &inBins)
+	if err != nil {
+		r.logger.Error("error getting bins quantity", "error", err)
+		return nil, err
+	}
+
+	rows, err := tx.Query(`SELECT ls.location_uuid, l.path, ls.`+column+` FROM location_stock ls
+					INNER JOIN products p ON p.uuid = ls.product_uuid
+					INNER JOIN locations l ON l.uuid = ls.location_uuid
+				WHERE p.article = ? AND ls.warehouse_uuid = ? AND ls.`+column+` > 0
+				ORDER BY l.path`, productArticle, warehouseUUID)
+	if err != nil {
+		r.logger.Error("error getting bins", "error", err)
+		return nil, err
+	}
+	bins, err := scanBinAllocations(rows)
+	if err != nil {
+		r.logger.Error("error scanning bins", "error", err)
+		return nil, err
+	}
+
+	allocations, unplaced := repository.AllocateBins(bins, count)
+	// в total уже нет списанного количества, а в inBins оно еще есть
+	if total-(inBins-(count-unplaced)) < 0 {
+		return nil, models.ErrNotEnoughProducts
+	}
+
+	for _, allocation := range allocations {
+		quantityDelta, reservedDelta := -allocation.Quantity, allocation.Quantity
+		if column == lotsReserved {
+			quantityDelta, reservedDelta = 0, -allocation.Quantity
+		}
+		if err := r.updateBinQuantities(tx, productArticle, allocation.LocationUUID, quantityDelta, reservedDelta); err != nil {
+			return nil, err
+		}
+	}
+
+	return allocations, nil
+}
+
+// createReservationBins запоминает ячейки, из которых собрана позиция резерва
+func (r *SQLiteRepo) createReservationBins(tx *sql.Tx, reservationUUID string, productArticle string, warehouseUUID string, allocations []repository.BinAllocation) error {
+	query := `INSERT INTO reservation_item_bins (reservation_uuid, product_uuid, warehouse_uuid, location_uuid, quantity)
+				SELECT ?, uuid, ?, ?, ? FROM products WHERE article = ?
+				ON CONFLICT (reservation_uuid, product_uuid, warehouse_uuid, location_uuid) DO UPDATE SET quantity = quantity + excluded.quantity`
+
+	for _, allocation := range allocations {
+		if _, err := tx.Exec(query, reservationUUID, warehouseUUID, allocation.LocationUUID, allocation.Quantity, productArticle); err != nil {
+			r.logger.Error("error creating reservation bins", "error", err)
+			return err
+		}
+	}
+
+	return nil
+}
+
+// releaseReservationBins возвращает count штук позиции резерва в ячейки, из которых они были взяты.
+// Первым возвращается товар вне ячеек, затем ячейки в порядке, обратном обходу.
+// Вызывается до обновления released_quantity позиции
+func (r *SQLiteRepo) releaseReservationBins(tx *sql.Tx, reservationUUID string, productArticle string, warehouseUUID string, count int) error {
+	var remaining, inBins int
+	err := tx.QueryRow(`SELECT ri.quantity - ri.released_quantity - ri.short_quantity,
+					(SELECT COALESCE(SUM(rib.quantity - rib.released_quantity), 0) FROM reservation_item_bins rib
+						WHERE rib.reservation_uuid = ri.reservation_uuid AND rib.product_uuid = ri.product_uuid
+							AND rib.warehouse_uuid = ri.warehouse_uuid)
+				FROM reservation_items ri
+					INNER JOIN products p ON p.uuid = ri.product_uuid
+				WHERE ri.reservation_uuid = ? AND p.article = ? AND ri.warehouse_uuid = ?`,
+		reservationUUID, productArticle, warehouseUUID).Scan(&remaining, &inBins)
+	if errors.Is(err, sql.ErrNoRows) {
+		// отсутствие позиции обнаружит обновление released_quantity
+		return nil
+	}
+	if err != nil {
+		r.logger.Error("error getting reservation bins quantity", "error", err)
+		return err
+	}
+
+	fromBins := count - (remaining - inBins)
+	if fromBins <= 0 {
+		return nil
+	}
+
+	rows, err := tx.Query(`SELECT rib.location_uuid, l.path, rib.quantity - rib.released_quantity FROM reservation_item_bins rib
+					INNER JOIN products p ON p.uuid = rib.product_uuid
+					INNER JOIN locations l ON l.uuid = rib.location_uuid
+				WHERE rib.reservation_uuid = ? AND p.article = ? AND rib.warehouse_uuid = ?
+					AND rib.quantity > rib.released_quantity
+				ORDER BY l.path DESC`,
+		reservationUUID, productArticle, warehouseUUID)
+	if err != nil {
+		r.logger.Error("error getting reservation bins", "error", err)
+		return err
+	}
+	bins, err := scanBinAllocations(rows)
+	if err != nil {
+		r.logger.Error("error scanning reservation bins", "error", err)
+		return err
+	}
+
+	allocations, _ := repository.AllocateBins(bins, fromBins)
+
+	query := `UPDATE reservation_item_bins SET released_quantity = released_quantity + ?
+				WHERE reservation_uuid = ? AND warehouse_uuid = ? AND location_uuid = ?
+					AND product_uuid = (SELECT uuid FROM products WHERE article = ?)`
+
+	for _, allocation := range allocations {
+		if _, err := tx.Exec(query, allocation.Quantity, reservationUUID, warehouseUUID, allocation.LocationUUID, productArticle); err != nil {
+			r.logger.Error("error releasing reservation bins", "error", err)
+			return err
+		}
+		if err := r.updateBinQuantities(tx, productArticle, allocation.LocationUUID, allocation.Quantity, -allocation.Quantity); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// shortReservationBin списывает count штук позиции резерва, которых не нашли в ячейке locationUUID,
+// пустой locationUUID - вне ячеек. Если там столько в резерве нет, возвращается models.ErrNotEnoughReserved.
+// Вызывается до обновления short_quantity позиции
+func (r *SQLiteRepo) shortReservationBin(tx *sql.Tx, reservationUUID string, productArticle string, warehouseUUID string, locationUUID string, count int) error {
+	if locationUUID == "" {
+		var unplaced int
+		err := tx.QueryRow(`SELECT ri.quantity - ri.released_quantity - ri.short_quantity -
+						(SELECT COALESCE(SUM(rib.quantity - rib.released_quantity), 0) FROM reservation_item_bins rib
+							WHERE rib.reservation_uuid = ri.reservation_uuid AND rib.product_uuid = ri.product_uuid
+								AND rib.warehouse_uuid = ri.warehouse_uuid)
+					FROM reservation_items ri
+						INNER JOIN products p ON p.uuid = ri.product_uuid
+					WHERE ri.reservation_uuid = ? AND p.article = ? AND ri.warehouse_uuid = ?`,
+			reservationUUID, productArticle, warehouseUUID).Scan(&unplaced)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			r.logger.Error("error getting reservation bins quantity", "error", err)
+			return err
+		}
+		if unplaced < count {
+			return fmt.Errorf("%w: %s outside bins", models.ErrNotEnoughReserved, productArticle)
+		}
+		return nil
+	}
+
+	result, err := tx.Exec(`UPDATE reservation_item_bins SET released_quantity = released_quantity + ?
+				WHERE reservation_uuid = ? AND warehouse_uuid = ? AND location_uuid = ?
+					AND product_uuid = (SELECT uuid FROM products WHERE article = ?) AND quantity - released_quantity >= ?`,
+		count, reservationUUID, warehouseUUID, locationUUID, productArticle, count)
+	if err != nil {
+		r.logger.Error("error shorting reservation bin", "error", err)
+		return err
+	}
+	if rows, err := result.RowsAffected(); err != nil || rows == 0 {
+		return errors.Join(fmt.Errorf("%w: %s in bin", models.ErrNotEnoughReserved, productArticle), err)
+	}
+
+	return r.updateBinQuantities(tx, productArticle, locationUUID, 0, -count)
+}
+
+func (r *SQLiteRepo) updateBinQuantities(tx *sql.Tx, productArticle string, locationUUID string, quantityDelta int, reservedQuantityDelta int) error {
+	query := `UPDATE location_stock
+				SET quantity = quantity + ?, reserved_quantity = reserved_quantity + ?
+				WHERE product_uuid = (SELECT uuid FROM products WHERE article = ?) AND location_uuid = ?`
+
+	if _, err := tx.Exec(query, quantityDelta, reservedQuantityDelta, productArticle, locationUUID); err != nil {
+		r.logger.Error("error occurred while updating bins", "error", err)
+		return err
+	}
+
+	return nil
+}
+
+// reservationBins возвращает ячейки с еще не освобожденным товаром резерва по артикулу и складу
+func (r *SQLiteRepo) reservationBins(reservationUUID string) (map[[2]string][]models.BinQuantity, error) {
+	query := `SELECT p.article, rib.warehouse_uuid, l.path, rib.quantity - rib.released_quantity
+				FROM reservation_item_bins rib
+					INNER JOIN products p ON p.uuid = rib.product_uuid
+					INNER JOIN locations l ON l.uuid = rib.location_uuid
+				WHERE rib.reservation_uuid = ? AND rib.quantity > rib.released_quantity
+				ORDER BY l.path`
+
+	rows, err := r.db.Query(query, reservationUUID)
+	if err != nil {
+		r.logger.Error("error getting reservation bins", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	bins := make(map[[2]string][]models.BinQuantity)
+	for rows.Next() {
+		var article, warehouseUUID string
+		var bin models.BinQuantity
+		if err := rows.Scan(&article, &warehouseUUID, &bin.Path, &bin.Quantity); err != nil {
+			r.logger.Error("error scanning reservation bins", "error", err)
+			return nil, err
+		}
+		key := [2]string{article, warehouseUUID}
+		bins[key] = append(bins[key], bin)
+	}
+
+	return bins, rows.Err()
+}
+
+func scanLocation(row interface{ Scan(dest ...any) error }) (models.Location, error) {
+	var location models.Location
+	var parentUUID sql.NullString
+	err := row.Scan(&location.UUID, &location.WarehouseUUID, &parentUUID, &location.Kind, &location.Code, &location.Path)
+	location.ParentUUID = parentUUID.String
+
+	return location, err
+}
+
+func scanBinAllocations(rows *sql.Rows) ([]repository.BinAllocation, error) {
+	defer rows.Close()
+
+	bins := make([]repository.BinAllocation, 0)
+	for rows.Next() {
+		var bin repository.BinAllocation
+		if err := rows.Scan(&bin.LocationUUID, &bin.Path, &bin.Quantity); err != nil {
+			return nil, err
+		}
+		bins = append(bins, bin)
+	}
+
+	return bins, rows.Err()
+}
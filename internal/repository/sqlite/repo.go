@@ -0,0 +1,615 @@
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+	"github.com/shamank/warehouse-service/internal/domain/models"
+	"github.com/shamank/warehouse-service/internal/domain/schemas"
+	"github.com/shamank/warehouse-service/internal/repository"
+	"github.com/shamank/warehouse-service/internal/service"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+var _ service.Repository = (*SQLiteRepo)(nil)
+
+// SQLiteRepo - хранилище для однонодовых установок (пункты выдачи без PostgreSQL).
+// Транзакции открываются как BEGIN IMMEDIATE (см. database.OpenSQLite),
+// поэтому резервирование и освобождение сериализуются на уровне базы
+type SQLiteRepo struct {
+	db     *sql.DB
+	logger *slog.Logger
+}
+
+func NewSQLiteRepo(db *sql.DB, logger *slog.Logger) *SQLiteRepo {
+	return &SQLiteRepo{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// GetProductsQuantity возвращает остатки товара на доступных складах. Товар в просроченных партиях не учитывается
+func (r *SQLiteRepo) GetProductsQuantity(productArticle string) ([]models.WarehouseProduct, error) {
+
+	query := `select wp.warehouse_uuid, wp.quantity - ` + repository.ExpiredQuantitySQL("?") + `, wp.reserved_quantity from warehouse_products wp
+    			inner join products p on wp.product_uuid = p.uuid
+                inner join warehouses w on wp.warehouse_uuid = w.uuid
+        			where p.article = ? and w.is_available = true`
+
+	rows, err := r.db.Query(query, repository.Today(), productArticle)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	productByWarehouses := make([]models.WarehouseProduct, 0)
+
+	for rows.Next() {
+		var warehouseProduct models.WarehouseProduct
+		err := rows.Scan(&warehouseProduct.WarehouseUUID, &warehouseProduct.Quantity, &warehouseProduct.ReservedQuantity)
+		if err != nil {
+			r.logger.Error("error scanning warehouse products", "error", err)
+			return nil, err
+		}
+		productByWarehouses = append(productByWarehouses, warehouseProduct)
+	}
+	return productByWarehouses, rows.Err()
+}
+
+// колонки, по которым разрешена сортировка остатков. Товар без названия сортируется как с пустым,
+// иначе сравнение с курсором отбрасывало бы его со всех страниц
+var sortColumns = map[string]string{
+	schemas.SortByArticle:  "p.article",
+	schemas.SortByName:     "COALESCE(p.name, '')",
+	schemas.SortByQuantity: "wp.quantity",
+}
+
+// GetRemainingProductsByWarehouse возвращает страницу остатков на складе и число всех товаров, подходящих под фильтр.
+// Значения фильтра передаются только параметрами, а имена колонок берутся из sortColumns
+func (r *SQLiteRepo) GetRemainingProductsByWarehouse(warehouseUUID string, filter schemas.RemainingProductsFilter) ([]models.Product, int, error) {
+
+	args := []any{warehouseUUID}
+	conditions := []string{"wp.warehouse_uuid = ?"}
+
+	if filter.ArticlePrefix != "" {
+		conditions = append(conditions, "p.article LIKE ? ESCAPE '"+repository.LikeEscape+"'")
+		args = append(args, repository.EscapeLike(filter.ArticlePrefix)+"%")
+	}
+	if filter.Name != "" {
+		conditions = append(conditions, "LOWER(p.name) LIKE LOWER(?) ESCAPE '"+repository.LikeEscape+"'")
+		args = append(args, "%"+repository.EscapeLike(filter.Name)+"%")
+	}
+	if filter.Size != "" {
+		conditions = append(conditions, "p.size = ?")
+		args = append(args, filter.Size)
+	}
+	if filter.MinQuantity > 0 {
+		conditions = append(conditions, "wp.quantity >= ?")
+		args = append(args, filter.MinQuantity)
+	}
+	if filter.InStock {
+		conditions = append(conditions, "wp.quantity > 0")
+	}
+	if !filter.IncludeZeroStock {
+		conditions = append(conditions, `(wp.quantity + wp.reserved_quantity > 0 OR EXISTS (SELECT 1 FROM stock_buckets sb
+				WHERE sb.warehouse_uuid = wp.warehouse_uuid AND sb.product_uuid = wp.product_uuid AND sb.quantity > 0))`)
+	}
+
+	from := ` FROM warehouse_products wp
+					INNER JOIN products p ON p.uuid = wp.product_uuid`
+
+	var total int
+	err := r.db.QueryRow("SELECT count(*)"+from+" WHERE "+strings.Join(conditions, " AND "), args...).Scan(&total)
+	if err != nil {
+		r.logger.Error("error counting warehouse products", "error", err)
+		return nil, 0, err
+	}
+
+	sortColumn, ok := sortColumns[filter.Sort]
+	if !ok {
+		sortColumn = sortColumns[schemas.SortByArticle]
+	}
+	direction, compare := "ASC", ">"
+	if filter.Desc {
+		direction, compare = "DESC", "<"
+	}
+
+	if filter.After != nil {
+		switch filter.Sort {
+		case schemas.SortByName:
+			conditions = append(conditions, "(COALESCE(p.name, ''), p.article) "+compare+" (?, ?)")
+			args = append(args, filter.After.Name, filter.After.Article)
+		case schemas.SortByQuantity:
+			conditions = append(conditions, "(wp.quantity, p.article) "+compare+" (?, ?)")
+			args = append(args, filter.After.Quantity, filter.After.Article)
+		default:
+			conditions = append(conditions, "p.article "+compare+" ?")
+			args = append(args, filter.After.Article)
+		}
+	}
+
+	query := "SELECT COALESCE(p.name, ''), p.size, p.article, wp.quantity, wp.reserved_quantity, " + repository.ExpiredQuantitySQL("?") + from +
+		" WHERE " + strings.Join(conditions, " AND ") +
+		" ORDER BY " + sortColumn + " " + direction + ", p.article " + direction
+	if filter.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, filter.Limit)
+	}
+
+	// дата для подзапроса просроченного остатка стоит в запросе раньше условий
+	rows, err := r.db.Query(query, append([]any{repository.Today()}, args...)...)
+	if err != nil {
+		r.logger.Error("error getting warehouse products", "error", err)
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	products := make([]models.Product, 0)
+
+	for rows.Next() {
+		var product models.Product
+
+		err := rows.Scan(&product.Name, &product.Size, &product.Code, &product.Quantity, &product.ReservedQuantity, &product.ExpiredQuantity)
+		if err != nil {
+			r.logger.Error("error scanning warehouse products", "error", err)
+			return nil, 0, err
+		}
+		products = append(products, product)
+	}
+
+	return products, total, rows.Err()
+}
+
+// GetProductsByArticles возвращает товары с указанными артикулами, неизвестные артикулы пропускаются
+func (r *SQLiteRepo) GetProductsByArticles(articles []string) ([]models.Product, error) {
+	if len(articles) == 0 {
+		return []models.Product{}, nil
+	}
+
+	args := make([]any, len(articles))
+	for i, article := range articles {
+		args[i] = article
+	}
+
+	rows, err := r.db.Query("SELECT uuid, name, size, article, serialized FROM products WHERE article IN ("+placeholders(len(articles))+") ORDER BY article", args...)
+	if err != nil {
+		r.logger.Error("error getting products", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	products := make([]models.Product, 0, len(articles))
+
+	for rows.Next() {
+		var product models.Product
+		if err := rows.Scan(&product.UUID, &product.Name, &product.Size, &product.Code, &product.Serialized); err != nil {
+			r.logger.Error("error scanning products", "error", err)
+			return nil, err
+		}
+		products = append(products, product)
+	}
+
+	return products, rows.Err()
+}
+
+// GetWarehouse возвращает склад по uuid или models.ErrWarehouseNotFound
+func (r *SQLiteRepo) GetWarehouse(warehouseUUID string) (models.Warehouse, error) {
+	var warehouse models.Warehouse
+
+	err := r.db.QueryRow("SELECT uuid, name, is_available FROM warehouses WHERE uuid = ?", warehouseUUID).
+		Scan(&warehouse.UUID, &warehouse.Name, &warehouse.Availability)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Warehouse{}, models.ErrWarehouseNotFound
+	}
+	if err != nil {
+		r.logger.Error("error getting warehouse", "error", err)
+		return models.Warehouse{}, err
+	}
+
+	return warehouse, nil
+}
+
+// GetProductsStock возвращает остатки товаров по складам вместе с непродаваемыми корзинами за один запрос.
+// Пустой список articles или warehouseUUIDs означает отсутствие фильтра по нему
+func (r *SQLiteRepo) GetProductsStock(articles []string, warehouseUUIDs []string) ([]models.ProductStock, error) {
+
+	query := `SELECT p.article, p.name, p.size, w.uuid, w.is_available, wp.quantity, wp.reserved_quantity, ` + repository.ExpiredQuantitySQL("?") + `,
+					COALESCE(sb.bucket, ''), COALESCE(sb.quantity, 0)
+				FROM warehouse_products wp
+					INNER JOIN products p ON p.uuid = wp.product_uuid
+					INNER JOIN warehouses w ON w.uuid = wp.warehouse_uuid
+					LEFT JOIN stock_buckets sb ON sb.warehouse_uuid = wp.warehouse_uuid AND sb.product_uuid = wp.product_uuid
+						AND sb.quantity > 0`
+
+	conditions := make([]string, 0, 2)
+	args := make([]any, 0, len(articles)+len(warehouseUUIDs)+1)
+	args = append(args, repository.Today())
+
+	if len(articles) > 0 {
+		conditions = append(conditions, "p.article IN ("+placeholders(len(articles))+")")
+		for _, article := range articles {
+			args = append(args, article)
+		}
+	}
+	if len(warehouseUUIDs) > 0 {
+		conditions = append(conditions, "wp.warehouse_uuid IN ("+placeholders(len(warehouseUUIDs))+")")
+		for _, warehouseUUID := range warehouseUUIDs {
+			args = append(args, warehouseUUID)
+		}
+	}
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY p.article, w.uuid, sb.bucket"
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		r.logger.Error("error getting products stock", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	stocks := make([]models.ProductStock, 0)
+
+	for rows.Next() {
+		var stock models.ProductStock
+		var bucket string
+		var bucketQuantity int
+		err := rows.Scan(&stock.ProductArticle, &stock.ProductName, &stock.ProductSize, &stock.WarehouseUUID,
+			&stock.WarehouseAvailability, &stock.Quantity, &stock.ReservedQuantity, &stock.ExpiredQuantity, &bucket, &bucketQuantity)
+		if err != nil {
+			r.logger.Error("error scanning products stock", "error", err)
+			return nil, err
+		}
+		stocks = repository.AppendProductStock(stocks, stock, bucket, bucketQuantity)
+	}
+
+	return stocks, rows.Err()
+}
+
+// ReserveProducts резервирует товары, внутри склада партии берутся в порядке FEFO, ячейки - в порядке обхода
+func (r *SQLiteRepo) ReserveProducts(products []schemas.ProductWarehouseSplitted) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	for _, product := range products {
+		for _, warehouseData := range product.WarehouseData {
+			err := r.updateProductQuantities(tx, product.ProductArticle, warehouseData.WarehouseUUID, -warehouseData.Count, warehouseData.Count)
+			if err != nil {
+				tx.Rollback()
+				return err
+			}
+
+			if _, err := r.takeFromLots(tx, product.ProductArticle, warehouseData.WarehouseUUID, warehouseData.Count, lotsFree); err != nil {
+				tx.Rollback()
+				return err
+			}
+
+			_, err = r.moveSerials(tx, product.ProductArticle, warehouseData.WarehouseUUID, warehouseData.Count, repository.ReserveSerials(""))
+			if err != nil {
+				tx.Rollback()
+				return err
+			}
+
+			if _, err := r.takeFromBins(tx, product.ProductArticle, warehouseData.WarehouseUUID, warehouseData.Count, lotsFree); err != nil {
+				tx.Rollback()
+				return err
+			}
+		}
+	}
+
+	return tx.Commit()
+
+}
+
+// ReleaseProducts списывает товары из резерва, внутри склада партии отгружаются в порядке FEFO, ячейки - в порядке обхода
+func (r *SQLiteRepo) ReleaseProducts(productsWithSplit []schemas.ProductWarehouseSplitted) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	for _, product := range productsWithSplit {
+		for _, warehouseData := range product.WarehouseData {
+			err := r.updateProductQuantities(tx, product.ProductArticle, warehouseData.WarehouseUUID, 0, -warehouseData.Count)
+			if err != nil {
+				tx.Rollback()
+				return err
+			}
+
+			if _, err := r.takeFromLots(tx, product.ProductArticle, warehouseData.WarehouseUUID, warehouseData.Count, lotsReserved); err != nil {
+				tx.Rollback()
+				return err
+			}
+
+			_, err = r.moveSerials(tx, product.ProductArticle, warehouseData.WarehouseUUID, warehouseData.Count, repository.ShipSerials())
+			if err != nil {
+				tx.Rollback()
+				return err
+			}
+
+			if _, err := r.takeFromBins(tx, product.ProductArticle, warehouseData.WarehouseUUID, warehouseData.Count, lotsReserved); err != nil {
+				tx.Rollback()
+				return err
+			}
+		}
+	}
+
+	return tx.Commit()
+}
+
+// CreateReservation резервирует товары и сохраняет резерв с uuid reservationUUID в одной транзакции.
+// Партии и ячейки, из которых взят товар, запоминаются, чтобы ReleaseReservation вернул его туда же
+func (r *SQLiteRepo) CreateReservation(reservationUUID string, products []schemas.ProductWarehouseSplitted) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+
+	_, err = tx.Exec("INSERT INTO reservations (uuid, status, created_at, updated_at) VALUES (?, ?, ?, ?)",
+		reservationUUID, models.ReservationActive, now, now)
+	if err != nil {
+		tx.Rollback()
+		r.logger.Error("error creating reservation", "error", err)
+		return err
+	}
+
+	for _, product := range products {
+		for _, warehouseData := range product.WarehouseData {
+			if err := r.reserveItem(tx, reservationUUID, product.ProductArticle, product.SubstituteFor, warehouseData); err != nil {
+				tx.Rollback()
+				return err
+			}
+		}
+	}
+
+	return tx.Commit()
+}
+
+// reserveItem резервирует товар на складе в позицию резерва v2: остаток, партии, серийные номера и ячейки.
+// Если позиция уже есть, количество добавляется к ней. substituteFor - артикул, вместо которого резервируется товар
+func (r *SQLiteRepo) reserveItem(tx *sql.Tx, reservationUUID string, productArticle string, substituteFor string, warehouseData schemas.WarehouseCounter) error {
+	err := r.updateProductQuantities(tx, productArticle, warehouseData.WarehouseUUID, -warehouseData.Count, warehouseData.Count)
+	if err != nil {
+		return err
+	}
+
+	allocations, err := r.takeFromLots(tx, productArticle, warehouseData.WarehouseUUID, warehouseData.Count, lotsFree)
+	if err != nil {
+		return err
+	}
+
+	query := `INSERT INTO reservation_items (reservation_uuid, product_uuid, warehouse_uuid, quantity, substitute_for)
+				SELECT ?, uuid, ?, ?, (SELECT uuid FROM products WHERE article = ?) FROM products WHERE article = ?
+				ON CONFLICT (reservation_uuid, product_uuid, warehouse_uuid) DO UPDATE SET quantity = quantity + excluded.quantity`
+
+	if _, err := tx.Exec(query, reservationUUID, warehouseData.WarehouseUUID, warehouseData.Count, substituteFor, productArticle); err != nil {
+		r.logger.Error("error creating reservation items", "error", err)
+		return err
+	}
+
+	if err := r.createReservationLots(tx, reservationUUID, productArticle, warehouseData.WarehouseUUID, allocations); err != nil {
+		return err
+	}
+
+	_, err = r.moveSerials(tx, productArticle, warehouseData.WarehouseUUID, warehouseData.Count, repository.ReserveSerials(reservationUUID))
+	if err != nil {
+		return err
+	}
+
+	bins, err := r.takeFromBins(tx, productArticle, warehouseData.WarehouseUUID, warehouseData.Count, lotsFree)
+	if err != nil {
+		return err
+	}
+
+	return r.createReservationBins(tx, reservationUUID, productArticle, warehouseData.WarehouseUUID, bins)
+}
+
+// GetReservation возвращает резерв вместе с позициями, серийными номерами и ячейками в резерве или models.ErrReservationNotFound
+func (r *SQLiteRepo) GetReservation(reservationUUID string) (models.Reservation, error) {
+	var reservation models.Reservation
+
+	err := r.db.QueryRow("SELECT uuid, status, created_at, updated_at FROM reservations WHERE uuid = ?", reservationUUID).
+		Scan(&reservation.UUID, &reservation.Status, &reservation.CreatedAt, &reservation.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Reservation{}, models.ErrReservationNotFound
+	}
+	if err != nil {
+		r.logger.Error("error getting reservation", "error", err)
+		return models.Reservation{}, err
+	}
+	reservation.CreatedAt = reservation.CreatedAt.UTC()
+	reservation.UpdatedAt = reservation.UpdatedAt.UTC()
+
+	query := `SELECT p.article, ri.warehouse_uuid, ri.quantity, ri.released_quantity, ri.short_quantity, ri.unfulfilled_quantity,
+					COALESCE(s.article, '')
+				FROM reservation_items ri
+					INNER JOIN products p ON p.uuid = ri.product_uuid
+					LEFT JOIN products s ON s.uuid = ri.substitute_for
+				WHERE ri.reservation_uuid = ?
+				ORDER BY p.article, ri.warehouse_uuid`
+
+	rows, err := r.db.Query(query, reservationUUID)
+	if err != nil {
+		r.logger.Error("error getting reservation items", "error", err)
+		return models.Reservation{}, err
+	}
+	defer rows.Close()
+
+	reservation.Items = make([]models.ReservationItem, 0)
+
+	for rows.Next() {
+		var item models.ReservationItem
+		if err := rows.Scan(&item.ProductArticle, &item.WarehouseUUID, &item.Quantity, &item.ReleasedQuantity, &item.ShortQuantity, &item.UnfulfilledQuantity, &item.SubstituteFor); err != nil {
+			r.logger.Error("error scanning reservation items", "error", err)
+			return models.Reservation{}, err
+		}
+		reservation.Items = append(reservation.Items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return models.Reservation{}, err
+	}
+
+	serials, err := r.reservationSerials(reservationUUID)
+	if err != nil {
+		return models.Reservation{}, err
+	}
+	bins, err := r.reservationBins(reservationUUID)
+	if err != nil {
+		return models.Reservation{}, err
+	}
+	for i, item := range reservation.Items {
+		key := [2]string{item.ProductArticle, item.WarehouseUUID}
+		reservation.Items[i].Serials = serials[key]
+		reservation.Items[i].Bins = bins[key]
+	}
+
+	return reservation, nil
+}
+
+// ReleaseReservation возвращает часть резерва на склады и переводит резерв в статус status
+func (r *SQLiteRepo) ReleaseReservation(reservationUUID string, products []schemas.ProductWarehouseSplitted, status string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	query := `UPDATE reservation_items SET released_quantity = released_quantity + ?
+				WHERE reservation_uuid = ? AND warehouse_uuid = ?
+					AND product_uuid = (SELECT uuid FROM products WHERE article = ?)`
+
+	for _, product := range products {
+		for _, warehouseData := range product.WarehouseData {
+			err := r.updateProductQuantities(tx, product.ProductArticle, warehouseData.WarehouseUUID, warehouseData.Count, -warehouseData.Count)
+			if err != nil {
+				tx.Rollback()
+				return err
+			}
+
+			err = r.releaseReservationLots(tx, reservationUUID, product.ProductArticle, warehouseData.WarehouseUUID, warehouseData.Count, false)
+			if err != nil {
+				tx.Rollback()
+				return err
+			}
+
+			_, err = r.moveSerials(tx, product.ProductArticle, warehouseData.WarehouseUUID, warehouseData.Count, repository.ReleaseSerials(reservationUUID))
+			if err != nil {
+				tx.Rollback()
+				return err
+			}
+
+			err = r.releaseReservationBins(tx, reservationUUID, product.ProductArticle, warehouseData.WarehouseUUID, warehouseData.Count)
+			if err != nil {
+				tx.Rollback()
+				return err
+			}
+
+			result, err := tx.Exec(query, warehouseData.Count, reservationUUID, warehouseData.WarehouseUUID, product.ProductArticle)
+			if err != nil {
+				tx.Rollback()
+				r.logger.Error("error releasing reservation items", "error", err)
+				return err
+			}
+			if rows, err := result.RowsAffected(); err != nil || rows == 0 {
+				tx.Rollback()
+				return errors.Join(repository.ErrNoUpdatedProducts, err)
+			}
+		}
+	}
+
+	result, err := tx.Exec("UPDATE reservations SET status = ?, updated_at = ? WHERE uuid = ?", status, time.Now().UTC(), reservationUUID)
+	if err != nil {
+		tx.Rollback()
+		r.logger.Error("error updating reservation", "error", err)
+		return err
+	}
+	if rows, err := result.RowsAffected(); err != nil || rows == 0 {
+		tx.Rollback()
+		return errors.Join(models.ErrReservationNotFound, err)
+	}
+
+	return tx.Commit()
+}
+
+// updateProductQuantities обновляет остатки товара на складе.
+// uuid товара ищется подзапросом, а не через UPDATE ... FROM, который появился только в SQLite 3.33
+func (r *SQLiteRepo) updateProductQuantities(tx *sql.Tx, productArticle string, warehouseUUID string, quantityDelta int, reservedQuantityDelta int) error {
+	query := `UPDATE warehouse_products
+				SET quantity = quantity + ?, reserved_quantity = reserved_quantity + ?
+				WHERE product_uuid = (SELECT uuid FROM products WHERE article = ?) AND warehouse_uuid = ?`
+
+	result, err := tx.Exec(query, quantityDelta, reservedQuantityDelta, productArticle, warehouseUUID)
+	if err != nil {
+		r.logger.Error("error occurred while updating products", "error", err)
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		r.logger.Error("error rows affected: ", "error", err)
+		return err
+	}
+
+	if rows == 0 {
+		return repository.ErrNoUpdatedProducts
+	}
+
+	return nil
+}
+
+func (r *SQLiteRepo) GenerateTestData() error {
+
+	query1 := `INSERT INTO warehouses (uuid, name, is_available) VALUES
+					('af5fc7cd-afb0-43f8-a9d2-ce532512b2ac', 'warehouse1', true),
+					('f1dd9277-a8af-49ee-a5b1-3f8ee9e74cfd', 'warehouse2', false),
+					('c1bf338d-1953-4b9f-8dd7-71dfca0a29cc', 'warehouse3', true);`
+
+	query2 := `INSERT INTO products (uuid, name, size, article) VALUES
+					('854427c7-c53c-40be-935f-a97df1c89a13', 'product1', '10', '123'),
+					('a8bff1ba-125a-45cb-b779-a1f5b813f0c3', 'product2', '20', '456'),
+					('c175b84e-a62c-4094-871f-4c03c34aa37e', 'product3', '30', '789'),
+					('5cb17c38-aa38-4797-a295-475244bb2e53', 'product4', '40', '987'),
+					('d19031d1-eb57-4e2b-9c0b-db80fd694a51', 'product5', '50', '654'),
+					('463d8a77-7916-4c1f-94b3-2408017f22da', 'product6', '60', '321'),
+					('0b6f2a0e-7d35-4c8e-9f1a-3e5b8c2d4a71', 'product7', '70', '111');`
+
+	query3 := `INSERT INTO warehouse_products (warehouse_uuid, product_uuid, quantity, reserved_quantity) VALUES
+					('af5fc7cd-afb0-43f8-a9d2-ce532512b2ac', '854427c7-c53c-40be-935f-a97df1c89a13', 15, 0),
+					('af5fc7cd-afb0-43f8-a9d2-ce532512b2ac', 'a8bff1ba-125a-45cb-b779-a1f5b813f0c3', 25, 2),
+					('af5fc7cd-afb0-43f8-a9d2-ce532512b2ac', 'c175b84e-a62c-4094-871f-4c03c34aa37e', 35, 5),
+					('af5fc7cd-afb0-43f8-a9d2-ce532512b2ac', '5cb17c38-aa38-4797-a295-475244bb2e53', 45, 7),
+					('f1dd9277-a8af-49ee-a5b1-3f8ee9e74cfd', '463d8a77-7916-4c1f-94b3-2408017f22da', 12, 0),
+					('f1dd9277-a8af-49ee-a5b1-3f8ee9e74cfd', '5cb17c38-aa38-4797-a295-475244bb2e53', 32, 23),
+					('c1bf338d-1953-4b9f-8dd7-71dfca0a29cc', '463d8a77-7916-4c1f-94b3-2408017f22da', 10, 30),
+					('c1bf338d-1953-4b9f-8dd7-71dfca0a29cc', '5cb17c38-aa38-4797-a295-475244bb2e53', 0, 20),
+					('c1bf338d-1953-4b9f-8dd7-71dfca0a29cc', 'd19031d1-eb57-4e2b-9c0b-db80fd694a51', 2, 5),
+					('c1bf338d-1953-4b9f-8dd7-71dfca0a29cc', '0b6f2a0e-7d35-4c8e-9f1a-3e5b8c2d4a71', 0, 0);`
+
+	query4 := `INSERT INTO products (uuid, name, size, article, serialized) VALUES
+					('7e0c5b9a-3f1d-4a62-8c47-2d9e6b1f5a08', 'product8', '80', '222', true);`
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		r.logger.Error("failed to start transaction", "error", err)
+		return err
+	}
+
+	for _, query := range []string{query1, query2, query3, query4} {
+		if _, err := tx.Exec(query); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// placeholders возвращает n плейсхолдеров через запятую для условия IN
+func placeholders(n int) string {
+	return strings.Repeat("?, ", n-1) + "?"
+}
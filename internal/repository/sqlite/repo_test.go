@@ -0,0 +1,68 @@
+package sqlite
+
+import (
+	"github.com/shamank/warehouse-service/internal/config"
+	"github.com/shamank/warehouse-service/internal/database"
+	"github.com/shamank/warehouse-service/internal/domain/schemas"
+	"github.com/shamank/warehouse-service/internal/repository/repotest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"log/slog"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+const migrationsPath = "../../../migrations/sqlite"
+
+func TestSQLiteRepo(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) repotest.Repository {
+		db, err := database.OpenSQLite(filepath.Join(t.TempDir(), "warehouse.db"))
+		require.NoError(t, err)
+		t.Cleanup(func() { db.Close() })
+
+		require.NoError(t, database.Migrate(db, config.StorageSQLite, migrationsPath))
+
+		return NewSQLiteRepo(db, slog.Default())
+	})
+}
+
+func TestSQLiteRepo_RemainingProductsWithoutName(t *testing.T) {
+	db, err := database.OpenSQLite(filepath.Join(t.TempDir(), "warehouse.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	require.NoError(t, database.Migrate(db, config.StorageSQLite, migrationsPath))
+
+	repo := NewSQLiteRepo(db, slog.Default())
+	require.NoError(t, repo.GenerateTestData())
+
+	// у товара из старых данных нет названия
+	_, err = db.Exec(`INSERT INTO products (uuid, name, size, article) VALUES ('3c4d5e6f-7a8b-4c9d-8e0f-1a2b3c4d5e6f', NULL, '90', '000')`)
+	require.NoError(t, err)
+	_, err = db.Exec(`INSERT INTO warehouse_products (warehouse_uuid, product_uuid, quantity, reserved_quantity)
+				VALUES ('af5fc7cd-afb0-43f8-a9d2-ce532512b2ac', '3c4d5e6f-7a8b-4c9d-8e0f-1a2b3c4d5e6f', 1, 0)`)
+	require.NoError(t, err)
+
+	for _, desc := range []bool{false, true} {
+		filter := schemas.RemainingProductsFilter{Sort: schemas.SortByName, Desc: desc, Limit: 2}
+		articles := make([]string, 0)
+		for {
+			products, _, err := repo.GetRemainingProductsByWarehouse(repotest.Warehouse1, filter)
+			require.NoError(t, err)
+			if len(products) == 0 {
+				break
+			}
+			for _, product := range products {
+				articles = append(articles, product.Code)
+			}
+			last := products[len(products)-1]
+			filter.After = &schemas.ProductCursor{Sort: schemas.SortByName, Desc: desc, Name: last.Name, Article: last.Code}
+		}
+
+		expected := []string{"000", "123", "456", "789", "987"}
+		if desc {
+			slices.Reverse(expected)
+		}
+		assert.Equal(t, expected, articles)
+	}
+}
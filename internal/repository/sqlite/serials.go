@@ -0,0 +1,230 @@
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/shamank/warehouse-service/internal/domain/models"
+	"github.com/shamank/warehouse-service/internal/repository"
+	"strings"
+	"time"
+)
+
+// GetSerial возвращает серийный номер товара или models.ErrSerialNotFound
+func (r *SQLiteRepo) GetSerial(productArticle string, serialNumber string) (models.ProductSerial, error) {
+	query := `SELECT p.article, ps.serial_number, ps.warehouse_uuid, ps.status, ps.reservation_uuid, ps.updated_at
+				FROM product_serials ps
+					INNER JOIN products p ON p.uuid = ps.product_uuid
+				WHERE p.article = ? AND ps.serial_number = ?`
+
+	var serial models.ProductSerial
+	var reservationUUID sql.NullString
+	err := r.db.QueryRow(query, productArticle, serialNumber).Scan(&serial.ProductArticle, &serial.SerialNumber,
+		&serial.WarehouseUUID, &serial.Status, &reservationUUID, &serial.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.ProductSerial{}, models.ErrSerialNotFound
+	}
+	if err != nil {
+		r.logger.Error("error getting serial", "error", err)
+		return models.ProductSerial{}, err
+	}
+	serial.ReservationUUID = reservationUUID.String
+	serial.UpdatedAt = serial.UpdatedAt.UTC()
+
+	return serial, nil
+}
+
+// GetSerialHistory возвращает события серийного номера от первого к последнему или models.ErrSerialNotFound
+func (r *SQLiteRepo) GetSerialHistory(productArticle string, serialNumber string) ([]models.SerialEvent, error) {
+	if _, err := r.GetSerial(productArticle, serialNumber); err != nil {
+		return nil, err
+	}
+
+	query := `SELECT e.status, e.warehouse_uuid, e.reservation_uuid, e.created_at
+				FROM product_serial_events e
+					INNER JOIN products p ON p.uuid = e.product_uuid
+				WHERE p.article = ? AND e.serial_number = ?
+				ORDER BY e.seq`
+
+	rows, err := r.db.Query(query, productArticle, serialNumber)
+	if err != nil {
+		r.logger.Error("error getting serial history", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := make([]models.SerialEvent, 0)
+
+	for rows.Next() {
+		var event models.SerialEvent
+		var reservationUUID sql.NullString
+		if err := rows.Scan(&event.Status, &event.WarehouseUUID, &reservationUUID, &event.CreatedAt); err != nil {
+			r.logger.Error("error scanning serial history", "error", err)
+			return nil, err
+		}
+		event.ReservationUUID = reservationUUID.String
+		event.CreatedAt = event.CreatedAt.UTC()
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
+
+// receiveSerials регистрирует серийные номера принятого товара. Новый номер становится доступным,
+// отгруженный ранее или не найденный при отборе - возвращенным. Номер, который уже на складе, - models.ErrSerialExists
+func (r *SQLiteRepo) receiveSerials(tx *sql.Tx, productUUID string, item models.ReceiptItem, warehouseUUID string) error {
+	for _, serialNumber := range item.Serials {
+		var status string
+		err := tx.QueryRow("SELECT status FROM product_serials WHERE product_uuid = ? AND serial_number = ?", productUUID, serialNumber).
+			Scan(&status)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			now := time.Now().UTC()
+			_, err := tx.Exec(`INSERT INTO product_serials (product_uuid, serial_number, warehouse_uuid, status, updated_at)
+						VALUES (?, ?, ?, ?, ?)`, productUUID, serialNumber, warehouseUUID, models.SerialAvailable, now)
+			if err != nil {
+				r.logger.Error("error creating serial", "error", err)
+				return err
+			}
+			if err := r.addSerialEvent(tx, productUUID, serialNumber, warehouseUUID, models.SerialAvailable, "", now); err != nil {
+				return err
+			}
+		case err != nil:
+			r.logger.Error("error getting serial", "error", err)
+			return err
+		case status == models.SerialShipped || status == models.SerialMissing:
+			if err := r.setSerialStatus(tx, productUUID, serialNumber, warehouseUUID, models.SerialReturned, ""); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("%w: %s %s", models.ErrSerialExists, item.ProductArticle, serialNumber)
+		}
+	}
+
+	return nil
+}
+
+// moveSerials переводит count серийных номеров товара на складе по правилу move и возвращает их.
+// Для товара без серийных номеров ничего не делает
+func (r *SQLiteRepo) moveSerials(tx *sql.Tx, productArticle string, warehouseUUID string, count int, move repository.SerialMove) ([]string, error) {
+	var productUUID string
+	var serialized bool
+	err := tx.QueryRow("SELECT uuid, serialized FROM products WHERE article = ?", productArticle).Scan(&productUUID, &serialized)
+	if err != nil {
+		r.logger.Error("error getting product", "error", err)
+		return nil, err
+	}
+	if !serialized {
+		return nil, nil
+	}
+
+	args := []any{productUUID, warehouseUUID}
+	for _, status := range move.From {
+		args = append(args, status)
+	}
+	conditions := []string{"product_uuid = ?", "warehouse_uuid = ?", "status IN (" + placeholders(len(move.From)) + ")"}
+	if move.FromReservation == "" {
+		conditions = append(conditions, "reservation_uuid IS NULL")
+	} else {
+		conditions = append(conditions, "reservation_uuid = ?")
+		args = append(args, move.FromReservation)
+	}
+	args = append(args, count)
+
+	rows, err := tx.Query("SELECT serial_number FROM product_serials WHERE "+strings.Join(conditions, " AND ")+
+		" ORDER BY serial_number LIMIT ?", args...)
+	if err != nil {
+		r.logger.Error("error getting serials", "error", err)
+		return nil, err
+	}
+	serials, err := scanStrings(rows)
+	if err != nil {
+		r.logger.Error("error scanning serials", "error", err)
+		return nil, err
+	}
+	if len(serials) < count {
+		return nil, fmt.Errorf("%w: serial numbers of %s", move.Err, productArticle)
+	}
+
+	for _, serialNumber := range serials {
+		if err := r.setSerialStatus(tx, productUUID, serialNumber, warehouseUUID, move.To, move.ToReservation); err != nil {
+			return nil, err
+		}
+	}
+
+	return serials, nil
+}
+
+// setSerialStatus меняет состояние серийного номера и записывает событие в историю
+func (r *SQLiteRepo) setSerialStatus(tx *sql.Tx, productUUID string, serialNumber string, warehouseUUID string, status string, reservationUUID string) error {
+	now := time.Now().UTC()
+
+	_, err := tx.Exec(`UPDATE product_serials SET warehouse_uuid = ?, status = ?, reservation_uuid = ?, updated_at = ?
+				WHERE product_uuid = ? AND serial_number = ?`,
+		warehouseUUID, status, repository.NullString(reservationUUID), now, productUUID, serialNumber)
+	if err != nil {
+		r.logger.Error("error updating serial", "error", err)
+		return err
+	}
+
+	return r.addSerialEvent(tx, productUUID, serialNumber, warehouseUUID, status, reservationUUID, now)
+}
+
+func (r *SQLiteRepo) addSerialEvent(tx *sql.Tx, productUUID string, serialNumber string, warehouseUUID string, status string, reservationUUID string, createdAt time.Time) error {
+	query := `INSERT INTO product_serial_events (product_uuid, serial_number, seq, status, warehouse_uuid, reservation_uuid, created_at)
+				SELECT ?, ?, COALESCE(MAX(seq), 0) + 1, ?, ?, ?, ? FROM product_serial_events
+				WHERE product_uuid = ? AND serial_number = ?`
+
+	_, err := tx.Exec(query, productUUID, serialNumber, status, warehouseUUID, repository.NullString(reservationUUID), createdAt,
+		productUUID, serialNumber)
+	if err != nil {
+		r.logger.Error("error adding serial event", "error", err)
+		return err
+	}
+
+	return nil
+}
+
+// reservationSerials возвращает номера, которые сейчас в резерве, по артикулу и складу
+func (r *SQLiteRepo) reservationSerials(reservationUUID string) (map[[2]string][]string, error) {
+	query := `SELECT p.article, ps.warehouse_uuid, ps.serial_number
+				FROM product_serials ps
+					INNER JOIN products p ON p.uuid = ps.product_uuid
+				WHERE ps.reservation_uuid = ? AND ps.status = ?
+				ORDER BY ps.serial_number`
+
+	rows, err := r.db.Query(query, reservationUUID, models.SerialReserved)
+	if err != nil {
+		r.logger.Error("error getting reservation serials", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	serials := make(map[[2]string][]string)
+	for rows.Next() {
+		var article, warehouseUUID, serialNumber string
+		if err := rows.Scan(&article, &warehouseUUID, &serialNumber); err != nil {
+			r.logger.Error("error scanning reservation serials", "error", err)
+			return nil, err
+		}
+		key := [2]string{article, warehouseUUID}
+		serials[key] = append(serials[key], serialNumber)
+	}
+
+	return serials, rows.Err()
+}
+
+func scanStrings(rows *sql.Rows) ([]string, error) {
+	defer rows.Close()
+
+	values := make([]string, 0)
+	for rows.Next() {
+		var value string
+		if err := rows.Scan(&value); err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+
+	return values, rows.Err()
+}
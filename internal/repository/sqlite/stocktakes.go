@@ -0,0 +1,314 @@
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/shamank/warehouse-service/internal/domain/models"
+	"github.com/shamank/warehouse-service/internal/repository"
+	"strings"
+	"time"
+)
+
+// CreateStocktake открывает инвентаризацию и запоминает остатки вместе с резервом: по товарам склада
+// или по ячейкам зоны stocktake.ZonePath. Штучный товар не инвентаризируется. Если на складе уже идет
+// инвентаризация всего склада или той же зоны, возвращается models.ErrStocktakeOpen
+func (r *SQLiteRepo) CreateStocktake(stocktake models.Stocktake) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	query := "SELECT COUNT(*) FROM stocktakes WHERE warehouse_uuid = ? AND status = ?"
+	args := []any{stocktake.WarehouseUUID, models.StocktakeOpen}
+	if stocktake.ZoneUUID != "" {
+		query += " AND (zone_uuid IS NULL OR zone_uuid = ?)"
+		args = append(args, stocktake.ZoneUUID)
+	}
+
+	var open int
+	if err := tx.QueryRow(query, args...).Scan(&open); err != nil {
+		tx.Rollback()
+		r.logger.Error("error getting open stocktakes", "error", err)
+		return err
+	}
+	if open > 0 {
+		tx.Rollback()
+		return fmt.Errorf("%w: warehouse %s", models.ErrStocktakeOpen, stocktake.WarehouseUUID)
+	}
+
+	_, err = tx.Exec(`INSERT INTO stocktakes (uuid, warehouse_uuid, zone_uuid, blind, status, created_by, created_at)
+				VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		stocktake.UUID, stocktake.WarehouseUUID, repository.NullString(stocktake.ZoneUUID), stocktake.Blind, stocktake.Status,
+		stocktake.CreatedBy, stocktake.CreatedAt)
+	if err != nil {
+		tx.Rollback()
+		r.logger.Error("error creating stocktake", "error", err)
+		return err
+	}
+
+	lines, err := r.stocktakeSnapshot(tx, stocktake)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	for i, line := range lines {
+		if err := r.addStocktakeLine(tx, stocktake.UUID, i+1, line); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// stocktakeSnapshot возвращает строки инвентаризации с остатком вместе с резервом в порядке обхода
+func (r *SQLiteRepo) stocktakeSnapshot(tx *sql.Tx, stocktake models.Stocktake) ([]repository.StocktakeSnapshot, error) {
+	query := `SELECT p.article, '', '', wp.quantity + wp.reserved_quantity
+				FROM warehouse_products wp
+					INNER JOIN products p ON p.uuid = wp.product_uuid
+				WHERE wp.warehouse_uuid = ? AND NOT p.serialized AND wp.quantity + wp.reserved_quantity > 0
+				ORDER BY p.article`
+	if stocktake.ZoneUUID != "" {
+		query = `SELECT p.article, l.path, ls.location_uuid, ls.quantity + ls.reserved_quantity
+				FROM location_stock ls
+					INNER JOIN products p ON p.uuid = ls.product_uuid
+					INNER JOIN locations l ON l.uuid = ls.location_uuid
+				WHERE ls.warehouse_uuid = ? AND NOT p.serialized AND ls.quantity + ls.reserved_quantity > 0
+				ORDER BY l.path, p.article`
+	}
+
+	rows, err := tx.Query(query, stocktake.WarehouseUUID)
+	if err != nil {
+		r.logger.Error("error getting stocktake snapshot", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	lines := make([]repository.StocktakeSnapshot, 0)
+	for rows.Next() {
+		var line repository.StocktakeSnapshot
+		var path string
+		if err := rows.Scan(&line.ProductArticle, &path, &line.LocationUUID, &line.Expected); err != nil {
+			r.logger.Error("error scanning stocktake snapshot", "error", err)
+			return nil, err
+		}
+		if stocktake.ZoneUUID != "" && !strings.HasPrefix(path, stocktake.ZonePath+"/") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+
+	return lines, rows.Err()
+}
+
+// addStocktakeLine добавляет строку инвентаризации, без locationUUID - товар на складе целиком
+func (r *SQLiteRepo) addStocktakeLine(tx *sql.Tx, stocktakeUUID string, lineNo int, line repository.StocktakeSnapshot) error {
+	_, err := tx.Exec(`INSERT INTO stocktake_lines (stocktake_uuid, line_no, product_uuid, location_uuid, expected)
+				SELECT ?, ?, uuid, ?, ? FROM products WHERE article = ?`,
+		stocktakeUUID, lineNo, repository.NullString(line.LocationUUID), line.Expected, line.ProductArticle)
+	if err != nil {
+		r.logger.Error("error creating stocktake line", "error", err)
+		return err
+	}
+
+	return nil
+}
+
+// GetStocktake возвращает инвентаризацию со строками или models.ErrStocktakeNotFound
+func (r *SQLiteRepo) GetStocktake(stocktakeUUID string) (models.Stocktake, error) {
+	var stocktake models.Stocktake
+	var closedAt sql.NullTime
+
+	err := r.db.QueryRow(`SELECT s.uuid, s.warehouse_uuid, COALESCE(s.zone_uuid, ''), COALESCE(l.path, ''), s.blind, s.status,
+					s.created_by, s.created_at, COALESCE(s.closed_by, ''), s.closed_at
+				FROM stocktakes s
+					LEFT JOIN locations l ON l.uuid = s.zone_uuid
+				WHERE s.uuid = ?`, stocktakeUUID).
+		Scan(&stocktake.UUID, &stocktake.WarehouseUUID, &stocktake.ZoneUUID, &stocktake.ZonePath, &stocktake.Blind, &stocktake.Status,
+			&stocktake.CreatedBy, &stocktake.CreatedAt, &stocktake.ClosedBy, &closedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Stocktake{}, models.ErrStocktakeNotFound
+	}
+	if err != nil {
+		r.logger.Error("error getting stocktake", "error", err)
+		return models.Stocktake{}, err
+	}
+	stocktake.CreatedAt = stocktake.CreatedAt.UTC()
+	if closedAt.Valid {
+		closed := closedAt.Time.UTC()
+		stocktake.ClosedAt = &closed
+	}
+
+	rows, err := r.db.Query(`SELECT sl.line_no, p.article, COALESCE(l.path, ''), sl.expected, sl.counted, sl.on_hand, sl.counted_at,
+					COALESCE(sl.adjustment_uuid, ''), sl.unadjusted
+				FROM stocktake_lines sl
+					INNER JOIN products p ON p.uuid = sl.product_uuid
+					LEFT JOIN locations l ON l.uuid = sl.location_uuid
+				WHERE sl.stocktake_uuid = ?
+				ORDER BY sl.line_no`, stocktakeUUID)
+	if err != nil {
+		r.logger.Error("error getting stocktake lines", "error", err)
+		return models.Stocktake{}, err
+	}
+	defer rows.Close()
+
+	stocktake.Lines = make([]models.StocktakeLine, 0)
+	for rows.Next() {
+		var line models.StocktakeLine
+		var counted sql.NullInt64
+		var countedAt sql.NullTime
+		err := rows.Scan(&line.LineNo, &line.ProductArticle, &line.LocationPath, &line.Expected, &counted, &line.OnHand, &countedAt,
+			&line.AdjustmentUUID, &line.Unadjusted)
+		if err != nil {
+			r.logger.Error("error scanning stocktake lines", "error", err)
+			return models.Stocktake{}, err
+		}
+		if counted.Valid {
+			quantity := int(counted.Int64)
+			line.Counted = &quantity
+		}
+		if countedAt.Valid {
+			at := countedAt.Time.UTC()
+			line.CountedAt = &at
+		}
+		stocktake.Lines = append(stocktake.Lines, line)
+	}
+
+	return stocktake, rows.Err()
+}
+
+// CountStocktake запоминает пересчитанные количества вместе с остатком в момент пересчета. Повторный пересчет
+// строки заменяет прежний, товар вне снимка добавляется новой строкой. Закрытая инвентаризация -
+// models.ErrStocktakeClosed
+func (r *SQLiteRepo) CountStocktake(stocktakeUUID string, counts []models.StocktakeCount, countedAt time.Time) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	var warehouseUUID, status string
+	err = tx.QueryRow("SELECT warehouse_uuid, status FROM stocktakes WHERE uuid = ?", stocktakeUUID).Scan(&warehouseUUID, &status)
+	if errors.Is(err, sql.ErrNoRows) {
+		tx.Rollback()
+		return models.ErrStocktakeNotFound
+	}
+	if err != nil {
+		tx.Rollback()
+		r.logger.Error("error getting stocktake", "error", err)
+		return err
+	}
+	if status != models.StocktakeOpen {
+		tx.Rollback()
+		return fmt.Errorf("%w: %s", models.ErrStocktakeClosed, stocktakeUUID)
+	}
+
+	for _, count := range counts {
+		if err := r.countStocktakeLine(tx, stocktakeUUID, warehouseUUID, count, countedAt); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *SQLiteRepo) countStocktakeLine(tx *sql.Tx, stocktakeUUID string, warehouseUUID string, count models.StocktakeCount, countedAt time.Time) error {
+	var locationUUID string
+	onHandQuery := `SELECT wp.quantity + wp.reserved_quantity FROM warehouse_products wp
+					INNER JOIN products p ON p.uuid = wp.product_uuid
+				WHERE p.article = ? AND wp.warehouse_uuid = ?`
+	args := []any{count.ProductArticle, warehouseUUID}
+	if count.LocationPath != "" {
+		var err error
+		locationUUID, err = r.binUUID(tx, warehouseUUID, count.LocationPath)
+		if err != nil {
+			return err
+		}
+		onHandQuery = `SELECT ls.quantity + ls.reserved_quantity FROM location_stock ls
+					INNER JOIN products p ON p.uuid = ls.product_uuid
+				WHERE p.article = ? AND ls.location_uuid = ?`
+		args = []any{count.ProductArticle, locationUUID}
+	}
+
+	var onHand int
+	if err := tx.QueryRow(onHandQuery, args...).Scan(&onHand); err != nil && !errors.Is(err, sql.ErrNoRows) {
+		r.logger.Error("error getting quantity on hand", "error", err)
+		return err
+	}
+
+	var lineNo int
+	err := tx.QueryRow(`SELECT sl.line_no FROM stocktake_lines sl
+					INNER JOIN products p ON p.uuid = sl.product_uuid
+				WHERE sl.stocktake_uuid = ? AND p.article = ? AND COALESCE(sl.location_uuid, '') = ?`,
+		stocktakeUUID, count.ProductArticle, locationUUID).Scan(&lineNo)
+	if errors.Is(err, sql.ErrNoRows) {
+		if err := tx.QueryRow("SELECT COALESCE(MAX(line_no), 0) + 1 FROM stocktake_lines WHERE stocktake_uuid = ?", stocktakeUUID).Scan(&lineNo); err != nil {
+			r.logger.Error("error getting stocktake lines", "error", err)
+			return err
+		}
+		line := repository.StocktakeSnapshot{ProductArticle: count.ProductArticle, LocationUUID: locationUUID}
+		if err := r.addStocktakeLine(tx, stocktakeUUID, lineNo, line); err != nil {
+			return err
+		}
+	} else if err != nil {
+		r.logger.Error("error getting stocktake line", "error", err)
+		return err
+	}
+
+	result, err := tx.Exec("UPDATE stocktake_lines SET counted = ?, on_hand = ?, counted_at = ? WHERE stocktake_uuid = ? AND line_no = ?",
+		count.Counted, onHand, countedAt, stocktakeUUID, lineNo)
+	if err != nil {
+		r.logger.Error("error counting stocktake line", "error", err)
+		return err
+	}
+	if rows, err := result.RowsAffected(); err != nil || rows == 0 {
+		return errors.Join(fmt.Errorf("%w: %s", models.ErrProductNotFound, count.ProductArticle), err)
+	}
+
+	return nil
+}
+
+// CloseStocktake закрывает инвентаризацию и в той же транзакции сохраняет корректировки расхождений,
+// связывая их со строками по AdjustmentUUID, и несписанные недостачи строк. Закрытая инвентаризация - models.ErrStocktakeClosed
+func (r *SQLiteRepo) CloseStocktake(stocktake models.Stocktake, adjustments []models.Adjustment) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	result, err := tx.Exec("UPDATE stocktakes SET status = ?, closed_by = ?, closed_at = ? WHERE uuid = ? AND status = ?",
+		models.StocktakeClosed, repository.NullString(stocktake.ClosedBy), stocktake.ClosedAt, stocktake.UUID, models.StocktakeOpen)
+	if err != nil {
+		tx.Rollback()
+		r.logger.Error("error closing stocktake", "error", err)
+		return err
+	}
+	if rows, err := result.RowsAffected(); err != nil || rows == 0 {
+		tx.Rollback()
+		return errors.Join(fmt.Errorf("%w: %s", models.ErrStocktakeClosed, stocktake.UUID), err)
+	}
+
+	for _, adjustment := range adjustments {
+		if err := r.createAdjustment(tx, adjustment); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	for _, line := range stocktake.Lines {
+		if line.AdjustmentUUID == "" && line.Unadjusted == 0 {
+			continue
+		}
+		_, err := tx.Exec("UPDATE stocktake_lines SET adjustment_uuid = ?, unadjusted = ? WHERE stocktake_uuid = ? AND line_no = ?",
+			repository.NullString(line.AdjustmentUUID), line.Unadjusted, stocktake.UUID, line.LineNo)
+		if err != nil {
+			tx.Rollback()
+			r.logger.Error("error linking stocktake adjustment", "error", err)
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,9 @@
+package repository
+
+// StocktakeSnapshot - остаток товара вместе с резервом при открытии инвентаризации,
+// в ячейке LocationUUID или, без нее, на складе целиком
+type StocktakeSnapshot struct {
+	ProductArticle string
+	LocationUUID   string
+	Expected       int
+}
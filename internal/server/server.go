@@ -2,7 +2,7 @@ package server
 
 import (
 	"context"
-	"fmt"
+	"errors"
 	"github.com/shamank/warehouse-service/internal/config"
 	"net/http"
 )
@@ -12,8 +12,6 @@ type Server struct {
 }
 
 func NewServer(httpConfig config.HTTPConfig) *Server {
-
-	fmt.Println(httpConfig)
 	return &Server{
 		httpServer: &http.Server{
 			Addr:           httpConfig.Host + ":" + httpConfig.Port,
@@ -25,14 +23,20 @@ func NewServer(httpConfig config.HTTPConfig) *Server {
 	}
 }
 
-func (s *Server) Start() error {
-	return s.httpServer.ListenAndServe()
+// Run принимает запросы до остановки. Остановка через Stop не считается ошибкой
+func (s *Server) Run() error {
+	if err := s.httpServer.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return nil
 }
 
 func (s *Server) SetHandler(handler http.Handler) {
 	s.httpServer.Handler = handler
 }
 
+// Stop перестает принимать соединения и ждет завершения начатых запросов, но не дольше ctx
 func (s *Server) Stop(ctx context.Context) error {
 	return s.httpServer.Shutdown(ctx)
 }
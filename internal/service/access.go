@@ -0,0 +1,64 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/shamank/warehouse-service/internal/auth"
+	"github.com/shamank/warehouse-service/internal/domain/models"
+)
+
+// clientWarehouses возвращает склады, доступные клиенту запроса. nil - все склады
+func clientWarehouses(ctx context.Context) []string {
+	principal, ok := auth.PrincipalFromContext(ctx)
+	if !ok {
+		return nil
+	}
+	return principal.Warehouses
+}
+
+// clientSubject возвращает имя клиента запроса или пустую строку, если проверка доступа выключена
+func clientSubject(ctx context.Context) string {
+	principal, ok := auth.PrincipalFromContext(ctx)
+	if !ok {
+		return ""
+	}
+	return principal.Subject
+}
+
+// checkWarehouseAccess возвращает models.ErrWarehouseAccessDenied для первого склада, недоступного клиенту запроса.
+// Запросы без клиента в контексте (проверка доступа выключена) не ограничиваются
+func checkWarehouseAccess(ctx context.Context, warehouseUUIDs ...string) error {
+	principal, ok := auth.PrincipalFromContext(ctx)
+	if !ok {
+		return nil
+	}
+
+	for _, warehouseUUID := range warehouseUUIDs {
+		if !principal.CanAccessWarehouse(warehouseUUID) {
+			return fmt.Errorf("%w: client %q is not allowed to use warehouse %s",
+				models.ErrWarehouseAccessDenied, principal.Subject, warehouseUUID)
+		}
+	}
+
+	return nil
+}
+
+// checkReservationAccess запрещает клиенту резервы, в которых есть недоступные ему склады
+func checkReservationAccess(ctx context.Context, reservation models.Reservation) error {
+	warehouses := make([]string, len(reservation.Items))
+	for i, item := range reservation.Items {
+		warehouses[i] = item.WarehouseUUID
+	}
+
+	return checkWarehouseAccess(ctx, warehouses...)
+}
+
+// checkPickListAccess запрещает клиенту листы отбора, в которых есть недоступные ему склады
+func checkPickListAccess(ctx context.Context, pickList models.PickList) error {
+	warehouses := make([]string, len(pickList.Lines))
+	for i, line := range pickList.Lines {
+		warehouses[i] = line.WarehouseUUID
+	}
+
+	return checkWarehouseAccess(ctx, warehouses...)
+}
@@ -0,0 +1,131 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/shamank/warehouse-service/internal/auth"
+	"github.com/shamank/warehouse-service/internal/domain/models"
+	"github.com/shamank/warehouse-service/internal/domain/schemas"
+	"github.com/shamank/warehouse-service/internal/service/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"log/slog"
+	"testing"
+)
+
+func TestService_WarehouseAccess(t *testing.T) {
+	const (
+		allowed   = "d10c8d17-6d15-445e-b643-6affa59aa26c"
+		forbidden = "a00518e4-be6e-4eb7-9f95-bb52cc8b8548"
+	)
+
+	ctx := auth.WithPrincipal(context.Background(), auth.Principal{
+		Subject:    "billing",
+		Scopes:     []string{auth.ScopeAdmin},
+		Warehouses: []string{allowed},
+	})
+	denied := fmt.Errorf("%w: client \"billing\" is not allowed to use warehouse %s", models.ErrWarehouseAccessDenied, forbidden)
+
+	// на недоступном складе товара больше, но резерв берется только с доступного
+	quantities := []models.WarehouseProduct{
+		{WarehouseUUID: forbidden, Quantity: 10},
+		{WarehouseUUID: allowed, Quantity: 3},
+	}
+
+	t.Run("stock of forbidden warehouse", func(t *testing.T) {
+		svc := NewService(mocks.NewRepository(t), slog.Default())
+
+		_, err := svc.GetRemainingProducts(ctx, forbidden, schemas.RemainingProductsFilter{})
+		assert.Equal(t, denied, err)
+	})
+
+	t.Run("batch without warehouses is limited to allowed ones", func(t *testing.T) {
+		repo := mocks.NewRepository(t)
+		repo.On("GetKits", mock.Anything).Return([]models.Kit{}, nil).Maybe()
+		repo.On("GetProductsStock", []string{"product1"}, []string{allowed}).Return([]models.ProductStock{}, nil)
+
+		svc := NewService(repo, slog.Default())
+
+		_, err := svc.GetRemainingProductsBatch(ctx, schemas.ProductsStockQuery{Articles: []string{"product1"}})
+		assert.NoError(t, err)
+	})
+
+	t.Run("batch with forbidden warehouse", func(t *testing.T) {
+		svc := NewService(mocks.NewRepository(t), slog.Default())
+
+		_, err := svc.GetRemainingProductsBatch(ctx, schemas.ProductsStockQuery{Warehouses: []string{allowed, forbidden}})
+		assert.Equal(t, denied, err)
+	})
+
+	t.Run("reserve only from allowed warehouses", func(t *testing.T) {
+		repo := mocks.NewRepository(t)
+		repo.On("GetKits", mock.Anything).Return([]models.Kit{}, nil).Maybe()
+		repo.On("GetProductsByArticles", []string{"product1"}).Return([]models.Product{{Code: "product1"}}, nil)
+		repo.On("GetProductsQuantity", "product1").Return(quantities, nil)
+		repo.On("ReserveProducts", []schemas.ProductWarehouseSplitted{
+			{ProductArticle: "product1", WarehouseData: []schemas.WarehouseCounter{{WarehouseUUID: allowed, Count: 2}}},
+		}).Return(nil)
+
+		svc := NewService(repo, slog.Default())
+
+		require.NoError(t, svc.ReserveItems(ctx, []schemas.ReserveItem{{Article: "product1", Quantity: 2}}))
+	})
+
+	t.Run("reserve more than allowed warehouses have", func(t *testing.T) {
+		repo := mocks.NewRepository(t)
+		repo.On("GetKits", mock.Anything).Return([]models.Kit{}, nil).Maybe()
+		repo.On("GetProductsQuantity", "product1").Return(quantities, nil)
+
+		svc := NewService(repo, slog.Default())
+
+		err := svc.ReserveProducts(ctx, []string{"product1", "product1", "product1", "product1"})
+		assert.ErrorIs(t, err, models.ErrNotEnoughProducts)
+	})
+
+	t.Run("reserve from forbidden warehouse", func(t *testing.T) {
+		svc := NewService(mocks.NewRepository(t), slog.Default())
+
+		err := svc.ReserveItems(ctx, []schemas.ReserveItem{{Article: "product1", Quantity: 1, WarehouseUUID: forbidden}})
+		assert.Equal(t, denied, err)
+
+		_, err = svc.CreateReservation(ctx, []schemas.ReserveItem{{Article: "product1", Quantity: 1, AllowedWarehouses: []string{forbidden}}})
+		assert.Equal(t, denied, err)
+	})
+
+	t.Run("reservation on forbidden warehouse", func(t *testing.T) {
+		const reservationUUID = "5b0f2c3e-7a41-4d8e-9c6b-2e1f0a9d8c7b"
+
+		repo := mocks.NewRepository(t)
+		repo.On("GetKits", mock.Anything).Return([]models.Kit{}, nil).Maybe()
+		repo.On("GetReservation", reservationUUID).Return(models.Reservation{
+			UUID:   reservationUUID,
+			Status: models.ReservationActive,
+			Items: []models.ReservationItem{
+				{ProductArticle: "product1", WarehouseUUID: allowed, Quantity: 1},
+				{ProductArticle: "product1", WarehouseUUID: forbidden, Quantity: 1},
+			},
+		}, nil)
+
+		svc := NewService(repo, slog.Default())
+
+		_, err := svc.GetReservation(ctx, reservationUUID)
+		assert.Equal(t, denied, err)
+
+		_, err = svc.CancelReservation(ctx, reservationUUID)
+		assert.Equal(t, denied, err)
+	})
+
+	t.Run("without principal all warehouses are allowed", func(t *testing.T) {
+		repo := mocks.NewRepository(t)
+		repo.On("GetKits", mock.Anything).Return([]models.Kit{}, nil).Maybe()
+		repo.On("GetProductsQuantity", "product1").Return(quantities, nil)
+		repo.On("ReserveProducts", []schemas.ProductWarehouseSplitted{
+			{ProductArticle: "product1", WarehouseData: []schemas.WarehouseCounter{{WarehouseUUID: forbidden, Count: 4}}},
+		}).Return(nil)
+
+		svc := NewService(repo, slog.Default())
+
+		require.NoError(t, svc.ReserveProducts(context.Background(), []string{"product1", "product1", "product1", "product1"}))
+	})
+}
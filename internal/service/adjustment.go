@@ -0,0 +1,183 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/shamank/warehouse-service/internal/auth"
+	"github.com/shamank/warehouse-service/internal/domain/models"
+	"github.com/shamank/warehouse-service/internal/domain/schemas"
+	"time"
+)
+
+// SetAdjustmentThreshold задает порог подтверждения корректировок: корректировка больше threshold штук
+// в любую сторону ждет подтверждения администратора. При нулевом пороге корректировки применяются сразу
+func (s *Service) SetAdjustmentThreshold(threshold int) {
+	s.adjustmentThreshold = threshold
+}
+
+// CreateAdjustment корректирует свободный остаток товара на складе. Корректировка больше порога
+// сохраняется в статусе models.AdjustmentPending и не меняет остаток до подтверждения
+func (s *Service) CreateAdjustment(ctx context.Context, warehouseUUID string, request schemas.NewAdjustment) (schemas.Adjustment, error) {
+	if err := checkWarehouseAccess(ctx, warehouseUUID); err != nil {
+		return schemas.Adjustment{}, err
+	}
+
+	if _, err := s.repo.GetWarehouse(warehouseUUID); err != nil {
+		return schemas.Adjustment{}, err
+	}
+
+	if err := validateAdjustment(request); err != nil {
+		return schemas.Adjustment{}, err
+	}
+
+	products, err := s.productsByArticle([]string{request.Article})
+	if err != nil {
+		return schemas.Adjustment{}, err
+	}
+	if products[request.Article].Serialized {
+		return schemas.Adjustment{}, fmt.Errorf("%w: %s is tracked by serial numbers", models.ErrInvalidAdjustment, request.Article)
+	}
+
+	// автор из запроса принимается только без проверки доступа, иначе клиент мог бы записать в журнал чужое имя
+	actor := clientSubject(ctx)
+	if _, ok := auth.PrincipalFromContext(ctx); !ok {
+		actor = request.Actor
+	}
+	if actor == "" {
+		return schemas.Adjustment{}, fmt.Errorf("%w: actor is required", models.ErrInvalidAdjustment)
+	}
+
+	adjustment := models.Adjustment{
+		UUID:           uuid.NewString(),
+		WarehouseUUID:  warehouseUUID,
+		ProductArticle: request.Article,
+		Delta:          request.Delta,
+		Reason:         request.Reason,
+		Comment:        request.Comment,
+		Actor:          actor,
+		Status:         models.AdjustmentApplied,
+		CreatedAt:      time.Now().UTC(),
+	}
+	if s.adjustmentThreshold > 0 && abs(request.Delta) > s.adjustmentThreshold {
+		adjustment.Status = models.AdjustmentPending
+	}
+
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	if err := s.repo.CreateAdjustment(adjustment); err != nil {
+		return schemas.Adjustment{}, err
+	}
+
+	return adjustmentSchema(adjustment), nil
+}
+
+// GetAdjustments возвращает корректировки склада от старых к новым, с непустым status - только в этом статусе
+func (s *Service) GetAdjustments(ctx context.Context, warehouseUUID string, status string) ([]schemas.Adjustment, error) {
+	if err := checkWarehouseAccess(ctx, warehouseUUID); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.repo.GetWarehouse(warehouseUUID); err != nil {
+		return nil, err
+	}
+
+	adjustments, err := s.repo.GetAdjustments(warehouseUUID, status)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]schemas.Adjustment, len(adjustments))
+	for i, adjustment := range adjustments {
+		result[i] = adjustmentSchema(adjustment)
+	}
+
+	return result, nil
+}
+
+// ApproveAdjustment применяет корректировку, ждущую подтверждения
+func (s *Service) ApproveAdjustment(ctx context.Context, adjustmentUUID string) (schemas.Adjustment, error) {
+	return s.reviewAdjustment(ctx, adjustmentUUID, models.AdjustmentApplied)
+}
+
+// RejectAdjustment отклоняет корректировку, ждущую подтверждения, не меняя остаток
+func (s *Service) RejectAdjustment(ctx context.Context, adjustmentUUID string) (schemas.Adjustment, error) {
+	return s.reviewAdjustment(ctx, adjustmentUUID, models.AdjustmentRejected)
+}
+
+func (s *Service) reviewAdjustment(ctx context.Context, adjustmentUUID string, status string) (schemas.Adjustment, error) {
+	adjustment, err := s.repo.GetAdjustment(adjustmentUUID)
+	if err != nil {
+		return schemas.Adjustment{}, err
+	}
+
+	if err := checkWarehouseAccess(ctx, adjustment.WarehouseUUID); err != nil {
+		return schemas.Adjustment{}, err
+	}
+
+	if adjustment.Status != models.AdjustmentPending {
+		return schemas.Adjustment{}, fmt.Errorf("%w: %s", models.ErrAdjustmentReviewed, adjustment.Status)
+	}
+
+	// иначе порог подтверждения ничего не значит: автор подтвердил бы свою корректировку сам
+	if reviewer := clientSubject(ctx); status == models.AdjustmentApplied && reviewer != "" && reviewer == adjustment.Actor {
+		return schemas.Adjustment{}, models.ErrSelfApproval
+	}
+
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	if err := s.repo.ReviewAdjustment(adjustmentUUID, status, clientSubject(ctx), time.Now().UTC()); err != nil {
+		return schemas.Adjustment{}, err
+	}
+
+	adjustment, err = s.repo.GetAdjustment(adjustmentUUID)
+	if err != nil {
+		return schemas.Adjustment{}, err
+	}
+
+	return adjustmentSchema(adjustment), nil
+}
+
+// validateAdjustment проверяет, что причина корректировки согласуется со знаком: повреждение и потеря
+// уменьшают остаток, находка увеличивает
+func validateAdjustment(request schemas.NewAdjustment) error {
+	switch {
+	case request.Delta == 0:
+		return fmt.Errorf("%w: delta must not be zero", models.ErrInvalidAdjustment)
+	case (request.Reason == models.AdjustmentDamaged || request.Reason == models.AdjustmentLost) && request.Delta > 0:
+		return fmt.Errorf("%w: %s stock can only be written off", models.ErrInvalidAdjustment, request.Reason)
+	case request.Reason == models.AdjustmentFound && request.Delta < 0:
+		return fmt.Errorf("%w: found stock can only be added", models.ErrInvalidAdjustment)
+	case request.Reason != models.AdjustmentDamaged && request.Reason != models.AdjustmentLost &&
+		request.Reason != models.AdjustmentFound && request.Reason != models.AdjustmentCorrection:
+		return fmt.Errorf("%w: unknown reason %q", models.ErrInvalidAdjustment, request.Reason)
+	}
+
+	return nil
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+func adjustmentSchema(adjustment models.Adjustment) schemas.Adjustment {
+	return schemas.Adjustment{
+		ID:            adjustment.UUID,
+		WarehouseUUID: adjustment.WarehouseUUID,
+		Article:       adjustment.ProductArticle,
+		Location:      adjustment.BinPath,
+		Delta:         adjustment.Delta,
+		Reason:        adjustment.Reason,
+		Comment:       adjustment.Comment,
+		Actor:         adjustment.Actor,
+		Status:        adjustment.Status,
+		CreatedAt:     adjustment.CreatedAt,
+		ReviewedBy:    adjustment.ReviewedBy,
+		ReviewedAt:    adjustment.ReviewedAt,
+	}
+}
@@ -0,0 +1,207 @@
+package service
+
+import (
+	"context"
+	"github.com/shamank/warehouse-service/internal/auth"
+	"github.com/shamank/warehouse-service/internal/domain/models"
+	"github.com/shamank/warehouse-service/internal/domain/schemas"
+	"github.com/shamank/warehouse-service/internal/service/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestService_CreateAdjustment(t *testing.T) {
+	const warehouse = "d10c8d17-6d15-445e-b643-6affa59aa26c"
+
+	ctx := auth.WithPrincipal(context.Background(), auth.Principal{
+		Subject: "inventory",
+		Scopes:  []string{auth.ScopeAdmin},
+	})
+
+	newRepo := func(t *testing.T) *mocks.Repository {
+		repo := mocks.NewRepository(t)
+		repo.On("GetWarehouse", warehouse).Return(models.Warehouse{UUID: warehouse, Name: "warehouse", Availability: true}, nil)
+		return repo
+	}
+
+	t.Run("applied below threshold", func(t *testing.T) {
+		repo := newRepo(t)
+		repo.On("GetProductsByArticles", []string{"product1"}).Return([]models.Product{{Code: "product1"}}, nil)
+		repo.On("CreateAdjustment", mock.MatchedBy(func(adjustment models.Adjustment) bool {
+			return adjustment.UUID != "" && adjustment.WarehouseUUID == warehouse && adjustment.ProductArticle == "product1" &&
+				adjustment.Delta == -10 && adjustment.Actor == "inventory" && adjustment.Status == models.AdjustmentApplied
+		})).Return(nil)
+
+		svc := NewService(repo, slog.Default())
+		svc.SetAdjustmentThreshold(10)
+
+		adjustment, err := svc.CreateAdjustment(ctx, warehouse, schemas.NewAdjustment{
+			Article: "product1", Delta: -10, Reason: models.AdjustmentDamaged, Comment: "dropped",
+		})
+		require.NoError(t, err)
+		assert.NotEmpty(t, adjustment.ID)
+		assert.Equal(t, models.AdjustmentApplied, adjustment.Status)
+		assert.Equal(t, "dropped", adjustment.Comment)
+	})
+
+	t.Run("pending above threshold", func(t *testing.T) {
+		repo := newRepo(t)
+		repo.On("GetProductsByArticles", []string{"product1"}).Return([]models.Product{{Code: "product1"}}, nil)
+		repo.On("CreateAdjustment", mock.MatchedBy(func(adjustment models.Adjustment) bool {
+			return adjustment.Actor == "inventory" && adjustment.Status == models.AdjustmentPending
+		})).Return(nil)
+
+		svc := NewService(repo, slog.Default())
+		svc.SetAdjustmentThreshold(10)
+
+		// автор из запроса не подменяет клиента API
+		adjustment, err := svc.CreateAdjustment(ctx, warehouse, schemas.NewAdjustment{
+			Article: "product1", Delta: 11, Reason: models.AdjustmentCorrection, Actor: "supervisor",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, models.AdjustmentPending, adjustment.Status)
+	})
+
+	t.Run("zero threshold applies everything", func(t *testing.T) {
+		repo := newRepo(t)
+		repo.On("GetProductsByArticles", []string{"product1"}).Return([]models.Product{{Code: "product1"}}, nil)
+		repo.On("CreateAdjustment", mock.MatchedBy(func(adjustment models.Adjustment) bool {
+			return adjustment.Status == models.AdjustmentApplied
+		})).Return(nil)
+
+		svc := NewService(repo, slog.Default())
+
+		_, err := svc.CreateAdjustment(ctx, warehouse, schemas.NewAdjustment{Article: "product1", Delta: 1000, Reason: models.AdjustmentFound})
+		require.NoError(t, err)
+	})
+
+	t.Run("reason contradicts delta", func(t *testing.T) {
+		svc := NewService(newRepo(t), slog.Default())
+
+		for _, request := range []schemas.NewAdjustment{
+			{Article: "product1", Delta: 1, Reason: models.AdjustmentDamaged},
+			{Article: "product1", Delta: 1, Reason: models.AdjustmentLost},
+			{Article: "product1", Delta: -1, Reason: models.AdjustmentFound},
+			{Article: "product1", Delta: 0, Reason: models.AdjustmentCorrection},
+			{Article: "product1", Delta: 1, Reason: "stolen"},
+		} {
+			_, err := svc.CreateAdjustment(ctx, warehouse, request)
+			assert.ErrorIs(t, err, models.ErrInvalidAdjustment, "%+v", request)
+		}
+	})
+
+	t.Run("serialized product", func(t *testing.T) {
+		repo := newRepo(t)
+		repo.On("GetProductsByArticles", []string{"phone"}).Return([]models.Product{{Code: "phone", Serialized: true}}, nil)
+
+		svc := NewService(repo, slog.Default())
+
+		_, err := svc.CreateAdjustment(ctx, warehouse, schemas.NewAdjustment{Article: "phone", Delta: -1, Reason: models.AdjustmentLost})
+		assert.ErrorIs(t, err, models.ErrInvalidAdjustment)
+	})
+
+	t.Run("actor from request without auth", func(t *testing.T) {
+		repo := newRepo(t)
+		repo.On("GetProductsByArticles", []string{"product1"}).Return([]models.Product{{Code: "product1"}}, nil)
+		repo.On("CreateAdjustment", mock.MatchedBy(func(adjustment models.Adjustment) bool {
+			return adjustment.Actor == "night shift"
+		})).Return(nil)
+
+		svc := NewService(repo, slog.Default())
+
+		adjustment, err := svc.CreateAdjustment(context.Background(), warehouse, schemas.NewAdjustment{
+			Article: "product1", Delta: -1, Reason: models.AdjustmentLost, Actor: "night shift",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "night shift", adjustment.Actor)
+	})
+
+	t.Run("actor is required without auth", func(t *testing.T) {
+		repo := newRepo(t)
+		repo.On("GetProductsByArticles", []string{"product1"}).Return([]models.Product{{Code: "product1"}}, nil)
+
+		svc := NewService(repo, slog.Default())
+
+		_, err := svc.CreateAdjustment(context.Background(), warehouse, schemas.NewAdjustment{Article: "product1", Delta: -1, Reason: models.AdjustmentLost})
+		assert.ErrorIs(t, err, models.ErrInvalidAdjustment)
+	})
+}
+
+func TestService_ReviewAdjustment(t *testing.T) {
+	const (
+		warehouse  = "d10c8d17-6d15-445e-b643-6affa59aa26c"
+		adjustment = "7b3e9a2c-4d1f-4e8b-9c6a-0f5d2e8b1a47"
+	)
+
+	ctx := auth.WithPrincipal(context.Background(), auth.Principal{
+		Subject: "supervisor",
+		Scopes:  []string{auth.ScopeAdmin},
+	})
+	pending := models.Adjustment{
+		UUID: adjustment, WarehouseUUID: warehouse, ProductArticle: "product1", Delta: -500,
+		Reason: models.AdjustmentLost, Actor: "inventory", Status: models.AdjustmentPending,
+	}
+
+	t.Run("approve", func(t *testing.T) {
+		reviewedAt := time.Now().UTC()
+		approved := pending
+		approved.Status = models.AdjustmentApplied
+		approved.ReviewedBy = "supervisor"
+		approved.ReviewedAt = &reviewedAt
+
+		repo := mocks.NewRepository(t)
+		repo.On("GetAdjustment", adjustment).Return(pending, nil).Once()
+		repo.On("ReviewAdjustment", adjustment, models.AdjustmentApplied, "supervisor", mock.AnythingOfType("time.Time")).Return(nil)
+		repo.On("GetAdjustment", adjustment).Return(approved, nil).Once()
+
+		svc := NewService(repo, slog.Default())
+
+		result, err := svc.ApproveAdjustment(ctx, adjustment)
+		require.NoError(t, err)
+		assert.Equal(t, models.AdjustmentApplied, result.Status)
+		assert.Equal(t, "supervisor", result.ReviewedBy)
+	})
+
+	t.Run("already reviewed", func(t *testing.T) {
+		rejected := pending
+		rejected.Status = models.AdjustmentRejected
+
+		repo := mocks.NewRepository(t)
+		repo.On("GetAdjustment", adjustment).Return(rejected, nil)
+
+		svc := NewService(repo, slog.Default())
+
+		_, err := svc.ApproveAdjustment(ctx, adjustment)
+		assert.ErrorIs(t, err, models.ErrAdjustmentReviewed)
+	})
+
+	t.Run("approved by author", func(t *testing.T) {
+		repo := mocks.NewRepository(t)
+		repo.On("GetAdjustment", adjustment).Return(pending, nil)
+
+		svc := NewService(repo, slog.Default())
+
+		ctx := auth.WithPrincipal(context.Background(), auth.Principal{Subject: "inventory", Scopes: []string{auth.ScopeAdmin}})
+		_, err := svc.ApproveAdjustment(ctx, adjustment)
+		assert.ErrorIs(t, err, models.ErrSelfApproval)
+	})
+
+	t.Run("forbidden warehouse", func(t *testing.T) {
+		repo := mocks.NewRepository(t)
+		repo.On("GetAdjustment", adjustment).Return(pending, nil)
+
+		svc := NewService(repo, slog.Default())
+
+		ctx := auth.WithPrincipal(context.Background(), auth.Principal{
+			Subject:    "supervisor",
+			Scopes:     []string{auth.ScopeAdmin},
+			Warehouses: []string{"a00518e4-be6e-4eb7-9f95-bb52cc8b8548"},
+		})
+		_, err := svc.RejectAdjustment(ctx, adjustment)
+		assert.ErrorIs(t, err, models.ErrWarehouseAccessDenied)
+	})
+}
@@ -0,0 +1,71 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/shamank/warehouse-service/internal/domain/models"
+	"github.com/shamank/warehouse-service/internal/domain/schemas"
+	"slices"
+)
+
+// MoveBucketStock перемещает товар между корзинами остатка склада. Все перемещения выполняются вместе или не выполняются.
+// Товар в непродаваемых корзинах не резервируется, поэтому перемещение в продаваемую корзину возвращает его в продажу
+func (s *Service) MoveBucketStock(ctx context.Context, warehouseUUID string, moves []schemas.BucketMove) error {
+	if err := checkWarehouseAccess(ctx, warehouseUUID); err != nil {
+		return err
+	}
+
+	if _, err := s.repo.GetWarehouse(warehouseUUID); err != nil {
+		return err
+	}
+
+	articles := make([]string, 0, len(moves))
+	bucketMoves := make([]models.BucketMove, 0, len(moves))
+	for _, move := range moves {
+		if move.Quantity <= 0 {
+			return fmt.Errorf("%w: %s", models.ErrInvalidQuantity, move.Article)
+		}
+		if !slices.Contains(models.StockBuckets, move.From) || !slices.Contains(models.StockBuckets, move.To) {
+			return fmt.Errorf("%w: unknown bucket for %s", models.ErrInvalidBucketMove, move.Article)
+		}
+		if move.From == move.To {
+			return fmt.Errorf("%w: %s is moved to the same bucket", models.ErrInvalidBucketMove, move.Article)
+		}
+		articles = append(articles, move.Article)
+		bucketMoves = append(bucketMoves, models.BucketMove{
+			ProductArticle: move.Article,
+			Quantity:       move.Quantity,
+			From:           move.From,
+			To:             move.To,
+		})
+	}
+
+	products, err := s.productsByArticle(articles)
+	if err != nil {
+		return err
+	}
+	for _, article := range articles {
+		if products[article].Serialized {
+			return fmt.Errorf("%w: %s is tracked by serial numbers", models.ErrInvalidBucketMove, article)
+		}
+	}
+
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	return s.repo.MoveBucketStock(warehouseUUID, bucketMoves)
+}
+
+// productBuckets группирует непродаваемые остатки по товару и складу
+func productBuckets(stocks []models.BucketStock) map[[2]string]map[string]int {
+	buckets := make(map[[2]string]map[string]int)
+	for _, stock := range stocks {
+		key := [2]string{stock.ProductArticle, stock.WarehouseUUID}
+		if buckets[key] == nil {
+			buckets[key] = make(map[string]int)
+		}
+		buckets[key][stock.Bucket] = stock.Quantity
+	}
+
+	return buckets
+}
@@ -0,0 +1,56 @@
+package service
+
+import (
+	"context"
+	"github.com/shamank/warehouse-service/internal/auth"
+	"github.com/shamank/warehouse-service/internal/domain/models"
+	"github.com/shamank/warehouse-service/internal/domain/schemas"
+	"github.com/shamank/warehouse-service/internal/service/mocks"
+	"github.com/stretchr/testify/assert"
+	"log/slog"
+	"testing"
+)
+
+func TestService_MoveBucketStock(t *testing.T) {
+	const warehouse = "d10c8d17-6d15-445e-b643-6affa59aa26c"
+
+	ctx := auth.WithPrincipal(context.Background(), auth.Principal{
+		Subject: "inventory",
+		Scopes:  []string{auth.ScopeAdmin},
+	})
+
+	t.Run("move", func(t *testing.T) {
+		repo := mocks.NewRepository(t)
+		repo.On("GetWarehouse", warehouse).Return(models.Warehouse{UUID: warehouse, Availability: true}, nil)
+		repo.On("GetProductsByArticles", []string{"product1", "product2"}).Return([]models.Product{{Code: "product1"}, {Code: "product2"}}, nil)
+		repo.On("MoveBucketStock", warehouse, []models.BucketMove{
+			{ProductArticle: "product1", Quantity: 2, From: models.BucketSellable, To: models.BucketDamaged},
+			{ProductArticle: "product2", Quantity: 1, From: models.BucketQuarantine, To: models.BucketSellable},
+		}).Return(nil)
+
+		svc := NewService(repo, slog.Default())
+
+		err := svc.MoveBucketStock(ctx, warehouse, []schemas.BucketMove{
+			{Article: "product1", Quantity: 2, From: models.BucketSellable, To: models.BucketDamaged},
+			{Article: "product2", Quantity: 1, From: models.BucketQuarantine, To: models.BucketSellable},
+		})
+		assert.NoError(t, err)
+	})
+
+	t.Run("invalid moves", func(t *testing.T) {
+		repo := mocks.NewRepository(t)
+		repo.On("GetWarehouse", warehouse).Return(models.Warehouse{UUID: warehouse, Availability: true}, nil)
+		repo.On("GetProductsByArticles", []string{"phone"}).Return([]models.Product{{Code: "phone", Serialized: true}}, nil)
+
+		svc := NewService(repo, slog.Default())
+
+		for _, move := range []schemas.BucketMove{
+			{Article: "product1", Quantity: 1, From: models.BucketDamaged, To: models.BucketDamaged},
+			{Article: "product1", Quantity: 1, From: "lost", To: models.BucketSellable},
+			{Article: "phone", Quantity: 1, From: models.BucketSellable, To: models.BucketDamaged},
+		} {
+			err := svc.MoveBucketStock(ctx, warehouse, []schemas.BucketMove{move})
+			assert.ErrorIs(t, err, models.ErrInvalidBucketMove, "%+v", move)
+		}
+	})
+}
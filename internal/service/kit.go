@@ -0,0 +1,282 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/shamank/warehouse-service/internal/domain/models"
+	"github.com/shamank/warehouse-service/internal/domain/schemas"
+	"slices"
+	"strings"
+)
+
+// SaveKit задает состав набора, заменяя прежний. Набор - артикул каталога без собственного остатка,
+// компоненты - обычные товары, не наборы
+func (s *Service) SaveKit(ctx context.Context, article string, definition schemas.KitDefinition) (schemas.Kit, error) {
+	articles := []string{article}
+	kit := models.Kit{ProductArticle: article, Components: make([]models.KitComponent, 0, len(definition.Components))}
+	for _, component := range definition.Components {
+		if component.Quantity <= 0 {
+			return schemas.Kit{}, fmt.Errorf("%w: %s", models.ErrInvalidQuantity, component.Article)
+		}
+		if slices.Contains(articles, component.Article) {
+			return schemas.Kit{}, fmt.Errorf("%w: %s is listed twice or is the kit itself", models.ErrInvalidKit, component.Article)
+		}
+		articles = append(articles, component.Article)
+		kit.Components = append(kit.Components, models.KitComponent{ProductArticle: component.Article, Quantity: component.Quantity})
+	}
+	if len(kit.Components) == 0 {
+		return schemas.Kit{}, fmt.Errorf("%w: %s has no components", models.ErrInvalidKit, article)
+	}
+
+	if err := s.checkArticles(articles); err != nil {
+		return schemas.Kit{}, err
+	}
+
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	kits, err := s.repo.GetKits(nil)
+	if err != nil {
+		return schemas.Kit{}, err
+	}
+	for _, other := range kits {
+		if slices.Contains(articles[1:], other.ProductArticle) {
+			return schemas.Kit{}, fmt.Errorf("%w: component %s is a kit", models.ErrInvalidKit, other.ProductArticle)
+		}
+		for _, component := range other.Components {
+			if component.ProductArticle == article {
+				return schemas.Kit{}, fmt.Errorf("%w: %s is a component of kit %s", models.ErrInvalidKit, article, other.ProductArticle)
+			}
+		}
+	}
+
+	stock, err := s.repo.GetProductsQuantity(article)
+	if err != nil {
+		return schemas.Kit{}, err
+	}
+	for _, warehouseProduct := range stock {
+		if warehouseProduct.Quantity+warehouseProduct.ReservedQuantity > 0 {
+			return schemas.Kit{}, fmt.Errorf("%w: %s has its own stock", models.ErrInvalidKit, article)
+		}
+	}
+
+	if err := s.repo.SaveKit(kit); err != nil {
+		return schemas.Kit{}, err
+	}
+
+	return s.getKit(ctx, article)
+}
+
+// GetKit возвращает состав набора и сколько наборов можно собрать на доступных клиенту складах
+func (s *Service) GetKit(ctx context.Context, article string) (schemas.Kit, error) {
+	return s.getKit(ctx, article)
+}
+
+// DeleteKit удаляет состав набора, артикул остается в каталоге
+func (s *Service) DeleteKit(ctx context.Context, article string) error {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	return s.repo.DeleteKit(article)
+}
+
+func (s *Service) getKit(ctx context.Context, article string) (schemas.Kit, error) {
+	kits, err := s.repo.GetKits([]string{article})
+	if err != nil {
+		return schemas.Kit{}, err
+	}
+	if len(kits) == 0 {
+		return schemas.Kit{}, fmt.Errorf("%w: %s", models.ErrKitNotFound, article)
+	}
+
+	stocks, err := s.repo.GetProductsStock(kitComponents(kits), clientWarehouses(ctx))
+	if err != nil {
+		return schemas.Kit{}, err
+	}
+	kitStock := kitStocks(kits, stocks)[0]
+
+	components := make([]schemas.KitComponent, len(kits[0].Components))
+	for i, component := range kits[0].Components {
+		components[i] = schemas.KitComponent{Article: component.ProductArticle, Quantity: component.Quantity}
+	}
+
+	return schemas.Kit{
+		Article:    kits[0].ProductArticle,
+		Name:       kits[0].ProductName,
+		Size:       kits[0].ProductSize,
+		Components: components,
+		Available:  kitStock.Available,
+		Warehouses: kitStock.Warehouses,
+	}, nil
+}
+
+// kitComponents возвращает артикулы компонентов наборов без повторов
+func kitComponents(kits []models.Kit) []string {
+	articles := make([]string, 0)
+	for _, kit := range kits {
+		for _, component := range kit.Components {
+			if !slices.Contains(articles, component.ProductArticle) {
+				articles = append(articles, component.ProductArticle)
+			}
+		}
+	}
+
+	return articles
+}
+
+// kitStocks считает по остаткам stocks, сколько наборов можно собрать из свободного остатка компонентов
+// без просроченных партий: на каждом складе, где есть все компоненты, и на доступных складах вместе.
+// Остатки других товаров в stocks не учитываются
+func kitStocks(kits []models.Kit, stocks []models.ProductStock) []schemas.ProductStock {
+	components := kitComponents(kits)
+
+	// склады в порядке отчета об остатках, свободный остаток компонентов по складам и на доступных складах вместе
+	warehouses := make([]models.ProductStock, 0)
+	free := make(map[string]map[string]int)
+	total := make(map[string]int)
+	for _, stock := range stocks {
+		if !slices.Contains(components, stock.ProductArticle) {
+			continue
+		}
+		if free[stock.WarehouseUUID] == nil {
+			free[stock.WarehouseUUID] = make(map[string]int)
+			warehouses = append(warehouses, stock)
+		}
+		free[stock.WarehouseUUID][stock.ProductArticle] = stock.Quantity - stock.ExpiredQuantity
+		if stock.WarehouseAvailability {
+			total[stock.ProductArticle] += stock.Quantity - stock.ExpiredQuantity
+		}
+	}
+	slices.SortFunc(warehouses, func(a, b models.ProductStock) int {
+		return strings.Compare(a.WarehouseUUID, b.WarehouseUUID)
+	})
+
+	result := make([]schemas.ProductStock, len(kits))
+	for i, kit := range kits {
+		result[i] = schemas.ProductStock{
+			Name:       kit.ProductName,
+			Size:       kit.ProductSize,
+			Code:       kit.ProductArticle,
+			Kit:        true,
+			Available:  kit.Available(total),
+			Warehouses: make([]schemas.WarehouseStock, 0),
+		}
+
+		for _, warehouse := range warehouses {
+			complete := true
+			for _, component := range kit.Components {
+				if _, ok := free[warehouse.WarehouseUUID][component.ProductArticle]; !ok {
+					complete = false
+					break
+				}
+			}
+			if !complete {
+				continue
+			}
+
+			result[i].Warehouses = append(result[i].Warehouses, schemas.WarehouseStock{
+				WarehouseUUID: warehouse.WarehouseUUID,
+				IsAvailable:   warehouse.WarehouseAvailability,
+				Available:     kit.Available(free[warehouse.WarehouseUUID]),
+			})
+		}
+	}
+
+	return result
+}
+
+// expandKits заменяет каждое упоминание набора в списке артикулов компонентами, по одному упоминанию на штуку.
+// Вызывается под s.mx, чтобы состав набора не поменялся до резервирования
+func (s *Service) expandKits(articles []string) ([]string, error) {
+	kits, err := s.kitsByArticle(articles)
+	if err != nil || len(kits) == 0 {
+		return articles, err
+	}
+
+	expanded := make([]string, 0, len(articles))
+	for _, article := range articles {
+		kit, ok := kits[article]
+		if !ok {
+			expanded = append(expanded, article)
+			continue
+		}
+		for _, component := range kit.Components {
+			for i := 0; i < component.Quantity; i++ {
+				expanded = append(expanded, component.ProductArticle)
+			}
+		}
+	}
+
+	return expanded, nil
+}
+
+// expandKitItems заменяет позиции наборов позициями компонентов с теми же ограничениями по складам и заменам.
+// Позиции одного компонента с одинаковыми ограничениями складываются, с разными - models.ErrDuplicateArticle.
+// Вызывается под s.mx, как expandKits
+func (s *Service) expandKitItems(items []schemas.ReserveItem) ([]schemas.ReserveItem, error) {
+	articles := make([]string, len(items))
+	for i, item := range items {
+		articles[i] = item.Article
+	}
+
+	kits, err := s.kitsByArticle(articles)
+	if err != nil || len(kits) == 0 {
+		return items, err
+	}
+
+	expanded := make([]schemas.ReserveItem, 0, len(items))
+	add := func(item schemas.ReserveItem) error {
+		for i, other := range expanded {
+			if other.Article != item.Article {
+				continue
+			}
+			if other.WarehouseUUID != item.WarehouseUUID || !slices.Equal(other.AllowedWarehouses, item.AllowedWarehouses) ||
+				other.AllowSubstitutes != item.AllowSubstitutes {
+				return fmt.Errorf("%w: %s", models.ErrDuplicateArticle, item.Article)
+			}
+			expanded[i].Quantity += item.Quantity
+			return nil
+		}
+		expanded = append(expanded, item)
+		return nil
+	}
+
+	for _, item := range items {
+		kit, ok := kits[item.Article]
+		if !ok {
+			if err := add(item); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		for _, component := range kit.Expand(item.Quantity) {
+			componentItem := item
+			componentItem.Article = component.ProductArticle
+			componentItem.Quantity = component.Quantity
+			if err := add(componentItem); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return expanded, nil
+}
+
+// kitsByArticle возвращает наборы среди articles
+func (s *Service) kitsByArticle(articles []string) (map[string]models.Kit, error) {
+	if len(articles) == 0 {
+		return nil, nil
+	}
+
+	kits, err := s.repo.GetKits(articles)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]models.Kit, len(kits))
+	for _, kit := range kits {
+		result[kit.ProductArticle] = kit
+	}
+
+	return result, nil
+}
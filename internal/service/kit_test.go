@@ -0,0 +1,170 @@
+package service
+
+import (
+	"context"
+	"github.com/shamank/warehouse-service/internal/domain/models"
+	"github.com/shamank/warehouse-service/internal/domain/schemas"
+	"github.com/shamank/warehouse-service/internal/service/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"log/slog"
+	"testing"
+)
+
+// gift - набор из одного мыла и двух кремов
+var giftKit = models.Kit{
+	ProductArticle: "gift",
+	ProductName:    "gift set",
+	Components: []models.KitComponent{
+		{ProductArticle: "soap", Quantity: 1},
+		{ProductArticle: "cream", Quantity: 2},
+	},
+}
+
+func TestService_SaveKit(t *testing.T) {
+	definition := schemas.KitDefinition{Components: []schemas.KitComponent{
+		{Article: "soap", Quantity: 1},
+		{Article: "cream", Quantity: 2},
+	}}
+
+	t.Run("save", func(t *testing.T) {
+		repo := mocks.NewRepository(t)
+		repo.On("GetProductsByArticles", []string{"gift", "soap", "cream"}).
+			Return([]models.Product{{Code: "gift"}, {Code: "soap"}, {Code: "cream"}}, nil)
+		repo.On("GetKits", []string(nil)).Return([]models.Kit{}, nil)
+		repo.On("GetProductsQuantity", "gift").Return([]models.WarehouseProduct{}, nil)
+		repo.On("SaveKit", models.Kit{ProductArticle: "gift", Components: giftKit.Components}).Return(nil)
+		repo.On("GetKits", []string{"gift"}).Return([]models.Kit{giftKit}, nil)
+		repo.On("GetProductsStock", []string{"soap", "cream"}, []string(nil)).Return([]models.ProductStock{
+			{ProductArticle: "cream", WarehouseUUID: "w1", WarehouseAvailability: true, Quantity: 5},
+			{ProductArticle: "soap", WarehouseUUID: "w1", WarehouseAvailability: true, Quantity: 4},
+		}, nil)
+
+		svc := NewService(repo, slog.Default())
+
+		kit, err := svc.SaveKit(context.Background(), "gift", definition)
+		require.NoError(t, err)
+		assert.Equal(t, schemas.Kit{
+			Article:    "gift",
+			Name:       "gift set",
+			Components: definition.Components,
+			Available:  2,
+			Warehouses: []schemas.WarehouseStock{{WarehouseUUID: "w1", IsAvailable: true, Available: 2}},
+		}, kit)
+	})
+
+	t.Run("invalid definitions", func(t *testing.T) {
+		svc := NewService(mocks.NewRepository(t), slog.Default())
+
+		for _, components := range [][]schemas.KitComponent{
+			{{Article: "gift", Quantity: 1}},
+			{{Article: "soap", Quantity: 1}, {Article: "soap", Quantity: 2}},
+		} {
+			_, err := svc.SaveKit(context.Background(), "gift", schemas.KitDefinition{Components: components})
+			assert.ErrorIs(t, err, models.ErrInvalidKit, "%+v", components)
+		}
+	})
+
+	t.Run("nested kit", func(t *testing.T) {
+		repo := mocks.NewRepository(t)
+		repo.On("GetProductsByArticles", []string{"box", "gift"}).Return([]models.Product{{Code: "box"}, {Code: "gift"}}, nil)
+		repo.On("GetKits", []string(nil)).Return([]models.Kit{giftKit}, nil)
+
+		svc := NewService(repo, slog.Default())
+
+		_, err := svc.SaveKit(context.Background(), "box", schemas.KitDefinition{Components: []schemas.KitComponent{{Article: "gift", Quantity: 1}}})
+		assert.ErrorIs(t, err, models.ErrInvalidKit)
+	})
+
+	t.Run("kit with own stock", func(t *testing.T) {
+		repo := mocks.NewRepository(t)
+		repo.On("GetProductsByArticles", []string{"gift", "soap", "cream"}).
+			Return([]models.Product{{Code: "gift"}, {Code: "soap"}, {Code: "cream"}}, nil)
+		repo.On("GetKits", []string(nil)).Return([]models.Kit{}, nil)
+		repo.On("GetProductsQuantity", "gift").Return([]models.WarehouseProduct{{WarehouseUUID: "w1", ReservedQuantity: 1}}, nil)
+
+		svc := NewService(repo, slog.Default())
+
+		_, err := svc.SaveKit(context.Background(), "gift", definition)
+		assert.ErrorIs(t, err, models.ErrInvalidKit)
+	})
+}
+
+func TestService_ReserveProducts_Kit(t *testing.T) {
+	repo := mocks.NewRepository(t)
+	repo.On("GetKits", []string{"gift", "soap"}).Return([]models.Kit{giftKit}, nil)
+	repo.On("GetProductsQuantity", "soap").Return([]models.WarehouseProduct{{WarehouseUUID: "w1", Quantity: 5}}, nil)
+	repo.On("GetProductsQuantity", "cream").Return([]models.WarehouseProduct{{WarehouseUUID: "w1", Quantity: 1}, {WarehouseUUID: "w2", Quantity: 3}}, nil)
+	repo.On("ReserveProducts", []schemas.ProductWarehouseSplitted{
+		{ProductArticle: "soap", WarehouseData: []schemas.WarehouseCounter{{WarehouseUUID: "w1", Count: 2}}},
+		{ProductArticle: "cream", WarehouseData: []schemas.WarehouseCounter{{WarehouseUUID: "w1", Count: 1}, {WarehouseUUID: "w2", Count: 1}}},
+	}).Return(nil)
+
+	svc := NewService(repo, slog.Default())
+
+	assert.NoError(t, svc.ReserveProducts(context.Background(), []string{"gift", "soap"}))
+}
+
+func TestService_ReserveItems_Kit(t *testing.T) {
+	t.Run("expanded", func(t *testing.T) {
+		repo := mocks.NewRepository(t)
+		repo.On("GetProductsByArticles", []string{"gift"}).Return([]models.Product{{Code: "gift"}}, nil)
+		repo.On("GetKits", []string{"gift"}).Return([]models.Kit{giftKit}, nil)
+		repo.On("GetProductsQuantity", "soap").Return([]models.WarehouseProduct{{WarehouseUUID: "w1", Quantity: 5}}, nil)
+		repo.On("GetProductsQuantity", "cream").Return([]models.WarehouseProduct{{WarehouseUUID: "w1", Quantity: 6}}, nil)
+		repo.On("ReserveProducts", []schemas.ProductWarehouseSplitted{
+			{ProductArticle: "soap", WarehouseData: []schemas.WarehouseCounter{{WarehouseUUID: "w1", Count: 3}}},
+			{ProductArticle: "cream", WarehouseData: []schemas.WarehouseCounter{{WarehouseUUID: "w1", Count: 6}}},
+		}).Return(nil)
+
+		svc := NewService(repo, slog.Default())
+
+		assert.NoError(t, svc.ReserveItems(context.Background(), []schemas.ReserveItem{{Article: "gift", Quantity: 3}}))
+	})
+
+	t.Run("component with other warehouse constraints", func(t *testing.T) {
+		repo := mocks.NewRepository(t)
+		repo.On("GetProductsByArticles", []string{"gift", "soap"}).Return([]models.Product{{Code: "gift"}, {Code: "soap"}}, nil)
+		repo.On("GetKits", []string{"gift", "soap"}).Return([]models.Kit{giftKit}, nil)
+
+		svc := NewService(repo, slog.Default())
+
+		err := svc.ReserveItems(context.Background(), []schemas.ReserveItem{
+			{Article: "gift", Quantity: 1},
+			{Article: "soap", Quantity: 1, WarehouseUUID: "d10c8d17-6d15-445e-b643-6affa59aa26c"},
+		})
+		assert.ErrorIs(t, err, models.ErrDuplicateArticle)
+	})
+}
+
+func TestService_GetRemainingProductsBatch_Kit(t *testing.T) {
+	repo := mocks.NewRepository(t)
+	repo.On("GetKits", []string{"gift"}).Return([]models.Kit{giftKit}, nil)
+	// остатки компонентов читаются одним запросом с остатками набора, но в отчет не попадают
+	repo.On("GetProductsStock", []string{"gift", "soap", "cream"}, []string(nil)).Return([]models.ProductStock{
+		{ProductArticle: "cream", WarehouseUUID: "w1", WarehouseAvailability: true, Quantity: 3},
+		{ProductArticle: "cream", WarehouseUUID: "w2", WarehouseAvailability: true, Quantity: 12, ExpiredQuantity: 2},
+		{ProductArticle: "cream", WarehouseUUID: "w3", WarehouseAvailability: false, Quantity: 50},
+		{ProductArticle: "soap", WarehouseUUID: "w1", WarehouseAvailability: true, Quantity: 5},
+		{ProductArticle: "soap", WarehouseUUID: "w2", WarehouseAvailability: true, Quantity: 1},
+		{ProductArticle: "soap", WarehouseUUID: "w3", WarehouseAvailability: false, Quantity: 50},
+		{ProductArticle: "soap", WarehouseUUID: "w4", WarehouseAvailability: true, Quantity: 7},
+	}, nil)
+
+	svc := NewService(repo, slog.Default())
+
+	result, err := svc.GetRemainingProductsBatch(context.Background(), schemas.ProductsStockQuery{Articles: []string{"gift"}})
+	require.NoError(t, err)
+	// компоненты одного набора могут быть на разных складах: мыла 13, крема 13 на доступных складах
+	assert.Equal(t, []schemas.ProductStock{{
+		Name:      "gift set",
+		Code:      "gift",
+		Kit:       true,
+		Available: 6,
+		Warehouses: []schemas.WarehouseStock{
+			{WarehouseUUID: "w1", IsAvailable: true, Available: 1},
+			{WarehouseUUID: "w2", IsAvailable: true, Available: 1},
+			{WarehouseUUID: "w3", IsAvailable: false, Available: 25},
+		},
+	}}, result)
+}
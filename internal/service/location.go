@@ -0,0 +1,186 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/shamank/warehouse-service/internal/domain/models"
+	"github.com/shamank/warehouse-service/internal/domain/schemas"
+	"slices"
+)
+
+// CreateLocation создает место хранения на складе. Зона создается без родителя, остальные уровни -
+// внутри места предыдущего уровня: проход в зоне, стеллаж в проходе, ячейка на стеллаже
+func (s *Service) CreateLocation(ctx context.Context, warehouseUUID string, request schemas.NewLocation) (schemas.Location, error) {
+	if err := checkWarehouseAccess(ctx, warehouseUUID); err != nil {
+		return schemas.Location{}, err
+	}
+
+	if _, err := s.repo.GetWarehouse(warehouseUUID); err != nil {
+		return schemas.Location{}, err
+	}
+
+	level := slices.Index(models.LocationKinds, request.Kind)
+	if level < 0 {
+		return schemas.Location{}, fmt.Errorf("%w: unknown kind %q", models.ErrInvalidLocation, request.Kind)
+	}
+
+	location := models.Location{
+		UUID:          uuid.NewString(),
+		WarehouseUUID: warehouseUUID,
+		ParentUUID:    request.ParentID,
+		Kind:          request.Kind,
+		Code:          request.Code,
+		Path:          request.Code,
+	}
+
+	if request.ParentID == "" {
+		if level != 0 {
+			return schemas.Location{}, fmt.Errorf("%w: %s must be inside a %s", models.ErrInvalidLocation, request.Kind, models.LocationKinds[level-1])
+		}
+	} else {
+		parent, err := s.repo.GetLocation(warehouseUUID, request.ParentID)
+		if err != nil {
+			return schemas.Location{}, err
+		}
+		if level == 0 || parent.Kind != models.LocationKinds[level-1] {
+			return schemas.Location{}, fmt.Errorf("%w: %s cannot be inside a %s", models.ErrInvalidLocation, request.Kind, parent.Kind)
+		}
+		location.Path = parent.Path + "/" + request.Code
+	}
+
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	if err := s.repo.CreateLocation(location); err != nil {
+		return schemas.Location{}, err
+	}
+
+	return locationSchema(location), nil
+}
+
+// GetLocations возвращает места хранения склада в порядке обхода
+func (s *Service) GetLocations(ctx context.Context, warehouseUUID string) ([]schemas.Location, error) {
+	if err := checkWarehouseAccess(ctx, warehouseUUID); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.repo.GetWarehouse(warehouseUUID); err != nil {
+		return nil, err
+	}
+
+	locations, err := s.repo.GetLocations(warehouseUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]schemas.Location, len(locations))
+	for i, location := range locations {
+		result[i] = locationSchema(location)
+	}
+
+	return result, nil
+}
+
+// MoveStock перемещает свободный товар между ячейками склада. Все перемещения выполняются вместе или не выполняются
+func (s *Service) MoveStock(ctx context.Context, warehouseUUID string, moves []schemas.StockMove) error {
+	if err := checkWarehouseAccess(ctx, warehouseUUID); err != nil {
+		return err
+	}
+
+	if _, err := s.repo.GetWarehouse(warehouseUUID); err != nil {
+		return err
+	}
+
+	articles := make([]string, 0, len(moves))
+	stockMoves := make([]models.StockMove, 0, len(moves))
+	for _, move := range moves {
+		if move.Quantity <= 0 {
+			return fmt.Errorf("%w: %s", models.ErrInvalidQuantity, move.Article)
+		}
+		if move.From == move.To {
+			return fmt.Errorf("%w: %s is moved to the same place", models.ErrInvalidLocation, move.Article)
+		}
+		articles = append(articles, move.Article)
+		stockMoves = append(stockMoves, models.StockMove{
+			ProductArticle: move.Article,
+			Quantity:       move.Quantity,
+			FromPath:       move.From,
+			ToPath:         move.To,
+		})
+	}
+
+	if err := s.checkArticles(articles); err != nil {
+		return err
+	}
+
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	return s.repo.MoveStock(warehouseUUID, stockMoves)
+}
+
+// GetReservationPickList возвращает лист отбора по тому, что еще осталось в резерве: строки по складам в порядке обхода ячеек,
+// товар вне ячеек - в конце склада
+func (s *Service) GetReservationPickList(ctx context.Context, reservationUUID string) (schemas.ReservationPickList, error) {
+	reservation, err := s.repo.GetReservation(reservationUUID)
+	if err != nil {
+		return schemas.ReservationPickList{}, err
+	}
+
+	if err := checkReservationAccess(ctx, reservation); err != nil {
+		return schemas.ReservationPickList{}, err
+	}
+
+	pickLines := reservationPickLines(reservation)
+	sortPickLines(pickLines)
+
+	lines := make([]schemas.PickLine, len(pickLines))
+	for i, line := range pickLines {
+		lines[i] = schemas.PickLine{
+			WarehouseUUID: line.WarehouseUUID,
+			Location:      line.LocationPath,
+			Article:       line.ProductArticle,
+			Quantity:      line.Quantity,
+		}
+	}
+
+	return schemas.ReservationPickList{ReservationID: reservation.UUID, Lines: lines}, nil
+}
+
+// productBins раскладывает остатки ячеек по товарам
+func productBins(stocks []models.BinStock) map[string][]schemas.ProductBin {
+	bins := make(map[string][]schemas.ProductBin)
+	for _, stock := range stocks {
+		bins[stock.ProductArticle] = append(bins[stock.ProductArticle], schemas.ProductBin{
+			Location: stock.Path,
+			Quantity: stock.Quantity,
+			Reserved: stock.ReservedQuantity,
+		})
+	}
+
+	return bins
+}
+
+func reservationBins(bins []models.BinQuantity) []schemas.BinQuantity {
+	if len(bins) == 0 {
+		return nil
+	}
+
+	result := make([]schemas.BinQuantity, len(bins))
+	for i, bin := range bins {
+		result[i] = schemas.BinQuantity{Location: bin.Path, Quantity: bin.Quantity}
+	}
+
+	return result
+}
+
+func locationSchema(location models.Location) schemas.Location {
+	return schemas.Location{
+		ID:       location.UUID,
+		ParentID: location.ParentUUID,
+		Kind:     location.Kind,
+		Code:     location.Code,
+		Path:     location.Path,
+	}
+}
@@ -0,0 +1,131 @@
+package service
+
+import (
+	"context"
+	"github.com/shamank/warehouse-service/internal/domain/models"
+	"github.com/shamank/warehouse-service/internal/domain/schemas"
+	"github.com/shamank/warehouse-service/internal/service/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"log/slog"
+	"testing"
+)
+
+func TestService_CreateLocation(t *testing.T) {
+	const (
+		warehouse = "d10c8d17-6d15-445e-b643-6affa59aa26c"
+		shelf     = "6a2f4c81-3b5d-4e97-a0c6-8d1e2f3b4c5a"
+	)
+
+	newRepo := func(t *testing.T) *mocks.Repository {
+		repo := mocks.NewRepository(t)
+		repo.On("GetWarehouse", warehouse).Return(models.Warehouse{UUID: warehouse, Name: "warehouse", Availability: true}, nil)
+		return repo
+	}
+
+	t.Run("bin on shelf", func(t *testing.T) {
+		repo := newRepo(t)
+		repo.On("GetLocation", warehouse, shelf).Return(models.Location{
+			UUID: shelf, WarehouseUUID: warehouse, Kind: models.LocationShelf, Code: "3", Path: "A/12/3",
+		}, nil)
+		repo.On("CreateLocation", mock.MatchedBy(func(location models.Location) bool {
+			return location.UUID != "" && location.WarehouseUUID == warehouse && location.ParentUUID == shelf &&
+				location.Kind == models.LocationBin && location.Path == "A/12/3/07"
+		})).Return(nil)
+
+		svc := NewService(repo, slog.Default())
+
+		location, err := svc.CreateLocation(context.Background(), warehouse, schemas.NewLocation{
+			Kind: models.LocationBin, Code: "07", ParentID: shelf,
+		})
+		require.NoError(t, err)
+		assert.NotEmpty(t, location.ID)
+		assert.Equal(t, schemas.Location{ID: location.ID, ParentID: shelf, Kind: models.LocationBin, Code: "07", Path: "A/12/3/07"}, location)
+	})
+
+	t.Run("zone without parent", func(t *testing.T) {
+		repo := newRepo(t)
+		repo.On("CreateLocation", mock.MatchedBy(func(location models.Location) bool {
+			return location.ParentUUID == "" && location.Path == "A"
+		})).Return(nil)
+
+		svc := NewService(repo, slog.Default())
+
+		_, err := svc.CreateLocation(context.Background(), warehouse, schemas.NewLocation{Kind: models.LocationZone, Code: "A"})
+		require.NoError(t, err)
+	})
+
+	t.Run("wrong nesting", func(t *testing.T) {
+		repo := newRepo(t)
+		repo.On("GetLocation", warehouse, shelf).Return(models.Location{
+			UUID: shelf, WarehouseUUID: warehouse, Kind: models.LocationShelf, Code: "3", Path: "A/12/3",
+		}, nil)
+
+		svc := NewService(repo, slog.Default())
+
+		_, err := svc.CreateLocation(context.Background(), warehouse, schemas.NewLocation{Kind: models.LocationBin, Code: "07"})
+		assert.ErrorIs(t, err, models.ErrInvalidLocation)
+		_, err = svc.CreateLocation(context.Background(), warehouse, schemas.NewLocation{Kind: models.LocationAisle, Code: "1", ParentID: shelf})
+		assert.ErrorIs(t, err, models.ErrInvalidLocation)
+		_, err = svc.CreateLocation(context.Background(), warehouse, schemas.NewLocation{Kind: models.LocationZone, Code: "B", ParentID: shelf})
+		assert.ErrorIs(t, err, models.ErrInvalidLocation)
+	})
+}
+
+func TestService_MoveStock(t *testing.T) {
+	const warehouse = "d10c8d17-6d15-445e-b643-6affa59aa26c"
+
+	repo := mocks.NewRepository(t)
+	repo.On("GetWarehouse", warehouse).Return(models.Warehouse{UUID: warehouse, Name: "warehouse", Availability: true}, nil)
+	repo.On("GetProductsByArticles", []string{"soap"}).Return([]models.Product{{Code: "soap"}}, nil)
+	repo.On("MoveStock", warehouse, []models.StockMove{{ProductArticle: "soap", Quantity: 2, ToPath: "A/1/1/01"}}).Return(nil)
+
+	svc := NewService(repo, slog.Default())
+
+	err := svc.MoveStock(context.Background(), warehouse, []schemas.StockMove{{Article: "soap", Quantity: 2, To: "A/1/1/01"}})
+	require.NoError(t, err)
+
+	err = svc.MoveStock(context.Background(), warehouse, []schemas.StockMove{{Article: "soap", Quantity: 2, From: "A/1/1/01", To: "A/1/1/01"}})
+	assert.ErrorIs(t, err, models.ErrInvalidLocation)
+}
+
+func TestService_GetReservationPickList(t *testing.T) {
+	const (
+		warehouse1  = "d10c8d17-6d15-445e-b643-6affa59aa26c"
+		warehouse2  = "a00518e4-be6e-4eb7-9f95-bb52cc8b8548"
+		reservation = "3f0c4a52-8b3e-4a55-9d7c-5a1f2b6e9c10"
+	)
+
+	repo := mocks.NewRepository(t)
+	repo.On("GetReservation", reservation).Return(models.Reservation{
+		UUID:   reservation,
+		Status: models.ReservationActive,
+		Items: []models.ReservationItem{
+			{ProductArticle: "soap", WarehouseUUID: warehouse2, Quantity: 4, ReleasedQuantity: 1, Bins: []models.BinQuantity{
+				{Path: "B/1/1/01", Quantity: 2},
+			}},
+			{ProductArticle: "cream", WarehouseUUID: warehouse1, Quantity: 3},
+			{ProductArticle: "brush", WarehouseUUID: warehouse1, Quantity: 5, Bins: []models.BinQuantity{
+				{Path: "A/1/1/01", Quantity: 1},
+				{Path: "A/2/1/01", Quantity: 4},
+			}},
+			{ProductArticle: "soap", WarehouseUUID: warehouse1, Quantity: 2, ReleasedQuantity: 2},
+		},
+	}, nil)
+
+	svc := NewService(repo, slog.Default())
+
+	pickList, err := svc.GetReservationPickList(context.Background(), reservation)
+	require.NoError(t, err)
+	assert.Equal(t, schemas.ReservationPickList{
+		ReservationID: reservation,
+		Lines: []schemas.PickLine{
+			{WarehouseUUID: warehouse2, Location: "B/1/1/01", Article: "soap", Quantity: 2},
+			{WarehouseUUID: warehouse2, Article: "soap", Quantity: 1},
+			{WarehouseUUID: warehouse1, Location: "A/1/1/01", Article: "brush", Quantity: 1},
+			{WarehouseUUID: warehouse1, Location: "A/2/1/01", Article: "brush", Quantity: 4},
+			{WarehouseUUID: warehouse1, Article: "cream", Quantity: 3},
+		},
+	}, pickList)
+}
@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/shamank/warehouse-service/internal/domain/models"
+	"github.com/shamank/warehouse-service/internal/domain/schemas"
+	"time"
+)
+
+// ReceiveProducts принимает товары на склад, товары с партией - в указанную партию.
+// Партия создается при первом поступлении, для существующей даты должны совпадать, иначе models.ErrLotMismatch.
+// Штучный товар принимается только с серийными номерами, по одному на единицу
+func (s *Service) ReceiveProducts(ctx context.Context, warehouseUUID string, items []schemas.ReceiptItem) error {
+	if err := checkWarehouseAccess(ctx, warehouseUUID); err != nil {
+		return err
+	}
+
+	if _, err := s.repo.GetWarehouse(warehouseUUID); err != nil {
+		return err
+	}
+
+	articles := make([]string, 0, len(items))
+	receipt := make([]models.ReceiptItem, 0, len(items))
+	for _, item := range items {
+		if item.Quantity <= 0 {
+			return fmt.Errorf("%w: %s", models.ErrInvalidQuantity, item.Article)
+		}
+		articles = append(articles, item.Article)
+
+		receiptItem := models.ReceiptItem{
+			ProductArticle: item.Article,
+			Quantity:       item.Quantity,
+		}
+		if item.Lot != nil {
+			lot := models.Lot{
+				Number:         item.Lot.Number,
+				ManufacturedAt: item.Lot.ManufacturedAt.TimePtr(),
+				ExpiresAt:      item.Lot.ExpiresAt.TimePtr(),
+			}
+			if lot.ManufacturedAt != nil && lot.ExpiresAt != nil && lot.ExpiresAt.Before(*lot.ManufacturedAt) {
+				return fmt.Errorf("%w: %s %s", models.ErrInvalidLotDates, item.Article, lot.Number)
+			}
+			receiptItem.Lot = &lot
+		}
+		if len(item.Serials) > 0 {
+			receiptItem.Serials = item.Serials
+		}
+		receiptItem.BinPath = item.Bin
+		receipt = append(receipt, receiptItem)
+	}
+
+	products, err := s.productsByArticle(articles)
+	if err != nil {
+		return err
+	}
+	if err := checkReceiptSerials(receipt, products); err != nil {
+		return err
+	}
+
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	return s.repo.ReceiveProducts(warehouseUUID, receipt)
+}
+
+// productLots раскладывает остатки партий по товарам
+func productLots(stocks []models.LotStock, today time.Time) map[string][]schemas.ProductLot {
+	lots := make(map[string][]schemas.ProductLot)
+	for _, stock := range stocks {
+		lots[stock.ProductArticle] = append(lots[stock.ProductArticle], schemas.ProductLot{
+			Lot: schemas.Lot{
+				Number:         stock.Lot.Number,
+				ManufacturedAt: schemas.NewDate(stock.Lot.ManufacturedAt),
+				ExpiresAt:      schemas.NewDate(stock.Lot.ExpiresAt),
+			},
+			Quantity: stock.Quantity,
+			Reserved: stock.ReservedQuantity,
+			Expired:  stock.Lot.Expired(today),
+		})
+	}
+
+	return lots
+}
@@ -0,0 +1,193 @@
+package service
+
+import (
+	"context"
+	"github.com/shamank/warehouse-service/internal/auth"
+	"github.com/shamank/warehouse-service/internal/domain/models"
+	"github.com/shamank/warehouse-service/internal/domain/schemas"
+	"github.com/shamank/warehouse-service/internal/service/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestService_ReceiveProducts(t *testing.T) {
+	const warehouse = "d10c8d17-6d15-445e-b643-6affa59aa26c"
+
+	manufacturedAt := time.Date(2026, 1, 10, 0, 0, 0, 0, time.UTC)
+	expiresAt := time.Date(2027, 1, 10, 0, 0, 0, 0, time.UTC)
+
+	t.Run("lot and untracked products", func(t *testing.T) {
+		repo := mocks.NewRepository(t)
+		repo.On("GetWarehouse", warehouse).Return(models.Warehouse{UUID: warehouse}, nil)
+		repo.On("GetProductsByArticles", []string{"cream", "soap"}).Return([]models.Product{{Code: "cream"}, {Code: "soap"}}, nil)
+		repo.On("ReceiveProducts", warehouse, []models.ReceiptItem{
+			{ProductArticle: "cream", Quantity: 10, Lot: &models.Lot{Number: "L-1", ManufacturedAt: &manufacturedAt, ExpiresAt: &expiresAt}},
+			{ProductArticle: "soap", Quantity: 5},
+		}).Return(nil)
+
+		svc := NewService(repo, slog.Default())
+
+		err := svc.ReceiveProducts(context.Background(), warehouse, []schemas.ReceiptItem{
+			{Article: "cream", Quantity: 10, Lot: &schemas.Lot{
+				Number:         "L-1",
+				ManufacturedAt: &schemas.Date{Time: manufacturedAt},
+				ExpiresAt:      &schemas.Date{Time: expiresAt},
+			}},
+			{Article: "soap", Quantity: 5},
+		})
+		require.NoError(t, err)
+	})
+
+	t.Run("lot expires before manufacture", func(t *testing.T) {
+		repo := mocks.NewRepository(t)
+		repo.On("GetWarehouse", warehouse).Return(models.Warehouse{UUID: warehouse}, nil)
+
+		svc := NewService(repo, slog.Default())
+
+		err := svc.ReceiveProducts(context.Background(), warehouse, []schemas.ReceiptItem{
+			{Article: "cream", Quantity: 10, Lot: &schemas.Lot{
+				Number:         "L-1",
+				ManufacturedAt: &schemas.Date{Time: expiresAt},
+				ExpiresAt:      &schemas.Date{Time: manufacturedAt},
+			}},
+		})
+		assert.ErrorIs(t, err, models.ErrInvalidLotDates)
+	})
+
+	t.Run("serialized product", func(t *testing.T) {
+		repo := mocks.NewRepository(t)
+		repo.On("GetWarehouse", warehouse).Return(models.Warehouse{UUID: warehouse}, nil)
+		repo.On("GetProductsByArticles", []string{"phone"}).Return([]models.Product{{Code: "phone", Serialized: true}}, nil)
+		repo.On("ReceiveProducts", warehouse, []models.ReceiptItem{
+			{ProductArticle: "phone", Quantity: 2, Serials: []string{"IMEI-1", "IMEI-2"}},
+		}).Return(nil)
+
+		svc := NewService(repo, slog.Default())
+
+		err := svc.ReceiveProducts(context.Background(), warehouse, []schemas.ReceiptItem{
+			{Article: "phone", Quantity: 2, Serials: []string{"IMEI-1", "IMEI-2"}},
+		})
+		require.NoError(t, err)
+	})
+
+	for _, testCase := range []struct {
+		name    string
+		items   []schemas.ReceiptItem
+		product models.Product
+	}{
+		{
+			name:    "serials missing",
+			items:   []schemas.ReceiptItem{{Article: "phone", Quantity: 2, Serials: []string{"IMEI-1"}}},
+			product: models.Product{Code: "phone", Serialized: true},
+		},
+		{
+			name: "serial repeated",
+			items: []schemas.ReceiptItem{
+				{Article: "phone", Quantity: 1, Serials: []string{"IMEI-1"}},
+				{Article: "phone", Quantity: 1, Serials: []string{"IMEI-1"}},
+			},
+			product: models.Product{Code: "phone", Serialized: true},
+		},
+		{
+			name:    "serials for ordinary product",
+			items:   []schemas.ReceiptItem{{Article: "phone", Quantity: 1, Serials: []string{"IMEI-1"}}},
+			product: models.Product{Code: "phone"},
+		},
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			articles := make([]string, len(testCase.items))
+			for i, item := range testCase.items {
+				articles[i] = item.Article
+			}
+
+			repo := mocks.NewRepository(t)
+			repo.On("GetWarehouse", warehouse).Return(models.Warehouse{UUID: warehouse}, nil)
+			repo.On("GetProductsByArticles", articles).Return([]models.Product{testCase.product}, nil)
+
+			svc := NewService(repo, slog.Default())
+
+			err := svc.ReceiveProducts(context.Background(), warehouse, testCase.items)
+			assert.ErrorIs(t, err, models.ErrInvalidSerials)
+		})
+	}
+
+	t.Run("unknown product", func(t *testing.T) {
+		repo := mocks.NewRepository(t)
+		repo.On("GetWarehouse", warehouse).Return(models.Warehouse{UUID: warehouse}, nil)
+		repo.On("GetProductsByArticles", []string{"cream"}).Return([]models.Product{}, nil)
+
+		svc := NewService(repo, slog.Default())
+
+		err := svc.ReceiveProducts(context.Background(), warehouse, []schemas.ReceiptItem{{Article: "cream", Quantity: 1}})
+		assert.ErrorIs(t, err, models.ErrProductNotFound)
+	})
+
+	t.Run("unknown warehouse", func(t *testing.T) {
+		repo := mocks.NewRepository(t)
+		repo.On("GetWarehouse", warehouse).Return(models.Warehouse{}, models.ErrWarehouseNotFound)
+
+		svc := NewService(repo, slog.Default())
+
+		err := svc.ReceiveProducts(context.Background(), warehouse, []schemas.ReceiptItem{{Article: "cream", Quantity: 1}})
+		assert.ErrorIs(t, err, models.ErrWarehouseNotFound)
+	})
+
+	t.Run("forbidden warehouse", func(t *testing.T) {
+		ctx := auth.WithPrincipal(context.Background(), auth.Principal{
+			Subject:    "billing",
+			Scopes:     []string{auth.ScopeAdmin},
+			Warehouses: []string{"a00518e4-be6e-4eb7-9f95-bb52cc8b8548"},
+		})
+
+		svc := NewService(mocks.NewRepository(t), slog.Default())
+
+		err := svc.ReceiveProducts(ctx, warehouse, []schemas.ReceiptItem{{Article: "cream", Quantity: 1}})
+		assert.ErrorIs(t, err, models.ErrWarehouseAccessDenied)
+	})
+}
+
+func TestService_GetRemainingProducts_ByLot(t *testing.T) {
+	const warehouse = "d10c8d17-6d15-445e-b643-6affa59aa26c"
+
+	expired := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	fresh := time.Date(2999, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	repo := mocks.NewRepository(t)
+	repo.On("GetWarehouse", warehouse).Return(models.Warehouse{UUID: warehouse, Name: "warehouse", Availability: true}, nil)
+	repo.On("GetRemainingProductsByWarehouse", warehouse, schemas.RemainingProductsFilter{
+		Sort:  schemas.SortByArticle,
+		ByLot: true,
+	}).Return([]models.Product{
+		{Name: "cream", Code: "cream", Quantity: 10, ReservedQuantity: 2, ExpiredQuantity: 3},
+		{Name: "soap", Code: "soap", Quantity: 5},
+	}, 2, nil)
+	repo.On("GetLotsStock", warehouse, []string{"cream", "soap"}).Return([]models.LotStock{
+		{ProductArticle: "cream", Lot: models.Lot{Number: "old", ExpiresAt: &expired}, Quantity: 3},
+		{ProductArticle: "cream", Lot: models.Lot{Number: "new", ExpiresAt: &fresh}, Quantity: 4, ReservedQuantity: 2},
+	}, nil)
+	repo.On("GetBucketsStock", []string{"cream", "soap"}, []string{warehouse}).Return([]models.BucketStock{}, nil)
+
+	svc := NewService(repo, slog.Default())
+
+	result, err := svc.GetRemainingProducts(context.Background(), warehouse, schemas.RemainingProductsFilter{ByLot: true})
+	require.NoError(t, err)
+	assert.Equal(t, []schemas.Product{
+		{
+			Name:      "cream",
+			Code:      "cream",
+			Quantity:  10,
+			Available: 7,
+			Reserved:  2,
+			OnHand:    12,
+			Expired:   3,
+			Lots: []schemas.ProductLot{
+				{Lot: schemas.Lot{Number: "old", ExpiresAt: &schemas.Date{Time: expired}}, Quantity: 3, Expired: true},
+				{Lot: schemas.Lot{Number: "new", ExpiresAt: &schemas.Date{Time: fresh}}, Quantity: 4, Reserved: 2},
+			},
+		},
+		{Name: "soap", Code: "soap", Quantity: 5, Available: 5, OnHand: 5},
+	}, result.Items)
+}
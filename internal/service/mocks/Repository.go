@@ -6,6 +6,8 @@ import (
 	models "github.com/shamank/warehouse-service/internal/domain/models"
 	schemas "github.com/shamank/warehouse-service/internal/domain/schemas"
 	mock "github.com/stretchr/testify/mock"
+
+	time "time"
 )
 
 // Repository is an autogenerated mock type for the Repository type
@@ -13,6 +15,480 @@ type Repository struct {
 	mock.Mock
 }
 
+// CloseStocktake provides a mock function with given fields: stocktake, adjustments
+func (_m *Repository) CloseStocktake(stocktake models.Stocktake, adjustments []models.Adjustment) error {
+	ret := _m.Called(stocktake, adjustments)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CloseStocktake")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(models.Stocktake, []models.Adjustment) error); ok {
+		r0 = rf(stocktake, adjustments)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ConfirmPickLine provides a mock function with given fields: pickListUUID, lineNo, pickedQuantity, reallocation
+func (_m *Repository) ConfirmPickLine(pickListUUID string, lineNo int, pickedQuantity int, reallocation []schemas.WarehouseCounter) error {
+	ret := _m.Called(pickListUUID, lineNo, pickedQuantity, reallocation)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ConfirmPickLine")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, int, int, []schemas.WarehouseCounter) error); ok {
+		r0 = rf(pickListUUID, lineNo, pickedQuantity, reallocation)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CountStocktake provides a mock function with given fields: stocktakeUUID, counts, countedAt
+func (_m *Repository) CountStocktake(stocktakeUUID string, counts []models.StocktakeCount, countedAt time.Time) error {
+	ret := _m.Called(stocktakeUUID, counts, countedAt)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CountStocktake")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, []models.StocktakeCount, time.Time) error); ok {
+		r0 = rf(stocktakeUUID, counts, countedAt)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CreateAdjustment provides a mock function with given fields: adjustment
+func (_m *Repository) CreateAdjustment(adjustment models.Adjustment) error {
+	ret := _m.Called(adjustment)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateAdjustment")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(models.Adjustment) error); ok {
+		r0 = rf(adjustment)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CreateLocation provides a mock function with given fields: location
+func (_m *Repository) CreateLocation(location models.Location) error {
+	ret := _m.Called(location)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateLocation")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(models.Location) error); ok {
+		r0 = rf(location)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CreatePickList provides a mock function with given fields: pickList
+func (_m *Repository) CreatePickList(pickList models.PickList) error {
+	ret := _m.Called(pickList)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreatePickList")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(models.PickList) error); ok {
+		r0 = rf(pickList)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CreateReservation provides a mock function with given fields: reservationUUID, products
+func (_m *Repository) CreateReservation(reservationUUID string, products []schemas.ProductWarehouseSplitted) error {
+	ret := _m.Called(reservationUUID, products)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateReservation")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, []schemas.ProductWarehouseSplitted) error); ok {
+		r0 = rf(reservationUUID, products)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CreateReturn provides a mock function with given fields: ret
+func (_m *Repository) CreateReturn(ret models.Return) error {
+	ret_1 := _m.Called(ret)
+
+	if len(ret_1) == 0 {
+		panic("no return value specified for CreateReturn")
+	}
+
+	var r0 error
+	if rf, ok := ret_1.Get(0).(func(models.Return) error); ok {
+		r0 = rf(ret)
+	} else {
+		r0 = ret_1.Error(0)
+	}
+
+	return r0
+}
+
+// CreateStocktake provides a mock function with given fields: stocktake
+func (_m *Repository) CreateStocktake(stocktake models.Stocktake) error {
+	ret := _m.Called(stocktake)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateStocktake")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(models.Stocktake) error); ok {
+		r0 = rf(stocktake)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteKit provides a mock function with given fields: article
+func (_m *Repository) DeleteKit(article string) error {
+	ret := _m.Called(article)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteKit")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string) error); ok {
+		r0 = rf(article)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetAdjustment provides a mock function with given fields: adjustmentUUID
+func (_m *Repository) GetAdjustment(adjustmentUUID string) (models.Adjustment, error) {
+	ret := _m.Called(adjustmentUUID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetAdjustment")
+	}
+
+	var r0 models.Adjustment
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (models.Adjustment, error)); ok {
+		return rf(adjustmentUUID)
+	}
+	if rf, ok := ret.Get(0).(func(string) models.Adjustment); ok {
+		r0 = rf(adjustmentUUID)
+	} else {
+		r0 = ret.Get(0).(models.Adjustment)
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(adjustmentUUID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetAdjustments provides a mock function with given fields: warehouseUUID, status
+func (_m *Repository) GetAdjustments(warehouseUUID string, status string) ([]models.Adjustment, error) {
+	ret := _m.Called(warehouseUUID, status)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetAdjustments")
+	}
+
+	var r0 []models.Adjustment
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, string) ([]models.Adjustment, error)); ok {
+		return rf(warehouseUUID, status)
+	}
+	if rf, ok := ret.Get(0).(func(string, string) []models.Adjustment); ok {
+		r0 = rf(warehouseUUID, status)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.Adjustment)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string, string) error); ok {
+		r1 = rf(warehouseUUID, status)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetBinsStock provides a mock function with given fields: warehouseUUID, articles
+func (_m *Repository) GetBinsStock(warehouseUUID string, articles []string) ([]models.BinStock, error) {
+	ret := _m.Called(warehouseUUID, articles)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetBinsStock")
+	}
+
+	var r0 []models.BinStock
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, []string) ([]models.BinStock, error)); ok {
+		return rf(warehouseUUID, articles)
+	}
+	if rf, ok := ret.Get(0).(func(string, []string) []models.BinStock); ok {
+		r0 = rf(warehouseUUID, articles)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.BinStock)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string, []string) error); ok {
+		r1 = rf(warehouseUUID, articles)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetBucketsStock provides a mock function with given fields: articles, warehouseUUIDs
+func (_m *Repository) GetBucketsStock(articles []string, warehouseUUIDs []string) ([]models.BucketStock, error) {
+	ret := _m.Called(articles, warehouseUUIDs)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetBucketsStock")
+	}
+
+	var r0 []models.BucketStock
+	var r1 error
+	if rf, ok := ret.Get(0).(func([]string, []string) ([]models.BucketStock, error)); ok {
+		return rf(articles, warehouseUUIDs)
+	}
+	if rf, ok := ret.Get(0).(func([]string, []string) []models.BucketStock); ok {
+		r0 = rf(articles, warehouseUUIDs)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.BucketStock)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func([]string, []string) error); ok {
+		r1 = rf(articles, warehouseUUIDs)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetKits provides a mock function with given fields: articles
+func (_m *Repository) GetKits(articles []string) ([]models.Kit, error) {
+	ret := _m.Called(articles)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetKits")
+	}
+
+	var r0 []models.Kit
+	var r1 error
+	if rf, ok := ret.Get(0).(func([]string) ([]models.Kit, error)); ok {
+		return rf(articles)
+	}
+	if rf, ok := ret.Get(0).(func([]string) []models.Kit); ok {
+		r0 = rf(articles)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.Kit)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func([]string) error); ok {
+		r1 = rf(articles)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetLocation provides a mock function with given fields: warehouseUUID, locationUUID
+func (_m *Repository) GetLocation(warehouseUUID string, locationUUID string) (models.Location, error) {
+	ret := _m.Called(warehouseUUID, locationUUID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetLocation")
+	}
+
+	var r0 models.Location
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, string) (models.Location, error)); ok {
+		return rf(warehouseUUID, locationUUID)
+	}
+	if rf, ok := ret.Get(0).(func(string, string) models.Location); ok {
+		r0 = rf(warehouseUUID, locationUUID)
+	} else {
+		r0 = ret.Get(0).(models.Location)
+	}
+
+	if rf, ok := ret.Get(1).(func(string, string) error); ok {
+		r1 = rf(warehouseUUID, locationUUID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetLocations provides a mock function with given fields: warehouseUUID
+func (_m *Repository) GetLocations(warehouseUUID string) ([]models.Location, error) {
+	ret := _m.Called(warehouseUUID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetLocations")
+	}
+
+	var r0 []models.Location
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) ([]models.Location, error)); ok {
+		return rf(warehouseUUID)
+	}
+	if rf, ok := ret.Get(0).(func(string) []models.Location); ok {
+		r0 = rf(warehouseUUID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.Location)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(warehouseUUID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetLotsStock provides a mock function with given fields: warehouseUUID, articles
+func (_m *Repository) GetLotsStock(warehouseUUID string, articles []string) ([]models.LotStock, error) {
+	ret := _m.Called(warehouseUUID, articles)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetLotsStock")
+	}
+
+	var r0 []models.LotStock
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, []string) ([]models.LotStock, error)); ok {
+		return rf(warehouseUUID, articles)
+	}
+	if rf, ok := ret.Get(0).(func(string, []string) []models.LotStock); ok {
+		r0 = rf(warehouseUUID, articles)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.LotStock)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string, []string) error); ok {
+		r1 = rf(warehouseUUID, articles)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetPickList provides a mock function with given fields: pickListUUID
+func (_m *Repository) GetPickList(pickListUUID string) (models.PickList, error) {
+	ret := _m.Called(pickListUUID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetPickList")
+	}
+
+	var r0 models.PickList
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (models.PickList, error)); ok {
+		return rf(pickListUUID)
+	}
+	if rf, ok := ret.Get(0).(func(string) models.PickList); ok {
+		r0 = rf(pickListUUID)
+	} else {
+		r0 = ret.Get(0).(models.PickList)
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(pickListUUID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetProductsByArticles provides a mock function with given fields: articles
+func (_m *Repository) GetProductsByArticles(articles []string) ([]models.Product, error) {
+	ret := _m.Called(articles)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetProductsByArticles")
+	}
+
+	var r0 []models.Product
+	var r1 error
+	if rf, ok := ret.Get(0).(func([]string) ([]models.Product, error)); ok {
+		return rf(articles)
+	}
+	if rf, ok := ret.Get(0).(func([]string) []models.Product); ok {
+		r0 = rf(articles)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.Product)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func([]string) error); ok {
+		r1 = rf(articles)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetProductsQuantity provides a mock function with given fields: productArticle
 func (_m *Repository) GetProductsQuantity(productArticle string) ([]models.WarehouseProduct, error) {
 	ret := _m.Called(productArticle)
@@ -43,27 +519,264 @@ func (_m *Repository) GetProductsQuantity(productArticle string) ([]models.Wareh
 	return r0, r1
 }
 
-// GetRemainingProductsByWarehouse provides a mock function with given fields: warehouseUUID
-func (_m *Repository) GetRemainingProductsByWarehouse(warehouseUUID string) ([]models.Product, error) {
-	ret := _m.Called(warehouseUUID)
+// GetProductsStock provides a mock function with given fields: articles, warehouseUUIDs
+func (_m *Repository) GetProductsStock(articles []string, warehouseUUIDs []string) ([]models.ProductStock, error) {
+	ret := _m.Called(articles, warehouseUUIDs)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetProductsStock")
+	}
+
+	var r0 []models.ProductStock
+	var r1 error
+	if rf, ok := ret.Get(0).(func([]string, []string) ([]models.ProductStock, error)); ok {
+		return rf(articles, warehouseUUIDs)
+	}
+	if rf, ok := ret.Get(0).(func([]string, []string) []models.ProductStock); ok {
+		r0 = rf(articles, warehouseUUIDs)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.ProductStock)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func([]string, []string) error); ok {
+		r1 = rf(articles, warehouseUUIDs)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetRemainingProductsByWarehouse provides a mock function with given fields: warehouseUUID, filter
+func (_m *Repository) GetRemainingProductsByWarehouse(warehouseUUID string, filter schemas.RemainingProductsFilter) ([]models.Product, int, error) {
+	ret := _m.Called(warehouseUUID, filter)
 
 	if len(ret) == 0 {
 		panic("no return value specified for GetRemainingProductsByWarehouse")
 	}
 
 	var r0 []models.Product
-	var r1 error
-	if rf, ok := ret.Get(0).(func(string) ([]models.Product, error)); ok {
-		return rf(warehouseUUID)
+	var r1 int
+	var r2 error
+	if rf, ok := ret.Get(0).(func(string, schemas.RemainingProductsFilter) ([]models.Product, int, error)); ok {
+		return rf(warehouseUUID, filter)
 	}
-	if rf, ok := ret.Get(0).(func(string) []models.Product); ok {
-		r0 = rf(warehouseUUID)
+	if rf, ok := ret.Get(0).(func(string, schemas.RemainingProductsFilter) []models.Product); ok {
+		r0 = rf(warehouseUUID, filter)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]models.Product)
 		}
 	}
 
+	if rf, ok := ret.Get(1).(func(string, schemas.RemainingProductsFilter) int); ok {
+		r1 = rf(warehouseUUID, filter)
+	} else {
+		r1 = ret.Get(1).(int)
+	}
+
+	if rf, ok := ret.Get(2).(func(string, schemas.RemainingProductsFilter) error); ok {
+		r2 = rf(warehouseUUID, filter)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GetReservation provides a mock function with given fields: reservationUUID
+func (_m *Repository) GetReservation(reservationUUID string) (models.Reservation, error) {
+	ret := _m.Called(reservationUUID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetReservation")
+	}
+
+	var r0 models.Reservation
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (models.Reservation, error)); ok {
+		return rf(reservationUUID)
+	}
+	if rf, ok := ret.Get(0).(func(string) models.Reservation); ok {
+		r0 = rf(reservationUUID)
+	} else {
+		r0 = ret.Get(0).(models.Reservation)
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(reservationUUID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetReturn provides a mock function with given fields: returnUUID
+func (_m *Repository) GetReturn(returnUUID string) (models.Return, error) {
+	ret := _m.Called(returnUUID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetReturn")
+	}
+
+	var r0 models.Return
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (models.Return, error)); ok {
+		return rf(returnUUID)
+	}
+	if rf, ok := ret.Get(0).(func(string) models.Return); ok {
+		r0 = rf(returnUUID)
+	} else {
+		r0 = ret.Get(0).(models.Return)
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(returnUUID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetSerial provides a mock function with given fields: productArticle, serialNumber
+func (_m *Repository) GetSerial(productArticle string, serialNumber string) (models.ProductSerial, error) {
+	ret := _m.Called(productArticle, serialNumber)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetSerial")
+	}
+
+	var r0 models.ProductSerial
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, string) (models.ProductSerial, error)); ok {
+		return rf(productArticle, serialNumber)
+	}
+	if rf, ok := ret.Get(0).(func(string, string) models.ProductSerial); ok {
+		r0 = rf(productArticle, serialNumber)
+	} else {
+		r0 = ret.Get(0).(models.ProductSerial)
+	}
+
+	if rf, ok := ret.Get(1).(func(string, string) error); ok {
+		r1 = rf(productArticle, serialNumber)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetSerialHistory provides a mock function with given fields: productArticle, serialNumber
+func (_m *Repository) GetSerialHistory(productArticle string, serialNumber string) ([]models.SerialEvent, error) {
+	ret := _m.Called(productArticle, serialNumber)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetSerialHistory")
+	}
+
+	var r0 []models.SerialEvent
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, string) ([]models.SerialEvent, error)); ok {
+		return rf(productArticle, serialNumber)
+	}
+	if rf, ok := ret.Get(0).(func(string, string) []models.SerialEvent); ok {
+		r0 = rf(productArticle, serialNumber)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.SerialEvent)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string, string) error); ok {
+		r1 = rf(productArticle, serialNumber)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetStocktake provides a mock function with given fields: stocktakeUUID
+func (_m *Repository) GetStocktake(stocktakeUUID string) (models.Stocktake, error) {
+	ret := _m.Called(stocktakeUUID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetStocktake")
+	}
+
+	var r0 models.Stocktake
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (models.Stocktake, error)); ok {
+		return rf(stocktakeUUID)
+	}
+	if rf, ok := ret.Get(0).(func(string) models.Stocktake); ok {
+		r0 = rf(stocktakeUUID)
+	} else {
+		r0 = ret.Get(0).(models.Stocktake)
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(stocktakeUUID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetSubstitutes provides a mock function with given fields: article
+func (_m *Repository) GetSubstitutes(article string) ([]models.Substitute, error) {
+	ret := _m.Called(article)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetSubstitutes")
+	}
+
+	var r0 []models.Substitute
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) ([]models.Substitute, error)); ok {
+		return rf(article)
+	}
+	if rf, ok := ret.Get(0).(func(string) []models.Substitute); ok {
+		r0 = rf(article)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.Substitute)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(article)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetWarehouse provides a mock function with given fields: warehouseUUID
+func (_m *Repository) GetWarehouse(warehouseUUID string) (models.Warehouse, error) {
+	ret := _m.Called(warehouseUUID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetWarehouse")
+	}
+
+	var r0 models.Warehouse
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (models.Warehouse, error)); ok {
+		return rf(warehouseUUID)
+	}
+	if rf, ok := ret.Get(0).(func(string) models.Warehouse); ok {
+		r0 = rf(warehouseUUID)
+	} else {
+		r0 = ret.Get(0).(models.Warehouse)
+	}
+
 	if rf, ok := ret.Get(1).(func(string) error); ok {
 		r1 = rf(warehouseUUID)
 	} else {
@@ -73,6 +786,96 @@ func (_m *Repository) GetRemainingProductsByWarehouse(warehouseUUID string) ([]m
 	return r0, r1
 }
 
+// InspectReturn provides a mock function with given fields: returnUUID, outcome, comment, inspectedBy, inspectedAt
+func (_m *Repository) InspectReturn(returnUUID string, outcome string, comment string, inspectedBy string, inspectedAt time.Time) error {
+	ret := _m.Called(returnUUID, outcome, comment, inspectedBy, inspectedAt)
+
+	if len(ret) == 0 {
+		panic("no return value specified for InspectReturn")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string, string, string, time.Time) error); ok {
+		r0 = rf(returnUUID, outcome, comment, inspectedBy, inspectedAt)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MoveBucketStock provides a mock function with given fields: warehouseUUID, moves
+func (_m *Repository) MoveBucketStock(warehouseUUID string, moves []models.BucketMove) error {
+	ret := _m.Called(warehouseUUID, moves)
+
+	if len(ret) == 0 {
+		panic("no return value specified for MoveBucketStock")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, []models.BucketMove) error); ok {
+		r0 = rf(warehouseUUID, moves)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MoveStock provides a mock function with given fields: warehouseUUID, moves
+func (_m *Repository) MoveStock(warehouseUUID string, moves []models.StockMove) error {
+	ret := _m.Called(warehouseUUID, moves)
+
+	if len(ret) == 0 {
+		panic("no return value specified for MoveStock")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, []models.StockMove) error); ok {
+		r0 = rf(warehouseUUID, moves)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ReceiveProducts provides a mock function with given fields: warehouseUUID, items
+func (_m *Repository) ReceiveProducts(warehouseUUID string, items []models.ReceiptItem) error {
+	ret := _m.Called(warehouseUUID, items)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ReceiveProducts")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, []models.ReceiptItem) error); ok {
+		r0 = rf(warehouseUUID, items)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ReceiveReturn provides a mock function with given fields: returnUUID, receivedBy, receivedAt
+func (_m *Repository) ReceiveReturn(returnUUID string, receivedBy string, receivedAt time.Time) error {
+	ret := _m.Called(returnUUID, receivedBy, receivedAt)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ReceiveReturn")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string, time.Time) error); ok {
+		r0 = rf(returnUUID, receivedBy, receivedAt)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // ReleaseProducts provides a mock function with given fields: productsWithSplit
 func (_m *Repository) ReleaseProducts(productsWithSplit []schemas.ProductWarehouseSplitted) error {
 	ret := _m.Called(productsWithSplit)
@@ -91,6 +894,24 @@ func (_m *Repository) ReleaseProducts(productsWithSplit []schemas.ProductWarehou
 	return r0
 }
 
+// ReleaseReservation provides a mock function with given fields: reservationUUID, products, status
+func (_m *Repository) ReleaseReservation(reservationUUID string, products []schemas.ProductWarehouseSplitted, status string) error {
+	ret := _m.Called(reservationUUID, products, status)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ReleaseReservation")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, []schemas.ProductWarehouseSplitted, string) error); ok {
+		r0 = rf(reservationUUID, products, status)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // ReserveProducts provides a mock function with given fields: products
 func (_m *Repository) ReserveProducts(products []schemas.ProductWarehouseSplitted) error {
 	ret := _m.Called(products)
@@ -109,6 +930,60 @@ func (_m *Repository) ReserveProducts(products []schemas.ProductWarehouseSplitte
 	return r0
 }
 
+// ReviewAdjustment provides a mock function with given fields: adjustmentUUID, status, reviewedBy, reviewedAt
+func (_m *Repository) ReviewAdjustment(adjustmentUUID string, status string, reviewedBy string, reviewedAt time.Time) error {
+	ret := _m.Called(adjustmentUUID, status, reviewedBy, reviewedAt)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ReviewAdjustment")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string, string, time.Time) error); ok {
+		r0 = rf(adjustmentUUID, status, reviewedBy, reviewedAt)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SaveKit provides a mock function with given fields: kit
+func (_m *Repository) SaveKit(kit models.Kit) error {
+	ret := _m.Called(kit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SaveKit")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(models.Kit) error); ok {
+		r0 = rf(kit)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SaveSubstitutes provides a mock function with given fields: article, substitutes
+func (_m *Repository) SaveSubstitutes(article string, substitutes []models.Substitute) error {
+	ret := _m.Called(article, substitutes)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SaveSubstitutes")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, []models.Substitute) error); ok {
+		r0 = rf(article, substitutes)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // NewRepository creates a new instance of Repository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewRepository(t interface {
@@ -0,0 +1,246 @@
+package service
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/shamank/warehouse-service/internal/domain/models"
+	"github.com/shamank/warehouse-service/internal/domain/schemas"
+	"slices"
+	"time"
+)
+
+// CreatePickList составляет лист отбора по тому, что осталось в активных резервах: строки по складам
+// в порядке обхода ячеек, товар вне ячеек - в конце склада. Резерв может быть только в одном открытом листе отбора
+func (s *Service) CreatePickList(ctx context.Context, request schemas.NewPickList) (schemas.PickList, error) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	now := time.Now().UTC()
+	pickList := models.PickList{
+		UUID:      uuid.NewString(),
+		Status:    models.PickListOpen,
+		CreatedAt: now,
+		UpdatedAt: now,
+		Lines:     make([]models.PickLine, 0),
+	}
+
+	added := make(map[string]bool, len(request.Reservations))
+	for _, reservationUUID := range request.Reservations {
+		if added[reservationUUID] {
+			continue
+		}
+		added[reservationUUID] = true
+
+		reservation, err := s.repo.GetReservation(reservationUUID)
+		if err != nil {
+			return schemas.PickList{}, err
+		}
+
+		if err := checkReservationAccess(ctx, reservation); err != nil {
+			return schemas.PickList{}, err
+		}
+
+		if reservation.Status != models.ReservationActive {
+			return schemas.PickList{}, fmt.Errorf("%w: %s is %s", models.ErrReservationClosed, reservation.UUID, reservation.Status)
+		}
+
+		pickList.Lines = append(pickList.Lines, reservationPickLines(reservation)...)
+	}
+
+	if len(pickList.Lines) == 0 {
+		return schemas.PickList{}, fmt.Errorf("%w: nothing to pick", models.ErrNotEnoughReserved)
+	}
+
+	sortPickLines(pickList.Lines)
+	for i := range pickList.Lines {
+		pickList.Lines[i].LineNo = i + 1
+		pickList.Lines[i].Status = models.PickLinePending
+	}
+
+	if err := s.repo.CreatePickList(pickList); err != nil {
+		return schemas.PickList{}, err
+	}
+
+	return pickListSchema(pickList), nil
+}
+
+// GetPickList возвращает лист отбора или models.ErrPickListNotFound
+func (s *Service) GetPickList(ctx context.Context, pickListUUID string) (schemas.PickList, error) {
+	pickList, err := s.repo.GetPickList(pickListUUID)
+	if err != nil {
+		return schemas.PickList{}, err
+	}
+
+	if err := checkPickListAccess(ctx, pickList); err != nil {
+		return schemas.PickList{}, err
+	}
+
+	return pickListSchema(pickList), nil
+}
+
+// ConfirmPickLine подтверждает сборку строки листа отбора. Если собрано меньше, чем в строке, недостача списывается
+// со склада и резервируется на других складах клиента так же, как при резервировании. Что не удалось
+// зарезервировать, остается в резерве как невыполненное, и резерв получает признак частичного выполнения
+func (s *Service) ConfirmPickLine(ctx context.Context, pickListUUID string, lineNo int, picked int) (schemas.PickList, error) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	pickList, err := s.repo.GetPickList(pickListUUID)
+	if err != nil {
+		return schemas.PickList{}, err
+	}
+
+	if err := checkPickListAccess(ctx, pickList); err != nil {
+		return schemas.PickList{}, err
+	}
+
+	index := slices.IndexFunc(pickList.Lines, func(line models.PickLine) bool { return line.LineNo == lineNo })
+	if index < 0 {
+		return schemas.PickList{}, fmt.Errorf("%w: line %d", models.ErrPickListNotFound, lineNo)
+	}
+	line := pickList.Lines[index]
+
+	if line.Status != models.PickLinePending {
+		return schemas.PickList{}, fmt.Errorf("%w: line %d is %s", models.ErrPickLineConfirmed, lineNo, line.Status)
+	}
+
+	if picked < 0 || picked > line.Quantity {
+		return schemas.PickList{}, fmt.Errorf("%w: picked %d of %d", models.ErrInvalidQuantity, picked, line.Quantity)
+	}
+
+	reservation, err := s.repo.GetReservation(line.ReservationUUID)
+	if err != nil {
+		return schemas.PickList{}, err
+	}
+
+	if reservation.Status != models.ReservationActive {
+		return schemas.PickList{}, fmt.Errorf("%w: %s", models.ErrReservationClosed, reservation.Status)
+	}
+
+	var reallocation []schemas.WarehouseCounter
+	if short := line.Quantity - picked; short > 0 {
+		reallocation, err = s.reallocate(ctx, line.ProductArticle, line.WarehouseUUID, short)
+		if err != nil {
+			return schemas.PickList{}, err
+		}
+	}
+
+	if err := s.repo.ConfirmPickLine(pickListUUID, lineNo, picked, reallocation); err != nil {
+		return schemas.PickList{}, err
+	}
+
+	pickList, err = s.repo.GetPickList(pickListUUID)
+	if err != nil {
+		return schemas.PickList{}, err
+	}
+
+	return pickListSchema(pickList), nil
+}
+
+// reallocate распределяет недостачу товара по другим складам клиента через processProduct.
+// Если свободного товара на них не хватает, распределяется сколько есть. Вызывается под s.mx
+func (s *Service) reallocate(ctx context.Context, productArticle string, shortWarehouseUUID string, quantity int) ([]schemas.WarehouseCounter, error) {
+	productInWarehouses, err := s.repo.GetProductsQuantity(productArticle)
+	if err != nil {
+		return nil, err
+	}
+
+	allowedWarehouses := clientWarehouses(ctx)
+	warehouses := make([]string, 0, len(productInWarehouses))
+	available := 0
+	for _, warehouseProduct := range productInWarehouses {
+		if warehouseProduct.WarehouseUUID == shortWarehouseUUID {
+			continue
+		}
+		if len(allowedWarehouses) > 0 && !slices.Contains(allowedWarehouses, warehouseProduct.WarehouseUUID) {
+			continue
+		}
+		warehouses = append(warehouses, warehouseProduct.WarehouseUUID)
+		available += warehouseProduct.Quantity
+	}
+
+	quantity = min(quantity, available)
+	if quantity <= 0 {
+		return nil, nil
+	}
+
+	return s.processProduct(productArticle, quantity, false, warehouses)
+}
+
+// reservationPickLines раскладывает то, что осталось в резерве, на строки листа отбора: по ячейкам
+// и строку для товара вне ячеек
+func reservationPickLines(reservation models.Reservation) []models.PickLine {
+	lines := make([]models.PickLine, 0, len(reservation.Items))
+	for _, item := range reservation.Items {
+		unplaced := item.Remaining()
+		for _, bin := range item.Bins {
+			lines = append(lines, models.PickLine{
+				ReservationUUID: reservation.UUID,
+				ProductArticle:  item.ProductArticle,
+				WarehouseUUID:   item.WarehouseUUID,
+				LocationPath:    bin.Path,
+				Quantity:        bin.Quantity,
+			})
+			unplaced -= bin.Quantity
+		}
+
+		if unplaced > 0 {
+			lines = append(lines, models.PickLine{
+				ReservationUUID: reservation.UUID,
+				ProductArticle:  item.ProductArticle,
+				WarehouseUUID:   item.WarehouseUUID,
+				Quantity:        unplaced,
+			})
+		}
+	}
+
+	return lines
+}
+
+// sortPickLines упорядочивает строки по складам, внутри склада - по пути ячейки, товар вне ячеек - в конце
+func sortPickLines(lines []models.PickLine) {
+	slices.SortFunc(lines, func(a, b models.PickLine) int {
+		if a.WarehouseUUID != b.WarehouseUUID {
+			return cmp.Compare(a.WarehouseUUID, b.WarehouseUUID)
+		}
+		if (a.LocationPath == "") != (b.LocationPath == "") {
+			if a.LocationPath == "" {
+				return 1
+			}
+			return -1
+		}
+		if a.LocationPath != b.LocationPath {
+			return cmp.Compare(a.LocationPath, b.LocationPath)
+		}
+		if a.ProductArticle != b.ProductArticle {
+			return cmp.Compare(a.ProductArticle, b.ProductArticle)
+		}
+		return cmp.Compare(a.ReservationUUID, b.ReservationUUID)
+	})
+}
+
+func pickListSchema(pickList models.PickList) schemas.PickList {
+	lines := make([]schemas.PickListLine, len(pickList.Lines))
+	for i, line := range pickList.Lines {
+		lines[i] = schemas.PickListLine{
+			Line:          line.LineNo,
+			ReservationID: line.ReservationUUID,
+			WarehouseUUID: line.WarehouseUUID,
+			Location:      line.LocationPath,
+			Article:       line.ProductArticle,
+			Quantity:      line.Quantity,
+			Picked:        line.PickedQuantity,
+			Status:        line.Status,
+		}
+	}
+
+	return schemas.PickList{
+		ID:        pickList.UUID,
+		Status:    pickList.Status,
+		CreatedAt: pickList.CreatedAt,
+		UpdatedAt: pickList.UpdatedAt,
+		Lines:     lines,
+	}
+}
@@ -0,0 +1,190 @@
+package service
+
+import (
+	"context"
+	"github.com/shamank/warehouse-service/internal/auth"
+	"github.com/shamank/warehouse-service/internal/domain/models"
+	"github.com/shamank/warehouse-service/internal/domain/schemas"
+	"github.com/shamank/warehouse-service/internal/service/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"log/slog"
+	"testing"
+)
+
+func TestService_CreatePickList(t *testing.T) {
+	const (
+		warehouse1   = "d10c8d17-6d15-445e-b643-6affa59aa26c"
+		warehouse2   = "a00518e4-be6e-4eb7-9f95-bb52cc8b8548"
+		reservation1 = "3f0c4a52-8b3e-4a55-9d7c-5a1f2b6e9c10"
+		reservation2 = "7b2d9e41-6c3a-4f58-8e1d-2a9c4b7f0d35"
+	)
+
+	t.Run("lines of several reservations", func(t *testing.T) {
+		repo := mocks.NewRepository(t)
+		repo.On("GetReservation", reservation1).Return(models.Reservation{
+			UUID:   reservation1,
+			Status: models.ReservationActive,
+			Items: []models.ReservationItem{
+				{ProductArticle: "soap", WarehouseUUID: warehouse2, Quantity: 2},
+				{ProductArticle: "soap", WarehouseUUID: warehouse1, Quantity: 4, ShortQuantity: 1, Bins: []models.BinQuantity{
+					{Path: "A/2/1/01", Quantity: 3},
+				}},
+			},
+		}, nil)
+		repo.On("GetReservation", reservation2).Return(models.Reservation{
+			UUID:   reservation2,
+			Status: models.ReservationActive,
+			Items: []models.ReservationItem{
+				{ProductArticle: "brush", WarehouseUUID: warehouse1, Quantity: 1, Bins: []models.BinQuantity{
+					{Path: "A/1/1/01", Quantity: 1},
+				}},
+			},
+		}, nil)
+
+		// строки по складам, внутри склада - в порядке обхода ячеек
+		expected := []models.PickLine{
+			{LineNo: 1, ReservationUUID: reservation1, ProductArticle: "soap", WarehouseUUID: warehouse2, Quantity: 2, Status: models.PickLinePending},
+			{LineNo: 2, ReservationUUID: reservation2, ProductArticle: "brush", WarehouseUUID: warehouse1, LocationPath: "A/1/1/01", Quantity: 1, Status: models.PickLinePending},
+			{LineNo: 3, ReservationUUID: reservation1, ProductArticle: "soap", WarehouseUUID: warehouse1, LocationPath: "A/2/1/01", Quantity: 3, Status: models.PickLinePending},
+		}
+		repo.On("CreatePickList", mock.MatchedBy(func(pickList models.PickList) bool {
+			return pickList.UUID != "" && pickList.Status == models.PickListOpen && assert.ObjectsAreEqual(expected, pickList.Lines)
+		})).Return(nil)
+
+		svc := NewService(repo, slog.Default())
+
+		pickList, err := svc.CreatePickList(context.Background(), schemas.NewPickList{Reservations: []string{reservation1, reservation2, reservation1}})
+		require.NoError(t, err)
+		assert.NotEmpty(t, pickList.ID)
+		assert.Equal(t, models.PickListOpen, pickList.Status)
+		assert.Equal(t, []schemas.PickListLine{
+			{Line: 1, ReservationID: reservation1, WarehouseUUID: warehouse2, Article: "soap", Quantity: 2, Status: models.PickLinePending},
+			{Line: 2, ReservationID: reservation2, WarehouseUUID: warehouse1, Location: "A/1/1/01", Article: "brush", Quantity: 1, Status: models.PickLinePending},
+			{Line: 3, ReservationID: reservation1, WarehouseUUID: warehouse1, Location: "A/2/1/01", Article: "soap", Quantity: 3, Status: models.PickLinePending},
+		}, pickList.Lines)
+	})
+
+	t.Run("closed reservation", func(t *testing.T) {
+		repo := mocks.NewRepository(t)
+		repo.On("GetReservation", reservation1).Return(models.Reservation{UUID: reservation1, Status: models.ReservationReleased}, nil)
+
+		svc := NewService(repo, slog.Default())
+
+		_, err := svc.CreatePickList(context.Background(), schemas.NewPickList{Reservations: []string{reservation1}})
+		assert.ErrorIs(t, err, models.ErrReservationClosed)
+	})
+
+	t.Run("nothing to pick", func(t *testing.T) {
+		repo := mocks.NewRepository(t)
+		repo.On("GetReservation", reservation1).Return(models.Reservation{
+			UUID:   reservation1,
+			Status: models.ReservationActive,
+			Items:  []models.ReservationItem{{ProductArticle: "soap", WarehouseUUID: warehouse1, Quantity: 2, ReleasedQuantity: 1, ShortQuantity: 1}},
+		}, nil)
+
+		svc := NewService(repo, slog.Default())
+
+		_, err := svc.CreatePickList(context.Background(), schemas.NewPickList{Reservations: []string{reservation1}})
+		assert.ErrorIs(t, err, models.ErrNotEnoughReserved)
+	})
+}
+
+func TestService_ConfirmPickLine(t *testing.T) {
+	const (
+		warehouse1  = "d10c8d17-6d15-445e-b643-6affa59aa26c"
+		warehouse2  = "a00518e4-be6e-4eb7-9f95-bb52cc8b8548"
+		warehouse3  = "e4aa0556-aec5-41d4-8280-885865842719"
+		reservation = "3f0c4a52-8b3e-4a55-9d7c-5a1f2b6e9c10"
+		pickList    = "5a7e0c93-1d4f-4b26-9c8e-3f6a2d1b7e40"
+	)
+
+	newRepo := func(t *testing.T, status string) *mocks.Repository {
+		repo := mocks.NewRepository(t)
+		repo.On("GetPickList", pickList).Return(models.PickList{
+			UUID:   pickList,
+			Status: models.PickListOpen,
+			Lines: []models.PickLine{
+				{LineNo: 1, ReservationUUID: reservation, ProductArticle: "soap", WarehouseUUID: warehouse1, LocationPath: "A/1/1/01", Quantity: 5, Status: status},
+			},
+		}, nil)
+		return repo
+	}
+
+	activeReservation := models.Reservation{
+		UUID:   reservation,
+		Status: models.ReservationActive,
+		Items:  []models.ReservationItem{{ProductArticle: "soap", WarehouseUUID: warehouse1, Quantity: 5}},
+	}
+
+	t.Run("picked in full", func(t *testing.T) {
+		repo := newRepo(t, models.PickLinePending)
+		repo.On("GetReservation", reservation).Return(activeReservation, nil)
+		repo.On("ConfirmPickLine", pickList, 1, 5, []schemas.WarehouseCounter(nil)).Return(nil)
+
+		svc := NewService(repo, slog.Default())
+
+		_, err := svc.ConfirmPickLine(context.Background(), pickList, 1, 5)
+		require.NoError(t, err)
+	})
+
+	t.Run("short pick is reallocated to client warehouses", func(t *testing.T) {
+		ctx := auth.WithPrincipal(context.Background(), auth.Principal{
+			Subject:    "picker",
+			Scopes:     []string{auth.ScopeStockReserve},
+			Warehouses: []string{warehouse1, warehouse2},
+		})
+
+		repo := newRepo(t, models.PickLinePending)
+		repo.On("GetReservation", reservation).Return(activeReservation, nil)
+		// на первом складе товар есть, но там его и не нашли, третий склад клиенту недоступен
+		repo.On("GetProductsQuantity", "soap").Return([]models.WarehouseProduct{
+			{WarehouseUUID: warehouse1, Quantity: 10},
+			{WarehouseUUID: warehouse3, Quantity: 10},
+			{WarehouseUUID: warehouse2, Quantity: 1},
+		}, nil)
+		repo.On("ConfirmPickLine", pickList, 1, 2, []schemas.WarehouseCounter{{WarehouseUUID: warehouse2, Count: 1}}).Return(nil)
+
+		svc := NewService(repo, slog.Default())
+
+		_, err := svc.ConfirmPickLine(ctx, pickList, 1, 2)
+		require.NoError(t, err)
+	})
+
+	t.Run("short pick without stock elsewhere", func(t *testing.T) {
+		repo := newRepo(t, models.PickLinePending)
+		repo.On("GetReservation", reservation).Return(activeReservation, nil)
+		repo.On("GetProductsQuantity", "soap").Return([]models.WarehouseProduct{{WarehouseUUID: warehouse1, Quantity: 10}}, nil)
+		repo.On("ConfirmPickLine", pickList, 1, 0, []schemas.WarehouseCounter(nil)).Return(nil)
+
+		svc := NewService(repo, slog.Default())
+
+		_, err := svc.ConfirmPickLine(context.Background(), pickList, 1, 0)
+		require.NoError(t, err)
+	})
+
+	t.Run("invalid confirmations", func(t *testing.T) {
+		svc := NewService(newRepo(t, models.PickLinePending), slog.Default())
+
+		_, err := svc.ConfirmPickLine(context.Background(), pickList, 1, 6)
+		assert.ErrorIs(t, err, models.ErrInvalidQuantity)
+		_, err = svc.ConfirmPickLine(context.Background(), pickList, 2, 1)
+		assert.ErrorIs(t, err, models.ErrPickListNotFound)
+
+		svc = NewService(newRepo(t, models.PickLinePicked), slog.Default())
+
+		_, err = svc.ConfirmPickLine(context.Background(), pickList, 1, 5)
+		assert.ErrorIs(t, err, models.ErrPickLineConfirmed)
+	})
+
+	t.Run("closed reservation", func(t *testing.T) {
+		repo := newRepo(t, models.PickLinePending)
+		repo.On("GetReservation", reservation).Return(models.Reservation{UUID: reservation, Status: models.ReservationCancelled}, nil)
+
+		svc := NewService(repo, slog.Default())
+
+		_, err := svc.ConfirmPickLine(context.Background(), pickList, 1, 5)
+		assert.ErrorIs(t, err, models.ErrReservationClosed)
+	})
+}
@@ -0,0 +1,203 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/shamank/warehouse-service/internal/domain/models"
+	"github.com/shamank/warehouse-service/internal/domain/schemas"
+)
+
+// CreateReservation резервирует позиции и сохраняет их как резерв с собственным id,
+// по которому резерв потом можно освободить или отменить. Наборы сохраняются в резерве компонентами,
+// замена - под своим артикулом с артикулом заменяемого товара
+func (s *Service) CreateReservation(ctx context.Context, items []schemas.ReserveItem) (schemas.Reservation, error) {
+	if err := s.validateItems(ctx, items); err != nil {
+		return schemas.Reservation{}, err
+	}
+
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	items, err := s.expandKitItems(items)
+	if err != nil {
+		return schemas.Reservation{}, err
+	}
+
+	productsWithSplit, err := s.processItems(items, clientWarehouses(ctx))
+	if err != nil {
+		return schemas.Reservation{}, err
+	}
+
+	reservationUUID := uuid.NewString()
+	if err := s.repo.CreateReservation(reservationUUID, productsWithSplit); err != nil {
+		return schemas.Reservation{}, err
+	}
+
+	return s.getReservation(reservationUUID)
+}
+
+// GetReservation возвращает резерв или models.ErrReservationNotFound.
+// Резерв на недоступных клиенту складах возвращает models.ErrWarehouseAccessDenied
+func (s *Service) GetReservation(ctx context.Context, reservationUUID string) (schemas.Reservation, error) {
+	reservation, err := s.repo.GetReservation(reservationUUID)
+	if err != nil {
+		return schemas.Reservation{}, err
+	}
+
+	if err := checkReservationAccess(ctx, reservation); err != nil {
+		return schemas.Reservation{}, err
+	}
+
+	return reservationSchema(reservation), nil
+}
+
+// ReleaseReservation возвращает на склады часть резерва. Пустой items освобождает все, что осталось в резерве.
+// Когда в резерве ничего не остается, он получает статус models.ReservationReleased
+func (s *Service) ReleaseReservation(ctx context.Context, reservationUUID string, items []schemas.ReleaseItem) (schemas.Reservation, error) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	return s.releaseReservation(ctx, reservationUUID, items, models.ReservationReleased)
+}
+
+// CancelReservation возвращает на склады весь оставшийся резерв и переводит его в статус models.ReservationCancelled
+func (s *Service) CancelReservation(ctx context.Context, reservationUUID string) (schemas.Reservation, error) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	return s.releaseReservation(ctx, reservationUUID, nil, models.ReservationCancelled)
+}
+
+// releaseReservation освобождает items (все, если items пустой) и, если резерв опустел, ставит ему closedStatus.
+// Вызывается под s.mx
+func (s *Service) releaseReservation(ctx context.Context, reservationUUID string, items []schemas.ReleaseItem, closedStatus string) (schemas.Reservation, error) {
+	reservation, err := s.repo.GetReservation(reservationUUID)
+	if err != nil {
+		return schemas.Reservation{}, err
+	}
+
+	if err := checkReservationAccess(ctx, reservation); err != nil {
+		return schemas.Reservation{}, err
+	}
+
+	if reservation.Status != models.ReservationActive {
+		return schemas.Reservation{}, fmt.Errorf("%w: %s", models.ErrReservationClosed, reservation.Status)
+	}
+
+	// сколько еще можно вернуть по каждому товару, склады - в порядке позиций резерва
+	remaining := make(map[string][]schemas.WarehouseCounter)
+	articles := make([]string, 0)
+	total := 0
+	for _, item := range reservation.Items {
+		count := item.Remaining()
+		if count == 0 {
+			continue
+		}
+		if _, ok := remaining[item.ProductArticle]; !ok {
+			articles = append(articles, item.ProductArticle)
+		}
+		remaining[item.ProductArticle] = append(remaining[item.ProductArticle], schemas.WarehouseCounter{
+			WarehouseUUID: item.WarehouseUUID,
+			Count:         count,
+		})
+		total += count
+	}
+
+	if len(items) == 0 {
+		items = make([]schemas.ReleaseItem, 0, len(articles))
+		for _, article := range articles {
+			quantity := 0
+			for _, warehouseData := range remaining[article] {
+				quantity += warehouseData.Count
+			}
+			items = append(items, schemas.ReleaseItem{Article: article, Quantity: quantity})
+		}
+	}
+
+	productsWithSplit := make([]schemas.ProductWarehouseSplitted, 0, len(items))
+	released := make(map[string]bool, len(items))
+
+	for _, item := range items {
+		if item.Quantity <= 0 {
+			return schemas.Reservation{}, fmt.Errorf("%w: %s", models.ErrInvalidQuantity, item.Article)
+		}
+		if released[item.Article] {
+			return schemas.Reservation{}, fmt.Errorf("%w: %s", models.ErrDuplicateArticle, item.Article)
+		}
+		released[item.Article] = true
+
+		quantity := item.Quantity
+		warehouseData := make([]schemas.WarehouseCounter, 0)
+
+		for _, reserved := range remaining[item.Article] {
+			count := min(quantity, reserved.Count)
+			warehouseData = append(warehouseData, schemas.WarehouseCounter{
+				WarehouseUUID: reserved.WarehouseUUID,
+				Count:         count,
+			})
+			quantity -= count
+			if quantity == 0 {
+				break
+			}
+		}
+
+		if quantity > 0 {
+			return schemas.Reservation{}, fmt.Errorf("%w: %s", models.ErrNotEnoughReserved, item.Article)
+		}
+
+		productsWithSplit = append(productsWithSplit, schemas.ProductWarehouseSplitted{
+			ProductArticle: item.Article,
+			WarehouseData:  warehouseData,
+		})
+		total -= item.Quantity
+	}
+
+	status := models.ReservationActive
+	if total == 0 {
+		status = closedStatus
+	}
+
+	if err := s.repo.ReleaseReservation(reservationUUID, productsWithSplit, status); err != nil {
+		return schemas.Reservation{}, err
+	}
+
+	return s.getReservation(reservationUUID)
+}
+
+func (s *Service) getReservation(reservationUUID string) (schemas.Reservation, error) {
+	reservation, err := s.repo.GetReservation(reservationUUID)
+	if err != nil {
+		return schemas.Reservation{}, err
+	}
+
+	return reservationSchema(reservation), nil
+}
+
+func reservationSchema(reservation models.Reservation) schemas.Reservation {
+	items := make([]schemas.ReservationItem, len(reservation.Items))
+	partiallyFulfilled := false
+	for i, item := range reservation.Items {
+		items[i] = schemas.ReservationItem{
+			Article:       item.ProductArticle,
+			WarehouseUUID: item.WarehouseUUID,
+			Quantity:      item.Quantity,
+			Released:      item.ReleasedQuantity,
+			Short:         item.ShortQuantity,
+			Unfulfilled:   item.UnfulfilledQuantity,
+			Serials:       item.Serials,
+			Bins:          reservationBins(item.Bins),
+			SubstituteFor: item.SubstituteFor,
+		}
+		partiallyFulfilled = partiallyFulfilled || item.UnfulfilledQuantity > 0
+	}
+
+	return schemas.Reservation{
+		ID:                 reservation.UUID,
+		Status:             reservation.Status,
+		CreatedAt:          reservation.CreatedAt,
+		UpdatedAt:          reservation.UpdatedAt,
+		Items:              items,
+		PartiallyFulfilled: partiallyFulfilled,
+	}
+}
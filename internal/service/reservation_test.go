@@ -0,0 +1,184 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/shamank/warehouse-service/internal/domain/models"
+	"github.com/shamank/warehouse-service/internal/domain/schemas"
+	"github.com/shamank/warehouse-service/internal/service/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"log/slog"
+	"testing"
+)
+
+func TestService_CreateReservation(t *testing.T) {
+	const warehouse = "d10c8d17-6d15-445e-b643-6affa59aa26c"
+
+	repo := mocks.NewRepository(t)
+	repo.On("GetKits", mock.Anything).Return([]models.Kit{}, nil).Maybe()
+	repo.On("GetProductsByArticles", []string{"product1"}).Return([]models.Product{{Code: "product1"}}, nil)
+	repo.On("GetProductsQuantity", "product1").Return([]models.WarehouseProduct{{WarehouseUUID: warehouse, Quantity: 10}}, nil)
+
+	var reservationUUID string
+	repo.On("CreateReservation", mock.AnythingOfType("string"), []schemas.ProductWarehouseSplitted{
+		{
+			ProductArticle: "product1",
+			WarehouseData:  []schemas.WarehouseCounter{{WarehouseUUID: warehouse, Count: 4}},
+		},
+	}).Run(func(args mock.Arguments) {
+		reservationUUID = args.String(0)
+	}).Return(nil)
+	repo.On("GetReservation", mock.AnythingOfType("string")).Return(func(id string) (models.Reservation, error) {
+		return models.Reservation{
+			UUID:   id,
+			Status: models.ReservationActive,
+			Items:  []models.ReservationItem{{ProductArticle: "product1", WarehouseUUID: warehouse, Quantity: 4}},
+		}, nil
+	})
+
+	svc := NewService(repo, slog.Default())
+
+	reservation, err := svc.CreateReservation(context.Background(), []schemas.ReserveItem{{Article: "product1", Quantity: 4}})
+	require.NoError(t, err)
+	assert.NotEmpty(t, reservationUUID)
+	assert.Equal(t, schemas.Reservation{
+		ID:     reservationUUID,
+		Status: models.ReservationActive,
+		Items:  []schemas.ReservationItem{{Article: "product1", WarehouseUUID: warehouse, Quantity: 4}},
+	}, reservation)
+}
+
+func TestService_ReleaseReservation(t *testing.T) {
+	const (
+		reservationUUID = "5b0f2c3e-7a41-4d8e-9c6b-2e1f0a9d8c7b"
+		warehouse1      = "d10c8d17-6d15-445e-b643-6affa59aa26c"
+		warehouse2      = "a00518e4-be6e-4eb7-9f95-bb52cc8b8548"
+	)
+
+	// по товару product1 осталось 2 + 5, по товару product2 - ничего
+	reservation := models.Reservation{
+		UUID:   reservationUUID,
+		Status: models.ReservationActive,
+		Items: []models.ReservationItem{
+			{ProductArticle: "product1", WarehouseUUID: warehouse1, Quantity: 5, ReleasedQuantity: 3},
+			{ProductArticle: "product1", WarehouseUUID: warehouse2, Quantity: 5},
+			{ProductArticle: "product2", WarehouseUUID: warehouse1, Quantity: 1, ReleasedQuantity: 1},
+		},
+	}
+
+	testCases := []struct {
+		name           string
+		reservation    models.Reservation
+		cancel         bool
+		items          []schemas.ReleaseItem
+		expectedSplit  []schemas.ProductWarehouseSplitted
+		expectedStatus string
+		expectedError  error
+	}{
+		{
+			name:        "part of reservation",
+			reservation: reservation,
+			items:       []schemas.ReleaseItem{{Article: "product1", Quantity: 4}},
+			expectedSplit: []schemas.ProductWarehouseSplitted{
+				{
+					ProductArticle: "product1",
+					WarehouseData: []schemas.WarehouseCounter{
+						{WarehouseUUID: warehouse1, Count: 2},
+						{WarehouseUUID: warehouse2, Count: 2},
+					},
+				},
+			},
+			expectedStatus: models.ReservationActive,
+		},
+		{
+			name:        "rest of reservation",
+			reservation: reservation,
+			items:       []schemas.ReleaseItem{{Article: "product1", Quantity: 7}},
+			expectedSplit: []schemas.ProductWarehouseSplitted{
+				{
+					ProductArticle: "product1",
+					WarehouseData: []schemas.WarehouseCounter{
+						{WarehouseUUID: warehouse1, Count: 2},
+						{WarehouseUUID: warehouse2, Count: 5},
+					},
+				},
+			},
+			expectedStatus: models.ReservationReleased,
+		},
+		{
+			name:        "whole reservation without items",
+			reservation: reservation,
+			expectedSplit: []schemas.ProductWarehouseSplitted{
+				{
+					ProductArticle: "product1",
+					WarehouseData: []schemas.WarehouseCounter{
+						{WarehouseUUID: warehouse1, Count: 2},
+						{WarehouseUUID: warehouse2, Count: 5},
+					},
+				},
+			},
+			expectedStatus: models.ReservationReleased,
+		},
+		{
+			name:        "cancel",
+			reservation: reservation,
+			cancel:      true,
+			expectedSplit: []schemas.ProductWarehouseSplitted{
+				{
+					ProductArticle: "product1",
+					WarehouseData: []schemas.WarehouseCounter{
+						{WarehouseUUID: warehouse1, Count: 2},
+						{WarehouseUUID: warehouse2, Count: 5},
+					},
+				},
+			},
+			expectedStatus: models.ReservationCancelled,
+		},
+		{
+			name:          "more than reserved",
+			reservation:   reservation,
+			items:         []schemas.ReleaseItem{{Article: "product1", Quantity: 8}},
+			expectedError: fmt.Errorf("%w: product1", models.ErrNotEnoughReserved),
+		},
+		{
+			name:          "already released product",
+			reservation:   reservation,
+			items:         []schemas.ReleaseItem{{Article: "product2", Quantity: 1}},
+			expectedError: fmt.Errorf("%w: product2", models.ErrNotEnoughReserved),
+		},
+		{
+			name:          "duplicate article",
+			reservation:   reservation,
+			items:         []schemas.ReleaseItem{{Article: "product1", Quantity: 1}, {Article: "product1", Quantity: 1}},
+			expectedError: fmt.Errorf("%w: product1", models.ErrDuplicateArticle),
+		},
+		{
+			name:          "closed reservation",
+			reservation:   models.Reservation{UUID: reservationUUID, Status: models.ReservationCancelled},
+			cancel:        true,
+			expectedError: fmt.Errorf("%w: cancelled", models.ErrReservationClosed),
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			repo := mocks.NewRepository(t)
+			repo.On("GetReservation", reservationUUID).Return(testCase.reservation, nil)
+			if testCase.expectedError == nil {
+				repo.On("ReleaseReservation", reservationUUID, testCase.expectedSplit, testCase.expectedStatus).Once().Return(nil)
+			}
+
+			svc := NewService(repo, slog.Default())
+
+			var err error
+			if testCase.cancel {
+				_, err = svc.CancelReservation(context.Background(), reservationUUID)
+			} else {
+				_, err = svc.ReleaseReservation(context.Background(), reservationUUID, testCase.items)
+			}
+			assert.Equal(t, testCase.expectedError, err)
+		})
+	}
+}
@@ -0,0 +1,153 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/shamank/warehouse-service/internal/domain/models"
+	"github.com/shamank/warehouse-service/internal/domain/schemas"
+	"time"
+)
+
+// CreateReturn создает возврат товара покупателем на склад. Остаток не меняется до осмотра
+func (s *Service) CreateReturn(ctx context.Context, warehouseUUID string, request schemas.NewReturn) (schemas.Return, error) {
+	if err := checkWarehouseAccess(ctx, warehouseUUID); err != nil {
+		return schemas.Return{}, err
+	}
+
+	if _, err := s.repo.GetWarehouse(warehouseUUID); err != nil {
+		return schemas.Return{}, err
+	}
+
+	if request.Quantity <= 0 {
+		return schemas.Return{}, fmt.Errorf("%w: %s", models.ErrInvalidQuantity, request.Article)
+	}
+
+	products, err := s.productsByArticle([]string{request.Article})
+	if err != nil {
+		return schemas.Return{}, err
+	}
+	if products[request.Article].Serialized {
+		return schemas.Return{}, fmt.Errorf("%w: %s is tracked by serial numbers", models.ErrInvalidReturn, request.Article)
+	}
+
+	ret := models.Return{
+		UUID:           uuid.NewString(),
+		WarehouseUUID:  warehouseUUID,
+		ProductArticle: request.Article,
+		Quantity:       request.Quantity,
+		Reference:      request.Reference,
+		Reason:         request.Reason,
+		Status:         models.ReturnCreated,
+		CreatedBy:      clientSubject(ctx),
+		CreatedAt:      time.Now().UTC(),
+	}
+
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	if err := s.repo.CreateReturn(ret); err != nil {
+		return schemas.Return{}, err
+	}
+
+	return returnSchema(ret), nil
+}
+
+// GetReturn возвращает возврат со всеми пройденными шагами
+func (s *Service) GetReturn(ctx context.Context, returnUUID string) (schemas.Return, error) {
+	ret, err := s.repo.GetReturn(returnUUID)
+	if err != nil {
+		return schemas.Return{}, err
+	}
+
+	if err := checkWarehouseAccess(ctx, ret.WarehouseUUID); err != nil {
+		return schemas.Return{}, err
+	}
+
+	return returnSchema(ret), nil
+}
+
+// ReceiveReturn отмечает, что возвращенный товар прибыл на склад и ждет осмотра
+func (s *Service) ReceiveReturn(ctx context.Context, returnUUID string) (schemas.Return, error) {
+	ret, err := s.repo.GetReturn(returnUUID)
+	if err != nil {
+		return schemas.Return{}, err
+	}
+
+	if err := checkWarehouseAccess(ctx, ret.WarehouseUUID); err != nil {
+		return schemas.Return{}, err
+	}
+
+	if ret.Status != models.ReturnCreated {
+		return schemas.Return{}, fmt.Errorf("%w: %s is %s", models.ErrReturnStatus, returnUUID, ret.Status)
+	}
+
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	if err := s.repo.ReceiveReturn(returnUUID, clientSubject(ctx), time.Now().UTC()); err != nil {
+		return schemas.Return{}, err
+	}
+
+	ret, err = s.repo.GetReturn(returnUUID)
+	if err != nil {
+		return schemas.Return{}, err
+	}
+
+	return returnSchema(ret), nil
+}
+
+// InspectReturn сохраняет результат осмотра принятого возврата: models.ReturnRestock возвращает товар в свободный
+// остаток, models.ReturnQuarantine - в непродаваемую корзину карантина, models.ReturnScrap только учитывается
+func (s *Service) InspectReturn(ctx context.Context, returnUUID string, request schemas.ReturnInspection) (schemas.Return, error) {
+	ret, err := s.repo.GetReturn(returnUUID)
+	if err != nil {
+		return schemas.Return{}, err
+	}
+
+	if err := checkWarehouseAccess(ctx, ret.WarehouseUUID); err != nil {
+		return schemas.Return{}, err
+	}
+
+	if request.Outcome != models.ReturnRestock && request.Outcome != models.ReturnQuarantine && request.Outcome != models.ReturnScrap {
+		return schemas.Return{}, fmt.Errorf("%w: unknown outcome %q", models.ErrInvalidReturn, request.Outcome)
+	}
+
+	if ret.Status != models.ReturnReceived {
+		return schemas.Return{}, fmt.Errorf("%w: %s is %s", models.ErrReturnStatus, returnUUID, ret.Status)
+	}
+
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	if err := s.repo.InspectReturn(returnUUID, request.Outcome, request.Comment, clientSubject(ctx), time.Now().UTC()); err != nil {
+		return schemas.Return{}, err
+	}
+
+	ret, err = s.repo.GetReturn(returnUUID)
+	if err != nil {
+		return schemas.Return{}, err
+	}
+
+	return returnSchema(ret), nil
+}
+
+func returnSchema(ret models.Return) schemas.Return {
+	return schemas.Return{
+		ID:            ret.UUID,
+		WarehouseUUID: ret.WarehouseUUID,
+		Article:       ret.ProductArticle,
+		Quantity:      ret.Quantity,
+		Reference:     ret.Reference,
+		Reason:        ret.Reason,
+		Status:        ret.Status,
+		Outcome:       ret.Outcome,
+		Comment:       ret.Comment,
+		CreatedBy:     ret.CreatedBy,
+		CreatedAt:     ret.CreatedAt,
+		ReceivedBy:    ret.ReceivedBy,
+		ReceivedAt:    ret.ReceivedAt,
+		InspectedBy:   ret.InspectedBy,
+		InspectedAt:   ret.InspectedAt,
+	}
+}
@@ -0,0 +1,126 @@
+package service
+
+import (
+	"context"
+	"github.com/shamank/warehouse-service/internal/auth"
+	"github.com/shamank/warehouse-service/internal/domain/models"
+	"github.com/shamank/warehouse-service/internal/domain/schemas"
+	"github.com/shamank/warehouse-service/internal/service/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestService_CreateReturn(t *testing.T) {
+	const warehouse = "d10c8d17-6d15-445e-b643-6affa59aa26c"
+
+	ctx := auth.WithPrincipal(context.Background(), auth.Principal{
+		Subject: "support",
+		Scopes:  []string{auth.ScopeAdmin},
+	})
+
+	t.Run("create", func(t *testing.T) {
+		repo := mocks.NewRepository(t)
+		repo.On("GetWarehouse", warehouse).Return(models.Warehouse{UUID: warehouse, Availability: true}, nil)
+		repo.On("GetProductsByArticles", []string{"product1"}).Return([]models.Product{{Code: "product1"}}, nil)
+		repo.On("CreateReturn", mock.MatchedBy(func(ret models.Return) bool {
+			return ret.UUID != "" && ret.WarehouseUUID == warehouse && ret.ProductArticle == "product1" && ret.Quantity == 2 &&
+				ret.Reference == "order-42" && ret.Status == models.ReturnCreated && ret.CreatedBy == "support"
+		})).Return(nil)
+
+		svc := NewService(repo, slog.Default())
+
+		ret, err := svc.CreateReturn(ctx, warehouse, schemas.NewReturn{Article: "product1", Quantity: 2, Reference: "order-42"})
+		require.NoError(t, err)
+		assert.NotEmpty(t, ret.ID)
+		assert.Equal(t, models.ReturnCreated, ret.Status)
+	})
+
+	t.Run("serialized product", func(t *testing.T) {
+		repo := mocks.NewRepository(t)
+		repo.On("GetWarehouse", warehouse).Return(models.Warehouse{UUID: warehouse, Availability: true}, nil)
+		repo.On("GetProductsByArticles", []string{"phone"}).Return([]models.Product{{Code: "phone", Serialized: true}}, nil)
+
+		svc := NewService(repo, slog.Default())
+
+		_, err := svc.CreateReturn(ctx, warehouse, schemas.NewReturn{Article: "phone", Quantity: 1})
+		assert.ErrorIs(t, err, models.ErrInvalidReturn)
+	})
+}
+
+func TestService_InspectReturn(t *testing.T) {
+	const (
+		warehouse = "d10c8d17-6d15-445e-b643-6affa59aa26c"
+		returnID  = "3a4b5c6d-7e8f-4a9b-8c0d-1e2f3a4b5c6d"
+	)
+
+	ctx := auth.WithPrincipal(context.Background(), auth.Principal{
+		Subject: "inspector",
+		Scopes:  []string{auth.ScopeAdmin},
+	})
+	received := models.Return{
+		UUID: returnID, WarehouseUUID: warehouse, ProductArticle: "product1", Quantity: 2, Status: models.ReturnReceived,
+	}
+
+	t.Run("quarantine", func(t *testing.T) {
+		inspectedAt := time.Now().UTC()
+		inspected := received
+		inspected.Status = models.ReturnInspected
+		inspected.Outcome = models.ReturnQuarantine
+		inspected.InspectedBy = "inspector"
+		inspected.InspectedAt = &inspectedAt
+
+		repo := mocks.NewRepository(t)
+		repo.On("GetReturn", returnID).Return(received, nil).Once()
+		repo.On("InspectReturn", returnID, models.ReturnQuarantine, "torn box", "inspector", mock.AnythingOfType("time.Time")).Return(nil)
+		repo.On("GetReturn", returnID).Return(inspected, nil).Once()
+
+		svc := NewService(repo, slog.Default())
+
+		ret, err := svc.InspectReturn(ctx, returnID, schemas.ReturnInspection{Outcome: models.ReturnQuarantine, Comment: "torn box"})
+		require.NoError(t, err)
+		assert.Equal(t, models.ReturnInspected, ret.Status)
+		assert.Equal(t, models.ReturnQuarantine, ret.Outcome)
+	})
+
+	t.Run("not received", func(t *testing.T) {
+		created := received
+		created.Status = models.ReturnCreated
+
+		repo := mocks.NewRepository(t)
+		repo.On("GetReturn", returnID).Return(created, nil)
+
+		svc := NewService(repo, slog.Default())
+
+		_, err := svc.InspectReturn(ctx, returnID, schemas.ReturnInspection{Outcome: models.ReturnRestock})
+		assert.ErrorIs(t, err, models.ErrReturnStatus)
+	})
+
+	t.Run("unknown outcome", func(t *testing.T) {
+		repo := mocks.NewRepository(t)
+		repo.On("GetReturn", returnID).Return(received, nil)
+
+		svc := NewService(repo, slog.Default())
+
+		_, err := svc.InspectReturn(ctx, returnID, schemas.ReturnInspection{Outcome: "resell"})
+		assert.ErrorIs(t, err, models.ErrInvalidReturn)
+	})
+
+	t.Run("forbidden warehouse", func(t *testing.T) {
+		repo := mocks.NewRepository(t)
+		repo.On("GetReturn", returnID).Return(received, nil)
+
+		svc := NewService(repo, slog.Default())
+
+		ctx := auth.WithPrincipal(context.Background(), auth.Principal{
+			Subject:    "inspector",
+			Scopes:     []string{auth.ScopeAdmin},
+			Warehouses: []string{"a00518e4-be6e-4eb7-9f95-bb52cc8b8548"},
+		})
+		_, err := svc.ReceiveReturn(ctx, returnID)
+		assert.ErrorIs(t, err, models.ErrWarehouseAccessDenied)
+	})
+}
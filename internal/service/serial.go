@@ -0,0 +1,86 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/shamank/warehouse-service/internal/domain/models"
+	"github.com/shamank/warehouse-service/internal/domain/schemas"
+)
+
+// GetSerial возвращает серийный номер товара и его текущее состояние или models.ErrSerialNotFound.
+// Номер на складе, недоступном клиенту, возвращает models.ErrWarehouseAccessDenied
+func (s *Service) GetSerial(ctx context.Context, article string, serialNumber string) (schemas.Serial, error) {
+	serial, err := s.repo.GetSerial(article, serialNumber)
+	if err != nil {
+		return schemas.Serial{}, err
+	}
+
+	if err := checkWarehouseAccess(ctx, serial.WarehouseUUID); err != nil {
+		return schemas.Serial{}, err
+	}
+
+	return schemas.Serial{
+		Article:       serial.ProductArticle,
+		SerialNumber:  serial.SerialNumber,
+		WarehouseUUID: serial.WarehouseUUID,
+		Status:        serial.Status,
+		ReservationID: serial.ReservationUUID,
+		UpdatedAt:     serial.UpdatedAt,
+	}, nil
+}
+
+// GetSerialHistory возвращает смены состояния серийного номера от первой к последней.
+// Доступ проверяется по складу, на котором номер находится сейчас
+func (s *Service) GetSerialHistory(ctx context.Context, article string, serialNumber string) ([]schemas.SerialEvent, error) {
+	if _, err := s.GetSerial(ctx, article, serialNumber); err != nil {
+		return nil, err
+	}
+
+	events, err := s.repo.GetSerialHistory(article, serialNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	history := make([]schemas.SerialEvent, len(events))
+	for i, event := range events {
+		history[i] = schemas.SerialEvent{
+			Status:        event.Status,
+			WarehouseUUID: event.WarehouseUUID,
+			ReservationID: event.ReservationUUID,
+			CreatedAt:     event.CreatedAt,
+		}
+	}
+
+	return history, nil
+}
+
+// checkReceiptSerials проверяет, что штучный товар принимается с уникальными серийными номерами по одному на единицу,
+// а остальной товар - без номеров. Иначе возвращается models.ErrInvalidSerials
+func checkReceiptSerials(receipt []models.ReceiptItem, products map[string]models.Product) error {
+	seen := make(map[string]map[string]bool)
+	for _, item := range receipt {
+		if !products[item.ProductArticle].Serialized {
+			if len(item.Serials) > 0 {
+				return fmt.Errorf("%w: %s is not serialized", models.ErrInvalidSerials, item.ProductArticle)
+			}
+			continue
+		}
+
+		if len(item.Serials) != item.Quantity {
+			return fmt.Errorf("%w: %s needs %d serial numbers, got %d",
+				models.ErrInvalidSerials, item.ProductArticle, item.Quantity, len(item.Serials))
+		}
+
+		if seen[item.ProductArticle] == nil {
+			seen[item.ProductArticle] = make(map[string]bool)
+		}
+		for _, serial := range item.Serials {
+			if seen[item.ProductArticle][serial] {
+				return fmt.Errorf("%w: %s %s is repeated", models.ErrInvalidSerials, item.ProductArticle, serial)
+			}
+			seen[item.ProductArticle][serial] = true
+		}
+	}
+
+	return nil
+}
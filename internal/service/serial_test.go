@@ -0,0 +1,88 @@
+package service
+
+import (
+	"context"
+	"github.com/shamank/warehouse-service/internal/auth"
+	"github.com/shamank/warehouse-service/internal/domain/models"
+	"github.com/shamank/warehouse-service/internal/domain/schemas"
+	"github.com/shamank/warehouse-service/internal/service/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestService_GetSerialHistory(t *testing.T) {
+	const (
+		warehouse   = "d10c8d17-6d15-445e-b643-6affa59aa26c"
+		reservation = "3f0c4a52-8b3e-4a55-9d7c-5a1f2b6e9c10"
+	)
+
+	receivedAt := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	reservedAt := receivedAt.Add(time.Hour)
+
+	newRepo := func(t *testing.T) *mocks.Repository {
+		repo := mocks.NewRepository(t)
+		repo.On("GetSerial", "phone", "IMEI-1").Return(models.ProductSerial{
+			ProductArticle:  "phone",
+			SerialNumber:    "IMEI-1",
+			WarehouseUUID:   warehouse,
+			Status:          models.SerialReserved,
+			ReservationUUID: reservation,
+			UpdatedAt:       reservedAt,
+		}, nil)
+		return repo
+	}
+
+	t.Run("history", func(t *testing.T) {
+		repo := newRepo(t)
+		repo.On("GetSerialHistory", "phone", "IMEI-1").Return([]models.SerialEvent{
+			{Status: models.SerialAvailable, WarehouseUUID: warehouse, CreatedAt: receivedAt},
+			{Status: models.SerialReserved, WarehouseUUID: warehouse, ReservationUUID: reservation, CreatedAt: reservedAt},
+		}, nil)
+
+		svc := NewService(repo, slog.Default())
+
+		serial, err := svc.GetSerial(context.Background(), "phone", "IMEI-1")
+		require.NoError(t, err)
+		assert.Equal(t, schemas.Serial{
+			Article:       "phone",
+			SerialNumber:  "IMEI-1",
+			WarehouseUUID: warehouse,
+			Status:        models.SerialReserved,
+			ReservationID: reservation,
+			UpdatedAt:     reservedAt,
+		}, serial)
+
+		history, err := svc.GetSerialHistory(context.Background(), "phone", "IMEI-1")
+		require.NoError(t, err)
+		assert.Equal(t, []schemas.SerialEvent{
+			{Status: models.SerialAvailable, WarehouseUUID: warehouse, CreatedAt: receivedAt},
+			{Status: models.SerialReserved, WarehouseUUID: warehouse, ReservationID: reservation, CreatedAt: reservedAt},
+		}, history)
+	})
+
+	t.Run("forbidden warehouse", func(t *testing.T) {
+		ctx := auth.WithPrincipal(context.Background(), auth.Principal{
+			Subject:    "billing",
+			Scopes:     []string{auth.ScopeStockRead},
+			Warehouses: []string{"a00518e4-be6e-4eb7-9f95-bb52cc8b8548"},
+		})
+
+		svc := NewService(newRepo(t), slog.Default())
+
+		_, err := svc.GetSerialHistory(ctx, "phone", "IMEI-1")
+		assert.ErrorIs(t, err, models.ErrWarehouseAccessDenied)
+	})
+
+	t.Run("unknown serial", func(t *testing.T) {
+		repo := mocks.NewRepository(t)
+		repo.On("GetSerial", "phone", "IMEI-2").Return(models.ProductSerial{}, models.ErrSerialNotFound)
+
+		svc := NewService(repo, slog.Default())
+
+		_, err := svc.GetSerialHistory(context.Background(), "phone", "IMEI-2")
+		assert.ErrorIs(t, err, models.ErrSerialNotFound)
+	})
+}
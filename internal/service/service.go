@@ -1,26 +1,68 @@
 package service
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"github.com/shamank/warehouse-service/internal/domain/models"
 	"github.com/shamank/warehouse-service/internal/domain/schemas"
 	"github.com/shamank/warehouse-service/internal/handler"
 	"log/slog"
+	"slices"
+	"strings"
 	"sync"
+	"time"
 )
 
 var (
-	ErrNotEnoughProducts = errors.New("not enough products in warehouses")
+	ErrNotEnoughProducts = models.ErrNotEnoughProducts
 )
 
 var _ handler.Service = (*Service)(nil)
 
 //go:generate mockery --name=Repository
 type Repository interface {
-	GetRemainingProductsByWarehouse(warehouseUUID string) ([]models.Product, error)
+	GetRemainingProductsByWarehouse(warehouseUUID string, filter schemas.RemainingProductsFilter) ([]models.Product, int, error)
 	GetProductsQuantity(productArticle string) ([]models.WarehouseProduct, error)
 	ReserveProducts(products []schemas.ProductWarehouseSplitted) error
 	ReleaseProducts(productsWithSplit []schemas.ProductWarehouseSplitted) error
+	GetProductsStock(articles []string, warehouseUUIDs []string) ([]models.ProductStock, error)
+	GetWarehouse(warehouseUUID string) (models.Warehouse, error)
+	GetProductsByArticles(articles []string) ([]models.Product, error)
+	CreateReservation(reservationUUID string, products []schemas.ProductWarehouseSplitted) error
+	GetReservation(reservationUUID string) (models.Reservation, error)
+	ReleaseReservation(reservationUUID string, products []schemas.ProductWarehouseSplitted, status string) error
+	ReceiveProducts(warehouseUUID string, items []models.ReceiptItem) error
+	GetLotsStock(warehouseUUID string, articles []string) ([]models.LotStock, error)
+	GetSerial(productArticle string, serialNumber string) (models.ProductSerial, error)
+	GetSerialHistory(productArticle string, serialNumber string) ([]models.SerialEvent, error)
+	CreateLocation(location models.Location) error
+	GetLocation(warehouseUUID string, locationUUID string) (models.Location, error)
+	GetLocations(warehouseUUID string) ([]models.Location, error)
+	GetBinsStock(warehouseUUID string, articles []string) ([]models.BinStock, error)
+	MoveStock(warehouseUUID string, moves []models.StockMove) error
+	GetBucketsStock(articles []string, warehouseUUIDs []string) ([]models.BucketStock, error)
+	MoveBucketStock(warehouseUUID string, moves []models.BucketMove) error
+	SaveKit(kit models.Kit) error
+	GetKits(articles []string) ([]models.Kit, error)
+	DeleteKit(article string) error
+	SaveSubstitutes(article string, substitutes []models.Substitute) error
+	GetSubstitutes(article string) ([]models.Substitute, error)
+	CreatePickList(pickList models.PickList) error
+	GetPickList(pickListUUID string) (models.PickList, error)
+	ConfirmPickLine(pickListUUID string, lineNo int, pickedQuantity int, reallocation []schemas.WarehouseCounter) error
+	CreateAdjustment(adjustment models.Adjustment) error
+	GetAdjustment(adjustmentUUID string) (models.Adjustment, error)
+	GetAdjustments(warehouseUUID string, status string) ([]models.Adjustment, error)
+	ReviewAdjustment(adjustmentUUID string, status string, reviewedBy string, reviewedAt time.Time) error
+	CreateStocktake(stocktake models.Stocktake) error
+	GetStocktake(stocktakeUUID string) (models.Stocktake, error)
+	CountStocktake(stocktakeUUID string, counts []models.StocktakeCount, countedAt time.Time) error
+	CloseStocktake(stocktake models.Stocktake, adjustments []models.Adjustment) error
+	CreateReturn(ret models.Return) error
+	GetReturn(returnUUID string) (models.Return, error)
+	ReceiveReturn(returnUUID string, receivedBy string, receivedAt time.Time) error
+	InspectReturn(returnUUID string, outcome string, comment string, inspectedBy string, inspectedAt time.Time) error
 	//ReserveProduct(productArticle string, warehouseUUID string, quantity int) error
 }
 
@@ -28,6 +70,8 @@ type Service struct {
 	repo   Repository
 	logger *slog.Logger
 	mx     *sync.Mutex
+	// adjustmentThreshold - корректировки больше этого количества ждут подтверждения, 0 - не ждут
+	adjustmentThreshold int
 }
 
 func NewService(repo Repository, logger *slog.Logger) *Service {
@@ -38,11 +82,94 @@ func NewService(repo Repository, logger *slog.Logger) *Service {
 	}
 }
 
-func (s *Service) GetRemainingProducts(warehouseUUID string) ([]schemas.Product, error) {
-	products, err := s.repo.GetRemainingProductsByWarehouse(warehouseUUID)
+// DefaultRemainingProductsLimit - размер страницы остатков, если запрошена следующая страница без limit
+const DefaultRemainingProductsLimit = 50
+
+// GetRemainingProducts возвращает страницу остатков на складе, с filter.ByLot - вместе с остатками партий,
+// с filter.ByLocation - вместе с остатками ячеек. Без limit и курсора возвращаются все остатки, как до появления страниц.
+// Для неизвестного склада возвращается models.ErrWarehouseNotFound
+func (s *Service) GetRemainingProducts(ctx context.Context, warehouseUUID string, filter schemas.RemainingProductsFilter) (schemas.RemainingProducts, error) {
+	// доступ проверяется до поиска склада, чтобы клиент не узнавал о существовании чужих складов
+	if err := checkWarehouseAccess(ctx, warehouseUUID); err != nil {
+		return schemas.RemainingProducts{}, err
+	}
+
+	warehouse, err := s.repo.GetWarehouse(warehouseUUID)
+	if err != nil {
+		return schemas.RemainingProducts{}, err
+	}
+
+	if filter.Sort == "" {
+		filter.Sort = schemas.SortByArticle
+	}
+
+	limit := filter.Limit
+	if limit <= 0 && filter.After != nil {
+		limit = DefaultRemainingProductsLimit
+	}
+
+	// запрашиваем на один товар больше, чтобы понять, есть ли следующая страница
+	if limit > 0 {
+		filter.Limit = limit + 1
+	}
+
+	products, total, err := s.repo.GetRemainingProductsByWarehouse(warehouseUUID, filter)
 	if err != nil {
 		// TODO: обработка ошибки
-		return nil, err
+		return schemas.RemainingProducts{}, err
+	}
+
+	var nextCursor *string
+	if limit > 0 && len(products) > limit {
+		products = products[:limit]
+
+		last := products[limit-1]
+		cursor := schemas.ProductCursor{
+			Sort:    filter.Sort,
+			Desc:    filter.Desc,
+			Article: last.Code,
+		}
+		switch filter.Sort {
+		case schemas.SortByName:
+			cursor.Name = last.Name
+		case schemas.SortByQuantity:
+			cursor.Quantity = last.Quantity
+		}
+
+		encoded := cursor.Encode()
+		nextCursor = &encoded
+	}
+
+	articles := make([]string, len(products))
+	for i, product := range products {
+		articles[i] = product.Code
+	}
+
+	var lots map[string][]schemas.ProductLot
+	if filter.ByLot && len(products) > 0 {
+		stocks, err := s.repo.GetLotsStock(warehouseUUID, articles)
+		if err != nil {
+			return schemas.RemainingProducts{}, err
+		}
+		lots = productLots(stocks, time.Now().UTC())
+	}
+
+	var bins map[string][]schemas.ProductBin
+	if filter.ByLocation && len(products) > 0 {
+		stocks, err := s.repo.GetBinsStock(warehouseUUID, articles)
+		if err != nil {
+			return schemas.RemainingProducts{}, err
+		}
+		bins = productBins(stocks)
+	}
+
+	var buckets map[[2]string]map[string]int
+	if len(products) > 0 {
+		stocks, err := s.repo.GetBucketsStock(articles, []string{warehouseUUID})
+		if err != nil {
+			return schemas.RemainingProducts{}, err
+		}
+		buckets = productBuckets(stocks)
 	}
 
 	result := make([]schemas.Product, len(products))
@@ -53,18 +180,141 @@ func (s *Service) GetRemainingProducts(warehouseUUID string) ([]schemas.Product,
 			Size:     product.Size,
 			Code:     product.Code,
 			Quantity: product.Quantity,
+			Reserved: product.ReservedQuantity,
+			OnHand:   product.Quantity + product.ReservedQuantity,
+			Expired:  product.ExpiredQuantity,
+			Lots:     lots[product.Code],
+			Bins:     bins[product.Code],
+			Buckets:  buckets[[2]string{product.Code, warehouseUUID}],
+		}
+
+		// товар на недоступном складе и просроченный товар нельзя продать
+		if warehouse.Availability {
+			result[i].Available = product.Quantity - product.ExpiredQuantity
+		}
+	}
+
+	return schemas.RemainingProducts{
+		Warehouse: schemas.WarehouseInfo{
+			UUID:        warehouse.UUID,
+			Name:        warehouse.Name,
+			IsAvailable: warehouse.Availability,
+		},
+		Items:      result,
+		NextCursor: nextCursor,
+		Total:      total,
+	}, nil
+}
+
+// GetRemainingProductsBatch возвращает остатки по списку товаров и/или складов.
+// Суммарные остатки считаются только по доступным складам.
+// Запрошенные товары, которых нет ни на одном складе, возвращаются с нулевыми остатками.
+// Клиенту с ограничением по складам без списка складов отдаются только его склады
+func (s *Service) GetRemainingProductsBatch(ctx context.Context, query schemas.ProductsStockQuery) ([]schemas.ProductStock, error) {
+	if err := checkWarehouseAccess(ctx, query.Warehouses...); err != nil {
+		return nil, err
+	}
+	if len(query.Warehouses) == 0 {
+		query.Warehouses = clientWarehouses(ctx)
+	}
+
+	kits, err := s.repo.GetKits(query.Articles)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]schemas.ProductStock, 0)
+	productIndex := make(map[string]int)
+
+	// у набора нет собственного остатка, его доступность считается по компонентам. Остатки компонентов
+	// читаются тем же запросом, что и остатки товаров, но в отчет попадают, только если их запросили
+	articles := query.Articles
+	for _, kit := range kits {
+		productIndex[kit.ProductArticle] = -1
+	}
+	if len(query.Articles) > 0 {
+		articles = slices.Clone(query.Articles)
+		for _, component := range kitComponents(kits) {
+			if !slices.Contains(articles, component) {
+				articles = append(articles, component)
+				productIndex[component] = -1
+			}
+		}
+	}
+
+	stocks, err := s.repo.GetProductsStock(articles, query.Warehouses)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, stock := range stocks {
+		i, ok := productIndex[stock.ProductArticle]
+		if i < 0 {
+			continue
+		}
+		if !ok {
+			i = len(result)
+			productIndex[stock.ProductArticle] = i
+			result = append(result, schemas.ProductStock{
+				Name:       stock.ProductName,
+				Size:       stock.ProductSize,
+				Code:       stock.ProductArticle,
+				Warehouses: make([]schemas.WarehouseStock, 0),
+			})
+		}
+
+		product := &result[i]
+		product.Warehouses = append(product.Warehouses, schemas.WarehouseStock{
+			WarehouseUUID: stock.WarehouseUUID,
+			IsAvailable:   stock.WarehouseAvailability,
+			Available:     stock.Quantity - stock.ExpiredQuantity,
+			Reserved:      stock.ReservedQuantity,
+			Expired:       stock.ExpiredQuantity,
+			Buckets:       stock.Buckets,
+		})
+
+		if stock.WarehouseAvailability {
+			product.Available += stock.Quantity - stock.ExpiredQuantity
+			product.Reserved += stock.ReservedQuantity
+		}
+	}
+
+	for _, kitStock := range kitStocks(kits, stocks) {
+		// без списка артикулов показываются только наборы, которые можно собрать хотя бы на одном складе
+		if len(query.Articles) == 0 && len(kitStock.Warehouses) == 0 {
+			continue
+		}
+		productIndex[kitStock.Code] = len(result)
+		result = append(result, kitStock)
+	}
+
+	for _, article := range query.Articles {
+		if _, ok := productIndex[article]; !ok {
+			productIndex[article] = len(result)
+			result = append(result, schemas.ProductStock{
+				Code:       article,
+				Warehouses: make([]schemas.WarehouseStock, 0),
+			})
 		}
 	}
 
 	return result, nil
 }
 
-func (s *Service) processProducts(productsToProcess []string, isRelease bool) ([]schemas.ProductWarehouseSplitted, error) {
+// processProducts распределяет товары по складам, используя только allowedWarehouses, если список не пустой
+func (s *Service) processProducts(productsToProcess []string, isRelease bool, allowedWarehouses []string) ([]schemas.ProductWarehouseSplitted, error) {
 	products := s.getProductWithCounts(productsToProcess)
 	productsWithSplit := make([]schemas.ProductWarehouseSplitted, 0)
 
-	for product, quantity := range products {
-		warehouseData, err := s.processProduct(product, quantity, isRelease)
+	// товары обрабатываются в порядке первого упоминания в запросе, а не в порядке обхода map
+	for _, product := range productsToProcess {
+		quantity, ok := products[product]
+		if !ok {
+			continue
+		}
+		delete(products, product)
+
+		warehouseData, err := s.processProduct(product, quantity, isRelease, allowedWarehouses)
 		if err != nil {
 			return nil, err
 		}
@@ -78,7 +328,9 @@ func (s *Service) processProducts(productsToProcess []string, isRelease bool) ([
 	return productsWithSplit, nil
 }
 
-func (s *Service) processProduct(product string, quantity int, isRelease bool) ([]schemas.WarehouseCounter, error) {
+// processProduct распределяет quantity товара по складам.
+// Если allowedWarehouses не пустой, используются только перечисленные склады
+func (s *Service) processProduct(product string, quantity int, isRelease bool, allowedWarehouses []string) ([]schemas.WarehouseCounter, error) {
 	warehouseData := make([]schemas.WarehouseCounter, 0)
 
 	productInWarehouses, err := s.repo.GetProductsQuantity(product)
@@ -87,6 +339,9 @@ func (s *Service) processProduct(product string, quantity int, isRelease bool) (
 	}
 
 	for _, warehouseProduct := range productInWarehouses {
+		if len(allowedWarehouses) > 0 && !slices.Contains(allowedWarehouses, warehouseProduct.WarehouseUUID) {
+			continue
+		}
 
 		warehouseQuantity := warehouseProduct.Quantity
 		if isRelease {
@@ -115,16 +370,21 @@ func (s *Service) processProduct(product string, quantity int, isRelease bool) (
 	return warehouseData, nil
 }
 
-func (s *Service) ReserveProducts(productsToReserve []string) error {
+func (s *Service) ReserveProducts(ctx context.Context, productsToReserve []string) error {
 	// т.к. во время выполнения операции может быть одновременно много запросов,
 	// то для предотвращения ситуации, при которой product.quantity может уйти в минус
 	// необходимо использовать мьютекс
 	s.mx.Lock()
 	defer s.mx.Unlock()
 
-	productsWithSplit, err := s.processProducts(productsToReserve, false)
+	productsToReserve, err := s.expandKits(productsToReserve)
 	if err != nil {
-		s.logger.Error("error processing products", err)
+		return err
+	}
+
+	productsWithSplit, err := s.processProducts(productsToReserve, false, clientWarehouses(ctx))
+	if err != nil {
+		s.logger.Error("error processing products", "error", err)
 		return err
 	}
 
@@ -132,14 +392,151 @@ func (s *Service) ReserveProducts(productsToReserve []string) error {
 	return err
 }
 
+// ReserveItems резервирует товары в указанных количествах с учетом ограничений по складам.
+// Для неизвестных артикулов возвращается models.ErrProductNotFound. Замены не поддерживаются:
+// резерв без id не может сообщить, какой товар зарезервирован вместо заказанного
+func (s *Service) ReserveItems(ctx context.Context, items []schemas.ReserveItem) error {
+	for _, item := range items {
+		if item.AllowSubstitutes {
+			return fmt.Errorf("%w: substitutes are only reserved by reservations with id", models.ErrInvalidSubstitute)
+		}
+	}
+
+	if err := s.validateItems(ctx, items); err != nil {
+		return err
+	}
+
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	items, err := s.expandKitItems(items)
+	if err != nil {
+		return err
+	}
+
+	productsWithSplit, err := s.processItems(items, clientWarehouses(ctx))
+	if err != nil {
+		return err
+	}
+
+	return s.repo.ReserveProducts(productsWithSplit)
+}
+
+// validateItems проверяет количества и повторы артикулов в позициях, доступ клиента к указанным складам
+// и наличие товаров в каталоге
+func (s *Service) validateItems(ctx context.Context, items []schemas.ReserveItem) error {
+	articles := make([]string, 0, len(items))
+	for _, item := range items {
+		if item.Quantity <= 0 {
+			return fmt.Errorf("%w: %s", models.ErrInvalidQuantity, item.Article)
+		}
+		// количество по складам считается от остатков до резервирования,
+		// поэтому два распределения одного товара могут занять одни и те же штуки
+		if slices.Contains(articles, item.Article) {
+			return fmt.Errorf("%w: %s", models.ErrDuplicateArticle, item.Article)
+		}
+		articles = append(articles, item.Article)
+
+		if item.WarehouseUUID != "" {
+			if err := checkWarehouseAccess(ctx, item.WarehouseUUID); err != nil {
+				return err
+			}
+		}
+		if err := checkWarehouseAccess(ctx, item.AllowedWarehouses...); err != nil {
+			return err
+		}
+	}
+
+	return s.checkArticles(articles)
+}
+
+// processItems распределяет позиции по складам с учетом ограничений позиций, а без них - по clientWarehouses.
+// Позиция с AllowSubstitutes, которой не хватает товара, резервируется заменой, см. substituteItem.
+// Склады из позиций уже проверены validateItems и входят в clientWarehouses. Вызывается под s.mx
+func (s *Service) processItems(items []schemas.ReserveItem, clientWarehouses []string) ([]schemas.ProductWarehouseSplitted, error) {
+	productsWithSplit := make([]schemas.ProductWarehouseSplitted, 0, len(items))
+
+	// товары позиций и уже выбранные замены: по той же причине, что и повтор артикула в validateItems,
+	// они не могут быть заменой
+	taken := make([]string, len(items))
+	for i, item := range items {
+		taken[i] = item.Article
+	}
+
+	for _, item := range items {
+		allowedWarehouses := clientWarehouses
+		if item.WarehouseUUID != "" {
+			allowedWarehouses = []string{item.WarehouseUUID}
+		} else if len(item.AllowedWarehouses) > 0 {
+			allowedWarehouses = item.AllowedWarehouses
+		}
+
+		article, substituteFor := item.Article, ""
+		warehouseData, err := s.processProduct(item.Article, item.Quantity, false, allowedWarehouses)
+		if errors.Is(err, ErrNotEnoughProducts) && item.AllowSubstitutes {
+			article, warehouseData, err = s.substituteItem(item, allowedWarehouses, taken)
+			substituteFor = item.Article
+			taken = append(taken, article)
+		}
+		if err != nil {
+			s.logger.Error("error processing products", "error", err)
+			return nil, err
+		}
+
+		productsWithSplit = append(productsWithSplit, schemas.ProductWarehouseSplitted{
+			ProductArticle: article,
+			WarehouseData:  warehouseData,
+			SubstituteFor:  substituteFor,
+		})
+	}
+
+	return productsWithSplit, nil
+}
+
+// checkArticles возвращает models.ErrProductNotFound со списком артикулов, которых нет в каталоге
+func (s *Service) checkArticles(articles []string) error {
+	_, err := s.productsByArticle(articles)
+	return err
+}
+
+// productsByArticle возвращает товары каталога по артикулам или models.ErrProductNotFound, как checkArticles
+func (s *Service) productsByArticle(articles []string) (map[string]models.Product, error) {
+	products, err := s.repo.GetProductsByArticles(articles)
+	if err != nil {
+		return nil, err
+	}
+
+	known := make(map[string]models.Product, len(products))
+	for _, product := range products {
+		known[product.Code] = product
+	}
+
+	unknown := make([]string, 0)
+	for _, article := range articles {
+		if _, ok := known[article]; !ok {
+			unknown = append(unknown, article)
+		}
+	}
+
+	if len(unknown) > 0 {
+		return nil, fmt.Errorf("%w: %s", models.ErrProductNotFound, strings.Join(unknown, ", "))
+	}
+	return known, nil
+}
+
 // ReleaseProducts releases products based on the given condition
-func (s *Service) ReleaseProducts(productsToRelease []string) error {
+func (s *Service) ReleaseProducts(ctx context.Context, productsToRelease []string) error {
 	s.mx.Lock()
 	defer s.mx.Unlock()
 
-	productsWithSplit, err := s.processProducts(productsToRelease, true)
+	productsToRelease, err := s.expandKits(productsToRelease)
+	if err != nil {
+		return err
+	}
+
+	productsWithSplit, err := s.processProducts(productsToRelease, true, clientWarehouses(ctx))
 	if err != nil {
-		s.logger.Error("error processing products", err)
+		s.logger.Error("error processing products", "error", err)
 		return err
 	}
 
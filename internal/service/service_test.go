@@ -1,82 +1,284 @@
 package service
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"github.com/shamank/warehouse-service/internal/domain/models"
 	"github.com/shamank/warehouse-service/internal/domain/schemas"
 	"github.com/shamank/warehouse-service/internal/service/mocks"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"log/slog"
 	"testing"
 )
 
 func TestService_GetRemainingProducts(t *testing.T) {
 	type Args struct {
-		input  string
-		output []models.Product
-		error  error
+		warehouse      models.Warehouse
+		warehouseError error
+		input          schemas.RemainingProductsFilter
+		output         []models.Product
+		total          int
+		error          error
+		buckets        []models.BucketStock
 	}
 
 	type TestCase struct {
 		warehouseUUID   string
+		filter          schemas.RemainingProductsFilter
 		args            Args
 		expectedError   error
-		excepctedResult []schemas.Product
+		excepctedResult schemas.RemainingProducts
 	}
 
+	warehouse := models.Warehouse{UUID: "uuid", Name: "warehouse", Availability: true}
+	warehouseInfo := schemas.WarehouseInfo{UUID: "uuid", Name: "warehouse", IsAvailable: true}
+
+	nextCursor := schemas.ProductCursor{Sort: schemas.SortByQuantity, Desc: true, Article: "asd-xsdad", Quantity: 3}.Encode()
+
 	testCases := []TestCase{
 		{
 			warehouseUUID: "uuid",
 			expectedError: nil,
 			args: Args{
-				input: "uuid",
+				warehouse: warehouse,
+				input:     schemas.RemainingProductsFilter{Sort: schemas.SortByArticle},
 				output: []models.Product{
 					{
 
-						UUID:     "94df01f6-4ead-482a-8bd5-84ee7e1aef57",
-						Name:     "nike",
-						Size:     "XL",
-						Code:     "asd-xsdad",
-						Quantity: 1,
+						UUID:             "94df01f6-4ead-482a-8bd5-84ee7e1aef57",
+						Name:             "nike",
+						Size:             "XL",
+						Code:             "asd-xsdad",
+						Quantity:         1,
+						ReservedQuantity: 2,
 					},
 				},
+				total: 1,
 				error: nil,
+				buckets: []models.BucketStock{
+					{ProductArticle: "asd-xsdad", WarehouseUUID: "uuid", Bucket: models.BucketDamaged, Quantity: 2},
+				},
 			},
-			excepctedResult: []schemas.Product{
-				{
-					Name:     "nike",
-					Size:     "XL",
-					Code:     "asd-xsdad",
-					Quantity: 1,
+			excepctedResult: schemas.RemainingProducts{
+				Warehouse: warehouseInfo,
+				Items: []schemas.Product{
+					{
+						Name:      "nike",
+						Size:      "XL",
+						Code:      "asd-xsdad",
+						Quantity:  1,
+						Available: 1,
+						Reserved:  2,
+						OnHand:    3,
+						Buckets:   map[string]int{models.BucketDamaged: 2},
+					},
+				},
+				Total: 1,
+			},
+		},
+		{
+			warehouseUUID: "uuid",
+			filter:        schemas.RemainingProductsFilter{Sort: schemas.SortByQuantity, Desc: true, Limit: 1},
+			expectedError: nil,
+			args: Args{
+				warehouse: warehouse,
+				input:     schemas.RemainingProductsFilter{Sort: schemas.SortByQuantity, Desc: true, Limit: 2},
+				output: []models.Product{
+					{Name: "nike", Size: "XL", Code: "asd-xsdad", Quantity: 3},
+					{Name: "adidas", Size: "L", Code: "qwe-zxc", Quantity: 2},
+				},
+				total: 5,
+				error: nil,
+			},
+			excepctedResult: schemas.RemainingProducts{
+				Warehouse: warehouseInfo,
+				Items: []schemas.Product{
+					{Name: "nike", Size: "XL", Code: "asd-xsdad", Quantity: 3, Available: 3, OnHand: 3},
 				},
+				NextCursor: &nextCursor,
+				Total:      5,
 			},
 		},
+		{
+			// следующая страница без limit отдается страницей по умолчанию
+			warehouseUUID: "uuid",
+			filter:        schemas.RemainingProductsFilter{Sort: schemas.SortByArticle, After: &schemas.ProductCursor{Sort: schemas.SortByArticle, Article: "asd"}},
+			expectedError: nil,
+			args: Args{
+				warehouse: warehouse,
+				input: schemas.RemainingProductsFilter{
+					Sort:  schemas.SortByArticle,
+					Limit: DefaultRemainingProductsLimit + 1,
+					After: &schemas.ProductCursor{Sort: schemas.SortByArticle, Article: "asd"},
+				},
+				output: []models.Product{},
+				total:  1,
+			},
+			excepctedResult: schemas.RemainingProducts{
+				Warehouse: warehouseInfo,
+				Items:     []schemas.Product{},
+				Total:     1,
+			},
+		},
+		{
+			// остатки недоступного склада нельзя продать
+			warehouseUUID: "uuid",
+			expectedError: nil,
+			args: Args{
+				warehouse: models.Warehouse{UUID: "uuid", Name: "warehouse", Availability: false},
+				input:     schemas.RemainingProductsFilter{Sort: schemas.SortByArticle},
+				output: []models.Product{
+					{Name: "nike", Size: "XL", Code: "asd-xsdad", Quantity: 4, ReservedQuantity: 1},
+				},
+				total: 1,
+			},
+			excepctedResult: schemas.RemainingProducts{
+				Warehouse: schemas.WarehouseInfo{UUID: "uuid", Name: "warehouse", IsAvailable: false},
+				Items: []schemas.Product{
+					{Name: "nike", Size: "XL", Code: "asd-xsdad", Quantity: 4, Available: 0, Reserved: 1, OnHand: 5},
+				},
+				Total: 1,
+			},
+		},
+		{
+			warehouseUUID: "unknown",
+			expectedError: models.ErrWarehouseNotFound,
+			args: Args{
+				warehouseError: models.ErrWarehouseNotFound,
+			},
+			excepctedResult: schemas.RemainingProducts{},
+		},
 		{
 			warehouseUUID: "uuid",
 			expectedError: errors.New("some error"),
 			args: Args{
-				input:  "uuid",
-				output: nil,
-				error:  errors.New("some error"),
+				warehouse: warehouse,
+				input:     schemas.RemainingProductsFilter{Sort: schemas.SortByArticle},
+				output:    nil,
+				error:     errors.New("some error"),
 			},
-			excepctedResult: nil,
+			excepctedResult: schemas.RemainingProducts{},
 		},
 	}
 
 	for _, testCase := range testCases {
 		repo := mocks.NewRepository(t)
 
-		repo.On("GetRemainingProductsByWarehouse", testCase.args.input).Return(testCase.args.output, testCase.args.error)
+		repo.On("GetWarehouse", testCase.warehouseUUID).Return(testCase.args.warehouse, testCase.args.warehouseError)
+		repo.On("GetRemainingProductsByWarehouse", testCase.warehouseUUID, testCase.args.input).Return(testCase.args.output, testCase.args.total, testCase.args.error).Maybe()
+		repo.On("GetBucketsStock", mock.Anything, []string{testCase.warehouseUUID}).Return(testCase.args.buckets, nil).Maybe()
 
 		svc := NewService(repo, slog.Default())
 
-		result, err := svc.GetRemainingProducts(testCase.warehouseUUID)
+		result, err := svc.GetRemainingProducts(context.Background(), testCase.warehouseUUID, testCase.filter)
 
 		assert.Equal(t, err, testCase.expectedError)
 		assert.Equal(t, result, testCase.excepctedResult)
 	}
 }
 
+func TestService_GetRemainingProductsBatch(t *testing.T) {
+	type Args struct {
+		articles   []string
+		warehouses []string
+		output     []models.ProductStock
+		error      error
+	}
+
+	type TestCase struct {
+		query          schemas.ProductsStockQuery
+		args           Args
+		expectedError  error
+		expectedResult []schemas.ProductStock
+	}
+
+	testCases := []TestCase{
+		{
+			query: schemas.ProductsStockQuery{
+				Articles: []string{"asd-xsdad", "missing"},
+			},
+			args: Args{
+				articles: []string{"asd-xsdad", "missing"},
+				output: []models.ProductStock{
+					{
+						ProductArticle:        "asd-xsdad",
+						ProductName:           "nike",
+						ProductSize:           "XL",
+						WarehouseUUID:         "d10c8d17-6d15-445e-b643-6affa59aa26c",
+						WarehouseAvailability: true,
+						Quantity:              3,
+						ReservedQuantity:      1,
+					},
+					{
+						ProductArticle:        "asd-xsdad",
+						ProductName:           "nike",
+						ProductSize:           "XL",
+						WarehouseUUID:         "a00518e4-be6e-4eb7-9f95-bb52cc8b8548",
+						WarehouseAvailability: false,
+						Quantity:              10,
+						ReservedQuantity:      2,
+					},
+					{
+						ProductArticle:        "asd-xsdad",
+						ProductName:           "nike",
+						ProductSize:           "XL",
+						WarehouseUUID:         "233ef39e-bdea-41dc-a5a2-31c8f0e29d6e",
+						WarehouseAvailability: true,
+						Quantity:              4,
+						ReservedQuantity:      0,
+						Buckets:               map[string]int{models.BucketQuarantine: 5},
+					},
+				},
+			},
+			expectedResult: []schemas.ProductStock{
+				{
+					Name:      "nike",
+					Size:      "XL",
+					Code:      "asd-xsdad",
+					Available: 7,
+					Reserved:  1,
+					Warehouses: []schemas.WarehouseStock{
+						{WarehouseUUID: "d10c8d17-6d15-445e-b643-6affa59aa26c", IsAvailable: true, Available: 3, Reserved: 1},
+						{WarehouseUUID: "a00518e4-be6e-4eb7-9f95-bb52cc8b8548", IsAvailable: false, Available: 10, Reserved: 2},
+						{WarehouseUUID: "233ef39e-bdea-41dc-a5a2-31c8f0e29d6e", IsAvailable: true, Available: 4, Reserved: 0,
+							Buckets: map[string]int{models.BucketQuarantine: 5}},
+					},
+				},
+				{
+					Code:       "missing",
+					Warehouses: []schemas.WarehouseStock{},
+				},
+			},
+		},
+		{
+			query: schemas.ProductsStockQuery{
+				Warehouses: []string{"d10c8d17-6d15-445e-b643-6affa59aa26c"},
+			},
+			args: Args{
+				warehouses: []string{"d10c8d17-6d15-445e-b643-6affa59aa26c"},
+				error:      errors.New("some error"),
+			},
+			expectedError: errors.New("some error"),
+		},
+	}
+
+	for _, testCase := range testCases {
+		repo := mocks.NewRepository(t)
+		repo.On("GetKits", mock.Anything).Return([]models.Kit{}, nil).Maybe()
+
+		repo.On("GetProductsStock", testCase.args.articles, testCase.args.warehouses).Return(testCase.args.output, testCase.args.error)
+
+		svc := NewService(repo, slog.Default())
+
+		result, err := svc.GetRemainingProductsBatch(context.Background(), testCase.query)
+
+		assert.Equal(t, testCase.expectedError, err)
+		assert.Equal(t, testCase.expectedResult, result)
+	}
+}
+
 func TestService_ReserveProducts(t *testing.T) {
 	type productQuantityArgs struct {
 		input               string
@@ -181,7 +383,9 @@ func TestService_ReserveProducts(t *testing.T) {
 	}
 
 	repo1 := mocks.NewRepository(t)
+	repo1.On("GetKits", mock.Anything).Return([]models.Kit{}, nil).Maybe()
 	repo2 := mocks.NewRepository(t)
+	repo2.On("GetKits", mock.Anything).Return([]models.Kit{}, nil).Maybe()
 
 	for _, productQuantityArgs := range testCase1.productQuantityArgs {
 		repo1.On("GetProductsQuantity", productQuantityArgs.input).Maybe().Return(productQuantityArgs.productInWarehouses, productQuantityArgs.error)
@@ -196,13 +400,105 @@ func TestService_ReserveProducts(t *testing.T) {
 	svc1 := NewService(repo1, slog.Default())
 	svc2 := NewService(repo2, slog.Default())
 
-	err := svc1.ReserveProducts(testCase1.productsToReserve)
+	err := svc1.ReserveProducts(context.Background(), testCase1.productsToReserve)
 	assert.Equal(t, err, testCase1.expectedError)
 
-	err = svc2.ReserveProducts(testCase2.productsToReserve)
+	err = svc2.ReserveProducts(context.Background(), testCase2.productsToReserve)
 	assert.Equal(t, err, testCase2.expectedError)
 }
 
+func TestService_ReserveItems(t *testing.T) {
+	const (
+		warehouse1 = "d10c8d17-6d15-445e-b643-6affa59aa26c"
+		warehouse2 = "a00518e4-be6e-4eb7-9f95-bb52cc8b8548"
+	)
+
+	productInWarehouses := []models.WarehouseProduct{
+		{WarehouseUUID: warehouse1, Quantity: 300},
+		{WarehouseUUID: warehouse2, Quantity: 300},
+	}
+
+	testCases := []struct {
+		name            string
+		items           []schemas.ReserveItem
+		knownProducts   []models.Product
+		expectedReserve []schemas.ProductWarehouseSplitted
+		expectedError   error
+	}{
+		{
+			name:          "quantity split between warehouses",
+			items:         []schemas.ReserveItem{{Article: "product1", Quantity: 500}},
+			knownProducts: []models.Product{{Code: "product1"}},
+			expectedReserve: []schemas.ProductWarehouseSplitted{
+				{
+					ProductArticle: "product1",
+					WarehouseData: []schemas.WarehouseCounter{
+						{WarehouseUUID: warehouse1, Count: 300},
+						{WarehouseUUID: warehouse2, Count: 200},
+					},
+				},
+			},
+		},
+		{
+			name: "warehouse constraints",
+			items: []schemas.ReserveItem{
+				{Article: "product1", Quantity: 10, WarehouseUUID: warehouse2},
+				{Article: "product2", Quantity: 20, AllowedWarehouses: []string{warehouse2}},
+			},
+			knownProducts: []models.Product{{Code: "product1"}, {Code: "product2"}},
+			expectedReserve: []schemas.ProductWarehouseSplitted{
+				{
+					ProductArticle: "product1",
+					WarehouseData:  []schemas.WarehouseCounter{{WarehouseUUID: warehouse2, Count: 10}},
+				},
+				{
+					ProductArticle: "product2",
+					WarehouseData:  []schemas.WarehouseCounter{{WarehouseUUID: warehouse2, Count: 20}},
+				},
+			},
+		},
+		{
+			name:          "not enough in allowed warehouse",
+			items:         []schemas.ReserveItem{{Article: "product1", Quantity: 301, WarehouseUUID: warehouse1}},
+			knownProducts: []models.Product{{Code: "product1"}},
+			expectedError: ErrNotEnoughProducts,
+		},
+		{
+			name:          "unknown articles",
+			items:         []schemas.ReserveItem{{Article: "product1", Quantity: 1}, {Article: "x", Quantity: 1}, {Article: "y", Quantity: 1}},
+			knownProducts: []models.Product{{Code: "product1"}},
+			expectedError: fmt.Errorf("%w: x, y", models.ErrProductNotFound),
+		},
+		{
+			name:          "non-positive quantity",
+			items:         []schemas.ReserveItem{{Article: "product1", Quantity: 0}},
+			expectedError: fmt.Errorf("%w: product1", models.ErrInvalidQuantity),
+		},
+		{
+			name:          "duplicate article",
+			items:         []schemas.ReserveItem{{Article: "product1", Quantity: 1}, {Article: "product1", Quantity: 2}},
+			expectedError: fmt.Errorf("%w: product1", models.ErrDuplicateArticle),
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			repo := mocks.NewRepository(t)
+			repo.On("GetKits", mock.Anything).Return([]models.Kit{}, nil).Maybe()
+			repo.On("GetProductsByArticles", mock.Anything).Return(testCase.knownProducts, nil).Maybe()
+			repo.On("GetProductsQuantity", mock.Anything).Return(productInWarehouses, nil).Maybe()
+			if testCase.expectedReserve != nil {
+				repo.On("ReserveProducts", testCase.expectedReserve).Once().Return(nil)
+			}
+
+			svc := NewService(repo, slog.Default())
+
+			err := svc.ReserveItems(context.Background(), testCase.items)
+			assert.Equal(t, testCase.expectedError, err)
+		})
+	}
+}
+
 func TestService_ReleaseProducts(t *testing.T) {
 	type productQuantityArgs struct {
 		input               string
@@ -312,7 +608,9 @@ func TestService_ReleaseProducts(t *testing.T) {
 	}
 
 	repo1 := mocks.NewRepository(t)
+	repo1.On("GetKits", mock.Anything).Return([]models.Kit{}, nil).Maybe()
 	repo2 := mocks.NewRepository(t)
+	repo2.On("GetKits", mock.Anything).Return([]models.Kit{}, nil).Maybe()
 
 	for _, productQuantityArgs := range testCase1.productQuantityArgs {
 		repo1.On("GetProductsQuantity", productQuantityArgs.input).Maybe().Return(productQuantityArgs.productInWarehouses, productQuantityArgs.error)
@@ -327,9 +625,9 @@ func TestService_ReleaseProducts(t *testing.T) {
 	svc1 := NewService(repo1, slog.Default())
 	svc2 := NewService(repo2, slog.Default())
 
-	err := svc1.ReleaseProducts(testCase1.productsToRelease)
+	err := svc1.ReleaseProducts(context.Background(), testCase1.productsToRelease)
 	assert.Equal(t, err, testCase1.expectedError)
 
-	err = svc2.ReleaseProducts(testCase2.productsToRelease)
+	err = svc2.ReleaseProducts(context.Background(), testCase2.productsToRelease)
 	assert.Equal(t, err, testCase2.expectedError)
 }
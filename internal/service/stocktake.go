@@ -0,0 +1,303 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/shamank/warehouse-service/internal/domain/models"
+	"github.com/shamank/warehouse-service/internal/domain/schemas"
+	"strings"
+	"time"
+)
+
+// stocktakeActor - автор корректировок по инвентаризации, закрытой без аутентификации
+const stocktakeActor = "stocktake"
+
+// CreateStocktake открывает инвентаризацию склада или зоны и запоминает остатки вместе с резервом.
+// Продажи на время инвентаризации не останавливаются
+func (s *Service) CreateStocktake(ctx context.Context, warehouseUUID string, request schemas.NewStocktake) (schemas.Stocktake, error) {
+	if err := checkWarehouseAccess(ctx, warehouseUUID); err != nil {
+		return schemas.Stocktake{}, err
+	}
+
+	if _, err := s.repo.GetWarehouse(warehouseUUID); err != nil {
+		return schemas.Stocktake{}, err
+	}
+
+	stocktake := models.Stocktake{
+		UUID:          uuid.NewString(),
+		WarehouseUUID: warehouseUUID,
+		Blind:         request.Blind,
+		Status:        models.StocktakeOpen,
+		CreatedBy:     clientSubject(ctx),
+		CreatedAt:     time.Now().UTC(),
+	}
+
+	if request.ZoneID != "" {
+		zone, err := s.repo.GetLocation(warehouseUUID, request.ZoneID)
+		if err != nil {
+			return schemas.Stocktake{}, err
+		}
+		if zone.Kind != models.LocationZone {
+			return schemas.Stocktake{}, fmt.Errorf("%w: %s is a %s, not a zone", models.ErrInvalidLocation, zone.Path, zone.Kind)
+		}
+		stocktake.ZoneUUID = zone.UUID
+		stocktake.ZonePath = zone.Path
+	}
+
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	if err := s.repo.CreateStocktake(stocktake); err != nil {
+		return schemas.Stocktake{}, err
+	}
+
+	stocktake, err := s.repo.GetStocktake(stocktake.UUID)
+	if err != nil {
+		return schemas.Stocktake{}, err
+	}
+
+	return stocktakeSchema(stocktake), nil
+}
+
+// GetStocktake возвращает инвентаризацию. В слепой инвентаризации до закрытия видны только пересчитанные количества
+func (s *Service) GetStocktake(ctx context.Context, stocktakeUUID string) (schemas.Stocktake, error) {
+	stocktake, err := s.repo.GetStocktake(stocktakeUUID)
+	if err != nil {
+		return schemas.Stocktake{}, err
+	}
+
+	if err := checkWarehouseAccess(ctx, stocktake.WarehouseUUID); err != nil {
+		return schemas.Stocktake{}, err
+	}
+
+	return stocktakeSchema(stocktake), nil
+}
+
+// CountStocktake запоминает пересчитанные количества. Расхождение считается с остатком в момент пересчета,
+// поэтому резервы и отгрузки во время инвентаризации его не искажают. В инвентаризации зоны товар
+// пересчитывается по ячейкам зоны, в инвентаризации склада - по складу целиком
+func (s *Service) CountStocktake(ctx context.Context, stocktakeUUID string, counts []schemas.StocktakeCount) (schemas.Stocktake, error) {
+	stocktake, err := s.repo.GetStocktake(stocktakeUUID)
+	if err != nil {
+		return schemas.Stocktake{}, err
+	}
+
+	if err := checkWarehouseAccess(ctx, stocktake.WarehouseUUID); err != nil {
+		return schemas.Stocktake{}, err
+	}
+
+	if stocktake.Status != models.StocktakeOpen {
+		return schemas.Stocktake{}, fmt.Errorf("%w: %s", models.ErrStocktakeClosed, stocktakeUUID)
+	}
+
+	articles := make([]string, 0, len(counts))
+	stocktakeCounts := make([]models.StocktakeCount, len(counts))
+	seen := make(map[models.StocktakeCount]bool, len(counts))
+	for i, count := range counts {
+		switch {
+		case stocktake.ZoneUUID == "" && count.Location != "":
+			return schemas.Stocktake{}, fmt.Errorf("%w: warehouse stocktake is counted without locations", models.ErrInvalidCount)
+		case stocktake.ZoneUUID != "" && !strings.HasPrefix(count.Location, stocktake.ZonePath+"/"):
+			return schemas.Stocktake{}, fmt.Errorf("%w: %q is not a location in zone %s", models.ErrInvalidCount, count.Location, stocktake.ZonePath)
+		}
+
+		key := models.StocktakeCount{ProductArticle: count.Article, LocationPath: count.Location}
+		if seen[key] {
+			return schemas.Stocktake{}, fmt.Errorf("%w: %s counted twice", models.ErrInvalidCount, count.Article)
+		}
+		seen[key] = true
+
+		articles = append(articles, count.Article)
+		stocktakeCounts[i] = models.StocktakeCount{ProductArticle: count.Article, LocationPath: count.Location, Counted: *count.Counted}
+	}
+
+	products, err := s.productsByArticle(articles)
+	if err != nil {
+		return schemas.Stocktake{}, err
+	}
+	for _, article := range articles {
+		if products[article].Serialized {
+			return schemas.Stocktake{}, fmt.Errorf("%w: %s is tracked by serial numbers", models.ErrInvalidCount, article)
+		}
+	}
+
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	if err := s.repo.CountStocktake(stocktakeUUID, stocktakeCounts, time.Now().UTC()); err != nil {
+		return schemas.Stocktake{}, err
+	}
+
+	stocktake, err = s.repo.GetStocktake(stocktakeUUID)
+	if err != nil {
+		return schemas.Stocktake{}, err
+	}
+
+	return stocktakeSchema(stocktake), nil
+}
+
+// CloseStocktake закрывает инвентаризацию и одной транзакцией проводит корректировки по расхождениям
+// пересчитанных строк. Корректировка больше порога ждет подтверждения, как и созданная вручную.
+// Недостача списывается не больше свободного остатка, остаток недостачи запоминается на строке.
+// Непересчитанные строки не корректируются
+func (s *Service) CloseStocktake(ctx context.Context, stocktakeUUID string) (schemas.Stocktake, error) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	stocktake, err := s.repo.GetStocktake(stocktakeUUID)
+	if err != nil {
+		return schemas.Stocktake{}, err
+	}
+
+	if err := checkWarehouseAccess(ctx, stocktake.WarehouseUUID); err != nil {
+		return schemas.Stocktake{}, err
+	}
+
+	if stocktake.Status != models.StocktakeOpen {
+		return schemas.Stocktake{}, fmt.Errorf("%w: %s", models.ErrStocktakeClosed, stocktakeUUID)
+	}
+
+	closedAt := time.Now().UTC()
+	stocktake.ClosedBy = clientSubject(ctx)
+	stocktake.ClosedAt = &closedAt
+
+	actor := stocktake.ClosedBy
+	if actor == "" {
+		actor = stocktakeActor
+	}
+
+	free, err := s.stocktakeFree(stocktake)
+	if err != nil {
+		return schemas.Stocktake{}, err
+	}
+
+	adjustments := make([]models.Adjustment, 0)
+	for i, line := range stocktake.Lines {
+		variance := line.Variance()
+		if variance == 0 {
+			continue
+		}
+
+		status := models.AdjustmentApplied
+		if s.adjustmentThreshold > 0 && abs(variance) > s.adjustmentThreshold {
+			status = models.AdjustmentPending
+		}
+
+		// товар, зарезервированный после пересчета, не списывается: остаток недостачи остается на строке
+		key := models.StocktakeCount{ProductArticle: line.ProductArticle, LocationPath: line.LocationPath}
+		if status == models.AdjustmentApplied && -variance > free[key] {
+			stocktake.Lines[i].Unadjusted = -variance - free[key]
+			variance = -free[key]
+			if variance == 0 {
+				continue
+			}
+		}
+
+		adjustment := models.Adjustment{
+			UUID:           uuid.NewString(),
+			WarehouseUUID:  stocktake.WarehouseUUID,
+			ProductArticle: line.ProductArticle,
+			BinPath:        line.LocationPath,
+			Delta:          variance,
+			Reason:         models.AdjustmentCorrection,
+			Comment:        "stocktake " + stocktake.UUID,
+			Actor:          actor,
+			Status:         status,
+			CreatedAt:      closedAt,
+		}
+
+		adjustments = append(adjustments, adjustment)
+		stocktake.Lines[i].AdjustmentUUID = adjustment.UUID
+	}
+
+	if err := s.repo.CloseStocktake(stocktake, adjustments); err != nil {
+		return schemas.Stocktake{}, err
+	}
+
+	stocktake, err = s.repo.GetStocktake(stocktakeUUID)
+	if err != nil {
+		return schemas.Stocktake{}, err
+	}
+
+	return stocktakeSchema(stocktake), nil
+}
+
+// stocktakeFree возвращает свободный остаток товаров пересчитанных строк инвентаризации, включая просроченные партии:
+// по ячейкам для строк с ячейкой и по складу для остальных. Вызывается под s.mx
+func (s *Service) stocktakeFree(stocktake models.Stocktake) (map[models.StocktakeCount]int, error) {
+	var articles, binArticles []string
+	for _, line := range stocktake.Lines {
+		switch {
+		case line.Variance() >= 0:
+		case line.LocationPath != "":
+			binArticles = append(binArticles, line.ProductArticle)
+		default:
+			articles = append(articles, line.ProductArticle)
+		}
+	}
+
+	free := make(map[models.StocktakeCount]int)
+	if len(articles) > 0 {
+		stocks, err := s.repo.GetProductsStock(articles, []string{stocktake.WarehouseUUID})
+		if err != nil {
+			return nil, err
+		}
+		for _, stock := range stocks {
+			free[models.StocktakeCount{ProductArticle: stock.ProductArticle}] = stock.Quantity
+		}
+	}
+
+	if len(binArticles) > 0 {
+		bins, err := s.repo.GetBinsStock(stocktake.WarehouseUUID, binArticles)
+		if err != nil {
+			return nil, err
+		}
+		for _, bin := range bins {
+			free[models.StocktakeCount{ProductArticle: bin.ProductArticle, LocationPath: bin.Path}] = bin.Quantity
+		}
+	}
+
+	return free, nil
+}
+
+func stocktakeSchema(stocktake models.Stocktake) schemas.Stocktake {
+	hidden := stocktake.Blind && stocktake.Status == models.StocktakeOpen
+
+	lines := make([]schemas.StocktakeLine, len(stocktake.Lines))
+	for i, line := range stocktake.Lines {
+		lines[i] = schemas.StocktakeLine{
+			Line:         line.LineNo,
+			Location:     line.LocationPath,
+			Article:      line.ProductArticle,
+			Counted:      line.Counted,
+			CountedAt:    line.CountedAt,
+			AdjustmentID: line.AdjustmentUUID,
+			Unadjusted:   line.Unadjusted,
+		}
+		if hidden {
+			continue
+		}
+
+		expected := line.Expected
+		lines[i].Expected = &expected
+		if line.Counted != nil {
+			onHand, variance := line.OnHand, line.Variance()
+			lines[i].OnHand = &onHand
+			lines[i].Variance = &variance
+		}
+	}
+
+	return schemas.Stocktake{
+		ID:            stocktake.UUID,
+		WarehouseUUID: stocktake.WarehouseUUID,
+		Zone:          stocktake.ZonePath,
+		Blind:         stocktake.Blind,
+		Status:        stocktake.Status,
+		CreatedBy:     stocktake.CreatedBy,
+		CreatedAt:     stocktake.CreatedAt,
+		ClosedBy:      stocktake.ClosedBy,
+		ClosedAt:      stocktake.ClosedAt,
+		Lines:         lines,
+	}
+}
@@ -0,0 +1,214 @@
+package service
+
+import (
+	"context"
+	"github.com/shamank/warehouse-service/internal/auth"
+	"github.com/shamank/warehouse-service/internal/domain/models"
+	"github.com/shamank/warehouse-service/internal/domain/schemas"
+	"github.com/shamank/warehouse-service/internal/service/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestService_CreateStocktake(t *testing.T) {
+	const (
+		warehouse = "d10c8d17-6d15-445e-b643-6affa59aa26c"
+		zone      = "4a7d2c1e-8b3f-4e6a-9d5c-2f1e0b7a3c68"
+	)
+
+	ctx := auth.WithPrincipal(context.Background(), auth.Principal{
+		Subject: "inventory",
+		Scopes:  []string{auth.ScopeAdmin},
+	})
+
+	t.Run("zone", func(t *testing.T) {
+		repo := mocks.NewRepository(t)
+		repo.On("GetWarehouse", warehouse).Return(models.Warehouse{UUID: warehouse, Availability: true}, nil)
+		repo.On("GetLocation", warehouse, zone).Return(models.Location{UUID: zone, Kind: models.LocationZone, Code: "A", Path: "A"}, nil)
+		repo.On("CreateStocktake", mock.MatchedBy(func(stocktake models.Stocktake) bool {
+			return stocktake.UUID != "" && stocktake.ZoneUUID == zone && stocktake.ZonePath == "A" && stocktake.Blind &&
+				stocktake.Status == models.StocktakeOpen && stocktake.CreatedBy == "inventory"
+		})).Return(nil)
+		repo.On("GetStocktake", mock.AnythingOfType("string")).Return(models.Stocktake{
+			UUID: "created", WarehouseUUID: warehouse, ZoneUUID: zone, ZonePath: "A", Blind: true, Status: models.StocktakeOpen,
+			Lines: []models.StocktakeLine{{LineNo: 1, ProductArticle: "product1", LocationPath: "A/1/1/1", Expected: 7}},
+		}, nil)
+
+		svc := NewService(repo, slog.Default())
+
+		stocktake, err := svc.CreateStocktake(ctx, warehouse, schemas.NewStocktake{ZoneID: zone, Blind: true})
+		require.NoError(t, err)
+		assert.Equal(t, "A", stocktake.Zone)
+		assert.Equal(t, []schemas.StocktakeLine{{Line: 1, Location: "A/1/1/1", Article: "product1"}}, stocktake.Lines)
+	})
+
+	t.Run("not a zone", func(t *testing.T) {
+		repo := mocks.NewRepository(t)
+		repo.On("GetWarehouse", warehouse).Return(models.Warehouse{UUID: warehouse, Availability: true}, nil)
+		repo.On("GetLocation", warehouse, zone).Return(models.Location{UUID: zone, Kind: models.LocationAisle, Path: "A/1"}, nil)
+
+		svc := NewService(repo, slog.Default())
+
+		_, err := svc.CreateStocktake(ctx, warehouse, schemas.NewStocktake{ZoneID: zone})
+		assert.ErrorIs(t, err, models.ErrInvalidLocation)
+	})
+}
+
+func TestService_CountStocktake(t *testing.T) {
+	const (
+		warehouse = "d10c8d17-6d15-445e-b643-6affa59aa26c"
+		stocktake = "5e1c7a3b-2f4d-4c8e-9a6b-1d0f3e7c2b58"
+	)
+
+	ctx := auth.WithPrincipal(context.Background(), auth.Principal{
+		Subject: "inventory",
+		Scopes:  []string{auth.ScopeAdmin},
+	})
+	counted := 3
+	zoneStocktake := models.Stocktake{UUID: stocktake, WarehouseUUID: warehouse, ZoneUUID: "zone", ZonePath: "A", Status: models.StocktakeOpen}
+
+	t.Run("count", func(t *testing.T) {
+		repo := mocks.NewRepository(t)
+		repo.On("GetStocktake", stocktake).Return(zoneStocktake, nil)
+		repo.On("GetProductsByArticles", []string{"product1"}).Return([]models.Product{{Code: "product1"}}, nil)
+		repo.On("CountStocktake", stocktake, []models.StocktakeCount{{ProductArticle: "product1", LocationPath: "A/1/1/1", Counted: 3}},
+			mock.AnythingOfType("time.Time")).Return(nil)
+
+		svc := NewService(repo, slog.Default())
+
+		_, err := svc.CountStocktake(ctx, stocktake, []schemas.StocktakeCount{{Article: "product1", Location: "A/1/1/1", Counted: &counted}})
+		require.NoError(t, err)
+	})
+
+	t.Run("invalid counts", func(t *testing.T) {
+		repo := mocks.NewRepository(t)
+		repo.On("GetStocktake", stocktake).Return(zoneStocktake, nil)
+		repo.On("GetProductsByArticles", []string{"phone"}).Return([]models.Product{{Code: "phone", Serialized: true}}, nil)
+
+		svc := NewService(repo, slog.Default())
+
+		for _, counts := range [][]schemas.StocktakeCount{
+			{{Article: "product1", Counted: &counted}},
+			{{Article: "product1", Location: "B/1/1/1", Counted: &counted}},
+			{{Article: "product1", Location: "AB/1/1/1", Counted: &counted}},
+			{{Article: "product1", Location: "A/1/1/1", Counted: &counted}, {Article: "product1", Location: "A/1/1/1", Counted: &counted}},
+			{{Article: "phone", Location: "A/1/1/1", Counted: &counted}},
+		} {
+			_, err := svc.CountStocktake(ctx, stocktake, counts)
+			assert.ErrorIs(t, err, models.ErrInvalidCount, "%+v", counts)
+		}
+	})
+
+	t.Run("closed", func(t *testing.T) {
+		closed := zoneStocktake
+		closed.Status = models.StocktakeClosed
+
+		repo := mocks.NewRepository(t)
+		repo.On("GetStocktake", stocktake).Return(closed, nil)
+
+		svc := NewService(repo, slog.Default())
+
+		_, err := svc.CountStocktake(ctx, stocktake, []schemas.StocktakeCount{{Article: "product1", Location: "A/1/1/1", Counted: &counted}})
+		assert.ErrorIs(t, err, models.ErrStocktakeClosed)
+	})
+}
+
+func TestService_CloseStocktake(t *testing.T) {
+	const (
+		warehouse = "d10c8d17-6d15-445e-b643-6affa59aa26c"
+		stocktake = "5e1c7a3b-2f4d-4c8e-9a6b-1d0f3e7c2b58"
+	)
+
+	ctx := auth.WithPrincipal(context.Background(), auth.Principal{
+		Subject: "inventory",
+		Scopes:  []string{auth.ScopeAdmin},
+	})
+	count := func(n int) *int { return &n }
+	countedAt := time.Date(2026, time.October, 19, 12, 0, 0, 0, time.UTC)
+
+	open := models.Stocktake{
+		UUID: stocktake, WarehouseUUID: warehouse, Blind: true, Status: models.StocktakeOpen,
+		Lines: []models.StocktakeLine{
+			// пересчитано 8 при остатке 10: недостача 2, хотя при открытии ожидалось 12
+			{LineNo: 1, ProductArticle: "product1", Expected: 12, Counted: count(8), OnHand: 10, CountedAt: &countedAt},
+			{LineNo: 2, ProductArticle: "product2", Expected: 5, Counted: count(5), OnHand: 5, CountedAt: &countedAt},
+			{LineNo: 3, ProductArticle: "product3", Expected: 4},
+			{LineNo: 4, ProductArticle: "product4", Expected: 0, Counted: count(50), OnHand: 0, CountedAt: &countedAt},
+		},
+	}
+
+	repo := mocks.NewRepository(t)
+	repo.On("GetStocktake", stocktake).Return(open, nil).Once()
+	repo.On("GetProductsStock", []string{"product1"}, []string{warehouse}).Return([]models.ProductStock{
+		{ProductArticle: "product1", WarehouseUUID: warehouse, Quantity: 7, ReservedQuantity: 3},
+	}, nil)
+	repo.On("CloseStocktake", mock.MatchedBy(func(closed models.Stocktake) bool {
+		return closed.ClosedBy == "inventory" && closed.ClosedAt != nil && closed.Lines[0].Unadjusted == 0 &&
+			closed.Lines[0].AdjustmentUUID != "" && closed.Lines[1].AdjustmentUUID == "" &&
+			closed.Lines[2].AdjustmentUUID == "" && closed.Lines[3].AdjustmentUUID != ""
+	}), mock.MatchedBy(func(adjustments []models.Adjustment) bool {
+		return len(adjustments) == 2 &&
+			adjustments[0].ProductArticle == "product1" && adjustments[0].Delta == -2 &&
+			adjustments[0].Status == models.AdjustmentApplied && adjustments[0].Reason == models.AdjustmentCorrection &&
+			adjustments[0].Actor == "inventory" &&
+			adjustments[1].ProductArticle == "product4" && adjustments[1].Delta == 50 &&
+			adjustments[1].Status == models.AdjustmentPending
+	})).Return(nil)
+	closed := open
+	closed.Status = models.StocktakeClosed
+	repo.On("GetStocktake", stocktake).Return(closed, nil).Once()
+
+	svc := NewService(repo, slog.Default())
+	svc.SetAdjustmentThreshold(10)
+
+	result, err := svc.CloseStocktake(ctx, stocktake)
+	require.NoError(t, err)
+	assert.Equal(t, models.StocktakeClosed, result.Status)
+	require.NotNil(t, result.Lines[0].Variance)
+	assert.Equal(t, -2, *result.Lines[0].Variance)
+	assert.Equal(t, 12, *result.Lines[0].Expected)
+	assert.Nil(t, result.Lines[2].Variance)
+}
+
+func TestService_CloseStocktake_Reserved(t *testing.T) {
+	const (
+		warehouse = "d10c8d17-6d15-445e-b643-6affa59aa26c"
+		stocktake = "5e1c7a3b-2f4d-4c8e-9a6b-1d0f3e7c2b58"
+	)
+
+	count := func(n int) *int { return &n }
+	open := models.Stocktake{
+		UUID: stocktake, WarehouseUUID: warehouse, Status: models.StocktakeOpen,
+		Lines: []models.StocktakeLine{
+			// недостача 5, но после пересчета товар зарезервировали и свободно только 3
+			{LineNo: 1, ProductArticle: "product1", Counted: count(5), OnHand: 10},
+			// в ячейке свободного товара не осталось совсем
+			{LineNo: 2, ProductArticle: "product2", LocationPath: "A/1/1/1", Counted: count(0), OnHand: 2},
+		},
+	}
+
+	repo := mocks.NewRepository(t)
+	repo.On("GetStocktake", stocktake).Return(open, nil).Once()
+	repo.On("GetProductsStock", []string{"product1"}, []string{warehouse}).Return([]models.ProductStock{
+		{ProductArticle: "product1", WarehouseUUID: warehouse, Quantity: 3, ReservedQuantity: 6},
+	}, nil)
+	repo.On("GetBinsStock", warehouse, []string{"product2"}).Return([]models.BinStock{
+		{ProductArticle: "product2", Path: "A/1/1/1", ReservedQuantity: 2},
+	}, nil)
+	repo.On("CloseStocktake", mock.MatchedBy(func(closed models.Stocktake) bool {
+		return closed.Lines[0].AdjustmentUUID != "" && closed.Lines[0].Unadjusted == 2 &&
+			closed.Lines[1].AdjustmentUUID == "" && closed.Lines[1].Unadjusted == 2
+	}), mock.MatchedBy(func(adjustments []models.Adjustment) bool {
+		return len(adjustments) == 1 && adjustments[0].ProductArticle == "product1" && adjustments[0].Delta == -3
+	})).Return(nil)
+	repo.On("GetStocktake", stocktake).Return(open, nil).Once()
+
+	svc := NewService(repo, slog.Default())
+
+	_, err := svc.CloseStocktake(context.Background(), stocktake)
+	require.NoError(t, err)
+}
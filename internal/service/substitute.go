@@ -0,0 +1,121 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/shamank/warehouse-service/internal/domain/models"
+	"github.com/shamank/warehouse-service/internal/domain/schemas"
+	"slices"
+)
+
+// SaveSubstitutes задает замены товара, заменяя прежние. Пустой список удаляет замены.
+// Ни товар, ни замены не могут быть наборами
+func (s *Service) SaveSubstitutes(ctx context.Context, article string, definition schemas.SubstitutesDefinition) (schemas.Substitutes, error) {
+	articles := []string{article}
+	substitutes := make([]models.Substitute, 0, len(definition.Substitutes))
+	for _, rule := range definition.Substitutes {
+		if slices.Contains(articles, rule.Article) {
+			return schemas.Substitutes{}, fmt.Errorf("%w: %s is listed twice or is the product itself", models.ErrInvalidSubstitute, rule.Article)
+		}
+		articles = append(articles, rule.Article)
+		substitutes = append(substitutes, models.Substitute{ProductArticle: rule.Article, SameSize: rule.SameSize})
+	}
+
+	if err := s.checkArticles(articles); err != nil {
+		return schemas.Substitutes{}, err
+	}
+
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	// у набора нет своего остатка, а позиции наборов раскладываются на компоненты до подбора замен,
+	// поэтому замена набора или на набор никогда не сработает. Пустой список только удаляет замены
+	if len(substitutes) > 0 {
+		kits, err := s.repo.GetKits(articles)
+		if err != nil {
+			return schemas.Substitutes{}, err
+		}
+		if len(kits) > 0 {
+			return schemas.Substitutes{}, fmt.Errorf("%w: %s is a kit", models.ErrInvalidSubstitute, kits[0].ProductArticle)
+		}
+	}
+
+	if err := s.repo.SaveSubstitutes(article, substitutes); err != nil {
+		return schemas.Substitutes{}, err
+	}
+
+	return s.getSubstitutes(article)
+}
+
+// GetSubstitutes возвращает замены товара в порядке, в котором они пробуются при нехватке
+func (s *Service) GetSubstitutes(ctx context.Context, article string) (schemas.Substitutes, error) {
+	if err := s.checkArticles([]string{article}); err != nil {
+		return schemas.Substitutes{}, err
+	}
+
+	return s.getSubstitutes(article)
+}
+
+func (s *Service) getSubstitutes(article string) (schemas.Substitutes, error) {
+	substitutes, err := s.repo.GetSubstitutes(article)
+	if err != nil {
+		return schemas.Substitutes{}, err
+	}
+
+	result := schemas.Substitutes{Article: article, Substitutes: make([]schemas.Substitute, len(substitutes))}
+	for i, substitute := range substitutes {
+		result.Substitutes[i] = schemas.Substitute{
+			Article:  substitute.ProductArticle,
+			Name:     substitute.ProductName,
+			Size:     substitute.ProductSize,
+			SameSize: substitute.SameSize,
+		}
+	}
+
+	return result, nil
+}
+
+// substituteItem распределяет количество позиции по складам для первой по порядку замены, которой хватает
+// на все количество: позиция не делится между товаром и заменами. Замены из taken и замены с SameSize
+// другого размера пропускаются. Если не подходит ни одна замена, возвращается ErrNotEnoughProducts.
+// Вызывается под s.mx
+func (s *Service) substituteItem(item schemas.ReserveItem, allowedWarehouses []string, taken []string) (string, []schemas.WarehouseCounter, error) {
+	substitutes, err := s.repo.GetSubstitutes(item.Article)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var product *models.Product
+	for _, substitute := range substitutes {
+		if slices.Contains(taken, substitute.ProductArticle) {
+			continue
+		}
+
+		if substitute.SameSize {
+			if product == nil {
+				products, err := s.productsByArticle([]string{item.Article})
+				if err != nil {
+					return "", nil, err
+				}
+				known := products[item.Article]
+				product = &known
+			}
+			if substitute.ProductSize != product.Size {
+				continue
+			}
+		}
+
+		warehouseData, err := s.processProduct(substitute.ProductArticle, item.Quantity, false, allowedWarehouses)
+		if errors.Is(err, ErrNotEnoughProducts) {
+			continue
+		}
+		if err != nil {
+			return "", nil, err
+		}
+
+		return substitute.ProductArticle, warehouseData, nil
+	}
+
+	return "", nil, fmt.Errorf("%w: %s and its substitutes", ErrNotEnoughProducts, item.Article)
+}
@@ -0,0 +1,176 @@
+package service
+
+import (
+	"context"
+	"github.com/shamank/warehouse-service/internal/domain/models"
+	"github.com/shamank/warehouse-service/internal/domain/schemas"
+	"github.com/shamank/warehouse-service/internal/service/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"log/slog"
+	"testing"
+)
+
+func TestService_SaveSubstitutes(t *testing.T) {
+	t.Run("save", func(t *testing.T) {
+		repo := mocks.NewRepository(t)
+		repo.On("GetProductsByArticles", []string{"shirt-m", "shirt-m-blue", "shirt-l"}).
+			Return([]models.Product{{Code: "shirt-m"}, {Code: "shirt-m-blue"}, {Code: "shirt-l"}}, nil)
+		repo.On("GetKits", []string{"shirt-m", "shirt-m-blue", "shirt-l"}).Return([]models.Kit{}, nil)
+		repo.On("SaveSubstitutes", "shirt-m", []models.Substitute{
+			{ProductArticle: "shirt-m-blue", SameSize: true},
+			{ProductArticle: "shirt-l"},
+		}).Return(nil)
+		repo.On("GetSubstitutes", "shirt-m").Return([]models.Substitute{
+			{ProductArticle: "shirt-m-blue", ProductName: "shirt", ProductSize: "M", SameSize: true},
+			{ProductArticle: "shirt-l", ProductName: "shirt", ProductSize: "L"},
+		}, nil)
+
+		svc := NewService(repo, slog.Default())
+
+		result, err := svc.SaveSubstitutes(context.Background(), "shirt-m", schemas.SubstitutesDefinition{Substitutes: []schemas.SubstituteRule{
+			{Article: "shirt-m-blue", SameSize: true},
+			{Article: "shirt-l"},
+		}})
+		require.NoError(t, err)
+		assert.Equal(t, schemas.Substitutes{Article: "shirt-m", Substitutes: []schemas.Substitute{
+			{Article: "shirt-m-blue", Name: "shirt", Size: "M", SameSize: true},
+			{Article: "shirt-l", Name: "shirt", Size: "L"},
+		}}, result)
+	})
+
+	t.Run("invalid rules", func(t *testing.T) {
+		svc := NewService(mocks.NewRepository(t), slog.Default())
+
+		for _, rules := range [][]schemas.SubstituteRule{
+			{{Article: "shirt-m"}},
+			{{Article: "shirt-l"}, {Article: "shirt-l", SameSize: true}},
+		} {
+			_, err := svc.SaveSubstitutes(context.Background(), "shirt-m", schemas.SubstitutesDefinition{Substitutes: rules})
+			assert.ErrorIs(t, err, models.ErrInvalidSubstitute, "%+v", rules)
+		}
+	})
+	t.Run("kits", func(t *testing.T) {
+		// набор не может ни заменять товар, ни иметь замен
+		for _, article := range []string{"gift", "shirt-m"} {
+			repo := mocks.NewRepository(t)
+			repo.On("GetProductsByArticles", mock.Anything).Return([]models.Product{{Code: "gift"}, {Code: "shirt-m"}}, nil)
+			repo.On("GetKits", mock.Anything).Return([]models.Kit{giftKit}, nil)
+
+			svc := NewService(repo, slog.Default())
+
+			substitute := "gift"
+			if article == "gift" {
+				substitute = "shirt-m"
+			}
+			_, err := svc.SaveSubstitutes(context.Background(), article, schemas.SubstitutesDefinition{Substitutes: []schemas.SubstituteRule{{Article: substitute}}})
+			assert.ErrorIs(t, err, models.ErrInvalidSubstitute)
+		}
+	})
+
+	t.Run("substitutes of a kit removed", func(t *testing.T) {
+		repo := mocks.NewRepository(t)
+		repo.On("GetProductsByArticles", []string{"gift"}).Return([]models.Product{{Code: "gift"}}, nil)
+		repo.On("SaveSubstitutes", "gift", []models.Substitute{}).Return(nil)
+		repo.On("GetSubstitutes", "gift").Return([]models.Substitute{}, nil)
+
+		svc := NewService(repo, slog.Default())
+
+		_, err := svc.SaveSubstitutes(context.Background(), "gift", schemas.SubstitutesDefinition{})
+		assert.NoError(t, err)
+	})
+}
+
+func TestService_CreateReservation_Substitutes(t *testing.T) {
+	const warehouse = "d10c8d17-6d15-445e-b643-6affa59aa26c"
+
+	stock := func(quantity int) []models.WarehouseProduct {
+		return []models.WarehouseProduct{{WarehouseUUID: warehouse, Quantity: quantity}}
+	}
+	substitutes := []models.Substitute{
+		// товар другой позиции, другого размера и замена, которой не хватает, пропускаются
+		{ProductArticle: "cap"},
+		{ProductArticle: "shirt-l", ProductSize: "L", SameSize: true},
+		{ProductArticle: "shirt-m-blue", ProductSize: "M", SameSize: true},
+		{ProductArticle: "shirt-m-red", ProductSize: "M", SameSize: true},
+	}
+
+	t.Run("substitute reserved", func(t *testing.T) {
+		repo := mocks.NewRepository(t)
+		repo.On("GetKits", mock.Anything).Return([]models.Kit{}, nil)
+		repo.On("GetProductsByArticles", []string{"shirt-m", "cap"}).Return([]models.Product{{Code: "shirt-m"}, {Code: "cap"}}, nil)
+		repo.On("GetProductsQuantity", "shirt-m").Return(stock(2), nil)
+		repo.On("GetSubstitutes", "shirt-m").Return(substitutes, nil)
+		repo.On("GetProductsByArticles", []string{"shirt-m"}).Return([]models.Product{{Code: "shirt-m", Size: "M"}}, nil)
+		repo.On("GetProductsQuantity", "shirt-m-blue").Return(stock(1), nil)
+		repo.On("GetProductsQuantity", "shirt-m-red").Return(stock(5), nil)
+		repo.On("GetProductsQuantity", "cap").Return(stock(5), nil)
+		repo.On("CreateReservation", mock.AnythingOfType("string"), []schemas.ProductWarehouseSplitted{
+			{
+				ProductArticle: "shirt-m-red",
+				WarehouseData:  []schemas.WarehouseCounter{{WarehouseUUID: warehouse, Count: 3}},
+				SubstituteFor:  "shirt-m",
+			},
+			{
+				ProductArticle: "cap",
+				WarehouseData:  []schemas.WarehouseCounter{{WarehouseUUID: warehouse, Count: 1}},
+			},
+		}).Return(nil)
+		repo.On("GetReservation", mock.AnythingOfType("string")).Return(models.Reservation{
+			Status: models.ReservationActive,
+			Items: []models.ReservationItem{
+				{ProductArticle: "cap", WarehouseUUID: warehouse, Quantity: 1},
+				{ProductArticle: "shirt-m-red", WarehouseUUID: warehouse, Quantity: 3, SubstituteFor: "shirt-m"},
+			},
+		}, nil)
+
+		svc := NewService(repo, slog.Default())
+
+		reservation, err := svc.CreateReservation(context.Background(), []schemas.ReserveItem{
+			{Article: "shirt-m", Quantity: 3, AllowSubstitutes: true},
+			{Article: "cap", Quantity: 1},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, schemas.ReservationItem{Article: "shirt-m-red", WarehouseUUID: warehouse, Quantity: 3, SubstituteFor: "shirt-m"},
+			reservation.Items[1])
+	})
+
+	t.Run("no substitute fits", func(t *testing.T) {
+		repo := mocks.NewRepository(t)
+		repo.On("GetKits", mock.Anything).Return([]models.Kit{}, nil)
+		repo.On("GetProductsByArticles", []string{"shirt-m", "cap"}).Return([]models.Product{{Code: "shirt-m"}, {Code: "cap"}}, nil)
+		repo.On("GetProductsQuantity", "shirt-m").Return(stock(2), nil)
+		repo.On("GetSubstitutes", "shirt-m").Return(substitutes, nil)
+		repo.On("GetProductsByArticles", []string{"shirt-m"}).Return([]models.Product{{Code: "shirt-m", Size: "M"}}, nil)
+		repo.On("GetProductsQuantity", "shirt-m-blue").Return(stock(1), nil)
+		repo.On("GetProductsQuantity", "shirt-m-red").Return(stock(1), nil)
+
+		svc := NewService(repo, slog.Default())
+
+		_, err := svc.CreateReservation(context.Background(), []schemas.ReserveItem{
+			{Article: "shirt-m", Quantity: 3, AllowSubstitutes: true},
+			{Article: "cap", Quantity: 1},
+		})
+		assert.ErrorIs(t, err, models.ErrNotEnoughProducts)
+	})
+
+	t.Run("substitutes not allowed", func(t *testing.T) {
+		repo := mocks.NewRepository(t)
+		repo.On("GetKits", mock.Anything).Return([]models.Kit{}, nil)
+		repo.On("GetProductsByArticles", []string{"shirt-m"}).Return([]models.Product{{Code: "shirt-m"}}, nil)
+		repo.On("GetProductsQuantity", "shirt-m").Return(stock(2), nil)
+
+		svc := NewService(repo, slog.Default())
+
+		_, err := svc.CreateReservation(context.Background(), []schemas.ReserveItem{{Article: "shirt-m", Quantity: 3}})
+		assert.ErrorIs(t, err, models.ErrNotEnoughProducts)
+	})
+}
+
+func TestService_ReserveItems_Substitutes(t *testing.T) {
+	svc := NewService(mocks.NewRepository(t), slog.Default())
+
+	err := svc.ReserveItems(context.Background(), []schemas.ReserveItem{{Article: "shirt-m", Quantity: 3, AllowSubstitutes: true}})
+	assert.ErrorIs(t, err, models.ErrInvalidSubstitute)
+}
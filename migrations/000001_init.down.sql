@@ -1,5 +1,5 @@
-drop table if exists warehouse_items;
-
-drop table if exists items;
+drop table if exists warehouse_products;
 
 drop table if exists warehouses;
+
+drop table if exists products;
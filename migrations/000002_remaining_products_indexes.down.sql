@@ -0,0 +1,3 @@
+drop index if exists idx_warehouse_products_quantity;
+drop index if exists idx_products_name_trgm;
+drop index if exists idx_products_article_pattern;
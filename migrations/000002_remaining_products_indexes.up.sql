@@ -0,0 +1,9 @@
+-- поиск по префиксу артикула (LIKE 'abc%') независимо от collation базы
+create index idx_products_article_pattern on products (article varchar_pattern_ops);
+
+-- поиск по подстроке названия (ILIKE '%abc%')
+create extension if not exists pg_trgm;
+create index idx_products_name_trgm on products using gin (name gin_trgm_ops);
+
+-- фильтр и сортировка по остатку внутри склада
+create index idx_warehouse_products_quantity on warehouse_products (warehouse_uuid, quantity);
@@ -0,0 +1,9 @@
+create table api_keys
+(
+    uuid       uuid primary key,
+    name       varchar     not null,
+    key_hash   char(64)    not null unique,
+    scopes     varchar     not null,
+    created_at timestamptz not null,
+    revoked_at timestamptz
+);
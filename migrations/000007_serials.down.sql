@@ -0,0 +1,5 @@
+drop table if exists product_serial_events;
+
+drop table if exists product_serials;
+
+alter table products drop column serialized;
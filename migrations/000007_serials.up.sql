@@ -0,0 +1,35 @@
+alter table products add column serialized boolean not null default false;
+
+-- серийные номера штучного товара. Номер уникален в пределах товара,
+-- reservation_uuid заполнен у номеров, зарезервированных через резерв v2
+create table product_serials
+(
+    product_uuid     uuid,
+    serial_number    varchar,
+    warehouse_uuid   uuid not null,
+    status           varchar not null,
+    reservation_uuid uuid,
+    updated_at       timestamptz not null,
+
+    primary key (product_uuid, serial_number),
+    foreign key (product_uuid) references products (uuid),
+    foreign key (warehouse_uuid) references warehouses (uuid),
+    foreign key (reservation_uuid) references reservations (uuid)
+);
+
+create index idx_product_serials_stock on product_serials (product_uuid, warehouse_uuid, status);
+
+-- история серийного номера, seq - порядковый номер события
+create table product_serial_events
+(
+    product_uuid     uuid,
+    serial_number    varchar,
+    seq              int not null,
+    status           varchar not null,
+    warehouse_uuid   uuid not null,
+    reservation_uuid uuid,
+    created_at       timestamptz not null,
+
+    primary key (product_uuid, serial_number, seq),
+    foreign key (product_uuid, serial_number) references product_serials (product_uuid, serial_number)
+);
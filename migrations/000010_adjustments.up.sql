@@ -0,0 +1,23 @@
+-- корректировка остатка товара на складе: delta прибавляется к свободному количеству.
+-- Корректировка больше порога ждет подтверждения администратора в статусе pending
+create table inventory_adjustments
+(
+    uuid           uuid primary key,
+    warehouse_uuid uuid not null,
+    product_uuid   uuid not null,
+    delta          int not null,
+    reason         varchar not null,
+    comment        varchar not null default '',
+    actor          varchar not null,
+    status         varchar not null,
+    created_at     timestamptz not null,
+    reviewed_by    varchar,
+    reviewed_at    timestamptz,
+
+    foreign key (warehouse_uuid) references warehouses (uuid),
+    foreign key (product_uuid) references products (uuid),
+
+    constraint check_adjustment_delta check (delta <> 0)
+);
+
+create index idx_inventory_adjustments_warehouse on inventory_adjustments (warehouse_uuid, status, created_at);
@@ -0,0 +1,48 @@
+-- ячейка корректировки: товар списывается из нее или оприходуется в нее, без location_uuid - по складу целиком
+alter table inventory_adjustments add column location_uuid uuid references locations (uuid);
+
+-- инвентаризация склада или зоны. Без zone_uuid пересчитываются товары склада, с зоной - товары в ячейках зоны.
+-- blind - пересчет вслепую: ожидаемые количества не показываются до закрытия
+create table stocktakes
+(
+    uuid           uuid primary key,
+    warehouse_uuid uuid not null,
+    zone_uuid      uuid,
+    blind          boolean not null default false,
+    status         varchar not null,
+    created_by     varchar not null,
+    created_at     timestamptz not null,
+    closed_by      varchar,
+    closed_at      timestamptz,
+
+    foreign key (warehouse_uuid) references warehouses (uuid),
+    foreign key (zone_uuid) references locations (uuid)
+);
+
+create index idx_stocktakes_warehouse on stocktakes (warehouse_uuid, status);
+
+-- строка инвентаризации: товар в ячейке, без location_uuid - товар на складе целиком.
+-- expected - остаток вместе с резервом при открытии, on_hand - остаток вместе с резервом в момент пересчета:
+-- расхождение считается от on_hand, поэтому резервы, отгрузки и приемка во время инвентаризации его не искажают.
+-- unadjusted - недостача, не списанная при закрытии, потому что товар к тому времени зарезервировали
+create table stocktake_lines
+(
+    stocktake_uuid  uuid,
+    line_no         int,
+    product_uuid    uuid not null,
+    location_uuid   uuid,
+    expected        int not null,
+    counted         int,
+    on_hand         int not null default 0,
+    counted_at      timestamptz,
+    adjustment_uuid uuid,
+    unadjusted      int not null default 0,
+
+    primary key (stocktake_uuid, line_no),
+    foreign key (stocktake_uuid) references stocktakes (uuid),
+    foreign key (product_uuid) references products (uuid),
+    foreign key (location_uuid) references locations (uuid),
+    foreign key (adjustment_uuid) references inventory_adjustments (uuid),
+
+    constraint check_stocktake_counted check (counted >= 0)
+);
@@ -0,0 +1,15 @@
+-- состав набора: артикул набора не хранится на складе, а собирается из компонентов.
+-- Резерв набора резервирует компоненты, доступность набора считается по их остаткам
+create table kit_components
+(
+    kit_uuid       uuid not null,
+    component_uuid uuid not null,
+    quantity       int not null,
+
+    primary key (kit_uuid, component_uuid),
+    foreign key (kit_uuid) references products (uuid),
+    foreign key (component_uuid) references products (uuid),
+
+    constraint check_kit_component_quantity check (quantity > 0),
+    constraint check_kit_component_self check (kit_uuid <> component_uuid)
+);
@@ -0,0 +1,5 @@
+drop table if exists warehouse_products;
+
+drop table if exists warehouses;
+
+drop table if exists products;
@@ -0,0 +1,33 @@
+create table products
+(
+    uuid    char(36) primary key default (uuid()),
+    name    varchar(255),
+    size    varchar(255),
+    article varchar(255),
+
+    unique (article)
+);
+
+create index idx_article on products (article);
+
+create table warehouses
+(
+    uuid         char(36) primary key default (uuid()),
+    name         varchar(255),
+    is_available boolean
+);
+
+create table warehouse_products
+(
+    warehouse_uuid    char(36),
+    product_uuid      char(36),
+    quantity          int,
+    reserved_quantity int,
+
+    primary key (warehouse_uuid, product_uuid),
+    foreign key (warehouse_uuid) references warehouses (uuid),
+    foreign key (product_uuid) references products (uuid),
+
+    constraint check_quantity check (quantity >= 0),
+    constraint check_reserved_quantity check (reserved_quantity >= 0)
+);
@@ -0,0 +1 @@
+drop index idx_warehouse_products_quantity on warehouse_products;
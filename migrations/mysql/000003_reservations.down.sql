@@ -0,0 +1,3 @@
+drop table if exists reservation_items;
+
+drop table if exists reservations;
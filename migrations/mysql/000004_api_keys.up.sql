@@ -0,0 +1,9 @@
+create table api_keys
+(
+    uuid       char(36) primary key,
+    name       varchar(255) not null,
+    key_hash   char(64)     not null unique,
+    scopes     varchar(255) not null,
+    created_at datetime(6)  not null,
+    revoked_at datetime(6)
+);
@@ -0,0 +1 @@
+alter table api_keys add column warehouses varchar(2048) not null default '';
@@ -0,0 +1,5 @@
+drop table if exists reservation_item_lots;
+
+drop table if exists warehouse_lots;
+
+drop table if exists lots;
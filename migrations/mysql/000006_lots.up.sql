@@ -0,0 +1,47 @@
+-- партия товара. Номер уникален в пределах товара, даты без времени, срок годности может быть не указан
+create table lots
+(
+    product_uuid    char(36),
+    lot_number      varchar(64),
+    manufactured_at date,
+    expires_at      date,
+
+    primary key (product_uuid, lot_number),
+    foreign key (product_uuid) references products (uuid)
+);
+
+-- остатки партий на складе. Сумма по партиям не больше остатка в warehouse_products,
+-- разница - товар без партии
+create table warehouse_lots
+(
+    warehouse_uuid    char(36),
+    product_uuid      char(36),
+    lot_number        varchar(64),
+    quantity          int not null default 0,
+    reserved_quantity int not null default 0,
+
+    primary key (warehouse_uuid, product_uuid, lot_number),
+    foreign key (warehouse_uuid, product_uuid) references warehouse_products (warehouse_uuid, product_uuid),
+    foreign key (product_uuid, lot_number) references lots (product_uuid, lot_number),
+
+    constraint check_lot_quantity check (quantity >= 0),
+    constraint check_lot_reserved_quantity check (reserved_quantity >= 0)
+);
+
+-- партии, из которых собрана позиция резерва, чтобы при освобождении вернуть товар в те же партии
+create table reservation_item_lots
+(
+    reservation_uuid  char(36),
+    product_uuid      char(36),
+    warehouse_uuid    char(36),
+    lot_number        varchar(64),
+    quantity          int not null,
+    released_quantity int not null default 0,
+
+    primary key (reservation_uuid, product_uuid, warehouse_uuid, lot_number),
+    foreign key (reservation_uuid, product_uuid, warehouse_uuid) references reservation_items (reservation_uuid, product_uuid, warehouse_uuid),
+    foreign key (product_uuid, lot_number) references lots (product_uuid, lot_number),
+
+    constraint check_reservation_lot_quantity check (quantity > 0),
+    constraint check_released_lot_quantity check (released_quantity >= 0 and released_quantity <= quantity)
+);
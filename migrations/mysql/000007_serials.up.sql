@@ -0,0 +1,35 @@
+alter table products add column serialized boolean not null default false;
+
+-- серийные номера штучного товара. Номер уникален в пределах товара,
+-- reservation_uuid заполнен у номеров, зарезервированных через резерв v2
+create table product_serials
+(
+    product_uuid     char(36),
+    serial_number    varchar(255),
+    warehouse_uuid   char(36) not null,
+    status           varchar(32) not null,
+    reservation_uuid char(36),
+    updated_at       datetime(6) not null,
+
+    primary key (product_uuid, serial_number),
+    foreign key (product_uuid) references products (uuid),
+    foreign key (warehouse_uuid) references warehouses (uuid),
+    foreign key (reservation_uuid) references reservations (uuid)
+);
+
+create index idx_product_serials_stock on product_serials (product_uuid, warehouse_uuid, status);
+
+-- история серийного номера, seq - порядковый номер события
+create table product_serial_events
+(
+    product_uuid     char(36),
+    serial_number    varchar(255),
+    seq              int not null,
+    status           varchar(32) not null,
+    warehouse_uuid   char(36) not null,
+    reservation_uuid char(36),
+    created_at       datetime(6) not null,
+
+    primary key (product_uuid, serial_number, seq),
+    foreign key (product_uuid, serial_number) references product_serials (product_uuid, serial_number)
+);
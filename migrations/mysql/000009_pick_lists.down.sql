@@ -0,0 +1,7 @@
+drop table if exists pick_list_lines;
+
+drop table if exists pick_lists;
+
+alter table reservation_items drop column unfulfilled_quantity;
+
+alter table reservation_items drop column short_quantity;
@@ -0,0 +1,23 @@
+-- корректировка остатка товара на складе: delta прибавляется к свободному количеству.
+-- Корректировка больше порога ждет подтверждения администратора в статусе pending
+create table inventory_adjustments
+(
+    uuid           char(36) primary key,
+    warehouse_uuid char(36) not null,
+    product_uuid   char(36) not null,
+    delta          int not null,
+    reason         varchar(32) not null,
+    comment        varchar(1000) not null default '',
+    actor          varchar(255) not null,
+    status         varchar(32) not null,
+    created_at     datetime(6) not null,
+    reviewed_by    varchar(255),
+    reviewed_at    datetime(6),
+
+    foreign key (warehouse_uuid) references warehouses (uuid),
+    foreign key (product_uuid) references products (uuid),
+
+    constraint check_adjustment_delta check (delta <> 0)
+);
+
+create index idx_inventory_adjustments_warehouse on inventory_adjustments (warehouse_uuid, status, created_at);
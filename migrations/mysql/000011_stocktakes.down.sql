@@ -0,0 +1,6 @@
+drop table if exists stocktake_lines;
+
+drop table if exists stocktakes;
+
+alter table inventory_adjustments drop foreign key fk_inventory_adjustments_location;
+alter table inventory_adjustments drop column location_uuid;
@@ -0,0 +1,41 @@
+-- товар возвратов на карантине: не входит ни в quantity, ни в reserved_quantity и не резервируется
+create table quarantine_stock
+(
+    warehouse_uuid char(36) not null,
+    product_uuid   char(36) not null,
+    quantity       int not null default 0,
+
+    primary key (warehouse_uuid, product_uuid),
+    foreign key (warehouse_uuid) references warehouses (uuid),
+    foreign key (product_uuid) references products (uuid),
+
+    constraint check_quarantine_quantity check (quantity >= 0)
+);
+
+-- возврат товара покупателем: создан, принят на склад, осмотрен. По результату осмотра outcome товар
+-- возвращается в продажу, уходит на карантин или только списывается в учете
+create table product_returns
+(
+    uuid           char(36) primary key,
+    warehouse_uuid char(36) not null,
+    product_uuid   char(36) not null,
+    quantity       int not null,
+    reference      varchar(255) not null default '',
+    reason         varchar(1000) not null default '',
+    status         varchar(32) not null,
+    outcome        varchar(32),
+    comment        varchar(1000) not null default '',
+    created_by     varchar(255) not null,
+    created_at     datetime(6) not null,
+    received_by    varchar(255),
+    received_at    datetime(6),
+    inspected_by   varchar(255),
+    inspected_at   datetime(6),
+
+    foreign key (warehouse_uuid) references warehouses (uuid),
+    foreign key (product_uuid) references products (uuid),
+
+    constraint check_return_quantity check (quantity > 0)
+);
+
+create index idx_product_returns_warehouse on product_returns (warehouse_uuid, status);
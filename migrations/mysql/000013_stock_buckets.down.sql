@@ -0,0 +1,20 @@
+-- товар остальных корзин теряется: до корзин его не было
+create table quarantine_stock
+(
+    warehouse_uuid char(36) not null,
+    product_uuid   char(36) not null,
+    quantity       int not null default 0,
+
+    primary key (warehouse_uuid, product_uuid),
+    foreign key (warehouse_uuid) references warehouses (uuid),
+    foreign key (product_uuid) references products (uuid),
+
+    constraint check_quarantine_quantity check (quantity >= 0)
+);
+
+insert into quarantine_stock (warehouse_uuid, product_uuid, quantity)
+select warehouse_uuid, product_uuid, quantity from stock_buckets where bucket = 'quarantine';
+
+drop table if exists stock_bucket_bins;
+drop table if exists stock_bucket_lots;
+drop table if exists stock_buckets;
@@ -0,0 +1,54 @@
+-- непродаваемый остаток товара на складе по корзинам: товар в корзине не входит ни в quantity,
+-- ни в reserved_quantity и не резервируется. Карантин возвратов становится корзиной quarantine
+create table stock_buckets
+(
+    warehouse_uuid char(36) not null,
+    product_uuid   char(36) not null,
+    bucket         varchar(32) not null,
+    quantity       int not null default 0,
+
+    primary key (warehouse_uuid, product_uuid, bucket),
+    foreign key (warehouse_uuid) references warehouses (uuid),
+    foreign key (product_uuid) references products (uuid),
+
+    constraint check_bucket_quantity check (quantity >= 0)
+);
+
+-- партии товара в корзине: товар уходит в корзину и возвращается в продажу со своими партиями, поэтому
+-- просроченная партия остается просроченной. Сумма по партиям не больше остатка корзины, разница - товар без партии
+create table stock_bucket_lots
+(
+    warehouse_uuid char(36),
+    product_uuid   char(36),
+    bucket         varchar(32),
+    lot_number     varchar(64),
+    quantity       int not null default 0,
+
+    primary key (warehouse_uuid, product_uuid, bucket, lot_number),
+    foreign key (warehouse_uuid, product_uuid, bucket) references stock_buckets (warehouse_uuid, product_uuid, bucket),
+    foreign key (product_uuid, lot_number) references lots (product_uuid, lot_number),
+
+    constraint check_bucket_lot_quantity check (quantity >= 0)
+);
+
+-- ячейки, из которых товар ушел в корзину: в продажу он возвращается в них же.
+-- Сумма по ячейкам не больше остатка корзины, разница - товар вне ячеек
+create table stock_bucket_bins
+(
+    warehouse_uuid char(36),
+    product_uuid   char(36),
+    bucket         varchar(32),
+    location_uuid  char(36),
+    quantity       int not null default 0,
+
+    primary key (warehouse_uuid, product_uuid, bucket, location_uuid),
+    foreign key (warehouse_uuid, product_uuid, bucket) references stock_buckets (warehouse_uuid, product_uuid, bucket),
+    foreign key (location_uuid) references locations (uuid),
+
+    constraint check_bucket_bin_quantity check (quantity >= 0)
+);
+
+insert into stock_buckets (warehouse_uuid, product_uuid, bucket, quantity)
+select warehouse_uuid, product_uuid, 'quarantine', quantity from quarantine_stock;
+
+drop table quarantine_stock;
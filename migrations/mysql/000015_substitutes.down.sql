@@ -0,0 +1,4 @@
+alter table reservation_items drop foreign key fk_reservation_items_substitute;
+alter table reservation_items drop column substitute_for;
+
+drop table if exists product_substitutes;
@@ -0,0 +1,19 @@
+-- замены товара при нехватке: замены пробуются по возрастанию priority.
+-- same_size - замена подходит, только если ее размер совпадает с размером заменяемого товара
+create table product_substitutes
+(
+    product_uuid    char(36) not null,
+    substitute_uuid char(36) not null,
+    priority        int not null,
+    same_size       boolean not null default false,
+
+    primary key (product_uuid, substitute_uuid),
+    foreign key (product_uuid) references products (uuid),
+    foreign key (substitute_uuid) references products (uuid),
+
+    constraint check_product_substitute_self check (product_uuid <> substitute_uuid)
+);
+
+-- substitute_for - товар, вместо которого зарезервирована позиция
+alter table reservation_items add column substitute_for char(36);
+alter table reservation_items add constraint fk_reservation_items_substitute foreign key (substitute_for) references products (uuid);
@@ -0,0 +1,33 @@
+create table products
+(
+    uuid    text primary key,
+    name    text,
+    size    text,
+    article text,
+
+    unique (article)
+);
+
+create index idx_article on products (article);
+
+create table warehouses
+(
+    uuid         text primary key,
+    name         text,
+    is_available boolean
+);
+
+create table warehouse_products
+(
+    warehouse_uuid    text,
+    product_uuid      text,
+    quantity          int,
+    reserved_quantity int,
+
+    primary key (warehouse_uuid, product_uuid),
+    foreign key (warehouse_uuid) references warehouses (uuid),
+    foreign key (product_uuid) references products (uuid),
+
+    constraint check_quantity check (quantity >= 0),
+    constraint check_reserved_quantity check (reserved_quantity >= 0)
+);
@@ -0,0 +1 @@
+drop index if exists idx_warehouse_products_quantity;
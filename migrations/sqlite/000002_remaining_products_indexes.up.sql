@@ -0,0 +1,2 @@
+-- фильтр и сортировка по остатку внутри склада
+create index idx_warehouse_products_quantity on warehouse_products (warehouse_uuid, quantity);
@@ -0,0 +1,24 @@
+create table reservations
+(
+    uuid       text primary key,
+    status     text     not null,
+    created_at datetime not null,
+    updated_at datetime not null
+);
+
+create table reservation_items
+(
+    reservation_uuid  text,
+    product_uuid      text,
+    warehouse_uuid    text,
+    quantity          int not null,
+    released_quantity int not null default 0,
+
+    primary key (reservation_uuid, product_uuid, warehouse_uuid),
+    foreign key (reservation_uuid) references reservations (uuid),
+    foreign key (product_uuid) references products (uuid),
+    foreign key (warehouse_uuid) references warehouses (uuid),
+
+    constraint check_reservation_quantity check (quantity > 0),
+    constraint check_released_quantity check (released_quantity >= 0 and released_quantity <= quantity)
+);
@@ -0,0 +1,9 @@
+create table api_keys
+(
+    uuid       text primary key,
+    name       text     not null,
+    key_hash   text     not null unique,
+    scopes     text     not null,
+    created_at datetime not null,
+    revoked_at datetime
+);
@@ -0,0 +1 @@
+alter table api_keys add column warehouses text not null default '';
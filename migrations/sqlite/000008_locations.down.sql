@@ -0,0 +1,5 @@
+drop table if exists reservation_item_bins;
+
+drop table if exists location_stock;
+
+drop table if exists locations;
@@ -0,0 +1,53 @@
+-- места хранения на складе: зона -> проход -> стеллаж -> ячейка.
+-- path - коды от зоны до места через "/", уникален в пределах склада и задает порядок обхода при отборе
+create table locations
+(
+    uuid           text primary key,
+    warehouse_uuid text not null,
+    parent_uuid    text,
+    kind           text not null,
+    code           text not null,
+    path           text not null,
+
+    unique (warehouse_uuid, path),
+    foreign key (warehouse_uuid) references warehouses (uuid),
+    foreign key (parent_uuid) references locations (uuid)
+);
+
+-- остатки товара в ячейках. Сумма по ячейкам не больше остатка в warehouse_products,
+-- разница - товар, не размещенный по ячейкам
+create table location_stock
+(
+    location_uuid     text,
+    product_uuid      text,
+    warehouse_uuid    text not null,
+    quantity          int not null default 0,
+    reserved_quantity int not null default 0,
+
+    primary key (location_uuid, product_uuid),
+    foreign key (location_uuid) references locations (uuid),
+    foreign key (warehouse_uuid, product_uuid) references warehouse_products (warehouse_uuid, product_uuid),
+
+    constraint check_location_quantity check (quantity >= 0),
+    constraint check_location_reserved_quantity check (reserved_quantity >= 0)
+);
+
+create index idx_location_stock_product on location_stock (warehouse_uuid, product_uuid);
+
+-- ячейки, из которых собрана позиция резерва: по ним строится лист отбора и в них возвращается освобожденный товар
+create table reservation_item_bins
+(
+    reservation_uuid  text,
+    product_uuid      text,
+    warehouse_uuid    text,
+    location_uuid     text,
+    quantity          int not null,
+    released_quantity int not null default 0,
+
+    primary key (reservation_uuid, product_uuid, warehouse_uuid, location_uuid),
+    foreign key (reservation_uuid, product_uuid, warehouse_uuid) references reservation_items (reservation_uuid, product_uuid, warehouse_uuid),
+    foreign key (location_uuid) references locations (uuid),
+
+    constraint check_reservation_bin_quantity check (quantity > 0),
+    constraint check_released_bin_quantity check (released_quantity >= 0 and released_quantity <= quantity)
+);
@@ -0,0 +1,38 @@
+-- short_quantity - товар, который не нашли при отборе: он списан со склада и больше не в резерве.
+-- unfulfilled_quantity - часть short_quantity, которую не удалось зарезервировать на других складах
+alter table reservation_items add column short_quantity int not null default 0;
+alter table reservation_items add column unfulfilled_quantity int not null default 0;
+
+-- лист отбора по одному или нескольким резервам
+create table pick_lists
+(
+    uuid       text primary key,
+    status     text not null,
+    created_at datetime not null,
+    updated_at datetime not null
+);
+
+-- строка листа отбора: товар одного резерва из одной ячейки, без location_uuid - товар вне ячеек.
+-- line_no задает порядок обхода: по складам, затем по пути ячейки
+create table pick_list_lines
+(
+    pick_list_uuid   text,
+    line_no          int,
+    reservation_uuid text not null,
+    product_uuid     text not null,
+    warehouse_uuid   text not null,
+    location_uuid    text,
+    quantity         int not null,
+    picked_quantity  int not null default 0,
+    status           text not null,
+
+    primary key (pick_list_uuid, line_no),
+    foreign key (pick_list_uuid) references pick_lists (uuid),
+    foreign key (reservation_uuid, product_uuid, warehouse_uuid) references reservation_items (reservation_uuid, product_uuid, warehouse_uuid),
+    foreign key (location_uuid) references locations (uuid),
+
+    constraint check_pick_line_quantity check (quantity > 0),
+    constraint check_picked_quantity check (picked_quantity >= 0 and picked_quantity <= quantity)
+);
+
+create index idx_pick_list_lines_reservation on pick_list_lines (reservation_uuid);
@@ -0,0 +1,23 @@
+-- корректировка остатка товара на складе: delta прибавляется к свободному количеству.
+-- Корректировка больше порога ждет подтверждения администратора в статусе pending
+create table inventory_adjustments
+(
+    uuid           text primary key,
+    warehouse_uuid text not null,
+    product_uuid   text not null,
+    delta          int not null,
+    reason         text not null,
+    comment        text not null default '',
+    actor          text not null,
+    status         text not null,
+    created_at     datetime not null,
+    reviewed_by    text,
+    reviewed_at    datetime,
+
+    foreign key (warehouse_uuid) references warehouses (uuid),
+    foreign key (product_uuid) references products (uuid),
+
+    constraint check_adjustment_delta check (delta <> 0)
+);
+
+create index idx_inventory_adjustments_warehouse on inventory_adjustments (warehouse_uuid, status, created_at);
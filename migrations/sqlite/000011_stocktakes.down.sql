@@ -0,0 +1,5 @@
+drop table if exists stocktake_lines;
+
+drop table if exists stocktakes;
+
+alter table inventory_adjustments drop column location_uuid;
@@ -0,0 +1,49 @@
+-- ячейка корректировки: товар списывается из нее или оприходуется в нее, без location_uuid - по складу целиком.
+-- Без внешнего ключа: sqlite не удаляет колонку, на которую ссылается внешний ключ
+alter table inventory_adjustments add column location_uuid text;
+
+-- инвентаризация склада или зоны. Без zone_uuid пересчитываются товары склада, с зоной - товары в ячейках зоны.
+-- blind - пересчет вслепую: ожидаемые количества не показываются до закрытия
+create table stocktakes
+(
+    uuid           text primary key,
+    warehouse_uuid text not null,
+    zone_uuid      text,
+    blind          boolean not null default false,
+    status         text not null,
+    created_by     text not null,
+    created_at     datetime not null,
+    closed_by      text,
+    closed_at      datetime,
+
+    foreign key (warehouse_uuid) references warehouses (uuid),
+    foreign key (zone_uuid) references locations (uuid)
+);
+
+create index idx_stocktakes_warehouse on stocktakes (warehouse_uuid, status);
+
+-- строка инвентаризации: товар в ячейке, без location_uuid - товар на складе целиком.
+-- expected - остаток вместе с резервом при открытии, on_hand - остаток вместе с резервом в момент пересчета:
+-- расхождение считается от on_hand, поэтому резервы, отгрузки и приемка во время инвентаризации его не искажают.
+-- unadjusted - недостача, не списанная при закрытии, потому что товар к тому времени зарезервировали
+create table stocktake_lines
+(
+    stocktake_uuid  text,
+    line_no         int,
+    product_uuid    text not null,
+    location_uuid   text,
+    expected        int not null,
+    counted         int,
+    on_hand         int not null default 0,
+    counted_at      datetime,
+    adjustment_uuid text,
+    unadjusted      int not null default 0,
+
+    primary key (stocktake_uuid, line_no),
+    foreign key (stocktake_uuid) references stocktakes (uuid),
+    foreign key (product_uuid) references products (uuid),
+    foreign key (location_uuid) references locations (uuid),
+    foreign key (adjustment_uuid) references inventory_adjustments (uuid),
+
+    constraint check_stocktake_counted check (counted >= 0)
+);
@@ -0,0 +1,2 @@
+drop table if exists product_returns;
+drop table if exists quarantine_stock;
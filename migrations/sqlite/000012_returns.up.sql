@@ -0,0 +1,41 @@
+-- товар возвратов на карантине: не входит ни в quantity, ни в reserved_quantity и не резервируется
+create table quarantine_stock
+(
+    warehouse_uuid text not null,
+    product_uuid   text not null,
+    quantity       int not null default 0,
+
+    primary key (warehouse_uuid, product_uuid),
+    foreign key (warehouse_uuid) references warehouses (uuid),
+    foreign key (product_uuid) references products (uuid),
+
+    constraint check_quarantine_quantity check (quantity >= 0)
+);
+
+-- возврат товара покупателем: создан, принят на склад, осмотрен. По результату осмотра outcome товар
+-- возвращается в продажу, уходит на карантин или только списывается в учете
+create table product_returns
+(
+    uuid           text primary key,
+    warehouse_uuid text not null,
+    product_uuid   text not null,
+    quantity       int not null,
+    reference      text not null default '',
+    reason         text not null default '',
+    status         text not null,
+    outcome        text,
+    comment        text not null default '',
+    created_by     text not null,
+    created_at     datetime not null,
+    received_by    text,
+    received_at    datetime,
+    inspected_by   text,
+    inspected_at   datetime,
+
+    foreign key (warehouse_uuid) references warehouses (uuid),
+    foreign key (product_uuid) references products (uuid),
+
+    constraint check_return_quantity check (quantity > 0)
+);
+
+create index idx_product_returns_warehouse on product_returns (warehouse_uuid, status);
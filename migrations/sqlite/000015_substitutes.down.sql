@@ -0,0 +1,3 @@
+alter table reservation_items drop column substitute_for;
+
+drop table if exists product_substitutes;
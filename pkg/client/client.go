@@ -0,0 +1,598 @@
+// Package client - клиент API сервиса складов.
+//
+// Методы повторяют handler.Service. Запросы только на чтение при сетевой ошибке
+// или ответах 429, 502, 503 и 504 повторяются с экспоненциальной задержкой,
+// изменяющие запросы выполняются один раз, чтобы не зарезервировать товар дважды
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultRetries    = 3
+	defaultMinBackoff = 100 * time.Millisecond
+	defaultMaxBackoff = 2 * time.Second
+)
+
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	apiKey     string
+	token      string
+	retries    int
+	minBackoff time.Duration
+	maxBackoff time.Duration
+}
+
+type Option func(*Client)
+
+// WithHTTPClient задает http.Client, по умолчанию используется http.DefaultClient
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithAPIKey передает ключ доступа в заголовке X-API-Key
+func WithAPIKey(key string) Option {
+	return func(c *Client) {
+		c.apiKey = key
+	}
+}
+
+// WithBearerToken передает JWT в заголовке Authorization
+func WithBearerToken(token string) Option {
+	return func(c *Client) {
+		c.token = token
+	}
+}
+
+// WithRetries задает число повторов запросов на чтение, 0 отключает повторы
+func WithRetries(retries int) Option {
+	return func(c *Client) {
+		c.retries = retries
+	}
+}
+
+// WithBackoff задает задержку перед первым повтором и ее предел, задержка удваивается с каждым повтором
+func WithBackoff(min, max time.Duration) Option {
+	return func(c *Client) {
+		c.minBackoff = min
+		c.maxBackoff = max
+	}
+}
+
+// New создает клиент сервиса, доступного по baseURL, например http://warehouse:8000
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: http.DefaultClient,
+		retries:    defaultRetries,
+		minBackoff: defaultMinBackoff,
+		maxBackoff: defaultMaxBackoff,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// GetRemainingProducts возвращает страницу остатков на складе
+func (c *Client) GetRemainingProducts(ctx context.Context, warehouseUUID string, filter StockFilter) (RemainingProducts, error) {
+	var result RemainingProducts
+	path := "/api/v2/warehouses/" + url.PathEscape(warehouseUUID) + "/stock"
+	err := c.do(ctx, http.MethodGet, path, filter.query(), nil, true, &result)
+
+	return result, err
+}
+
+// GetRemainingProductsBatch возвращает остатки по списку товаров и/или складов
+func (c *Client) GetRemainingProductsBatch(ctx context.Context, query StockQuery) ([]ProductStock, error) {
+	var result []ProductStock
+	err := c.do(ctx, http.MethodPost, "/api/getRemainingProductsBatch", nil, query, true, &result)
+
+	return result, err
+}
+
+// ReserveProducts резервирует по одной штуке каждого артикула, количество задается повтором артикула
+func (c *Client) ReserveProducts(ctx context.Context, articles []string) error {
+	return c.do(ctx, http.MethodPost, "/api/reserveProducts", nil, articles, false, nil)
+}
+
+// ReserveItems резервирует позиции с количеством и ограничениями по складам
+func (c *Client) ReserveItems(ctx context.Context, items []ReserveItem) error {
+	return c.do(ctx, http.MethodPost, "/api/reserveProducts", nil, items, false, nil)
+}
+
+// ReleaseProducts освобождает резерв, сделанный ReserveProducts или ReserveItems
+func (c *Client) ReleaseProducts(ctx context.Context, articles []string) error {
+	return c.do(ctx, http.MethodPost, "/api/releaseProducts", nil, articles, false, nil)
+}
+
+// CreateReservation резервирует позиции как резерв с собственным id
+func (c *Client) CreateReservation(ctx context.Context, items []ReserveItem) (Reservation, error) {
+	var result Reservation
+	err := c.do(ctx, http.MethodPost, "/api/v2/reservations", nil, items, false, &result)
+
+	return result, err
+}
+
+func (c *Client) GetReservation(ctx context.Context, reservationUUID string) (Reservation, error) {
+	var result Reservation
+	err := c.do(ctx, http.MethodGet, "/api/v2/reservations/"+url.PathEscape(reservationUUID), nil, nil, true, &result)
+
+	return result, err
+}
+
+// ReleaseReservation возвращает на склады часть резерва, пустой items - весь оставшийся резерв
+func (c *Client) ReleaseReservation(ctx context.Context, reservationUUID string, items []ReleaseItem) (Reservation, error) {
+	var body any
+	if len(items) > 0 {
+		body = items
+	}
+
+	var result Reservation
+	path := "/api/v2/reservations/" + url.PathEscape(reservationUUID) + "/release"
+	err := c.do(ctx, http.MethodPost, path, nil, body, false, &result)
+
+	return result, err
+}
+
+// CancelReservation возвращает на склады весь оставшийся резерв и закрывает его.
+// Не повторяется: повтор после успешной отмены вернул бы ErrConflict
+func (c *Client) CancelReservation(ctx context.Context, reservationUUID string) (Reservation, error) {
+	var result Reservation
+	err := c.do(ctx, http.MethodDelete, "/api/v2/reservations/"+url.PathEscape(reservationUUID), nil, nil, false, &result)
+
+	return result, err
+}
+
+// ReceiveProducts принимает товары на склад. Повтор принял бы товар дважды, поэтому запрос не повторяется
+func (c *Client) ReceiveProducts(ctx context.Context, warehouseUUID string, items []ReceiptItem) error {
+	path := "/api/v2/warehouses/" + url.PathEscape(warehouseUUID) + "/receipts"
+	return c.do(ctx, http.MethodPost, path, nil, items, false, nil)
+}
+
+// GetSerial возвращает текущее состояние серийного номера товара
+func (c *Client) GetSerial(ctx context.Context, article string, serialNumber string) (Serial, error) {
+	var result Serial
+	err := c.do(ctx, http.MethodGet, serialPath(article, serialNumber), nil, nil, true, &result)
+
+	return result, err
+}
+
+// GetSerialHistory возвращает смены состояния серийного номера от первой к последней
+func (c *Client) GetSerialHistory(ctx context.Context, article string, serialNumber string) ([]SerialEvent, error) {
+	var result []SerialEvent
+	err := c.do(ctx, http.MethodGet, serialPath(article, serialNumber)+"/history", nil, nil, true, &result)
+
+	return result, err
+}
+
+// CreateLocation создает место хранения на складе. Повтор ответил бы ErrConflict, поэтому запрос не повторяется
+func (c *Client) CreateLocation(ctx context.Context, warehouseUUID string, location NewLocation) (Location, error) {
+	var result Location
+	path := "/api/v2/warehouses/" + url.PathEscape(warehouseUUID) + "/locations"
+	err := c.do(ctx, http.MethodPost, path, nil, location, false, &result)
+
+	return result, err
+}
+
+// GetLocations возвращает места хранения склада в порядке обхода
+func (c *Client) GetLocations(ctx context.Context, warehouseUUID string) ([]Location, error) {
+	var result []Location
+	path := "/api/v2/warehouses/" + url.PathEscape(warehouseUUID) + "/locations"
+	err := c.do(ctx, http.MethodGet, path, nil, nil, true, &result)
+
+	return result, err
+}
+
+// MoveStock перемещает свободный товар между ячейками склада. Повтор переместил бы товар дважды, поэтому запрос не повторяется
+func (c *Client) MoveStock(ctx context.Context, warehouseUUID string, moves []StockMove) error {
+	path := "/api/v2/warehouses/" + url.PathEscape(warehouseUUID) + "/moves"
+	return c.do(ctx, http.MethodPost, path, nil, moves, false, nil)
+}
+
+// MoveBucketStock перемещает товар между корзинами остатка склада. Повтор переместил бы товар дважды, поэтому запрос не повторяется
+func (c *Client) MoveBucketStock(ctx context.Context, warehouseUUID string, moves []BucketMove) error {
+	path := "/api/v2/warehouses/" + url.PathEscape(warehouseUUID) + "/bucket-moves"
+	return c.do(ctx, http.MethodPost, path, nil, moves, false, nil)
+}
+
+// GetReservationPickList возвращает лист отбора по оставшемуся резерву
+func (c *Client) GetReservationPickList(ctx context.Context, reservationUUID string) (ReservationPickList, error) {
+	var result ReservationPickList
+	path := "/api/v2/reservations/" + url.PathEscape(reservationUUID) + "/pick-list"
+	err := c.do(ctx, http.MethodGet, path, nil, nil, true, &result)
+
+	return result, err
+}
+
+// CreatePickList составляет лист отбора по активным резервам. Повтор ответил бы ErrConflict, поэтому запрос не повторяется
+func (c *Client) CreatePickList(ctx context.Context, reservationUUIDs []string) (PickList, error) {
+	var result PickList
+	body := map[string][]string{"reservations": reservationUUIDs}
+	err := c.do(ctx, http.MethodPost, "/api/v2/pick-lists", nil, body, false, &result)
+
+	return result, err
+}
+
+// GetPickList возвращает лист отбора
+func (c *Client) GetPickList(ctx context.Context, pickListUUID string) (PickList, error) {
+	var result PickList
+	err := c.do(ctx, http.MethodGet, "/api/v2/pick-lists/"+url.PathEscape(pickListUUID), nil, nil, true, &result)
+
+	return result, err
+}
+
+// ConfirmPickLine подтверждает, сколько собрано по строке листа отбора. Недостача списывается со склада
+// и резервируется на других складах. Повтор ответил бы ErrConflict, поэтому запрос не повторяется
+func (c *Client) ConfirmPickLine(ctx context.Context, pickListUUID string, line int, picked int) (PickList, error) {
+	var result PickList
+	path := "/api/v2/pick-lists/" + url.PathEscape(pickListUUID) + "/lines/" + strconv.Itoa(line) + "/confirm"
+	err := c.do(ctx, http.MethodPost, path, nil, map[string]int{"picked": picked}, false, &result)
+
+	return result, err
+}
+
+// CreateAdjustment корректирует остаток товара на складе. Корректировка выше порога возвращается
+// в статусе AdjustmentPending. Повтор применил бы корректировку дважды, поэтому запрос не повторяется
+func (c *Client) CreateAdjustment(ctx context.Context, warehouseUUID string, adjustment NewAdjustment) (Adjustment, error) {
+	var result Adjustment
+	path := "/api/v2/warehouses/" + url.PathEscape(warehouseUUID) + "/adjustments"
+	err := c.do(ctx, http.MethodPost, path, nil, adjustment, false, &result)
+
+	return result, err
+}
+
+// GetAdjustments возвращает корректировки склада, с непустым status - только в этом статусе
+func (c *Client) GetAdjustments(ctx context.Context, warehouseUUID string, status string) ([]Adjustment, error) {
+	var result []Adjustment
+	path := "/api/v2/warehouses/" + url.PathEscape(warehouseUUID) + "/adjustments"
+	query := url.Values{}
+	if status != "" {
+		query.Set("status", status)
+	}
+	err := c.do(ctx, http.MethodGet, path, query, nil, true, &result)
+
+	return result, err
+}
+
+// ApproveAdjustment применяет корректировку, ждущую подтверждения. Нужна область доступа admin
+func (c *Client) ApproveAdjustment(ctx context.Context, adjustmentUUID string) (Adjustment, error) {
+	var result Adjustment
+	path := "/api/v2/adjustments/" + url.PathEscape(adjustmentUUID) + "/approve"
+	err := c.do(ctx, http.MethodPost, path, nil, nil, false, &result)
+
+	return result, err
+}
+
+// RejectAdjustment отклоняет корректировку, ждущую подтверждения. Нужна область доступа admin
+func (c *Client) RejectAdjustment(ctx context.Context, adjustmentUUID string) (Adjustment, error) {
+	var result Adjustment
+	path := "/api/v2/adjustments/" + url.PathEscape(adjustmentUUID) + "/reject"
+	err := c.do(ctx, http.MethodPost, path, nil, nil, false, &result)
+
+	return result, err
+}
+
+// CreateStocktake открывает инвентаризацию склада или зоны. Повтор ответил бы ErrConflict, поэтому запрос не повторяется
+func (c *Client) CreateStocktake(ctx context.Context, warehouseUUID string, stocktake NewStocktake) (Stocktake, error) {
+	var result Stocktake
+	path := "/api/v2/warehouses/" + url.PathEscape(warehouseUUID) + "/stocktakes"
+	err := c.do(ctx, http.MethodPost, path, nil, stocktake, false, &result)
+
+	return result, err
+}
+
+// GetStocktake возвращает инвентаризацию
+func (c *Client) GetStocktake(ctx context.Context, stocktakeUUID string) (Stocktake, error) {
+	var result Stocktake
+	err := c.do(ctx, http.MethodGet, "/api/v2/stocktakes/"+url.PathEscape(stocktakeUUID), nil, nil, true, &result)
+
+	return result, err
+}
+
+// CountStocktake передает пересчитанные количества. Повторный пересчет заменяет прежний, поэтому запрос повторяется
+func (c *Client) CountStocktake(ctx context.Context, stocktakeUUID string, counts []StocktakeCount) (Stocktake, error) {
+	var result Stocktake
+	path := "/api/v2/stocktakes/" + url.PathEscape(stocktakeUUID) + "/counts"
+	body := map[string][]StocktakeCount{"counts": counts}
+	err := c.do(ctx, http.MethodPost, path, nil, body, true, &result)
+
+	return result, err
+}
+
+// CloseStocktake закрывает инвентаризацию с корректировками по расхождениям. Повтор ответил бы ErrConflict,
+// поэтому запрос не повторяется
+func (c *Client) CloseStocktake(ctx context.Context, stocktakeUUID string) (Stocktake, error) {
+	var result Stocktake
+	path := "/api/v2/stocktakes/" + url.PathEscape(stocktakeUUID) + "/close"
+	err := c.do(ctx, http.MethodPost, path, nil, nil, false, &result)
+
+	return result, err
+}
+
+// CreateReturn создает возврат товара покупателем. Повтор создал бы второй возврат, поэтому запрос не повторяется
+func (c *Client) CreateReturn(ctx context.Context, warehouseUUID string, ret NewReturn) (Return, error) {
+	var result Return
+	path := "/api/v2/warehouses/" + url.PathEscape(warehouseUUID) + "/returns"
+	err := c.do(ctx, http.MethodPost, path, nil, ret, false, &result)
+
+	return result, err
+}
+
+// GetReturn возвращает возврат
+func (c *Client) GetReturn(ctx context.Context, returnUUID string) (Return, error) {
+	var result Return
+	err := c.do(ctx, http.MethodGet, "/api/v2/returns/"+url.PathEscape(returnUUID), nil, nil, true, &result)
+
+	return result, err
+}
+
+// ReceiveReturn отмечает, что возврат прибыл на склад. Повтор ответил бы ErrConflict, поэтому запрос не повторяется
+func (c *Client) ReceiveReturn(ctx context.Context, returnUUID string) (Return, error) {
+	var result Return
+	path := "/api/v2/returns/" + url.PathEscape(returnUUID) + "/receive"
+	err := c.do(ctx, http.MethodPost, path, nil, nil, false, &result)
+
+	return result, err
+}
+
+// InspectReturn сохраняет результат осмотра возврата (одна из Return*). Повтор ответил бы ErrConflict,
+// поэтому запрос не повторяется
+func (c *Client) InspectReturn(ctx context.Context, returnUUID string, outcome string, comment string) (Return, error) {
+	var result Return
+	path := "/api/v2/returns/" + url.PathEscape(returnUUID) + "/inspect"
+	body := map[string]string{"outcome": outcome, "comment": comment}
+	err := c.do(ctx, http.MethodPost, path, nil, body, false, &result)
+
+	return result, err
+}
+
+// SaveKit задает состав набора, прежний состав заменяется. Нужна область доступа admin.
+// Повтор с тем же составом ничего не меняет, поэтому запрос повторяется
+func (c *Client) SaveKit(ctx context.Context, article string, components []KitComponent) (Kit, error) {
+	var result Kit
+	body := map[string][]KitComponent{"components": components}
+	err := c.do(ctx, http.MethodPut, kitPath(article), nil, body, true, &result)
+
+	return result, err
+}
+
+// GetKit возвращает состав набора и сколько наборов можно собрать
+func (c *Client) GetKit(ctx context.Context, article string) (Kit, error) {
+	var result Kit
+	err := c.do(ctx, http.MethodGet, kitPath(article), nil, nil, true, &result)
+
+	return result, err
+}
+
+// DeleteKit удаляет состав набора, артикул остается в каталоге. Нужна область доступа admin.
+// Повтор после успешного удаления вернул бы ErrNotFound, поэтому запрос не повторяется
+func (c *Client) DeleteKit(ctx context.Context, article string) error {
+	return c.do(ctx, http.MethodDelete, kitPath(article), nil, nil, false, nil)
+}
+
+// SaveSubstitutes задает замены товара в порядке, в котором они пробуются при нехватке, прежние замены заменяются.
+// Пустой список удаляет замены. Нужна область доступа admin. Повтор ничего не меняет, поэтому запрос повторяется
+func (c *Client) SaveSubstitutes(ctx context.Context, article string, rules []SubstituteRule) (Substitutes, error) {
+	var result Substitutes
+	if rules == nil {
+		rules = []SubstituteRule{}
+	}
+	body := map[string][]SubstituteRule{"substitutes": rules}
+	err := c.do(ctx, http.MethodPut, substitutesPath(article), nil, body, true, &result)
+
+	return result, err
+}
+
+// GetSubstitutes возвращает замены товара в порядке, в котором они пробуются при нехватке
+func (c *Client) GetSubstitutes(ctx context.Context, article string) (Substitutes, error) {
+	var result Substitutes
+	err := c.do(ctx, http.MethodGet, substitutesPath(article), nil, nil, true, &result)
+
+	return result, err
+}
+
+func kitPath(article string) string {
+	return "/api/v2/kits/" + url.PathEscape(article)
+}
+
+func substitutesPath(article string) string {
+	return "/api/v2/products/" + url.PathEscape(article) + "/substitutes"
+}
+
+func serialPath(article string, serialNumber string) string {
+	return "/api/v2/products/" + url.PathEscape(article) + "/serials/" + url.PathEscape(serialNumber)
+}
+
+func (f StockFilter) query() url.Values {
+	query := url.Values{}
+	if f.Limit != 0 {
+		query.Set("limit", strconv.Itoa(f.Limit))
+	}
+	if f.Cursor != "" {
+		query.Set("cursor", f.Cursor)
+	}
+	if f.ArticlePrefix != "" {
+		query.Set("article_prefix", f.ArticlePrefix)
+	}
+	if f.Name != "" {
+		query.Set("name", f.Name)
+	}
+	if f.Size != "" {
+		query.Set("size", f.Size)
+	}
+	if f.MinQuantity != 0 {
+		query.Set("min_quantity", strconv.Itoa(f.MinQuantity))
+	}
+	if f.InStock {
+		query.Set("in_stock", "true")
+	}
+	if f.IncludeZeroStock {
+		query.Set("include_zero_stock", "true")
+	}
+	if f.Sort != "" || f.Desc {
+		sort := f.Sort
+		if sort == "" {
+			sort = SortByArticle
+		}
+		if f.Desc {
+			sort = "-" + sort
+		}
+		query.Set("sort", sort)
+	}
+	if f.ByLot {
+		query.Set("by_lot", "true")
+	}
+	if f.ByLocation {
+		query.Set("by_location", "true")
+	}
+
+	return query
+}
+
+// do выполняет запрос и разбирает ответ в out. Запросы с retry повторяются при временных ошибках
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body any, retry bool, out any) error {
+	var payload []byte
+	if body != nil {
+		var err error
+		payload, err = json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("marshal request: %w", err)
+		}
+	}
+
+	target := c.baseURL + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+
+	attempts := 1
+	if retry {
+		attempts += c.retries
+	}
+
+	for attempt := 0; ; attempt++ {
+		resp, err := c.send(ctx, method, target, payload)
+		last := attempt == attempts-1
+
+		if err != nil {
+			if last || ctx.Err() != nil {
+				return err
+			}
+			if err := c.wait(ctx, attempt, 0); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if !last && retryableStatus(resp.StatusCode) {
+			retryAfter := parseRetryAfter(resp.Header.Get("Retry-After"))
+			drain(resp)
+			if err := c.wait(ctx, attempt, retryAfter); err != nil {
+				return err
+			}
+			continue
+		}
+
+		defer drain(resp)
+
+		if resp.StatusCode >= http.StatusBadRequest {
+			return decodeError(resp)
+		}
+		if out == nil {
+			return nil
+		}
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return fmt.Errorf("decode response: %w", err)
+		}
+
+		return nil
+	}
+}
+
+func (c *Client) send(ctx context.Context, method, target string, payload []byte) (*http.Response, error) {
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, target, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.apiKey != "" {
+		req.Header.Set("X-API-Key", c.apiKey)
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	return c.httpClient.Do(req)
+}
+
+// wait ждет перед повтором attempt: minBackoff * 2^attempt, но не больше maxBackoff, со случайным разбросом
+// до половины задержки. Retry-After сервера увеличивает задержку
+func (c *Client) wait(ctx context.Context, attempt int, retryAfter time.Duration) error {
+	delay := c.maxBackoff
+	if attempt < 30 {
+		delay = min(c.minBackoff<<attempt, c.maxBackoff)
+	}
+	if delay > 1 {
+		delay = delay/2 + time.Duration(rand.Int63n(int64(delay/2)))
+	}
+	delay = max(delay, retryAfter)
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func retryableStatus(status int) bool {
+	switch status {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+// parseRetryAfter разбирает Retry-After в секундах, формат с датой не поддерживается
+func parseRetryAfter(value string) time.Duration {
+	seconds, err := strconv.Atoi(value)
+	if err != nil || seconds < 0 {
+		return 0
+	}
+
+	return time.Duration(seconds) * time.Second
+}
+
+// drain дочитывает и закрывает тело, чтобы соединение вернулось в пул
+func drain(resp *http.Response) {
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<20))
+	_ = resp.Body.Close()
+}
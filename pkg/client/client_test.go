@@ -0,0 +1,718 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/shamank/warehouse-service/internal/auth"
+	"github.com/shamank/warehouse-service/internal/domain/models"
+	"github.com/shamank/warehouse-service/internal/domain/schemas"
+	"github.com/shamank/warehouse-service/internal/handler"
+	"github.com/shamank/warehouse-service/internal/handler/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const (
+	warehouseUUID   = "e4aa0556-aec5-41d4-8280-885865842719"
+	reservationUUID = "5b0f2c3e-7a41-4d8e-9c6b-2e1f0a9d8c7b"
+)
+
+// newTestServer запускает настоящий обработчик поверх мока сервиса
+func newTestServer(t *testing.T, service *mocks.Service) *httptest.Server {
+	server := httptest.NewServer(handler.NewHandler(service, slog.Default()).InitAPIRoutes())
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+// flaky отвечает status первые failures запросов, остальные передает next
+func flaky(next http.Handler, failures int32, status int, calls *atomic.Int32) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) <= failures {
+			w.WriteHeader(status)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func TestClient_GetRemainingProducts(t *testing.T) {
+	service := mocks.NewService(t)
+	service.On("GetRemainingProducts", mock.Anything, warehouseUUID, schemas.RemainingProductsFilter{
+		Name:    "nike",
+		InStock: true,
+		Sort:    schemas.SortByQuantity,
+		Desc:    true,
+		Limit:   10,
+	}).Return(schemas.RemainingProducts{
+		Warehouse: schemas.WarehouseInfo{UUID: warehouseUUID, Name: "main", IsAvailable: true},
+		Items:     []schemas.Product{{Name: "nike", Size: "XL", Code: "a1as1", Quantity: 3, Available: 3, Reserved: 1, OnHand: 4}},
+		Total:     1,
+	}, nil)
+
+	c := New(newTestServer(t, service).URL)
+
+	result, err := c.GetRemainingProducts(context.Background(), warehouseUUID, StockFilter{
+		Limit:   10,
+		Name:    "nike",
+		InStock: true,
+		Sort:    SortByQuantity,
+		Desc:    true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, RemainingProducts{
+		Warehouse: WarehouseInfo{UUID: warehouseUUID, Name: "main", IsAvailable: true},
+		Items:     []Product{{Name: "nike", Size: "XL", Code: "a1as1", Quantity: 3, Available: 3, Reserved: 1, OnHand: 4}},
+		Total:     1,
+	}, result)
+}
+
+func TestClient_GetRemainingProductsBatch(t *testing.T) {
+	service := mocks.NewService(t)
+	service.On("GetRemainingProductsBatch", mock.Anything, schemas.ProductsStockQuery{Articles: []string{"a1as1"}}).
+		Return([]schemas.ProductStock{{Code: "a1as1", Available: 2}}, nil)
+
+	c := New(newTestServer(t, service).URL)
+
+	result, err := c.GetRemainingProductsBatch(context.Background(), StockQuery{Articles: []string{"a1as1"}})
+	require.NoError(t, err)
+	assert.Equal(t, []ProductStock{{Code: "a1as1", Available: 2}}, result)
+}
+
+func TestClient_ReserveAndRelease(t *testing.T) {
+	service := mocks.NewService(t)
+	service.On("ReserveProducts", mock.Anything, []string{"a1as1", "a1as1"}).Return(nil)
+	service.On("ReserveItems", mock.Anything, []schemas.ReserveItem{{Article: "a1as1", Quantity: 2, WarehouseUUID: warehouseUUID}}).Return(nil)
+	service.On("ReleaseProducts", mock.Anything, []string{"a1as1"}).Return(nil)
+
+	c := New(newTestServer(t, service).URL)
+	ctx := context.Background()
+
+	require.NoError(t, c.ReserveProducts(ctx, []string{"a1as1", "a1as1"}))
+	require.NoError(t, c.ReserveItems(ctx, []ReserveItem{{Article: "a1as1", Quantity: 2, WarehouseUUID: warehouseUUID}}))
+	require.NoError(t, c.ReleaseProducts(ctx, []string{"a1as1"}))
+}
+
+func TestClient_Lots(t *testing.T) {
+	expiresAt := time.Date(2027, time.January, 31, 0, 0, 0, 0, time.UTC)
+
+	service := mocks.NewService(t)
+	service.On("ReceiveProducts", mock.Anything, warehouseUUID, []schemas.ReceiptItem{
+		{Article: "cream", Quantity: 4, Lot: &schemas.Lot{Number: "L-1", ExpiresAt: &schemas.Date{Time: expiresAt}}},
+	}).Return(nil)
+	service.On("GetRemainingProducts", mock.Anything, warehouseUUID, schemas.RemainingProductsFilter{Sort: schemas.SortByArticle, ByLot: true}).
+		Return(schemas.RemainingProducts{Items: []schemas.Product{{
+			Code:     "cream",
+			Quantity: 4,
+			OnHand:   4,
+			Lots: []schemas.ProductLot{
+				{Lot: schemas.Lot{Number: "L-1", ExpiresAt: &schemas.Date{Time: expiresAt}}, Quantity: 4},
+			},
+		}}}, nil)
+
+	c := New(newTestServer(t, service).URL)
+	ctx := context.Background()
+
+	err := c.ReceiveProducts(ctx, warehouseUUID, []ReceiptItem{
+		{Article: "cream", Quantity: 4, Lot: &Lot{Number: "L-1", ExpiresAt: "2027-01-31"}},
+	})
+	require.NoError(t, err)
+
+	result, err := c.GetRemainingProducts(ctx, warehouseUUID, StockFilter{ByLot: true})
+	require.NoError(t, err)
+	assert.Equal(t, []ProductLot{{Lot: Lot{Number: "L-1", ExpiresAt: "2027-01-31"}, Quantity: 4}}, result.Items[0].Lots)
+}
+
+func TestClient_Serials(t *testing.T) {
+	updated := time.Date(2026, time.October, 19, 10, 0, 0, 0, time.UTC)
+
+	service := mocks.NewService(t)
+	service.On("ReceiveProducts", mock.Anything, warehouseUUID, []schemas.ReceiptItem{
+		{Article: "phone", Quantity: 1, Serials: []string{"SN 1"}},
+	}).Return(nil)
+	service.On("GetSerial", mock.Anything, "phone", "SN 1").Return(schemas.Serial{
+		Article:       "phone",
+		SerialNumber:  "SN 1",
+		WarehouseUUID: warehouseUUID,
+		Status:        models.SerialAvailable,
+		UpdatedAt:     updated,
+	}, nil)
+	service.On("GetSerialHistory", mock.Anything, "phone", "SN 1").Return([]schemas.SerialEvent{
+		{Status: models.SerialAvailable, WarehouseUUID: warehouseUUID, CreatedAt: updated},
+	}, nil)
+
+	c := New(newTestServer(t, service).URL)
+	ctx := context.Background()
+
+	err := c.ReceiveProducts(ctx, warehouseUUID, []ReceiptItem{{Article: "phone", Quantity: 1, Serials: []string{"SN 1"}}})
+	require.NoError(t, err)
+
+	// номер с пробелом должен экранироваться в пути
+	serial, err := c.GetSerial(ctx, "phone", "SN 1")
+	require.NoError(t, err)
+	assert.Equal(t, Serial{
+		Article:       "phone",
+		SerialNumber:  "SN 1",
+		WarehouseUUID: warehouseUUID,
+		Status:        SerialAvailable,
+		UpdatedAt:     updated,
+	}, serial)
+
+	history, err := c.GetSerialHistory(ctx, "phone", "SN 1")
+	require.NoError(t, err)
+	assert.Equal(t, []SerialEvent{{Status: SerialAvailable, WarehouseUUID: warehouseUUID, CreatedAt: updated}}, history)
+}
+
+func TestClient_Locations(t *testing.T) {
+	const zoneUUID = "6a2f4c81-3b5d-4e97-a0c6-8d1e2f3b4c5a"
+
+	zone := schemas.Location{ID: zoneUUID, Kind: models.LocationZone, Code: "A", Path: "A"}
+
+	service := mocks.NewService(t)
+	service.On("CreateLocation", mock.Anything, warehouseUUID, schemas.NewLocation{Kind: models.LocationZone, Code: "A"}).Return(zone, nil)
+	service.On("GetLocations", mock.Anything, warehouseUUID).Return([]schemas.Location{zone}, nil)
+	service.On("MoveStock", mock.Anything, warehouseUUID, []schemas.StockMove{{Article: "soap", Quantity: 2, To: "A/1/1/01"}}).Return(nil)
+	service.On("GetReservationPickList", mock.Anything, reservationUUID).Return(schemas.ReservationPickList{
+		ReservationID: reservationUUID,
+		Lines:         []schemas.PickLine{{WarehouseUUID: warehouseUUID, Location: "A/1/1/01", Article: "soap", Quantity: 2}},
+	}, nil)
+
+	c := New(newTestServer(t, service).URL)
+	ctx := context.Background()
+
+	location, err := c.CreateLocation(ctx, warehouseUUID, NewLocation{Kind: LocationZone, Code: "A"})
+	require.NoError(t, err)
+	assert.Equal(t, Location{ID: zoneUUID, Kind: LocationZone, Code: "A", Path: "A"}, location)
+
+	locations, err := c.GetLocations(ctx, warehouseUUID)
+	require.NoError(t, err)
+	assert.Equal(t, []Location{location}, locations)
+
+	require.NoError(t, c.MoveStock(ctx, warehouseUUID, []StockMove{{Article: "soap", Quantity: 2, To: "A/1/1/01"}}))
+
+	pickList, err := c.GetReservationPickList(ctx, reservationUUID)
+	require.NoError(t, err)
+	assert.Equal(t, ReservationPickList{
+		ReservationID: reservationUUID,
+		Lines:         []PickLine{{WarehouseUUID: warehouseUUID, Location: "A/1/1/01", Article: "soap", Quantity: 2}},
+	}, pickList)
+}
+
+func TestClient_PickLists(t *testing.T) {
+	const pickListUUID = "8e3b1f6a-2c7d-4a90-b5e4-6d1c9f2a7b03"
+
+	created := time.Date(2026, time.October, 19, 11, 0, 0, 0, time.UTC)
+	pickList := schemas.PickList{
+		ID:        pickListUUID,
+		Status:    models.PickListOpen,
+		CreatedAt: created,
+		UpdatedAt: created,
+		Lines: []schemas.PickListLine{
+			{Line: 1, ReservationID: reservationUUID, WarehouseUUID: warehouseUUID, Location: "A/1/1/01", Article: "soap", Quantity: 2, Status: models.PickLinePending},
+		},
+	}
+	confirmed := pickList
+	confirmed.Status = models.PickListCompleted
+	confirmed.Lines = []schemas.PickListLine{
+		{Line: 1, ReservationID: reservationUUID, WarehouseUUID: warehouseUUID, Location: "A/1/1/01", Article: "soap", Quantity: 2, Picked: 1, Status: models.PickLineShort},
+	}
+
+	service := mocks.NewService(t)
+	service.On("CreatePickList", mock.Anything, schemas.NewPickList{Reservations: []string{reservationUUID}}).Return(pickList, nil)
+	service.On("GetPickList", mock.Anything, pickListUUID).Return(pickList, nil)
+	service.On("ConfirmPickLine", mock.Anything, pickListUUID, 1, 1).Return(confirmed, nil)
+
+	c := New(newTestServer(t, service).URL)
+	ctx := context.Background()
+
+	expected := PickList{
+		ID:        pickListUUID,
+		Status:    PickListOpen,
+		CreatedAt: created,
+		UpdatedAt: created,
+		Lines: []PickListLine{
+			{Line: 1, ReservationID: reservationUUID, WarehouseUUID: warehouseUUID, Location: "A/1/1/01", Article: "soap", Quantity: 2, Status: PickLinePending},
+		},
+	}
+
+	result, err := c.CreatePickList(ctx, []string{reservationUUID})
+	require.NoError(t, err)
+	assert.Equal(t, expected, result)
+
+	result, err = c.GetPickList(ctx, pickListUUID)
+	require.NoError(t, err)
+	assert.Equal(t, expected, result)
+
+	result, err = c.ConfirmPickLine(ctx, pickListUUID, 1, 1)
+	require.NoError(t, err)
+	assert.Equal(t, PickListCompleted, result.Status)
+	assert.Equal(t, PickListLine{
+		Line: 1, ReservationID: reservationUUID, WarehouseUUID: warehouseUUID, Location: "A/1/1/01", Article: "soap", Quantity: 2, Picked: 1, Status: PickLineShort,
+	}, result.Lines[0])
+}
+
+func TestClient_Adjustments(t *testing.T) {
+	const adjustmentUUID = "7b3e9a2c-4d1f-4e8b-9c6a-0f5d2e8b1a47"
+
+	created := time.Date(2026, time.October, 19, 12, 0, 0, 0, time.UTC)
+	reviewed := created.Add(time.Hour)
+	pending := schemas.Adjustment{
+		ID: adjustmentUUID, WarehouseUUID: warehouseUUID, Article: "soap", Delta: -200, Reason: models.AdjustmentLost,
+		Actor: "inventory", Status: models.AdjustmentPending, CreatedAt: created,
+	}
+	approved := pending
+	approved.Status = models.AdjustmentApplied
+	approved.ReviewedBy = "supervisor"
+	approved.ReviewedAt = &reviewed
+
+	service := mocks.NewService(t)
+	service.On("CreateAdjustment", mock.Anything, warehouseUUID,
+		schemas.NewAdjustment{Article: "soap", Delta: -200, Reason: models.AdjustmentLost, Comment: "inventory count"}).Return(pending, nil)
+	service.On("GetAdjustments", mock.Anything, warehouseUUID, models.AdjustmentPending).Return([]schemas.Adjustment{pending}, nil)
+	service.On("ApproveAdjustment", mock.Anything, adjustmentUUID).Return(approved, nil)
+
+	c := New(newTestServer(t, service).URL)
+	ctx := context.Background()
+
+	expected := Adjustment{
+		ID: adjustmentUUID, WarehouseUUID: warehouseUUID, Article: "soap", Delta: -200, Reason: AdjustmentLost,
+		Actor: "inventory", Status: AdjustmentPending, CreatedAt: created,
+	}
+
+	result, err := c.CreateAdjustment(ctx, warehouseUUID, NewAdjustment{Article: "soap", Delta: -200, Reason: AdjustmentLost, Comment: "inventory count"})
+	require.NoError(t, err)
+	assert.Equal(t, expected, result)
+
+	adjustments, err := c.GetAdjustments(ctx, warehouseUUID, AdjustmentPending)
+	require.NoError(t, err)
+	assert.Equal(t, []Adjustment{expected}, adjustments)
+
+	result, err = c.ApproveAdjustment(ctx, adjustmentUUID)
+	require.NoError(t, err)
+	assert.Equal(t, AdjustmentApplied, result.Status)
+	assert.Equal(t, "supervisor", result.ReviewedBy)
+	require.NotNil(t, result.ReviewedAt)
+	assert.True(t, reviewed.Equal(*result.ReviewedAt))
+}
+
+func TestClient_Stocktakes(t *testing.T) {
+	const stocktakeUUID = "5e1c7a3b-2f4d-4c8e-9a6b-1d0f3e7c2b58"
+
+	created := time.Date(2026, time.October, 19, 12, 0, 0, 0, time.UTC)
+	counted := 4
+	blind := schemas.Stocktake{
+		ID: stocktakeUUID, WarehouseUUID: warehouseUUID, Blind: true, Status: models.StocktakeOpen, CreatedAt: created,
+		Lines: []schemas.StocktakeLine{{Line: 1, Article: "soap"}},
+	}
+	expected, onHand, variance := 5, 5, -1
+	closed := schemas.Stocktake{
+		ID: stocktakeUUID, WarehouseUUID: warehouseUUID, Blind: true, Status: models.StocktakeClosed, CreatedAt: created,
+		Lines: []schemas.StocktakeLine{{
+			Line: 1, Article: "soap", Expected: &expected, Counted: &counted, OnHand: &onHand, Variance: &variance,
+			AdjustmentID: "7b3e9a2c-4d1f-4e8b-9c6a-0f5d2e8b1a47",
+		}},
+	}
+
+	service := mocks.NewService(t)
+	service.On("CreateStocktake", mock.Anything, warehouseUUID, schemas.NewStocktake{Blind: true}).Return(blind, nil)
+	service.On("CountStocktake", mock.Anything, stocktakeUUID, []schemas.StocktakeCount{{Article: "soap", Counted: &counted}}).Return(blind, nil)
+	service.On("CloseStocktake", mock.Anything, stocktakeUUID).Return(closed, nil)
+
+	c := New(newTestServer(t, service).URL)
+	ctx := context.Background()
+
+	result, err := c.CreateStocktake(ctx, warehouseUUID, NewStocktake{Blind: true})
+	require.NoError(t, err)
+	assert.Equal(t, StocktakeOpen, result.Status)
+	assert.Equal(t, []StocktakeLine{{Line: 1, Article: "soap"}}, result.Lines)
+
+	_, err = c.CountStocktake(ctx, stocktakeUUID, []StocktakeCount{{Article: "soap", Counted: 4}})
+	require.NoError(t, err)
+
+	result, err = c.CloseStocktake(ctx, stocktakeUUID)
+	require.NoError(t, err)
+	assert.Equal(t, StocktakeClosed, result.Status)
+	require.Len(t, result.Lines, 1)
+	require.NotNil(t, result.Lines[0].Variance)
+	assert.Equal(t, -1, *result.Lines[0].Variance)
+	assert.Equal(t, "7b3e9a2c-4d1f-4e8b-9c6a-0f5d2e8b1a47", result.Lines[0].AdjustmentID)
+}
+
+func TestClient_Returns(t *testing.T) {
+	const returnUUID = "3a4b5c6d-7e8f-4a9b-8c0d-1e2f3a4b5c6d"
+
+	created := time.Date(2026, time.October, 19, 12, 0, 0, 0, time.UTC)
+	ret := schemas.Return{
+		ID: returnUUID, WarehouseUUID: warehouseUUID, Article: "soap", Quantity: 2, Reference: "order-42",
+		Status: models.ReturnCreated, CreatedAt: created,
+	}
+	inspected := ret
+	inspected.Status = models.ReturnInspected
+	inspected.Outcome = models.ReturnScrap
+	inspected.Comment = "broken"
+
+	service := mocks.NewService(t)
+	service.On("CreateReturn", mock.Anything, warehouseUUID, schemas.NewReturn{Article: "soap", Quantity: 2, Reference: "order-42"}).
+		Return(ret, nil)
+	service.On("InspectReturn", mock.Anything, returnUUID, schemas.ReturnInspection{Outcome: models.ReturnScrap, Comment: "broken"}).
+		Return(inspected, nil)
+	service.On("GetReturn", mock.Anything, returnUUID).Return(schemas.Return{}, models.ErrReturnNotFound)
+
+	c := New(newTestServer(t, service).URL)
+	ctx := context.Background()
+
+	result, err := c.CreateReturn(ctx, warehouseUUID, NewReturn{Article: "soap", Quantity: 2, Reference: "order-42"})
+	require.NoError(t, err)
+	assert.Equal(t, Return{
+		ID: returnUUID, WarehouseUUID: warehouseUUID, Article: "soap", Quantity: 2, Reference: "order-42",
+		Status: ReturnCreated, CreatedAt: created,
+	}, result)
+
+	result, err = c.InspectReturn(ctx, returnUUID, ReturnScrap, "broken")
+	require.NoError(t, err)
+	assert.Equal(t, ReturnInspected, result.Status)
+	assert.Equal(t, ReturnScrap, result.Outcome)
+
+	_, err = c.GetReturn(ctx, returnUUID)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestClient_Buckets(t *testing.T) {
+	service := mocks.NewService(t)
+	service.On("MoveBucketStock", mock.Anything, warehouseUUID, []schemas.BucketMove{
+		{Article: "soap", Quantity: 2, From: models.BucketSellable, To: models.BucketDamaged},
+	}).Return(nil)
+	service.On("MoveBucketStock", mock.Anything, warehouseUUID, []schemas.BucketMove{
+		{Article: "soap", Quantity: 9, From: models.BucketDamaged, To: models.BucketSellable},
+	}).Return(fmt.Errorf("%w: soap in damaged", models.ErrNotEnoughProducts))
+	service.On("GetRemainingProductsBatch", mock.Anything, schemas.ProductsStockQuery{Articles: []string{"soap"}}).
+		Return([]schemas.ProductStock{{Code: "soap", Warehouses: []schemas.WarehouseStock{
+			{WarehouseUUID: warehouseUUID, IsAvailable: true, Available: 3, Buckets: map[string]int{models.BucketDamaged: 2}},
+		}}}, nil)
+
+	c := New(newTestServer(t, service).URL)
+	ctx := context.Background()
+
+	require.NoError(t, c.MoveBucketStock(ctx, warehouseUUID, []BucketMove{{Article: "soap", Quantity: 2, From: BucketSellable, To: BucketDamaged}}))
+
+	err := c.MoveBucketStock(ctx, warehouseUUID, []BucketMove{{Article: "soap", Quantity: 9, From: BucketDamaged, To: BucketSellable}})
+	assert.ErrorIs(t, err, ErrConflict)
+
+	result, err := c.GetRemainingProductsBatch(ctx, StockQuery{Articles: []string{"soap"}})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{BucketDamaged: 2}, result[0].Warehouses[0].Buckets)
+}
+
+func TestClient_Kits(t *testing.T) {
+	kit := schemas.Kit{
+		Article:    "gift",
+		Components: []schemas.KitComponent{{Article: "soap", Quantity: 1}, {Article: "cream", Quantity: 2}},
+		Available:  3,
+		Warehouses: []schemas.WarehouseStock{{WarehouseUUID: warehouseUUID, IsAvailable: true, Available: 3}},
+	}
+
+	service := mocks.NewService(t)
+	service.On("SaveKit", mock.Anything, "gift", schemas.KitDefinition{Components: kit.Components}).Return(kit, nil)
+	service.On("GetKit", mock.Anything, "gift").Return(kit, nil)
+	service.On("DeleteKit", mock.Anything, "gift").Return(nil).Once()
+	service.On("DeleteKit", mock.Anything, "gift").Return(fmt.Errorf("%w: gift", models.ErrKitNotFound))
+
+	c := New(newTestServer(t, service).URL)
+	ctx := context.Background()
+
+	expected := Kit{
+		Article:    "gift",
+		Components: []KitComponent{{Article: "soap", Quantity: 1}, {Article: "cream", Quantity: 2}},
+		Available:  3,
+		Warehouses: []WarehouseStock{{WarehouseUUID: warehouseUUID, IsAvailable: true, Available: 3}},
+	}
+
+	result, err := c.SaveKit(ctx, "gift", expected.Components)
+	require.NoError(t, err)
+	assert.Equal(t, expected, result)
+
+	result, err = c.GetKit(ctx, "gift")
+	require.NoError(t, err)
+	assert.Equal(t, expected, result)
+
+	require.NoError(t, c.DeleteKit(ctx, "gift"))
+	assert.ErrorIs(t, c.DeleteKit(ctx, "gift"), ErrNotFound)
+}
+
+func TestClient_Substitutes(t *testing.T) {
+	substitutes := schemas.Substitutes{
+		Article:     "shirt-m",
+		Substitutes: []schemas.Substitute{{Article: "shirt-m-blue", Name: "shirt", Size: "M", SameSize: true}},
+	}
+
+	service := mocks.NewService(t)
+	service.On("SaveSubstitutes", mock.Anything, "shirt-m", schemas.SubstitutesDefinition{
+		Substitutes: []schemas.SubstituteRule{{Article: "shirt-m-blue", SameSize: true}},
+	}).Return(substitutes, nil)
+	service.On("SaveSubstitutes", mock.Anything, "shirt-m", schemas.SubstitutesDefinition{Substitutes: []schemas.SubstituteRule{}}).
+		Return(schemas.Substitutes{Article: "shirt-m", Substitutes: []schemas.Substitute{}}, nil)
+	service.On("GetSubstitutes", mock.Anything, "shirt-m").Return(substitutes, nil)
+	service.On("CreateReservation", mock.Anything, []schemas.ReserveItem{{Article: "shirt-m", Quantity: 2, AllowSubstitutes: true}}).
+		Return(schemas.Reservation{ID: reservationUUID, Status: models.ReservationActive, Items: []schemas.ReservationItem{
+			{Article: "shirt-m-blue", WarehouseUUID: warehouseUUID, Quantity: 2, SubstituteFor: "shirt-m"},
+		}}, nil)
+
+	c := New(newTestServer(t, service).URL)
+	ctx := context.Background()
+
+	expected := Substitutes{Article: "shirt-m", Substitutes: []Substitute{{Article: "shirt-m-blue", Name: "shirt", Size: "M", SameSize: true}}}
+
+	result, err := c.SaveSubstitutes(ctx, "shirt-m", []SubstituteRule{{Article: "shirt-m-blue", SameSize: true}})
+	require.NoError(t, err)
+	assert.Equal(t, expected, result)
+
+	result, err = c.GetSubstitutes(ctx, "shirt-m")
+	require.NoError(t, err)
+	assert.Equal(t, expected, result)
+
+	result, err = c.SaveSubstitutes(ctx, "shirt-m", nil)
+	require.NoError(t, err)
+	assert.Empty(t, result.Substitutes)
+
+	reservation, err := c.CreateReservation(ctx, []ReserveItem{{Article: "shirt-m", Quantity: 2, AllowSubstitutes: true}})
+	require.NoError(t, err)
+	assert.Equal(t, []ReservationItem{{Article: "shirt-m-blue", WarehouseUUID: warehouseUUID, Quantity: 2, SubstituteFor: "shirt-m"}},
+		reservation.Items)
+}
+
+func TestClient_Reservations(t *testing.T) {
+	created := time.Date(2026, time.October, 19, 10, 0, 0, 0, time.UTC)
+	reservation := schemas.Reservation{
+		ID:        reservationUUID,
+		Status:    models.ReservationActive,
+		CreatedAt: created,
+		UpdatedAt: created,
+		Items:     []schemas.ReservationItem{{Article: "a1as1", WarehouseUUID: warehouseUUID, Quantity: 5}},
+	}
+	expected := Reservation{
+		ID:        reservationUUID,
+		Status:    ReservationActive,
+		CreatedAt: created,
+		UpdatedAt: created,
+		Items:     []ReservationItem{{Article: "a1as1", WarehouseUUID: warehouseUUID, Quantity: 5}},
+	}
+
+	service := mocks.NewService(t)
+	service.On("CreateReservation", mock.Anything, []schemas.ReserveItem{{Article: "a1as1", Quantity: 5}}).Return(reservation, nil)
+	service.On("GetReservation", mock.Anything, reservationUUID).Return(reservation, nil)
+	service.On("ReleaseReservation", mock.Anything, reservationUUID, []schemas.ReleaseItem{{Article: "a1as1", Quantity: 2}}).Return(reservation, nil)
+	service.On("ReleaseReservation", mock.Anything, reservationUUID, []schemas.ReleaseItem(nil)).Return(reservation, nil)
+	service.On("CancelReservation", mock.Anything, reservationUUID).Return(reservation, nil)
+
+	c := New(newTestServer(t, service).URL)
+	ctx := context.Background()
+
+	result, err := c.CreateReservation(ctx, []ReserveItem{{Article: "a1as1", Quantity: 5}})
+	require.NoError(t, err)
+	assert.Equal(t, expected, result)
+
+	result, err = c.GetReservation(ctx, reservationUUID)
+	require.NoError(t, err)
+	assert.Equal(t, expected, result)
+
+	result, err = c.ReleaseReservation(ctx, reservationUUID, []ReleaseItem{{Article: "a1as1", Quantity: 2}})
+	require.NoError(t, err)
+	assert.Equal(t, expected, result)
+
+	result, err = c.ReleaseReservation(ctx, reservationUUID, nil)
+	require.NoError(t, err)
+	assert.Equal(t, expected, result)
+
+	result, err = c.CancelReservation(ctx, reservationUUID)
+	require.NoError(t, err)
+	assert.Equal(t, expected, result)
+}
+
+func TestClient_Errors(t *testing.T) {
+	testCases := []struct {
+		name  string
+		setup func(service *mocks.Service)
+		call  func(c *Client) error
+
+		expectedError  error
+		expectedStatus int
+		expectedText   string
+	}{
+		{
+			name: "unknown warehouse",
+			setup: func(service *mocks.Service) {
+				service.On("GetRemainingProducts", mock.Anything, warehouseUUID, mock.Anything).
+					Return(schemas.RemainingProducts{}, models.ErrWarehouseNotFound)
+			},
+			call: func(c *Client) error {
+				_, err := c.GetRemainingProducts(context.Background(), warehouseUUID, StockFilter{})
+				return err
+			},
+			expectedError:  ErrNotFound,
+			expectedStatus: 404,
+			expectedText:   "warehouse not found",
+		},
+		{
+			name: "request rejected by spec",
+			call: func(c *Client) error {
+				_, err := c.GetReservation(context.Background(), "123")
+				return err
+			},
+			expectedError:  ErrBadRequest,
+			expectedStatus: 400,
+			expectedText:   `path parameter "id": string doesn't match the format "uuid" (not a valid uuid)`,
+		},
+		{
+			name: "not enough products",
+			setup: func(service *mocks.Service) {
+				service.On("CreateReservation", mock.Anything, mock.Anything).Return(schemas.Reservation{}, models.ErrNotEnoughProducts)
+			},
+			call: func(c *Client) error {
+				_, err := c.CreateReservation(context.Background(), []ReserveItem{{Article: "a1as1", Quantity: 500}})
+				return err
+			},
+			expectedError:  ErrConflict,
+			expectedStatus: 409,
+			expectedText:   "not enough products in warehouses",
+		},
+		{
+			name: "v1 reserve error",
+			setup: func(service *mocks.Service) {
+				service.On("ReserveProducts", mock.Anything, []string{"a1as1"}).Return(models.ErrNotEnoughProducts)
+			},
+			call: func(c *Client) error {
+				return c.ReserveProducts(context.Background(), []string{"a1as1"})
+			},
+			expectedError:  ErrBadRequest,
+			expectedStatus: 400,
+			expectedText:   "not enough products in warehouses",
+		},
+		{
+			name: "internal error",
+			setup: func(service *mocks.Service) {
+				service.On("GetReservation", mock.Anything, reservationUUID).Return(schemas.Reservation{}, errors.New("connection refused"))
+			},
+			call: func(c *Client) error {
+				_, err := c.GetReservation(context.Background(), reservationUUID)
+				return err
+			},
+			expectedError:  ErrServer,
+			expectedStatus: 500,
+			expectedText:   "internal server error",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			service := mocks.NewService(t)
+			if testCase.setup != nil {
+				testCase.setup(service)
+			}
+
+			c := New(newTestServer(t, service).URL, WithBackoff(time.Millisecond, time.Millisecond))
+
+			err := testCase.call(c)
+
+			assert.ErrorIs(t, err, testCase.expectedError)
+			var apiErr *Error
+			require.ErrorAs(t, err, &apiErr)
+			assert.Equal(t, testCase.expectedStatus, apiErr.StatusCode)
+			assert.Equal(t, testCase.expectedText, apiErr.Message)
+		})
+	}
+}
+
+func TestClient_Retries(t *testing.T) {
+	service := mocks.NewService(t)
+	service.On("GetReservation", mock.Anything, reservationUUID).Return(schemas.Reservation{ID: reservationUUID}, nil)
+
+	routes := handler.NewHandler(service, slog.Default()).InitAPIRoutes()
+
+	t.Run("read is retried", func(t *testing.T) {
+		var calls atomic.Int32
+		server := httptest.NewServer(flaky(routes, 2, http.StatusServiceUnavailable, &calls))
+		defer server.Close()
+
+		c := New(server.URL, WithBackoff(time.Millisecond, 5*time.Millisecond))
+
+		result, err := c.GetReservation(context.Background(), reservationUUID)
+		require.NoError(t, err)
+		assert.Equal(t, reservationUUID, result.ID)
+		assert.Equal(t, int32(3), calls.Load())
+	})
+
+	t.Run("retries are limited", func(t *testing.T) {
+		var calls atomic.Int32
+		server := httptest.NewServer(flaky(routes, 10, http.StatusBadGateway, &calls))
+		defer server.Close()
+
+		c := New(server.URL, WithRetries(2), WithBackoff(time.Millisecond, 5*time.Millisecond))
+
+		_, err := c.GetReservation(context.Background(), reservationUUID)
+		assert.ErrorIs(t, err, ErrServer)
+		assert.Equal(t, int32(3), calls.Load())
+	})
+
+	t.Run("write is not retried", func(t *testing.T) {
+		var calls atomic.Int32
+		server := httptest.NewServer(flaky(routes, 1, http.StatusServiceUnavailable, &calls))
+		defer server.Close()
+
+		c := New(server.URL, WithBackoff(time.Millisecond, 5*time.Millisecond))
+
+		err := c.ReserveProducts(context.Background(), []string{"a1as1"})
+		assert.ErrorIs(t, err, ErrServer)
+		assert.Equal(t, int32(1), calls.Load())
+	})
+
+	t.Run("context cancels backoff", func(t *testing.T) {
+		var calls atomic.Int32
+		server := httptest.NewServer(flaky(routes, 10, http.StatusServiceUnavailable, &calls))
+		defer server.Close()
+
+		c := New(server.URL, WithBackoff(time.Hour, time.Hour))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		_, err := c.GetReservation(ctx, reservationUUID)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Equal(t, int32(1), calls.Load())
+	})
+}
+
+func TestClient_Credentials(t *testing.T) {
+	const key = "whk_secret"
+
+	service := mocks.NewService(t)
+	service.On("GetReservation", mock.Anything, reservationUUID).Return(schemas.Reservation{ID: reservationUUID}, nil)
+
+	authenticator := mocks.NewAuthenticator(t)
+	authenticator.On("Authenticate", mock.MatchedBy(func(r *http.Request) bool {
+		return r.Header.Get("X-API-Key") == key
+	})).Return(auth.Principal{Subject: "billing", Scopes: []string{auth.ScopeStockRead}}, nil)
+	authenticator.On("Authenticate", mock.Anything).Return(auth.Principal{}, auth.ErrNoCredentials)
+
+	h := handler.NewHandler(service, slog.Default())
+	h.SetAuthenticator(authenticator)
+	server := httptest.NewServer(h.InitAPIRoutes())
+	defer server.Close()
+
+	_, err := New(server.URL).GetReservation(context.Background(), reservationUUID)
+	assert.ErrorIs(t, err, ErrUnauthorized)
+
+	result, err := New(server.URL, WithAPIKey(key)).GetReservation(context.Background(), reservationUUID)
+	require.NoError(t, err)
+	assert.Equal(t, reservationUUID, result.ID)
+
+	_, err = New(server.URL, WithAPIKey(key)).CancelReservation(context.Background(), reservationUUID)
+	assert.ErrorIs(t, err, ErrForbidden)
+}
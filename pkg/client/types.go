@@ -0,0 +1,463 @@
+package client
+
+import "time"
+
+// поля сортировки остатков, с Desc - по убыванию
+const (
+	SortByArticle  = "article"
+	SortByName     = "name"
+	SortByQuantity = "quantity"
+)
+
+// статусы резерва
+const (
+	ReservationActive    = "active"
+	ReservationReleased  = "released"
+	ReservationCancelled = "cancelled"
+)
+
+// уровни мест хранения, от зоны до ячейки. Товар хранится только в ячейках
+const (
+	LocationZone  = "zone"
+	LocationAisle = "aisle"
+	LocationShelf = "shelf"
+	LocationBin   = "bin"
+)
+
+// статусы серийного номера
+const (
+	SerialAvailable = "available"
+	SerialReserved  = "reserved"
+	SerialShipped   = "shipped"
+	SerialReturned  = "returned"
+	SerialMissing   = "missing"
+)
+
+// статусы листа отбора и его строк
+const (
+	PickListOpen      = "open"
+	PickListCompleted = "completed"
+
+	PickLinePending = "pending"
+	PickLinePicked  = "picked"
+	PickLineShort   = "short"
+)
+
+// причины корректировки остатка: повреждение и потеря только списывают, находка только оприходует
+const (
+	AdjustmentDamaged    = "damaged"
+	AdjustmentLost       = "lost"
+	AdjustmentFound      = "found"
+	AdjustmentCorrection = "correction"
+)
+
+// статусы инвентаризации
+const (
+	StocktakeOpen   = "open"
+	StocktakeClosed = "closed"
+)
+
+// шаги возврата
+const (
+	ReturnCreated   = "created"
+	ReturnReceived  = "received"
+	ReturnInspected = "inspected"
+)
+
+// результаты осмотра возврата: в продажу, на карантин или в утилизацию
+const (
+	ReturnRestock    = "restock"
+	ReturnQuarantine = "quarantine"
+	ReturnScrap      = "scrap"
+)
+
+// корзины остатка. Продается и резервируется только BucketSellable, остальные видны в остатках в Buckets
+const (
+	BucketSellable   = "sellable"
+	BucketQuarantine = "quarantine"
+	BucketDamaged    = "damaged"
+	BucketInTransit  = "in_transit"
+)
+
+// статусы корректировки. AdjustmentPending - выше порога, ждет подтверждения администратора
+const (
+	AdjustmentPending  = "pending"
+	AdjustmentApplied  = "applied"
+	AdjustmentRejected = "rejected"
+)
+
+type (
+	// StockFilter - фильтры, сортировка и страница остатков на складе. Нулевые значения не передаются
+	StockFilter struct {
+		Limit         int    // по умолчанию 50, не больше 500
+		Cursor        string // NextCursor предыдущей страницы
+		ArticlePrefix string
+		Name          string // поиск по подстроке без учета регистра
+		Size          string
+		MinQuantity   int
+		InStock       bool
+		// товары без остатка и без резерва по умолчанию не возвращаются
+		IncludeZeroStock bool
+		Sort             string // одно из SortBy*, по умолчанию SortByArticle
+		Desc             bool
+		ByLot            bool // вернуть остатки по партиям в Product.Lots
+		ByLocation       bool // вернуть остатки по ячейкам в Product.Bins
+	}
+
+	// RemainingProducts - страница остатков на складе. NextCursor равен nil на последней странице
+	RemainingProducts struct {
+		Warehouse  WarehouseInfo `json:"warehouse"`
+		Items      []Product     `json:"items"`
+		NextCursor *string       `json:"next_cursor"`
+		Total      int           `json:"total"`
+	}
+
+	WarehouseInfo struct {
+		UUID        string `json:"uuid"`
+		Name        string `json:"name"`
+		IsAvailable bool   `json:"is_available"`
+	}
+
+	// Product - остаток товара на складе. Available - сколько можно продать,
+	// OnHand - продаваемый товар на складе вместе с резервом, Expired - свободный остаток в просроченных партиях,
+	// Buckets - непродаваемый остаток по корзинам
+	Product struct {
+		Name      string         `json:"name"`
+		Size      string         `json:"size"`
+		Code      string         `json:"code"`
+		Quantity  int            `json:"quantity"`
+		Available int            `json:"available"`
+		Reserved  int            `json:"reserved"`
+		OnHand    int            `json:"on_hand"`
+		Expired   int            `json:"expired"`
+		Lots      []ProductLot   `json:"lots,omitempty"`
+		Bins      []ProductBin   `json:"bins,omitempty"`
+		Buckets   map[string]int `json:"buckets,omitempty"`
+	}
+
+	// Lot - партия товара. Даты в формате YYYY-MM-DD, пустая строка - дата не указана
+	Lot struct {
+		Number         string `json:"number"`
+		ManufacturedAt string `json:"manufactured_at,omitempty"`
+		ExpiresAt      string `json:"expires_at,omitempty"`
+	}
+
+	// ProductLot - остаток партии на складе. Просроченная партия не резервируется
+	ProductLot struct {
+		Lot
+		Quantity int  `json:"quantity"`
+		Reserved int  `json:"reserved"`
+		Expired  bool `json:"expired"`
+	}
+
+	// ProductBin - остаток товара в ячейке с путем Location
+	ProductBin struct {
+		Location string `json:"location"`
+		Quantity int    `json:"quantity"`
+		Reserved int    `json:"reserved"`
+	}
+
+	// ReceiptItem - поступление товара на склад, без Lot - товар без партии.
+	// Штучный товар принимается с Serials, по одному номеру на единицу, с Bin - сразу размещается в ячейке
+	ReceiptItem struct {
+		Article  string   `json:"article"`
+		Quantity int      `json:"quantity"`
+		Lot      *Lot     `json:"lot,omitempty"`
+		Serials  []string `json:"serials,omitempty"`
+		Bin      string   `json:"bin,omitempty"`
+	}
+
+	// StockQuery - запрос остатков по списку товаров и/или складов
+	StockQuery struct {
+		Articles   []string `json:"articles,omitempty"`
+		Warehouses []string `json:"warehouses,omitempty"`
+	}
+
+	// ProductStock - остатки товара по всем складам. Для набора (Kit) - сколько наборов можно собрать из компонентов
+	ProductStock struct {
+		Name       string           `json:"name"`
+		Size       string           `json:"size"`
+		Code       string           `json:"code"`
+		Kit        bool             `json:"kit,omitempty"`
+		Available  int              `json:"available"`
+		Reserved   int              `json:"reserved"`
+		Warehouses []WarehouseStock `json:"warehouses"`
+	}
+
+	WarehouseStock struct {
+		WarehouseUUID string         `json:"warehouse_uuid"`
+		IsAvailable   bool           `json:"is_available"`
+		Available     int            `json:"available"`
+		Reserved      int            `json:"reserved"`
+		Expired       int            `json:"expired"`
+		Buckets       map[string]int `json:"buckets,omitempty"`
+	}
+
+	// ReserveItem - позиция резервирования. WarehouseUUID и AllowedWarehouses ограничивают склады
+	// и не указываются вместе. AllowSubstitutes - при нехватке зарезервировать замену, только в CreateReservation
+	ReserveItem struct {
+		Article           string   `json:"article"`
+		Quantity          int      `json:"quantity"`
+		WarehouseUUID     string   `json:"warehouse_uuid,omitempty"`
+		AllowedWarehouses []string `json:"allowed_warehouses,omitempty"`
+		AllowSubstitutes  bool     `json:"allow_substitutes,omitempty"`
+	}
+
+	// ReleaseItem - сколько штук товара вернуть из резерва на склады
+	ReleaseItem struct {
+		Article  string `json:"article"`
+		Quantity int    `json:"quantity"`
+	}
+
+	// Reservation - резерв. PartiallyFulfilled - при сборке чего-то не нашли и не смогли зарезервировать на других складах
+	Reservation struct {
+		ID                 string            `json:"id"`
+		Status             string            `json:"status"`
+		CreatedAt          time.Time         `json:"created_at"`
+		UpdatedAt          time.Time         `json:"updated_at"`
+		Items              []ReservationItem `json:"items"`
+		PartiallyFulfilled bool              `json:"partially_fulfilled,omitempty"`
+	}
+
+	// ReservationItem - часть резерва одного товара на одном складе.
+	// Released - сколько из Quantity уже возвращено на склад, Serials - серийные номера, которые еще в резерве,
+	// Bins - ячейки, из которых взят товар в резерве. Short - сколько не нашли при сборке,
+	// Unfulfilled - сколько из Short не удалось зарезервировать на других складах,
+	// SubstituteFor - артикул, вместо которого зарезервирован товар
+	ReservationItem struct {
+		Article       string        `json:"article"`
+		WarehouseUUID string        `json:"warehouse_uuid"`
+		Quantity      int           `json:"quantity"`
+		Released      int           `json:"released"`
+		Short         int           `json:"short,omitempty"`
+		Unfulfilled   int           `json:"unfulfilled,omitempty"`
+		Serials       []string      `json:"serials,omitempty"`
+		Bins          []BinQuantity `json:"bins,omitempty"`
+		SubstituteFor string        `json:"substitute_for,omitempty"`
+	}
+
+	// BinQuantity - количество товара в ячейке с путем Location
+	BinQuantity struct {
+		Location string `json:"location"`
+		Quantity int    `json:"quantity"`
+	}
+
+	// Serial - серийный номер штучного товара, ReservationID заполнен, пока номер в резерве
+	Serial struct {
+		Article       string    `json:"article"`
+		SerialNumber  string    `json:"serial_number"`
+		WarehouseUUID string    `json:"warehouse_uuid"`
+		Status        string    `json:"status"`
+		ReservationID string    `json:"reservation_id,omitempty"`
+		UpdatedAt     time.Time `json:"updated_at"`
+	}
+
+	// NewLocation - место хранения, которое создается на складе. Без ParentID создается зона,
+	// проход создается в зоне, стеллаж - в проходе, ячейка - на стеллаже
+	NewLocation struct {
+		Kind     string `json:"kind"`
+		Code     string `json:"code"`
+		ParentID string `json:"parent_id,omitempty"`
+	}
+
+	// Location - место хранения на складе. Path - коды от зоны до места через "/"
+	Location struct {
+		ID       string `json:"id"`
+		ParentID string `json:"parent_id,omitempty"`
+		Kind     string `json:"kind"`
+		Code     string `json:"code"`
+		Path     string `json:"path"`
+	}
+
+	// StockMove - перемещение свободного товара между ячейками. Пустой From - товар, не размещенный по ячейкам,
+	// пустой To - снять товар с ячейки
+	StockMove struct {
+		Article  string `json:"article"`
+		Quantity int    `json:"quantity"`
+		From     string `json:"from,omitempty"`
+		To       string `json:"to,omitempty"`
+	}
+
+	// BucketMove - перемещение товара между корзинами остатка, From и To - одна из констант Bucket*
+	BucketMove struct {
+		Article  string `json:"article"`
+		Quantity int    `json:"quantity"`
+		From     string `json:"from"`
+		To       string `json:"to"`
+	}
+
+	// ReservationPickList - что и откуда собрать по резерву, строки в порядке обхода склада
+	ReservationPickList struct {
+		ReservationID string     `json:"reservation_id"`
+		Lines         []PickLine `json:"lines"`
+	}
+
+	// PickLine - строка листа отбора. Пустой Location - товар, не размещенный по ячейкам
+	PickLine struct {
+		WarehouseUUID string `json:"warehouse_uuid"`
+		Location      string `json:"location"`
+		Article       string `json:"article"`
+		Quantity      int    `json:"quantity"`
+	}
+
+	// PickList - лист отбора по одному или нескольким резервам, строки по складам в порядке обхода ячеек
+	PickList struct {
+		ID        string         `json:"id"`
+		Status    string         `json:"status"`
+		CreatedAt time.Time      `json:"created_at"`
+		UpdatedAt time.Time      `json:"updated_at"`
+		Lines     []PickListLine `json:"lines"`
+	}
+
+	// PickListLine - строка листа отбора: товар одного резерва в одной ячейке. Picked - сколько собрано
+	PickListLine struct {
+		Line          int    `json:"line"`
+		ReservationID string `json:"reservation_id"`
+		WarehouseUUID string `json:"warehouse_uuid"`
+		Location      string `json:"location"`
+		Article       string `json:"article"`
+		Quantity      int    `json:"quantity"`
+		Picked        int    `json:"picked"`
+		Status        string `json:"status"`
+	}
+
+	// SerialEvent - смена состояния серийного номера
+	SerialEvent struct {
+		Status        string    `json:"status"`
+		WarehouseUUID string    `json:"warehouse_uuid"`
+		ReservationID string    `json:"reservation_id,omitempty"`
+		CreatedAt     time.Time `json:"created_at"`
+	}
+
+	// NewAdjustment - корректировка остатка на Delta штук, отрицательное Delta списывает.
+	// Без Actor автором считается клиент API
+	NewAdjustment struct {
+		Article string `json:"article"`
+		Delta   int    `json:"delta"`
+		Reason  string `json:"reason"` // одна из Adjustment*
+		Comment string `json:"comment,omitempty"`
+		Actor   string `json:"actor,omitempty"`
+	}
+
+	// Adjustment - корректировка остатка, с Location - в ячейке. ReviewedBy и ReviewedAt заполнены
+	// после подтверждения или отклонения
+	Adjustment struct {
+		ID            string     `json:"id"`
+		WarehouseUUID string     `json:"warehouse_uuid"`
+		Article       string     `json:"article"`
+		Location      string     `json:"location"`
+		Delta         int        `json:"delta"`
+		Reason        string     `json:"reason"`
+		Comment       string     `json:"comment"`
+		Actor         string     `json:"actor"`
+		Status        string     `json:"status"`
+		CreatedAt     time.Time  `json:"created_at"`
+		ReviewedBy    string     `json:"reviewed_by"`
+		ReviewedAt    *time.Time `json:"reviewed_at"`
+	}
+
+	// NewStocktake - инвентаризация склада или, с ZoneID, зоны. Blind скрывает ожидаемые количества до закрытия
+	NewStocktake struct {
+		ZoneID string `json:"zone_id,omitempty"`
+		Blind  bool   `json:"blind,omitempty"`
+	}
+
+	// Stocktake - инвентаризация, строки в порядке обхода склада
+	Stocktake struct {
+		ID            string          `json:"id"`
+		WarehouseUUID string          `json:"warehouse_uuid"`
+		Zone          string          `json:"zone"`
+		Blind         bool            `json:"blind"`
+		Status        string          `json:"status"`
+		CreatedBy     string          `json:"created_by"`
+		CreatedAt     time.Time       `json:"created_at"`
+		ClosedBy      string          `json:"closed_by"`
+		ClosedAt      *time.Time      `json:"closed_at"`
+		Lines         []StocktakeLine `json:"lines"`
+	}
+
+	// StocktakeLine - строка инвентаризации. Counted nil - строку еще не пересчитали. В слепой инвентаризации
+	// до закрытия Expected, OnHand и Variance равны nil
+	StocktakeLine struct {
+		Line         int        `json:"line"`
+		Location     string     `json:"location"`
+		Article      string     `json:"article"`
+		Expected     *int       `json:"expected"`
+		Counted      *int       `json:"counted"`
+		OnHand       *int       `json:"on_hand"`
+		Variance     *int       `json:"variance"`
+		CountedAt    *time.Time `json:"counted_at"`
+		AdjustmentID string     `json:"adjustment_id"`
+	}
+
+	// StocktakeCount - пересчитанное количество товара в ячейке Location или, без нее, на складе
+	StocktakeCount struct {
+		Article  string `json:"article"`
+		Location string `json:"location,omitempty"`
+		Counted  int    `json:"counted"`
+	}
+
+	// NewReturn - возврат товара покупателем. Reference - номер заказа или заявки покупателя
+	NewReturn struct {
+		Article   string `json:"article"`
+		Quantity  int    `json:"quantity"`
+		Reference string `json:"reference,omitempty"`
+		Reason    string `json:"reason,omitempty"`
+	}
+
+	// Return - возврат со всеми пройденными шагами. Outcome - одна из Return*, заполняется при осмотре
+	Return struct {
+		ID            string     `json:"id"`
+		WarehouseUUID string     `json:"warehouse_uuid"`
+		Article       string     `json:"article"`
+		Quantity      int        `json:"quantity"`
+		Reference     string     `json:"reference"`
+		Reason        string     `json:"reason"`
+		Status        string     `json:"status"`
+		Outcome       string     `json:"outcome"`
+		Comment       string     `json:"comment"`
+		CreatedBy     string     `json:"created_by"`
+		CreatedAt     time.Time  `json:"created_at"`
+		ReceivedBy    string     `json:"received_by"`
+		ReceivedAt    *time.Time `json:"received_at"`
+		InspectedBy   string     `json:"inspected_by"`
+		InspectedAt   *time.Time `json:"inspected_at"`
+	}
+
+	// KitComponent - компонент набора и его количество в одном наборе
+	KitComponent struct {
+		Article  string `json:"article"`
+		Quantity int    `json:"quantity"`
+	}
+
+	// Kit - набор и сколько наборов можно собрать. Available - по доступным складам вместе, компоненты одного
+	// набора могут быть на разных складах; в Warehouses - на каждом складе отдельно
+	Kit struct {
+		Article    string           `json:"article"`
+		Name       string           `json:"name"`
+		Size       string           `json:"size"`
+		Components []KitComponent   `json:"components"`
+		Available  int              `json:"available"`
+		Warehouses []WarehouseStock `json:"warehouses"`
+	}
+
+	// SubstituteRule - замена товара. SameSize - только при совпадении размера с заменяемым товаром
+	SubstituteRule struct {
+		Article  string `json:"article"`
+		SameSize bool   `json:"same_size"`
+	}
+
+	// Substitutes - замены товара в порядке, в котором они пробуются при нехватке
+	Substitutes struct {
+		Article     string       `json:"article"`
+		Substitutes []Substitute `json:"substitutes"`
+	}
+
+	// Substitute - замена товара с ее названием и размером
+	Substitute struct {
+		Article  string `json:"article"`
+		Name     string `json:"name"`
+		Size     string `json:"size"`
+		SameSize bool   `json:"same_size"`
+	}
+)